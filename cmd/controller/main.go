@@ -53,10 +53,13 @@ func main() {
 			ctx,
 			op.Manager,
 			op.Config,
+			op.EC2API,
+			op.EC2Batcher,
 			op.Clock,
 			op.GetClient(),
 			op.EventRecorder,
 			op.UnavailableOfferingsCache,
+			op.PolledInterruptions,
 			op.SSMCache,
 			cloudProvider,
 			op.SubnetProvider,
@@ -66,8 +69,17 @@ func main() {
 			op.PricingProvider,
 			op.AMIProvider,
 			op.LaunchTemplateProvider,
+			op.CapacityReservationProvider,
+			op.PlacementGroupProvider,
+			op.HostProvider,
+			op.SpotPlacementScoreProvider,
+			op.ServiceQuotaProvider,
 			op.VersionProvider,
 			op.InstanceTypesProvider,
+			op.PricingOverridesProvider,
+			op.PricingSnapshotProvider,
+			op.CheckpointProvider,
+			op.InterruptionRulesProvider,
 		)...).
 		Start(ctx)
 }
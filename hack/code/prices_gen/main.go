@@ -33,6 +33,7 @@ import (
 	"github.com/aws/aws-sdk-go-v2/service/ec2"
 	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
 	"github.com/samber/lo"
+	"k8s.io/utils/clock"
 
 	controllerspricing "github.com/aws/karpenter-provider-aws/pkg/controllers/providers/pricing"
 	"github.com/aws/karpenter-provider-aws/pkg/operator/options"
@@ -68,13 +69,15 @@ func getPartitionSuffix(partition string) string {
 
 type Options struct {
 	partition string
+	snapshot  string
 	output    string
 }
 
 func NewOptions() *Options {
 	o := &Options{}
 	flag.StringVar(&o.partition, "partition", "aws", "The partition to generate prices for. Valid options are \"aws\", \"aws-us-gov\", and \"aws-cn\".")
-	flag.StringVar(&o.output, "output", "pkg/providers/pricing/zz_generated.pricing_aws.go", "The destination for the generated go file.")
+	flag.StringVar(&o.snapshot, "snapshot", "", "A pricing snapshot, such as one exported by hack/tools/pricing_snapshot, to generate the prices from instead of the pricing API.")
+	flag.StringVar(&o.output, "output", "pkg/providers/pricing/zz_generated.pricing_aws.go", "The destination for the generated go file, or for a pricing snapshot when it ends with .json.")
 	flag.Parse()
 	if !lo.Contains([]string{"aws", "aws-us-gov", "aws-cn"}, o.partition) {
 		log.Fatal("invalid partition: must be \"aws\", \"aws-us-gov\", or \"aws-cn\"")
@@ -90,6 +93,47 @@ func main() {
 	}
 	defer f.Close() // error handling omitted for example
 
+	snapshot := pricing.Snapshot{}
+	if opts.snapshot != "" {
+		data, err := os.ReadFile(opts.snapshot)
+		if err != nil {
+			log.Fatalf("reading pricing snapshot, %s", err)
+		}
+		if snapshot, err = pricing.ParseSnapshot(data); err != nil {
+			log.Fatalf("reading pricing snapshot, %s", err)
+		}
+	} else {
+		snapshot = fetchSnapshot(opts.partition)
+	}
+	// only the static on-demand Linux prices of the partition's regions are generated
+	snapshot = lo.MapEntries(lo.PickByKeys(snapshot, getAWSRegions(opts.partition)), func(region string, prices pricing.RegionSnapshot) (string, pricing.RegionSnapshot) {
+		return region, pricing.RegionSnapshot{OnDemand: lo.PickByKeys(prices.OnDemand, []pricing.OperatingSystem{pricing.OperatingSystemLinux})}
+	})
+	for _, region := range getAWSRegions(opts.partition) {
+		if _, ok := snapshot[region]; !ok {
+			log.Fatalf("no prices found for %s", region)
+		}
+	}
+
+	if strings.HasSuffix(opts.output, ".json") {
+		data, err := pricing.MarshalSnapshot(snapshot)
+		if err != nil {
+			log.Fatalf("writing output, %s", err)
+		}
+		if err := os.WriteFile(opts.output, data, 0644); err != nil {
+			log.Fatalf("writing output, %s", err)
+		}
+	} else {
+		writeSource(opts, snapshot)
+	}
+	runtime.GC()
+	if err := pprof.WriteHeapProfile(f); err != nil {
+		log.Fatal("could not write memory profile: ", err)
+	}
+}
+
+// fetchSnapshot retrieves the prices of the partition's regions from the pricing API
+func fetchSnapshot(partition string) pricing.Snapshot {
 	const region = "us-east-1"
 	os.Setenv("AWS_SDK_LOAD_CONFIG", "true")
 	os.Setenv("AWS_REGION", region)
@@ -97,30 +141,34 @@ func main() {
 	ctx = options.ToContext(ctx, test.Options())
 	cfg := lo.Must(config.LoadDefaultConfig(ctx, config.WithRegion(region)))
 	ec2api := ec2.NewFromConfig(cfg)
+	snapshot := pricing.Snapshot{}
+	// record prices for each region we are interested in
+	for _, region := range getAWSRegions(partition) {
+		log.Println("fetching for", region)
+		pricingProvider := pricing.NewDefaultProvider(ctx, pricing.NewAPI(cfg), ec2api, region, clock.RealClock{})
+		controller := controllerspricing.NewController(pricingProvider, clock.RealClock{})
+		_, err := controller.Reconcile(ctx)
+		if err != nil {
+			log.Fatalf("failed to initialize pricing provider %s", err)
+		}
+		snapshot = lo.Assign(snapshot, pricingProvider.Snapshot())
+	}
+	return snapshot
+}
+
+// writeSource writes the static on-demand prices of the pricing snapshot as a go file
+func writeSource(opts *Options, snapshot pricing.Snapshot) {
 	src := &bytes.Buffer{}
 	fmt.Fprintln(src, "//go:build !ignore_autogenerated")
 	license := lo.Must(os.ReadFile("hack/boilerplate.go.txt"))
 	fmt.Fprintln(src, string(license))
 	fmt.Fprintln(src, "package pricing")
 	now := time.Now().UTC().Format(time.RFC3339)
-	fmt.Fprintf(src, "// generated at %s for %s\n\n\n", now, region)
+	fmt.Fprintf(src, "// generated at %s for %s\n\n\n", now, strings.Join(getAWSRegions(opts.partition), ", "))
 	fmt.Fprintln(src, "import ec2types \"github.com/aws/aws-sdk-go-v2/service/ec2/types\"")
 	fmt.Fprintf(src, "var InitialOnDemandPrices%s = map[string]map[ec2types.InstanceType]float64{\n", getPartitionSuffix(opts.partition))
-	// record prices for each region we are interested in
 	for _, region := range getAWSRegions(opts.partition) {
-		log.Println("fetching for", region)
-		pricingProvider := pricing.NewDefaultProvider(ctx, pricing.NewAPI(cfg), ec2api, region)
-		controller := controllerspricing.NewController(pricingProvider)
-		_, err := controller.Reconcile(ctx)
-		if err != nil {
-			log.Fatalf("failed to initialize pricing provider %s", err)
-		}
-		instanceTypes := pricingProvider.InstanceTypes()
-		sort.SliceStable(instanceTypes, func(i, j int) bool {
-			return instanceTypes[i] < instanceTypes[j]
-		})
-
-		writePricing(src, instanceTypes, region, pricingProvider.OnDemandPrice)
+		writePricing(src, region, snapshot[region].OnDemand[pricing.OperatingSystemLinux])
 	}
 	fmt.Fprintln(src, "}")
 	formatted, err := format.Source(src.Bytes())
@@ -134,13 +182,10 @@ func main() {
 	if err := os.WriteFile(opts.output, formatted, 0644); err != nil {
 		log.Fatalf("writing output, %s", err)
 	}
-	runtime.GC()
-	if err := pprof.WriteHeapProfile(f); err != nil {
-		log.Fatal("could not write memory profile: ", err)
-	}
 }
 
-func writePricing(src *bytes.Buffer, instanceNames []ec2types.InstanceType, region string, getPrice func(instanceType ec2types.InstanceType) (float64, bool)) {
+func writePricing(src *bytes.Buffer, region string, prices map[ec2types.InstanceType]float64) {
+	instanceNames := lo.Keys(prices)
 	fmt.Fprintf(src, "// %s\n", region)
 	fmt.Fprintf(src, "%q: {\n", region)
 	lineLen := 0
@@ -153,10 +198,7 @@ func writePricing(src *bytes.Buffer, instanceNames []ec2types.InstanceType, regi
 		if len(segs) != 2 {
 			log.Fatalf("parsing instance family %s, got %v", instanceName, segs)
 		}
-		price, ok := getPrice(instanceName)
-		if !ok {
-			continue
-		}
+		price := prices[instanceName]
 
 		// separating by family should lead to smaller diffs instead of just breaking at line endings only
 		family := segs[0]
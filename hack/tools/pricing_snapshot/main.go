@@ -0,0 +1,78 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/samber/lo"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/clientcmd"
+
+	"github.com/aws/karpenter-provider-aws/pkg/providers/pricing"
+)
+
+// pricing_snapshot exports the prices that the pricing provider of a running controller has retrieved, for use as a
+// pricing snapshot by clusters that can't reach the pricing API. The prices are read from the controller's
+// /debug/pricing-snapshot endpoint through the API server's service proxy, once for each kubeconfig context, and merged
+// into a single snapshot covering the region of each cluster.
+func main() {
+	var contexts, namespace, service, port, output string
+	flag.StringVar(&contexts, "contexts", "", "Comma separated list of kubeconfig contexts of clusters to export prices from. Defaults to the current context.")
+	flag.StringVar(&namespace, "namespace", "kube-system", "The namespace of the Karpenter service.")
+	flag.StringVar(&service, "service", "karpenter", "The name of the Karpenter service.")
+	flag.StringVar(&port, "port", "http-metrics", "The name or number of the Karpenter service's metrics port.")
+	flag.StringVar(&output, "output", "", "The destination for the pricing snapshot. Defaults to stdout.")
+	flag.Parse()
+
+	ctx := context.Background()
+	snapshot := pricing.Snapshot{}
+	for _, kubeContext := range strings.Split(contexts, ",") {
+		log.Println("fetching from", lo.Ternary(kubeContext == "", "current context", kubeContext))
+		restConfig, err := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(
+			clientcmd.NewDefaultClientConfigLoadingRules(),
+			&clientcmd.ConfigOverrides{CurrentContext: kubeContext},
+		).ClientConfig()
+		if err != nil {
+			log.Fatalf("loading kubeconfig, %s", err)
+		}
+		data, err := lo.Must(kubernetes.NewForConfig(restConfig)).CoreV1().Services(namespace).
+			ProxyGet("http", service, port, "/debug/pricing-snapshot", nil).DoRaw(ctx)
+		if err != nil {
+			log.Fatalf("retrieving pricing snapshot, %s", err)
+		}
+		clusterSnapshot, err := pricing.ParseSnapshot(data)
+		if err != nil {
+			log.Fatalf("retrieving pricing snapshot, %s", err)
+		}
+		snapshot = lo.Assign(snapshot, clusterSnapshot)
+	}
+
+	data, err := pricing.MarshalSnapshot(snapshot)
+	if err != nil {
+		log.Fatalf("writing output, %s", err)
+	}
+	if output == "" {
+		lo.Must(os.Stdout.Write(data))
+		return
+	}
+	if err := os.WriteFile(output, data, 0644); err != nil {
+		log.Fatalf("writing output, %s", err)
+	}
+}
@@ -23,6 +23,7 @@ import (
 	"github.com/samber/lo"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	karpv1 "sigs.k8s.io/karpenter/pkg/apis/v1"
 )
 
 // EC2NodeClassSpec is the top level specification for the AWS Karpenter Provider.
@@ -43,9 +44,60 @@ type EC2NodeClassSpec struct {
 	// +kubebuilder:validation:MaxItems:=30
 	// +required
 	SecurityGroupSelectorTerms []SecurityGroupSelectorTerm `json:"securityGroupSelectorTerms" hash:"ignore"`
+	// CapacityReservationSelectorTerms is a list of capacity reservation selector terms. The terms are ORed.
+	// Matched On-Demand Capacity Reservations are exposed as "reserved" offerings which are preferred over on-demand
+	// and spot capacity when the NodePool's capacity type requirements allow it.
+	// +kubebuilder:validation:XValidation:message="expected at least one, got none, ['tags', 'id']",rule="self.all(x, has(x.tags) || has(x.id))"
+	// +kubebuilder:validation:XValidation:message="'id' is mutually exclusive, cannot be set with a combination of other fields in capacityReservationSelectorTerms",rule="!self.exists(x, has(x.id) && (has(x.tags) || has(x.ownerID)))"
+	// +kubebuilder:validation:MaxItems:=30
+	// +optional
+	CapacityReservationSelectorTerms []CapacityReservationSelectorTerm `json:"capacityReservationSelectorTerms,omitempty" hash:"ignore"`
+	// PlacementGroup configures the placement group that instances are launched into. An existing placement group can
+	// be selected by name or id, or a strategy can be specified for Karpenter to create and manage a placement group
+	// for the EC2NodeClass.
+	// +kubebuilder:validation:XValidation:message="expected exactly one of ['name', 'id', 'strategy']",rule="[has(self.name), has(self.id), has(self.strategy)].filter(x, x).size() == 1"
+	// +kubebuilder:validation:XValidation:message="'partitionCount' may only be set with the 'partition' strategy",rule="!has(self.partitionCount) || (has(self.strategy) && self.strategy == 'partition')"
+	// +optional
+	PlacementGroup *PlacementGroupSelector `json:"placementGroup,omitempty" hash:"ignore"`
+	// Tenancy is the tenancy of instances launched with the nodeclass. Instances with "dedicated" tenancy run on
+	// single-tenant hardware, and instances with "host" tenancy run on EC2 Dedicated Hosts. When unset, instances are
+	// launched with "default" tenancy. Spot capacity is only available for instances with "default" tenancy.
+	// +kubebuilder:validation:Enum:={default,dedicated,host}
+	// +optional
+	Tenancy *string `json:"tenancy,omitempty"`
+	// HostResourceGroupARN is the ARN of the host resource group that instances with "host" tenancy are launched into.
+	// Hosts in the host resource group are allocated and released by License Manager.
+	// +kubebuilder:validation:Pattern:="^arn:[^:]+:resource-groups:[^:]+:[0-9]{12}:group/.+$"
+	// +optional
+	HostResourceGroupARN *string `json:"hostResourceGroupARN,omitempty" hash:"ignore"`
+	// HostSelectorTerms is a list of Dedicated Host selector terms. The terms are ORed. Instances with "host" tenancy are
+	// placed onto the selected hosts, and instance types are filtered to those that the selected hosts are able to run.
+	// +kubebuilder:validation:XValidation:message="expected at least one, got none, ['tags', 'id']",rule="self.all(x, has(x.tags) || has(x.id))"
+	// +kubebuilder:validation:XValidation:message="'id' is mutually exclusive, cannot be set with a combination of other fields in hostSelectorTerms",rule="!self.exists(x, has(x.id) && has(x.tags))"
+	// +kubebuilder:validation:MaxItems:=30
+	// +optional
+	HostSelectorTerms []HostSelectorTerm `json:"hostSelectorTerms,omitempty" hash:"ignore"`
 	// AssociatePublicIPAddress controls if public IP addresses are assigned to instances that are launched with the nodeclass.
 	// +optional
 	AssociatePublicIPAddress *bool `json:"associatePublicIPAddress,omitempty"`
+	// NetworkInterfaces configures the network interfaces that instances are launched with. When unset, instances are
+	// launched with a single primary network interface, or with an EFA interface on each network card when EFA
+	// resources are requested. Instance types which can't be launched with the network interfaces aren't offered.
+	// +kubebuilder:validation:XValidation:message="expected exactly one primary network interface with 'networkCardIndex' 0 and 'deviceIndex' 0",rule="self.filter(x, x.networkCardIndex == 0 && x.deviceIndex == 0).size() == 1"
+	// +kubebuilder:validation:XValidation:message="network interfaces must have unique 'networkCardIndex' and 'deviceIndex' pairs",rule="self.all(x, self.filter(y, y.networkCardIndex == x.networkCardIndex && y.deviceIndex == x.deviceIndex).size() == 1)"
+	// +kubebuilder:validation:XValidation:message="the primary network interface can't have 'subnetSelectorTerms'",rule="self.all(x, x.networkCardIndex != 0 || x.deviceIndex != 0 || !has(x.subnetSelectorTerms))"
+	// +kubebuilder:validation:XValidation:message="the primary network interface can't be an 'efa-only' interface",rule="self.all(x, x.networkCardIndex != 0 || x.deviceIndex != 0 || x.interfaceType != 'efa-only')"
+	// +kubebuilder:validation:MaxItems:=16
+	// +optional
+	NetworkInterfaces []*NetworkInterface `json:"networkInterfaces,omitempty"`
+	// IPAddressMode is how the VPC CNI assigns IP addresses to pods. With "secondary-ip", pods are assigned secondary
+	// IPv4 addresses of the node's ENIs. With "prefix-delegation", pods are assigned addresses from /28 IPv4 prefixes
+	// that are assigned to the node's ENIs. With "ipv6", pods are assigned IPv6 addresses. The pod capacity and
+	// kube-reserved of nodes, and the subnet IP addresses that launches consume, are computed for the mode. When unset,
+	// "secondary-ip" is assumed.
+	// +kubebuilder:validation:Enum:={secondary-ip,prefix-delegation,ipv6}
+	// +optional
+	IPAddressMode *string `json:"ipAddressMode,omitempty"`
 	// AMISelectorTerms is a list of or ami selector terms. The terms are ORed.
 	// +kubebuilder:validation:XValidation:message="expected at least one, got none, ['tags', 'id', 'name', 'alias']",rule="self.all(x, has(x.tags) || has(x.id) || has(x.name) || has(x.alias))"
 	// +kubebuilder:validation:XValidation:message="'id' is mutually exclusive, cannot be set with a combination of other fields in amiSelectorTerms",rule="!self.exists(x, has(x.id) && (has(x.alias) || has(x.tags) || has(x.name) || has(x.owner)))"
@@ -92,6 +144,7 @@ type EC2NodeClassSpec struct {
 	// +kubebuilder:validation:XValidation:message="tag contains a restricted tag matching karpenter.sh/nodepool",rule="self.all(k, k != 'karpenter.sh/nodepool')"
 	// +kubebuilder:validation:XValidation:message="tag contains a restricted tag matching karpenter.sh/nodeclaim",rule="self.all(k, k !='karpenter.sh/nodeclaim')"
 	// +kubebuilder:validation:XValidation:message="tag contains a restricted tag matching karpenter.k8s.aws/ec2nodeclass",rule="self.all(k, k !='karpenter.k8s.aws/ec2nodeclass')"
+	// +kubebuilder:validation:XValidation:message="tag contains a restricted tag matching karpenter.k8s.aws/warm-pool",rule="self.all(k, k !='karpenter.k8s.aws/warm-pool')"
 	// +optional
 	Tags map[string]string `json:"tags,omitempty"`
 	// Kubelet defines args to be used when configuring kubelet on provisioned nodes.
@@ -113,6 +166,12 @@ type EC2NodeClassSpec struct {
 	// DetailedMonitoring controls if detailed monitoring is enabled for instances that are launched
 	// +optional
 	DetailedMonitoring *bool `json:"detailedMonitoring,omitempty"`
+	// CPUOptions configures the number of CPU cores and threads per core that instances are launched with, and whether
+	// AMD SEV-SNP is enabled. Instance types that can't be launched with the options aren't offered, and the capacity
+	// of the instance types that are reflects the reduced number of vCPUs.
+	// +kubebuilder:validation:XValidation:message="'coreCount' and 'coreRatio' are mutually exclusive",rule="!(has(self.coreCount) && has(self.coreRatio))"
+	// +optional
+	CPUOptions *CPUOptions `json:"cpuOptions,omitempty"`
 	// MetadataOptions for the generated launch template of provisioned nodes.
 	//
 	// This specifies the exposure of the Instance Metadata Service to
@@ -134,8 +193,93 @@ type EC2NodeClassSpec struct {
 	// https://docs.aws.amazon.com/AWSEC2/latest/APIReference/API_CreateFleet.html
 	// +optional
 	Context *string `json:"context,omitempty"`
+	// LaunchStrategy configures how EC2 Fleet chooses between the instance types and zones that a launch request can be
+	// fulfilled with. Changes to the launch strategy only apply to new launches and don't drift existing nodes.
+	// +kubebuilder:validation:XValidation:message="'instanceTypePriorities' may only be set with the 'capacity-optimized-prioritized' spot or 'prioritized' on-demand allocation strategy",rule="!has(self.instanceTypePriorities) || (has(self.spotAllocationStrategy) && self.spotAllocationStrategy == 'capacity-optimized-prioritized') || (has(self.onDemandAllocationStrategy) && self.onDemandAllocationStrategy == 'prioritized')"
+	// +optional
+	LaunchStrategy *LaunchStrategy `json:"launchStrategy,omitempty" hash:"ignore"`
+	// WarmPool configures a pool of stopped on-demand instances that are launched and bootstrapped ahead of time with the
+	// current AMI and launch template. Launches are fulfilled by starting a compatible warm instance, and only fall back
+	// to launching a new instance when there isn't one. Warm instances are replaced when the EC2NodeClass drifts.
+	// +optional
+	WarmPool *WarmPool `json:"warmPool,omitempty" hash:"ignore"`
+	// RebalanceRecommendationPolicy is how NodeClaims act on spot rebalance recommendations. With "None", an event is
+	// published and the NodeClaim is drained when it's interrupted. With "ReplaceBeforeDrain", a replacement NodeClaim is
+	// launched and the NodeClaim is drained once the replacement is ready. When unset, "None" is assumed.
+	// +optional
+	RebalanceRecommendationPolicy *RebalanceRecommendationPolicy `json:"rebalanceRecommendationPolicy,omitempty" hash:"ignore"`
+}
+
+// WarmPool defines the size and instance types of the warm pool of an EC2NodeClass.
+type WarmPool struct {
+	// NodePool is the name of the NodePool that the warm pool is kept for. Warm instances are bootstrapped with the
+	// labels, taints and startup taints of the NodePool's template, and can only be claimed by the NodePool's NodeClaims.
+	// +kubebuilder:validation:MinLength:=1
+	// +required
+	NodePool string `json:"nodePool"`
+	// Size is the number of warm instances that are kept in the pool.
+	// +kubebuilder:validation:Minimum:=0
+	// +kubebuilder:validation:Maximum:=100
+	// +required
+	Size int32 `json:"size"`
+	// InstanceTypes are the instance types that warm instances may be launched with. Each warm instance is launched with
+	// the cheapest of these instance types that is available.
+	// +kubebuilder:validation:XValidation:message="instanceTypes cannot contain empty values",rule="self.all(x, x != '')"
+	// +kubebuilder:validation:MinItems:=1
+	// +kubebuilder:validation:MaxItems:=20
+	// +required
+	InstanceTypes []string `json:"instanceTypes"`
+}
+
+// LaunchStrategy defines the allocation strategies that are used when launching instances.
+type LaunchStrategy struct {
+	// SpotAllocationStrategy is the allocation strategy used for spot launches. When unset, the
+	// "price-capacity-optimized" strategy is used. With the "capacity-optimized" and "capacity-optimized-prioritized"
+	// strategies, spot instance types that are more expensive than the cheapest on-demand instance type are no longer
+	// excluded from launches that are flexible to both spot and on-demand capacity.
+	// +kubebuilder:validation:Enum:={price-capacity-optimized,capacity-optimized,capacity-optimized-prioritized,lowest-price}
+	// +optional
+	SpotAllocationStrategy *string `json:"spotAllocationStrategy,omitempty"`
+	// OnDemandAllocationStrategy is the allocation strategy used for on-demand and reserved launches. When unset, the
+	// "lowest-price" strategy is used.
+	// +kubebuilder:validation:Enum:={lowest-price,prioritized}
+	// +optional
+	OnDemandAllocationStrategy *string `json:"onDemandAllocationStrategy,omitempty"`
+	// InstanceTypePriorities is the priority of each instance type when launching with a prioritized allocation
+	// strategy. Instance types without a priority are given the lowest priority. Prioritized instance types are kept
+	// ahead of cheaper instance types when the instance types in a launch request are truncated to the cheapest 60.
+	// The scheduler only considers the cheapest 60 compatible instance types for a NodeClaim, so a NodePool's
+	// requirements should be narrow enough to include the prioritized instance types. Weighting instance types by
+	// their capacity isn't supported, each instance type fulfills a launch request with a single instance.
+	// +listType=map
+	// +listMapKey=instanceType
+	// +kubebuilder:validation:MaxItems:=60
+	// +optional
+	InstanceTypePriorities []InstanceTypePriority `json:"instanceTypePriorities,omitempty"`
 }
 
+// InstanceTypePriority defines the launch priority of an instance type.
+type InstanceTypePriority struct {
+	// InstanceType is the name of the instance type, e.g. m5.large
+	// +kubebuilder:validation:MinLength:=1
+	// +kubebuilder:validation:MaxLength:=64
+	// +required
+	InstanceType string `json:"instanceType"`
+	// Priority is the launch priority of the instance type. The lower the number, the higher the priority.
+	// +kubebuilder:validation:Minimum:=0
+	// +required
+	Priority int32 `json:"priority"`
+}
+
+const (
+	SpotAllocationStrategyPriceCapacityOptimized       = "price-capacity-optimized"
+	SpotAllocationStrategyCapacityOptimized            = "capacity-optimized"
+	SpotAllocationStrategyCapacityOptimizedPrioritized = "capacity-optimized-prioritized"
+	SpotAllocationStrategyLowestPrice                  = "lowest-price"
+	OnDemandAllocationStrategyLowestPrice              = "lowest-price"
+	OnDemandAllocationStrategyPrioritized              = "prioritized"
+)
+
 // SubnetSelectorTerm defines selection logic for a subnet used by Karpenter to launch nodes.
 // If multiple fields are used for selection, the requirements are ANDed.
 type SubnetSelectorTerm struct {
@@ -151,6 +295,62 @@ type SubnetSelectorTerm struct {
 	ID string `json:"id,omitempty"`
 }
 
+// CapacityReservationSelectorTerm defines selection logic for a capacity reservation used by Karpenter to launch nodes.
+// If multiple fields are used for selection, the requirements are ANDed.
+type CapacityReservationSelectorTerm struct {
+	// Tags is a map of key/value tags used to select capacity reservations.
+	// Specifying '*' for a value selects all values for a given tag key.
+	// +kubebuilder:validation:XValidation:message="empty tag keys or values aren't supported",rule="self.all(k, k != '' && self[k] != '')"
+	// +kubebuilder:validation:MaxProperties:=20
+	// +optional
+	Tags map[string]string `json:"tags,omitempty"`
+	// ID is the capacity reservation id in EC2
+	// +kubebuilder:validation:Pattern="cr-[0-9a-z]+"
+	// +optional
+	ID string `json:"id,omitempty"`
+	// OwnerID is the id of the AWS account that owns the capacity reservation. This can be used to select
+	// reservations that are shared with this account.
+	// +kubebuilder:validation:Pattern="^[0-9]{12}$"
+	// +optional
+	OwnerID string `json:"ownerID,omitempty"`
+}
+
+// HostSelectorTerm defines selection logic for an EC2 Dedicated Host used by Karpenter to launch nodes.
+// If multiple fields are used for selection, the requirements are ANDed.
+type HostSelectorTerm struct {
+	// Tags is a map of key/value tags used to select hosts.
+	// Specifying '*' for a value selects all values for a given tag key.
+	// +kubebuilder:validation:XValidation:message="empty tag keys or values aren't supported",rule="self.all(k, k != '' && self[k] != '')"
+	// +kubebuilder:validation:MaxProperties:=20
+	// +optional
+	Tags map[string]string `json:"tags,omitempty"`
+	// ID is the host id in EC2
+	// +kubebuilder:validation:Pattern="h-[0-9a-z]+"
+	// +optional
+	ID string `json:"id,omitempty"`
+}
+
+// PlacementGroupSelector defines selection logic for the placement group used by Karpenter to launch nodes.
+type PlacementGroupSelector struct {
+	// Name is the name of an existing placement group
+	// +kubebuilder:validation:MaxLength:=255
+	// +optional
+	Name string `json:"name,omitempty"`
+	// ID is the id of an existing placement group
+	// +kubebuilder:validation:Pattern:="^pg-[0-9a-z]+$"
+	// +optional
+	ID string `json:"id,omitempty"`
+	// Strategy is the strategy of the placement group that Karpenter creates and manages for the EC2NodeClass.
+	// +kubebuilder:validation:Enum:={cluster,partition,spread}
+	// +optional
+	Strategy string `json:"strategy,omitempty"`
+	// PartitionCount is the number of partitions of a Karpenter-managed partition placement group.
+	// +kubebuilder:validation:Minimum:=1
+	// +kubebuilder:validation:Maximum:=7
+	// +optional
+	PartitionCount *int32 `json:"partitionCount,omitempty"`
+}
+
 // SecurityGroupSelectorTerm defines selection logic for a security group used by Karpenter to launch nodes.
 // If multiple fields are used for selection, the requirements are ANDed.
 type SecurityGroupSelectorTerm struct {
@@ -323,6 +523,93 @@ type MetadataOptions struct {
 	HTTPTokens *string `json:"httpTokens,omitempty"`
 }
 
+// NetworkInterface configures a network interface that instances are launched with. For more information, see
+// Network cards (https://docs.aws.amazon.com/AWSEC2/latest/UserGuide/using-eni.html#network-cards) in the Amazon
+// Elastic Compute Cloud User Guide.
+// +kubebuilder:validation:XValidation:message="'ipv4PrefixCount' and 'secondaryIPAddressCount' are mutually exclusive",rule="!(has(self.ipv4PrefixCount) && has(self.secondaryIPAddressCount))"
+// +kubebuilder:validation:XValidation:message="'efa-only' interfaces can't be assigned IP addresses",rule="self.interfaceType != 'efa-only' || !(has(self.ipv4PrefixCount) || has(self.secondaryIPAddressCount) || has(self.subnetSelectorTerms))"
+type NetworkInterface struct {
+	// NetworkCardIndex is the index of the network card that the network interface is attached to.
+	// +kubebuilder:validation:Minimum:=0
+	// +kubebuilder:default:=0
+	// +optional
+	NetworkCardIndex int32 `json:"networkCardIndex"`
+	// DeviceIndex is the device index of the network interface on its network card. The primary network interface has
+	// device index 0 on network card 0.
+	// +kubebuilder:validation:Minimum:=0
+	// +required
+	DeviceIndex int32 `json:"deviceIndex"`
+	// InterfaceType is the type of the network interface. An "efa" interface is an ENA interface with EFA, and an
+	// "efa-only" interface is an EFA interface without IP addresses.
+	// +kubebuilder:validation:Enum:={interface,efa,efa-only}
+	// +kubebuilder:default:=interface
+	// +optional
+	InterfaceType string `json:"interfaceType,omitempty"`
+	// SubnetSelectorTerms select the subnet that the network interface is created in. The subnet must be in the same
+	// zone as the instance, so instances are only launched into zones with a matching subnet. When unset, the network
+	// interface is created in the subnet that the instance is launched into.
+	// +kubebuilder:validation:XValidation:message="expected at least one, got none, ['tags', 'id']",rule="self.all(x, has(x.tags) || has(x.id))"
+	// +kubebuilder:validation:MaxItems:=30
+	// +optional
+	SubnetSelectorTerms []SubnetSelectorTerm `json:"subnetSelectorTerms,omitempty" hash:"ignore"`
+	// SecurityGroupSelectorTerms select the security groups of the network interface. When unset, the network interface
+	// uses the security groups of the EC2NodeClass.
+	// +kubebuilder:validation:XValidation:message="expected at least one, got none, ['tags', 'id', 'name']",rule="self.all(x, has(x.tags) || has(x.id) || has(x.name))"
+	// +kubebuilder:validation:MaxItems:=30
+	// +optional
+	SecurityGroupSelectorTerms []SecurityGroupSelectorTerm `json:"securityGroupSelectorTerms,omitempty" hash:"ignore"`
+	// IPv4PrefixCount is the number of /28 IPv4 prefixes that are assigned to the network interface. Assigning prefixes
+	// increases the number of pods that the VPC CNI can run on the instance when prefix delegation is enabled.
+	// +kubebuilder:validation:Minimum:=1
+	// +optional
+	IPv4PrefixCount *int32 `json:"ipv4PrefixCount,omitempty"`
+	// SecondaryIPAddressCount is the number of secondary private IPv4 addresses that are assigned to the network
+	// interface.
+	// +kubebuilder:validation:Minimum:=1
+	// +optional
+	SecondaryIPAddressCount *int32 `json:"secondaryIPAddressCount,omitempty"`
+}
+
+const (
+	IPAddressModeSecondaryIP      = "secondary-ip"
+	IPAddressModePrefixDelegation = "prefix-delegation"
+	IPAddressModeIPv6             = "ipv6"
+)
+
+const (
+	NetworkInterfaceTypeInterface = "interface"
+	NetworkInterfaceTypeEFA       = "efa"
+	NetworkInterfaceTypeEFAOnly   = "efa-only"
+)
+
+// CPUOptions configures the CPU of launched instances. For more information, see Optimize CPU options
+// (https://docs.aws.amazon.com/AWSEC2/latest/UserGuide/instance-optimize-cpu.html) and AMD SEV-SNP
+// (https://docs.aws.amazon.com/AWSEC2/latest/UserGuide/sev-snp.html) in the Amazon Elastic Compute Cloud User Guide.
+type CPUOptions struct {
+	// CoreCount is the number of CPU cores that instances are launched with. Only instance types which support the
+	// core count are offered.
+	// +kubebuilder:validation:Minimum:=1
+	// +optional
+	CoreCount *int32 `json:"coreCount,omitempty"`
+	// CoreRatio is the percentage of each instance type's default number of CPU cores that instances are launched
+	// with. The core count is rounded down to the nearest core count that the instance type supports, and instance
+	// types which don't support a core count that small aren't offered.
+	// +kubebuilder:validation:Minimum:=1
+	// +kubebuilder:validation:Maximum:=100
+	// +optional
+	CoreRatio *int32 `json:"coreRatio,omitempty"`
+	// ThreadsPerCore is the number of threads per CPU core. Set to 1 to disable simultaneous multithreading. Only
+	// instance types which support the number of threads per core are offered.
+	// +kubebuilder:validation:Minimum:=1
+	// +kubebuilder:validation:Maximum:=2
+	// +optional
+	ThreadsPerCore *int32 `json:"threadsPerCore,omitempty"`
+	// AMDSEVSNP enables or disables AMD SEV-SNP. When enabled, only instance types which support AMD SEV-SNP are offered.
+	// +kubebuilder:validation:Enum:={enabled,disabled}
+	// +optional
+	AMDSEVSNP *string `json:"amdSevSnp,omitempty"`
+}
+
 type BlockDeviceMapping struct {
 	// The device name (for example, /dev/sdh or xvdh).
 	// +optional
@@ -415,6 +702,18 @@ const (
 	InstanceStorePolicyRAID0 InstanceStorePolicy = "RAID0"
 )
 
+// RebalanceRecommendationPolicy enumerates how NodeClaims act on spot rebalance recommendations.
+// +kubebuilder:validation:Enum={None,ReplaceBeforeDrain}
+type RebalanceRecommendationPolicy string
+
+const (
+	// RebalanceRecommendationPolicyNone only publishes an event for rebalance recommendations
+	RebalanceRecommendationPolicyNone RebalanceRecommendationPolicy = "None"
+	// RebalanceRecommendationPolicyReplaceBeforeDrain launches a replacement NodeClaim on a rebalance recommendation and
+	// drains the recommended NodeClaim once the replacement is ready, or when the instance is interrupted
+	RebalanceRecommendationPolicyReplaceBeforeDrain RebalanceRecommendationPolicy = "ReplaceBeforeDrain"
+)
+
 // EC2NodeClass is the Schema for the EC2NodeClass API
 // +kubebuilder:object:root=true
 // +kubebuilder:printcolumn:name="Ready",type="string",JSONPath=".status.conditions[?(@.type==\"Ready\")].status",description=""
@@ -435,6 +734,8 @@ type EC2NodeClass struct {
 	// +kubebuilder:validation:XValidation:message="if set, amiFamily must be 'Windows2019' or 'Custom' when using a Windows2019 alias",rule="!has(self.amiFamily) || (self.amiSelectorTerms.exists(x, has(x.alias) && x.alias.find('^[^@]+') == 'windows2019') ? (self.amiFamily == 'Custom' || self.amiFamily == 'Windows2019') : true)"
 	// +kubebuilder:validation:XValidation:message="if set, amiFamily must be 'Windows2022' or 'Custom' when using a Windows2022 alias",rule="!has(self.amiFamily) || (self.amiSelectorTerms.exists(x, has(x.alias) && x.alias.find('^[^@]+') == 'windows2022') ? (self.amiFamily == 'Custom' || self.amiFamily == 'Windows2022') : true)"
 	// +kubebuilder:validation:XValidation:message="must specify amiFamily if amiSelectorTerms does not contain an alias",rule="self.amiSelectorTerms.exists(x, has(x.alias)) ? true : has(self.amiFamily)"
+	// +kubebuilder:validation:XValidation:message="'hostResourceGroupARN' and 'hostSelectorTerms' may only be set with 'host' tenancy",rule="!has(self.hostResourceGroupARN) && !has(self.hostSelectorTerms) || (has(self.tenancy) && self.tenancy == 'host')"
+	// +kubebuilder:validation:XValidation:message="'hostResourceGroupARN' and 'hostSelectorTerms' are mutually exclusive",rule="!(has(self.hostResourceGroupARN) && has(self.hostSelectorTerms))"
 	Spec   EC2NodeClassSpec   `json:"spec,omitempty"`
 	Status EC2NodeClassStatus `json:"status,omitempty"`
 }
@@ -459,7 +760,40 @@ func (in *EC2NodeClass) Hash() string {
 	})))
 }
 
+// WarmPoolHash returns the hash of the configuration that a warm instance is bootstrapped with for the NodeClaim. The
+// labels and taints of the NodeClaim are passed to the kubelet through the user data, so a warm instance can only be
+// claimed by a NodeClaim with the same hash.
+func (in *EC2NodeClass) WarmPoolHash(nodeClaim *karpv1.NodeClaim) string {
+	return fmt.Sprint(lo.Must(hashstructure.Hash([]interface{}{
+		in.Hash(),
+		nodeClaim.Labels,
+		nodeClaim.Spec.Taints,
+		nodeClaim.Spec.StartupTaints,
+	}, hashstructure.FormatV2, &hashstructure.HashOptions{
+		SlicesAsSets:    true,
+		IgnoreZeroValue: true,
+		ZeroNil:         true,
+	})))
+}
+
 func (in *EC2NodeClass) InstanceProfileName(clusterName, region string) string {
+	return in.managedResourceName(clusterName, region)
+}
+
+// PlacementGroupName is the name of the placement group that Karpenter creates and manages for the EC2NodeClass. Placement
+// groups can't be modified after creation, so the strategy and partition count are part of the name and a new placement
+// group is created when either of them changes.
+func (in *EC2NodeClass) PlacementGroupName(clusterName, region string) string {
+	name := fmt.Sprintf("%s_%s", in.managedResourceName(clusterName, region), in.Spec.PlacementGroup.Strategy)
+	if in.Spec.PlacementGroup.PartitionCount != nil {
+		name = fmt.Sprintf("%s_%d", name, lo.FromPtr(in.Spec.PlacementGroup.PartitionCount))
+	}
+	return name
+}
+
+// managedResourceName is the name of a resource that Karpenter creates and manages for the EC2NodeClass, which is unique
+// to the cluster, region and EC2NodeClass
+func (in *EC2NodeClass) managedResourceName(clusterName, region string) string {
 	return fmt.Sprintf("%s_%d", clusterName, lo.Must(hashstructure.Hash(fmt.Sprintf("%s%s", region, in.Name), hashstructure.FormatV2, nil)))
 }
 
@@ -504,6 +838,33 @@ func (a *Alias) String() string {
 	return fmt.Sprintf("%s@%s", a.Family, a.Version)
 }
 
+// Tenancy returns the tenancy of instances launched with the EC2NodeClass, defaulting to "default" when unset
+func (in *EC2NodeClass) Tenancy() string {
+	return lo.FromPtrOr(in.Spec.Tenancy, TenancyDefault)
+}
+
+// IPAddressMode returns how the VPC CNI assigns IP addresses to pods, defaulting to "secondary-ip" when unset
+func (in *EC2NodeClass) IPAddressMode() string {
+	return lo.FromPtrOr(in.Spec.IPAddressMode, IPAddressModeSecondaryIP)
+}
+
+// SpotAllocationStrategy returns the allocation strategy for spot launches, defaulting to "price-capacity-optimized"
+func (in *EC2NodeClass) SpotAllocationStrategy() string {
+	if in.Spec.LaunchStrategy == nil {
+		return SpotAllocationStrategyPriceCapacityOptimized
+	}
+	return lo.FromPtrOr(in.Spec.LaunchStrategy.SpotAllocationStrategy, SpotAllocationStrategyPriceCapacityOptimized)
+}
+
+// OnDemandAllocationStrategy returns the allocation strategy for on-demand and reserved launches, defaulting to
+// "lowest-price"
+func (in *EC2NodeClass) OnDemandAllocationStrategy() string {
+	if in.Spec.LaunchStrategy == nil {
+		return OnDemandAllocationStrategyLowestPrice
+	}
+	return lo.FromPtrOr(in.Spec.LaunchStrategy.OnDemandAllocationStrategy, OnDemandAllocationStrategyLowestPrice)
+}
+
 func (in *EC2NodeClass) Alias() *Alias {
 	term, ok := lo.Find(in.Spec.AMISelectorTerms, func(term AMISelectorTerm) bool {
 		return term.Alias != ""
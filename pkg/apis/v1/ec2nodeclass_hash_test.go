@@ -101,11 +101,21 @@ var _ = Describe("Hash", func() {
 		Entry("BlockDeviceMapping SnapshotID", "8031059801598053215", v1.EC2NodeClass{Spec: v1.EC2NodeClassSpec{BlockDeviceMappings: []*v1.BlockDeviceMapping{{EBS: &v1.BlockDevice{SnapshotID: lo.ToPtr("test")}}}}}),
 		Entry("BlockDeviceMapping Throughput", "14410045481146650034", v1.EC2NodeClass{Spec: v1.EC2NodeClassSpec{BlockDeviceMappings: []*v1.BlockDeviceMapping{{EBS: &v1.BlockDevice{Throughput: lo.ToPtr(int64(10))}}}}}),
 		Entry("BlockDeviceMapping VolumeType", "9480251663542054235", v1.EC2NodeClass{Spec: v1.EC2NodeClassSpec{BlockDeviceMappings: []*v1.BlockDeviceMapping{{EBS: &v1.BlockDevice{VolumeType: lo.ToPtr("io1")}}}}}),
+		Entry("CPUOptions CoreCount", "3576269491013093065", v1.EC2NodeClass{Spec: v1.EC2NodeClassSpec{CPUOptions: &v1.CPUOptions{CoreCount: lo.ToPtr(int32(4))}}}),
+		Entry("CPUOptions CoreRatio", "10112870131607470811", v1.EC2NodeClass{Spec: v1.EC2NodeClassSpec{CPUOptions: &v1.CPUOptions{CoreRatio: lo.ToPtr(int32(50))}}}),
+		Entry("CPUOptions ThreadsPerCore", "16263077798267922354", v1.EC2NodeClass{Spec: v1.EC2NodeClassSpec{CPUOptions: &v1.CPUOptions{ThreadsPerCore: lo.ToPtr(int32(1))}}}),
+		Entry("CPUOptions AMDSEVSNP", "14140514907081795596", v1.EC2NodeClass{Spec: v1.EC2NodeClassSpec{CPUOptions: &v1.CPUOptions{AMDSEVSNP: lo.ToPtr("enabled")}}}),
+		Entry("Tenancy", "3822920932056448472", v1.EC2NodeClass{Spec: v1.EC2NodeClassSpec{Tenancy: lo.ToPtr(v1.TenancyDedicated)}}),
+		Entry("IPAddressMode", "8875626615878461644", v1.EC2NodeClass{Spec: v1.EC2NodeClassSpec{IPAddressMode: lo.ToPtr(v1.IPAddressModePrefixDelegation)}}),
 
 		// Behavior / Dynamic fields, expect same hash as base
 		Entry("Modified AMISelector", staticHash, v1.EC2NodeClass{Spec: v1.EC2NodeClassSpec{AMISelectorTerms: []v1.AMISelectorTerm{{Tags: map[string]string{"": "ami-test-value"}}}}}),
 		Entry("Modified SubnetSelector", staticHash, v1.EC2NodeClass{Spec: v1.EC2NodeClassSpec{SubnetSelectorTerms: []v1.SubnetSelectorTerm{{Tags: map[string]string{"subnet-test-key": "subnet-test-value"}}}}}),
 		Entry("Modified SecurityGroupSelector", staticHash, v1.EC2NodeClass{Spec: v1.EC2NodeClassSpec{SecurityGroupSelectorTerms: []v1.SecurityGroupSelectorTerm{{Tags: map[string]string{"security-group-test-key": "security-group-test-value"}}}}}),
+		Entry("Modified HostResourceGroupARN", staticHash, v1.EC2NodeClass{Spec: v1.EC2NodeClassSpec{HostResourceGroupARN: lo.ToPtr("arn:aws:resource-groups:us-west-2:123456789012:group/test")}}),
+		Entry("Modified HostSelector", staticHash, v1.EC2NodeClass{Spec: v1.EC2NodeClassSpec{HostSelectorTerms: []v1.HostSelectorTerm{{ID: "h-test"}}}}),
+		Entry("Modified LaunchStrategy", staticHash, v1.EC2NodeClass{Spec: v1.EC2NodeClassSpec{LaunchStrategy: &v1.LaunchStrategy{SpotAllocationStrategy: lo.ToPtr(v1.SpotAllocationStrategyCapacityOptimized)}}}),
+		Entry("Modified RebalanceRecommendationPolicy", staticHash, v1.EC2NodeClass{Spec: v1.EC2NodeClassSpec{RebalanceRecommendationPolicy: lo.ToPtr(v1.RebalanceRecommendationPolicyReplaceBeforeDrain)}}),
 	)
 	// We create a separate test for updating blockDeviceMapping volumeSize, since resource.Quantity is a struct, and mergo.WithSliceDeepCopy
 	// doesn't work well with unexported fields, like the ones that are present in resource.Quantity
@@ -113,6 +123,12 @@ var _ = Describe("Hash", func() {
 		nodeClass.Spec.BlockDeviceMappings[0].EBS.VolumeSize = resource.NewScaledQuantity(10, resource.Giga)
 		Expect(nodeClass.Hash()).To(Equal("5906178522470964189"))
 	})
+	// We create a separate test for setting networkInterfaces, since mergo.WithSliceDeepCopy only merges into the elements
+	// that already exist in the destination slice, and the nodeClass has no networkInterfaces to merge into
+	It("should match static hash when setting networkInterfaces", func() {
+		nodeClass.Spec.NetworkInterfaces = []*v1.NetworkInterface{{NetworkCardIndex: 1, InterfaceType: v1.NetworkInterfaceTypeEFAOnly}}
+		Expect(nodeClass.Hash()).To(Equal("4991108300747819872"))
+	})
 	It("should match static hash for instanceProfile", func() {
 		nodeClass.Spec.Role = ""
 		nodeClass.Spec.InstanceProfile = lo.ToPtr("test-instance-profile")
@@ -151,6 +167,12 @@ var _ = Describe("Hash", func() {
 		Entry("BlockDeviceMapping SnapshotID", v1.EC2NodeClass{Spec: v1.EC2NodeClassSpec{BlockDeviceMappings: []*v1.BlockDeviceMapping{{EBS: &v1.BlockDevice{SnapshotID: lo.ToPtr("test")}}}}}),
 		Entry("BlockDeviceMapping Throughput", v1.EC2NodeClass{Spec: v1.EC2NodeClassSpec{BlockDeviceMappings: []*v1.BlockDeviceMapping{{EBS: &v1.BlockDevice{Throughput: lo.ToPtr(int64(10))}}}}}),
 		Entry("BlockDeviceMapping VolumeType", v1.EC2NodeClass{Spec: v1.EC2NodeClassSpec{BlockDeviceMappings: []*v1.BlockDeviceMapping{{EBS: &v1.BlockDevice{VolumeType: lo.ToPtr("io1")}}}}}),
+		Entry("CPUOptions CoreCount", v1.EC2NodeClass{Spec: v1.EC2NodeClassSpec{CPUOptions: &v1.CPUOptions{CoreCount: lo.ToPtr(int32(4))}}}),
+		Entry("CPUOptions CoreRatio", v1.EC2NodeClass{Spec: v1.EC2NodeClassSpec{CPUOptions: &v1.CPUOptions{CoreRatio: lo.ToPtr(int32(50))}}}),
+		Entry("CPUOptions ThreadsPerCore", v1.EC2NodeClass{Spec: v1.EC2NodeClassSpec{CPUOptions: &v1.CPUOptions{ThreadsPerCore: lo.ToPtr(int32(1))}}}),
+		Entry("CPUOptions AMDSEVSNP", v1.EC2NodeClass{Spec: v1.EC2NodeClassSpec{CPUOptions: &v1.CPUOptions{AMDSEVSNP: lo.ToPtr("enabled")}}}),
+		Entry("Tenancy", v1.EC2NodeClass{Spec: v1.EC2NodeClassSpec{Tenancy: lo.ToPtr(v1.TenancyDedicated)}}),
+		Entry("IPAddressMode", v1.EC2NodeClass{Spec: v1.EC2NodeClassSpec{IPAddressMode: lo.ToPtr(v1.IPAddressModePrefixDelegation)}}),
 	)
 	// We create a separate test for updating blockDeviceMapping volumeSize, since resource.Quantity is a struct, and mergo.WithSliceDeepCopy
 	// doesn't work well with unexported fields, like the ones that are present in resource.Quantity
@@ -160,6 +182,21 @@ var _ = Describe("Hash", func() {
 		updatedHash := nodeClass.Hash()
 		Expect(hash).ToNot(Equal(updatedHash))
 	})
+	It("should change hash when networkInterfaces are updated", func() {
+		nodeClass.Spec.NetworkInterfaces = []*v1.NetworkInterface{{}}
+		hash := nodeClass.Hash()
+		nodeClass.Spec.NetworkInterfaces[0].SecondaryIPAddressCount = lo.ToPtr[int32](2)
+		updatedHash := nodeClass.Hash()
+		Expect(hash).ToNot(Equal(updatedHash))
+	})
+	It("should not change hash when the selector terms of networkInterfaces are updated", func() {
+		nodeClass.Spec.NetworkInterfaces = []*v1.NetworkInterface{{DeviceIndex: 1}}
+		hash := nodeClass.Hash()
+		nodeClass.Spec.NetworkInterfaces[0].SubnetSelectorTerms = []v1.SubnetSelectorTerm{{ID: "subnet-test"}}
+		nodeClass.Spec.NetworkInterfaces[0].SecurityGroupSelectorTerms = []v1.SecurityGroupSelectorTerm{{ID: "sg-test"}}
+		updatedHash := nodeClass.Hash()
+		Expect(hash).To(Equal(updatedHash))
+	})
 	It("should change hash when instanceProfile is updated", func() {
 		nodeClass.Spec.Role = ""
 		nodeClass.Spec.InstanceProfile = lo.ToPtr("test-instance-profile")
@@ -17,14 +17,31 @@ package v1
 import (
 	"github.com/awslabs/operatorpkg/status"
 	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
 const (
-	ConditionTypeSubnetsReady         = "SubnetsReady"
-	ConditionTypeSecurityGroupsReady  = "SecurityGroupsReady"
-	ConditionTypeAMIsReady            = "AMIsReady"
-	ConditionTypeInstanceProfileReady = "InstanceProfileReady"
-	ConditionTypeValidationSucceeded  = "ValidationSucceeded"
+	ConditionTypeSubnetsReady              = "SubnetsReady"
+	ConditionTypeSecurityGroupsReady       = "SecurityGroupsReady"
+	ConditionTypeAMIsReady                 = "AMIsReady"
+	ConditionTypeInstanceProfileReady      = "InstanceProfileReady"
+	ConditionTypeValidationSucceeded       = "ValidationSucceeded"
+	ConditionTypeCapacityReservationsReady = "CapacityReservationsReady"
+	ConditionTypePlacementGroupReady       = "PlacementGroupReady"
+	ConditionTypeHostsReady                = "HostsReady"
+	ConditionTypeNetworkInterfacesReady    = "NetworkInterfacesReady"
+	ConditionTypeLaunchPermissionsReady    = "LaunchPermissionsReady"
+	// ConditionTypeVCPUQuotaAvailable indicates whether the account's EC2 vCPU quotas have room to launch the
+	// EC2NodeClass' instance types. It doesn't affect readiness, since other instance types or capacity types may
+	// still be launched.
+	ConditionTypeVCPUQuotaAvailable = "VCPUQuotaAvailable"
+)
+
+const (
+	// CapacityReservationTypeDefault is an On-Demand Capacity Reservation
+	CapacityReservationTypeDefault = "default"
+	// CapacityReservationTypeCapacityBlock is a Capacity Block for ML, which is only usable between its start and end time
+	CapacityReservationTypeCapacityBlock = "capacity-block"
 )
 
 // Subnet contains resolved Subnet selector values utilized for node launch
@@ -38,6 +55,19 @@ type Subnet struct {
 	// The associated availability zone ID
 	// +optional
 	ZoneID string `json:"zoneID,omitempty"`
+	// The type of the associated zone, one of availability-zone, local-zone, wavelength-zone or outpost
+	// +optional
+	ZoneType string `json:"zoneType,omitempty"`
+	// The availability zone that the associated Local Zone or Wavelength Zone is anchored to
+	// +optional
+	ParentZone string `json:"parentZone,omitempty"`
+	// The ARN of the Outpost that the subnet belongs to
+	// +optional
+	OutpostARN string `json:"outpostARN,omitempty"`
+	// PrefixFragmented is true when a recent launch failed because the subnet didn't have a contiguous /28 block of IPv4
+	// addresses for a prefix. Fragmented subnets aren't launched into with the "prefix-delegation" IP address mode.
+	// +optional
+	PrefixFragmented bool `json:"prefixFragmented,omitempty"`
 }
 
 // SecurityGroup contains resolved SecurityGroup selector values utilized for node launch
@@ -66,6 +96,98 @@ type AMI struct {
 	Requirements []corev1.NodeSelectorRequirement `json:"requirements"`
 }
 
+// CapacityReservation contains resolved CapacityReservation selector values utilized for node launch
+type CapacityReservation struct {
+	// ID of the capacity reservation
+	// +required
+	ID string `json:"id"`
+	// The instance type that the capacity reservation is for
+	// +required
+	InstanceType string `json:"instanceType"`
+	// The availability zone that the capacity reservation is in
+	// +required
+	AvailabilityZone string `json:"availabilityZone"`
+	// The id of the AWS account that owns the capacity reservation
+	// +optional
+	OwnerID string `json:"ownerID,omitempty"`
+	// Indicates the type of instance launches that the capacity reservation accepts. Karpenter always targets the
+	// reservation explicitly, so both "open" and "targeted" reservations can be used.
+	// +kubebuilder:validation:Enum:={open,targeted}
+	// +optional
+	InstanceMatchCriteria string `json:"instanceMatchCriteria,omitempty"`
+	// The type of the capacity reservation. Instances launched into a capacity block are terminated by EC2 when the
+	// block reaches its end time.
+	// +kubebuilder:validation:Enum:={default,capacity-block}
+	// +kubebuilder:default=default
+	// +optional
+	ReservationType string `json:"reservationType,omitempty"`
+	// The time at which the capacity reservation expires. Once expired, the reserved capacity is released and
+	// instances launched into the reservation continue running as on-demand instances, with the exception of
+	// capacity blocks whose instances are terminated.
+	// +optional
+	EndTime *metav1.Time `json:"endTime,omitempty"`
+}
+
+// PlacementGroup contains the resolved PlacementGroup selector values utilized for node launch
+type PlacementGroup struct {
+	// ID of the placement group
+	// +required
+	ID string `json:"id"`
+	// Name of the placement group
+	// +required
+	Name string `json:"name"`
+	// Strategy of the placement group
+	// +kubebuilder:validation:Enum:={cluster,partition,spread}
+	// +required
+	Strategy string `json:"strategy"`
+	// PartitionCount is the number of partitions of a partition placement group
+	// +optional
+	PartitionCount int32 `json:"partitionCount,omitempty"`
+	// Zone is the zone that instances are launched into for a cluster placement group, since all of the instances of a
+	// cluster placement group must be in a single zone
+	// +optional
+	Zone string `json:"zone,omitempty"`
+	// Managed is true when the placement group was created by Karpenter for the EC2NodeClass, in which case it's deleted
+	// along with the EC2NodeClass
+	// +optional
+	Managed bool `json:"managed,omitempty"`
+}
+
+// Host contains resolved Dedicated Host selector values utilized for node launch
+type Host struct {
+	// ID of the host
+	// +required
+	ID string `json:"id"`
+	// The availability zone that the host is in
+	// +required
+	AvailabilityZone string `json:"availabilityZone"`
+	// The instance type that the host supports. This is unset for hosts which support multiple instance types.
+	// +optional
+	InstanceType string `json:"instanceType,omitempty"`
+	// The instance family that the host supports
+	// +optional
+	InstanceFamily string `json:"instanceFamily,omitempty"`
+}
+
+// NetworkInterfaceStatus contains the resolved security groups and subnets of a network interface that instances are
+// launched with
+type NetworkInterfaceStatus struct {
+	// NetworkCardIndex is the index of the network card that the network interface is attached to
+	// +required
+	NetworkCardIndex int32 `json:"networkCardIndex"`
+	// DeviceIndex is the device index of the network interface on its network card
+	// +required
+	DeviceIndex int32 `json:"deviceIndex"`
+	// SecurityGroups contains the security groups of the network interface. This is unset for network interfaces
+	// which use the security groups of the EC2NodeClass.
+	// +optional
+	SecurityGroups []SecurityGroup `json:"securityGroups,omitempty"`
+	// Subnets contains the subnets that the network interface can be created in. This is unset for network interfaces
+	// which are created in the subnet that the instance is launched into.
+	// +optional
+	Subnets []Subnet `json:"subnets,omitempty"`
+}
+
 // EC2NodeClassStatus contains the resolved state of the EC2NodeClass
 type EC2NodeClassStatus struct {
 	// Subnets contains the current subnet values that are available to the
@@ -80,6 +202,21 @@ type EC2NodeClassStatus struct {
 	// cluster under the AMI selectors.
 	// +optional
 	AMIs []AMI `json:"amis,omitempty"`
+	// CapacityReservations contains the current capacity reservation values that are available to the
+	// cluster under the CapacityReservation selectors.
+	// +optional
+	CapacityReservations []CapacityReservation `json:"capacityReservations,omitempty"`
+	// PlacementGroup contains the resolved placement group that instances are launched into
+	// +optional
+	PlacementGroup *PlacementGroup `json:"placementGroup,omitempty"`
+	// Hosts contains the current Dedicated Host values that are available to the
+	// cluster under the Host selectors.
+	// +optional
+	Hosts []Host `json:"hosts,omitempty"`
+	// NetworkInterfaces contains the resolved security groups and subnets of the network interfaces that instances
+	// are launched with
+	// +optional
+	NetworkInterfaces []NetworkInterfaceStatus `json:"networkInterfaces,omitempty"`
 	// InstanceProfile contains the resolved instance profile for the role
 	// +optional
 	InstanceProfile string `json:"instanceProfile,omitempty"`
@@ -93,6 +230,10 @@ func (in *EC2NodeClass) StatusConditions() status.ConditionSet {
 		ConditionTypeAMIsReady,
 		ConditionTypeSubnetsReady,
 		ConditionTypeSecurityGroupsReady,
+		ConditionTypeCapacityReservationsReady,
+		ConditionTypePlacementGroupReady,
+		ConditionTypeHostsReady,
+		ConditionTypeNetworkInterfacesReady,
 		ConditionTypeInstanceProfileReady,
 		ConditionTypeValidationSucceeded,
 	).For(in)
@@ -209,6 +209,10 @@ var _ = Describe("CEL/Validation", func() {
 				"karpenter.sh/nodeclaim": "test",
 			}
 			Expect(env.Client.Create(ctx, nc)).To(Not(Succeed()))
+			nc.Spec.Tags = map[string]string{
+				v1.WarmPoolTagKey: "test",
+			}
+			Expect(env.Client.Create(ctx, nc)).To(Not(Succeed()))
 		})
 	})
 	Context("SubnetSelectorTerms", func() {
@@ -435,6 +439,77 @@ var _ = Describe("CEL/Validation", func() {
 			Expect(env.Client.Create(ctx, nc)).ToNot(Succeed())
 		})
 	})
+	Context("CapacityReservationSelectorTerms", func() {
+		It("should succeed with a valid capacity reservation selector on tags", func() {
+			nc.Spec.CapacityReservationSelectorTerms = []v1.CapacityReservationSelectorTerm{
+				{
+					Tags: map[string]string{
+						"test": "testvalue",
+					},
+				},
+			}
+			Expect(env.Client.Create(ctx, nc)).To(Succeed())
+		})
+		It("should succeed with a valid capacity reservation selector on id", func() {
+			nc.Spec.CapacityReservationSelectorTerms = []v1.CapacityReservationSelectorTerm{
+				{
+					ID: "cr-12345749",
+				},
+			}
+			Expect(env.Client.Create(ctx, nc)).To(Succeed())
+		})
+		It("should succeed with a valid capacity reservation selector on tags and owner", func() {
+			nc.Spec.CapacityReservationSelectorTerms = []v1.CapacityReservationSelectorTerm{
+				{
+					Tags: map[string]string{
+						"test": "testvalue",
+					},
+					OwnerID: "012345678901",
+				},
+			}
+			Expect(env.Client.Create(ctx, nc)).To(Succeed())
+		})
+		It("should fail when a capacity reservation selector term has no values", func() {
+			nc.Spec.CapacityReservationSelectorTerms = []v1.CapacityReservationSelectorTerm{
+				{},
+			}
+			Expect(env.Client.Create(ctx, nc)).ToNot(Succeed())
+		})
+		It("should fail when specifying id with tags", func() {
+			nc.Spec.CapacityReservationSelectorTerms = []v1.CapacityReservationSelectorTerm{
+				{
+					ID: "cr-12345749",
+					Tags: map[string]string{
+						"test": "testvalue",
+					},
+				},
+			}
+			Expect(env.Client.Create(ctx, nc)).ToNot(Succeed())
+		})
+		It("should fail when specifying id with ownerID", func() {
+			nc.Spec.CapacityReservationSelectorTerms = []v1.CapacityReservationSelectorTerm{
+				{
+					ID:      "cr-12345749",
+					OwnerID: "012345678901",
+				},
+			}
+			Expect(env.Client.Create(ctx, nc)).ToNot(Succeed())
+		})
+		It("should fail when a term specifying id with tags is mixed with valid terms", func() {
+			nc.Spec.CapacityReservationSelectorTerms = []v1.CapacityReservationSelectorTerm{
+				{
+					ID: "cr-12345749",
+				},
+				{
+					ID: "cr-98765432",
+					Tags: map[string]string{
+						"test": "testvalue",
+					},
+				},
+			}
+			Expect(env.Client.Create(ctx, nc)).ToNot(Succeed())
+		})
+	})
 	Context("AMISelectorTerms", func() {
 		It("should succeed with a valid ami selector on alias", func() {
 			nc.Spec.AMISelectorTerms = []v1.AMISelectorTerm{{
@@ -864,6 +939,276 @@ var _ = Describe("CEL/Validation", func() {
 			Expect(env.Client.Create(ctx, nc)).ToNot(Succeed())
 		})
 	})
+	Context("PlacementGroup", func() {
+		It("should succeed when selecting a placement group by name", func() {
+			nc.Spec.PlacementGroup = &v1.PlacementGroupSelector{Name: "test"}
+			Expect(env.Client.Create(ctx, nc)).To(Succeed())
+		})
+		It("should succeed when selecting a placement group by id", func() {
+			nc.Spec.PlacementGroup = &v1.PlacementGroupSelector{ID: "pg-0123456789abcdef0"}
+			Expect(env.Client.Create(ctx, nc)).To(Succeed())
+		})
+		It("should succeed when specifying a partition strategy with a partition count", func() {
+			nc.Spec.PlacementGroup = &v1.PlacementGroupSelector{Strategy: "partition", PartitionCount: aws.Int32(3)}
+			Expect(env.Client.Create(ctx, nc)).To(Succeed())
+		})
+		It("should fail when specifying more than one of name, id, and strategy", func() {
+			nc.Spec.PlacementGroup = &v1.PlacementGroupSelector{Name: "test", ID: "pg-0123456789abcdef0"}
+			Expect(env.Client.Create(ctx, nc)).ToNot(Succeed())
+		})
+		It("should fail when specifying none of name, id, and strategy", func() {
+			nc.Spec.PlacementGroup = &v1.PlacementGroupSelector{}
+			Expect(env.Client.Create(ctx, nc)).ToNot(Succeed())
+		})
+		It("should fail for an invalid id", func() {
+			nc.Spec.PlacementGroup = &v1.PlacementGroupSelector{ID: "test"}
+			Expect(env.Client.Create(ctx, nc)).ToNot(Succeed())
+		})
+		It("should fail for an invalid strategy", func() {
+			nc.Spec.PlacementGroup = &v1.PlacementGroupSelector{Strategy: "test"}
+			Expect(env.Client.Create(ctx, nc)).ToNot(Succeed())
+		})
+		It("should fail when specifying a partition count without the partition strategy", func() {
+			nc.Spec.PlacementGroup = &v1.PlacementGroupSelector{Strategy: "cluster", PartitionCount: aws.Int32(3)}
+			Expect(env.Client.Create(ctx, nc)).ToNot(Succeed())
+		})
+		It("should fail for a partition count greater than 7", func() {
+			nc.Spec.PlacementGroup = &v1.PlacementGroupSelector{Strategy: "partition", PartitionCount: aws.Int32(8)}
+			Expect(env.Client.Create(ctx, nc)).ToNot(Succeed())
+		})
+	})
+	Context("Tenancy", func() {
+		It("should succeed for a valid tenancy", func() {
+			nc.Spec.Tenancy = lo.ToPtr(v1.TenancyDedicated)
+			Expect(env.Client.Create(ctx, nc)).To(Succeed())
+		})
+		It("should fail for an invalid tenancy", func() {
+			nc.Spec.Tenancy = lo.ToPtr("test")
+			Expect(env.Client.Create(ctx, nc)).ToNot(Succeed())
+		})
+		It("should succeed when specifying host selector terms with host tenancy", func() {
+			nc.Spec.Tenancy = lo.ToPtr(v1.TenancyHost)
+			nc.Spec.HostSelectorTerms = []v1.HostSelectorTerm{{Tags: map[string]string{"test": "testvalue"}}, {ID: "h-0123456789abcdef0"}}
+			Expect(env.Client.Create(ctx, nc)).To(Succeed())
+		})
+		It("should succeed when specifying a host resource group with host tenancy", func() {
+			nc.Spec.Tenancy = lo.ToPtr(v1.TenancyHost)
+			nc.Spec.HostResourceGroupARN = lo.ToPtr("arn:aws:resource-groups:us-west-2:111122223333:group/test")
+			Expect(env.Client.Create(ctx, nc)).To(Succeed())
+		})
+		It("should fail when specifying host selector terms without host tenancy", func() {
+			nc.Spec.Tenancy = lo.ToPtr(v1.TenancyDedicated)
+			nc.Spec.HostSelectorTerms = []v1.HostSelectorTerm{{ID: "h-0123456789abcdef0"}}
+			Expect(env.Client.Create(ctx, nc)).ToNot(Succeed())
+		})
+		It("should fail when specifying a host resource group without a tenancy", func() {
+			nc.Spec.HostResourceGroupARN = lo.ToPtr("arn:aws:resource-groups:us-west-2:111122223333:group/test")
+			Expect(env.Client.Create(ctx, nc)).ToNot(Succeed())
+		})
+		It("should fail when specifying both a host resource group and host selector terms", func() {
+			nc.Spec.Tenancy = lo.ToPtr(v1.TenancyHost)
+			nc.Spec.HostResourceGroupARN = lo.ToPtr("arn:aws:resource-groups:us-west-2:111122223333:group/test")
+			nc.Spec.HostSelectorTerms = []v1.HostSelectorTerm{{ID: "h-0123456789abcdef0"}}
+			Expect(env.Client.Create(ctx, nc)).ToNot(Succeed())
+		})
+		It("should fail when a host selector term specifies both id and tags", func() {
+			nc.Spec.Tenancy = lo.ToPtr(v1.TenancyHost)
+			nc.Spec.HostSelectorTerms = []v1.HostSelectorTerm{{ID: "h-0123456789abcdef0", Tags: map[string]string{"test": "testvalue"}}}
+			Expect(env.Client.Create(ctx, nc)).ToNot(Succeed())
+		})
+		It("should fail when any of the host selector terms specifies both id and tags", func() {
+			nc.Spec.Tenancy = lo.ToPtr(v1.TenancyHost)
+			nc.Spec.HostSelectorTerms = []v1.HostSelectorTerm{
+				{Tags: map[string]string{"test": "testvalue"}},
+				{ID: "h-0123456789abcdef0", Tags: map[string]string{"test": "testvalue"}},
+			}
+			Expect(env.Client.Create(ctx, nc)).ToNot(Succeed())
+		})
+		It("should fail for an invalid host resource group arn", func() {
+			nc.Spec.Tenancy = lo.ToPtr(v1.TenancyHost)
+			nc.Spec.HostResourceGroupARN = lo.ToPtr("test")
+			Expect(env.Client.Create(ctx, nc)).ToNot(Succeed())
+		})
+	})
+	Context("LaunchStrategy", func() {
+		It("should succeed for valid allocation strategies", func() {
+			nc.Spec.LaunchStrategy = &v1.LaunchStrategy{
+				SpotAllocationStrategy:     lo.ToPtr(v1.SpotAllocationStrategyCapacityOptimized),
+				OnDemandAllocationStrategy: lo.ToPtr(v1.OnDemandAllocationStrategyLowestPrice),
+			}
+			Expect(env.Client.Create(ctx, nc)).To(Succeed())
+		})
+		It("should fail for an invalid spot allocation strategy", func() {
+			nc.Spec.LaunchStrategy = &v1.LaunchStrategy{SpotAllocationStrategy: lo.ToPtr("diversified")}
+			Expect(env.Client.Create(ctx, nc)).ToNot(Succeed())
+		})
+		It("should fail for an invalid on-demand allocation strategy", func() {
+			nc.Spec.LaunchStrategy = &v1.LaunchStrategy{OnDemandAllocationStrategy: lo.ToPtr("capacity-optimized")}
+			Expect(env.Client.Create(ctx, nc)).ToNot(Succeed())
+		})
+		It("should succeed when specifying instance type priorities with the capacity-optimized-prioritized spot allocation strategy", func() {
+			nc.Spec.LaunchStrategy = &v1.LaunchStrategy{
+				SpotAllocationStrategy: lo.ToPtr(v1.SpotAllocationStrategyCapacityOptimizedPrioritized),
+				InstanceTypePriorities: []v1.InstanceTypePriority{{InstanceType: "m5.large", Priority: 0}, {InstanceType: "m5.xlarge", Priority: 1}},
+			}
+			Expect(env.Client.Create(ctx, nc)).To(Succeed())
+		})
+		It("should succeed when specifying instance type priorities with the prioritized on-demand allocation strategy", func() {
+			nc.Spec.LaunchStrategy = &v1.LaunchStrategy{
+				OnDemandAllocationStrategy: lo.ToPtr(v1.OnDemandAllocationStrategyPrioritized),
+				InstanceTypePriorities:     []v1.InstanceTypePriority{{InstanceType: "m5.large", Priority: 0}},
+			}
+			Expect(env.Client.Create(ctx, nc)).To(Succeed())
+		})
+		It("should fail when specifying instance type priorities without a prioritized allocation strategy", func() {
+			nc.Spec.LaunchStrategy = &v1.LaunchStrategy{
+				SpotAllocationStrategy: lo.ToPtr(v1.SpotAllocationStrategyCapacityOptimized),
+				InstanceTypePriorities: []v1.InstanceTypePriority{{InstanceType: "m5.large", Priority: 0}},
+			}
+			Expect(env.Client.Create(ctx, nc)).ToNot(Succeed())
+		})
+		It("should fail when specifying duplicate instance type priorities", func() {
+			nc.Spec.LaunchStrategy = &v1.LaunchStrategy{
+				OnDemandAllocationStrategy: lo.ToPtr(v1.OnDemandAllocationStrategyPrioritized),
+				InstanceTypePriorities:     []v1.InstanceTypePriority{{InstanceType: "m5.large", Priority: 0}, {InstanceType: "m5.large", Priority: 1}},
+			}
+			Expect(env.Client.Create(ctx, nc)).ToNot(Succeed())
+		})
+		It("should fail when specifying a negative priority", func() {
+			nc.Spec.LaunchStrategy = &v1.LaunchStrategy{
+				OnDemandAllocationStrategy: lo.ToPtr(v1.OnDemandAllocationStrategyPrioritized),
+				InstanceTypePriorities:     []v1.InstanceTypePriority{{InstanceType: "m5.large", Priority: -1}},
+			}
+			Expect(env.Client.Create(ctx, nc)).ToNot(Succeed())
+		})
+	})
+	Context("WarmPool", func() {
+		It("should succeed for a valid warm pool", func() {
+			nc.Spec.WarmPool = &v1.WarmPool{NodePool: "default", Size: 2, InstanceTypes: []string{"m5.large", "m5.xlarge"}}
+			Expect(env.Client.Create(ctx, nc)).To(Succeed())
+		})
+		It("should succeed for an empty warm pool", func() {
+			nc.Spec.WarmPool = &v1.WarmPool{NodePool: "default", Size: 0, InstanceTypes: []string{"m5.large"}}
+			Expect(env.Client.Create(ctx, nc)).To(Succeed())
+		})
+		It("should fail for a negative size", func() {
+			nc.Spec.WarmPool = &v1.WarmPool{NodePool: "default", Size: -1, InstanceTypes: []string{"m5.large"}}
+			Expect(env.Client.Create(ctx, nc)).ToNot(Succeed())
+		})
+		It("should fail when no instance types are specified", func() {
+			nc.Spec.WarmPool = &v1.WarmPool{NodePool: "default", Size: 1}
+			Expect(env.Client.Create(ctx, nc)).ToNot(Succeed())
+		})
+		It("should fail when no nodepool is specified", func() {
+			nc.Spec.WarmPool = &v1.WarmPool{Size: 1, InstanceTypes: []string{"m5.large"}}
+			Expect(env.Client.Create(ctx, nc)).ToNot(Succeed())
+		})
+		It("should fail when an instance type is empty", func() {
+			nc.Spec.WarmPool = &v1.WarmPool{NodePool: "default", Size: 1, InstanceTypes: []string{""}}
+			Expect(env.Client.Create(ctx, nc)).ToNot(Succeed())
+		})
+	})
+	Context("CPUOptions", func() {
+		It("should succeed for valid CPU options", func() {
+			nc.Spec.CPUOptions = &v1.CPUOptions{CoreCount: lo.ToPtr[int32](2), ThreadsPerCore: lo.ToPtr[int32](1), AMDSEVSNP: lo.ToPtr("enabled")}
+			Expect(env.Client.Create(ctx, nc)).To(Succeed())
+		})
+		It("should succeed for a core ratio", func() {
+			nc.Spec.CPUOptions = &v1.CPUOptions{CoreRatio: lo.ToPtr[int32](50)}
+			Expect(env.Client.Create(ctx, nc)).To(Succeed())
+		})
+		It("should fail when specifying both a core count and a core ratio", func() {
+			nc.Spec.CPUOptions = &v1.CPUOptions{CoreCount: lo.ToPtr[int32](2), CoreRatio: lo.ToPtr[int32](50)}
+			Expect(env.Client.Create(ctx, nc)).ToNot(Succeed())
+		})
+		It("should fail for a core ratio greater than 100", func() {
+			nc.Spec.CPUOptions = &v1.CPUOptions{CoreRatio: lo.ToPtr[int32](101)}
+			Expect(env.Client.Create(ctx, nc)).ToNot(Succeed())
+		})
+		It("should fail for more than two threads per core", func() {
+			nc.Spec.CPUOptions = &v1.CPUOptions{ThreadsPerCore: lo.ToPtr[int32](3)}
+			Expect(env.Client.Create(ctx, nc)).ToNot(Succeed())
+		})
+		It("should fail for an invalid AMD SEV-SNP value", func() {
+			nc.Spec.CPUOptions = &v1.CPUOptions{AMDSEVSNP: lo.ToPtr("on")}
+			Expect(env.Client.Create(ctx, nc)).ToNot(Succeed())
+		})
+	})
+	Context("RebalanceRecommendationPolicy", func() {
+		It("should succeed for valid policies", func() {
+			for _, policy := range []v1.RebalanceRecommendationPolicy{v1.RebalanceRecommendationPolicyNone, v1.RebalanceRecommendationPolicyReplaceBeforeDrain} {
+				nc := nc.DeepCopy()
+				nc.Spec.RebalanceRecommendationPolicy = lo.ToPtr(policy)
+				Expect(env.Client.Create(ctx, nc)).To(Succeed())
+				Expect(env.Client.Delete(ctx, nc)).To(Succeed())
+			}
+		})
+		It("should fail for an invalid policy", func() {
+			nc.Spec.RebalanceRecommendationPolicy = lo.ToPtr(v1.RebalanceRecommendationPolicy("Drain"))
+			Expect(env.Client.Create(ctx, nc)).ToNot(Succeed())
+		})
+	})
+	Context("IPAddressMode", func() {
+		DescribeTable("should succeed for a valid IP address mode", func(ipAddressMode string) {
+			nc.Spec.IPAddressMode = lo.ToPtr(ipAddressMode)
+			Expect(env.Client.Create(ctx, nc)).To(Succeed())
+		},
+			Entry("secondary-ip", v1.IPAddressModeSecondaryIP),
+			Entry("prefix-delegation", v1.IPAddressModePrefixDelegation),
+			Entry("ipv6", v1.IPAddressModeIPv6),
+		)
+		It("should fail for an invalid IP address mode", func() {
+			nc.Spec.IPAddressMode = lo.ToPtr("dual-stack")
+			Expect(env.Client.Create(ctx, nc)).ToNot(Succeed())
+		})
+	})
+	Context("NetworkInterfaces", func() {
+		It("should succeed for a primary network interface with prefixes", func() {
+			nc.Spec.NetworkInterfaces = []*v1.NetworkInterface{{DeviceIndex: 0, IPv4PrefixCount: lo.ToPtr[int32](2)}}
+			Expect(env.Client.Create(ctx, nc)).To(Succeed())
+		})
+		It("should succeed for multiple network interfaces across network cards", func() {
+			nc.Spec.NetworkInterfaces = []*v1.NetworkInterface{
+				{NetworkCardIndex: 0, DeviceIndex: 0, InterfaceType: v1.NetworkInterfaceTypeEFA},
+				{NetworkCardIndex: 0, DeviceIndex: 1, SubnetSelectorTerms: []v1.SubnetSelectorTerm{{Tags: map[string]string{"Name": "pods"}}}},
+				{NetworkCardIndex: 1, DeviceIndex: 0, InterfaceType: v1.NetworkInterfaceTypeEFAOnly},
+			}
+			Expect(env.Client.Create(ctx, nc)).To(Succeed())
+		})
+		It("should default the network card index and interface type", func() {
+			nc.Spec.NetworkInterfaces = []*v1.NetworkInterface{{DeviceIndex: 0}}
+			Expect(env.Client.Create(ctx, nc)).To(Succeed())
+			Expect(nc.Spec.NetworkInterfaces[0].InterfaceType).To(Equal(v1.NetworkInterfaceTypeInterface))
+		})
+		It("should fail without a primary network interface", func() {
+			nc.Spec.NetworkInterfaces = []*v1.NetworkInterface{{NetworkCardIndex: 1, DeviceIndex: 0}}
+			Expect(env.Client.Create(ctx, nc)).ToNot(Succeed())
+		})
+		It("should fail for duplicate network interfaces", func() {
+			nc.Spec.NetworkInterfaces = []*v1.NetworkInterface{{DeviceIndex: 0}, {DeviceIndex: 1}, {DeviceIndex: 1}}
+			Expect(env.Client.Create(ctx, nc)).ToNot(Succeed())
+		})
+		It("should fail when the primary network interface selects subnets", func() {
+			nc.Spec.NetworkInterfaces = []*v1.NetworkInterface{{DeviceIndex: 0, SubnetSelectorTerms: []v1.SubnetSelectorTerm{{ID: "subnet-test"}}}}
+			Expect(env.Client.Create(ctx, nc)).ToNot(Succeed())
+		})
+		It("should fail when the primary network interface is efa-only", func() {
+			nc.Spec.NetworkInterfaces = []*v1.NetworkInterface{{DeviceIndex: 0, InterfaceType: v1.NetworkInterfaceTypeEFAOnly}}
+			Expect(env.Client.Create(ctx, nc)).ToNot(Succeed())
+		})
+		It("should fail when an efa-only network interface is assigned prefixes", func() {
+			nc.Spec.NetworkInterfaces = []*v1.NetworkInterface{{DeviceIndex: 0}, {NetworkCardIndex: 1, InterfaceType: v1.NetworkInterfaceTypeEFAOnly, IPv4PrefixCount: lo.ToPtr[int32](1)}}
+			Expect(env.Client.Create(ctx, nc)).ToNot(Succeed())
+		})
+		It("should fail when setting both prefixes and secondary IP addresses", func() {
+			nc.Spec.NetworkInterfaces = []*v1.NetworkInterface{{DeviceIndex: 0, IPv4PrefixCount: lo.ToPtr[int32](1), SecondaryIPAddressCount: lo.ToPtr[int32](1)}}
+			Expect(env.Client.Create(ctx, nc)).ToNot(Succeed())
+		})
+		It("should fail for an invalid interface type", func() {
+			nc.Spec.NetworkInterfaces = []*v1.NetworkInterface{{DeviceIndex: 0, InterfaceType: "trunk"}}
+			Expect(env.Client.Create(ctx, nc)).ToNot(Succeed())
+		})
+	})
 	Context("BlockDeviceMappings", func() {
 		It("should succeed if more than one root volume is specified", func() {
 			nodeClass := &v1.EC2NodeClass{
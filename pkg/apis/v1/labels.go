@@ -37,6 +37,7 @@ func init() {
 		LabelInstanceSize,
 		LabelInstanceLocalNVME,
 		LabelInstanceCPU,
+		LabelInstanceCPUCores,
 		LabelInstanceCPUManufacturer,
 		LabelInstanceCPUSustainedClockSpeedMhz,
 		LabelInstanceMemory,
@@ -50,6 +51,10 @@ func init() {
 		LabelInstanceAcceleratorManufacturer,
 		LabelInstanceAcceleratorCount,
 		LabelTopologyZoneID,
+		LabelTopologyZoneType,
+		LabelTopologyParentZone,
+		LabelCapacityReservationID,
+		LabelInstanceTenancy,
 		corev1.LabelWindowsBuild,
 	)
 }
@@ -59,6 +64,9 @@ var (
 	AWSToKubeArchitectures = map[string]string{
 		"x86_64":                 karpv1.ArchitectureAmd64,
 		karpv1.ArchitectureArm64: karpv1.ArchitectureArm64,
+		// Mac instances report their own architectures and are only available on Dedicated Hosts
+		"x86_64_mac": karpv1.ArchitectureAmd64,
+		"arm64_mac":  karpv1.ArchitectureArm64,
 	}
 	WellKnownArchitectures = sets.NewString(
 		karpv1.ArchitectureAmd64,
@@ -75,6 +83,7 @@ var (
 		regexp.MustCompile(fmt.Sprintf("^%s$", regexp.QuoteMeta(EKSClusterNameTagKey))),
 		regexp.MustCompile(fmt.Sprintf("^%s$", regexp.QuoteMeta(NodeClassTagKey))),
 		regexp.MustCompile(fmt.Sprintf("^%s$", regexp.QuoteMeta(NodeClaimTagKey))),
+		regexp.MustCompile(fmt.Sprintf("^%s$", regexp.QuoteMeta(WarmPoolTagKey))),
 	}
 	AMIFamilyBottlerocket                          = "Bottlerocket"
 	AMIFamilyAL2                                   = "AL2"
@@ -100,6 +109,29 @@ var (
 	LabelNodeClass = apis.Group + "/ec2nodeclass"
 
 	LabelTopologyZoneID = "topology.k8s.aws/zone-id"
+	// LabelTopologyZoneType is the type of the zone that the instance is launched into. Outposts aren't zones in EC2,
+	// but instances launched into an Outpost subnet are labeled with the "outpost" zone type.
+	LabelTopologyZoneType = "topology.k8s.aws/zone-type"
+	// LabelTopologyParentZone is the availability zone that a Local Zone or Wavelength Zone is anchored to
+	LabelTopologyParentZone = "topology.k8s.aws/parent-zone"
+
+	ZoneTypeAvailabilityZone = "availability-zone"
+	ZoneTypeLocalZone        = "local-zone"
+	ZoneTypeWavelengthZone   = "wavelength-zone"
+	ZoneTypeOutpost          = "outpost"
+
+	// CapacityTypeReserved is the capacity type for instances launched into an On-Demand Capacity Reservation
+	CapacityTypeReserved       = "reserved"
+	LabelCapacityReservationID = apis.Group + "/capacity-reservation-id"
+
+	// LabelPlacementGroupPartition is the partition of the partition placement group that the instance was launched
+	// into. EC2 assigns the partition at launch, so this label can't be used to schedule pods onto new capacity.
+	LabelPlacementGroupPartition = apis.Group + "/placement-group-partition"
+
+	TenancyDefault       = "default"
+	TenancyDedicated     = "dedicated"
+	TenancyHost          = "host"
+	LabelInstanceTenancy = apis.Group + "/instance-tenancy"
 
 	LabelInstanceHypervisor                   = apis.Group + "/instance-hypervisor"
 	LabelInstanceEncryptionInTransitSupported = apis.Group + "/instance-encryption-in-transit-supported"
@@ -109,6 +141,7 @@ var (
 	LabelInstanceLocalNVME                    = apis.Group + "/instance-local-nvme"
 	LabelInstanceSize                         = apis.Group + "/instance-size"
 	LabelInstanceCPU                          = apis.Group + "/instance-cpu"
+	LabelInstanceCPUCores                     = apis.Group + "/instance-cpu-cores"
 	LabelInstanceCPUManufacturer              = apis.Group + "/instance-cpu-manufacturer"
 	LabelInstanceCPUSustainedClockSpeedMhz    = apis.Group + "/instance-cpu-sustained-clock-speed-mhz"
 	LabelInstanceMemory                       = apis.Group + "/instance-memory"
@@ -126,10 +159,47 @@ var (
 	AnnotationEC2NodeClassHashVersion         = apis.Group + "/ec2nodeclass-hash-version"
 	AnnotationInstanceTagged                  = apis.Group + "/tagged"
 
+	// AnnotationRebalanceRecommendedAt is the time that a NodeClaim first received a rebalance recommendation
+	AnnotationRebalanceRecommendedAt = apis.Group + "/rebalance-recommended-at"
+	// AnnotationRebalanceReplacement is the name of the NodeClaim that replaces a NodeClaim on a rebalance recommendation
+	AnnotationRebalanceReplacement = apis.Group + "/rebalance-replacement"
+	// AnnotationRebalanceReplaces is the name of the NodeClaim that a replacement NodeClaim replaces
+	AnnotationRebalanceReplaces = apis.Group + "/rebalance-replaces"
+	// AnnotationRebalanceReplacementAttempts is the number of replacements that have been launched for a NodeClaim on a
+	// rebalance recommendation, and AnnotationRebalanceReplacedAt is the time that the last of them was launched
+	AnnotationRebalanceReplacementAttempts = apis.Group + "/rebalance-replacement-attempts"
+	AnnotationRebalanceReplacedAt          = apis.Group + "/rebalance-replaced-at"
+
+	// AnnotationMaintenanceWindowSchedule and AnnotationMaintenanceWindowDuration are set on a NodePool to define a
+	// recurring maintenance window, in which its NodeClaims are disrupted for scheduled changes. The schedule is a cron
+	// expression in UTC.
+	AnnotationMaintenanceWindowSchedule = apis.Group + "/maintenance-window-schedule"
+	AnnotationMaintenanceWindowDuration = apis.Group + "/maintenance-window-duration"
+	// AnnotationScheduledChangeDisruptionTime is the time at which a NodeClaim is disrupted for a scheduled change
+	AnnotationScheduledChangeDisruptionTime = apis.Group + "/scheduled-change-disruption-time"
+	// AnnotationCapacityBlockExpiring is set on a NodeClaim once the capacity block that it was launched into is expiring,
+	// drifting the NodeClaim so that it's replaced before it's deleted
+	AnnotationCapacityBlockExpiring = apis.Group + "/capacity-block-expiring"
+	// InterruptionRuleTaintKey is the key of the taint that interruption rules with the Taint action apply to nodes. The
+	// value is the name of the rule. The taint is only removed by Karpenter once the rule is removed or no longer has the
+	// Taint action, and is otherwise removed by the user.
+	InterruptionRuleTaintKey = apis.Group + "/interruption-rule"
+
 	NodeClaimTagKey          = coreapis.Group + "/nodeclaim"
 	NameTagKey               = "Name"
 	NodePoolTagKey           = karpv1.NodePoolLabelKey
 	NodeClassTagKey          = LabelNodeClass
 	LaunchTemplateNamePrefix = apis.Group
 	EKSClusterNameTagKey     = "eks:eks-cluster-name"
+	// WarmPoolTagKey marks an instance as a member of the warm pool of the EC2NodeClass in the NodeClassTagKey tag. The
+	// value is the hash of the EC2NodeClass that the instance was launched for.
+	WarmPoolTagKey = apis.Group + "/warm-pool"
+	// WarmPoolTaintKey is the key of the taint that warm instances are launched with, which keeps pods off of their
+	// nodes until the instance is claimed by a NodeClaim
+	WarmPoolTaintKey = apis.Group + "/warm-pool"
 )
+
+var WarmPoolNoScheduleTaint = corev1.Taint{
+	Key:    WarmPoolTaintKey,
+	Effect: corev1.TaintEffectNoSchedule,
+}
@@ -27,17 +27,40 @@ const (
 	// UnavailableOfferingsTTL is the time before offerings that were marked as unavailable
 	// are removed from the cache and are available for launch again
 	UnavailableOfferingsTTL = 3 * time.Minute
+	// UnavailableOfferingsMaxTTL is the longest time that an offering which repeatedly fails to launch is backed off for
+	UnavailableOfferingsMaxTTL = time.Hour
+	// UnavailableOfferingsBackoffResetTTL is the time after an offering becomes available again that a failure to launch
+	// it still counts as a repeated failure, extending the time that it is backed off for
+	UnavailableOfferingsBackoffResetTTL = 15 * time.Minute
 	// InstanceTypesAndZonesTTL is the time before we refresh instance types and zones at EC2
 	InstanceTypesAndZonesTTL = 5 * time.Minute
 	// InstanceProfileTTL is the time before we refresh checking instance profile existence at IAM
 	InstanceProfileTTL = 15 * time.Minute
+	// LaunchPermissionsTTL is the time before we re-check that the controller is authorized to launch instances with
+	// an EC2NodeClass
+	LaunchPermissionsTTL = 15 * time.Minute
 	// AvailableIPAddressTTL is time to drop AvailableIPAddress data if it is not updated within the TTL
 	AvailableIPAddressTTL = 5 * time.Minute
 	// AvailableIPAddressTTL is time to drop AssociatePublicIPAddressTTL data if it is not updated within the TTL
 	AssociatePublicIPAddressTTL = 5 * time.Minute
+	// PrefixFragmentedSubnetTTL is the time before a subnet that failed to allocate a /28 prefix is considered for prefix
+	// delegation again
+	PrefixFragmentedSubnetTTL = 30 * time.Minute
+	// CapacityReservationAvailabilityTTL is the time to drop the available instance count of a capacity reservation
+	// if it is not updated within the TTL
+	CapacityReservationAvailabilityTTL = 5 * time.Minute
+	// SpotPlacementScoreTTL is the time to drop the spot placement scores of a set of instance types if they are not refreshed
+	// within the TTL. Scores are refreshed every 12 hours, so the last known scores outlive a couple of failed refreshes.
+	SpotPlacementScoreTTL = 36 * time.Hour
+	// ServiceQuotasTTL is the time before we refresh the EC2 vCPU quotas of the account from Service Quotas. Quotas only
+	// change when an increase is requested, so we don't need to check them frequently.
+	ServiceQuotasTTL = 30 * time.Minute
 	// SSMGetParametersByPathTTL is the time to drop SSM Parameters by path data. This only queries EKS Optimized AMI
 	// releases, so we should expect this to be updated relatively infrequently.
 	SSMCacheTTL = 24 * time.Hour
+	// PolledInterruptionsTTL is the time that an interruption found by polling the EC2 API is remembered, so that it
+	// isn't acted on again each time that the EC2 API is polled while EC2 still reports it
+	PolledInterruptionsTTL = 24 * time.Hour
 	// DiscoveredCapacityCacheTTL is the time to drop discovered resource capacity data per-instance type
 	// if it is not updated by a node creation event or refreshed during controller reconciliation
 	DiscoveredCapacityCacheTTL = 60 * 24 * time.Hour
@@ -0,0 +1,73 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cache
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/patrickmn/go-cache"
+	"github.com/samber/lo"
+)
+
+// Checkpointable is implemented by caches whose items can be persisted and restored across controller restarts
+type Checkpointable interface {
+	// Checkpoint serializes the unexpired items of the cache
+	Checkpoint() ([]byte, error)
+	// Restore adds the items of a checkpoint to the cache
+	Restore([]byte) error
+	// CheckpointSeqNum is a monotonically increasing change counter of the items of the cache, so that a checkpoint is
+	// only written when the cache has changed since the last checkpoint
+	CheckpointSeqNum() uint64
+}
+
+// checkpointItem is a cached item along with the time that it expires at, in unix nanoseconds. Items that never expire
+// have an expiration of zero.
+type checkpointItem[T any] struct {
+	Object     T     `json:"object"`
+	Expiration int64 `json:"expiration,omitempty"`
+}
+
+// Checkpoint serializes the unexpired items of the cache along with their expiration
+func Checkpoint[T any](c *cache.Cache) ([]byte, error) {
+	items := lo.MapValues(c.Items(), func(item cache.Item, _ string) checkpointItem[T] {
+		return checkpointItem[T]{Object: item.Object.(T), Expiration: item.Expiration}
+	})
+	data, err := json.Marshal(items)
+	if err != nil {
+		return nil, fmt.Errorf("marshaling cache items, %w", err)
+	}
+	return data, nil
+}
+
+// Restore adds the items of a checkpoint to the cache. Items keep the expiration that they were checkpointed with, so
+// items that expired while the controller was down are dropped.
+func Restore[T any](c *cache.Cache, data []byte) error {
+	items := map[string]checkpointItem[T]{}
+	if err := json.Unmarshal(data, &items); err != nil {
+		return fmt.Errorf("unmarshaling cache items, %w", err)
+	}
+	now := time.Now().UnixNano()
+	for key, item := range items {
+		switch {
+		case item.Expiration == 0:
+			c.Set(key, item.Object, cache.NoExpiration)
+		case item.Expiration > now:
+			c.Set(key, item.Object, time.Duration(item.Expiration-now))
+		}
+	}
+	return nil
+}
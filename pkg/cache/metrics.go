@@ -0,0 +1,63 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cache
+
+import (
+	opmetrics "github.com/awslabs/operatorpkg/metrics"
+	"github.com/prometheus/client_golang/prometheus"
+	crmetrics "sigs.k8s.io/controller-runtime/pkg/metrics"
+
+	"sigs.k8s.io/karpenter/pkg/metrics"
+)
+
+const (
+	cloudProviderSubsystem = "cloudprovider"
+	instanceTypeLabel      = "instance_type"
+	capacityTypeLabel      = "capacity_type"
+	zoneLabel              = "zone"
+	placementGroupLabel    = "placement_group_id"
+	reasonLabel            = "reason"
+)
+
+var (
+	UnavailableOfferingsMarkedTotal = opmetrics.NewPrometheusCounter(
+		crmetrics.Registry,
+		prometheus.CounterOpts{
+			Namespace: metrics.Namespace,
+			Subsystem: cloudProviderSubsystem,
+			Name:      "unavailable_offerings_marked_total",
+			Help:      "The number of times that offerings were marked as unavailable, based on the reason and capacity type.",
+		},
+		[]string{
+			reasonLabel,
+			capacityTypeLabel,
+		},
+	)
+	UnavailableOfferingBackoffSeconds = opmetrics.NewPrometheusGauge(
+		crmetrics.Registry,
+		prometheus.GaugeOpts{
+			Namespace: metrics.Namespace,
+			Subsystem: cloudProviderSubsystem,
+			Name:      "unavailable_offering_backoff_seconds",
+			Help:      "The time, in seconds, that an unavailable offering is backed off for after it last failed to launch. Offerings that are unavailable for every instance type and zone of a capacity type have empty instance type and zone labels.",
+		},
+		[]string{
+			instanceTypeLabel,
+			zoneLabel,
+			capacityTypeLabel,
+			placementGroupLabel,
+		},
+	)
+)
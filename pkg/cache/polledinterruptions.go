@@ -0,0 +1,66 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cache
+
+import (
+	"sync/atomic"
+
+	"github.com/patrickmn/go-cache"
+)
+
+// PolledInterruptions stores the interruptions found by polling the EC2 API which have been acted on, since EC2 keeps
+// reporting an interruption after it has been acted on
+type PolledInterruptions struct {
+	cache  *cache.Cache
+	SeqNum uint64
+}
+
+func NewPolledInterruptions() *PolledInterruptions {
+	return &PolledInterruptions{
+		cache: cache.New(PolledInterruptionsTTL, DefaultCleanupInterval),
+	}
+}
+
+// IsHandled returns true if the interruption has been acted on
+func (p *PolledInterruptions) IsHandled(key string) bool {
+	_, ok := p.cache.Get(key)
+	return ok
+}
+
+// MarkHandled records that the interruption has been acted on
+func (p *PolledInterruptions) MarkHandled(key string) {
+	p.cache.SetDefault(key, struct{}{})
+	atomic.AddUint64(&p.SeqNum, 1)
+}
+
+// Checkpoint serializes the interruptions which have been acted on
+func (p *PolledInterruptions) Checkpoint() ([]byte, error) {
+	return Checkpoint[struct{}](p.cache)
+}
+
+// Restore adds the interruptions of a checkpoint, so that interruptions which were acted on before the controller
+// restarted aren't acted on again
+func (p *PolledInterruptions) Restore(data []byte) error {
+	if err := Restore[struct{}](p.cache, data); err != nil {
+		return err
+	}
+	atomic.AddUint64(&p.SeqNum, 1)
+	return nil
+}
+
+// CheckpointSeqNum returns the change counter of the interruptions which have been acted on
+func (p *PolledInterruptions) CheckpointSeqNum() uint64 {
+	return atomic.LoadUint64(&p.SeqNum)
+}
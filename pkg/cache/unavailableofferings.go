@@ -16,71 +16,370 @@ package cache
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
 	"sync/atomic"
+	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
 	"github.com/samber/lo"
+	"k8s.io/apimachinery/pkg/util/sets"
 
 	"github.com/patrickmn/go-cache"
 	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	"github.com/aws/karpenter-provider-aws/pkg/providers/servicequota"
+)
+
+var (
+	// unavailableOfferingsTTLs are the times that offerings are initially marked as unavailable for, based on the reason
+	// that they are unavailable. Reasons that aren't listed use UnavailableOfferingsTTL.
+	unavailableOfferingsTTLs = map[string]time.Duration{
+		"InsufficientInstanceCapacity":      3 * time.Minute,
+		"InsufficientFreeAddressesInSubnet": 5 * time.Minute,
+		"MaxSpotInstanceCountExceeded":      10 * time.Minute,
+		"VcpuLimitExceeded":                 10 * time.Minute,
+		// The kind of interruption message for spot interruption warnings. EC2 is reclaiming capacity from the pool, so
+		// it's unlikely that we can launch into it again soon.
+		"spot_interrupted": 10 * time.Minute,
+	}
+	// capacityTypeScopedReasons are the reasons, such as account quotas, that mean that no instance type can be launched
+	// in any zone with the capacity type, rather than just the offering that failed to launch
+	capacityTypeScopedReasons = sets.New(
+		"MaxSpotInstanceCountExceeded",
+	)
+	// vcpuQuotaScopedReasons are the reasons that mean that no instance type which counts towards the same vCPU quota as
+	// the instance type that failed to launch can be launched in any zone with the capacity type
+	vcpuQuotaScopedReasons = sets.New(
+		"VcpuLimitExceeded",
+	)
 )
 
+// UnavailableOffering is an offering that is unavailable, along with the reason that it is unavailable
+type UnavailableOffering struct {
+	Reason       string `json:"reason"`
+	CapacityType string `json:"capacityType"`
+	// InstanceType and Zone are empty if no instance type can be launched in any zone with the capacity type
+	InstanceType string `json:"instanceType,omitempty"`
+	// VCPUQuota is the instance class, or the instance family if its class isn't tracked, whose vCPU quota has been
+	// exceeded when no instance type which counts towards the quota can be launched in any zone with the capacity type
+	VCPUQuota        string `json:"vcpuQuota,omitempty"`
+	Zone             string `json:"zone,omitempty"`
+	PlacementGroupID string `json:"placementGroupID,omitempty"`
+	// Failures is the number of times in a row that the offering has failed to launch, which determines how long it is
+	// backed off for
+	Failures int `json:"failures"`
+}
+
+func (o UnavailableOffering) labels() map[string]string {
+	return map[string]string{
+		instanceTypeLabel:   o.InstanceType,
+		zoneLabel:           o.Zone,
+		capacityTypeLabel:   o.CapacityType,
+		placementGroupLabel: o.PlacementGroupID,
+	}
+}
+
 // UnavailableOfferings stores any offerings that return ICE (insufficient capacity errors) when
 // attempting to launch the capacity. These offerings are ignored as long as they are in the cache on
-// GetInstanceTypes responses
+// GetInstanceTypes responses. Offerings that repeatedly fail to launch are backed off exponentially,
+// up to UnavailableOfferingsMaxTTL.
 type UnavailableOfferings struct {
-	// key: <capacityType>:<instanceType>:<zone>, value: struct{}{}
-	cache  *cache.Cache
-	SeqNum uint64
+	// key: <capacityType>:<instanceType>:<zone>, value: UnavailableOffering
+	// Offerings that are only unavailable within a placement group are keyed by <placementGroupID>:<capacityType>:<instanceType>:<zone>
+	// Capacity types that are unavailable for every instance type and zone are keyed by <capacityType>
+	// vCPU quotas that are exceeded for a capacity type are keyed by <capacityType>:<vcpuQuota>
+	cache *cache.Cache
+	// failures counts the times in a row that each key has been marked as unavailable. Counts outlive the entries of the
+	// cache by UnavailableOfferingsBackoffResetTTL, so that an offering that fails again soon after it becomes available is
+	// backed off for longer.
+	failures *cache.Cache
+	mu       sync.Mutex
+	SeqNum   uint64
 }
 
 func NewUnavailableOfferings() *UnavailableOfferings {
 	uo := &UnavailableOfferings{
-		cache:  cache.New(UnavailableOfferingsTTL, UnavailableOfferingsCleanupInterval),
-		SeqNum: 0,
+		cache:    cache.New(UnavailableOfferingsTTL, UnavailableOfferingsCleanupInterval),
+		failures: cache.New(UnavailableOfferingsTTL+UnavailableOfferingsBackoffResetTTL, DefaultCleanupInterval),
+		SeqNum:   0,
 	}
-	uo.cache.OnEvicted(func(_ string, _ interface{}) {
+	uo.cache.OnEvicted(func(_ string, value interface{}) {
+		if offering, ok := value.(UnavailableOffering); ok {
+			UnavailableOfferingBackoffSeconds.Delete(offering.labels())
+		}
 		atomic.AddUint64(&uo.SeqNum, 1)
 	})
 	return uo
 }
 
-// IsUnavailable returns true if the offering appears in the cache
+// IsUnavailable returns true if the offering appears in the cache, or if its capacity type or the vCPU quota that it
+// counts towards is unavailable
 func (u *UnavailableOfferings) IsUnavailable(instanceType ec2types.InstanceType, zone, capacityType string) bool {
+	if u.IsCapacityTypeUnavailable(capacityType) {
+		return true
+	}
+	if _, found := u.cache.Get(u.vcpuQuotaKey(vcpuQuota(instanceType), capacityType)); found {
+		return true
+	}
 	_, found := u.cache.Get(u.key(instanceType, zone, capacityType))
 	return found
 }
 
+// IsPlacementGroupUnavailable returns true if the offering appears in the cache for the placement group
+func (u *UnavailableOfferings) IsPlacementGroupUnavailable(placementGroupID string, instanceType ec2types.InstanceType, zone, capacityType string) bool {
+	_, found := u.cache.Get(u.placementGroupKey(placementGroupID, instanceType, zone, capacityType))
+	return found
+}
+
+// IsCapacityTypeUnavailable returns true if no instance type can be launched in any zone with the capacity type
+func (u *UnavailableOfferings) IsCapacityTypeUnavailable(capacityType string) bool {
+	_, found := u.cache.Get(capacityType)
+	return found
+}
+
 // MarkUnavailable communicates recently observed temporary capacity shortages in the provided offerings
 func (u *UnavailableOfferings) MarkUnavailable(ctx context.Context, unavailableReason string, instanceType ec2types.InstanceType, zone, capacityType string) {
-	// even if the key is already in the cache, we still need to call Set to extend the cached entry's TTL
+	ttl := u.mark(u.key(instanceType, zone, capacityType), UnavailableOffering{
+		Reason:       unavailableReason,
+		CapacityType: capacityType,
+		InstanceType: string(instanceType),
+		Zone:         zone,
+	})
 	log.FromContext(ctx).WithValues(
 		"reason", unavailableReason,
 		"instance-type", instanceType,
 		"zone", zone,
 		"capacity-type", capacityType,
-		"ttl", UnavailableOfferingsTTL).V(1).Info("removing offering from offerings")
-	u.cache.SetDefault(u.key(instanceType, zone, capacityType), struct{}{})
-	atomic.AddUint64(&u.SeqNum, 1)
+		"ttl", ttl).V(1).Info("removing offering from offerings")
 }
 
-func (u *UnavailableOfferings) MarkUnavailableForFleetErr(ctx context.Context, fleetErr ec2types.CreateFleetError, capacityType string) {
+// MarkPlacementGroupUnavailable communicates recently observed temporary capacity shortages in the provided offerings
+// within a placement group. Capacity in a placement group is constrained by the placement of the instances that are
+// already in the group, so a shortage in the group doesn't imply a shortage for instances launched outside of it.
+func (u *UnavailableOfferings) MarkPlacementGroupUnavailable(ctx context.Context, unavailableReason, placementGroupID string, instanceType ec2types.InstanceType, zone, capacityType string) {
+	ttl := u.mark(u.placementGroupKey(placementGroupID, instanceType, zone, capacityType), UnavailableOffering{
+		Reason:           unavailableReason,
+		CapacityType:     capacityType,
+		InstanceType:     string(instanceType),
+		Zone:             zone,
+		PlacementGroupID: placementGroupID,
+	})
+	log.FromContext(ctx).WithValues(
+		"reason", unavailableReason,
+		"placement-group-id", placementGroupID,
+		"instance-type", instanceType,
+		"zone", zone,
+		"capacity-type", capacityType,
+		"ttl", ttl).V(1).Info("removing offering from placement group offerings")
+}
+
+// MarkCapacityTypeUnavailable communicates that no instance type can currently be launched in any zone with the
+// capacity type, e.g. because an account quota for the capacity type has been reached
+func (u *UnavailableOfferings) MarkCapacityTypeUnavailable(ctx context.Context, unavailableReason, capacityType string) {
+	ttl := u.mark(capacityType, UnavailableOffering{
+		Reason:       unavailableReason,
+		CapacityType: capacityType,
+	})
+	log.FromContext(ctx).WithValues(
+		"reason", unavailableReason,
+		"capacity-type", capacityType,
+		"ttl", ttl).V(1).Info("removing capacity type from offerings")
+}
+
+// MarkVCPUQuotaUnavailable communicates that no instance type which counts towards the same vCPU quota as the instance
+// type can currently be launched in any zone with the capacity type, because the quota has been exceeded
+func (u *UnavailableOfferings) MarkVCPUQuotaUnavailable(ctx context.Context, unavailableReason string, instanceType ec2types.InstanceType, capacityType string) {
+	quota := vcpuQuota(instanceType)
+	ttl := u.mark(u.vcpuQuotaKey(quota, capacityType), UnavailableOffering{
+		Reason:       unavailableReason,
+		CapacityType: capacityType,
+		VCPUQuota:    quota,
+	})
+	log.FromContext(ctx).WithValues(
+		"reason", unavailableReason,
+		"vcpu-quota", quota,
+		"capacity-type", capacityType,
+		"ttl", ttl).V(1).Info("removing vcpu quota from offerings")
+}
+
+// MarkUnavailableForFleetErr marks the offering of the fleet error as unavailable. If the instance was launched into a
+// placement group, the offering is only marked as unavailable within that placement group. Errors that apply to the
+// whole account mark the capacity type as unavailable instead, and exceeded vCPU quotas mark every instance type that
+// counts towards the quota as unavailable.
+func (u *UnavailableOfferings) MarkUnavailableForFleetErr(ctx context.Context, fleetErr ec2types.CreateFleetError, capacityType, placementGroupID string) {
+	reason := lo.FromPtr(fleetErr.ErrorCode)
+	if capacityTypeScopedReasons.Has(reason) {
+		u.MarkCapacityTypeUnavailable(ctx, reason, capacityType)
+		return
+	}
 	instanceType := fleetErr.LaunchTemplateAndOverrides.Overrides.InstanceType
+	if vcpuQuotaScopedReasons.Has(reason) {
+		u.MarkVCPUQuotaUnavailable(ctx, reason, instanceType, capacityType)
+		return
+	}
 	zone := aws.ToString(fleetErr.LaunchTemplateAndOverrides.Overrides.AvailabilityZone)
-	u.MarkUnavailable(ctx, lo.FromPtr(fleetErr.ErrorCode), instanceType, zone, capacityType)
+	if placementGroupID != "" {
+		u.MarkPlacementGroupUnavailable(ctx, reason, placementGroupID, instanceType, zone, capacityType)
+		return
+	}
+	u.MarkUnavailable(ctx, reason, instanceType, zone, capacityType)
 }
 
+// mark adds the offering to the cache, backing it off exponentially if it has failed to launch repeatedly, and returns
+// the time that it is unavailable for
+func (u *UnavailableOfferings) mark(key string, offering UnavailableOffering) time.Duration {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	failures := 0
+	if count, ok := u.failures.Get(key); ok {
+		failures = count.(int)
+	}
+	// Launches that race for the same capacity can mark an offering again while it is still unavailable. The offering
+	// hasn't been retried since it was marked, so this doesn't count as another failure.
+	if _, unavailable := u.cache.Get(key); !unavailable || failures == 0 {
+		failures++
+	}
+	offering.Failures = failures
+	ttl := backoffTTL(offering.Reason, failures)
+	// even if the key is already in the cache, we still need to call Set to extend the cached entry's TTL
+	u.failures.Set(key, failures, ttl+UnavailableOfferingsBackoffResetTTL)
+	u.cache.Set(key, offering, ttl)
+	atomic.AddUint64(&u.SeqNum, 1)
+
+	UnavailableOfferingsMarkedTotal.Inc(map[string]string{
+		reasonLabel:       offering.Reason,
+		capacityTypeLabel: offering.CapacityType,
+	})
+	UnavailableOfferingBackoffSeconds.Set(ttl.Seconds(), offering.labels())
+	return ttl
+}
+
+// backoffTTL returns the time that an offering is unavailable for, given the reason that it failed to launch and the
+// number of times in a row that it has failed to launch
+func backoffTTL(reason string, failures int) time.Duration {
+	ttl := lo.ValueOr(unavailableOfferingsTTLs, reason, UnavailableOfferingsTTL)
+	for i := 1; i < failures && ttl < UnavailableOfferingsMaxTTL; i++ {
+		ttl *= 2
+	}
+	return min(ttl, UnavailableOfferingsMaxTTL)
+}
+
+// Delete makes the offering available again. Its failures still count towards its backoff if it fails to launch again.
 func (u *UnavailableOfferings) Delete(instanceType ec2types.InstanceType, zone string, capacityType string) {
 	u.cache.Delete(u.key(instanceType, zone, capacityType))
 }
 
 func (u *UnavailableOfferings) Flush() {
 	u.cache.Flush()
+	u.failures.Flush()
+	UnavailableOfferingBackoffSeconds.Reset()
+}
+
+// unavailableOfferingsCheckpoint is the checkpoint of the unavailable offerings along with the failure counts that
+// they're backed off by
+type unavailableOfferingsCheckpoint struct {
+	Offerings json.RawMessage `json:"offerings"`
+	Failures  json.RawMessage `json:"failures"`
+}
+
+// Checkpoint serializes the offerings that are currently unavailable and the failure counts that they're backed off by
+func (u *UnavailableOfferings) Checkpoint() ([]byte, error) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	offerings, err := Checkpoint[UnavailableOffering](u.cache)
+	if err != nil {
+		return nil, err
+	}
+	failures, err := Checkpoint[int](u.failures)
+	if err != nil {
+		return nil, err
+	}
+	data, err := json.Marshal(unavailableOfferingsCheckpoint{Offerings: offerings, Failures: failures})
+	if err != nil {
+		return nil, fmt.Errorf("marshaling unavailable offerings, %w", err)
+	}
+	return data, nil
+}
+
+// CheckpointSeqNum returns the change counter of the unavailable offerings
+func (u *UnavailableOfferings) CheckpointSeqNum() uint64 {
+	return atomic.LoadUint64(&u.SeqNum)
+}
+
+// Restore marks the offerings of a checkpoint as unavailable until their checkpointed TTLs expire, and restores the
+// failure counts that they're backed off by
+func (u *UnavailableOfferings) Restore(data []byte) error {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	checkpoint := unavailableOfferingsCheckpoint{}
+	if err := json.Unmarshal(data, &checkpoint); err != nil {
+		return fmt.Errorf("unmarshaling unavailable offerings, %w", err)
+	}
+	if len(checkpoint.Offerings) != 0 {
+		if err := Restore[UnavailableOffering](u.cache, checkpoint.Offerings); err != nil {
+			return err
+		}
+	}
+	if len(checkpoint.Failures) != 0 {
+		if err := Restore[int](u.failures, checkpoint.Failures); err != nil {
+			return err
+		}
+	}
+	atomic.AddUint64(&u.SeqNum, 1)
+	return nil
+}
+
+// unavailableOfferingStatus is an unavailable offering along with the time that it becomes available again
+type unavailableOfferingStatus struct {
+	UnavailableOffering
+	Expiration time.Time `json:"expiration"`
+}
+
+// ServeHTTP writes the offerings that are currently unavailable as JSON, to help debug why offerings aren't launched
+func (u *UnavailableOfferings) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	items := u.cache.Items()
+	keys := lo.Keys(items)
+	sort.Strings(keys)
+	statuses := lo.Map(keys, func(key string, _ int) unavailableOfferingStatus {
+		offering, _ := items[key].Object.(UnavailableOffering)
+		return unavailableOfferingStatus{UnavailableOffering: offering, Expiration: time.Unix(0, items[key].Expiration).UTC()}
+	})
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(statuses); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
 }
 
 // key returns the cache key for all offerings in the cache
 func (u *UnavailableOfferings) key(instanceType ec2types.InstanceType, zone string, capacityType string) string {
 	return fmt.Sprintf("%s:%s:%s", capacityType, instanceType, zone)
 }
+
+// vcpuQuotaKey returns the cache key for vCPU quotas which are exceeded for a capacity type
+func (u *UnavailableOfferings) vcpuQuotaKey(quota string, capacityType string) string {
+	return fmt.Sprintf("%s:%s", capacityType, quota)
+}
+
+// vcpuQuota returns the vCPU quota that the instance type counts towards, which is the quota of its instance class. The
+// instance family is returned for families that have their own quotas, which aren't tracked as an instance class.
+func vcpuQuota(instanceType ec2types.InstanceType) string {
+	if class := servicequota.InstanceClass(string(instanceType)); class != "" {
+		return class
+	}
+	family, _, _ := strings.Cut(string(instanceType), ".")
+	return family
+}
+
+// placementGroupKey returns the cache key for offerings which are only unavailable within a placement group
+func (u *UnavailableOfferings) placementGroupKey(placementGroupID string, instanceType ec2types.InstanceType, zone string, capacityType string) string {
+	return fmt.Sprintf("%s:%s", placementGroupID, u.key(instanceType, zone, capacityType))
+}
@@ -107,9 +107,15 @@ func (c *CloudProvider) Create(ctx context.Context, nodeClaim *karpv1.NodeClaim)
 	if err != nil {
 		return nil, cloudprovider.NewNodeClassNotReadyError(err)
 	}
-	instance, err := c.instanceProvider.Create(ctx, nodeClass, nodeClaim, tags, instanceTypes)
+	instance, err := c.instanceProvider.CreateFromWarmPool(ctx, nodeClass, nodeClaim, tags, instanceTypes)
 	if err != nil {
-		return nil, fmt.Errorf("creating instance, %w", err)
+		// Failing to use the warm pool shouldn't fail the launch since we can always launch a new instance
+		log.FromContext(ctx).Error(err, "failed launching instance from warm pool")
+	}
+	if instance == nil {
+		if instance, err = c.instanceProvider.Create(ctx, nodeClass, nodeClaim, tags, instanceTypes); err != nil {
+			return nil, fmt.Errorf("creating instance, %w", err)
+		}
 	}
 	instanceType, _ := lo.Find(instanceTypes, func(i *cloudprovider.InstanceType) bool {
 		return i.Name == string(instance.Type)
@@ -414,11 +420,25 @@ func (c *CloudProvider) instanceToNodeClaim(i *instance.Instance, instanceType *
 	if nodeClass != nil {
 		if subnet, ok := lo.Find(nodeClass.Status.Subnets, func(s v1.Subnet) bool {
 			return s.Zone == i.Zone
-		}); ok && subnet.ZoneID != "" {
-			labels[v1.LabelTopologyZoneID] = subnet.ZoneID
+		}); ok {
+			if subnet.ZoneID != "" {
+				labels[v1.LabelTopologyZoneID] = subnet.ZoneID
+			}
+			if subnet.ZoneType != "" {
+				labels[v1.LabelTopologyZoneType] = subnet.ZoneType
+			}
+			if subnet.ParentZone != "" {
+				labels[v1.LabelTopologyParentZone] = subnet.ParentZone
+			}
 		}
 	}
 	labels[karpv1.CapacityTypeLabelKey] = i.CapacityType
+	if i.CapacityReservationID != "" {
+		labels[v1.LabelCapacityReservationID] = i.CapacityReservationID
+	}
+	if i.PartitionNumber != 0 {
+		labels[v1.LabelPlacementGroupPartition] = fmt.Sprint(i.PartitionNumber)
+	}
 	if v, ok := i.Tags[karpv1.NodePoolLabelKey]; ok {
 		labels[karpv1.NodePoolLabelKey] = v
 	}
@@ -32,13 +32,26 @@ import (
 )
 
 const (
-	AMIDrift           cloudprovider.DriftReason = "AMIDrift"
-	SubnetDrift        cloudprovider.DriftReason = "SubnetDrift"
-	SecurityGroupDrift cloudprovider.DriftReason = "SecurityGroupDrift"
-	NodeClassDrift     cloudprovider.DriftReason = "NodeClassDrift"
+	AMIDrift            cloudprovider.DriftReason = "AMIDrift"
+	SubnetDrift         cloudprovider.DriftReason = "SubnetDrift"
+	SecurityGroupDrift  cloudprovider.DriftReason = "SecurityGroupDrift"
+	PlacementGroupDrift cloudprovider.DriftReason = "PlacementGroupDrift"
+	// NetworkInterfaceDrift is reported for NodeClaims whose network interfaces aren't in the subnets or security
+	// groups that the network interfaces of the EC2NodeClass resolve to
+	NetworkInterfaceDrift cloudprovider.DriftReason = "NetworkInterfaceDrift"
+	// HostDrift is reported for NodeClaims whose instances aren't on a Dedicated Host or in a host resource group that
+	// the EC2NodeClass places instances onto
+	HostDrift      cloudprovider.DriftReason = "HostDrift"
+	NodeClassDrift cloudprovider.DriftReason = "NodeClassDrift"
+	// CapacityBlockDrift is reported for NodeClaims whose capacity block is expiring, so that they're replaced before
+	// the instances in the block are terminated
+	CapacityBlockDrift cloudprovider.DriftReason = "CapacityBlockDrift"
 )
 
 func (c *CloudProvider) isNodeClassDrifted(ctx context.Context, nodeClaim *karpv1.NodeClaim, nodePool *karpv1.NodePool, nodeClass *v1.EC2NodeClass) (cloudprovider.DriftReason, error) {
+	if _, ok := nodeClaim.Annotations[v1.AnnotationCapacityBlockExpiring]; ok {
+		return CapacityBlockDrift, nil
+	}
 	// First check if the node class is statically drifted to save on API calls.
 	if drifted := c.areStaticFieldsDrifted(nodeClaim, nodeClass); drifted != "" {
 		return drifted, nil
@@ -59,7 +72,13 @@ func (c *CloudProvider) isNodeClassDrifted(ctx context.Context, nodeClaim *karpv
 	if err != nil {
 		return "", fmt.Errorf("calculating subnet drift, %w", err)
 	}
-	drifted := lo.FindOrElse([]cloudprovider.DriftReason{amiDrifted, securitygroupDrifted, subnetDrifted}, "", func(i cloudprovider.DriftReason) bool {
+	placementGroupDrifted, err := c.isPlacementGroupDrifted(instance, nodeClass)
+	if err != nil {
+		return "", fmt.Errorf("calculating placement group drift, %w", err)
+	}
+	networkInterfacesDrifted := c.areNetworkInterfacesDrifted(instance, nodeClass)
+	hostDrifted := c.isHostDrifted(instance, nodeClass)
+	drifted := lo.FindOrElse([]cloudprovider.DriftReason{amiDrifted, securitygroupDrifted, subnetDrifted, placementGroupDrifted, networkInterfacesDrifted, hostDrifted}, "", func(i cloudprovider.DriftReason) bool {
 		return string(i) != ""
 	})
 	return drifted, nil
@@ -119,6 +138,68 @@ func (c *CloudProvider) areSecurityGroupsDrifted(ec2Instance *instance.Instance,
 	return "", nil
 }
 
+// Checks if the placement group is drifted, by comparing the placement group in the EC2NodeClass status to the ec2
+// instance placement group. The placement group isn't part of the static hash since placement groups selected by
+// strategy are resolved to the placement group that Karpenter creates for the EC2NodeClass.
+func (c *CloudProvider) isPlacementGroupDrifted(instance *instance.Instance, nodeClass *v1.EC2NodeClass) (cloudprovider.DriftReason, error) {
+	if nodeClass.Spec.PlacementGroup == nil {
+		return lo.Ternary(instance.PlacementGroupID != "", PlacementGroupDrift, ""), nil
+	}
+	// The placement group hasn't been resolved yet, so it's not known whether the instance is drifted
+	if nodeClass.Status.PlacementGroup == nil {
+		return "", nil
+	}
+	if nodeClass.Status.PlacementGroup.ID != instance.PlacementGroupID {
+		return PlacementGroupDrift, nil
+	}
+	return "", nil
+}
+
+// Checks if the network interfaces are drifted, by comparing the subnets and security groups that the network
+// interfaces of the EC2NodeClass resolve to with those of the ec2 instance network interfaces. Network interfaces
+// without subnet or security group selector terms use those of the instance, which are checked for drift separately.
+func (c *CloudProvider) areNetworkInterfacesDrifted(ec2Instance *instance.Instance, nodeClass *v1.EC2NodeClass) cloudprovider.DriftReason {
+	for _, resolved := range nodeClass.Status.NetworkInterfaces {
+		ni, ok := lo.Find(ec2Instance.NetworkInterfaces, func(ni instance.NetworkInterface) bool {
+			return ni.NetworkCardIndex == resolved.NetworkCardIndex && ni.DeviceIndex == resolved.DeviceIndex
+		})
+		if !ok {
+			continue
+		}
+		if len(resolved.Subnets) != 0 && !lo.ContainsBy(resolved.Subnets, func(s v1.Subnet) bool { return s.ID == ni.SubnetID }) {
+			return NetworkInterfaceDrift
+		}
+		if len(resolved.SecurityGroups) != 0 && !sets.New(lo.Map(resolved.SecurityGroups, func(sg v1.SecurityGroup, _ int) string { return sg.ID })...).Equal(sets.New(ni.SecurityGroupIDs...)) {
+			return NetworkInterfaceDrift
+		}
+	}
+	return ""
+}
+
+// Checks if the host is drifted, by comparing the host resource group of the EC2NodeClass to that of the ec2 instance,
+// or the Dedicated Hosts in the EC2NodeClass status to the host of the ec2 instance. Neither is part of the static hash
+// since hosts are selected by selector terms, and the host resource group takes precedence over them.
+func (c *CloudProvider) isHostDrifted(ec2Instance *instance.Instance, nodeClass *v1.EC2NodeClass) cloudprovider.DriftReason {
+	// Instances without "host" tenancy aren't on a Dedicated Host, and tenancy changes are part of the static hash
+	if nodeClass.Tenancy() != v1.TenancyHost {
+		return ""
+	}
+	if lo.FromPtr(nodeClass.Spec.HostResourceGroupARN) != ec2Instance.HostResourceGroupARN {
+		return HostDrift
+	}
+	if nodeClass.Spec.HostResourceGroupARN != nil || len(nodeClass.Spec.HostSelectorTerms) == 0 {
+		return ""
+	}
+	// The hosts haven't been resolved yet, so it's not known whether the instance is drifted
+	if len(nodeClass.Status.Hosts) == 0 {
+		return ""
+	}
+	if !lo.ContainsBy(nodeClass.Status.Hosts, func(h v1.Host) bool { return h.ID == ec2Instance.HostID }) {
+		return HostDrift
+	}
+	return ""
+}
+
 func (c *CloudProvider) areStaticFieldsDrifted(nodeClaim *karpv1.NodeClaim, nodeClass *v1.EC2NodeClass) cloudprovider.DriftReason {
 	nodeClassHash, foundNodeClassHash := nodeClass.Annotations[v1.AnnotationEC2NodeClassHash]
 	nodeClassHashVersion, foundNodeClassHashVersion := nodeClass.Annotations[v1.AnnotationEC2NodeClassHashVersion]
@@ -46,6 +46,7 @@ import (
 	"github.com/aws/karpenter-provider-aws/pkg/controllers/nodeclass"
 	"github.com/aws/karpenter-provider-aws/pkg/fake"
 	"github.com/aws/karpenter-provider-aws/pkg/operator/options"
+	"github.com/aws/karpenter-provider-aws/pkg/providers/pricing"
 	"github.com/aws/karpenter-provider-aws/pkg/test"
 
 	karpv1 "sigs.k8s.io/karpenter/pkg/apis/v1"
@@ -259,6 +260,24 @@ var _ = Describe("CloudProvider", func() {
 		Expect(ok).To(BeTrue())
 		Expect(zoneID).To(Equal(subnet.ZoneID))
 	})
+	It("should return the zone type and parent zone as labels on the nodeClaim", func() {
+		nodeClass.Status.Subnets = []v1.Subnet{
+			{
+				ID:         "subnet-test4",
+				Zone:       "test-zone-1a-local",
+				ZoneID:     "tstz1-1alocal",
+				ZoneType:   v1.ZoneTypeLocalZone,
+				ParentZone: "test-zone-1a",
+			},
+		}
+		ExpectApplied(ctx, env.Client, nodePool, nodeClass, nodeClaim)
+		cloudProviderNodeClaim, err := cloudProvider.Create(ctx, nodeClaim)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(cloudProviderNodeClaim).ToNot(BeNil())
+		Expect(cloudProviderNodeClaim.GetLabels()).To(HaveKeyWithValue(corev1.LabelTopologyZone, "test-zone-1a-local"))
+		Expect(cloudProviderNodeClaim.GetLabels()).To(HaveKeyWithValue(v1.LabelTopologyZoneType, v1.ZoneTypeLocalZone))
+		Expect(cloudProviderNodeClaim.GetLabels()).To(HaveKeyWithValue(v1.LabelTopologyParentZone, "test-zone-1a"))
+	})
 	It("should expect a strict set of annotation keys", func() {
 		ExpectApplied(ctx, env.Client, nodePool, nodeClass, nodeClaim)
 		cloudProviderNodeClaim, err := cloudProvider.Create(ctx, nodeClaim)
@@ -306,6 +325,231 @@ var _ = Describe("CloudProvider", func() {
 			Expect(createFleetInput.Context).To(BeNil())
 		})
 	})
+	Context("Launch Strategy", func() {
+		It("should default to the price-capacity-optimized spot allocation strategy", func() {
+			nodeClaim.Spec.Requirements[0].Values = []string{karpv1.CapacityTypeSpot}
+			ExpectApplied(ctx, env.Client, nodePool, nodeClass, nodeClaim)
+			_, err := cloudProvider.Create(ctx, nodeClaim)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(awsEnv.EC2API.CreateFleetBehavior.CalledWithInput.Len()).To(Equal(1))
+			createFleetInput := awsEnv.EC2API.CreateFleetBehavior.CalledWithInput.Pop()
+			Expect(createFleetInput.SpotOptions.AllocationStrategy).To(Equal(ec2types.SpotAllocationStrategyPriceCapacityOptimized))
+		})
+		It("should default to the lowest-price on-demand allocation strategy", func() {
+			ExpectApplied(ctx, env.Client, nodePool, nodeClass, nodeClaim)
+			_, err := cloudProvider.Create(ctx, nodeClaim)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(awsEnv.EC2API.CreateFleetBehavior.CalledWithInput.Len()).To(Equal(1))
+			createFleetInput := awsEnv.EC2API.CreateFleetBehavior.CalledWithInput.Pop()
+			Expect(createFleetInput.OnDemandOptions.AllocationStrategy).To(Equal(ec2types.FleetOnDemandAllocationStrategyLowestPrice))
+		})
+		It("should use the configured spot allocation strategy", func() {
+			nodeClass.Spec.LaunchStrategy = &v1.LaunchStrategy{SpotAllocationStrategy: lo.ToPtr(v1.SpotAllocationStrategyCapacityOptimized)}
+			nodeClaim.Spec.Requirements[0].Values = []string{karpv1.CapacityTypeSpot}
+			ExpectApplied(ctx, env.Client, nodePool, nodeClass, nodeClaim)
+			_, err := cloudProvider.Create(ctx, nodeClaim)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(awsEnv.EC2API.CreateFleetBehavior.CalledWithInput.Len()).To(Equal(1))
+			createFleetInput := awsEnv.EC2API.CreateFleetBehavior.CalledWithInput.Pop()
+			Expect(createFleetInput.SpotOptions.AllocationStrategy).To(Equal(ec2types.SpotAllocationStrategyCapacityOptimized))
+		})
+		It("should set instance type priorities on the overrides with the prioritized on-demand allocation strategy", func() {
+			nodeClass.Spec.LaunchStrategy = &v1.LaunchStrategy{
+				OnDemandAllocationStrategy: lo.ToPtr(v1.OnDemandAllocationStrategyPrioritized),
+				InstanceTypePriorities: []v1.InstanceTypePriority{
+					{InstanceType: "m5.large", Priority: 0},
+					{InstanceType: "m5.xlarge", Priority: 1},
+				},
+			}
+			ExpectApplied(ctx, env.Client, nodePool, nodeClass, nodeClaim)
+			_, err := cloudProvider.Create(ctx, nodeClaim)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(awsEnv.EC2API.CreateFleetBehavior.CalledWithInput.Len()).To(Equal(1))
+			createFleetInput := awsEnv.EC2API.CreateFleetBehavior.CalledWithInput.Pop()
+			Expect(createFleetInput.OnDemandOptions.AllocationStrategy).To(Equal(ec2types.FleetOnDemandAllocationStrategyPrioritized))
+			overrides := lo.FlatMap(createFleetInput.LaunchTemplateConfigs, func(ltc ec2types.FleetLaunchTemplateConfigRequest, _ int) []ec2types.FleetLaunchTemplateOverridesRequest {
+				return ltc.Overrides
+			})
+			Expect(overrides).ToNot(BeEmpty())
+			for _, override := range overrides {
+				switch override.InstanceType {
+				case "m5.large":
+					Expect(aws.ToFloat64(override.Priority)).To(BeNumerically("==", 0))
+				case "m5.xlarge":
+					Expect(aws.ToFloat64(override.Priority)).To(BeNumerically("==", 1))
+				default:
+					Expect(override.Priority).To(BeNil())
+				}
+			}
+		})
+		It("should not set instance type priorities when the allocation strategy for the capacity type isn't prioritized", func() {
+			nodeClass.Spec.LaunchStrategy = &v1.LaunchStrategy{
+				SpotAllocationStrategy: lo.ToPtr(v1.SpotAllocationStrategyCapacityOptimizedPrioritized),
+				InstanceTypePriorities: []v1.InstanceTypePriority{{InstanceType: "m5.large", Priority: 0}},
+			}
+			ExpectApplied(ctx, env.Client, nodePool, nodeClass, nodeClaim)
+			_, err := cloudProvider.Create(ctx, nodeClaim)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(awsEnv.EC2API.CreateFleetBehavior.CalledWithInput.Len()).To(Equal(1))
+			createFleetInput := awsEnv.EC2API.CreateFleetBehavior.CalledWithInput.Pop()
+			Expect(createFleetInput.OnDemandOptions.AllocationStrategy).To(Equal(ec2types.FleetOnDemandAllocationStrategyLowestPrice))
+			for _, ltc := range createFleetInput.LaunchTemplateConfigs {
+				for _, override := range ltc.Overrides {
+					Expect(override.Priority).To(BeNil())
+				}
+			}
+		})
+		Context("Unwanted Spot", func() {
+			BeforeEach(func() {
+				// Price every spot offering above its on-demand price so that only the cheapest on-demand instance type
+				// passes the price filter of mixed capacity type launches
+				ExpectApplied(ctx, env.Client, nodePool, nodeClass)
+				instanceTypes, err := cloudProvider.GetInstanceTypes(ctx, nodePool)
+				Expect(err).ToNot(HaveOccurred())
+				spotPriceHistory := &ec2.DescribeSpotPriceHistoryOutput{}
+				for _, it := range instanceTypes {
+					odPrice, ok := awsEnv.PricingProvider.OnDemandPrice(pricing.OperatingSystemLinux, ec2types.InstanceType(it.Name))
+					if !ok {
+						continue
+					}
+					for _, o := range it.Offerings {
+						if o.Requirements.Get(karpv1.CapacityTypeLabelKey).Any() != karpv1.CapacityTypeSpot {
+							continue
+						}
+						spotPriceHistory.SpotPriceHistory = append(spotPriceHistory.SpotPriceHistory, ec2types.SpotPrice{
+							AvailabilityZone: lo.ToPtr(o.Requirements.Get(corev1.LabelTopologyZone).Any()),
+							InstanceType:     ec2types.InstanceType(it.Name),
+							SpotPrice:        lo.ToPtr(fmt.Sprintf("%0.3f", odPrice*2)),
+							Timestamp:        lo.ToPtr(time.Now()),
+						})
+					}
+				}
+				awsEnv.EC2API.DescribeSpotPriceHistoryOutput.Set(spotPriceHistory)
+				Expect(awsEnv.PricingProvider.UpdateSpotPricing(ctx)).To(Succeed())
+				awsEnv.InstanceTypesProvider.Reset()
+				Expect(awsEnv.InstanceTypesProvider.UpdateInstanceTypes(ctx)).To(Succeed())
+				Expect(awsEnv.InstanceTypesProvider.UpdateInstanceTypeOfferings(ctx)).To(Succeed())
+				nodeClaim.Spec.Requirements[0].Values = []string{karpv1.CapacityTypeSpot, karpv1.CapacityTypeOnDemand}
+			})
+			It("should filter out spot instance types that are more expensive than the cheapest on-demand instance type", func() {
+				ExpectApplied(ctx, env.Client, nodePool, nodeClass, nodeClaim)
+				_, err := cloudProvider.Create(ctx, nodeClaim)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(awsEnv.EC2API.CreateFleetBehavior.CalledWithInput.Len()).To(Equal(1))
+				createFleetInput := awsEnv.EC2API.CreateFleetBehavior.CalledWithInput.Pop()
+				instanceTypes := sets.New[ec2types.InstanceType]()
+				for _, ltc := range createFleetInput.LaunchTemplateConfigs {
+					instanceTypes.Insert(lo.Map(ltc.Overrides, func(o ec2types.FleetLaunchTemplateOverridesRequest, _ int) ec2types.InstanceType {
+						return o.InstanceType
+					})...)
+				}
+				Expect(instanceTypes).To(HaveLen(1))
+			})
+			It("should not filter out spot instance types with the capacity-optimized spot allocation strategy", func() {
+				nodeClass.Spec.LaunchStrategy = &v1.LaunchStrategy{SpotAllocationStrategy: lo.ToPtr(v1.SpotAllocationStrategyCapacityOptimized)}
+				ExpectApplied(ctx, env.Client, nodePool, nodeClass, nodeClaim)
+				_, err := cloudProvider.Create(ctx, nodeClaim)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(awsEnv.EC2API.CreateFleetBehavior.CalledWithInput.Len()).To(Equal(1))
+				createFleetInput := awsEnv.EC2API.CreateFleetBehavior.CalledWithInput.Pop()
+				Expect(createFleetInput.SpotOptions.AllocationStrategy).To(Equal(ec2types.SpotAllocationStrategyCapacityOptimized))
+				instanceTypes := sets.New[ec2types.InstanceType]()
+				for _, ltc := range createFleetInput.LaunchTemplateConfigs {
+					instanceTypes.Insert(lo.Map(ltc.Overrides, func(o ec2types.FleetLaunchTemplateOverridesRequest, _ int) ec2types.InstanceType {
+						return o.InstanceType
+					})...)
+				}
+				Expect(len(instanceTypes)).To(BeNumerically(">", 1))
+			})
+		})
+	})
+	Context("Warm Pool", func() {
+		var warmInstance ec2types.Instance
+		BeforeEach(func() {
+			nodeClass.Spec.WarmPool = &v1.WarmPool{NodePool: nodePool.Name, Size: 1, InstanceTypes: []string{"m5.large"}}
+			nodeClaim.Spec.Requirements = append(nodeClaim.Spec.Requirements, karpv1.NodeSelectorRequirementWithMinValues{
+				NodeSelectorRequirement: corev1.NodeSelectorRequirement{Key: corev1.LabelInstanceTypeStable, Operator: corev1.NodeSelectorOpIn, Values: []string{"m5.large", "m5.xlarge"}},
+			})
+			ExpectApplied(ctx, env.Client, nodePool, nodeClass)
+			warmInstance = ec2types.Instance{
+				InstanceId:   aws.String(fake.InstanceID()),
+				InstanceType: "m5.large",
+				ImageId:      aws.String(nodeClass.Status.AMIs[0].ID),
+				State:        &ec2types.InstanceState{Name: ec2types.InstanceStateNameStopped},
+				Placement:    &ec2types.Placement{AvailabilityZone: aws.String("test-zone-1a")},
+				Tags: []ec2types.Tag{
+					{Key: aws.String(v1.EKSClusterNameTagKey), Value: aws.String(options.FromContext(ctx).ClusterName)},
+					{Key: aws.String(v1.NodeClassTagKey), Value: aws.String(nodeClass.Name)},
+					{Key: aws.String(v1.WarmPoolTagKey), Value: aws.String(nodeClass.WarmPoolHash(nodeClaim))},
+				},
+			}
+		})
+		It("should start a compatible warm instance instead of launching a new instance", func() {
+			awsEnv.EC2API.Instances.Store(aws.ToString(warmInstance.InstanceId), warmInstance)
+			ExpectApplied(ctx, env.Client, nodeClaim)
+			cloudProviderNodeClaim, err := cloudProvider.Create(ctx, nodeClaim)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(cloudProviderNodeClaim.Status.ProviderID).To(Equal(fmt.Sprintf("aws:///test-zone-1a/%s", aws.ToString(warmInstance.InstanceId))))
+			Expect(cloudProviderNodeClaim.Labels).To(HaveKeyWithValue(karpv1.CapacityTypeLabelKey, karpv1.CapacityTypeOnDemand))
+			Expect(awsEnv.EC2API.CreateFleetBehavior.CalledWithInput.Len()).To(Equal(0))
+			Expect(awsEnv.EC2API.StartInstancesBehavior.CalledWithInput.Len()).To(Equal(1))
+
+			instance, err := awsEnv.InstanceProvider.Get(ctx, aws.ToString(warmInstance.InstanceId))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(instance.State).To(Equal(ec2types.InstanceStateNamePending))
+			Expect(instance.Tags).To(HaveKeyWithValue(karpv1.NodePoolLabelKey, nodePool.Name))
+			Expect(instance.Tags).ToNot(HaveKey(v1.WarmPoolTagKey))
+		})
+		It("should launch a new instance when no warm instance is compatible", func() {
+			warmInstance.Placement.AvailabilityZone = aws.String("test-zone-1b")
+			awsEnv.EC2API.Instances.Store(aws.ToString(warmInstance.InstanceId), warmInstance)
+			nodeClaim.Spec.Requirements = append(nodeClaim.Spec.Requirements, karpv1.NodeSelectorRequirementWithMinValues{
+				NodeSelectorRequirement: corev1.NodeSelectorRequirement{Key: corev1.LabelTopologyZone, Operator: corev1.NodeSelectorOpIn, Values: []string{"test-zone-1a"}},
+			})
+			ExpectApplied(ctx, env.Client, nodeClaim)
+			_, err := cloudProvider.Create(ctx, nodeClaim)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(awsEnv.EC2API.StartInstancesBehavior.CalledWithInput.Len()).To(Equal(0))
+			Expect(awsEnv.EC2API.CreateFleetBehavior.CalledWithInput.Len()).To(Equal(1))
+		})
+		It("should not use warm instances launched with an outdated EC2NodeClass", func() {
+			warmInstance.Tags[2].Value = aws.String("stale-hash")
+			awsEnv.EC2API.Instances.Store(aws.ToString(warmInstance.InstanceId), warmInstance)
+			ExpectApplied(ctx, env.Client, nodeClaim)
+			_, err := cloudProvider.Create(ctx, nodeClaim)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(awsEnv.EC2API.StartInstancesBehavior.CalledWithInput.Len()).To(Equal(0))
+			Expect(awsEnv.EC2API.CreateFleetBehavior.CalledWithInput.Len()).To(Equal(1))
+		})
+		It("should not use warm instances bootstrapped with different labels or taints", func() {
+			nodeClaim.Spec.Taints = []corev1.Taint{{Key: "team", Value: "warm", Effect: corev1.TaintEffectNoSchedule}}
+			awsEnv.EC2API.Instances.Store(aws.ToString(warmInstance.InstanceId), warmInstance)
+			ExpectApplied(ctx, env.Client, nodeClaim)
+			_, err := cloudProvider.Create(ctx, nodeClaim)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(awsEnv.EC2API.StartInstancesBehavior.CalledWithInput.Len()).To(Equal(0))
+			Expect(awsEnv.EC2API.CreateFleetBehavior.CalledWithInput.Len()).To(Equal(1))
+		})
+		It("should not use warm instances for spot NodeClaims", func() {
+			awsEnv.EC2API.Instances.Store(aws.ToString(warmInstance.InstanceId), warmInstance)
+			nodeClaim.Spec.Requirements[0].Values = []string{karpv1.CapacityTypeSpot}
+			ExpectApplied(ctx, env.Client, nodeClaim)
+			_, err := cloudProvider.Create(ctx, nodeClaim)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(awsEnv.EC2API.StartInstancesBehavior.CalledWithInput.Len()).To(Equal(0))
+			Expect(awsEnv.EC2API.CreateFleetBehavior.CalledWithInput.Len()).To(Equal(1))
+		})
+		It("should terminate a warm instance that fails to start and launch a new instance", func() {
+			awsEnv.EC2API.Instances.Store(aws.ToString(warmInstance.InstanceId), warmInstance)
+			awsEnv.EC2API.StartInstancesBehavior.Error.Set(fmt.Errorf("failed"))
+			ExpectApplied(ctx, env.Client, nodeClaim)
+			_, err := cloudProvider.Create(ctx, nodeClaim)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(awsEnv.EC2API.CreateFleetBehavior.CalledWithInput.Len()).To(Equal(1))
+			_, ok := awsEnv.EC2API.Instances.Load(aws.ToString(warmInstance.InstanceId))
+			Expect(ok).To(BeFalse())
+		})
+	})
 	Context("MinValues", func() {
 		It("CreateFleet input should respect minValues for In operator requirement from NodePool", func() {
 			// Create fake InstanceTypes where one instances can fit 2 pods and another one can fit only 1 pod.
@@ -776,6 +1020,12 @@ var _ = Describe("CloudProvider", func() {
 			Expect(err).ToNot(HaveOccurred())
 			Expect(drifted).To(BeEmpty())
 		})
+		It("should return drifted if the capacity block of the NodeClaim is expiring", func() {
+			nodeClaim.Annotations = lo.Assign(nodeClaim.Annotations, map[string]string{v1.AnnotationCapacityBlockExpiring: "true"})
+			isDrifted, err := cloudProvider.IsDrifted(ctx, nodeClaim)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(isDrifted).To(Equal(cloudprovider.CapacityBlockDrift))
+		})
 		It("should return drifted if the AMI is not valid", func() {
 			// Instance is a reference to what we return in the GetInstances call
 			instance.ImageId = aws.String(fake.ImageID())
@@ -868,6 +1118,181 @@ var _ = Describe("CloudProvider", func() {
 			Expect(err).ToNot(HaveOccurred())
 			Expect(isDrifted).To(Equal(cloudprovider.SecurityGroupDrift))
 		})
+		It("should return drifted if the instance placement group doesn't match the resolved placement group", func() {
+			nodeClass.Spec.PlacementGroup = &v1.PlacementGroupSelector{Name: "test-placement-group"}
+			nodeClass.Status.PlacementGroup = &v1.PlacementGroup{ID: "pg-test", Name: "test-placement-group", Strategy: "cluster"}
+			ExpectApplied(ctx, env.Client, nodeClass)
+			instance.Placement.GroupId = aws.String("pg-other")
+			awsEnv.EC2API.DescribeInstancesBehavior.Output.Set(&ec2.DescribeInstancesOutput{
+				Reservations: []ec2types.Reservation{{Instances: []ec2types.Instance{instance}}},
+			})
+			isDrifted, err := cloudProvider.IsDrifted(ctx, nodeClaim)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(isDrifted).To(Equal(cloudprovider.PlacementGroupDrift))
+		})
+		It("should return drifted if the instance is in a placement group and none is configured", func() {
+			instance.Placement.GroupId = aws.String("pg-test")
+			awsEnv.EC2API.DescribeInstancesBehavior.Output.Set(&ec2.DescribeInstancesOutput{
+				Reservations: []ec2types.Reservation{{Instances: []ec2types.Instance{instance}}},
+			})
+			isDrifted, err := cloudProvider.IsDrifted(ctx, nodeClaim)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(isDrifted).To(Equal(cloudprovider.PlacementGroupDrift))
+		})
+		It("should not return drifted if the instance placement group matches the resolved placement group", func() {
+			nodeClass.Spec.PlacementGroup = &v1.PlacementGroupSelector{Name: "test-placement-group"}
+			nodeClass.Status.PlacementGroup = &v1.PlacementGroup{ID: "pg-test", Name: "test-placement-group", Strategy: "cluster"}
+			ExpectApplied(ctx, env.Client, nodeClass)
+			instance.Placement.GroupId = aws.String("pg-test")
+			awsEnv.EC2API.DescribeInstancesBehavior.Output.Set(&ec2.DescribeInstancesOutput{
+				Reservations: []ec2types.Reservation{{Instances: []ec2types.Instance{instance}}},
+			})
+			isDrifted, err := cloudProvider.IsDrifted(ctx, nodeClaim)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(isDrifted).To(BeEmpty())
+		})
+		It("should not return drifted if the placement group hasn't been resolved", func() {
+			nodeClass.Spec.PlacementGroup = &v1.PlacementGroupSelector{Name: "test-placement-group"}
+			nodeClass.Status.PlacementGroup = nil
+			ExpectApplied(ctx, env.Client, nodeClass)
+			instance.Placement.GroupId = aws.String("pg-test")
+			awsEnv.EC2API.DescribeInstancesBehavior.Output.Set(&ec2.DescribeInstancesOutput{
+				Reservations: []ec2types.Reservation{{Instances: []ec2types.Instance{instance}}},
+			})
+			isDrifted, err := cloudProvider.IsDrifted(ctx, nodeClaim)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(isDrifted).To(BeEmpty())
+		})
+		Context("Network Interfaces", func() {
+			BeforeEach(func() {
+				nodeClass.Spec.NetworkInterfaces = []*v1.NetworkInterface{
+					{DeviceIndex: 0},
+					{DeviceIndex: 1, SubnetSelectorTerms: []v1.SubnetSelectorTerm{{ID: "subnet-test1"}}, SecurityGroupSelectorTerms: []v1.SecurityGroupSelectorTerm{{ID: "sg-test3"}}},
+				}
+				nodeClass.Status.NetworkInterfaces = []v1.NetworkInterfaceStatus{
+					{DeviceIndex: 0},
+					{
+						DeviceIndex:    1,
+						Subnets:        []v1.Subnet{{ID: "subnet-test1", Zone: "test-zone-1a", ZoneID: "tstz1-1a"}},
+						SecurityGroups: []v1.SecurityGroup{{ID: "sg-test3", Name: "securityGroup-test3"}},
+					},
+				}
+				nodeClass.Annotations[v1.AnnotationEC2NodeClassHash] = nodeClass.Hash()
+				nodeClaim.Annotations[v1.AnnotationEC2NodeClassHash] = nodeClass.Hash()
+			})
+			expectInstanceNetworkInterface := func(subnetID string, securityGroupIDs ...string) {
+				instance.NetworkInterfaces = []ec2types.InstanceNetworkInterface{
+					{
+						Attachment: &ec2types.InstanceNetworkInterfaceAttachment{NetworkCardIndex: aws.Int32(0), DeviceIndex: aws.Int32(0)},
+						SubnetId:   aws.String(validSubnet1),
+						Groups:     []ec2types.GroupIdentifier{{GroupId: aws.String(validSecurityGroup)}},
+					},
+					{
+						Attachment: &ec2types.InstanceNetworkInterfaceAttachment{NetworkCardIndex: aws.Int32(0), DeviceIndex: aws.Int32(1)},
+						SubnetId:   aws.String(subnetID),
+						Groups: lo.Map(securityGroupIDs, func(id string, _ int) ec2types.GroupIdentifier {
+							return ec2types.GroupIdentifier{GroupId: aws.String(id)}
+						}),
+					},
+				}
+				awsEnv.EC2API.DescribeInstancesBehavior.Output.Set(&ec2.DescribeInstancesOutput{
+					Reservations: []ec2types.Reservation{{Instances: []ec2types.Instance{instance}}},
+				})
+			}
+			It("should not return drifted if the network interfaces match the resolved subnets and security groups", func() {
+				ExpectApplied(ctx, env.Client, nodeClass)
+				expectInstanceNetworkInterface("subnet-test1", "sg-test3")
+				isDrifted, err := cloudProvider.IsDrifted(ctx, nodeClaim)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(isDrifted).To(BeEmpty())
+			})
+			It("should not return drifted if only the selector terms of the network interfaces change", func() {
+				nodeClass.Spec.NetworkInterfaces[1].SubnetSelectorTerms = []v1.SubnetSelectorTerm{{Tags: map[string]string{"Name": "test-subnet-1"}}}
+				ExpectApplied(ctx, env.Client, nodeClass)
+				expectInstanceNetworkInterface("subnet-test1", "sg-test3")
+				isDrifted, err := cloudProvider.IsDrifted(ctx, nodeClaim)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(isDrifted).To(BeEmpty())
+			})
+			It("should return drifted if a network interface isn't in a resolved subnet", func() {
+				ExpectApplied(ctx, env.Client, nodeClass)
+				expectInstanceNetworkInterface("subnet-test2", "sg-test3")
+				isDrifted, err := cloudProvider.IsDrifted(ctx, nodeClaim)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(isDrifted).To(Equal(cloudprovider.NetworkInterfaceDrift))
+			})
+			It("should return drifted if the security groups of a network interface don't match the resolved security groups", func() {
+				ExpectApplied(ctx, env.Client, nodeClass)
+				expectInstanceNetworkInterface("subnet-test1", "sg-test3", "sg-test1")
+				isDrifted, err := cloudProvider.IsDrifted(ctx, nodeClaim)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(isDrifted).To(Equal(cloudprovider.NetworkInterfaceDrift))
+			})
+		})
+		Context("Hosts", func() {
+			BeforeEach(func() {
+				nodeClass.Spec.Tenancy = lo.ToPtr(v1.TenancyHost)
+				nodeClass.Spec.HostSelectorTerms = []v1.HostSelectorTerm{{Tags: map[string]string{"*": "*"}}}
+				nodeClass.Status.Hosts = []v1.Host{
+					{ID: "h-test1", AvailabilityZone: "test-zone-1a", InstanceFamily: "m5"},
+					{ID: "h-test2", AvailabilityZone: "test-zone-1a", InstanceFamily: "m5"},
+				}
+				nodeClass.Annotations[v1.AnnotationEC2NodeClassHash] = nodeClass.Hash()
+				nodeClaim.Annotations[v1.AnnotationEC2NodeClassHash] = nodeClass.Hash()
+			})
+			expectInstancePlacement := func(hostID, hostResourceGroupARN string) {
+				instance.Placement.HostId = lo.EmptyableToPtr(hostID)
+				instance.Placement.HostResourceGroupArn = lo.EmptyableToPtr(hostResourceGroupARN)
+				awsEnv.EC2API.DescribeInstancesBehavior.Output.Set(&ec2.DescribeInstancesOutput{
+					Reservations: []ec2types.Reservation{{Instances: []ec2types.Instance{instance}}},
+				})
+			}
+			It("should not return drifted if the instance is on a resolved host", func() {
+				ExpectApplied(ctx, env.Client, nodeClass)
+				expectInstancePlacement("h-test2", "")
+				isDrifted, err := cloudProvider.IsDrifted(ctx, nodeClaim)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(isDrifted).To(BeEmpty())
+			})
+			It("should return drifted if the instance isn't on a resolved host", func() {
+				ExpectApplied(ctx, env.Client, nodeClass)
+				expectInstancePlacement("h-other", "")
+				isDrifted, err := cloudProvider.IsDrifted(ctx, nodeClaim)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(isDrifted).To(Equal(cloudprovider.HostDrift))
+			})
+			It("should not return drifted if the hosts haven't been resolved", func() {
+				nodeClass.Status.Hosts = nil
+				ExpectApplied(ctx, env.Client, nodeClass)
+				expectInstancePlacement("h-other", "")
+				isDrifted, err := cloudProvider.IsDrifted(ctx, nodeClaim)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(isDrifted).To(BeEmpty())
+			})
+			It("should not return drifted if the instance is in the host resource group", func() {
+				nodeClass.Spec.HostResourceGroupARN = lo.ToPtr("arn:aws:resource-groups:us-west-2:111122223333:group/test")
+				ExpectApplied(ctx, env.Client, nodeClass)
+				expectInstancePlacement("h-other", "arn:aws:resource-groups:us-west-2:111122223333:group/test")
+				isDrifted, err := cloudProvider.IsDrifted(ctx, nodeClaim)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(isDrifted).To(BeEmpty())
+			})
+			It("should return drifted if the host resource group changes", func() {
+				nodeClass.Spec.HostResourceGroupARN = lo.ToPtr("arn:aws:resource-groups:us-west-2:111122223333:group/test")
+				ExpectApplied(ctx, env.Client, nodeClass)
+				expectInstancePlacement("h-test1", "arn:aws:resource-groups:us-west-2:111122223333:group/other")
+				isDrifted, err := cloudProvider.IsDrifted(ctx, nodeClaim)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(isDrifted).To(Equal(cloudprovider.HostDrift))
+			})
+			It("should return drifted if the instance is in a host resource group and none is configured", func() {
+				ExpectApplied(ctx, env.Client, nodeClass)
+				expectInstancePlacement("h-test1", "arn:aws:resource-groups:us-west-2:111122223333:group/test")
+				isDrifted, err := cloudProvider.IsDrifted(ctx, nodeClaim)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(isDrifted).To(Equal(cloudprovider.HostDrift))
+			})
+		})
 		It("should not return drifted if the security groups match", func() {
 			isDrifted, err := cloudProvider.IsDrifted(ctx, nodeClaim)
 			Expect(err).ToNot(HaveOccurred())
@@ -1156,7 +1581,7 @@ var _ = Describe("CloudProvider", func() {
 				{SubnetId: aws.String("test-subnet-2"), AvailabilityZone: aws.String("test-zone-1a"), AvailabilityZoneId: aws.String("tstz1-1a"), AvailableIpAddressCount: aws.Int32(100),
 					Tags: []ec2types.Tag{{Key: aws.String("Name"), Value: aws.String("test-subnet-2")}}},
 			}})
-			controller := nodeclass.NewController(env.Client, recorder, awsEnv.SubnetProvider, awsEnv.SecurityGroupProvider, awsEnv.AMIProvider, awsEnv.InstanceProfileProvider, awsEnv.LaunchTemplateProvider)
+			controller := nodeclass.NewController(env.Client, recorder, awsEnv.EC2API, awsEnv.SubnetProvider, awsEnv.SecurityGroupProvider, awsEnv.AMIProvider, awsEnv.InstanceProfileProvider, awsEnv.LaunchTemplateProvider, awsEnv.CapacityReservationProvider, awsEnv.PlacementGroupProvider, awsEnv.HostProvider, awsEnv.InstanceTypesProvider, awsEnv.ServiceQuotaProvider)
 			ExpectApplied(ctx, env.Client, nodePool, nodeClass)
 			ExpectObjectReconciled(ctx, env.Client, controller, nodeClass)
 			pod := coretest.UnschedulablePod(coretest.PodOptions{NodeSelector: map[string]string{corev1.LabelTopologyZone: "test-zone-1a"}})
@@ -1173,7 +1598,7 @@ var _ = Describe("CloudProvider", func() {
 				{SubnetId: aws.String("test-subnet-2"), AvailabilityZone: aws.String("test-zone-1a"), AvailabilityZoneId: aws.String("tstz1-1a"), AvailableIpAddressCount: aws.Int32(11),
 					Tags: []ec2types.Tag{{Key: aws.String("Name"), Value: aws.String("test-subnet-2")}}},
 			}})
-			controller := nodeclass.NewController(env.Client, recorder, awsEnv.SubnetProvider, awsEnv.SecurityGroupProvider, awsEnv.AMIProvider, awsEnv.InstanceProfileProvider, awsEnv.LaunchTemplateProvider)
+			controller := nodeclass.NewController(env.Client, recorder, awsEnv.EC2API, awsEnv.SubnetProvider, awsEnv.SecurityGroupProvider, awsEnv.AMIProvider, awsEnv.InstanceProfileProvider, awsEnv.LaunchTemplateProvider, awsEnv.CapacityReservationProvider, awsEnv.PlacementGroupProvider, awsEnv.HostProvider, awsEnv.InstanceTypesProvider, awsEnv.ServiceQuotaProvider)
 			nodeClass.Spec.Kubelet = &v1.KubeletConfiguration{
 				MaxPods: aws.Int32(1),
 			}
@@ -1194,6 +1619,99 @@ var _ = Describe("CloudProvider", func() {
 			createFleetInput = awsEnv.EC2API.CreateFleetBehavior.CalledWithInput.Pop()
 			Expect(fake.SubnetsFromFleetRequest(createFleetInput)).To(ConsistOf("test-subnet-1"))
 		})
+		It("should deduct whole /28 prefixes from in-flight IPs with prefix delegation", func() {
+			awsEnv.SubnetCache.Flush()
+			awsEnv.EC2API.DescribeSubnetsOutput.Set(&ec2.DescribeSubnetsOutput{Subnets: []ec2types.Subnet{
+				{SubnetId: aws.String("test-subnet-1"), AvailabilityZone: aws.String("test-zone-1a"), AvailabilityZoneId: aws.String("tstz1-1a"), AvailableIpAddressCount: aws.Int32(20),
+					Tags: []ec2types.Tag{{Key: aws.String("Name"), Value: aws.String("test-subnet-1")}}},
+				{SubnetId: aws.String("test-subnet-2"), AvailabilityZone: aws.String("test-zone-1a"), AvailabilityZoneId: aws.String("tstz1-1a"), AvailableIpAddressCount: aws.Int32(30),
+					Tags: []ec2types.Tag{{Key: aws.String("Name"), Value: aws.String("test-subnet-2")}}},
+			}})
+			controller := nodeclass.NewController(env.Client, recorder, awsEnv.EC2API, awsEnv.SubnetProvider, awsEnv.SecurityGroupProvider, awsEnv.AMIProvider, awsEnv.InstanceProfileProvider, awsEnv.LaunchTemplateProvider, awsEnv.CapacityReservationProvider, awsEnv.PlacementGroupProvider, awsEnv.HostProvider, awsEnv.InstanceTypesProvider, awsEnv.ServiceQuotaProvider)
+			nodeClass.Spec.IPAddressMode = lo.ToPtr(v1.IPAddressModePrefixDelegation)
+			nodeClass.Spec.Kubelet = &v1.KubeletConfiguration{
+				MaxPods: aws.Int32(1),
+			}
+			ExpectApplied(ctx, env.Client, nodePool, nodeClass)
+			nodeClass = ExpectExists(ctx, env.Client, nodeClass)
+			ExpectObjectReconciled(ctx, env.Client, controller, nodeClass)
+			pod1 := coretest.UnschedulablePod(coretest.PodOptions{NodeSelector: map[string]string{corev1.LabelTopologyZone: "test-zone-1a"}})
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod1)
+			ExpectScheduled(ctx, env.Client, pod1)
+			createFleetInput := awsEnv.EC2API.CreateFleetBehavior.CalledWithInput.Pop()
+			Expect(fake.SubnetsFromFleetRequest(createFleetInput)).To(ConsistOf("test-subnet-2"))
+			// A single pod consumes a whole prefix, which leaves the first subnet without room for another one
+			pod2 := coretest.UnschedulablePod(coretest.PodOptions{NodeSelector: map[string]string{corev1.LabelTopologyZone: "test-zone-1a"}})
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod2)
+			ExpectScheduled(ctx, env.Client, pod2)
+			createFleetInput = awsEnv.EC2API.CreateFleetBehavior.CalledWithInput.Pop()
+			Expect(fake.SubnetsFromFleetRequest(createFleetInput)).To(ConsistOf("test-subnet-1"))
+			// Neither subnet has a /28 prefix left
+			pod3 := coretest.UnschedulablePod(coretest.PodOptions{NodeSelector: map[string]string{corev1.LabelTopologyZone: "test-zone-1a"}})
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod3)
+			ExpectNotScheduled(ctx, env.Client, pod3)
+		})
+		It("should not launch instances into fragmented subnets with prefix delegation", func() {
+			awsEnv.SubnetCache.Flush()
+			awsEnv.EC2API.DescribeSubnetsOutput.Set(&ec2.DescribeSubnetsOutput{Subnets: []ec2types.Subnet{
+				{SubnetId: aws.String("test-subnet-1"), AvailabilityZone: aws.String("test-zone-1a"), AvailabilityZoneId: aws.String("tstz1-1a"), AvailableIpAddressCount: aws.Int32(10),
+					Tags: []ec2types.Tag{{Key: aws.String("Name"), Value: aws.String("test-subnet-1")}}},
+				{SubnetId: aws.String("test-subnet-2"), AvailabilityZone: aws.String("test-zone-1a"), AvailabilityZoneId: aws.String("tstz1-1a"), AvailableIpAddressCount: aws.Int32(100),
+					Tags: []ec2types.Tag{{Key: aws.String("Name"), Value: aws.String("test-subnet-2")}}},
+				{SubnetId: aws.String("test-subnet-3"), AvailabilityZone: aws.String("test-zone-1a"), AvailabilityZoneId: aws.String("tstz1-1a"), AvailableIpAddressCount: aws.Int32(50),
+					Tags: []ec2types.Tag{{Key: aws.String("Name"), Value: aws.String("test-subnet-3")}}},
+			}})
+			awsEnv.SubnetProvider.MarkPrefixFragmented("test-subnet-2")
+			controller := nodeclass.NewController(env.Client, recorder, awsEnv.EC2API, awsEnv.SubnetProvider, awsEnv.SecurityGroupProvider, awsEnv.AMIProvider, awsEnv.InstanceProfileProvider, awsEnv.LaunchTemplateProvider, awsEnv.CapacityReservationProvider, awsEnv.PlacementGroupProvider, awsEnv.HostProvider, awsEnv.InstanceTypesProvider, awsEnv.ServiceQuotaProvider)
+			nodeClass.Spec.IPAddressMode = lo.ToPtr(v1.IPAddressModePrefixDelegation)
+			ExpectApplied(ctx, env.Client, nodePool, nodeClass)
+			ExpectObjectReconciled(ctx, env.Client, controller, nodeClass)
+			pod := coretest.UnschedulablePod(coretest.PodOptions{NodeSelector: map[string]string{corev1.LabelTopologyZone: "test-zone-1a"}})
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+			ExpectScheduled(ctx, env.Client, pod)
+			createFleetInput := awsEnv.EC2API.CreateFleetBehavior.CalledWithInput.Pop()
+			Expect(fake.SubnetsFromFleetRequest(createFleetInput)).To(ConsistOf("test-subnet-3"))
+		})
+		It("should launch instances into fragmented subnets without prefix delegation", func() {
+			awsEnv.SubnetCache.Flush()
+			awsEnv.EC2API.DescribeSubnetsOutput.Set(&ec2.DescribeSubnetsOutput{Subnets: []ec2types.Subnet{
+				{SubnetId: aws.String("test-subnet-1"), AvailabilityZone: aws.String("test-zone-1a"), AvailabilityZoneId: aws.String("tstz1-1a"), AvailableIpAddressCount: aws.Int32(10),
+					Tags: []ec2types.Tag{{Key: aws.String("Name"), Value: aws.String("test-subnet-1")}}},
+				{SubnetId: aws.String("test-subnet-2"), AvailabilityZone: aws.String("test-zone-1a"), AvailabilityZoneId: aws.String("tstz1-1a"), AvailableIpAddressCount: aws.Int32(100),
+					Tags: []ec2types.Tag{{Key: aws.String("Name"), Value: aws.String("test-subnet-2")}}},
+			}})
+			awsEnv.SubnetProvider.MarkPrefixFragmented("test-subnet-2")
+			controller := nodeclass.NewController(env.Client, recorder, awsEnv.EC2API, awsEnv.SubnetProvider, awsEnv.SecurityGroupProvider, awsEnv.AMIProvider, awsEnv.InstanceProfileProvider, awsEnv.LaunchTemplateProvider, awsEnv.CapacityReservationProvider, awsEnv.PlacementGroupProvider, awsEnv.HostProvider, awsEnv.InstanceTypesProvider, awsEnv.ServiceQuotaProvider)
+			ExpectApplied(ctx, env.Client, nodePool, nodeClass)
+			ExpectObjectReconciled(ctx, env.Client, controller, nodeClass)
+			pod := coretest.UnschedulablePod(coretest.PodOptions{NodeSelector: map[string]string{corev1.LabelTopologyZone: "test-zone-1a"}})
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+			ExpectScheduled(ctx, env.Client, pod)
+			createFleetInput := awsEnv.EC2API.CreateFleetBehavior.CalledWithInput.Pop()
+			Expect(fake.SubnetsFromFleetRequest(createFleetInput)).To(ConsistOf("test-subnet-2"))
+		})
+		It("should only deduct the primary IPv4 address of the node from in-flight IPs with ipv6", func() {
+			awsEnv.SubnetCache.Flush()
+			awsEnv.EC2API.DescribeSubnetsOutput.Set(&ec2.DescribeSubnetsOutput{Subnets: []ec2types.Subnet{
+				{SubnetId: aws.String("test-subnet-1"), AvailabilityZone: aws.String("test-zone-1a"), AvailabilityZoneId: aws.String("tstz1-1a"), AvailableIpAddressCount: aws.Int32(10),
+					Tags: []ec2types.Tag{{Key: aws.String("Name"), Value: aws.String("test-subnet-1")}}},
+				{SubnetId: aws.String("test-subnet-2"), AvailabilityZone: aws.String("test-zone-1a"), AvailabilityZoneId: aws.String("tstz1-1a"), AvailableIpAddressCount: aws.Int32(12),
+					Tags: []ec2types.Tag{{Key: aws.String("Name"), Value: aws.String("test-subnet-2")}}},
+			}})
+			controller := nodeclass.NewController(env.Client, recorder, awsEnv.EC2API, awsEnv.SubnetProvider, awsEnv.SecurityGroupProvider, awsEnv.AMIProvider, awsEnv.InstanceProfileProvider, awsEnv.LaunchTemplateProvider, awsEnv.CapacityReservationProvider, awsEnv.PlacementGroupProvider, awsEnv.HostProvider, awsEnv.InstanceTypesProvider, awsEnv.ServiceQuotaProvider)
+			nodeClass.Spec.IPAddressMode = lo.ToPtr(v1.IPAddressModeIPv6)
+			ExpectApplied(ctx, env.Client, nodePool, nodeClass)
+			nodeClass = ExpectExists(ctx, env.Client, nodeClass)
+			ExpectObjectReconciled(ctx, env.Client, controller, nodeClass)
+			// The pods share a host port, so each of them is launched on its own node
+			for i := 0; i < 2; i++ {
+				pod := coretest.UnschedulablePod(coretest.PodOptions{NodeSelector: map[string]string{corev1.LabelTopologyZone: "test-zone-1a"}, HostPorts: []int32{8080}})
+				ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+				ExpectScheduled(ctx, env.Client, pod)
+				createFleetInput := awsEnv.EC2API.CreateFleetBehavior.CalledWithInput.Pop()
+				Expect(fake.SubnetsFromFleetRequest(createFleetInput)).To(ConsistOf("test-subnet-2"))
+			}
+		})
 		It("should update in-flight IPs when a CreateFleet error occurs", func() {
 			awsEnv.EC2API.DescribeSubnetsOutput.Set(&ec2.DescribeSubnetsOutput{Subnets: []ec2types.Subnet{
 				{SubnetId: aws.String("test-subnet-1"), AvailabilityZone: aws.String("test-zone-1a"), AvailableIpAddressCount: aws.Int32(10),
@@ -1214,7 +1732,7 @@ var _ = Describe("CloudProvider", func() {
 			}})
 			nodeClass.Spec.SubnetSelectorTerms = []v1.SubnetSelectorTerm{{Tags: map[string]string{"Name": "test-subnet-1"}}}
 			ExpectApplied(ctx, env.Client, nodePool, nodeClass)
-			controller := nodeclass.NewController(env.Client, recorder, awsEnv.SubnetProvider, awsEnv.SecurityGroupProvider, awsEnv.AMIProvider, awsEnv.InstanceProfileProvider, awsEnv.LaunchTemplateProvider)
+			controller := nodeclass.NewController(env.Client, recorder, awsEnv.EC2API, awsEnv.SubnetProvider, awsEnv.SecurityGroupProvider, awsEnv.AMIProvider, awsEnv.InstanceProfileProvider, awsEnv.LaunchTemplateProvider, awsEnv.CapacityReservationProvider, awsEnv.PlacementGroupProvider, awsEnv.HostProvider, awsEnv.InstanceTypesProvider, awsEnv.ServiceQuotaProvider)
 			ExpectObjectReconciled(ctx, env.Client, controller, nodeClass)
 			podSubnet1 := coretest.UnschedulablePod()
 			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, podSubnet1)
@@ -16,6 +16,7 @@ package controllers
 
 import (
 	"context"
+	"fmt"
 
 	"github.com/awslabs/operatorpkg/controller"
 	opevents "github.com/awslabs/operatorpkg/events"
@@ -29,15 +30,21 @@ import (
 	v1 "github.com/aws/karpenter-provider-aws/pkg/apis/v1"
 	nodeclass "github.com/aws/karpenter-provider-aws/pkg/controllers/nodeclass"
 	nodeclasshash "github.com/aws/karpenter-provider-aws/pkg/controllers/nodeclass/hash"
+	nodeclasswarmpool "github.com/aws/karpenter-provider-aws/pkg/controllers/nodeclass/warmpool"
+	controllerscheckpoint "github.com/aws/karpenter-provider-aws/pkg/controllers/providers/checkpoint"
 	controllersinstancetype "github.com/aws/karpenter-provider-aws/pkg/controllers/providers/instancetype"
 	controllersinstancetypecapacity "github.com/aws/karpenter-provider-aws/pkg/controllers/providers/instancetype/capacity"
 	controllerspricing "github.com/aws/karpenter-provider-aws/pkg/controllers/providers/pricing"
+	controllerspricingoverrides "github.com/aws/karpenter-provider-aws/pkg/controllers/providers/pricing/overrides"
+	controllerspricingsnapshot "github.com/aws/karpenter-provider-aws/pkg/controllers/providers/pricing/snapshot"
+	controllersservicequota "github.com/aws/karpenter-provider-aws/pkg/controllers/providers/servicequota"
+	controllersspotplacementscore "github.com/aws/karpenter-provider-aws/pkg/controllers/providers/spotplacementscore"
 	ssminvalidation "github.com/aws/karpenter-provider-aws/pkg/controllers/providers/ssm/invalidation"
 	controllersversion "github.com/aws/karpenter-provider-aws/pkg/controllers/providers/version"
+	"github.com/aws/karpenter-provider-aws/pkg/providers/host"
 	"github.com/aws/karpenter-provider-aws/pkg/providers/launchtemplate"
 	"github.com/aws/karpenter-provider-aws/pkg/providers/version"
 
-	servicesqs "github.com/aws/aws-sdk-go-v2/service/sqs"
 	"github.com/samber/lo"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/utils/clock"
@@ -45,17 +52,29 @@ import (
 
 	"sigs.k8s.io/karpenter/pkg/events"
 
+	sdk "github.com/aws/karpenter-provider-aws/pkg/aws"
+	"github.com/aws/karpenter-provider-aws/pkg/batcher"
 	awscache "github.com/aws/karpenter-provider-aws/pkg/cache"
 	"github.com/aws/karpenter-provider-aws/pkg/controllers/interruption"
+	"github.com/aws/karpenter-provider-aws/pkg/controllers/interruption/messages/rule"
+	interruptionrules "github.com/aws/karpenter-provider-aws/pkg/controllers/interruption/rules"
+	nodeclaimcapacityblock "github.com/aws/karpenter-provider-aws/pkg/controllers/nodeclaim/capacityblock"
 	nodeclaimgarbagecollection "github.com/aws/karpenter-provider-aws/pkg/controllers/nodeclaim/garbagecollection"
+	nodeclaimmaintenance "github.com/aws/karpenter-provider-aws/pkg/controllers/nodeclaim/maintenance"
+	nodeclaimrebalance "github.com/aws/karpenter-provider-aws/pkg/controllers/nodeclaim/rebalance"
 	nodeclaimtagging "github.com/aws/karpenter-provider-aws/pkg/controllers/nodeclaim/tagging"
 	"github.com/aws/karpenter-provider-aws/pkg/operator/options"
 	"github.com/aws/karpenter-provider-aws/pkg/providers/amifamily"
+	"github.com/aws/karpenter-provider-aws/pkg/providers/capacityreservation"
+	"github.com/aws/karpenter-provider-aws/pkg/providers/checkpoint"
 	"github.com/aws/karpenter-provider-aws/pkg/providers/instance"
 	"github.com/aws/karpenter-provider-aws/pkg/providers/instanceprofile"
 	"github.com/aws/karpenter-provider-aws/pkg/providers/instancetype"
+	"github.com/aws/karpenter-provider-aws/pkg/providers/placementgroup"
 	"github.com/aws/karpenter-provider-aws/pkg/providers/pricing"
 	"github.com/aws/karpenter-provider-aws/pkg/providers/securitygroup"
+	"github.com/aws/karpenter-provider-aws/pkg/providers/servicequota"
+	"github.com/aws/karpenter-provider-aws/pkg/providers/spotplacementscore"
 	"github.com/aws/karpenter-provider-aws/pkg/providers/sqs"
 	"github.com/aws/karpenter-provider-aws/pkg/providers/subnet"
 )
@@ -64,10 +83,13 @@ func NewControllers(
 	ctx context.Context,
 	mgr manager.Manager,
 	cfg aws.Config,
+	ec2api sdk.EC2API,
+	ec2Batcher *batcher.EC2API,
 	clk clock.Clock,
 	kubeClient client.Client,
 	recorder events.Recorder,
 	unavailableOfferings *awscache.UnavailableOfferings,
+	polledInterruptions *awscache.PolledInterruptions,
 	ssmCache *cache.Cache,
 	cloudProvider cloudprovider.CloudProvider,
 	subnetProvider subnet.Provider,
@@ -77,25 +99,72 @@ func NewControllers(
 	pricingProvider pricing.Provider,
 	amiProvider amifamily.Provider,
 	launchTemplateProvider launchtemplate.Provider,
+	capacityReservationProvider capacityreservation.Provider,
+	placementGroupProvider placementgroup.Provider,
+	hostProvider host.Provider,
+	spotPlacementScoreProvider spotplacementscore.Provider,
+	serviceQuotaProvider servicequota.Provider,
 	versionProvider *version.DefaultProvider,
-	instanceTypeProvider *instancetype.DefaultProvider) []controller.Controller {
+	instanceTypeProvider *instancetype.DefaultProvider,
+	pricingOverridesProvider pricing.OverridesProvider,
+	pricingSnapshotProvider pricing.SnapshotProvider,
+	checkpointProvider checkpoint.Provider,
+	interruptionRulesProvider rule.Provider) []controller.Controller {
 	controllers := []controller.Controller{
 		nodeclasshash.NewController(kubeClient),
-		nodeclass.NewController(kubeClient, recorder, subnetProvider, securityGroupProvider, amiProvider, instanceProfileProvider, launchTemplateProvider),
+		nodeclass.NewController(kubeClient, recorder, ec2api, subnetProvider, securityGroupProvider, amiProvider, instanceProfileProvider, launchTemplateProvider, capacityReservationProvider, placementGroupProvider, hostProvider, instanceTypeProvider, serviceQuotaProvider),
+		nodeclasswarmpool.NewController(kubeClient, clk, instanceProvider, instanceTypeProvider, pricingProvider),
+		nodeclasswarmpool.NewNodeController(kubeClient),
 		nodeclaimgarbagecollection.NewController(kubeClient, cloudProvider),
 		nodeclaimtagging.NewController(kubeClient, cloudProvider, instanceProvider),
-		controllerspricing.NewController(pricingProvider),
+		nodeclaimcapacityblock.NewController(kubeClient, cloudProvider, clk, recorder),
+		controllerspricing.NewController(pricingProvider, clk),
 		controllersinstancetype.NewController(instanceTypeProvider),
 		controllersinstancetypecapacity.NewController(kubeClient, cloudProvider, instanceTypeProvider),
+		controllersspotplacementscore.NewController(spotPlacementScoreProvider),
+		controllersservicequota.NewController(kubeClient, serviceQuotaProvider),
 		ssminvalidation.NewController(ssmCache, amiProvider),
 		status.NewController[*v1.EC2NodeClass](kubeClient, mgr.GetEventRecorderFor("karpenter"), status.EmitDeprecatedMetrics),
 		opevents.NewController[*corev1.Node](kubeClient, clk),
 		controllersversion.NewController(versionProvider, versionProvider.UpdateVersionWithValidation),
 	}
-	if options.FromContext(ctx).InterruptionQueue != "" {
-		sqsapi := servicesqs.NewFromConfig(cfg)
-		out := lo.Must(sqsapi.GetQueueUrl(ctx, &servicesqs.GetQueueUrlInput{QueueName: lo.ToPtr(options.FromContext(ctx).InterruptionQueue)}))
-		controllers = append(controllers, interruption.NewController(kubeClient, cloudProvider, clk, recorder, lo.Must(sqs.NewDefaultProvider(sqsapi, lo.FromPtr(out.QueueUrl))), unavailableOfferings))
+	// Each interruption queue is received from by a controller of its own. The interruption-queue is required to start,
+	// while the queues of interruption-queues are resolved when they're first received from, so that a queue which
+	// can't be resolved yet doesn't prevent handling interruptions from the other queues
+	var interruptionControllers []controller.Controller
+	parser := interruption.NewEventParser(interruption.DefaultParsers...)
+	for i, q := range lo.Must(options.FromContext(ctx).InterruptionQueueList()) {
+		if q.Name == options.FromContext(ctx).InterruptionQueue {
+			interruptionControllers = append(interruptionControllers, interruption.NewController("interruption", kubeClient, cloudProvider, clk, recorder, parser,
+				lo.Must(sqs.NewDefaultProviderForQueue(ctx, cfg, q.Name, q.RoleARN)), unavailableOfferings))
+			continue
+		}
+		interruptionControllers = append(interruptionControllers, interruption.NewController(fmt.Sprintf("interruption.%d", i), kubeClient, cloudProvider, clk, recorder, parser,
+			sqs.NewUnresolvedProviderForQueue(cfg, q.Name, q.RoleARN), unavailableOfferings))
+	}
+	if len(interruptionControllers) > 0 {
+		controllers = append(controllers, interruptionControllers...)
+		if interruptionRulesProvider != nil {
+			controllers = append(controllers, interruptionrules.NewController(kubeClient, interruptionRulesProvider, parser))
+		}
+	}
+	if options.FromContext(ctx).InterruptionPollingInterval > 0 {
+		controllers = append(controllers, interruption.NewPollingController(kubeClient, cloudProvider, clk, recorder, ec2api, ec2Batcher.DescribeInstancesBatcher, unavailableOfferings, polledInterruptions))
+	}
+	if len(interruptionControllers) > 0 || options.FromContext(ctx).InterruptionPollingInterval > 0 {
+		controllers = append(controllers,
+			nodeclaimrebalance.NewController(kubeClient, clk, cloudProvider, recorder),
+			nodeclaimmaintenance.NewController(kubeClient, cloudProvider, clk, recorder),
+		)
+	}
+	if options.FromContext(ctx).PricingOverridesConfigMap != "" {
+		controllers = append(controllers, controllerspricingoverrides.NewController(pricingOverridesProvider, pricingProvider))
+	}
+	if options.FromContext(ctx).PricingSnapshotPath != "" || options.FromContext(ctx).PricingSnapshotConfigMap != "" {
+		controllers = append(controllers, controllerspricingsnapshot.NewController(pricingSnapshotProvider, pricingProvider))
+	}
+	if options.FromContext(ctx).CacheCheckpointConfigMap != "" {
+		controllers = append(controllers, controllerscheckpoint.NewController(checkpointProvider))
 	}
 	return controllers
 }
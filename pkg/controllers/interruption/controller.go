@@ -25,8 +25,10 @@ import (
 	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
 	sqstypes "github.com/aws/aws-sdk-go-v2/service/sqs/types"
 	"github.com/awslabs/operatorpkg/singleton"
+	"github.com/samber/lo"
 	"go.uber.org/multierr"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/client-go/util/workqueue"
 	"k8s.io/klog/v2"
 	"k8s.io/utils/clock"
@@ -41,9 +43,13 @@ import (
 	nodeclaimutils "sigs.k8s.io/karpenter/pkg/utils/nodeclaim"
 	"sigs.k8s.io/karpenter/pkg/utils/pretty"
 
+	v1 "github.com/aws/karpenter-provider-aws/pkg/apis/v1"
 	"github.com/aws/karpenter-provider-aws/pkg/cache"
 	interruptionevents "github.com/aws/karpenter-provider-aws/pkg/controllers/interruption/events"
 	"github.com/aws/karpenter-provider-aws/pkg/controllers/interruption/messages"
+	"github.com/aws/karpenter-provider-aws/pkg/controllers/interruption/messages/rule"
+	"github.com/aws/karpenter-provider-aws/pkg/controllers/interruption/messages/scheduledchange"
+	"github.com/aws/karpenter-provider-aws/pkg/operator/options"
 	"github.com/aws/karpenter-provider-aws/pkg/providers/sqs"
 	"github.com/aws/karpenter-provider-aws/pkg/utils"
 
@@ -54,13 +60,24 @@ type Action string
 
 const (
 	CordonAndDrain Action = "CordonAndDrain"
-	NoAction       Action = "NoAction"
+	// ReplaceBeforeDrain launches a replacement NodeClaim, and the NodeClaim is cordoned and drained once the
+	// replacement is ready
+	ReplaceBeforeDrain Action = "ReplaceBeforeDrain"
+	// ScheduledCordonAndDrain cordons and drains the NodeClaim ahead of a scheduled change, at a time which is recorded
+	// on the NodeClaim
+	ScheduledCordonAndDrain Action = "ScheduledCordonAndDrain"
+	// Taint taints the Node so that no new pods are scheduled to it, without draining it
+	Taint    Action = "Taint"
+	NoAction Action = "NoAction"
 )
 
 // Controller is an AWS interruption controller.
 // It continually polls an SQS queue for events from aws.ec2 and aws.health that
-// trigger node health events or node spot interruption/rebalance events.
+// trigger node health events or node spot interruption/rebalance events. Each interruption
+// queue is polled by a controller of its own, so that long polling an empty queue doesn't
+// hold up the messages of the others.
 type Controller struct {
+	name                      string
 	kubeClient                client.Client
 	cloudProvider             cloudprovider.CloudProvider
 	clk                       clock.Clock
@@ -71,28 +88,33 @@ type Controller struct {
 	cm                        *pretty.ChangeMonitor
 }
 
+// NewController returns a controller for an interruption queue. Controller names must be unique, so the controllers of
+// additional interruption queues are named differently than the controller of the interruption queue.
 func NewController(
+	name string,
 	kubeClient client.Client,
 	cloudProvider cloudprovider.CloudProvider,
 	clk clock.Clock,
 	recorder events.Recorder,
+	parser *EventParser,
 	sqsProvider sqs.Provider,
 	unavailableOfferingsCache *cache.UnavailableOfferings,
 ) *Controller {
 	return &Controller{
+		name:                      name,
 		kubeClient:                kubeClient,
 		cloudProvider:             cloudProvider,
 		clk:                       clk,
 		recorder:                  recorder,
 		sqsProvider:               sqsProvider,
 		unavailableOfferingsCache: unavailableOfferingsCache,
-		parser:                    NewEventParser(DefaultParsers...),
+		parser:                    parser,
 		cm:                        pretty.NewChangeMonitor(),
 	}
 }
 
 func (c *Controller) Reconcile(ctx context.Context) (reconcile.Result, error) {
-	ctx = injection.WithControllerName(ctx, "interruption")
+	ctx = injection.WithControllerName(ctx, c.name)
 	ctx = log.IntoContext(ctx, log.FromContext(ctx).WithValues("queue", c.sqsProvider.Name()))
 	if c.cm.HasChanged(c.sqsProvider.Name(), nil) {
 		log.FromContext(ctx).V(1).Info("watching interruption queue")
@@ -114,10 +136,10 @@ func (c *Controller) Reconcile(ctx context.Context) (reconcile.Result, error) {
 	}
 	errs := make([]error, len(sqsMessages))
 	workqueue.ParallelizeUntil(ctx, 10, len(sqsMessages), func(i int) {
-		msg, e := c.parseMessage(sqsMessages[i])
+		msg, e := c.parseMessage(ctx, sqsMessages[i])
 		if e != nil {
 			// If we fail to parse, then we should delete the message but still log the error
-			log.FromContext(ctx).Error(err, "failed parsing interruption message")
+			log.FromContext(ctx).Error(e, "failed parsing interruption message")
 			errs[i] = c.deleteMessage(ctx, sqsMessages[i])
 			return
 		}
@@ -135,18 +157,18 @@ func (c *Controller) Reconcile(ctx context.Context) (reconcile.Result, error) {
 
 func (c *Controller) Register(_ context.Context, m manager.Manager) error {
 	return controllerruntime.NewControllerManagedBy(m).
-		Named("interruption").
+		Named(c.name).
 		WatchesRawSource(singleton.Source()).
 		Complete(singleton.AsReconciler(c))
 }
 
 // parseMessage parses the passed SQS message into an internal Message interface
-func (c *Controller) parseMessage(raw *sqstypes.Message) (messages.Message, error) {
+func (c *Controller) parseMessage(ctx context.Context, raw *sqstypes.Message) (messages.Message, error) {
 	// No message to parse in this case
 	if raw == nil || raw.Body == nil {
 		return nil, fmt.Errorf("message or message body is nil")
 	}
-	msg, err := c.parser.Parse(*raw.Body)
+	msg, err := c.parser.Parse(ctx, *raw.Body)
 	if err != nil {
 		return nil, fmt.Errorf("parsing sqs message, %w", err)
 	}
@@ -191,7 +213,10 @@ func (c *Controller) deleteMessage(ctx context.Context, msg *sqstypes.Message) e
 
 // handleNodeClaim retrieves the action for the message and then performs the appropriate action against the node
 func (c *Controller) handleNodeClaim(ctx context.Context, msg messages.Message, nodeClaim *karpv1.NodeClaim, node *corev1.Node) error {
-	action := actionForMessage(msg)
+	action, err := c.actionForNodeClaim(ctx, msg, nodeClaim)
+	if err != nil {
+		return fmt.Errorf("resolving action, %w", err)
+	}
 	ctx = log.IntoContext(ctx, log.FromContext(ctx).WithValues("NodeClaim", klog.KRef("", nodeClaim.Name), "action", string(action)))
 	if node != nil {
 		ctx = log.IntoContext(ctx, log.FromContext(ctx).WithValues("Node", klog.KRef("", node.Name)))
@@ -207,10 +232,150 @@ func (c *Controller) handleNodeClaim(ctx context.Context, msg messages.Message,
 		if zone != "" && instanceType != "" {
 			c.unavailableOfferingsCache.MarkUnavailable(ctx, string(msg.Kind()), ec2types.InstanceType(instanceType), zone, karpv1.CapacityTypeSpot)
 		}
+		if _, ok := nodeClaim.Annotations[v1.AnnotationRebalanceRecommendedAt]; ok {
+			RebalanceRecommendationsInterrupted.Inc(map[string]string{
+				metrics.NodePoolLabel: nodeClaim.Labels[karpv1.NodePoolLabelKey],
+			})
+		}
+	}
+	if msg.Kind() == messages.RebalanceRecommendationKind {
+		if err = c.recordRebalanceRecommendation(ctx, nodeClaim, action); err != nil {
+			return err
+		}
+	}
+	switch action {
+	case CordonAndDrain:
+		return c.deleteNodeClaim(ctx, msg, nodeClaim, node)
+	case ReplaceBeforeDrain:
+		return c.replaceNodeClaim(ctx, nodeClaim, node)
+	case ScheduledCordonAndDrain:
+		return c.scheduleNodeClaimDeletion(ctx, msg.(scheduledchange.Message), nodeClaim, node)
+	case Taint:
+		return c.taintNode(ctx, msg, node)
+	default:
+		return nil
+	}
+}
+
+// actionForNodeClaim retrieves the action for the message, which for rebalance recommendations depends on the
+// rebalance recommendation policy of the NodeClaim's EC2NodeClass
+func (c *Controller) actionForNodeClaim(ctx context.Context, msg messages.Message, nodeClaim *karpv1.NodeClaim) (Action, error) {
+	if msg.Kind() != messages.RebalanceRecommendationKind {
+		return actionForMessage(msg), nil
+	}
+	policy, err := c.rebalanceRecommendationPolicy(ctx, nodeClaim)
+	if err != nil {
+		return NoAction, err
+	}
+	if policy == v1.RebalanceRecommendationPolicyReplaceBeforeDrain {
+		return ReplaceBeforeDrain, nil
+	}
+	return NoAction, nil
+}
+
+// rebalanceRecommendationPolicy returns the rebalance recommendation policy of the NodeClaim's EC2NodeClass. NodeClaims
+// without an EC2NodeClass don't act on rebalance recommendations.
+func (c *Controller) rebalanceRecommendationPolicy(ctx context.Context, nodeClaim *karpv1.NodeClaim) (v1.RebalanceRecommendationPolicy, error) {
+	if nodeClaim.Spec.NodeClassRef == nil {
+		return v1.RebalanceRecommendationPolicyNone, nil
+	}
+	nodeClass := &v1.EC2NodeClass{}
+	if err := c.kubeClient.Get(ctx, client.ObjectKey{Name: nodeClaim.Spec.NodeClassRef.Name}, nodeClass); err != nil {
+		if errors.IsNotFound(err) {
+			return v1.RebalanceRecommendationPolicyNone, nil
+		}
+		return "", fmt.Errorf("getting ec2nodeclass, %w", err)
+	}
+	return lo.FromPtrOr(nodeClass.Spec.RebalanceRecommendationPolicy, v1.RebalanceRecommendationPolicyNone), nil
+}
+
+// recordRebalanceRecommendation records the time that the NodeClaim first received a rebalance recommendation, so that
+// rebalance recommendations which are followed by a spot interruption can be counted
+func (c *Controller) recordRebalanceRecommendation(ctx context.Context, nodeClaim *karpv1.NodeClaim, action Action) error {
+	if _, ok := nodeClaim.Annotations[v1.AnnotationRebalanceRecommendedAt]; ok {
+		return nil
+	}
+	stored := nodeClaim.DeepCopy()
+	patched := nodeClaim.DeepCopy()
+	patched.Annotations = lo.Assign(patched.Annotations, map[string]string{v1.AnnotationRebalanceRecommendedAt: c.clk.Now().UTC().Format(time.RFC3339)})
+	if err := c.kubeClient.Patch(ctx, patched, client.MergeFrom(stored)); err != nil {
+		return client.IgnoreNotFound(fmt.Errorf("patching nodeclaim, %w", err))
+	}
+	RebalanceRecommendations.Inc(map[string]string{
+		metrics.NodePoolLabel: nodeClaim.Labels[karpv1.NodePoolLabelKey],
+		actionLabel:           string(action),
+	})
+	return nil
+}
+
+// replaceNodeClaim launches a replacement for the NodeClaim, which is deleted once the replacement is ready
+func (c *Controller) replaceNodeClaim(ctx context.Context, nodeClaim *karpv1.NodeClaim, node *corev1.Node) error {
+	// NodeClaims which ran out of replacement attempts are drained when they're interrupted
+	if !nodeClaim.DeletionTimestamp.IsZero() || nodeClaim.Annotations[v1.AnnotationRebalanceReplacement] != "" || ReplacementAttempts(nodeClaim) >= MaxReplacementAttempts {
+		return nil
+	}
+	replacement, err := Replace(ctx, c.kubeClient, c.clk, nodeClaim)
+	if err != nil {
+		return fmt.Errorf("replacing nodeclaim, %w", err)
+	}
+	if replacement == nil {
+		return nil
+	}
+	// Steer the replacement away from the spot capacity pool which is at an elevated risk of interruption
+	zone := nodeClaim.Labels[corev1.LabelTopologyZone]
+	instanceType := nodeClaim.Labels[corev1.LabelInstanceTypeStable]
+	if nodeClaim.Labels[karpv1.CapacityTypeLabelKey] == karpv1.CapacityTypeSpot && zone != "" && instanceType != "" {
+		c.unavailableOfferingsCache.MarkUnavailable(ctx, string(messages.RebalanceRecommendationKind), ec2types.InstanceType(instanceType), zone, karpv1.CapacityTypeSpot)
+	}
+	log.FromContext(ctx).WithValues("replacement", klog.KRef("", replacement.Name)).Info("launching replacement from rebalance recommendation")
+	c.recorder.Publish(interruptionevents.ReplacingOnRebalanceRecommendation(node, nodeClaim, replacement)...)
+	return nil
+}
+
+// scheduleNodeClaimDeletion records when the NodeClaim is deleted ahead of the scheduled change, deleting it immediately
+// if that time has already come, the start of the scheduled change is unknown, or neither a lead time nor a maintenance
+// window is configured. The NodeClaim is deleted at the earliest time of all scheduled changes that affect it.
+func (c *Controller) scheduleNodeClaimDeletion(ctx context.Context, msg scheduledchange.Message, nodeClaim *karpv1.NodeClaim, node *corev1.Node) error {
+	if !nodeClaim.DeletionTimestamp.IsZero() {
+		return nil
+	}
+	nodePool := &karpv1.NodePool{}
+	if err := c.kubeClient.Get(ctx, client.ObjectKey{Name: nodeClaim.Labels[karpv1.NodePoolLabelKey]}, nodePool); client.IgnoreNotFound(err) != nil {
+		return fmt.Errorf("getting nodepool, %w", err)
+	}
+	window, err := NewMaintenanceWindow(nodePool)
+	if err != nil {
+		log.FromContext(ctx).WithValues("NodePool", klog.KRef("", nodePool.Name)).Error(err, "ignoring invalid maintenance window")
+	}
+	leadTime := options.FromContext(ctx).ScheduledChangeLeadTime
+	if leadTime == 0 {
+		if window == nil {
+			return c.deleteNodeClaim(ctx, msg, nodeClaim, node)
+		}
+		// Without a lead time, the NodeClaim is disrupted during the last maintenance window before the scheduled change
+		log.FromContext(ctx).WithValues("NodePool", klog.KRef("", nodePool.Name)).Info("maintenance window is set without a scheduled change lead time, disrupting before the start of the scheduled change")
+	}
+	start, _, err := msg.Window()
+	if err != nil {
+		// Without a start time the deletion can't be scheduled, so the NodeClaim is deleted as it would be without a lead time
+		log.FromContext(ctx).Error(err, "failed parsing scheduled change window, deleting immediately")
+		return c.deleteNodeClaim(ctx, msg, nodeClaim, node)
 	}
-	if action != NoAction {
+	disruptionTime := DisruptionTime(start, leadTime, window, c.clk.Now())
+	if !disruptionTime.After(c.clk.Now()) {
 		return c.deleteNodeClaim(ctx, msg, nodeClaim, node)
 	}
+	if scheduled, err := time.Parse(time.RFC3339, nodeClaim.Annotations[v1.AnnotationScheduledChangeDisruptionTime]); err == nil && !scheduled.After(disruptionTime) {
+		return nil
+	}
+	stored := nodeClaim.DeepCopy()
+	patched := nodeClaim.DeepCopy()
+	patched.Annotations = lo.Assign(patched.Annotations, map[string]string{v1.AnnotationScheduledChangeDisruptionTime: disruptionTime.UTC().Format(time.RFC3339)})
+	if err = c.kubeClient.Patch(ctx, patched, client.MergeFrom(stored)); err != nil {
+		return client.IgnoreNotFound(fmt.Errorf("patching nodeclaim, %w", err))
+	}
+	log.FromContext(ctx).WithValues("disruption-time", disruptionTime, "scheduled-change-start-time", start).Info("scheduled delete from interruption message")
+	c.recorder.Publish(interruptionevents.ScheduledTermination(node, nodeClaim, disruptionTime)...)
 	return nil
 }
 
@@ -232,6 +397,24 @@ func (c *Controller) deleteNodeClaim(ctx context.Context, msg messages.Message,
 	return nil
 }
 
+// taintNode taints the Node with the interruption rule that matched the message
+func (c *Controller) taintNode(ctx context.Context, msg messages.Message, node *corev1.Node) error {
+	if node == nil || !node.DeletionTimestamp.IsZero() {
+		return nil
+	}
+	taint := corev1.Taint{Key: v1.InterruptionRuleTaintKey, Value: string(msg.Kind()), Effect: corev1.TaintEffectNoSchedule}
+	if lo.ContainsBy(node.Spec.Taints, func(t corev1.Taint) bool { return t.MatchTaint(&taint) && t.Value == taint.Value }) {
+		return nil
+	}
+	stored := node.DeepCopy()
+	node.Spec.Taints = append(lo.Reject(node.Spec.Taints, func(t corev1.Taint, _ int) bool { return t.MatchTaint(&taint) }), taint)
+	if err := c.kubeClient.Patch(ctx, node, client.StrategicMergeFrom(stored)); err != nil {
+		return client.IgnoreNotFound(fmt.Errorf("tainting node, %w", err))
+	}
+	log.FromContext(ctx).Info("tainted node from interruption message")
+	return nil
+}
+
 // notifyForMessage publishes the relevant alert based on the message kind
 func (c *Controller) notifyForMessage(msg messages.Message, nodeClaim *karpv1.NodeClaim, n *corev1.Node) {
 	switch msg.Kind() {
@@ -251,6 +434,9 @@ func (c *Controller) notifyForMessage(msg messages.Message, nodeClaim *karpv1.No
 		c.recorder.Publish(interruptionevents.Terminating(n, nodeClaim)...)
 
 	default:
+		if m, ok := msg.(rule.Message); ok {
+			c.recorder.Publish(interruptionevents.InterruptionRuleMatched(n, nodeClaim, m.Rule.Name)...)
+		}
 	}
 }
 
@@ -297,6 +483,22 @@ func (c *Controller) makeNodeInstanceIDMap(ctx context.Context) (map[string]*cor
 }
 
 func actionForMessage(msg messages.Message) Action {
+	// Scheduled changes with a known start time are acted on ahead of the start time, rather than immediately
+	if scheduledChange, ok := msg.(scheduledchange.Message); ok {
+		if _, _, err := scheduledChange.Window(); err == nil {
+			return ScheduledCordonAndDrain
+		}
+	}
+	if m, ok := msg.(rule.Message); ok {
+		switch m.Rule.Action {
+		case rule.ActionCordonAndDrain:
+			return CordonAndDrain
+		case rule.ActionTaint:
+			return Taint
+		default:
+			return NoAction
+		}
+	}
 	switch msg.Kind() {
 	case messages.ScheduledChangeKind, messages.SpotInterruptionKind, messages.InstanceStoppedKind, messages.InstanceTerminatedKind:
 		return CordonAndDrain
@@ -15,6 +15,9 @@ limitations under the License.
 package events
 
 import (
+	"fmt"
+	"time"
+
 	corev1 "k8s.io/api/core/v1"
 
 	karpv1 "sigs.k8s.io/karpenter/pkg/apis/v1"
@@ -61,6 +64,46 @@ func RebalanceRecommendation(node *corev1.Node, nodeClaim *karpv1.NodeClaim) (ev
 	return evts
 }
 
+func ReplacingOnRebalanceRecommendation(node *corev1.Node, nodeClaim *karpv1.NodeClaim, replacement *karpv1.NodeClaim) (evts []events.Event) {
+	evts = append(evts, events.Event{
+		InvolvedObject: nodeClaim,
+		Type:           corev1.EventTypeNormal,
+		Reason:         "ReplacingOnRebalanceRecommendation",
+		Message:        fmt.Sprintf("Launching replacement NodeClaim %s before draining the NodeClaim", replacement.Name),
+		DedupeValues:   []string{string(nodeClaim.UID)},
+	})
+	if node != nil {
+		evts = append(evts, events.Event{
+			InvolvedObject: node,
+			Type:           corev1.EventTypeNormal,
+			Reason:         "ReplacingOnRebalanceRecommendation",
+			Message:        fmt.Sprintf("Launching replacement NodeClaim %s before draining the Node", replacement.Name),
+			DedupeValues:   []string{string(node.UID)},
+		})
+	}
+	return evts
+}
+
+func ScheduledTermination(node *corev1.Node, nodeClaim *karpv1.NodeClaim, disruptionTime time.Time) (evts []events.Event) {
+	evts = append(evts, events.Event{
+		InvolvedObject: nodeClaim,
+		Type:           corev1.EventTypeNormal,
+		Reason:         "ScheduledTerminationOnInterruption",
+		Message:        fmt.Sprintf("Scheduled change will terminate the NodeClaim at %s", disruptionTime.UTC().Format(time.RFC3339)),
+		DedupeValues:   []string{string(nodeClaim.UID)},
+	})
+	if node != nil {
+		evts = append(evts, events.Event{
+			InvolvedObject: node,
+			Type:           corev1.EventTypeNormal,
+			Reason:         "ScheduledTerminationOnInterruption",
+			Message:        fmt.Sprintf("Scheduled change will terminate the Node at %s", disruptionTime.UTC().Format(time.RFC3339)),
+			DedupeValues:   []string{string(node.UID)},
+		})
+	}
+	return evts
+}
+
 func Stopping(node *corev1.Node, nodeClaim *karpv1.NodeClaim) (evts []events.Event) {
 	evts = append(evts, events.Event{
 		InvolvedObject: nodeClaim,
@@ -140,3 +183,23 @@ func TerminatingOnInterruption(node *corev1.Node, nodeClaim *karpv1.NodeClaim) (
 	}
 	return evts
 }
+
+func InterruptionRuleMatched(node *corev1.Node, nodeClaim *karpv1.NodeClaim, rule string) (evts []events.Event) {
+	evts = append(evts, events.Event{
+		InvolvedObject: nodeClaim,
+		Type:           corev1.EventTypeWarning,
+		Reason:         "InterruptionRuleMatched",
+		Message:        fmt.Sprintf("Interruption rule %q was triggered", rule),
+		DedupeValues:   []string{string(nodeClaim.UID), rule},
+	})
+	if node != nil {
+		evts = append(evts, events.Event{
+			InvolvedObject: node,
+			Type:           corev1.EventTypeWarning,
+			Reason:         "InterruptionRuleMatched",
+			Message:        fmt.Sprintf("Interruption rule %q was triggered", rule),
+			DedupeValues:   []string{string(node.UID), rule},
+		})
+	}
+	return evts
+}
@@ -0,0 +1,82 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package interruption
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/robfig/cron/v3"
+
+	karpv1 "sigs.k8s.io/karpenter/pkg/apis/v1"
+
+	v1 "github.com/aws/karpenter-provider-aws/pkg/apis/v1"
+)
+
+// MaintenanceWindow is a recurring window, defined by a NodePool, in which its NodeClaims are disrupted for scheduled
+// changes
+type MaintenanceWindow struct {
+	schedule cron.Schedule
+	duration time.Duration
+}
+
+// NewMaintenanceWindow returns the maintenance window of the NodePool, or nil if it doesn't define one
+func NewMaintenanceWindow(nodePool *karpv1.NodePool) (*MaintenanceWindow, error) {
+	rawSchedule, hasSchedule := nodePool.Annotations[v1.AnnotationMaintenanceWindowSchedule]
+	rawDuration, hasDuration := nodePool.Annotations[v1.AnnotationMaintenanceWindowDuration]
+	if !hasSchedule && !hasDuration {
+		return nil, nil
+	}
+	if !hasSchedule || !hasDuration {
+		return nil, fmt.Errorf("both %s and %s must be set", v1.AnnotationMaintenanceWindowSchedule, v1.AnnotationMaintenanceWindowDuration)
+	}
+	schedule, err := cron.ParseStandard(fmt.Sprintf("TZ=UTC %s", rawSchedule))
+	if err != nil {
+		return nil, fmt.Errorf("parsing schedule, %w", err)
+	}
+	duration, err := time.ParseDuration(rawDuration)
+	if err != nil {
+		return nil, fmt.Errorf("parsing duration, %w", err)
+	}
+	if duration <= 0 {
+		return nil, fmt.Errorf("duration must be positive")
+	}
+	return &MaintenanceWindow{schedule: schedule, duration: duration}, nil
+}
+
+// DisruptionTime returns when a NodeClaim is disrupted for a scheduled change which starts at the start time. NodeClaims
+// are disrupted the lead time before the scheduled change, or earlier during the last maintenance window before then.
+// NodeClaims are disrupted immediately when the lead time has already passed.
+func DisruptionTime(start time.Time, leadTime time.Duration, window *MaintenanceWindow, now time.Time) time.Time {
+	deadline := start.Add(-leadTime)
+	if !deadline.After(now) {
+		return now
+	}
+	if window == nil {
+		return deadline
+	}
+	// Walk back the duration of the window so that a window which is already open is considered
+	var latest time.Time
+	for next := window.schedule.Next(now.Add(-window.duration)); !next.IsZero() && !next.After(deadline); next = window.schedule.Next(next) {
+		latest = next
+	}
+	if latest.IsZero() {
+		return deadline
+	}
+	if latest.Before(now) {
+		return now
+	}
+	return latest
+}
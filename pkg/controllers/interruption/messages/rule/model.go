@@ -0,0 +1,35 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rule
+
+import (
+	"github.com/aws/karpenter-provider-aws/pkg/controllers/interruption/messages"
+)
+
+// Message is an event that was matched by a user-defined interruption rule
+type Message struct {
+	messages.Metadata
+
+	Rule        Rule     `json:"-"`
+	InstanceIDs []string `json:"-"`
+}
+
+func (m Message) EC2InstanceIDs() []string {
+	return m.InstanceIDs
+}
+
+func (m Message) Kind() messages.Kind {
+	return messages.Kind(m.Rule.Name)
+}
@@ -0,0 +1,82 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rule
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/samber/lo"
+	"k8s.io/client-go/util/jsonpath"
+
+	"github.com/aws/karpenter-provider-aws/pkg/controllers/interruption/messages"
+)
+
+type Parser struct {
+	Rule Rule
+}
+
+func (p Parser) Parse(raw string) (messages.Message, error) {
+	msg := Message{Rule: p.Rule}
+	if err := json.Unmarshal([]byte(raw), &msg); err != nil {
+		return nil, fmt.Errorf("unmarshalling the message for interruption rule %q, %w", p.Rule.Name, err)
+	}
+	var event interface{}
+	if err := json.Unmarshal([]byte(raw), &event); err != nil {
+		return nil, fmt.Errorf("unmarshalling the message for interruption rule %q, %w", p.Rule.Name, err)
+	}
+	// A JSONPath keeps state while finding results, so one is created for each message that is parsed concurrently
+	path := jsonpath.New(p.Rule.Name).AllowMissingKeys(true)
+	if err := path.Parse(p.Rule.InstanceIDsPath); err != nil {
+		return nil, fmt.Errorf("parsing instanceIDsPath of interruption rule %q, %w", p.Rule.Name, err)
+	}
+	results, err := path.FindResults(event)
+	if err != nil {
+		return nil, fmt.Errorf("finding instance ids for interruption rule %q, %w", p.Rule.Name, err)
+	}
+	for _, result := range results {
+		for _, value := range result {
+			if id, ok := instanceID(value.Interface()); ok {
+				msg.InstanceIDs = append(msg.InstanceIDs, id)
+			}
+		}
+	}
+	msg.InstanceIDs = lo.Uniq(msg.InstanceIDs)
+	return msg, nil
+}
+
+// instanceID returns the instance ID of a value that is either an instance ID or an instance ARN
+// such as arn:aws:ec2:us-west-2:000000000000:instance/i-0123456789abcdef0
+func instanceID(value interface{}) (string, bool) {
+	s, ok := value.(string)
+	if !ok {
+		return "", false
+	}
+	s = s[strings.LastIndex(s, "/")+1:]
+	return s, strings.HasPrefix(s, "i-")
+}
+
+func (p Parser) Version() string {
+	return p.Rule.Version
+}
+
+func (p Parser) Source() string {
+	return p.Rule.Source
+}
+
+func (p Parser) DetailType() string {
+	return p.Rule.DetailType
+}
@@ -0,0 +1,165 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rule
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+
+	"github.com/samber/lo"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/util/jsonpath"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/yaml"
+
+	"github.com/aws/karpenter-provider-aws/pkg/controllers/interruption/messages"
+	"github.com/aws/karpenter-provider-aws/pkg/controllers/interruption/messages/rebalancerecommendation"
+	"github.com/aws/karpenter-provider-aws/pkg/controllers/interruption/messages/scheduledchange"
+	"github.com/aws/karpenter-provider-aws/pkg/controllers/interruption/messages/spotinterruption"
+	"github.com/aws/karpenter-provider-aws/pkg/controllers/interruption/messages/statechange"
+)
+
+// RulesKey is the key of the ConfigMap's data that holds the interruption rules
+const RulesKey = "rules"
+
+type Action string
+
+const (
+	// ActionCordonAndDrain cordons, drains and terminates the nodes of the affected instances
+	ActionCordonAndDrain Action = "CordonAndDrain"
+	// ActionTaint taints the nodes of the affected instances, so that no new pods are scheduled to them. The taint is kept
+	// until the rule is removed or its action changes, or until it's removed by the user.
+	ActionTaint Action = "Taint"
+	// ActionEventOnly only publishes an event for the affected instances
+	ActionEventOnly Action = "EventOnly"
+)
+
+var nameRegex = regexp.MustCompile(`^[a-z0-9]([-a-z0-9_]{0,61}[a-z0-9])?$`)
+
+// builtInKinds can't be used as rule names, since the name of a rule is the kind of the messages that it parses
+var builtInKinds = []messages.Kind{
+	messages.RebalanceRecommendationKind,
+	messages.ScheduledChangeKind,
+	messages.SpotInterruptionKind,
+	messages.InstanceStoppedKind,
+	messages.InstanceTerminatedKind,
+	messages.NoOpKind,
+}
+
+// builtInParsers parse the events that rules can't match, since these events are always parsed by the built-in parsers
+var builtInParsers = []messages.Parser{
+	statechange.Parser{},
+	spotinterruption.Parser{},
+	scheduledchange.Parser{},
+	rebalancerecommendation.Parser{},
+}
+
+// Rule routes the EventBridge events that match its source and detail type into the interruption controller. The
+// affected instances are read from the event with a JSONPath expression, which may select instance IDs or instance ARNs.
+type Rule struct {
+	// Name identifies the rule in events, metrics and taints
+	Name       string `json:"name"`
+	Source     string `json:"source"`
+	DetailType string `json:"detailType"`
+	// Version is the version of the event schema, which defaults to "0" as used by all AWS services
+	Version string `json:"version,omitempty"`
+	// InstanceIDsPath is a JSONPath expression selecting the affected instances, e.g. {.detail.instance-id} or {.resources[*]}
+	InstanceIDsPath string `json:"instanceIDsPath"`
+	Action          Action `json:"action"`
+}
+
+func (r Rule) validate() error {
+	if !nameRegex.MatchString(r.Name) {
+		return fmt.Errorf("name must consist of at most 63 lower case alphanumeric characters, '-' or '_', and start and end with an alphanumeric character")
+	}
+	if lo.Contains(builtInKinds, messages.Kind(r.Name)) {
+		return fmt.Errorf("name %q is reserved", r.Name)
+	}
+	if r.Source == "" || r.DetailType == "" {
+		return fmt.Errorf("source and detailType must be set")
+	}
+	if lo.ContainsBy(builtInParsers, func(p messages.Parser) bool {
+		return p.Version() == r.Version && p.Source() == r.Source && p.DetailType() == r.DetailType
+	}) {
+		return fmt.Errorf("source %q and detailType %q are matched by a built-in interruption event", r.Source, r.DetailType)
+	}
+	if err := jsonpath.New(r.Name).Parse(r.InstanceIDsPath); err != nil || r.InstanceIDsPath == "" {
+		return fmt.Errorf("instanceIDsPath must be a valid JSONPath expression")
+	}
+	if !lo.Contains([]Action{ActionCordonAndDrain, ActionTaint, ActionEventOnly}, r.Action) {
+		return fmt.Errorf("action must be one of %q, %q or %q", ActionCordonAndDrain, ActionTaint, ActionEventOnly)
+	}
+	return nil
+}
+
+// ParseRules parses and validates a YAML or JSON list of rules
+func ParseRules(data string) ([]Rule, error) {
+	var rules []Rule
+	if err := yaml.UnmarshalStrict([]byte(data), &rules); err != nil {
+		return nil, fmt.Errorf("parsing interruption rules, %w", err)
+	}
+	names := map[string]struct{}{}
+	events := map[[3]string]struct{}{}
+	for i := range rules {
+		rules[i].Version = lo.CoalesceOrEmpty(rules[i].Version, "0")
+		if err := rules[i].validate(); err != nil {
+			return nil, fmt.Errorf("validating interruption rule %d, %w", i, err)
+		}
+		if _, ok := names[rules[i].Name]; ok {
+			return nil, fmt.Errorf("validating interruption rule %d, name %q is used by another rule", i, rules[i].Name)
+		}
+		event := [3]string{rules[i].Version, rules[i].Source, rules[i].DetailType}
+		if _, ok := events[event]; ok {
+			return nil, fmt.Errorf("validating interruption rule %d, source %q and detailType %q are matched by another rule", i, rules[i].Source, rules[i].DetailType)
+		}
+		names[rules[i].Name] = struct{}{}
+		events[event] = struct{}{}
+	}
+	return rules, nil
+}
+
+type Provider interface {
+	Rules(context.Context) ([]Rule, error)
+}
+
+// ConfigMapProvider reads interruption rules from a ConfigMap. A missing ConfigMap, or a ConfigMap without rules,
+// results in no rules.
+type ConfigMapProvider struct {
+	kubeClient client.Client
+	namespace  string
+	name       string
+}
+
+func NewConfigMapProvider(kubeClient client.Client, namespace, name string) *ConfigMapProvider {
+	return &ConfigMapProvider{
+		kubeClient: kubeClient,
+		namespace:  namespace,
+		name:       name,
+	}
+}
+
+func (p *ConfigMapProvider) Rules(ctx context.Context) ([]Rule, error) {
+	cm := &corev1.ConfigMap{}
+	if err := p.kubeClient.Get(ctx, types.NamespacedName{Namespace: p.namespace, Name: p.name}, cm); err != nil {
+		if errors.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("getting configmap, %w", err)
+	}
+	return ParseRules(cm.Data[RulesKey])
+}
@@ -15,9 +15,16 @@ limitations under the License.
 package scheduledchange
 
 import (
+	"fmt"
+	"time"
+
 	"github.com/aws/karpenter-provider-aws/pkg/controllers/interruption/messages"
 )
 
+// timeLayouts are the layouts of the start and end times of AWS Health events, which are usually formatted like
+// "Sat, 05 Jun 2021 02:00:00 GMT"
+var timeLayouts = []string{time.RFC1123, time.RFC1123Z, time.RFC3339}
+
 // Message contains the properties defined in AWS EventBridge schema
 // aws.health@AWSHealthEvent v0.
 type Message struct {
@@ -38,6 +45,32 @@ func (Message) Kind() messages.Kind {
 	return messages.ScheduledChangeKind
 }
 
+// Window returns the start and end time of the scheduled change. The end time is zero when the scheduled change doesn't
+// have one.
+func (m Message) Window() (time.Time, time.Time, error) {
+	start, err := parseTime(m.Detail.StartTime)
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("parsing start time, %w", err)
+	}
+	if m.Detail.EndTime == "" {
+		return start, time.Time{}, nil
+	}
+	end, err := parseTime(m.Detail.EndTime)
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("parsing end time, %w", err)
+	}
+	return start, end, nil
+}
+
+func parseTime(value string) (time.Time, error) {
+	for _, layout := range timeLayouts {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("unrecognized time %q", value)
+}
+
 type Detail struct {
 	EventARN          string             `json:"eventArn"`
 	EventTypeCode     string             `json:"eventTypeCode"`
@@ -25,6 +25,7 @@ import (
 const (
 	interruptionSubsystem = "interruption"
 	messageTypeLabel      = "message_type"
+	actionLabel           = "action"
 )
 
 var (
@@ -59,4 +60,24 @@ var (
 		},
 		[]string{},
 	)
+	RebalanceRecommendations = opmetrics.NewPrometheusCounter(
+		crmetrics.Registry,
+		prometheus.CounterOpts{
+			Namespace: metrics.Namespace,
+			Subsystem: interruptionSubsystem,
+			Name:      "rebalance_recommendations_total",
+			Help:      "Count of NodeClaims that received a spot rebalance recommendation. Broken down by nodepool and the action taken.",
+		},
+		[]string{metrics.NodePoolLabel, actionLabel},
+	)
+	RebalanceRecommendationsInterrupted = opmetrics.NewPrometheusCounter(
+		crmetrics.Registry,
+		prometheus.CounterOpts{
+			Namespace: metrics.Namespace,
+			Subsystem: interruptionSubsystem,
+			Name:      "rebalance_recommendations_interrupted_total",
+			Help:      "Count of NodeClaims that received a spot interruption warning after a spot rebalance recommendation. Broken down by nodepool.",
+		},
+		[]string{metrics.NodePoolLabel},
+	)
 )
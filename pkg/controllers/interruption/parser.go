@@ -15,9 +15,12 @@ limitations under the License.
 package interruption
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
+	"sync"
 
+	"github.com/aws/aws-sdk-go-v2/aws/arn"
 	"github.com/samber/lo"
 
 	"github.com/aws/karpenter-provider-aws/pkg/controllers/interruption/messages"
@@ -26,6 +29,7 @@ import (
 	"github.com/aws/karpenter-provider-aws/pkg/controllers/interruption/messages/scheduledchange"
 	"github.com/aws/karpenter-provider-aws/pkg/controllers/interruption/messages/spotinterruption"
 	"github.com/aws/karpenter-provider-aws/pkg/controllers/interruption/messages/statechange"
+	"github.com/aws/karpenter-provider-aws/pkg/operator/options"
 )
 
 type parserKey struct {
@@ -59,8 +63,13 @@ var (
 	}
 )
 
+// EventParser parses messages with the parser for their kind of event. Parsers for user-defined interruption rules can
+// be replaced at runtime, but can't redefine the kinds of events that the EventParser was created with.
 type EventParser struct {
 	parserMap map[parserKey]messages.Parser
+
+	mu            sync.RWMutex
+	ruleParserMap map[parserKey]messages.Parser
 }
 
 func NewEventParser(parsers ...messages.Parser) *EventParser {
@@ -71,15 +80,50 @@ func NewEventParser(parsers ...messages.Parser) *EventParser {
 	}
 }
 
-func (p EventParser) Parse(msg string) (messages.Message, error) {
+// snsNotification is the envelope of a message which is delivered to an SQS queue by an SNS topic without raw message
+// delivery
+type snsNotification struct {
+	Type      string `json:"Type"`
+	MessageID string `json:"MessageId"`
+	TopicArn  string `json:"TopicArn"`
+	Message   string `json:"Message"`
+}
+
+// SetRuleParsers replaces the parsers for user-defined interruption rules
+func (p *EventParser) SetRuleParsers(parsers ...messages.Parser) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.ruleParserMap = lo.SliceToMap(parsers, func(p messages.Parser) (parserKey, messages.Parser) {
+		return newParserKeyFromParser(p), p
+	})
+}
+
+func (p *EventParser) parserFor(md messages.Metadata) (messages.Parser, bool) {
+	if parser, ok := p.parserMap[newParserKey(md)]; ok {
+		return parser, true
+	}
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	parser, ok := p.ruleParserMap[newParserKey(md)]
+	return parser, ok
+}
+
+func (p *EventParser) Parse(ctx context.Context, msg string) (messages.Message, error) {
 	if msg == "" {
 		return noop.Message{}, nil
 	}
+	msg, err := unwrapSNSNotification(ctx, msg)
+	if err != nil {
+		return noop.Message{}, err
+	}
 	md := messages.Metadata{}
 	if err := json.Unmarshal([]byte(msg), &md); err != nil {
 		return noop.Message{}, fmt.Errorf("unmarshalling the message as Metadata, %w", err)
 	}
-	if parser, ok := p.parserMap[newParserKey(md)]; ok {
+	if err := validateMetadata(ctx, md); err != nil {
+		return noop.Message{}, err
+	}
+	if parser, ok := p.parserFor(md); ok {
 		evt, err := parser.Parse(msg)
 		if err != nil {
 			return noop.Message{}, fmt.Errorf("parsing event message, %w", err)
@@ -91,3 +135,39 @@ func (p EventParser) Parse(msg string) (messages.Message, error) {
 	}
 	return noop.Message{Metadata: md}, nil
 }
+
+// unwrapSNSNotification returns the message of an SNS notification after verifying that its topic is in an allowed
+// account and region. Messages which aren't SNS notifications are returned as they are.
+func unwrapSNSNotification(ctx context.Context, msg string) (string, error) {
+	notification := snsNotification{}
+	if err := json.Unmarshal([]byte(msg), &notification); err != nil {
+		return "", fmt.Errorf("unmarshalling the message as an SNS notification, %w", err)
+	}
+	if notification.Type != "Notification" || notification.TopicArn == "" {
+		return msg, nil
+	}
+	topic, err := arn.Parse(notification.TopicArn)
+	if err != nil {
+		return "", fmt.Errorf("parsing SNS topic ARN, %w", err)
+	}
+	if accounts := options.FromContext(ctx).InterruptionAllowedAccountList(); len(accounts) > 0 && !lo.Contains(accounts, topic.AccountID) {
+		return "", fmt.Errorf("SNS topic %q isn't in an allowed account", notification.TopicArn)
+	}
+	if regions := options.FromContext(ctx).InterruptionAllowedRegionList(); len(regions) > 0 && !lo.Contains(regions, topic.Region) {
+		return "", fmt.Errorf("SNS topic %q isn't in an allowed region", notification.TopicArn)
+	}
+	return notification.Message, nil
+}
+
+// validateMetadata verifies that the event is from an allowed account and region. Every event is checked, whether it was
+// delivered directly by EventBridge or wrapped in an SNS notification, since an allowed topic can still forward events
+// from other accounts.
+func validateMetadata(ctx context.Context, md messages.Metadata) error {
+	if accounts := options.FromContext(ctx).InterruptionAllowedAccountList(); len(accounts) > 0 && !lo.Contains(accounts, md.Account) {
+		return fmt.Errorf("event %q from account %q isn't in an allowed account", md.ID, md.Account)
+	}
+	if regions := options.FromContext(ctx).InterruptionAllowedRegionList(); len(regions) > 0 && !lo.Contains(regions, md.Region) {
+		return fmt.Errorf("event %q from region %q isn't in an allowed region", md.ID, md.Region)
+	}
+	return nil
+}
@@ -0,0 +1,342 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package interruption
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/awslabs/operatorpkg/singleton"
+	"github.com/samber/lo"
+	"go.uber.org/multierr"
+	"k8s.io/client-go/util/workqueue"
+	"k8s.io/utils/clock"
+	controllerruntime "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/karpenter/pkg/cloudprovider"
+	"sigs.k8s.io/karpenter/pkg/events"
+	"sigs.k8s.io/karpenter/pkg/operator/injection"
+
+	sdk "github.com/aws/karpenter-provider-aws/pkg/aws"
+	"github.com/aws/karpenter-provider-aws/pkg/batcher"
+	awscache "github.com/aws/karpenter-provider-aws/pkg/cache"
+	"github.com/aws/karpenter-provider-aws/pkg/controllers/interruption/messages"
+	"github.com/aws/karpenter-provider-aws/pkg/controllers/interruption/messages/scheduledchange"
+	"github.com/aws/karpenter-provider-aws/pkg/controllers/interruption/messages/spotinterruption"
+	"github.com/aws/karpenter-provider-aws/pkg/controllers/interruption/messages/statechange"
+	awserrors "github.com/aws/karpenter-provider-aws/pkg/errors"
+	"github.com/aws/karpenter-provider-aws/pkg/operator/options"
+)
+
+// describeInstancesBatchSize is the number of instances which are described in a single DescribeInstances request
+// by the DescribeInstancesBatcher
+const describeInstancesBatchSize = 500
+
+var (
+	// scheduledEventCodes are the codes of the scheduled events which disrupt an instance
+	scheduledEventCodes = []ec2types.EventCode{
+		ec2types.EventCodeInstanceReboot,
+		ec2types.EventCodeSystemReboot,
+		ec2types.EventCodeSystemMaintenance,
+		ec2types.EventCodeInstanceRetirement,
+		ec2types.EventCodeInstanceStop,
+	}
+	// spotInterruptionStatusCodes are the status codes of spot instance requests whose instance is being interrupted
+	spotInterruptionStatusCodes = []string{"marked-for-termination", "marked-for-stop"}
+	// interruptedInstanceStates are the states of instances which are stopped or terminated
+	interruptedInstanceStates = []ec2types.InstanceStateName{
+		ec2types.InstanceStateNameStopping,
+		ec2types.InstanceStateNameStopped,
+		ec2types.InstanceStateNameShuttingDown,
+		ec2types.InstanceStateNameTerminated,
+	}
+)
+
+// PollingController is an AWS interruption controller for accounts which can't deliver EventBridge events to SQS.
+// It periodically polls the EC2 API for scheduled events, spot interruptions and stopped or terminated instances,
+// and acts on them as the same messages which are received from the interruption queue.
+type PollingController struct {
+	controller               *Controller
+	ec2api                   sdk.EC2API
+	describeInstancesBatcher *batcher.DescribeInstancesBatcher
+	handled                  *awscache.PolledInterruptions
+	// offset is the position in the sorted instances that the next poll starts checking instance states from, when
+	// the API budget doesn't cover every instance in a single poll
+	offset int
+}
+
+func NewPollingController(
+	kubeClient client.Client,
+	cloudProvider cloudprovider.CloudProvider,
+	clk clock.Clock,
+	recorder events.Recorder,
+	ec2api sdk.EC2API,
+	describeInstancesBatcher *batcher.DescribeInstancesBatcher,
+	unavailableOfferingsCache *awscache.UnavailableOfferings,
+	handled *awscache.PolledInterruptions,
+) *PollingController {
+	return &PollingController{
+		controller:               NewController("interruption.polling", kubeClient, cloudProvider, clk, recorder, nil, nil, unavailableOfferingsCache),
+		ec2api:                   ec2api,
+		describeInstancesBatcher: describeInstancesBatcher,
+		handled:                  handled,
+	}
+}
+
+// polledMessage is an interruption found by polling the EC2 API, along with a key which identifies the interruption
+// across polls
+type polledMessage struct {
+	key     string
+	message messages.Message
+}
+
+func (c *PollingController) Reconcile(ctx context.Context) (reconcile.Result, error) {
+	ctx = injection.WithControllerName(ctx, "interruption.polling")
+	requeueAfter := options.FromContext(ctx).InterruptionPollingInterval
+	nodeClaimInstanceIDMap, err := c.controller.makeNodeClaimInstanceIDMap(ctx)
+	if err != nil {
+		return reconcile.Result{}, fmt.Errorf("making nodeclaim instance id map, %w", err)
+	}
+	if len(nodeClaimInstanceIDMap) == 0 {
+		return reconcile.Result{RequeueAfter: requeueAfter}, nil
+	}
+	nodeInstanceIDMap, err := c.controller.makeNodeInstanceIDMap(ctx)
+	if err != nil {
+		return reconcile.Result{}, fmt.Errorf("making node instance id map, %w", err)
+	}
+	polledMessages, pollErr := c.poll(ctx, lo.Keys(nodeClaimInstanceIDMap))
+	errs := make([]error, len(polledMessages))
+	workqueue.ParallelizeUntil(ctx, 10, len(polledMessages), func(i int) {
+		if e := c.controller.handleMessage(ctx, nodeClaimInstanceIDMap, nodeInstanceIDMap, polledMessages[i].message); e != nil {
+			errs[i] = fmt.Errorf("handling message, %w", e)
+			return
+		}
+		c.handled.MarkHandled(polledMessages[i].key)
+	})
+	if err = multierr.Combine(append(errs, pollErr)...); err != nil {
+		return reconcile.Result{}, err
+	}
+	return reconcile.Result{RequeueAfter: requeueAfter}, nil
+}
+
+func (c *PollingController) Register(_ context.Context, m manager.Manager) error {
+	return controllerruntime.NewControllerManagedBy(m).
+		Named("interruption.polling").
+		WatchesRawSource(singleton.Source()).
+		Complete(singleton.AsReconciler(c))
+}
+
+// poll returns the interruptions of the instances which haven't been acted on yet. Scheduled events and spot
+// interruptions are looked up first, and the rest of the API budget is spent on checking the states of the instances.
+func (c *PollingController) poll(ctx context.Context, instanceIDs []string) ([]polledMessage, error) {
+	budget := options.FromContext(ctx).InterruptionPollingAPIBudget
+	managed := lo.SliceToMap(instanceIDs, func(id string) (string, struct{}) { return id, struct{}{} })
+
+	// Each lookup leaves at least one request for the lookups after it, so that a large number of scheduled events
+	// or spot instance requests doesn't stop the others from being polled
+	scheduledChanges, requests, scheduledChangesErr := c.scheduledChangeMessages(ctx, managed, budget-2)
+	budget -= requests
+	spotInterruptions, requests, spotInterruptionsErr := c.spotInterruptionMessages(ctx, managed, budget-1)
+	budget -= requests
+	stateChanges, stateChangesErr := c.stateChangeMessages(ctx, instanceIDs, budget)
+
+	polledMessages := lo.Filter(lo.Flatten([][]polledMessage{scheduledChanges, spotInterruptions, stateChanges}), func(m polledMessage, _ int) bool {
+		return !c.handled.IsHandled(m.key)
+	})
+	return polledMessages, multierr.Combine(scheduledChangesErr, spotInterruptionsErr, stateChangesErr)
+}
+
+// scheduledChangeMessages returns the upcoming scheduled events of the managed instances, making at most maxRequests
+// DescribeInstanceStatus requests. It also returns the number of requests which were made.
+func (c *PollingController) scheduledChangeMessages(ctx context.Context, managed map[string]struct{}, maxRequests int) ([]polledMessage, int, error) {
+	var polledMessages []polledMessage
+	paginator := ec2.NewDescribeInstanceStatusPaginator(c.ec2api, &ec2.DescribeInstanceStatusInput{
+		Filters: []ec2types.Filter{{
+			Name:   aws.String("event.code"),
+			Values: lo.Map(scheduledEventCodes, func(code ec2types.EventCode, _ int) string { return string(code) }),
+		}},
+		MaxResults: aws.Int32(1000),
+	})
+	requests := 0
+	for ; paginator.HasMorePages() && requests < maxRequests; requests++ {
+		out, err := paginator.NextPage(ctx)
+		if err != nil {
+			return polledMessages, requests + 1, fmt.Errorf("describing instance status, %w", err)
+		}
+		for _, status := range out.InstanceStatuses {
+			instanceID := aws.ToString(status.InstanceId)
+			if _, ok := managed[instanceID]; !ok {
+				continue
+			}
+			for _, event := range status.Events {
+				// Events which have completed or been canceled are still reported for a while, with their description
+				// marked as such
+				description := aws.ToString(event.Description)
+				if strings.HasPrefix(description, "[Completed]") || strings.HasPrefix(description, "[Canceled]") {
+					continue
+				}
+				polledMessages = append(polledMessages, c.scheduledChangeMessage(instanceID, event))
+			}
+		}
+	}
+	if paginator.HasMorePages() {
+		log.FromContext(ctx).V(1).Info("api budget exhausted before all scheduled events were polled")
+	}
+	return polledMessages, requests, nil
+}
+
+func (c *PollingController) scheduledChangeMessage(instanceID string, event ec2types.InstanceStatusEvent) polledMessage {
+	detail := scheduledchange.Detail{
+		Service:           "EC2",
+		EventTypeCategory: "scheduledChange",
+		EventDescription:  []scheduledchange.EventDescription{{LatestDescription: aws.ToString(event.Description), Language: "en_US"}},
+		AffectedEntities:  []scheduledchange.AffectedEntity{{EntityValue: instanceID}},
+	}
+	if event.NotBefore != nil {
+		detail.StartTime = event.NotBefore.UTC().Format(time.RFC3339)
+	}
+	if event.NotAfter != nil {
+		detail.EndTime = event.NotAfter.UTC().Format(time.RFC3339)
+	}
+	// A scheduled event may be rescheduled, so its start time is part of the key
+	key := fmt.Sprintf("%s/%s/%s", instanceID, aws.ToString(event.InstanceEventId), detail.StartTime)
+	return polledMessage{
+		key: key,
+		message: scheduledchange.Message{
+			Metadata: messages.Metadata{
+				DetailType: scheduledchange.Parser{}.DetailType(),
+				ID:         key,
+				Source:     scheduledchange.Parser{}.Source(),
+				Time:       c.controller.clk.Now(),
+				Version:    scheduledchange.Parser{}.Version(),
+			},
+			Detail: detail,
+		},
+	}
+}
+
+// spotInterruptionMessages returns the spot interruptions of the managed instances, making at most maxRequests
+// DescribeSpotInstanceRequests requests. It also returns the number of requests which were made.
+func (c *PollingController) spotInterruptionMessages(ctx context.Context, managed map[string]struct{}, maxRequests int) ([]polledMessage, int, error) {
+	var polledMessages []polledMessage
+	paginator := ec2.NewDescribeSpotInstanceRequestsPaginator(c.ec2api, &ec2.DescribeSpotInstanceRequestsInput{
+		Filters: []ec2types.Filter{{
+			Name:   aws.String("status-code"),
+			Values: spotInterruptionStatusCodes,
+		}},
+		MaxResults: aws.Int32(1000),
+	})
+	requests := 0
+	for ; paginator.HasMorePages() && requests < maxRequests; requests++ {
+		out, err := paginator.NextPage(ctx)
+		if err != nil {
+			return polledMessages, requests + 1, fmt.Errorf("describing spot instance requests, %w", err)
+		}
+		for _, request := range out.SpotInstanceRequests {
+			instanceID := aws.ToString(request.InstanceId)
+			if _, ok := managed[instanceID]; !ok {
+				continue
+			}
+			polledMessages = append(polledMessages, c.spotInterruptionMessage(instanceID, request))
+		}
+	}
+	if paginator.HasMorePages() {
+		log.FromContext(ctx).V(1).Info("api budget exhausted before all spot instance requests were polled")
+	}
+	return polledMessages, requests, nil
+}
+
+func (c *PollingController) spotInterruptionMessage(instanceID string, request ec2types.SpotInstanceRequest) polledMessage {
+	status := lo.FromPtr(request.Status)
+	key := fmt.Sprintf("%s/%s/%s", instanceID, aws.ToString(request.SpotInstanceRequestId), aws.ToString(status.Code))
+	return polledMessage{
+		key: key,
+		message: spotinterruption.Message{
+			Metadata: messages.Metadata{
+				DetailType: spotinterruption.Parser{}.DetailType(),
+				ID:         key,
+				Source:     spotinterruption.Parser{}.Source(),
+				Time:       lo.FromPtrOr(status.UpdateTime, c.controller.clk.Now()),
+				Version:    spotinterruption.Parser{}.Version(),
+			},
+			Detail: spotinterruption.Detail{
+				InstanceID:     instanceID,
+				InstanceAction: lo.Ternary(aws.ToString(status.Code) == "marked-for-stop", "stop", "terminate"),
+			},
+		},
+	}
+}
+
+// stateChangeMessages returns the state changes of the instances which are stopped or terminated. The states are
+// described through the DescribeInstancesBatcher, so the API budget covers maxRequests batches of instances. When
+// that doesn't cover every instance, the next poll continues from the instance after the last that was checked.
+func (c *PollingController) stateChangeMessages(ctx context.Context, instanceIDs []string, maxRequests int) ([]polledMessage, error) {
+	if len(instanceIDs) == 0 || maxRequests <= 0 {
+		return nil, nil
+	}
+	sort.Strings(instanceIDs)
+	n := lo.Min([]int{len(instanceIDs), maxRequests * describeInstancesBatchSize})
+	start := c.offset % len(instanceIDs)
+	ids := lo.Times(n, func(i int) string { return instanceIDs[(start+i)%len(instanceIDs)] })
+	c.offset = (start + n) % len(instanceIDs)
+
+	results := make([]*polledMessage, n)
+	errs := make([]error, n)
+	workqueue.ParallelizeUntil(ctx, describeInstancesBatchSize, n, func(i int) {
+		out, err := c.describeInstancesBatcher.DescribeInstances(ctx, &ec2.DescribeInstancesInput{InstanceIds: []string{ids[i]}})
+		if err != nil {
+			// Instances which are no longer found are cleaned up by garbage collection
+			if !awserrors.IsNotFound(err) {
+				errs[i] = fmt.Errorf("describing instance %q, %w", ids[i], err)
+			}
+			return
+		}
+		instances := lo.FlatMap(out.Reservations, func(r ec2types.Reservation, _ int) []ec2types.Instance { return r.Instances })
+		if len(instances) == 0 {
+			return
+		}
+		state := lo.FromPtr(instances[0].State).Name
+		if !lo.Contains(interruptedInstanceStates, state) {
+			return
+		}
+		key := fmt.Sprintf("%s/%s", ids[i], state)
+		results[i] = &polledMessage{
+			key: key,
+			message: statechange.Message{
+				Metadata: messages.Metadata{
+					DetailType: statechange.Parser{}.DetailType(),
+					ID:         key,
+					Source:     statechange.Parser{}.Source(),
+					Time:       c.controller.clk.Now(),
+					Version:    statechange.Parser{}.Version(),
+				},
+				Detail: statechange.Detail{
+					InstanceID: ids[i],
+					State:      string(state),
+				},
+			},
+		}
+	})
+	return lo.FromSlicePtr(lo.Compact(results)), multierr.Combine(errs...)
+}
@@ -0,0 +1,118 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package interruption
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/awslabs/operatorpkg/object"
+	"github.com/samber/lo"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/clock"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	karpv1 "sigs.k8s.io/karpenter/pkg/apis/v1"
+	"sigs.k8s.io/karpenter/pkg/scheduling"
+	"sigs.k8s.io/karpenter/pkg/utils/resources"
+
+	v1 "github.com/aws/karpenter-provider-aws/pkg/apis/v1"
+)
+
+// NewReplacement returns a NodeClaim which replaces the NodeClaim on a rebalance recommendation. The replacement is
+// built from the NodePool's template like any other NodeClaim of the NodePool, and keeps the NodeClaim's requirements
+// and resource requests so that it can host the NodeClaim's pods once the NodeClaim is drained. The replacement can't be
+// disrupted until the NodeClaim is gone, since it's empty until the NodeClaim's pods are rescheduled onto it and would
+// otherwise be consolidated.
+func NewReplacement(nodePool *karpv1.NodePool, nodeClaim *karpv1.NodeClaim) *karpv1.NodeClaim {
+	requirements := scheduling.NewNodeSelectorRequirementsWithMinValues(nodeClaim.Spec.Requirements...)
+	return &karpv1.NodeClaim{
+		ObjectMeta: metav1.ObjectMeta{
+			GenerateName: fmt.Sprintf("%s-", nodePool.Name),
+			Annotations: lo.Assign(nodePool.Spec.Template.Annotations, map[string]string{
+				karpv1.NodePoolHashAnnotationKey:        nodePool.Hash(),
+				karpv1.NodePoolHashVersionAnnotationKey: karpv1.NodePoolHashVersion,
+				v1.AnnotationRebalanceReplaces:          nodeClaim.Name,
+				karpv1.DoNotDisruptAnnotationKey:        "true",
+			}),
+			Labels: lo.Assign(nodePool.Spec.Template.Labels, requirements.Labels(), map[string]string{
+				karpv1.NodePoolLabelKey: nodePool.Name,
+				karpv1.NodeClassLabelKey(nodeClaim.Spec.NodeClassRef.GroupKind()): nodeClaim.Spec.NodeClassRef.Name,
+			}),
+			OwnerReferences: []metav1.OwnerReference{
+				{
+					APIVersion:         object.GVK(nodePool).GroupVersion().String(),
+					Kind:               object.GVK(nodePool).Kind,
+					Name:               nodePool.Name,
+					UID:                nodePool.UID,
+					BlockOwnerDeletion: lo.ToPtr(true),
+				},
+			},
+		},
+		Spec: *nodeClaim.Spec.DeepCopy(),
+	}
+}
+
+const (
+	// MaxReplacementAttempts is the number of replacements that are launched for a NodeClaim on a rebalance
+	// recommendation. Once that many replacements have been deleted before becoming ready, the NodeClaim is no longer
+	// replaced and is drained when it's interrupted.
+	MaxReplacementAttempts = 3
+	// ReplacementBackoff is how long after the last replacement was launched that a replacement which was deleted before
+	// becoming ready is launched again
+	ReplacementBackoff = time.Minute
+)
+
+// ReplacementAttempts returns the number of replacements that have been launched for the NodeClaim
+func ReplacementAttempts(nodeClaim *karpv1.NodeClaim) int {
+	attempts, err := strconv.Atoi(nodeClaim.Annotations[v1.AnnotationRebalanceReplacementAttempts])
+	if err != nil {
+		return 0
+	}
+	return attempts
+}
+
+// Replace creates a replacement for the NodeClaim and records its name, the number of replacements and the time of the
+// replacement on the NodeClaim. Replacements aren't created when their resource requests would exceed the NodePool's
+// limits, in which case no replacement is returned.
+func Replace(ctx context.Context, kubeClient client.Client, clk clock.Clock, nodeClaim *karpv1.NodeClaim) (*karpv1.NodeClaim, error) {
+	nodePool := &karpv1.NodePool{}
+	if err := kubeClient.Get(ctx, client.ObjectKey{Name: nodeClaim.Labels[karpv1.NodePoolLabelKey]}, nodePool); err != nil {
+		return nil, client.IgnoreNotFound(fmt.Errorf("getting nodepool, %w", err))
+	}
+	replacement := NewReplacement(nodePool, nodeClaim)
+	// The replacement is launched alongside the NodeClaim, so its resources are added to the NodePool's usage
+	if err := nodePool.Spec.Limits.ExceededBy(resources.Merge(nodePool.Status.Resources, replacement.Spec.Resources.Requests)); err != nil {
+		log.FromContext(ctx).WithValues("NodePool", nodePool.Name).V(1).Info(fmt.Sprintf("skipping rebalance replacement, %s", err))
+		return nil, nil
+	}
+	if err := kubeClient.Create(ctx, replacement); err != nil {
+		return nil, fmt.Errorf("creating replacement nodeclaim, %w", err)
+	}
+	stored := nodeClaim.DeepCopy()
+	patched := nodeClaim.DeepCopy()
+	patched.Annotations = lo.Assign(patched.Annotations, map[string]string{
+		v1.AnnotationRebalanceReplacement:         replacement.Name,
+		v1.AnnotationRebalanceReplacementAttempts: strconv.Itoa(ReplacementAttempts(nodeClaim) + 1),
+		v1.AnnotationRebalanceReplacedAt:          clk.Now().UTC().Format(time.RFC3339),
+	})
+	if err := kubeClient.Patch(ctx, patched, client.MergeFrom(stored)); err != nil {
+		return nil, client.IgnoreNotFound(fmt.Errorf("patching nodeclaim, %w", err))
+	}
+	return replacement, nil
+}
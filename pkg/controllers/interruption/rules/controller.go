@@ -0,0 +1,114 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rules
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/awslabs/operatorpkg/singleton"
+	"github.com/samber/lo"
+	"go.uber.org/multierr"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/klog/v2"
+	controllerruntime "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/karpenter/pkg/operator/injection"
+	"sigs.k8s.io/karpenter/pkg/utils/pretty"
+
+	v1 "github.com/aws/karpenter-provider-aws/pkg/apis/v1"
+	"github.com/aws/karpenter-provider-aws/pkg/controllers/interruption"
+	"github.com/aws/karpenter-provider-aws/pkg/controllers/interruption/messages"
+	"github.com/aws/karpenter-provider-aws/pkg/controllers/interruption/messages/rule"
+)
+
+// Controller periodically reads the user-defined interruption rules and registers a parser for each of them with the
+// interruption controller's parser. Rules that fail to parse or validate are rejected, and the previously registered
+// rules remain in effect. The taints of rules which were removed, or which no longer have the Taint action, are removed
+// from the nodes.
+type Controller struct {
+	kubeClient    client.Client
+	rulesProvider rule.Provider
+	parser        *interruption.EventParser
+	cm            *pretty.ChangeMonitor
+}
+
+func NewController(kubeClient client.Client, rulesProvider rule.Provider, parser *interruption.EventParser) *Controller {
+	return &Controller{
+		kubeClient:    kubeClient,
+		rulesProvider: rulesProvider,
+		parser:        parser,
+		cm:            pretty.NewChangeMonitor(),
+	}
+}
+
+func (c *Controller) Reconcile(ctx context.Context) (reconcile.Result, error) {
+	ctx = injection.WithControllerName(ctx, "interruption.rules")
+
+	rules, err := c.rulesProvider.Rules(ctx)
+	if err != nil {
+		return reconcile.Result{}, fmt.Errorf("getting interruption rules, %w", err)
+	}
+	c.parser.SetRuleParsers(lo.Map(rules, func(r rule.Rule, _ int) messages.Parser { return rule.Parser{Rule: r} })...)
+	if c.cm.HasChanged("rules", rules) {
+		log.FromContext(ctx).WithValues("rules", lo.Map(rules, func(r rule.Rule, _ int) string { return r.Name })).Info("registered interruption rules")
+	}
+	if err = c.removeStaleTaints(ctx, rules); err != nil {
+		return reconcile.Result{}, fmt.Errorf("removing interruption rule taints, %w", err)
+	}
+	return reconcile.Result{RequeueAfter: time.Minute}, nil
+}
+
+// removeStaleTaints removes the taints of interruption rules which were removed or no longer have the Taint action.
+// The taints of rules which still have the Taint action are kept until they're removed by the user, since the events
+// that rules match don't report when the condition that they were raised for has cleared.
+func (c *Controller) removeStaleTaints(ctx context.Context, rules []rule.Rule) error {
+	taintRules := sets.New(lo.FilterMap(rules, func(r rule.Rule, _ int) (string, bool) { return r.Name, r.Action == rule.ActionTaint })...)
+	isStale := func(t corev1.Taint, _ int) bool {
+		return t.Key == v1.InterruptionRuleTaintKey && !taintRules.Has(t.Value)
+	}
+	nodeList := &corev1.NodeList{}
+	if err := c.kubeClient.List(ctx, nodeList); err != nil {
+		return fmt.Errorf("listing nodes, %w", err)
+	}
+	var errs []error
+	for i := range nodeList.Items {
+		node := &nodeList.Items[i]
+		stale := lo.Filter(node.Spec.Taints, isStale)
+		if len(stale) == 0 {
+			continue
+		}
+		stored := node.DeepCopy()
+		node.Spec.Taints = lo.Reject(node.Spec.Taints, isStale)
+		if err := c.kubeClient.Patch(ctx, node, client.StrategicMergeFrom(stored)); client.IgnoreNotFound(err) != nil {
+			errs = append(errs, fmt.Errorf("patching node, %w", err))
+			continue
+		}
+		log.FromContext(ctx).WithValues("Node", klog.KRef("", node.Name), "rules", lo.Map(stale, func(t corev1.Taint, _ int) string { return t.Value })).Info("removed interruption rule taint")
+	}
+	return multierr.Combine(errs...)
+}
+
+func (c *Controller) Register(_ context.Context, m manager.Manager) error {
+	return controllerruntime.NewControllerManagedBy(m).
+		Named("interruption.rules").
+		WatchesRawSource(singleton.Source()).
+		Complete(singleton.AsReconciler(c))
+}
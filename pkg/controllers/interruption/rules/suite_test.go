@@ -0,0 +1,257 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rules_test
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/samber/lo"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	coreoptions "sigs.k8s.io/karpenter/pkg/operator/options"
+	coretest "sigs.k8s.io/karpenter/pkg/test"
+	"sigs.k8s.io/karpenter/pkg/test/v1alpha1"
+
+	"github.com/aws/karpenter-provider-aws/pkg/apis"
+	v1 "github.com/aws/karpenter-provider-aws/pkg/apis/v1"
+	"github.com/aws/karpenter-provider-aws/pkg/controllers/interruption"
+	"github.com/aws/karpenter-provider-aws/pkg/controllers/interruption/messages"
+	"github.com/aws/karpenter-provider-aws/pkg/controllers/interruption/messages/rule"
+	"github.com/aws/karpenter-provider-aws/pkg/controllers/interruption/messages/spotinterruption"
+	"github.com/aws/karpenter-provider-aws/pkg/controllers/interruption/rules"
+	"github.com/aws/karpenter-provider-aws/pkg/operator/options"
+	"github.com/aws/karpenter-provider-aws/pkg/test"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	. "sigs.k8s.io/karpenter/pkg/test/expectations"
+	. "sigs.k8s.io/karpenter/pkg/utils/testing"
+)
+
+const (
+	configMapNamespace = "default"
+	configMapName      = "karpenter-interruption-rules"
+)
+
+var ctx context.Context
+var env *coretest.Environment
+var parser *interruption.EventParser
+var controller *rules.Controller
+
+func TestAWS(t *testing.T) {
+	ctx = TestContextWithLogger(t)
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "InterruptionRules")
+}
+
+var _ = BeforeSuite(func() {
+	env = coretest.NewEnvironment(coretest.WithCRDs(apis.CRDs...), coretest.WithCRDs(v1alpha1.CRDs...))
+	ctx = coreoptions.ToContext(ctx, coretest.Options())
+	ctx = options.ToContext(ctx, test.Options(test.OptionsFields{InterruptionRulesConfigMap: lo.ToPtr(configMapName)}))
+})
+
+var _ = AfterSuite(func() {
+	Expect(env.Stop()).To(Succeed(), "Failed to stop environment")
+})
+
+var _ = BeforeEach(func() {
+	parser = interruption.NewEventParser(interruption.DefaultParsers...)
+	controller = rules.NewController(env.Client, rule.NewConfigMapProvider(env.Client, configMapNamespace, configMapName), parser)
+})
+
+var _ = AfterEach(func() {
+	Expect(client.IgnoreNotFound(env.Client.Delete(ctx, &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Namespace: configMapNamespace, Name: configMapName}}))).To(Succeed())
+	ExpectCleanedUp(ctx, env.Client)
+})
+
+func applyRules(rules string) {
+	ExpectApplied(ctx, env.Client, &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Namespace: configMapNamespace, Name: configMapName},
+		Data:       map[string]string{rule.RulesKey: rules},
+	})
+}
+
+const eccRule = `
+- name: ecc-errors
+  source: aws.cloudwatch
+  detailType: CloudWatch Alarm State Change
+  instanceIDsPath: "{.detail.configuration.metrics[*].metricStat.metric.dimensions.InstanceId}"
+  action: Taint
+`
+
+func eccAlarmEvent(instanceIDs ...string) string {
+	return string(lo.Must(json.Marshal(map[string]any{
+		"version":     "0",
+		"id":          "7bf73129-1428-4cd3-a780-95db273d1602",
+		"source":      "aws.cloudwatch",
+		"detail-type": "CloudWatch Alarm State Change",
+		"account":     "000000000000",
+		"region":      "us-west-2",
+		"time":        "2026-10-18T12:00:00Z",
+		"detail": map[string]any{
+			"configuration": map[string]any{
+				"metrics": lo.Map(instanceIDs, func(id string, _ int) map[string]any {
+					return map[string]any{"metricStat": map[string]any{"metric": map[string]any{"dimensions": map[string]any{"InstanceId": id}}}}
+				}),
+			},
+		},
+	})))
+}
+
+var _ = Describe("InterruptionRules", func() {
+	It("should not parse events for rules when the ConfigMap doesn't exist", func() {
+		ExpectSingletonReconciled(ctx, controller)
+		msg, err := parser.Parse(ctx, eccAlarmEvent("i-0123456789abcdef0"))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(msg.Kind()).To(Equal(messages.NoOpKind))
+	})
+	It("should parse the instance ids of events that match a rule", func() {
+		applyRules(eccRule)
+		ExpectSingletonReconciled(ctx, controller)
+		msg, err := parser.Parse(ctx, eccAlarmEvent("i-0123456789abcdef0", "i-0123456789abcdef1", "i-0123456789abcdef0"))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(msg.Kind()).To(Equal(messages.Kind("ecc-errors")))
+		Expect(msg.EC2InstanceIDs()).To(ConsistOf("i-0123456789abcdef0", "i-0123456789abcdef1"))
+		Expect(msg.(rule.Message).Rule.Action).To(Equal(rule.ActionTaint))
+	})
+	It("should parse instance ids from instance ARNs", func() {
+		applyRules(`
+- name: guardduty
+  source: aws.guardduty
+  detailType: GuardDuty Finding
+  instanceIDsPath: "{.resources[*]}"
+  action: CordonAndDrain
+`)
+		ExpectSingletonReconciled(ctx, controller)
+		msg, err := parser.Parse(ctx, string(lo.Must(json.Marshal(map[string]any{
+			"version":     "0",
+			"source":      "aws.guardduty",
+			"detail-type": "GuardDuty Finding",
+			"resources":   []string{"arn:aws:ec2:us-west-2:000000000000:instance/i-0123456789abcdef0", "arn:aws:ec2:us-west-2:000000000000:volume/vol-0123456789abcdef0"},
+		}))))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(msg.EC2InstanceIDs()).To(ConsistOf("i-0123456789abcdef0"))
+	})
+	It("should reject rules that redefine built-in events", func() {
+		applyRules(`
+- name: spot
+  source: aws.ec2
+  detailType: EC2 Spot Instance Interruption Warning
+  instanceIDsPath: "{.detail.instance-id}"
+  action: EventOnly
+`)
+		err := ExpectSingletonReconcileFailed(ctx, controller)
+		Expect(err.Error()).To(ContainSubstring(`source "aws.ec2" and detailType "EC2 Spot Instance Interruption Warning" are matched by a built-in interruption event`))
+		msg, err := parser.Parse(ctx, string(lo.Must(json.Marshal(spotinterruption.Message{
+			Metadata: messages.Metadata{Version: "0", Source: "aws.ec2", DetailType: "EC2 Spot Instance Interruption Warning"},
+			Detail:   spotinterruption.Detail{InstanceID: "i-0123456789abcdef0"},
+		}))))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(msg.Kind()).To(Equal(messages.SpotInterruptionKind))
+	})
+	It("should keep the previous rules when the rules are invalid", func() {
+		applyRules(eccRule)
+		ExpectSingletonReconciled(ctx, controller)
+		for _, invalid := range []string{
+			`- name: Invalid Name
+  source: aws.cloudwatch
+  detailType: CloudWatch Alarm State Change
+  instanceIDsPath: "{.resources[*]}"
+  action: Taint`,
+			`- name: spot_interrupted
+  source: aws.cloudwatch
+  detailType: CloudWatch Alarm State Change
+  instanceIDsPath: "{.resources[*]}"
+  action: Taint`,
+			`- name: missing-source
+  detailType: CloudWatch Alarm State Change
+  instanceIDsPath: "{.resources[*]}"
+  action: Taint`,
+			`- name: invalid-path
+  source: aws.cloudwatch
+  detailType: CloudWatch Alarm State Change
+  instanceIDsPath: "{.resources[*}"
+  action: Taint`,
+			`- name: invalid-action
+  source: aws.cloudwatch
+  detailType: CloudWatch Alarm State Change
+  instanceIDsPath: "{.resources[*]}"
+  action: Reboot`,
+			eccRule + `
+- name: duplicate-event
+  source: aws.cloudwatch
+  detailType: CloudWatch Alarm State Change
+  instanceIDsPath: "{.resources[*]}"
+  action: EventOnly`,
+		} {
+			applyRules(invalid)
+			_ = ExpectSingletonReconcileFailed(ctx, controller)
+			msg, err := parser.Parse(ctx, eccAlarmEvent("i-0123456789abcdef0"))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(msg.Kind()).To(Equal(messages.Kind("ecc-errors")))
+		}
+	})
+	Context("Taints", func() {
+		var node *corev1.Node
+		BeforeEach(func() {
+			node = coretest.Node(coretest.NodeOptions{
+				Taints: []corev1.Taint{
+					{Key: v1.InterruptionRuleTaintKey, Value: "ecc-errors", Effect: corev1.TaintEffectNoSchedule},
+					{Key: "test-key", Value: "test-value", Effect: corev1.TaintEffectNoSchedule},
+				},
+			})
+			ExpectApplied(ctx, env.Client, node)
+		})
+		It("should keep the taints of rules with the Taint action", func() {
+			applyRules(eccRule)
+			ExpectSingletonReconciled(ctx, controller)
+			node = ExpectExists(ctx, env.Client, node)
+			Expect(node.Spec.Taints).To(ContainElement(corev1.Taint{Key: v1.InterruptionRuleTaintKey, Value: "ecc-errors", Effect: corev1.TaintEffectNoSchedule}))
+		})
+		It("should remove the taints of rules which were removed", func() {
+			applyRules(eccRule)
+			ExpectSingletonReconciled(ctx, controller)
+			applyRules("[]")
+			ExpectSingletonReconciled(ctx, controller)
+			node = ExpectExists(ctx, env.Client, node)
+			Expect(node.Spec.Taints).To(ConsistOf(corev1.Taint{Key: "test-key", Value: "test-value", Effect: corev1.TaintEffectNoSchedule}))
+		})
+		It("should remove the taints of rules which no longer have the Taint action", func() {
+			applyRules(strings.Replace(eccRule, "action: Taint", "action: EventOnly", 1))
+			ExpectSingletonReconciled(ctx, controller)
+			node = ExpectExists(ctx, env.Client, node)
+			Expect(node.Spec.Taints).To(ConsistOf(corev1.Taint{Key: "test-key", Value: "test-value", Effect: corev1.TaintEffectNoSchedule}))
+		})
+		It("should keep the taints when the rules are invalid", func() {
+			applyRules(strings.Replace(eccRule, "action: Taint", "action: Reboot", 1))
+			_ = ExpectSingletonReconcileFailed(ctx, controller)
+			node = ExpectExists(ctx, env.Client, node)
+			Expect(node.Spec.Taints).To(ContainElement(corev1.Taint{Key: v1.InterruptionRuleTaintKey, Value: "ecc-errors", Effect: corev1.TaintEffectNoSchedule}))
+		})
+	})
+	It("should remove the rules when the ConfigMap is deleted", func() {
+		applyRules(eccRule)
+		ExpectSingletonReconciled(ctx, controller)
+		Expect(env.Client.Delete(ctx, &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Namespace: configMapNamespace, Name: configMapName}})).To(Succeed())
+		ExpectSingletonReconciled(ctx, controller)
+		msg, err := parser.Parse(ctx, eccAlarmEvent("i-0123456789abcdef0"))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(msg.Kind()).To(Equal(messages.NoOpKind))
+	})
+})
@@ -18,17 +18,21 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"strings"
 	"testing"
 	"time"
 
 	"sigs.k8s.io/karpenter/pkg/metrics"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
 	servicesqs "github.com/aws/aws-sdk-go-v2/service/sqs"
 	sqstypes "github.com/aws/aws-sdk-go-v2/service/sqs/types"
 	"github.com/aws/smithy-go"
 	"github.com/samber/lo"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/util/uuid"
 	"k8s.io/client-go/tools/record"
@@ -41,10 +45,14 @@ import (
 	coretest "sigs.k8s.io/karpenter/pkg/test"
 
 	"github.com/aws/karpenter-provider-aws/pkg/apis"
+	v1 "github.com/aws/karpenter-provider-aws/pkg/apis/v1"
+	"github.com/aws/karpenter-provider-aws/pkg/batcher"
 	awscache "github.com/aws/karpenter-provider-aws/pkg/cache"
 	"github.com/aws/karpenter-provider-aws/pkg/cloudprovider"
 	"github.com/aws/karpenter-provider-aws/pkg/controllers/interruption"
 	"github.com/aws/karpenter-provider-aws/pkg/controllers/interruption/messages"
+	"github.com/aws/karpenter-provider-aws/pkg/controllers/interruption/messages/rebalancerecommendation"
+	"github.com/aws/karpenter-provider-aws/pkg/controllers/interruption/messages/rule"
 	"github.com/aws/karpenter-provider-aws/pkg/controllers/interruption/messages/scheduledchange"
 	"github.com/aws/karpenter-provider-aws/pkg/controllers/interruption/messages/spotinterruption"
 	"github.com/aws/karpenter-provider-aws/pkg/controllers/interruption/messages/statechange"
@@ -75,6 +83,9 @@ var sqsProvider *sqs.DefaultProvider
 var unavailableOfferingsCache *awscache.UnavailableOfferings
 var fakeClock *clock.FakeClock
 var controller *interruption.Controller
+var parser *interruption.EventParser
+var cloudProvider *cloudprovider.CloudProvider
+var describeInstancesBatcher *batcher.DescribeInstancesBatcher
 
 func TestAPIs(t *testing.T) {
 	ctx = TestContextWithLogger(t)
@@ -89,10 +100,12 @@ var _ = BeforeSuite(func() {
 	fakeClock = &clock.FakeClock{}
 	unavailableOfferingsCache = awscache.NewUnavailableOfferings()
 	sqsapi = &fake.SQSAPI{}
+	parser = interruption.NewEventParser(interruption.DefaultParsers...)
 	sqsProvider = lo.Must(sqs.NewDefaultProvider(sqsapi, fmt.Sprintf("https://sqs.%s.amazonaws.com/%s/test-cluster", fake.DefaultRegion, fake.DefaultAccount)))
-	cloudProvider := cloudprovider.New(awsEnv.InstanceTypesProvider, awsEnv.InstanceProvider, events.NewRecorder(&record.FakeRecorder{}),
+	cloudProvider = cloudprovider.New(awsEnv.InstanceTypesProvider, awsEnv.InstanceProvider, events.NewRecorder(&record.FakeRecorder{}),
 		env.Client, awsEnv.AMIProvider, awsEnv.SecurityGroupProvider)
-	controller = interruption.NewController(env.Client, cloudProvider, fakeClock, events.NewRecorder(&record.FakeRecorder{}), sqsProvider, unavailableOfferingsCache)
+	describeInstancesBatcher = batcher.NewDescribeInstancesBatcher(ctx, awsEnv.EC2API)
+	controller = interruption.NewController("interruption", env.Client, cloudProvider, fakeClock, events.NewRecorder(&record.FakeRecorder{}), parser, sqsProvider, unavailableOfferingsCache)
 })
 
 var _ = AfterSuite(func() {
@@ -257,6 +270,652 @@ var _ = Describe("InterruptionHandling", func() {
 			Expect(unavailableOfferingsCache.IsUnavailable("t3.large", "coretest-zone-1a", karpv1.CapacityTypeSpot)).To(BeTrue())
 		})
 	})
+	Context("SNS Notifications", func() {
+		BeforeEach(func() {
+			ctx = options.ToContext(ctx, test.Options())
+		})
+		It("should delete the NodeClaim when receiving a spot interruption warning in an SNS notification", func() {
+			ExpectMessagesCreated(snsNotification(defaultAccountID, fake.DefaultRegion, spotInterruptionMessage(lo.Must(utils.ParseInstanceID(nodeClaim.Status.ProviderID)))))
+			ExpectApplied(ctx, env.Client, nodeClaim, node)
+
+			ExpectSingletonReconciled(ctx, controller)
+			Expect(sqsapi.ReceiveMessageBehavior.SuccessfulCalls()).To(Equal(1))
+			ExpectNotFound(ctx, env.Client, nodeClaim)
+			Expect(sqsapi.DeleteMessageBehavior.SuccessfulCalls()).To(Equal(1))
+		})
+		It("should delete the NodeClaim when the SNS topic is in an allowed account and region", func() {
+			ctx = options.ToContext(ctx, test.Options(test.OptionsFields{
+				InterruptionAllowedAccounts: lo.ToPtr(fmt.Sprintf("111111111111,%s", defaultAccountID)),
+				InterruptionAllowedRegions:  lo.ToPtr(fake.DefaultRegion),
+			}))
+			ExpectMessagesCreated(snsNotification(defaultAccountID, fake.DefaultRegion, spotInterruptionMessage(lo.Must(utils.ParseInstanceID(nodeClaim.Status.ProviderID)))))
+			ExpectApplied(ctx, env.Client, nodeClaim, node)
+
+			ExpectSingletonReconciled(ctx, controller)
+			ExpectNotFound(ctx, env.Client, nodeClaim)
+			Expect(sqsapi.DeleteMessageBehavior.SuccessfulCalls()).To(Equal(1))
+		})
+		DescribeTable("should delete the message without acting on it when the SNS topic isn't allowed",
+			func(accounts, regions string) {
+				ctx = options.ToContext(ctx, test.Options(test.OptionsFields{
+					InterruptionAllowedAccounts: lo.ToPtr(accounts),
+					InterruptionAllowedRegions:  lo.ToPtr(regions),
+				}))
+				ExpectMessagesCreated(snsNotification(defaultAccountID, fake.DefaultRegion, spotInterruptionMessage(lo.Must(utils.ParseInstanceID(nodeClaim.Status.ProviderID)))))
+				ExpectApplied(ctx, env.Client, nodeClaim, node)
+
+				ExpectSingletonReconciled(ctx, controller)
+				ExpectExists(ctx, env.Client, nodeClaim)
+				Expect(sqsapi.DeleteMessageBehavior.SuccessfulCalls()).To(Equal(1))
+			},
+			Entry("account isn't allowed", "111111111111", ""),
+			Entry("region isn't allowed", "", "eu-west-1"),
+		)
+		It("should delete the message without acting on it when a raw event isn't from an allowed account", func() {
+			ctx = options.ToContext(ctx, test.Options(test.OptionsFields{
+				InterruptionAllowedAccounts: lo.ToPtr("111111111111"),
+			}))
+			ExpectMessagesCreated(spotInterruptionMessage(lo.Must(utils.ParseInstanceID(nodeClaim.Status.ProviderID))))
+			ExpectApplied(ctx, env.Client, nodeClaim, node)
+
+			ExpectSingletonReconciled(ctx, controller)
+			ExpectExists(ctx, env.Client, nodeClaim)
+			Expect(sqsapi.DeleteMessageBehavior.SuccessfulCalls()).To(Equal(1))
+		})
+		It("should delete the message without acting on it when a raw event isn't from an allowed region", func() {
+			ctx = options.ToContext(ctx, test.Options(test.OptionsFields{
+				InterruptionAllowedRegions: lo.ToPtr("eu-west-1"),
+			}))
+			ExpectMessagesCreated(spotInterruptionMessage(lo.Must(utils.ParseInstanceID(nodeClaim.Status.ProviderID))))
+			ExpectApplied(ctx, env.Client, nodeClaim, node)
+
+			ExpectSingletonReconciled(ctx, controller)
+			ExpectExists(ctx, env.Client, nodeClaim)
+			Expect(sqsapi.DeleteMessageBehavior.SuccessfulCalls()).To(Equal(1))
+		})
+		It("should delete the message without acting on it when the event in an allowed SNS topic isn't from an allowed account", func() {
+			ctx = options.ToContext(ctx, test.Options(test.OptionsFields{
+				InterruptionAllowedAccounts: lo.ToPtr(defaultAccountID),
+			}))
+			msg := spotInterruptionMessage(lo.Must(utils.ParseInstanceID(nodeClaim.Status.ProviderID)))
+			msg.Account = "111111111111"
+			ExpectMessagesCreated(snsNotification(defaultAccountID, fake.DefaultRegion, msg))
+			ExpectApplied(ctx, env.Client, nodeClaim, node)
+
+			ExpectSingletonReconciled(ctx, controller)
+			ExpectExists(ctx, env.Client, nodeClaim)
+			Expect(sqsapi.DeleteMessageBehavior.SuccessfulCalls()).To(Equal(1))
+		})
+		It("should delete the NodeClaim when a raw event is from an allowed account and region", func() {
+			ctx = options.ToContext(ctx, test.Options(test.OptionsFields{
+				InterruptionAllowedAccounts: lo.ToPtr(defaultAccountID),
+				InterruptionAllowedRegions:  lo.ToPtr(fake.DefaultRegion),
+			}))
+			ExpectMessagesCreated(spotInterruptionMessage(lo.Must(utils.ParseInstanceID(nodeClaim.Status.ProviderID))))
+			ExpectApplied(ctx, env.Client, nodeClaim, node)
+
+			ExpectSingletonReconciled(ctx, controller)
+			ExpectNotFound(ctx, env.Client, nodeClaim)
+		})
+	})
+	Context("Multiple Queues", func() {
+		var otherSQSAPI *fake.SQSAPI
+		var otherController *interruption.Controller
+		BeforeEach(func() {
+			otherSQSAPI = &fake.SQSAPI{}
+			otherSQSProvider := sqs.NewUnresolvedProvider(otherSQSAPI, "test-cluster")
+			otherController = interruption.NewController("interruption.1", env.Client, cloudProvider, fakeClock, events.NewRecorder(&record.FakeRecorder{}), parser,
+				otherSQSProvider, unavailableOfferingsCache)
+			sqsapi.ReceiveMessageBehavior.Output.Set(&servicesqs.ReceiveMessageOutput{})
+			otherSQSAPI.ReceiveMessageBehavior.Output.Set(&servicesqs.ReceiveMessageOutput{
+				Messages: []sqstypes.Message{{
+					Body:      aws.String(string(lo.Must(json.Marshal(spotInterruptionMessage(lo.Must(utils.ParseInstanceID(nodeClaim.Status.ProviderID))))))),
+					MessageId: aws.String(string(uuid.NewUUID())),
+				}},
+			})
+		})
+		It("should handle messages from an additional queue and delete them from that queue", func() {
+			ExpectApplied(ctx, env.Client, nodeClaim, node)
+
+			ExpectSingletonReconciled(ctx, otherController)
+			Expect(otherSQSAPI.ReceiveMessageBehavior.SuccessfulCalls()).To(Equal(1))
+			Expect(sqsapi.ReceiveMessageBehavior.Calls()).To(Equal(0))
+			ExpectNotFound(ctx, env.Client, nodeClaim)
+			Expect(sqsapi.DeleteMessageBehavior.Calls()).To(Equal(0))
+			Expect(otherSQSAPI.DeleteMessageBehavior.SuccessfulCalls()).To(Equal(1))
+		})
+		It("should handle messages from an additional queue when receiving from the interruption queue fails", func() {
+			sqsapi.ReceiveMessageBehavior.Error.Set(smithyErrWithCode("AccessDenied"))
+			ExpectApplied(ctx, env.Client, nodeClaim, node)
+
+			_, err := controller.Reconcile(ctx)
+			Expect(err).To(HaveOccurred())
+			ExpectSingletonReconciled(ctx, otherController)
+			ExpectNotFound(ctx, env.Client, nodeClaim)
+			Expect(otherSQSAPI.DeleteMessageBehavior.SuccessfulCalls()).To(Equal(1))
+		})
+		It("should retry resolving an additional queue that couldn't be resolved", func() {
+			otherSQSAPI.GetQueueURLBehavior.Error.Set(smithyErrWithCode("AccessDenied"))
+			ExpectApplied(ctx, env.Client, nodeClaim, node)
+
+			_, err := otherController.Reconcile(ctx)
+			Expect(err).To(HaveOccurred())
+			Expect(otherSQSAPI.ReceiveMessageBehavior.Calls()).To(Equal(0))
+			ExpectExists(ctx, env.Client, nodeClaim)
+
+			ExpectSingletonReconciled(ctx, otherController)
+			Expect(otherSQSAPI.GetQueueURLBehavior.SuccessfulCalls()).To(Equal(1))
+			ExpectNotFound(ctx, env.Client, nodeClaim)
+			Expect(otherSQSAPI.DeleteMessageBehavior.SuccessfulCalls()).To(Equal(1))
+		})
+	})
+	Context("Interruption Rules", func() {
+		BeforeEach(func() {
+			parser.SetRuleParsers(lo.Map([]rule.Action{rule.ActionCordonAndDrain, rule.ActionTaint, rule.ActionEventOnly}, func(action rule.Action, _ int) messages.Parser {
+				return rule.Parser{Rule: rule.Rule{
+					Name:            strings.ToLower(string(action)),
+					Version:         "0",
+					Source:          "custom.compliance",
+					DetailType:      string(action),
+					InstanceIDsPath: "{.detail.instance-ids[*]}",
+					Action:          action,
+				}}
+			})...)
+		})
+		AfterEach(func() {
+			parser.SetRuleParsers()
+		})
+		It("should delete the NodeClaim when a rule with the CordonAndDrain action matches", func() {
+			ExpectMessagesCreated(ruleMessage(string(rule.ActionCordonAndDrain), lo.Must(utils.ParseInstanceID(nodeClaim.Status.ProviderID))))
+			ExpectApplied(ctx, env.Client, nodeClaim, node)
+
+			ExpectSingletonReconciled(ctx, controller)
+			ExpectMetricCounterValue(metrics.NodeClaimsDisruptedTotal, 1, map[string]string{
+				metrics.ReasonLabel: "cordonanddrain",
+				"nodepool":          "default",
+			})
+			ExpectNotFound(ctx, env.Client, nodeClaim)
+			Expect(sqsapi.DeleteMessageBehavior.SuccessfulCalls()).To(Equal(1))
+		})
+		It("should taint the Node when a rule with the Taint action matches", func() {
+			ExpectMessagesCreated(ruleMessage(string(rule.ActionTaint), lo.Must(utils.ParseInstanceID(nodeClaim.Status.ProviderID))))
+			ExpectApplied(ctx, env.Client, nodeClaim, node)
+
+			ExpectSingletonReconciled(ctx, controller)
+			ExpectExists(ctx, env.Client, nodeClaim)
+			node = ExpectExists(ctx, env.Client, node)
+			Expect(node.Spec.Taints).To(ContainElement(corev1.Taint{Key: v1.InterruptionRuleTaintKey, Value: "taint", Effect: corev1.TaintEffectNoSchedule}))
+			Expect(sqsapi.DeleteMessageBehavior.SuccessfulCalls()).To(Equal(1))
+
+			// Tainting the Node again doesn't duplicate the taint
+			ExpectMessagesCreated(ruleMessage(string(rule.ActionTaint), lo.Must(utils.ParseInstanceID(nodeClaim.Status.ProviderID))))
+			ExpectSingletonReconciled(ctx, controller)
+			node = ExpectExists(ctx, env.Client, node)
+			Expect(lo.CountBy(node.Spec.Taints, func(t corev1.Taint) bool { return t.Key == v1.InterruptionRuleTaintKey })).To(Equal(1))
+		})
+		It("should not act on the NodeClaim when a rule with the EventOnly action matches", func() {
+			ExpectMessagesCreated(ruleMessage(string(rule.ActionEventOnly), lo.Must(utils.ParseInstanceID(nodeClaim.Status.ProviderID))))
+			ExpectApplied(ctx, env.Client, nodeClaim, node)
+
+			ExpectSingletonReconciled(ctx, controller)
+			ExpectExists(ctx, env.Client, nodeClaim)
+			node = ExpectExists(ctx, env.Client, node)
+			Expect(node.Spec.Taints).ToNot(ContainElement(HaveField("Key", v1.InterruptionRuleTaintKey)))
+			Expect(sqsapi.DeleteMessageBehavior.SuccessfulCalls()).To(Equal(1))
+		})
+	})
+	Context("Scheduled Changes", func() {
+		var nodePool *karpv1.NodePool
+		BeforeEach(func() {
+			ctx = options.ToContext(ctx, test.Options(test.OptionsFields{ScheduledChangeLeadTime: lo.ToPtr(24 * time.Hour)}))
+			nodePool = coretest.NodePool(karpv1.NodePool{ObjectMeta: metav1.ObjectMeta{Name: "default"}})
+			fakeClock.SetTime(time.Date(2026, time.October, 18, 12, 0, 0, 0, time.UTC))
+		})
+		It("should delete the NodeClaim immediately when no lead time is configured", func() {
+			ctx = options.ToContext(ctx, test.Options())
+			ExpectMessagesCreated(scheduledChangeMessageStartingAt(lo.Must(utils.ParseInstanceID(nodeClaim.Status.ProviderID)), fakeClock.Now().Add(72*time.Hour)))
+			ExpectApplied(ctx, env.Client, nodePool, nodeClaim, node)
+
+			ExpectSingletonReconciled(ctx, controller)
+			ExpectNotFound(ctx, env.Client, nodeClaim)
+			ExpectMetricCounterValue(metrics.NodeClaimsDisruptedTotal, 1, map[string]string{
+				metrics.ReasonLabel: "scheduled_change",
+				"nodepool":          "default",
+			})
+		})
+		It("should schedule the deletion of the NodeClaim the lead time before the scheduled change", func() {
+			ExpectMessagesCreated(scheduledChangeMessageStartingAt(lo.Must(utils.ParseInstanceID(nodeClaim.Status.ProviderID)), fakeClock.Now().Add(72*time.Hour)))
+			ExpectApplied(ctx, env.Client, nodePool, nodeClaim, node)
+
+			ExpectSingletonReconciled(ctx, controller)
+			nodeClaim = ExpectExists(ctx, env.Client, nodeClaim)
+			Expect(nodeClaim.DeletionTimestamp.IsZero()).To(BeTrue())
+			Expect(nodeClaim.Annotations).To(HaveKeyWithValue(v1.AnnotationScheduledChangeDisruptionTime, "2026-10-20T12:00:00Z"))
+			Expect(sqsapi.DeleteMessageBehavior.SuccessfulCalls()).To(Equal(1))
+		})
+		It("should delete the NodeClaim immediately when the scheduled change starts within the lead time", func() {
+			ExpectMessagesCreated(scheduledChangeMessageStartingAt(lo.Must(utils.ParseInstanceID(nodeClaim.Status.ProviderID)), fakeClock.Now().Add(2*time.Hour)))
+			ExpectApplied(ctx, env.Client, nodePool, nodeClaim, node)
+
+			ExpectSingletonReconciled(ctx, controller)
+			ExpectNotFound(ctx, env.Client, nodeClaim)
+			ExpectMetricCounterValue(metrics.NodeClaimsDisruptedTotal, 1, map[string]string{
+				metrics.ReasonLabel: "scheduled_change",
+				"nodepool":          "default",
+			})
+		})
+		It("should delete the NodeClaim immediately when the scheduled change has no start time", func() {
+			msg := scheduledChangeMessageStartingAt(lo.Must(utils.ParseInstanceID(nodeClaim.Status.ProviderID)), fakeClock.Now().Add(72*time.Hour))
+			msg.Detail.StartTime = ""
+			ExpectMessagesCreated(msg)
+			ExpectApplied(ctx, env.Client, nodePool, nodeClaim, node)
+
+			ExpectSingletonReconciled(ctx, controller)
+			ExpectNotFound(ctx, env.Client, nodeClaim)
+			Expect(sqsapi.DeleteMessageBehavior.SuccessfulCalls()).To(Equal(1))
+		})
+		It("should delete the NodeClaim immediately when the scheduled change has an unparseable start time", func() {
+			msg := scheduledChangeMessageStartingAt(lo.Must(utils.ParseInstanceID(nodeClaim.Status.ProviderID)), fakeClock.Now().Add(72*time.Hour))
+			msg.Detail.StartTime = "not-a-time"
+			ExpectMessagesCreated(msg)
+			ExpectApplied(ctx, env.Client, nodePool, nodeClaim, node)
+
+			ExpectSingletonReconciled(ctx, controller)
+			ExpectNotFound(ctx, env.Client, nodeClaim)
+			Expect(sqsapi.DeleteMessageBehavior.SuccessfulCalls()).To(Equal(1))
+		})
+		It("should use the configured lead time", func() {
+			ctx = options.ToContext(ctx, test.Options(test.OptionsFields{ScheduledChangeLeadTime: lo.ToPtr(time.Hour)}))
+			ExpectMessagesCreated(scheduledChangeMessageStartingAt(lo.Must(utils.ParseInstanceID(nodeClaim.Status.ProviderID)), fakeClock.Now().Add(2*time.Hour)))
+			ExpectApplied(ctx, env.Client, nodePool, nodeClaim, node)
+
+			ExpectSingletonReconciled(ctx, controller)
+			nodeClaim = ExpectExists(ctx, env.Client, nodeClaim)
+			Expect(nodeClaim.Annotations).To(HaveKeyWithValue(v1.AnnotationScheduledChangeDisruptionTime, "2026-10-18T13:00:00Z"))
+		})
+		It("should schedule the deletion of the NodeClaim in the NodePool's last maintenance window before the lead time", func() {
+			nodePool.Annotations = map[string]string{
+				v1.AnnotationMaintenanceWindowSchedule: "0 2 * * *",
+				v1.AnnotationMaintenanceWindowDuration: "4h",
+			}
+			ExpectMessagesCreated(scheduledChangeMessageStartingAt(lo.Must(utils.ParseInstanceID(nodeClaim.Status.ProviderID)), fakeClock.Now().Add(7*24*time.Hour)))
+			ExpectApplied(ctx, env.Client, nodePool, nodeClaim, node)
+
+			ExpectSingletonReconciled(ctx, controller)
+			nodeClaim = ExpectExists(ctx, env.Client, nodeClaim)
+			Expect(nodeClaim.Annotations).To(HaveKeyWithValue(v1.AnnotationScheduledChangeDisruptionTime, "2026-10-24T02:00:00Z"))
+		})
+		It("should schedule the deletion of the NodeClaim in the NodePool's last maintenance window before the scheduled change when no lead time is configured", func() {
+			ctx = options.ToContext(ctx, test.Options())
+			nodePool.Annotations = map[string]string{
+				v1.AnnotationMaintenanceWindowSchedule: "0 2 * * *",
+				v1.AnnotationMaintenanceWindowDuration: "4h",
+			}
+			ExpectMessagesCreated(scheduledChangeMessageStartingAt(lo.Must(utils.ParseInstanceID(nodeClaim.Status.ProviderID)), fakeClock.Now().Add(7*24*time.Hour)))
+			ExpectApplied(ctx, env.Client, nodePool, nodeClaim, node)
+
+			ExpectSingletonReconciled(ctx, controller)
+			nodeClaim = ExpectExists(ctx, env.Client, nodeClaim)
+			Expect(nodeClaim.DeletionTimestamp.IsZero()).To(BeTrue())
+			Expect(nodeClaim.Annotations).To(HaveKeyWithValue(v1.AnnotationScheduledChangeDisruptionTime, "2026-10-25T02:00:00Z"))
+		})
+		It("should fall back to the lead time when there's no maintenance window before it", func() {
+			nodePool.Annotations = map[string]string{
+				v1.AnnotationMaintenanceWindowSchedule: "0 2 1 * *",
+				v1.AnnotationMaintenanceWindowDuration: "4h",
+			}
+			ExpectMessagesCreated(scheduledChangeMessageStartingAt(lo.Must(utils.ParseInstanceID(nodeClaim.Status.ProviderID)), fakeClock.Now().Add(72*time.Hour)))
+			ExpectApplied(ctx, env.Client, nodePool, nodeClaim, node)
+
+			ExpectSingletonReconciled(ctx, controller)
+			nodeClaim = ExpectExists(ctx, env.Client, nodeClaim)
+			Expect(nodeClaim.Annotations).To(HaveKeyWithValue(v1.AnnotationScheduledChangeDisruptionTime, "2026-10-20T12:00:00Z"))
+		})
+		It("should keep the earliest disruption time of multiple scheduled changes", func() {
+			ExpectApplied(ctx, env.Client, nodePool, nodeClaim, node)
+			for _, start := range []time.Duration{72 * time.Hour, 120 * time.Hour} {
+				ExpectMessagesCreated(scheduledChangeMessageStartingAt(lo.Must(utils.ParseInstanceID(nodeClaim.Status.ProviderID)), fakeClock.Now().Add(start)))
+				ExpectSingletonReconciled(ctx, controller)
+			}
+			nodeClaim = ExpectExists(ctx, env.Client, nodeClaim)
+			Expect(nodeClaim.Annotations).To(HaveKeyWithValue(v1.AnnotationScheduledChangeDisruptionTime, "2026-10-20T12:00:00Z"))
+		})
+	})
+	Context("Rebalance Recommendations", func() {
+		var nodePool *karpv1.NodePool
+		var nodeClass *v1.EC2NodeClass
+		BeforeEach(func() {
+			nodePool = coretest.NodePool(karpv1.NodePool{ObjectMeta: metav1.ObjectMeta{Name: "default"}})
+			nodeClass = test.EC2NodeClass(v1.EC2NodeClass{ObjectMeta: metav1.ObjectMeta{Name: nodeClaim.Spec.NodeClassRef.Name}})
+			nodeClaim.Labels = lo.Assign(nodeClaim.Labels, map[string]string{
+				corev1.LabelTopologyZone:       "test-zone-1a",
+				corev1.LabelInstanceTypeStable: "t3.large",
+				karpv1.CapacityTypeLabelKey:    karpv1.CapacityTypeSpot,
+			})
+			nodeClaim.Spec.Requirements = []karpv1.NodeSelectorRequirementWithMinValues{
+				{
+					NodeSelectorRequirement: corev1.NodeSelectorRequirement{
+						Key:      corev1.LabelInstanceTypeStable,
+						Operator: corev1.NodeSelectorOpIn,
+						Values:   []string{"t3.large", "m5.large"},
+					},
+				},
+				{
+					NodeSelectorRequirement: corev1.NodeSelectorRequirement{
+						Key:      karpv1.CapacityTypeLabelKey,
+						Operator: corev1.NodeSelectorOpIn,
+						Values:   []string{karpv1.CapacityTypeSpot},
+					},
+				},
+			}
+			interruption.RebalanceRecommendations.Reset()
+			interruption.RebalanceRecommendationsInterrupted.Reset()
+		})
+		It("should only record the rebalance recommendation by default", func() {
+			ExpectMessagesCreated(rebalanceRecommendationMessage(lo.Must(utils.ParseInstanceID(nodeClaim.Status.ProviderID))))
+			ExpectApplied(ctx, env.Client, nodePool, nodeClass, nodeClaim, node)
+
+			ExpectSingletonReconciled(ctx, controller)
+			nodeClaim = ExpectExists(ctx, env.Client, nodeClaim)
+			Expect(nodeClaim.Annotations).To(HaveKey(v1.AnnotationRebalanceRecommendedAt))
+			Expect(nodeClaim.Annotations).ToNot(HaveKey(v1.AnnotationRebalanceReplacement))
+			Expect(ExpectNodeClaims(ctx, env.Client)).To(HaveLen(1))
+			ExpectMetricCounterValue(interruption.RebalanceRecommendations, 1, map[string]string{
+				"nodepool": "default",
+				"action":   string(interruption.NoAction),
+			})
+			Expect(unavailableOfferingsCache.IsUnavailable("t3.large", "test-zone-1a", karpv1.CapacityTypeSpot)).To(BeFalse())
+		})
+		It("should launch a replacement when the EC2NodeClass opts into replacing before draining", func() {
+			nodeClass.Spec.RebalanceRecommendationPolicy = lo.ToPtr(v1.RebalanceRecommendationPolicyReplaceBeforeDrain)
+			ExpectMessagesCreated(rebalanceRecommendationMessage(lo.Must(utils.ParseInstanceID(nodeClaim.Status.ProviderID))))
+			ExpectApplied(ctx, env.Client, nodePool, nodeClass, nodeClaim, node)
+
+			ExpectSingletonReconciled(ctx, controller)
+			nodeClaim = ExpectExists(ctx, env.Client, nodeClaim)
+			Expect(nodeClaim.DeletionTimestamp.IsZero()).To(BeTrue())
+			replacement, ok := lo.Find(ExpectNodeClaims(ctx, env.Client), func(nc *karpv1.NodeClaim) bool { return nc.Name != nodeClaim.Name })
+			Expect(ok).To(BeTrue())
+			Expect(nodeClaim.Annotations).To(HaveKeyWithValue(v1.AnnotationRebalanceReplacement, replacement.Name))
+			Expect(nodeClaim.Annotations).To(HaveKeyWithValue(v1.AnnotationRebalanceReplacementAttempts, "1"))
+			Expect(nodeClaim.Annotations).To(HaveKeyWithValue(v1.AnnotationRebalanceReplacedAt, fakeClock.Now().UTC().Format(time.RFC3339)))
+			Expect(replacement.Annotations).To(HaveKeyWithValue(v1.AnnotationRebalanceReplaces, nodeClaim.Name))
+			Expect(replacement.Annotations).To(HaveKeyWithValue(karpv1.DoNotDisruptAnnotationKey, "true"))
+			Expect(replacement.Labels).To(HaveKeyWithValue(karpv1.NodePoolLabelKey, nodePool.Name))
+			Expect(replacement.Labels).ToNot(HaveKey(corev1.LabelTopologyZone))
+			Expect(replacement.Spec.Requirements).To(Equal(nodeClaim.Spec.Requirements))
+			Expect(replacement.OwnerReferences).To(ContainElement(HaveField("Name", nodePool.Name)))
+			ExpectMetricCounterValue(interruption.RebalanceRecommendations, 1, map[string]string{
+				"nodepool": "default",
+				"action":   string(interruption.ReplaceBeforeDrain),
+			})
+			// Expect the spot capacity pool at risk of interruption to be avoided by the replacement
+			Expect(unavailableOfferingsCache.IsUnavailable("t3.large", "test-zone-1a", karpv1.CapacityTypeSpot)).To(BeTrue())
+		})
+		It("should not launch a replacement when the EC2NodeClass's policy is None", func() {
+			nodeClass.Spec.RebalanceRecommendationPolicy = lo.ToPtr(v1.RebalanceRecommendationPolicyNone)
+			ExpectMessagesCreated(rebalanceRecommendationMessage(lo.Must(utils.ParseInstanceID(nodeClaim.Status.ProviderID))))
+			ExpectApplied(ctx, env.Client, nodePool, nodeClass, nodeClaim, node)
+
+			ExpectSingletonReconciled(ctx, controller)
+			Expect(ExpectNodeClaims(ctx, env.Client)).To(HaveLen(1))
+		})
+		It("should not launch a replacement when the NodeClaim's EC2NodeClass doesn't exist", func() {
+			nodeClass.Spec.RebalanceRecommendationPolicy = lo.ToPtr(v1.RebalanceRecommendationPolicyReplaceBeforeDrain)
+			nodeClaim.Spec.NodeClassRef.Name = "does-not-exist"
+			ExpectMessagesCreated(rebalanceRecommendationMessage(lo.Must(utils.ParseInstanceID(nodeClaim.Status.ProviderID))))
+			ExpectApplied(ctx, env.Client, nodePool, nodeClass, nodeClaim, node)
+
+			ExpectSingletonReconciled(ctx, controller)
+			Expect(ExpectNodeClaims(ctx, env.Client)).To(HaveLen(1))
+			ExpectMetricCounterValue(interruption.RebalanceRecommendations, 1, map[string]string{
+				"nodepool": "default",
+				"action":   string(interruption.NoAction),
+			})
+		})
+		It("should not launch a replacement when the NodeClaim has run out of replacement attempts", func() {
+			nodeClass.Spec.RebalanceRecommendationPolicy = lo.ToPtr(v1.RebalanceRecommendationPolicyReplaceBeforeDrain)
+			nodeClaim.Annotations = lo.Assign(nodeClaim.Annotations, map[string]string{
+				v1.AnnotationRebalanceReplacementAttempts: fmt.Sprint(interruption.MaxReplacementAttempts),
+			})
+			ExpectMessagesCreated(rebalanceRecommendationMessage(lo.Must(utils.ParseInstanceID(nodeClaim.Status.ProviderID))))
+			ExpectApplied(ctx, env.Client, nodePool, nodeClass, nodeClaim, node)
+
+			ExpectSingletonReconciled(ctx, controller)
+			Expect(ExpectNodeClaims(ctx, env.Client)).To(HaveLen(1))
+
+			// The NodeClaim is still drained when it's interrupted
+			ExpectMessagesCreated(spotInterruptionMessage(lo.Must(utils.ParseInstanceID(nodeClaim.Status.ProviderID))))
+			ExpectSingletonReconciled(ctx, controller)
+			ExpectNotFound(ctx, env.Client, nodeClaim)
+		})
+		It("should only launch one replacement for repeated rebalance recommendations", func() {
+			nodeClass.Spec.RebalanceRecommendationPolicy = lo.ToPtr(v1.RebalanceRecommendationPolicyReplaceBeforeDrain)
+			ExpectApplied(ctx, env.Client, nodePool, nodeClass, nodeClaim, node)
+			for range 2 {
+				ExpectMessagesCreated(rebalanceRecommendationMessage(lo.Must(utils.ParseInstanceID(nodeClaim.Status.ProviderID))))
+				ExpectSingletonReconciled(ctx, controller)
+			}
+			Expect(ExpectNodeClaims(ctx, env.Client)).To(HaveLen(2))
+			ExpectMetricCounterValue(interruption.RebalanceRecommendations, 1, map[string]string{
+				"nodepool": "default",
+				"action":   string(interruption.ReplaceBeforeDrain),
+			})
+		})
+		It("should not launch a replacement when the NodePool's limits are exceeded", func() {
+			nodeClass.Spec.RebalanceRecommendationPolicy = lo.ToPtr(v1.RebalanceRecommendationPolicyReplaceBeforeDrain)
+			nodePool.Spec.Limits = karpv1.Limits{corev1.ResourceCPU: resource.MustParse("0")}
+			ExpectMessagesCreated(rebalanceRecommendationMessage(lo.Must(utils.ParseInstanceID(nodeClaim.Status.ProviderID))))
+			ExpectApplied(ctx, env.Client, nodePool, nodeClass, nodeClaim, node)
+			nodePool.Status.Resources = corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("2")}
+			ExpectApplied(ctx, env.Client, nodePool)
+
+			ExpectSingletonReconciled(ctx, controller)
+			Expect(ExpectNodeClaims(ctx, env.Client)).To(HaveLen(1))
+			Expect(unavailableOfferingsCache.IsUnavailable("t3.large", "test-zone-1a", karpv1.CapacityTypeSpot)).To(BeFalse())
+		})
+		It("should not launch a replacement when its resource requests would exceed the NodePool's limits", func() {
+			nodeClass.Spec.RebalanceRecommendationPolicy = lo.ToPtr(v1.RebalanceRecommendationPolicyReplaceBeforeDrain)
+			nodePool.Spec.Limits = karpv1.Limits{corev1.ResourceCPU: resource.MustParse("4")}
+			nodeClaim.Spec.Resources.Requests = corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("3")}
+			ExpectMessagesCreated(rebalanceRecommendationMessage(lo.Must(utils.ParseInstanceID(nodeClaim.Status.ProviderID))))
+			ExpectApplied(ctx, env.Client, nodePool, nodeClass, nodeClaim, node)
+			nodePool.Status.Resources = corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("2")}
+			ExpectApplied(ctx, env.Client, nodePool)
+
+			ExpectSingletonReconciled(ctx, controller)
+			Expect(ExpectNodeClaims(ctx, env.Client)).To(HaveLen(1))
+		})
+		It("should count spot interruptions that follow a rebalance recommendation", func() {
+			ExpectMessagesCreated(rebalanceRecommendationMessage(lo.Must(utils.ParseInstanceID(nodeClaim.Status.ProviderID))))
+			ExpectApplied(ctx, env.Client, nodePool, nodeClass, nodeClaim, node)
+			ExpectSingletonReconciled(ctx, controller)
+
+			ExpectMessagesCreated(spotInterruptionMessage(lo.Must(utils.ParseInstanceID(nodeClaim.Status.ProviderID))))
+			ExpectSingletonReconciled(ctx, controller)
+			ExpectNotFound(ctx, env.Client, nodeClaim)
+			ExpectMetricCounterValue(interruption.RebalanceRecommendationsInterrupted, 1, map[string]string{
+				"nodepool": "default",
+			})
+		})
+	})
+	Context("Polling", func() {
+		var pollingController *interruption.PollingController
+		var polledInterruptions *awscache.PolledInterruptions
+		var instanceID string
+		BeforeEach(func() {
+			ctx = options.ToContext(ctx, test.Options(test.OptionsFields{InterruptionPollingInterval: lo.ToPtr(time.Minute)}))
+			awsEnv.EC2API.Reset()
+			fakeClock.SetTime(time.Date(2026, time.October, 18, 12, 0, 0, 0, time.UTC))
+			polledInterruptions = awscache.NewPolledInterruptions()
+			pollingController = interruption.NewPollingController(env.Client, cloudProvider, fakeClock, events.NewRecorder(&record.FakeRecorder{}), awsEnv.EC2API, describeInstancesBatcher, unavailableOfferingsCache, polledInterruptions)
+			instanceID = lo.Must(utils.ParseInstanceID(nodeClaim.Status.ProviderID))
+			nodeClaim.Labels = lo.Assign(nodeClaim.Labels, map[string]string{
+				corev1.LabelTopologyZone:       "coretest-zone-1a",
+				corev1.LabelInstanceTypeStable: "t3.large",
+				karpv1.CapacityTypeLabelKey:    karpv1.CapacityTypeSpot,
+			})
+		})
+		It("should delete the NodeClaim when its spot instance request is marked for termination", func() {
+			awsEnv.EC2API.DescribeSpotInstanceRequestsBehavior.Output.Set(&ec2.DescribeSpotInstanceRequestsOutput{
+				SpotInstanceRequests: []ec2types.SpotInstanceRequest{{
+					InstanceId:            aws.String(instanceID),
+					SpotInstanceRequestId: aws.String("sir-1"),
+					Status:                &ec2types.SpotInstanceStatus{Code: aws.String("marked-for-termination"), UpdateTime: aws.Time(fakeClock.Now())},
+				}},
+			})
+			ExpectApplied(ctx, env.Client, nodeClaim, node)
+
+			result := ExpectSingletonReconciled(ctx, pollingController)
+			Expect(result.RequeueAfter).To(Equal(time.Minute))
+			ExpectNotFound(ctx, env.Client, nodeClaim)
+			ExpectMetricCounterValue(metrics.NodeClaimsDisruptedTotal, 1, map[string]string{
+				metrics.ReasonLabel: "spot_interrupted",
+				"nodepool":          "default",
+			})
+			Expect(unavailableOfferingsCache.IsUnavailable("t3.large", "coretest-zone-1a", karpv1.CapacityTypeSpot)).To(BeTrue())
+			Expect(sqsapi.ReceiveMessageBehavior.Calls()).To(Equal(0))
+		})
+		It("should schedule the deletion of the NodeClaim ahead of a scheduled event", func() {
+			ctx = options.ToContext(ctx, test.Options(test.OptionsFields{InterruptionPollingInterval: lo.ToPtr(time.Minute), ScheduledChangeLeadTime: lo.ToPtr(24 * time.Hour)}))
+			nodePool := coretest.NodePool(karpv1.NodePool{ObjectMeta: metav1.ObjectMeta{Name: "default"}})
+			awsEnv.EC2API.DescribeInstanceStatusBehavior.Output.Set(&ec2.DescribeInstanceStatusOutput{
+				InstanceStatuses: []ec2types.InstanceStatus{{
+					InstanceId: aws.String(instanceID),
+					Events: []ec2types.InstanceStatusEvent{
+						{
+							Code:            ec2types.EventCodeInstanceRetirement,
+							Description:     aws.String("The instance is running on degraded hardware"),
+							InstanceEventId: aws.String("instance-event-1"),
+							NotBefore:       aws.Time(fakeClock.Now().Add(72 * time.Hour)),
+						},
+						{
+							Code:            ec2types.EventCodeSystemReboot,
+							Description:     aws.String("[Completed] The instance is scheduled for a reboot"),
+							InstanceEventId: aws.String("instance-event-2"),
+							NotBefore:       aws.Time(fakeClock.Now().Add(-time.Hour)),
+						},
+					},
+				}},
+			})
+			ExpectApplied(ctx, env.Client, nodePool, nodeClaim, node)
+
+			ExpectSingletonReconciled(ctx, pollingController)
+			nodeClaim = ExpectExists(ctx, env.Client, nodeClaim)
+			Expect(nodeClaim.DeletionTimestamp.IsZero()).To(BeTrue())
+			Expect(nodeClaim.Annotations).To(HaveKeyWithValue(v1.AnnotationScheduledChangeDisruptionTime, "2026-10-20T12:00:00Z"))
+		})
+		It("should delete the NodeClaim when its instance is stopped", func() {
+			awsEnv.EC2API.Instances.Store(instanceID, ec2types.Instance{
+				InstanceId: aws.String(instanceID),
+				State:      &ec2types.InstanceState{Name: ec2types.InstanceStateNameStopped},
+			})
+			ExpectApplied(ctx, env.Client, nodeClaim, node)
+
+			ExpectSingletonReconciled(ctx, pollingController)
+			ExpectNotFound(ctx, env.Client, nodeClaim)
+			ExpectMetricCounterValue(metrics.NodeClaimsDisruptedTotal, 1, map[string]string{
+				metrics.ReasonLabel: "instance_stopped",
+				"nodepool":          "default",
+			})
+		})
+		It("should not delete the NodeClaim when its instance is running", func() {
+			awsEnv.EC2API.Instances.Store(instanceID, ec2types.Instance{
+				InstanceId: aws.String(instanceID),
+				State:      &ec2types.InstanceState{Name: ec2types.InstanceStateNameRunning},
+			})
+			ExpectApplied(ctx, env.Client, nodeClaim, node)
+
+			ExpectSingletonReconciled(ctx, pollingController)
+			ExpectExists(ctx, env.Client, nodeClaim)
+			Expect(awsEnv.EC2API.DescribeInstancesBehavior.SuccessfulCalls()).To(Equal(1))
+		})
+		It("should not act on the same interruption twice", func() {
+			ctx = options.ToContext(ctx, test.Options(test.OptionsFields{InterruptionPollingInterval: lo.ToPtr(time.Minute), ScheduledChangeLeadTime: lo.ToPtr(24 * time.Hour)}))
+			awsEnv.EC2API.DescribeInstanceStatusBehavior.Output.Set(&ec2.DescribeInstanceStatusOutput{
+				InstanceStatuses: []ec2types.InstanceStatus{{
+					InstanceId: aws.String(instanceID),
+					Events: []ec2types.InstanceStatusEvent{{
+						Code:            ec2types.EventCodeInstanceRetirement,
+						InstanceEventId: aws.String("instance-event-1"),
+						NotBefore:       aws.Time(fakeClock.Now().Add(72 * time.Hour)),
+					}},
+				}},
+			})
+			ExpectApplied(ctx, env.Client, nodeClaim, node)
+			ExpectSingletonReconciled(ctx, pollingController)
+			nodeClaim = ExpectExists(ctx, env.Client, nodeClaim)
+			Expect(nodeClaim.Annotations).To(HaveKey(v1.AnnotationScheduledChangeDisruptionTime))
+
+			// Removing the annotation shows whether the scheduled event is acted on again
+			delete(nodeClaim.Annotations, v1.AnnotationScheduledChangeDisruptionTime)
+			ExpectApplied(ctx, env.Client, nodeClaim)
+			ExpectSingletonReconciled(ctx, pollingController)
+			nodeClaim = ExpectExists(ctx, env.Client, nodeClaim)
+			Expect(nodeClaim.Annotations).ToNot(HaveKey(v1.AnnotationScheduledChangeDisruptionTime))
+		})
+		It("should not act on interruptions that were acted on before a restart", func() {
+			ctx = options.ToContext(ctx, test.Options(test.OptionsFields{InterruptionPollingInterval: lo.ToPtr(time.Minute), ScheduledChangeLeadTime: lo.ToPtr(24 * time.Hour)}))
+			awsEnv.EC2API.DescribeInstanceStatusBehavior.Output.Set(&ec2.DescribeInstanceStatusOutput{
+				InstanceStatuses: []ec2types.InstanceStatus{{
+					InstanceId: aws.String(instanceID),
+					Events: []ec2types.InstanceStatusEvent{{
+						Code:            ec2types.EventCodeInstanceRetirement,
+						InstanceEventId: aws.String("instance-event-1"),
+						NotBefore:       aws.Time(fakeClock.Now().Add(72 * time.Hour)),
+					}},
+				}},
+			})
+			ExpectApplied(ctx, env.Client, nodeClaim, node)
+			ExpectSingletonReconciled(ctx, pollingController)
+			nodeClaim = ExpectExists(ctx, env.Client, nodeClaim)
+			Expect(nodeClaim.Annotations).To(HaveKey(v1.AnnotationScheduledChangeDisruptionTime))
+
+			// The restarted controller is restored from a checkpoint of the interruptions that were acted on
+			data := lo.Must(polledInterruptions.Checkpoint())
+			restored := awscache.NewPolledInterruptions()
+			Expect(restored.Restore(data)).To(Succeed())
+			pollingController = interruption.NewPollingController(env.Client, cloudProvider, fakeClock, events.NewRecorder(&record.FakeRecorder{}), awsEnv.EC2API, describeInstancesBatcher, unavailableOfferingsCache, restored)
+
+			delete(nodeClaim.Annotations, v1.AnnotationScheduledChangeDisruptionTime)
+			ExpectApplied(ctx, env.Client, nodeClaim)
+			ExpectSingletonReconciled(ctx, pollingController)
+			nodeClaim = ExpectExists(ctx, env.Client, nodeClaim)
+			Expect(nodeClaim.Annotations).ToNot(HaveKey(v1.AnnotationScheduledChangeDisruptionTime))
+		})
+		It("should ignore interruptions of instances that aren't managed", func() {
+			awsEnv.EC2API.DescribeSpotInstanceRequestsBehavior.Output.Set(&ec2.DescribeSpotInstanceRequestsOutput{
+				SpotInstanceRequests: []ec2types.SpotInstanceRequest{{
+					InstanceId:            aws.String(fake.InstanceID()),
+					SpotInstanceRequestId: aws.String("sir-1"),
+					Status:                &ec2types.SpotInstanceStatus{Code: aws.String("marked-for-termination")},
+				}},
+			})
+			ExpectApplied(ctx, env.Client, nodeClaim, node)
+
+			ExpectSingletonReconciled(ctx, pollingController)
+			ExpectExists(ctx, env.Client, nodeClaim)
+			Expect(unavailableOfferingsCache.IsUnavailable("t3.large", "coretest-zone-1a", karpv1.CapacityTypeSpot)).To(BeFalse())
+		})
+		It("should not make more requests than the api budget", func() {
+			ctx = options.ToContext(ctx, test.Options(test.OptionsFields{
+				InterruptionPollingInterval:  lo.ToPtr(time.Minute),
+				InterruptionPollingAPIBudget: lo.ToPtr(5),
+			}))
+			awsEnv.EC2API.DescribeInstanceStatusBehavior.Output.Set(&ec2.DescribeInstanceStatusOutput{NextToken: aws.String("next")})
+			awsEnv.EC2API.DescribeSpotInstanceRequestsBehavior.Output.Set(&ec2.DescribeSpotInstanceRequestsOutput{NextToken: aws.String("next")})
+			awsEnv.EC2API.Instances.Store(instanceID, ec2types.Instance{
+				InstanceId: aws.String(instanceID),
+				State:      &ec2types.InstanceState{Name: ec2types.InstanceStateNameRunning},
+			})
+			ExpectApplied(ctx, env.Client, nodeClaim, node)
+
+			ExpectSingletonReconciled(ctx, pollingController)
+			Expect(awsEnv.EC2API.DescribeInstanceStatusBehavior.Calls()).To(Equal(3))
+			Expect(awsEnv.EC2API.DescribeSpotInstanceRequestsBehavior.Calls()).To(Equal(1))
+			Expect(awsEnv.EC2API.DescribeInstancesBehavior.Calls()).To(Equal(1))
+		})
+	})
 })
 
 var _ = Describe("Error Handling", func() {
@@ -287,6 +946,30 @@ func ExpectMessagesCreated(messages ...interface{}) {
 	)
 }
 
+func ruleMessage(detailType string, involvedInstanceIDs ...string) map[string]any {
+	return map[string]any{
+		"version":     "0",
+		"id":          string(uuid.NewUUID()),
+		"source":      "custom.compliance",
+		"detail-type": detailType,
+		"account":     defaultAccountID,
+		"region":      fake.DefaultRegion,
+		"time":        time.Now(),
+		"detail":      map[string]any{"instance-ids": involvedInstanceIDs},
+	}
+}
+
+// snsNotification wraps the message in the envelope of an SNS notification from a topic in the account and region
+func snsNotification(account, region string, message interface{}) map[string]string {
+	return map[string]string{
+		"Type":      "Notification",
+		"MessageId": string(uuid.NewUUID()),
+		"TopicArn":  fmt.Sprintf("arn:aws:sns:%s:%s:karpenter-interruption", region, account),
+		"Message":   string(lo.Must(json.Marshal(message))),
+		"Timestamp": time.Now().UTC().Format(time.RFC3339),
+	}
+}
+
 func smithyErrWithCode(code string) smithy.APIError {
 	return &smithy.GenericAPIError{
 		Code:    code,
@@ -315,6 +998,33 @@ func spotInterruptionMessage(involvedInstanceID string) spotinterruption.Message
 	}
 }
 
+func scheduledChangeMessageStartingAt(involvedInstanceID string, start time.Time) scheduledchange.Message {
+	msg := scheduledChangeMessage(involvedInstanceID)
+	msg.Detail.StartTime = start.UTC().Format("Mon, 02 Jan 2006 15:04:05 GMT")
+	msg.Detail.EndTime = start.Add(2 * time.Hour).UTC().Format("Mon, 02 Jan 2006 15:04:05 GMT")
+	return msg
+}
+
+func rebalanceRecommendationMessage(involvedInstanceID string) rebalancerecommendation.Message {
+	return rebalancerecommendation.Message{
+		Metadata: messages.Metadata{
+			Version:    "0",
+			Account:    defaultAccountID,
+			DetailType: "EC2 Instance Rebalance Recommendation",
+			ID:         string(uuid.NewUUID()),
+			Region:     fake.DefaultRegion,
+			Resources: []string{
+				fmt.Sprintf("arn:aws:ec2:%s:instance/%s", fake.DefaultRegion, involvedInstanceID),
+			},
+			Source: ec2Source,
+			Time:   time.Now(),
+		},
+		Detail: rebalancerecommendation.Detail{
+			InstanceID: involvedInstanceID,
+		},
+	}
+}
+
 func stateChangeMessage(involvedInstanceID, state string) statechange.Message {
 	return statechange.Message{
 		Metadata: messages.Metadata{
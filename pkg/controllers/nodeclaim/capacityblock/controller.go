@@ -0,0 +1,122 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package capacityblock
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/awslabs/operatorpkg/reasonable"
+	"github.com/samber/lo"
+	"k8s.io/utils/clock"
+	controllerruntime "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	karpv1 "sigs.k8s.io/karpenter/pkg/apis/v1"
+	"sigs.k8s.io/karpenter/pkg/cloudprovider"
+	"sigs.k8s.io/karpenter/pkg/events"
+	"sigs.k8s.io/karpenter/pkg/metrics"
+	"sigs.k8s.io/karpenter/pkg/operator/injection"
+	"sigs.k8s.io/karpenter/pkg/utils/nodeclaim"
+
+	v1 "github.com/aws/karpenter-provider-aws/pkg/apis/v1"
+	"github.com/aws/karpenter-provider-aws/pkg/providers/capacityreservation"
+)
+
+// Controller replaces NodeClaims which were launched into a capacity block ahead of the block's end time. Once the block
+// is expiring, the NodeClaim is marked as drifted so that a replacement is launched before its node is drained. NodeClaims
+// which haven't been replaced by the block's deletion window are deleted, so that their pods are rescheduled before EC2
+// terminates the instances in the block.
+type Controller struct {
+	kubeClient    client.Client
+	cloudProvider cloudprovider.CloudProvider
+	clk           clock.Clock
+	recorder      events.Recorder
+}
+
+func NewController(kubeClient client.Client, cloudProvider cloudprovider.CloudProvider, clk clock.Clock, recorder events.Recorder) *Controller {
+	return &Controller{
+		kubeClient:    kubeClient,
+		cloudProvider: cloudProvider,
+		clk:           clk,
+		recorder:      recorder,
+	}
+}
+
+func (c *Controller) Reconcile(ctx context.Context, nodeClaim *karpv1.NodeClaim) (reconcile.Result, error) {
+	ctx = injection.WithControllerName(ctx, "nodeclaim.capacityblock")
+
+	if !isCapacityReservationNodeClaim(nodeClaim) || nodeClaim.Spec.NodeClassRef == nil {
+		return reconcile.Result{}, nil
+	}
+	nodeClass := &v1.EC2NodeClass{}
+	if err := c.kubeClient.Get(ctx, client.ObjectKey{Name: nodeClaim.Spec.NodeClassRef.Name}, nodeClass); err != nil {
+		return reconcile.Result{}, client.IgnoreNotFound(err)
+	}
+	cr, ok := lo.Find(nodeClass.Status.CapacityReservations, func(cr v1.CapacityReservation) bool {
+		return cr.ID == nodeClaim.Labels[v1.LabelCapacityReservationID]
+	})
+	if !ok || cr.ReservationType != v1.CapacityReservationTypeCapacityBlock || cr.EndTime == nil {
+		return reconcile.Result{}, nil
+	}
+	if !capacityreservation.IsExpiring(cr, c.clk.Now()) {
+		return reconcile.Result{RequeueAfter: cr.EndTime.Add(-capacityreservation.CapacityBlockExpirationWindow).Sub(c.clk.Now())}, nil
+	}
+	if _, ok := nodeClaim.Annotations[v1.AnnotationCapacityBlockExpiring]; !ok {
+		stored := nodeClaim.DeepCopy()
+		nodeClaim.Annotations = lo.Assign(nodeClaim.Annotations, map[string]string{v1.AnnotationCapacityBlockExpiring: "true"})
+		if err := c.kubeClient.Patch(ctx, nodeClaim, client.MergeFrom(stored)); err != nil {
+			return reconcile.Result{}, client.IgnoreNotFound(fmt.Errorf("patching nodeclaim, %w", err))
+		}
+		log.FromContext(ctx).WithValues("capacity-reservation-id", cr.ID, "end-time", cr.EndTime.Time).Info("replacing nodeclaim for expiring capacity block")
+	}
+	if deletionTime := cr.EndTime.Add(-capacityreservation.CapacityBlockDeletionWindow); c.clk.Now().Before(deletionTime) {
+		return reconcile.Result{RequeueAfter: deletionTime.Sub(c.clk.Now())}, nil
+	}
+	if err := c.kubeClient.Delete(ctx, nodeClaim); err != nil {
+		return reconcile.Result{}, client.IgnoreNotFound(fmt.Errorf("deleting nodeclaim, %w", err))
+	}
+	log.FromContext(ctx).WithValues("capacity-reservation-id", cr.ID, "end-time", cr.EndTime.Time).Info("initiating delete for expiring capacity block")
+	c.recorder.Publish(CapacityBlockExpiringEvent(nodeClaim, cr))
+	metrics.NodeClaimsDisruptedTotal.Inc(map[string]string{
+		metrics.ReasonLabel:       "capacity_block_expiring",
+		metrics.NodePoolLabel:     nodeClaim.Labels[karpv1.NodePoolLabelKey],
+		metrics.CapacityTypeLabel: nodeClaim.Labels[karpv1.CapacityTypeLabelKey],
+	})
+	return reconcile.Result{}, nil
+}
+
+func (c *Controller) Register(_ context.Context, m manager.Manager) error {
+	return controllerruntime.NewControllerManagedBy(m).
+		Named("nodeclaim.capacityblock").
+		For(&karpv1.NodeClaim{}, builder.WithPredicates(nodeclaim.IsManagedPredicateFuncs(c.cloudProvider))).
+		WithEventFilter(predicate.NewPredicateFuncs(func(o client.Object) bool {
+			return isCapacityReservationNodeClaim(o.(*karpv1.NodeClaim))
+		})).
+		WithOptions(controller.Options{
+			RateLimiter: reasonable.RateLimiter(),
+		}).
+		Complete(reconcile.AsReconciler(m.GetClient(), c))
+}
+
+func isCapacityReservationNodeClaim(nc *karpv1.NodeClaim) bool {
+	return nc.Labels[v1.LabelCapacityReservationID] != "" && nc.DeletionTimestamp.IsZero()
+}
@@ -0,0 +1,175 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package capacityblock_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/awslabs/operatorpkg/object"
+	"github.com/samber/lo"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+	clock "k8s.io/utils/clock/testing"
+	karpv1 "sigs.k8s.io/karpenter/pkg/apis/v1"
+	"sigs.k8s.io/karpenter/pkg/events"
+	coreoptions "sigs.k8s.io/karpenter/pkg/operator/options"
+	coretest "sigs.k8s.io/karpenter/pkg/test"
+	"sigs.k8s.io/karpenter/pkg/test/v1alpha1"
+
+	"github.com/aws/karpenter-provider-aws/pkg/apis"
+	v1 "github.com/aws/karpenter-provider-aws/pkg/apis/v1"
+	"github.com/aws/karpenter-provider-aws/pkg/cloudprovider"
+	"github.com/aws/karpenter-provider-aws/pkg/controllers/nodeclaim/capacityblock"
+	"github.com/aws/karpenter-provider-aws/pkg/operator/options"
+	"github.com/aws/karpenter-provider-aws/pkg/providers/capacityreservation"
+	"github.com/aws/karpenter-provider-aws/pkg/test"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	. "sigs.k8s.io/karpenter/pkg/test/expectations"
+	. "sigs.k8s.io/karpenter/pkg/utils/testing"
+)
+
+var ctx context.Context
+var awsEnv *test.Environment
+var env *coretest.Environment
+var fakeClock *clock.FakeClock
+var capacityBlockController *capacityblock.Controller
+
+func TestAPIs(t *testing.T) {
+	ctx = TestContextWithLogger(t)
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "CapacityBlock")
+}
+
+var _ = BeforeSuite(func() {
+	env = coretest.NewEnvironment(coretest.WithCRDs(apis.CRDs...), coretest.WithCRDs(v1alpha1.CRDs...))
+	ctx = coreoptions.ToContext(ctx, coretest.Options())
+	ctx = options.ToContext(ctx, test.Options())
+	awsEnv = test.NewEnvironment(ctx, env)
+	fakeClock = clock.NewFakeClock(time.Now())
+	recorder := events.NewRecorder(&record.FakeRecorder{})
+	cloudProvider := cloudprovider.New(awsEnv.InstanceTypesProvider, awsEnv.InstanceProvider, recorder,
+		env.Client, awsEnv.AMIProvider, awsEnv.SecurityGroupProvider)
+	capacityBlockController = capacityblock.NewController(env.Client, cloudProvider, fakeClock, recorder)
+})
+
+var _ = AfterSuite(func() {
+	Expect(env.Stop()).To(Succeed(), "Failed to stop environment")
+})
+
+var _ = BeforeEach(func() {
+	awsEnv.Reset()
+	fakeClock.SetTime(time.Now())
+})
+
+var _ = AfterEach(func() {
+	ExpectCleanedUp(ctx, env.Client)
+})
+
+var _ = Describe("CapacityBlock", func() {
+	var nodeClass *v1.EC2NodeClass
+	var nodeClaim *karpv1.NodeClaim
+	var endTime time.Time
+
+	BeforeEach(func() {
+		endTime = fakeClock.Now().Add(time.Hour)
+		nodeClass = test.EC2NodeClass()
+		nodeClass.Status.CapacityReservations = []v1.CapacityReservation{
+			{
+				ID:               "cr-block1",
+				InstanceType:     "p5.48xlarge",
+				AvailabilityZone: "test-zone-1a",
+				ReservationType:  v1.CapacityReservationTypeCapacityBlock,
+				EndTime:          lo.ToPtr(metav1.NewTime(endTime)),
+			},
+			{
+				ID:               "cr-odcr1",
+				InstanceType:     "m5.large",
+				AvailabilityZone: "test-zone-1a",
+				ReservationType:  v1.CapacityReservationTypeDefault,
+				EndTime:          lo.ToPtr(metav1.NewTime(endTime)),
+			},
+		}
+		nodeClaim = coretest.NodeClaim(karpv1.NodeClaim{
+			ObjectMeta: metav1.ObjectMeta{
+				Labels: map[string]string{
+					karpv1.CapacityTypeLabelKey:   v1.CapacityTypeReserved,
+					v1.LabelCapacityReservationID: "cr-block1",
+				},
+			},
+			Spec: karpv1.NodeClaimSpec{
+				NodeClassRef: &karpv1.NodeClassReference{
+					Group: object.GVK(nodeClass).Group,
+					Kind:  object.GVK(nodeClass).Kind,
+					Name:  nodeClass.Name,
+				},
+			},
+		})
+	})
+	It("should requeue until the capacity block is expiring", func() {
+		ExpectApplied(ctx, env.Client, nodeClass, nodeClaim)
+		result := ExpectObjectReconciled(ctx, env.Client, capacityBlockController, nodeClaim)
+		Expect(result.RequeueAfter).To(BeNumerically("~", time.Hour-capacityreservation.CapacityBlockExpirationWindow, time.Second))
+		nodeClaim = ExpectExists(ctx, env.Client, nodeClaim)
+		Expect(nodeClaim.DeletionTimestamp.IsZero()).To(BeTrue())
+	})
+	It("should mark the nodeclaim for replacement once the capacity block is expiring", func() {
+		ExpectApplied(ctx, env.Client, nodeClass, nodeClaim)
+		fakeClock.Step(time.Hour - capacityreservation.CapacityBlockExpirationWindow)
+		result := ExpectObjectReconciled(ctx, env.Client, capacityBlockController, nodeClaim)
+		Expect(result.RequeueAfter).To(BeNumerically("~", capacityreservation.CapacityBlockExpirationWindow-capacityreservation.CapacityBlockDeletionWindow, time.Second))
+		nodeClaim = ExpectExists(ctx, env.Client, nodeClaim)
+		Expect(nodeClaim.DeletionTimestamp.IsZero()).To(BeTrue())
+		Expect(nodeClaim.Annotations).To(HaveKeyWithValue(v1.AnnotationCapacityBlockExpiring, "true"))
+	})
+	It("should delete the nodeclaim once the capacity block reaches its deletion window", func() {
+		ExpectApplied(ctx, env.Client, nodeClass, nodeClaim)
+		fakeClock.Step(time.Hour - capacityreservation.CapacityBlockExpirationWindow)
+		ExpectObjectReconciled(ctx, env.Client, capacityBlockController, nodeClaim)
+		nodeClaim = ExpectExists(ctx, env.Client, nodeClaim)
+		fakeClock.Step(capacityreservation.CapacityBlockExpirationWindow - capacityreservation.CapacityBlockDeletionWindow)
+		ExpectObjectReconciled(ctx, env.Client, capacityBlockController, nodeClaim)
+		ExpectNotFound(ctx, env.Client, nodeClaim)
+	})
+	It("should not delete nodeclaims launched into an On-Demand Capacity Reservation", func() {
+		nodeClaim.Labels[v1.LabelCapacityReservationID] = "cr-odcr1"
+		ExpectApplied(ctx, env.Client, nodeClass, nodeClaim)
+		fakeClock.Step(time.Hour)
+		result := ExpectObjectReconciled(ctx, env.Client, capacityBlockController, nodeClaim)
+		Expect(result.RequeueAfter).To(BeZero())
+		nodeClaim = ExpectExists(ctx, env.Client, nodeClaim)
+		Expect(nodeClaim.DeletionTimestamp.IsZero()).To(BeTrue())
+	})
+	It("should not delete nodeclaims that weren't launched into a capacity reservation", func() {
+		delete(nodeClaim.Labels, v1.LabelCapacityReservationID)
+		nodeClaim.Labels[karpv1.CapacityTypeLabelKey] = karpv1.CapacityTypeOnDemand
+		ExpectApplied(ctx, env.Client, nodeClass, nodeClaim)
+		fakeClock.Step(time.Hour)
+		ExpectObjectReconciled(ctx, env.Client, capacityBlockController, nodeClaim)
+		nodeClaim = ExpectExists(ctx, env.Client, nodeClaim)
+		Expect(nodeClaim.DeletionTimestamp.IsZero()).To(BeTrue())
+	})
+	It("should not delete nodeclaims whose capacity block is no longer resolved", func() {
+		nodeClass.Status.CapacityReservations = nil
+		ExpectApplied(ctx, env.Client, nodeClass, nodeClaim)
+		fakeClock.Step(time.Hour)
+		ExpectObjectReconciled(ctx, env.Client, capacityBlockController, nodeClaim)
+		nodeClaim = ExpectExists(ctx, env.Client, nodeClaim)
+		Expect(nodeClaim.DeletionTimestamp.IsZero()).To(BeTrue())
+	})
+})
@@ -0,0 +1,208 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rebalance
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/awslabs/operatorpkg/reasonable"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/klog/v2"
+	"k8s.io/utils/clock"
+	controllerruntime "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	karpv1 "sigs.k8s.io/karpenter/pkg/apis/v1"
+	"sigs.k8s.io/karpenter/pkg/cloudprovider"
+	"sigs.k8s.io/karpenter/pkg/events"
+	"sigs.k8s.io/karpenter/pkg/metrics"
+	"sigs.k8s.io/karpenter/pkg/operator/injection"
+	"sigs.k8s.io/karpenter/pkg/utils/nodeclaim"
+
+	v1 "github.com/aws/karpenter-provider-aws/pkg/apis/v1"
+	"github.com/aws/karpenter-provider-aws/pkg/controllers/interruption"
+	"github.com/aws/karpenter-provider-aws/pkg/controllers/interruption/messages"
+)
+
+// Controller deletes NodeClaims which are being replaced on a rebalance recommendation once their replacement is ready.
+// Deleting the NodeClaim cordons and drains the node, so that its pods are rescheduled onto the replacement. NodeClaims
+// whose replacement is deleted before it's ready, such as when it fails to launch, are replaced again until they run out
+// of replacement attempts. Replacements can't be disrupted until the NodeClaim that they replace is gone.
+type Controller struct {
+	kubeClient    client.Client
+	clk           clock.Clock
+	cloudProvider cloudprovider.CloudProvider
+	recorder      events.Recorder
+}
+
+func NewController(kubeClient client.Client, clk clock.Clock, cloudProvider cloudprovider.CloudProvider, recorder events.Recorder) *Controller {
+	return &Controller{
+		kubeClient:    kubeClient,
+		clk:           clk,
+		cloudProvider: cloudProvider,
+		recorder:      recorder,
+	}
+}
+
+func (c *Controller) Reconcile(ctx context.Context, nodeClaim *karpv1.NodeClaim) (reconcile.Result, error) {
+	ctx = injection.WithControllerName(ctx, "nodeclaim.rebalance")
+
+	if isReplacement(nodeClaim) {
+		if err := c.allowDisruption(ctx, nodeClaim); err != nil {
+			return reconcile.Result{}, err
+		}
+	}
+	if !isReplacedNodeClaim(nodeClaim) {
+		return reconcile.Result{}, nil
+	}
+	replacement := &karpv1.NodeClaim{}
+	if err := c.kubeClient.Get(ctx, client.ObjectKey{Name: nodeClaim.Annotations[v1.AnnotationRebalanceReplacement]}, replacement); err != nil {
+		if !errors.IsNotFound(err) {
+			return reconcile.Result{}, fmt.Errorf("getting replacement nodeclaim, %w", err)
+		}
+		replacement = nil
+	}
+	if replacement == nil || !replacement.DeletionTimestamp.IsZero() {
+		return c.relaunch(ctx, nodeClaim)
+	}
+	if !replacement.StatusConditions().Root().IsTrue() {
+		return reconcile.Result{}, nil
+	}
+	if err := c.kubeClient.Delete(ctx, nodeClaim); err != nil {
+		return reconcile.Result{}, client.IgnoreNotFound(fmt.Errorf("deleting nodeclaim, %w", err))
+	}
+	log.FromContext(ctx).WithValues("replacement", klog.KRef("", replacement.Name)).Info("initiating delete for rebalance recommendation")
+	c.recorder.Publish(ReplacementReadyEvent(nodeClaim, replacement))
+	metrics.NodeClaimsDisruptedTotal.Inc(map[string]string{
+		metrics.ReasonLabel:       string(messages.RebalanceRecommendationKind),
+		metrics.NodePoolLabel:     nodeClaim.Labels[karpv1.NodePoolLabelKey],
+		metrics.CapacityTypeLabel: nodeClaim.Labels[karpv1.CapacityTypeLabelKey],
+	})
+	return reconcile.Result{}, nil
+}
+
+// relaunch launches another replacement for a NodeClaim whose replacement was deleted before it became ready, once the
+// backoff since the last replacement has passed. NodeClaims that have run out of replacement attempts are no longer
+// replaced, and are drained when they're interrupted.
+func (c *Controller) relaunch(ctx context.Context, nodeClaim *karpv1.NodeClaim) (reconcile.Result, error) {
+	attempts := interruption.ReplacementAttempts(nodeClaim)
+	if attempts >= interruption.MaxReplacementAttempts {
+		stored := nodeClaim.DeepCopy()
+		patched := nodeClaim.DeepCopy()
+		delete(patched.Annotations, v1.AnnotationRebalanceReplacement)
+		if err := c.kubeClient.Patch(ctx, patched, client.MergeFrom(stored)); err != nil {
+			return reconcile.Result{}, client.IgnoreNotFound(fmt.Errorf("patching nodeclaim, %w", err))
+		}
+		log.FromContext(ctx).WithValues("attempts", attempts).Info("abandoning replacement from rebalance recommendation")
+		c.recorder.Publish(ReplacementAttemptsExhaustedEvent(nodeClaim, attempts))
+		return reconcile.Result{}, nil
+	}
+	if replacedAt, err := time.Parse(time.RFC3339, nodeClaim.Annotations[v1.AnnotationRebalanceReplacedAt]); err == nil {
+		if backoff := interruption.ReplacementBackoff - c.clk.Since(replacedAt); backoff > 0 {
+			return reconcile.Result{RequeueAfter: backoff}, nil
+		}
+	}
+	relaunched, err := interruption.Replace(ctx, c.kubeClient, c.clk, nodeClaim)
+	if err != nil {
+		return reconcile.Result{}, fmt.Errorf("replacing nodeclaim, %w", err)
+	}
+	if relaunched != nil {
+		log.FromContext(ctx).WithValues("replacement", klog.KRef("", relaunched.Name), "attempts", attempts+1).Info("relaunching replacement from rebalance recommendation")
+	}
+	return reconcile.Result{}, nil
+}
+
+// allowDisruption removes the do-not-disrupt annotation from a replacement and its node once the NodeClaim that it
+// replaces is gone, unless the NodePool's template sets the annotation. The node is patched before the replacement
+// since the replacement's annotations are only copied onto its node when the node registers.
+func (c *Controller) allowDisruption(ctx context.Context, replacement *karpv1.NodeClaim) error {
+	if err := c.kubeClient.Get(ctx, client.ObjectKey{Name: replacement.Annotations[v1.AnnotationRebalanceReplaces]}, &karpv1.NodeClaim{}); err == nil {
+		return nil
+	} else if !errors.IsNotFound(err) {
+		return fmt.Errorf("getting replaced nodeclaim, %w", err)
+	}
+	nodePool := &karpv1.NodePool{}
+	if err := c.kubeClient.Get(ctx, client.ObjectKey{Name: replacement.Labels[karpv1.NodePoolLabelKey]}, nodePool); client.IgnoreNotFound(err) != nil {
+		return fmt.Errorf("getting nodepool, %w", err)
+	}
+	protected := nodePool.Spec.Template.Annotations[karpv1.DoNotDisruptAnnotationKey] == "true"
+	if replacement.Status.NodeName != "" && !protected {
+		node := &corev1.Node{}
+		if err := c.kubeClient.Get(ctx, client.ObjectKey{Name: replacement.Status.NodeName}, node); client.IgnoreNotFound(err) != nil {
+			return fmt.Errorf("getting node, %w", err)
+		} else if err == nil {
+			stored := node.DeepCopy()
+			delete(node.Annotations, karpv1.DoNotDisruptAnnotationKey)
+			if !equality.Semantic.DeepEqual(stored, node) {
+				if err = c.kubeClient.Patch(ctx, node, client.MergeFrom(stored)); err != nil {
+					return client.IgnoreNotFound(fmt.Errorf("patching node, %w", err))
+				}
+			}
+		}
+	}
+	stored := replacement.DeepCopy()
+	delete(replacement.Annotations, v1.AnnotationRebalanceReplaces)
+	if !protected {
+		delete(replacement.Annotations, karpv1.DoNotDisruptAnnotationKey)
+	}
+	if err := c.kubeClient.Patch(ctx, replacement, client.MergeFrom(stored)); err != nil {
+		return client.IgnoreNotFound(fmt.Errorf("patching replacement nodeclaim, %w", err))
+	}
+	log.FromContext(ctx).V(1).Info("allowing disruption of rebalance replacement")
+	return nil
+}
+
+func (c *Controller) Register(_ context.Context, m manager.Manager) error {
+	return controllerruntime.NewControllerManagedBy(m).
+		Named("nodeclaim.rebalance").
+		For(&karpv1.NodeClaim{}, builder.WithPredicates(nodeclaim.IsManagedPredicateFuncs(c.cloudProvider), predicate.NewPredicateFuncs(func(o client.Object) bool {
+			return isReplacedNodeClaim(o.(*karpv1.NodeClaim)) || isReplacement(o.(*karpv1.NodeClaim))
+		}))).
+		// Changes to a replacement, such as it becoming ready, are enqueued for the NodeClaim that it replaces, and
+		// changes to a replaced NodeClaim, such as it being deleted, are enqueued for its replacement
+		Watches(&karpv1.NodeClaim{}, handler.EnqueueRequestsFromMapFunc(func(_ context.Context, o client.Object) []reconcile.Request {
+			var requests []reconcile.Request
+			for _, key := range []string{v1.AnnotationRebalanceReplaces, v1.AnnotationRebalanceReplacement} {
+				if name := o.GetAnnotations()[key]; name != "" {
+					requests = append(requests, reconcile.Request{NamespacedName: types.NamespacedName{Name: name}})
+				}
+			}
+			return requests
+		})).
+		WithOptions(controller.Options{
+			RateLimiter: reasonable.RateLimiter(),
+		}).
+		Complete(reconcile.AsReconciler(m.GetClient(), c))
+}
+
+func isReplacement(nc *karpv1.NodeClaim) bool {
+	return nc.Annotations[v1.AnnotationRebalanceReplaces] != "" && nc.DeletionTimestamp.IsZero()
+}
+
+func isReplacedNodeClaim(nc *karpv1.NodeClaim) bool {
+	return nc.Annotations[v1.AnnotationRebalanceReplacement] != "" && nc.DeletionTimestamp.IsZero()
+}
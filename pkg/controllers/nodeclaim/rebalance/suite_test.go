@@ -0,0 +1,215 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rebalance_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/samber/lo"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+	clock "k8s.io/utils/clock/testing"
+	karpv1 "sigs.k8s.io/karpenter/pkg/apis/v1"
+	"sigs.k8s.io/karpenter/pkg/events"
+	coreoptions "sigs.k8s.io/karpenter/pkg/operator/options"
+	coretest "sigs.k8s.io/karpenter/pkg/test"
+	"sigs.k8s.io/karpenter/pkg/test/v1alpha1"
+
+	"github.com/aws/karpenter-provider-aws/pkg/apis"
+	v1 "github.com/aws/karpenter-provider-aws/pkg/apis/v1"
+	"github.com/aws/karpenter-provider-aws/pkg/cloudprovider"
+	"github.com/aws/karpenter-provider-aws/pkg/controllers/interruption"
+	"github.com/aws/karpenter-provider-aws/pkg/controllers/nodeclaim/rebalance"
+	"github.com/aws/karpenter-provider-aws/pkg/operator/options"
+	"github.com/aws/karpenter-provider-aws/pkg/test"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	. "sigs.k8s.io/karpenter/pkg/test/expectations"
+	. "sigs.k8s.io/karpenter/pkg/utils/testing"
+)
+
+var ctx context.Context
+var awsEnv *test.Environment
+var env *coretest.Environment
+var rebalanceController *rebalance.Controller
+var fakeClock *clock.FakeClock
+
+func TestAPIs(t *testing.T) {
+	ctx = TestContextWithLogger(t)
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Rebalance")
+}
+
+var _ = BeforeSuite(func() {
+	env = coretest.NewEnvironment(coretest.WithCRDs(apis.CRDs...), coretest.WithCRDs(v1alpha1.CRDs...))
+	ctx = coreoptions.ToContext(ctx, coretest.Options())
+	ctx = options.ToContext(ctx, test.Options())
+	awsEnv = test.NewEnvironment(ctx, env)
+	recorder := events.NewRecorder(&record.FakeRecorder{})
+	cloudProvider := cloudprovider.New(awsEnv.InstanceTypesProvider, awsEnv.InstanceProvider, recorder,
+		env.Client, awsEnv.AMIProvider, awsEnv.SecurityGroupProvider)
+	fakeClock = clock.NewFakeClock(time.Now())
+	rebalanceController = rebalance.NewController(env.Client, fakeClock, cloudProvider, recorder)
+})
+
+var _ = AfterSuite(func() {
+	Expect(env.Stop()).To(Succeed(), "Failed to stop environment")
+})
+
+var _ = BeforeEach(func() {
+	awsEnv.Reset()
+})
+
+var _ = AfterEach(func() {
+	ExpectCleanedUp(ctx, env.Client)
+})
+
+var _ = Describe("Rebalance", func() {
+	var nodePool *karpv1.NodePool
+	var nodeClaim *karpv1.NodeClaim
+	var replacement *karpv1.NodeClaim
+
+	BeforeEach(func() {
+		nodePool = coretest.NodePool()
+		replacement = coretest.NodeClaim(karpv1.NodeClaim{
+			ObjectMeta: metav1.ObjectMeta{
+				Labels: map[string]string{
+					karpv1.NodePoolLabelKey: nodePool.Name,
+				},
+			},
+		})
+		nodeClaim = coretest.NodeClaim(karpv1.NodeClaim{
+			ObjectMeta: metav1.ObjectMeta{
+				Labels: map[string]string{
+					karpv1.NodePoolLabelKey: nodePool.Name,
+				},
+				Annotations: map[string]string{
+					v1.AnnotationRebalanceReplacement: replacement.Name,
+				},
+			},
+		})
+		replacement.Annotations = map[string]string{v1.AnnotationRebalanceReplaces: nodeClaim.Name}
+	})
+	It("should delete the nodeclaim once its replacement is ready", func() {
+		replacement.StatusConditions().SetTrue(karpv1.ConditionTypeLaunched)
+		replacement.StatusConditions().SetTrue(karpv1.ConditionTypeRegistered)
+		replacement.StatusConditions().SetTrue(karpv1.ConditionTypeInitialized)
+		ExpectApplied(ctx, env.Client, nodePool, nodeClaim, replacement)
+		ExpectObjectReconciled(ctx, env.Client, rebalanceController, nodeClaim)
+		ExpectNotFound(ctx, env.Client, nodeClaim)
+		ExpectExists(ctx, env.Client, replacement)
+	})
+	It("should not delete the nodeclaim until its replacement is ready", func() {
+		replacement.StatusConditions().SetTrue(karpv1.ConditionTypeLaunched)
+		ExpectApplied(ctx, env.Client, nodePool, nodeClaim, replacement)
+		ExpectObjectReconciled(ctx, env.Client, rebalanceController, nodeClaim)
+		nodeClaim = ExpectExists(ctx, env.Client, nodeClaim)
+		Expect(nodeClaim.DeletionTimestamp.IsZero()).To(BeTrue())
+	})
+	It("should launch another replacement when the replacement no longer exists", func() {
+		ExpectApplied(ctx, env.Client, nodePool, nodeClaim)
+		ExpectObjectReconciled(ctx, env.Client, rebalanceController, nodeClaim)
+		nodeClaim = ExpectExists(ctx, env.Client, nodeClaim)
+		Expect(nodeClaim.DeletionTimestamp.IsZero()).To(BeTrue())
+
+		relaunched, ok := lo.Find(ExpectNodeClaims(ctx, env.Client), func(nc *karpv1.NodeClaim) bool { return nc.Name != nodeClaim.Name })
+		Expect(ok).To(BeTrue())
+		Expect(nodeClaim.Annotations).To(HaveKeyWithValue(v1.AnnotationRebalanceReplacement, relaunched.Name))
+		Expect(nodeClaim.Annotations).To(HaveKeyWithValue(v1.AnnotationRebalanceReplacementAttempts, "1"))
+		Expect(relaunched.Annotations).To(HaveKeyWithValue(v1.AnnotationRebalanceReplaces, nodeClaim.Name))
+		Expect(relaunched.Annotations).To(HaveKeyWithValue(karpv1.DoNotDisruptAnnotationKey, "true"))
+	})
+	It("should wait for the backoff since the last replacement before launching another replacement", func() {
+		nodeClaim.Annotations = lo.Assign(nodeClaim.Annotations, map[string]string{
+			v1.AnnotationRebalanceReplacementAttempts: "1",
+			v1.AnnotationRebalanceReplacedAt:          fakeClock.Now().UTC().Format(time.RFC3339),
+		})
+		ExpectApplied(ctx, env.Client, nodePool, nodeClaim)
+		result := ExpectObjectReconciled(ctx, env.Client, rebalanceController, nodeClaim)
+		Expect(result.RequeueAfter).To(BeNumerically(">", 0))
+		Expect(result.RequeueAfter).To(BeNumerically("<=", interruption.ReplacementBackoff))
+		Expect(ExpectNodeClaims(ctx, env.Client)).To(HaveLen(1))
+
+		fakeClock.Step(interruption.ReplacementBackoff)
+		ExpectObjectReconciled(ctx, env.Client, rebalanceController, nodeClaim)
+		Expect(ExpectNodeClaims(ctx, env.Client)).To(HaveLen(2))
+		nodeClaim = ExpectExists(ctx, env.Client, nodeClaim)
+		Expect(nodeClaim.Annotations).To(HaveKeyWithValue(v1.AnnotationRebalanceReplacementAttempts, "2"))
+		Expect(nodeClaim.Annotations).To(HaveKeyWithValue(v1.AnnotationRebalanceReplacedAt, fakeClock.Now().UTC().Format(time.RFC3339)))
+	})
+	It("should stop replacing the nodeclaim once it runs out of replacement attempts", func() {
+		nodeClaim.Annotations = lo.Assign(nodeClaim.Annotations, map[string]string{
+			v1.AnnotationRebalanceReplacementAttempts: fmt.Sprint(interruption.MaxReplacementAttempts),
+			v1.AnnotationRebalanceReplacedAt:          fakeClock.Now().Add(-interruption.ReplacementBackoff).UTC().Format(time.RFC3339),
+		})
+		ExpectApplied(ctx, env.Client, nodePool, nodeClaim)
+		ExpectObjectReconciled(ctx, env.Client, rebalanceController, nodeClaim)
+		Expect(ExpectNodeClaims(ctx, env.Client)).To(HaveLen(1))
+		nodeClaim = ExpectExists(ctx, env.Client, nodeClaim)
+		Expect(nodeClaim.DeletionTimestamp.IsZero()).To(BeTrue())
+		Expect(nodeClaim.Annotations).ToNot(HaveKey(v1.AnnotationRebalanceReplacement))
+		Expect(nodeClaim.Annotations).To(HaveKeyWithValue(v1.AnnotationRebalanceReplacementAttempts, fmt.Sprint(interruption.MaxReplacementAttempts)))
+	})
+	Context("Disruption", func() {
+		var node *corev1.Node
+		BeforeEach(func() {
+			replacement.Annotations[karpv1.DoNotDisruptAnnotationKey] = "true"
+			node = coretest.Node(coretest.NodeOptions{ObjectMeta: metav1.ObjectMeta{
+				Annotations: map[string]string{karpv1.DoNotDisruptAnnotationKey: "true"},
+			}})
+			replacement.Status.NodeName = node.Name
+		})
+		It("should not allow the replacement to be disrupted while the nodeclaim exists", func() {
+			ExpectApplied(ctx, env.Client, nodePool, nodeClaim, replacement, node)
+			ExpectObjectReconciled(ctx, env.Client, rebalanceController, replacement)
+			replacement = ExpectExists(ctx, env.Client, replacement)
+			Expect(replacement.Annotations).To(HaveKeyWithValue(karpv1.DoNotDisruptAnnotationKey, "true"))
+			node = ExpectExists(ctx, env.Client, node)
+			Expect(node.Annotations).To(HaveKeyWithValue(karpv1.DoNotDisruptAnnotationKey, "true"))
+		})
+		It("should allow the replacement and its node to be disrupted once the nodeclaim is gone", func() {
+			ExpectApplied(ctx, env.Client, nodePool, replacement, node)
+			ExpectObjectReconciled(ctx, env.Client, rebalanceController, replacement)
+			replacement = ExpectExists(ctx, env.Client, replacement)
+			Expect(replacement.Annotations).ToNot(HaveKey(karpv1.DoNotDisruptAnnotationKey))
+			Expect(replacement.Annotations).ToNot(HaveKey(v1.AnnotationRebalanceReplaces))
+			node = ExpectExists(ctx, env.Client, node)
+			Expect(node.Annotations).ToNot(HaveKey(karpv1.DoNotDisruptAnnotationKey))
+		})
+		It("should keep the do-not-disrupt annotation when it's set by the nodepool's template", func() {
+			nodePool.Spec.Template.Annotations = map[string]string{karpv1.DoNotDisruptAnnotationKey: "true"}
+			ExpectApplied(ctx, env.Client, nodePool, replacement, node)
+			ExpectObjectReconciled(ctx, env.Client, rebalanceController, replacement)
+			replacement = ExpectExists(ctx, env.Client, replacement)
+			Expect(replacement.Annotations).To(HaveKeyWithValue(karpv1.DoNotDisruptAnnotationKey, "true"))
+			Expect(replacement.Annotations).ToNot(HaveKey(v1.AnnotationRebalanceReplaces))
+			node = ExpectExists(ctx, env.Client, node)
+			Expect(node.Annotations).To(HaveKeyWithValue(karpv1.DoNotDisruptAnnotationKey, "true"))
+		})
+	})
+	It("should not delete nodeclaims that aren't being replaced", func() {
+		nodeClaim.Annotations = nil
+		ExpectApplied(ctx, env.Client, nodePool, nodeClaim)
+		ExpectObjectReconciled(ctx, env.Client, rebalanceController, nodeClaim)
+		nodeClaim = ExpectExists(ctx, env.Client, nodeClaim)
+		Expect(nodeClaim.DeletionTimestamp.IsZero()).To(BeTrue())
+		Expect(ExpectNodeClaims(ctx, env.Client)).To(HaveLen(1))
+	})
+})
@@ -0,0 +1,68 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package nodeclass
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/samber/lo"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	v1 "github.com/aws/karpenter-provider-aws/pkg/apis/v1"
+	"github.com/aws/karpenter-provider-aws/pkg/providers/capacityreservation"
+)
+
+type CapacityReservation struct {
+	capacityReservationProvider capacityreservation.Provider
+}
+
+func (c *CapacityReservation) Reconcile(ctx context.Context, nodeClass *v1.EC2NodeClass) (reconcile.Result, error) {
+	if len(nodeClass.Spec.CapacityReservationSelectorTerms) == 0 {
+		nodeClass.Status.CapacityReservations = nil
+		nodeClass.StatusConditions().SetTrue(v1.ConditionTypeCapacityReservationsReady)
+		return reconcile.Result{}, nil
+	}
+	capacityReservations, err := c.capacityReservationProvider.List(ctx, nodeClass)
+	if err != nil {
+		return reconcile.Result{}, fmt.Errorf("getting capacity reservations, %w", err)
+	}
+	if len(capacityReservations) == 0 {
+		nodeClass.Status.CapacityReservations = nil
+		nodeClass.StatusConditions().SetFalse(v1.ConditionTypeCapacityReservationsReady, "CapacityReservationsNotFound", "CapacityReservationSelector did not match any active CapacityReservations")
+		// Reservations may become active after the EC2NodeClass has been created, so we need to continue to re-check
+		return reconcile.Result{RequeueAfter: time.Minute}, nil
+	}
+	sort.Slice(capacityReservations, func(i, j int) bool {
+		return lo.FromPtr(capacityReservations[i].CapacityReservationId) < lo.FromPtr(capacityReservations[j].CapacityReservationId)
+	})
+	nodeClass.Status.CapacityReservations = lo.Map(capacityReservations, func(cr ec2types.CapacityReservation, _ int) v1.CapacityReservation {
+		return v1.CapacityReservation{
+			ID:                    lo.FromPtr(cr.CapacityReservationId),
+			InstanceType:          lo.FromPtr(cr.InstanceType),
+			AvailabilityZone:      lo.FromPtr(cr.AvailabilityZone),
+			OwnerID:               lo.FromPtr(cr.OwnerId),
+			InstanceMatchCriteria: string(cr.InstanceMatchCriteria),
+			ReservationType:       string(lo.Ternary(cr.ReservationType != "", cr.ReservationType, ec2types.CapacityReservationTypeDefault)),
+			EndTime:               lo.Ternary(cr.EndDate != nil, &metav1.Time{Time: lo.FromPtr(cr.EndDate)}, nil),
+		}
+	})
+	nodeClass.StatusConditions().SetTrue(v1.ConditionTypeCapacityReservationsReady)
+	return reconcile.Result{RequeueAfter: time.Minute}, nil
+}
@@ -0,0 +1,207 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package nodeclass_test
+
+import (
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/samber/lo"
+
+	v1 "github.com/aws/karpenter-provider-aws/pkg/apis/v1"
+	"github.com/aws/karpenter-provider-aws/pkg/test"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	. "sigs.k8s.io/karpenter/pkg/test/expectations"
+)
+
+var _ = Describe("NodeClass Capacity Reservation Status Controller", func() {
+	BeforeEach(func() {
+		nodeClass = test.EC2NodeClass(v1.EC2NodeClass{
+			Spec: v1.EC2NodeClassSpec{
+				SubnetSelectorTerms: []v1.SubnetSelectorTerm{
+					{
+						Tags: map[string]string{"*": "*"},
+					},
+				},
+				SecurityGroupSelectorTerms: []v1.SecurityGroupSelectorTerm{
+					{
+						Tags: map[string]string{"*": "*"},
+					},
+				},
+				AMIFamily: lo.ToPtr(v1.AMIFamilyCustom),
+				AMISelectorTerms: []v1.AMISelectorTerm{
+					{
+						Tags: map[string]string{"*": "*"},
+					},
+				},
+			},
+		})
+		awsEnv.EC2API.DescribeCapacityReservationsOutput.Set(&ec2.DescribeCapacityReservationsOutput{
+			CapacityReservations: []ec2types.CapacityReservation{
+				{
+					CapacityReservationId:  aws.String("cr-test2"),
+					InstanceType:           aws.String("m5.large"),
+					AvailabilityZone:       aws.String("test-zone-1b"),
+					OwnerId:                aws.String("012345678901"),
+					InstanceMatchCriteria:  ec2types.InstanceMatchCriteriaTargeted,
+					AvailableInstanceCount: aws.Int32(5),
+					State:                  ec2types.CapacityReservationStateActive,
+					Tags:                   []ec2types.Tag{{Key: aws.String("Name"), Value: aws.String("test-cr-2")}},
+				},
+				{
+					CapacityReservationId:  aws.String("cr-test1"),
+					InstanceType:           aws.String("m5.large"),
+					AvailabilityZone:       aws.String("test-zone-1a"),
+					OwnerId:                aws.String("012345678901"),
+					InstanceMatchCriteria:  ec2types.InstanceMatchCriteriaOpen,
+					AvailableInstanceCount: aws.Int32(10),
+					State:                  ec2types.CapacityReservationStateActive,
+					Tags:                   []ec2types.Tag{{Key: aws.String("Name"), Value: aws.String("test-cr-1")}},
+				},
+				{
+					CapacityReservationId:  aws.String("cr-test3"),
+					InstanceType:           aws.String("m5.large"),
+					AvailabilityZone:       aws.String("test-zone-1a"),
+					OwnerId:                aws.String("012345678901"),
+					InstanceMatchCriteria:  ec2types.InstanceMatchCriteriaOpen,
+					AvailableInstanceCount: aws.Int32(10),
+					State:                  ec2types.CapacityReservationStateExpired,
+					Tags:                   []ec2types.Tag{{Key: aws.String("Name"), Value: aws.String("test-cr-3")}},
+				},
+			},
+		})
+	})
+	It("should not resolve capacity reservations when no selector terms are specified", func() {
+		ExpectApplied(ctx, env.Client, nodeClass)
+		ExpectObjectReconciled(ctx, env.Client, controller, nodeClass)
+		nodeClass = ExpectExists(ctx, env.Client, nodeClass)
+		Expect(nodeClass.Status.CapacityReservations).To(BeEmpty())
+		Expect(nodeClass.StatusConditions().Get(v1.ConditionTypeCapacityReservationsReady).IsTrue()).To(BeTrue())
+	})
+	It("should resolve active capacity reservations by tags", func() {
+		nodeClass.Spec.CapacityReservationSelectorTerms = []v1.CapacityReservationSelectorTerm{
+			{
+				Tags: map[string]string{"*": "*"},
+			},
+		}
+		ExpectApplied(ctx, env.Client, nodeClass)
+		ExpectObjectReconciled(ctx, env.Client, controller, nodeClass)
+		nodeClass = ExpectExists(ctx, env.Client, nodeClass)
+		Expect(nodeClass.Status.CapacityReservations).To(Equal([]v1.CapacityReservation{
+			{
+				ID:                    "cr-test1",
+				InstanceType:          "m5.large",
+				AvailabilityZone:      "test-zone-1a",
+				OwnerID:               "012345678901",
+				InstanceMatchCriteria: string(ec2types.InstanceMatchCriteriaOpen),
+				ReservationType:       v1.CapacityReservationTypeDefault,
+			},
+			{
+				ID:                    "cr-test2",
+				InstanceType:          "m5.large",
+				AvailabilityZone:      "test-zone-1b",
+				OwnerID:               "012345678901",
+				InstanceMatchCriteria: string(ec2types.InstanceMatchCriteriaTargeted),
+				ReservationType:       v1.CapacityReservationTypeDefault,
+			},
+		}))
+		Expect(nodeClass.StatusConditions().Get(v1.ConditionTypeCapacityReservationsReady).IsTrue()).To(BeTrue())
+	})
+	It("should resolve capacity reservations by id", func() {
+		nodeClass.Spec.CapacityReservationSelectorTerms = []v1.CapacityReservationSelectorTerm{
+			{
+				ID: "cr-test2",
+			},
+		}
+		ExpectApplied(ctx, env.Client, nodeClass)
+		ExpectObjectReconciled(ctx, env.Client, controller, nodeClass)
+		nodeClass = ExpectExists(ctx, env.Client, nodeClass)
+		Expect(nodeClass.Status.CapacityReservations).To(HaveLen(1))
+		Expect(nodeClass.Status.CapacityReservations[0].ID).To(Equal("cr-test2"))
+		Expect(nodeClass.StatusConditions().Get(v1.ConditionTypeCapacityReservationsReady).IsTrue()).To(BeTrue())
+	})
+	It("should resolve capacity reservations by tags and owner", func() {
+		nodeClass.Spec.CapacityReservationSelectorTerms = []v1.CapacityReservationSelectorTerm{
+			{
+				Tags:    map[string]string{"Name": "test-cr-1"},
+				OwnerID: "012345678901",
+			},
+		}
+		ExpectApplied(ctx, env.Client, nodeClass)
+		ExpectObjectReconciled(ctx, env.Client, controller, nodeClass)
+		nodeClass = ExpectExists(ctx, env.Client, nodeClass)
+		Expect(nodeClass.Status.CapacityReservations).To(HaveLen(1))
+		Expect(nodeClass.Status.CapacityReservations[0].ID).To(Equal("cr-test1"))
+	})
+	It("should resolve capacity blocks along with their end time", func() {
+		endTime := time.Now().Add(time.Hour).Truncate(time.Second)
+		awsEnv.EC2API.DescribeCapacityReservationsOutput.Set(&ec2.DescribeCapacityReservationsOutput{
+			CapacityReservations: []ec2types.CapacityReservation{
+				{
+					CapacityReservationId:  aws.String("cr-block1"),
+					InstanceType:           aws.String("p5.48xlarge"),
+					AvailabilityZone:       aws.String("test-zone-1a"),
+					OwnerId:                aws.String("012345678901"),
+					InstanceMatchCriteria:  ec2types.InstanceMatchCriteriaTargeted,
+					ReservationType:        ec2types.CapacityReservationTypeCapacityBlock,
+					AvailableInstanceCount: aws.Int32(2),
+					EndDate:                aws.Time(endTime),
+					State:                  ec2types.CapacityReservationStateActive,
+				},
+			},
+		})
+		nodeClass.Spec.CapacityReservationSelectorTerms = []v1.CapacityReservationSelectorTerm{
+			{
+				ID: "cr-block1",
+			},
+		}
+		ExpectApplied(ctx, env.Client, nodeClass)
+		ExpectObjectReconciled(ctx, env.Client, controller, nodeClass)
+		nodeClass = ExpectExists(ctx, env.Client, nodeClass)
+		Expect(nodeClass.Status.CapacityReservations).To(HaveLen(1))
+		Expect(nodeClass.Status.CapacityReservations[0].ReservationType).To(Equal(v1.CapacityReservationTypeCapacityBlock))
+		Expect(nodeClass.Status.CapacityReservations[0].EndTime).ToNot(BeNil())
+		Expect(nodeClass.Status.CapacityReservations[0].EndTime.Time.Equal(endTime)).To(BeTrue())
+	})
+	It("should not resolve capacity reservations which are not active", func() {
+		nodeClass.Spec.CapacityReservationSelectorTerms = []v1.CapacityReservationSelectorTerm{
+			{
+				ID: "cr-test3",
+			},
+		}
+		ExpectApplied(ctx, env.Client, nodeClass)
+		ExpectObjectReconciled(ctx, env.Client, controller, nodeClass)
+		nodeClass = ExpectExists(ctx, env.Client, nodeClass)
+		Expect(nodeClass.Status.CapacityReservations).To(BeEmpty())
+		Expect(nodeClass.StatusConditions().Get(v1.ConditionTypeCapacityReservationsReady).IsFalse()).To(BeTrue())
+	})
+	It("should not resolve capacity reservations owned by a different account", func() {
+		nodeClass.Spec.CapacityReservationSelectorTerms = []v1.CapacityReservationSelectorTerm{
+			{
+				Tags:    map[string]string{"*": "*"},
+				OwnerID: "111111111111",
+			},
+		}
+		ExpectApplied(ctx, env.Client, nodeClass)
+		ExpectObjectReconciled(ctx, env.Client, controller, nodeClass)
+		nodeClass = ExpectExists(ctx, env.Client, nodeClass)
+		Expect(nodeClass.Status.CapacityReservations).To(BeEmpty())
+		Expect(nodeClass.StatusConditions().Get(v1.ConditionTypeCapacityReservationsReady).IsFalse()).To(BeTrue())
+	})
+})
@@ -19,6 +19,7 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/patrickmn/go-cache"
 	"go.uber.org/multierr"
 	"k8s.io/apimachinery/pkg/api/errors"
 	"sigs.k8s.io/karpenter/pkg/operator/injection"
@@ -44,10 +45,17 @@ import (
 	"sigs.k8s.io/karpenter/pkg/events"
 
 	v1 "github.com/aws/karpenter-provider-aws/pkg/apis/v1"
+	sdk "github.com/aws/karpenter-provider-aws/pkg/aws"
+	awscache "github.com/aws/karpenter-provider-aws/pkg/cache"
 	"github.com/aws/karpenter-provider-aws/pkg/providers/amifamily"
+	"github.com/aws/karpenter-provider-aws/pkg/providers/capacityreservation"
+	"github.com/aws/karpenter-provider-aws/pkg/providers/host"
 	"github.com/aws/karpenter-provider-aws/pkg/providers/instanceprofile"
+	"github.com/aws/karpenter-provider-aws/pkg/providers/instancetype"
 	"github.com/aws/karpenter-provider-aws/pkg/providers/launchtemplate"
+	"github.com/aws/karpenter-provider-aws/pkg/providers/placementgroup"
 	"github.com/aws/karpenter-provider-aws/pkg/providers/securitygroup"
+	"github.com/aws/karpenter-provider-aws/pkg/providers/servicequota"
 	"github.com/aws/karpenter-provider-aws/pkg/providers/subnet"
 )
 
@@ -60,16 +68,24 @@ type Controller struct {
 	recorder               events.Recorder
 	launchTemplateProvider launchtemplate.Provider
 
-	ami             *AMI
-	instanceProfile *InstanceProfile
-	subnet          *Subnet
-	securityGroup   *SecurityGroup
-	validation      *Validation
-	readiness       *Readiness //TODO : Remove this when we have sub status conditions
+	ami                 *AMI
+	instanceProfile     *InstanceProfile
+	subnet              *Subnet
+	securityGroup       *SecurityGroup
+	capacityReservation *CapacityReservation
+	placementGroup      *PlacementGroup
+	host                *Host
+	networkInterface    *NetworkInterface
+	launchPermissions   *LaunchPermissions
+	serviceQuota        *ServiceQuota
+	validation          *Validation
+	readiness           *Readiness //TODO : Remove this when we have sub status conditions
 }
 
-func NewController(kubeClient client.Client, recorder events.Recorder, subnetProvider subnet.Provider, securityGroupProvider securitygroup.Provider,
-	amiProvider amifamily.Provider, instanceProfileProvider instanceprofile.Provider, launchTemplateProvider launchtemplate.Provider) *Controller {
+func NewController(kubeClient client.Client, recorder events.Recorder, ec2api sdk.EC2API, subnetProvider subnet.Provider, securityGroupProvider securitygroup.Provider,
+	amiProvider amifamily.Provider, instanceProfileProvider instanceprofile.Provider, launchTemplateProvider launchtemplate.Provider,
+	capacityReservationProvider capacityreservation.Provider, placementGroupProvider placementgroup.Provider, hostProvider host.Provider,
+	instanceTypeProvider instancetype.Provider, serviceQuotaProvider servicequota.Provider) *Controller {
 
 	return &Controller{
 		kubeClient:             kubeClient,
@@ -78,9 +94,21 @@ func NewController(kubeClient client.Client, recorder events.Recorder, subnetPro
 		ami:                    &AMI{amiProvider: amiProvider},
 		subnet:                 &Subnet{subnetProvider: subnetProvider},
 		securityGroup:          &SecurityGroup{securityGroupProvider: securityGroupProvider},
+		capacityReservation:    &CapacityReservation{capacityReservationProvider: capacityReservationProvider},
+		placementGroup:         &PlacementGroup{placementGroupProvider: placementGroupProvider},
+		host:                   &Host{hostProvider: hostProvider},
+		networkInterface:       &NetworkInterface{subnetProvider: subnetProvider, securityGroupProvider: securityGroupProvider},
 		instanceProfile:        &InstanceProfile{instanceProfileProvider: instanceProfileProvider},
-		validation:             &Validation{},
-		readiness:              &Readiness{launchTemplateProvider: launchTemplateProvider},
+		launchPermissions: &LaunchPermissions{
+			kubeClient:             kubeClient,
+			ec2api:                 ec2api,
+			instanceTypeProvider:   instanceTypeProvider,
+			launchTemplateProvider: launchTemplateProvider,
+			cache:                  cache.New(awscache.LaunchPermissionsTTL, awscache.DefaultCleanupInterval),
+		},
+		serviceQuota: &ServiceQuota{instanceTypeProvider: instanceTypeProvider, serviceQuotaProvider: serviceQuotaProvider},
+		validation:   &Validation{},
+		readiness:    &Readiness{launchTemplateProvider: launchTemplateProvider},
 	}
 }
 
@@ -117,9 +145,16 @@ func (c *Controller) Reconcile(ctx context.Context, nodeClass *v1.EC2NodeClass)
 		c.ami,
 		c.subnet,
 		c.securityGroup,
+		c.capacityReservation,
+		c.placementGroup,
+		c.host,
+		c.networkInterface,
 		c.instanceProfile,
 		c.validation,
 		c.readiness,
+		// Launch templates for AL2023 can't be resolved until the readiness reconciler has resolved the cluster CIDR
+		c.launchPermissions,
+		c.serviceQuota,
 	} {
 		res, err := reconciler.Reconcile(ctx, nodeClass)
 		errs = multierr.Append(errs, err)
@@ -164,6 +199,9 @@ func (c *Controller) finalize(ctx context.Context, nodeClass *v1.EC2NodeClass) (
 	if err := c.launchTemplateProvider.DeleteAll(ctx, nodeClass); err != nil {
 		return reconcile.Result{}, fmt.Errorf("deleting launch templates, %w", err)
 	}
+	if _, err := c.placementGroup.Finalize(ctx, nodeClass); err != nil {
+		return reconcile.Result{}, err
+	}
 	controllerutil.RemoveFinalizer(nodeClass, v1.TerminationFinalizer)
 	if !equality.Semantic.DeepEqual(stored, nodeClass) {
 		// We use client.MergeFromWithOptimisticLock because patching a list with a JSON merge patch
@@ -0,0 +1,64 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package nodeclass
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/samber/lo"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	v1 "github.com/aws/karpenter-provider-aws/pkg/apis/v1"
+	"github.com/aws/karpenter-provider-aws/pkg/providers/host"
+)
+
+type Host struct {
+	hostProvider host.Provider
+}
+
+func (h *Host) Reconcile(ctx context.Context, nodeClass *v1.EC2NodeClass) (reconcile.Result, error) {
+	if len(nodeClass.Spec.HostSelectorTerms) == 0 {
+		nodeClass.Status.Hosts = nil
+		nodeClass.StatusConditions().SetTrue(v1.ConditionTypeHostsReady)
+		return reconcile.Result{}, nil
+	}
+	hosts, err := h.hostProvider.List(ctx, nodeClass)
+	if err != nil {
+		return reconcile.Result{}, fmt.Errorf("getting hosts, %w", err)
+	}
+	if len(hosts) == 0 {
+		nodeClass.Status.Hosts = nil
+		nodeClass.StatusConditions().SetFalse(v1.ConditionTypeHostsReady, "HostsNotFound", "HostSelector did not match any available Hosts")
+		// Hosts may be allocated after the EC2NodeClass has been created, so we need to continue to re-check
+		return reconcile.Result{RequeueAfter: time.Minute}, nil
+	}
+	sort.Slice(hosts, func(i, j int) bool {
+		return lo.FromPtr(hosts[i].HostId) < lo.FromPtr(hosts[j].HostId)
+	})
+	nodeClass.Status.Hosts = lo.Map(hosts, func(h ec2types.Host, _ int) v1.Host {
+		return v1.Host{
+			ID:               lo.FromPtr(h.HostId),
+			AvailabilityZone: lo.FromPtr(h.AvailabilityZone),
+			InstanceType:     lo.FromPtr(lo.FromPtr(h.HostProperties).InstanceType),
+			InstanceFamily:   lo.FromPtr(lo.FromPtr(h.HostProperties).InstanceFamily),
+		}
+	})
+	nodeClass.StatusConditions().SetTrue(v1.ConditionTypeHostsReady)
+	return reconcile.Result{RequeueAfter: time.Minute}, nil
+}
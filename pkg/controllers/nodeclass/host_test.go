@@ -0,0 +1,123 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package nodeclass_test
+
+import (
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/samber/lo"
+
+	v1 "github.com/aws/karpenter-provider-aws/pkg/apis/v1"
+	"github.com/aws/karpenter-provider-aws/pkg/test"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	. "sigs.k8s.io/karpenter/pkg/test/expectations"
+)
+
+var _ = Describe("NodeClass Host Status Controller", func() {
+	BeforeEach(func() {
+		nodeClass = test.EC2NodeClass(v1.EC2NodeClass{
+			Spec: v1.EC2NodeClassSpec{
+				SubnetSelectorTerms: []v1.SubnetSelectorTerm{
+					{
+						Tags: map[string]string{"*": "*"},
+					},
+				},
+				SecurityGroupSelectorTerms: []v1.SecurityGroupSelectorTerm{
+					{
+						Tags: map[string]string{"*": "*"},
+					},
+				},
+				AMIFamily: lo.ToPtr(v1.AMIFamilyCustom),
+				AMISelectorTerms: []v1.AMISelectorTerm{
+					{
+						Tags: map[string]string{"*": "*"},
+					},
+				},
+				Tenancy: lo.ToPtr(v1.TenancyHost),
+			},
+		})
+		awsEnv.EC2API.DescribeHostsOutput.Set(&ec2.DescribeHostsOutput{
+			Hosts: []ec2types.Host{
+				{
+					HostId:           aws.String("h-test1"),
+					AvailabilityZone: aws.String("test-zone-1a"),
+					State:            ec2types.AllocationStateAvailable,
+					HostProperties:   &ec2types.HostProperties{InstanceType: aws.String("mac2.metal")},
+					Tags:             []ec2types.Tag{{Key: aws.String("foo"), Value: aws.String("bar")}},
+				},
+				{
+					HostId:           aws.String("h-test2"),
+					AvailabilityZone: aws.String("test-zone-1b"),
+					State:            ec2types.AllocationStateAvailable,
+					HostProperties:   &ec2types.HostProperties{InstanceFamily: aws.String("m5")},
+				},
+				{
+					HostId:           aws.String("h-test3"),
+					AvailabilityZone: aws.String("test-zone-1c"),
+					State:            ec2types.AllocationStateReleased,
+					HostProperties:   &ec2types.HostProperties{InstanceFamily: aws.String("m5")},
+					Tags:             []ec2types.Tag{{Key: aws.String("foo"), Value: aws.String("bar")}},
+				},
+			},
+		})
+	})
+	It("should not resolve hosts when no host selector terms are specified", func() {
+		ExpectApplied(ctx, env.Client, nodeClass)
+		ExpectObjectReconciled(ctx, env.Client, controller, nodeClass)
+		nodeClass = ExpectExists(ctx, env.Client, nodeClass)
+		Expect(nodeClass.Status.Hosts).To(BeNil())
+		Expect(nodeClass.StatusConditions().Get(v1.ConditionTypeHostsReady).IsTrue()).To(BeTrue())
+	})
+	It("should resolve available hosts by tags", func() {
+		nodeClass.Spec.HostSelectorTerms = []v1.HostSelectorTerm{{Tags: map[string]string{"foo": "bar"}}}
+		ExpectApplied(ctx, env.Client, nodeClass)
+		ExpectObjectReconciled(ctx, env.Client, controller, nodeClass)
+		nodeClass = ExpectExists(ctx, env.Client, nodeClass)
+		Expect(nodeClass.Status.Hosts).To(Equal([]v1.Host{
+			{
+				ID:               "h-test1",
+				AvailabilityZone: "test-zone-1a",
+				InstanceType:     "mac2.metal",
+			},
+		}))
+		Expect(nodeClass.StatusConditions().Get(v1.ConditionTypeHostsReady).IsTrue()).To(BeTrue())
+	})
+	It("should resolve available hosts by id", func() {
+		nodeClass.Spec.HostSelectorTerms = []v1.HostSelectorTerm{{ID: "h-test2"}, {ID: "h-test3"}}
+		ExpectApplied(ctx, env.Client, nodeClass)
+		ExpectObjectReconciled(ctx, env.Client, controller, nodeClass)
+		nodeClass = ExpectExists(ctx, env.Client, nodeClass)
+		Expect(nodeClass.Status.Hosts).To(Equal([]v1.Host{
+			{
+				ID:               "h-test2",
+				AvailabilityZone: "test-zone-1b",
+				InstanceFamily:   "m5",
+			},
+		}))
+		Expect(nodeClass.StatusConditions().Get(v1.ConditionTypeHostsReady).IsTrue()).To(BeTrue())
+	})
+	It("should set the HostsReady condition to false when no hosts are available", func() {
+		nodeClass.Spec.HostSelectorTerms = []v1.HostSelectorTerm{{ID: "h-test3"}}
+		ExpectApplied(ctx, env.Client, nodeClass)
+		ExpectObjectReconciled(ctx, env.Client, controller, nodeClass)
+		nodeClass = ExpectExists(ctx, env.Client, nodeClass)
+		Expect(nodeClass.Status.Hosts).To(BeNil())
+		Expect(nodeClass.StatusConditions().Get(v1.ConditionTypeHostsReady).IsFalse()).To(BeTrue())
+		Expect(nodeClass.StatusConditions().Get(v1.ConditionTypeHostsReady).Reason).To(Equal("HostsNotFound"))
+	})
+})
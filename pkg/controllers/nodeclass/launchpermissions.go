@@ -0,0 +1,385 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package nodeclass
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/mitchellh/hashstructure/v2"
+	"github.com/patrickmn/go-cache"
+	"github.com/samber/lo"
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	karpv1 "sigs.k8s.io/karpenter/pkg/apis/v1"
+	"sigs.k8s.io/karpenter/pkg/cloudprovider"
+	"sigs.k8s.io/karpenter/pkg/scheduling"
+
+	v1 "github.com/aws/karpenter-provider-aws/pkg/apis/v1"
+	sdk "github.com/aws/karpenter-provider-aws/pkg/aws"
+	awscache "github.com/aws/karpenter-provider-aws/pkg/cache"
+	awserrors "github.com/aws/karpenter-provider-aws/pkg/errors"
+	"github.com/aws/karpenter-provider-aws/pkg/operator/options"
+	"github.com/aws/karpenter-provider-aws/pkg/providers/amifamily"
+	"github.com/aws/karpenter-provider-aws/pkg/providers/instancetype"
+	"github.com/aws/karpenter-provider-aws/pkg/providers/launchtemplate"
+)
+
+// LaunchPermissions verifies that the controller is authorized to launch instances with an EC2NodeClass by performing
+// the CreateLaunchTemplate, RunInstances and CreateFleet calls that a launch makes as DryRun requests. Failures are
+// surfaced before a NodeClaim is ever launched, rather than as launch failures. The condition is informational and
+// doesn't affect the readiness of the EC2NodeClass, since the DryRun requests can't replicate every launch exactly.
+type LaunchPermissions struct {
+	kubeClient             client.Client
+	ec2api                 sdk.EC2API
+	instanceTypeProvider   instancetype.Provider
+	launchTemplateProvider launchtemplate.Provider
+	cache                  *cache.Cache
+}
+
+// launchPermissionsFailure is the action which the controller isn't authorized to perform for a NodePool, and the
+// reason EC2 gave. Unverified failures are actions which couldn't be verified, rather than being unauthorized.
+type launchPermissionsFailure struct {
+	nodePool   string
+	action     string
+	message    string
+	unverified bool
+}
+
+func (l *LaunchPermissions) Reconcile(ctx context.Context, nodeClass *v1.EC2NodeClass) (reconcile.Result, error) {
+	// The DryRun requests are made with the resolved AMIs, subnets, security groups and instance profile and the
+	// EC2NodeClass' tags, so they can't be made until those have been resolved and validated
+	if !lo.EveryBy([]string{
+		v1.ConditionTypeAMIsReady,
+		v1.ConditionTypeSubnetsReady,
+		v1.ConditionTypeSecurityGroupsReady,
+		v1.ConditionTypeInstanceProfileReady,
+		v1.ConditionTypeValidationSucceeded,
+	}, func(t string) bool {
+		return nodeClass.StatusConditions().Get(t).IsTrue()
+	}) {
+		nodeClass.StatusConditions().SetUnknownWithReason(v1.ConditionTypeLaunchPermissionsReady, "DependenciesNotReady",
+			"Waiting for AMIs, Subnets, SecurityGroups and InstanceProfile to be resolved and validated")
+		return reconcile.Result{}, nil
+	}
+	// Launches are tagged with the NodePool that they're made for, which the recommended controller policy scopes
+	// them by, so the launch permissions are verified for every NodePool that references the EC2NodeClass
+	nodePools, err := l.nodePools(ctx, nodeClass)
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+	if len(nodePools) == 0 {
+		nodeClass.StatusConditions().SetUnknownWithReason(v1.ConditionTypeLaunchPermissionsReady, "NoNodePools",
+			"No NodePools reference the EC2NodeClass")
+		return reconcile.Result{}, nil
+	}
+	key, err := l.cacheKey(nodeClass, nodePools)
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+	if failure, ok := l.cache.Get(key); ok {
+		l.setCondition(nodeClass, failure.(*launchPermissionsFailure))
+		return reconcile.Result{RequeueAfter: awscache.LaunchPermissionsTTL}, nil
+	}
+	failure, err := l.dryRun(ctx, nodeClass, nodePools)
+	if err != nil {
+		// Errors other than authorization failures don't say anything about the launch permissions, so they're
+		// retried rather than failing the reconciliation of the EC2NodeClass
+		log.FromContext(ctx).Error(err, "failed validating launch permissions")
+		nodeClass.StatusConditions().SetUnknownWithReason(v1.ConditionTypeLaunchPermissionsReady, "ValidationFailed",
+			fmt.Sprintf("Failed validating launch permissions, %s", err))
+		return reconcile.Result{RequeueAfter: time.Minute}, nil
+	}
+	l.cache.SetDefault(key, failure)
+	l.setCondition(nodeClass, failure)
+	return reconcile.Result{RequeueAfter: awscache.LaunchPermissionsTTL}, nil
+}
+
+func (l *LaunchPermissions) setCondition(nodeClass *v1.EC2NodeClass, failure *launchPermissionsFailure) {
+	if failure == nil {
+		nodeClass.StatusConditions().SetTrue(v1.ConditionTypeLaunchPermissionsReady)
+		return
+	}
+	if failure.unverified {
+		nodeClass.StatusConditions().SetTrueWithReason(v1.ConditionTypeLaunchPermissionsReady, fmt.Sprintf("%sUnverified", failure.action),
+			fmt.Sprintf("Unable to verify %s for NodePool %q, %s", failure.action, failure.nodePool, failure.message))
+		return
+	}
+	nodeClass.StatusConditions().SetFalse(v1.ConditionTypeLaunchPermissionsReady, fmt.Sprintf("%sUnauthorized", failure.action),
+		fmt.Sprintf("Not authorized to perform %s for NodePool %q, %s", failure.action, failure.nodePool, failure.message))
+}
+
+// cacheKey identifies the inputs to the DryRun requests so that they're repeated as soon as any of them change
+func (l *LaunchPermissions) cacheKey(nodeClass *v1.EC2NodeClass, nodePools []string) (string, error) {
+	hash, err := hashstructure.Hash([]interface{}{
+		nodeClass.Name,
+		nodePools,
+		nodeClass.Spec,
+		nodeClass.Status.AMIs,
+		nodeClass.Status.Subnets,
+		nodeClass.Status.SecurityGroups,
+		nodeClass.Status.InstanceProfile,
+	}, hashstructure.FormatV2, &hashstructure.HashOptions{SlicesAsSets: true})
+	if err != nil {
+		return "", fmt.Errorf("hashing launch permissions inputs, %w", err)
+	}
+	return fmt.Sprintf("%s/%d", nodeClass.UID, hash), nil
+}
+
+// dryRun returns the first action in the launch path that the controller isn't authorized to perform for one of the
+// NodePools, or nil if it's authorized to perform all of them. Actions which couldn't be verified are only returned when
+// all of the others are authorized.
+func (l *LaunchPermissions) dryRun(ctx context.Context, nodeClass *v1.EC2NodeClass, nodePools []string) (*launchPermissionsFailure, error) {
+	instanceType, subnet, err := l.instanceTypeAndSubnet(ctx, nodeClass)
+	if err != nil {
+		return nil, err
+	}
+	// Without an instance type that can be launched there's nothing to authorize, the condition is re-evaluated once
+	// the cached result expires
+	if instanceType == nil {
+		return nil, nil
+	}
+	var unverified *launchPermissionsFailure
+	for _, nodePool := range nodePools {
+		failure, err := l.dryRunForNodePool(ctx, nodeClass, nodePool, instanceType, subnet)
+		if err != nil {
+			return nil, err
+		}
+		if failure != nil && !failure.unverified {
+			return failure, nil
+		}
+		if unverified == nil {
+			unverified = failure
+		}
+	}
+	return unverified, nil
+}
+
+// dryRunForNodePool returns the first action in the launch path that the controller isn't authorized to perform when
+// launching the instance type for the NodePool, or nil if it's authorized to perform all of them
+func (l *LaunchPermissions) dryRunForNodePool(ctx context.Context, nodeClass *v1.EC2NodeClass, nodePool string, instanceType *cloudprovider.InstanceType, subnet *v1.Subnet) (*launchPermissionsFailure, error) {
+	tags := lo.Assign(nodeClass.Spec.Tags, map[string]string{
+		fmt.Sprintf("kubernetes.io/cluster/%s", options.FromContext(ctx).ClusterName): "owned",
+		karpv1.NodePoolLabelKey: nodePool,
+		v1.EKSClusterNameTagKey: options.FromContext(ctx).ClusterName,
+		v1.LabelNodeClass:       nodeClass.Name,
+	})
+	inputs, err := l.launchTemplateProvider.CreateLaunchTemplateInputs(ctx, nodeClass, &karpv1.NodeClaim{}, []*cloudprovider.InstanceType{instanceType}, karpv1.CapacityTypeOnDemand, tags)
+	if err != nil {
+		return nil, fmt.Errorf("resolving launch template, %w", err)
+	}
+	if len(inputs) == 0 {
+		return nil, fmt.Errorf("resolving launch template, no launch template resolved for instance type %q", instanceType.Name)
+	}
+	launchTemplateInput := inputs[0]
+	launchTemplateInput.DryRun = aws.Bool(true)
+	for _, call := range []struct {
+		action string
+		dryRun func() error
+	}{
+		{action: "CreateLaunchTemplate", dryRun: func() error {
+			_, err := l.ec2api.CreateLaunchTemplate(ctx, launchTemplateInput)
+			return err
+		}},
+		{action: "RunInstances", dryRun: func() error {
+			_, err := l.ec2api.RunInstances(ctx, runInstancesInput(launchTemplateInput, instanceType.Name, subnet.ID))
+			return err
+		}},
+		{action: "CreateFleet", dryRun: func() error {
+			_, err := l.ec2api.CreateFleet(ctx, createFleetInput(launchTemplateInput, instanceType.Name, subnet.ID))
+			return err
+		}},
+	} {
+		err := call.dryRun()
+		if awserrors.IsDryRunError(err) {
+			continue
+		}
+		if awserrors.IsUnauthorizedOperation(err) {
+			_, message := awserrors.ToReasonMessage(err)
+			return &launchPermissionsFailure{nodePool: nodePool, action: call.action, message: message}, nil
+		}
+		// The CreateFleet request references the launch template by name, which doesn't exist until the first launch
+		// with the EC2NodeClass creates it. Until then, the CreateFleet permissions can't be verified.
+		if call.action == "CreateFleet" && awserrors.IsLaunchTemplateNotFound(err) {
+			return &launchPermissionsFailure{nodePool: nodePool, action: call.action, message: "launch template hasn't been created yet", unverified: true}, nil
+		}
+		// DryRun requests only return DryRunOperation or an authorization error, anything else is unexpected
+		return nil, fmt.Errorf("dry-running %s, %w", call.action, err)
+	}
+	return nil, nil
+}
+
+// instanceTypeAndSubnet selects the cheapest on-demand instance type that's compatible with one of the resolved AMIs,
+// along with a subnet in a zone that it's offered in
+func (l *LaunchPermissions) instanceTypeAndSubnet(ctx context.Context, nodeClass *v1.EC2NodeClass) (*cloudprovider.InstanceType, *v1.Subnet, error) {
+	instanceTypes, err := l.instanceTypeProvider.List(ctx, nodeClass)
+	if err != nil {
+		return nil, nil, fmt.Errorf("listing instance types, %w", err)
+	}
+	reqs := scheduling.NewRequirements(scheduling.NewRequirement(karpv1.CapacityTypeLabelKey, corev1.NodeSelectorOpIn, karpv1.CapacityTypeOnDemand))
+	instanceTypes = lo.Filter(instanceTypes, func(it *cloudprovider.InstanceType, _ int) bool {
+		return it.Offerings.Available().HasCompatible(reqs)
+	})
+	for _, it := range cloudprovider.InstanceTypes(instanceTypes).OrderByPrice(reqs) {
+		if len(amifamily.MapToInstanceTypes([]*cloudprovider.InstanceType{it}, nodeClass.Status.AMIs)) == 0 {
+			continue
+		}
+		if subnet, ok := lo.Find(nodeClass.Status.Subnets, func(s v1.Subnet) bool {
+			return it.Offerings.Available().HasCompatible(scheduling.NewRequirements(
+				scheduling.NewRequirement(karpv1.CapacityTypeLabelKey, corev1.NodeSelectorOpIn, karpv1.CapacityTypeOnDemand),
+				scheduling.NewRequirement(corev1.LabelTopologyZone, corev1.NodeSelectorOpIn, s.Zone),
+			))
+		}); ok {
+			return it, &subnet, nil
+		}
+	}
+	return nil, nil, nil
+}
+
+// nodePools returns the sorted names of the NodePools that reference the EC2NodeClass
+func (l *LaunchPermissions) nodePools(ctx context.Context, nodeClass *v1.EC2NodeClass) ([]string, error) {
+	nodePoolList := &karpv1.NodePoolList{}
+	if err := l.kubeClient.List(ctx, nodePoolList); err != nil {
+		return nil, fmt.Errorf("listing nodepools, %w", err)
+	}
+	nodePools := lo.FilterMap(nodePoolList.Items, func(np karpv1.NodePool, _ int) (string, bool) {
+		ref := np.Spec.Template.Spec.NodeClassRef
+		return np.Name, ref != nil && ref.Name == nodeClass.Name && ref.Kind == "EC2NodeClass"
+	})
+	sort.Strings(nodePools)
+	return nodePools, nil
+}
+
+// runInstancesInput converts a launch template into the equivalent RunInstances DryRun request
+func runInstancesInput(launchTemplateInput *ec2.CreateLaunchTemplateInput, instanceType, subnetID string) *ec2.RunInstancesInput {
+	data := launchTemplateInput.LaunchTemplateData
+	tags := launchTemplateInput.TagSpecifications[0].Tags
+	input := &ec2.RunInstancesInput{
+		DryRun:       aws.Bool(true),
+		MinCount:     aws.Int32(1),
+		MaxCount:     aws.Int32(1),
+		ImageId:      data.ImageId,
+		InstanceType: ec2types.InstanceType(instanceType),
+		IamInstanceProfile: &ec2types.IamInstanceProfileSpecification{
+			Name: data.IamInstanceProfile.Name,
+		},
+		UserData:         data.UserData,
+		SecurityGroupIds: data.SecurityGroupIds,
+		BlockDeviceMappings: lo.Map(data.BlockDeviceMappings, func(b ec2types.LaunchTemplateBlockDeviceMappingRequest, _ int) ec2types.BlockDeviceMapping {
+			mapping := ec2types.BlockDeviceMapping{DeviceName: b.DeviceName}
+			if b.Ebs != nil {
+				mapping.Ebs = &ec2types.EbsBlockDevice{
+					DeleteOnTermination: b.Ebs.DeleteOnTermination,
+					Encrypted:           b.Ebs.Encrypted,
+					Iops:                b.Ebs.Iops,
+					KmsKeyId:            b.Ebs.KmsKeyId,
+					SnapshotId:          b.Ebs.SnapshotId,
+					Throughput:          b.Ebs.Throughput,
+					VolumeSize:          b.Ebs.VolumeSize,
+					VolumeType:          b.Ebs.VolumeType,
+				}
+			}
+			return mapping
+		}),
+		MetadataOptions: &ec2types.InstanceMetadataOptionsRequest{
+			HttpEndpoint:            ec2types.InstanceMetadataEndpointState(data.MetadataOptions.HttpEndpoint),
+			HttpProtocolIpv6:        ec2types.InstanceMetadataProtocolState(data.MetadataOptions.HttpProtocolIpv6),
+			HttpPutResponseHopLimit: data.MetadataOptions.HttpPutResponseHopLimit,
+			HttpTokens:              ec2types.HttpTokensState(data.MetadataOptions.HttpTokens),
+			InstanceMetadataTags:    ec2types.InstanceMetadataTagsStateDisabled,
+		},
+		TagSpecifications: []ec2types.TagSpecification{
+			{ResourceType: ec2types.ResourceTypeInstance, Tags: tags},
+			{ResourceType: ec2types.ResourceTypeVolume, Tags: tags},
+			{ResourceType: ec2types.ResourceTypeNetworkInterface, Tags: tags},
+		},
+	}
+	if data.CpuOptions != nil {
+		input.CpuOptions = &ec2types.CpuOptionsRequest{
+			CoreCount:      data.CpuOptions.CoreCount,
+			ThreadsPerCore: data.CpuOptions.ThreadsPerCore,
+			AmdSevSnp:      data.CpuOptions.AmdSevSnp,
+		}
+	}
+	if data.Placement != nil {
+		input.Placement = &ec2types.Placement{
+			GroupId:              data.Placement.GroupId,
+			HostResourceGroupArn: data.Placement.HostResourceGroupArn,
+			Tenancy:              data.Placement.Tenancy,
+		}
+	}
+	// The subnet is specified on the network interfaces when they're defined
+	if len(data.NetworkInterfaces) == 0 {
+		input.SubnetId = aws.String(subnetID)
+		return input
+	}
+	input.NetworkInterfaces = lo.Map(data.NetworkInterfaces, func(ni ec2types.LaunchTemplateInstanceNetworkInterfaceSpecificationRequest, _ int) ec2types.InstanceNetworkInterfaceSpecification {
+		return ec2types.InstanceNetworkInterfaceSpecification{
+			AssociatePublicIpAddress:       ni.AssociatePublicIpAddress,
+			DeviceIndex:                    ni.DeviceIndex,
+			NetworkCardIndex:               ni.NetworkCardIndex,
+			InterfaceType:                  ni.InterfaceType,
+			Groups:                         ni.Groups,
+			Ipv6AddressCount:               ni.Ipv6AddressCount,
+			PrimaryIpv6:                    ni.PrimaryIpv6,
+			Ipv4PrefixCount:                ni.Ipv4PrefixCount,
+			SecondaryPrivateIpAddressCount: ni.SecondaryPrivateIpAddressCount,
+			// Network interfaces that are created in subnets of their own keep them
+			SubnetId: lo.Ternary(ni.SubnetId != nil, ni.SubnetId, aws.String(subnetID)),
+		}
+	})
+	return input
+}
+
+// createFleetInput returns the CreateFleet DryRun request that launches an instance with the launch template
+func createFleetInput(launchTemplateInput *ec2.CreateLaunchTemplateInput, instanceType, subnetID string) *ec2.CreateFleetInput {
+	tags := launchTemplateInput.TagSpecifications[0].Tags
+	return &ec2.CreateFleetInput{
+		DryRun: aws.Bool(true),
+		Type:   ec2types.FleetTypeInstant,
+		LaunchTemplateConfigs: []ec2types.FleetLaunchTemplateConfigRequest{
+			{
+				LaunchTemplateSpecification: &ec2types.FleetLaunchTemplateSpecificationRequest{
+					LaunchTemplateName: launchTemplateInput.LaunchTemplateName,
+					Version:            aws.String("$Latest"),
+				},
+				Overrides: []ec2types.FleetLaunchTemplateOverridesRequest{
+					{
+						InstanceType: ec2types.InstanceType(instanceType),
+						SubnetId:     aws.String(subnetID),
+						ImageId:      launchTemplateInput.LaunchTemplateData.ImageId,
+					},
+				},
+			},
+		},
+		TargetCapacitySpecification: &ec2types.TargetCapacitySpecificationRequest{
+			DefaultTargetCapacityType: ec2types.DefaultTargetCapacityTypeOnDemand,
+			TotalTargetCapacity:       aws.Int32(1),
+		},
+		TagSpecifications: []ec2types.TagSpecification{
+			{ResourceType: ec2types.ResourceTypeInstance, Tags: tags},
+			{ResourceType: ec2types.ResourceTypeVolume, Tags: tags},
+			{ResourceType: ec2types.ResourceTypeFleet, Tags: tags},
+		},
+	}
+}
@@ -0,0 +1,216 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package nodeclass_test
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	"github.com/aws/smithy-go"
+	"github.com/awslabs/operatorpkg/object"
+	"github.com/awslabs/operatorpkg/status"
+	"github.com/samber/lo"
+	karpv1 "sigs.k8s.io/karpenter/pkg/apis/v1"
+	coretest "sigs.k8s.io/karpenter/pkg/test"
+
+	v1 "github.com/aws/karpenter-provider-aws/pkg/apis/v1"
+	"github.com/aws/karpenter-provider-aws/pkg/test"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	. "sigs.k8s.io/karpenter/pkg/test/expectations"
+)
+
+var _ = Describe("NodeClass Launch Permissions Status Controller", func() {
+	var nodePool *karpv1.NodePool
+	// nodePoolFor returns a NodePool that references the EC2NodeClass
+	nodePoolFor := func(nodeClass *v1.EC2NodeClass) *karpv1.NodePool {
+		return coretest.NodePool(karpv1.NodePool{
+			Spec: karpv1.NodePoolSpec{
+				Template: karpv1.NodeClaimTemplate{
+					Spec: karpv1.NodeClaimTemplateSpec{
+						NodeClassRef: &karpv1.NodeClassReference{
+							Group: object.GVK(nodeClass).Group,
+							Kind:  object.GVK(nodeClass).Kind,
+							Name:  nodeClass.Name,
+						},
+					},
+				},
+			},
+		})
+	}
+	BeforeEach(func() {
+		nodeClass = test.EC2NodeClass(v1.EC2NodeClass{
+			Spec: v1.EC2NodeClassSpec{
+				SubnetSelectorTerms: []v1.SubnetSelectorTerm{
+					{
+						Tags: map[string]string{"*": "*"},
+					},
+				},
+				SecurityGroupSelectorTerms: []v1.SecurityGroupSelectorTerm{
+					{
+						Tags: map[string]string{"*": "*"},
+					},
+				},
+				AMIFamily: lo.ToPtr(v1.AMIFamilyCustom),
+				AMISelectorTerms: []v1.AMISelectorTerm{
+					{
+						Tags: map[string]string{"*": "*"},
+					},
+				},
+			},
+		})
+		nodePool = nodePoolFor(nodeClass)
+	})
+	It("should set LaunchPermissionsReady to true when all dry-runs succeed", func() {
+		ExpectApplied(ctx, env.Client, nodePool, nodeClass)
+		ExpectObjectReconciled(ctx, env.Client, controller, nodeClass)
+		nodeClass = ExpectExists(ctx, env.Client, nodeClass)
+		Expect(nodeClass.StatusConditions().Get(v1.ConditionTypeLaunchPermissionsReady).IsTrue()).To(BeTrue())
+		Expect(nodeClass.StatusConditions().Get(status.ConditionReady).IsTrue()).To(BeTrue())
+	})
+	It("should validate the launch permissions for every NodePool that references the EC2NodeClass", func() {
+		other := nodePoolFor(nodeClass)
+		ExpectApplied(ctx, env.Client, nodePool, other, nodeClass)
+		ExpectObjectReconciled(ctx, env.Client, controller, nodeClass)
+		nodeClass = ExpectExists(ctx, env.Client, nodeClass)
+		Expect(nodeClass.StatusConditions().Get(v1.ConditionTypeLaunchPermissionsReady).IsTrue()).To(BeTrue())
+
+		var nodePools []string
+		awsEnv.EC2API.CalledWithDryRunCreateFleetInput.ForEach(func(input *ec2.CreateFleetInput) {
+			for _, tag := range input.TagSpecifications[0].Tags {
+				if aws.ToString(tag.Key) == karpv1.NodePoolLabelKey {
+					nodePools = append(nodePools, aws.ToString(tag.Value))
+				}
+			}
+		})
+		Expect(nodePools).To(ConsistOf(nodePool.Name, other.Name))
+	})
+	It("should set LaunchPermissionsReady to unknown when no NodePools reference the EC2NodeClass", func() {
+		ExpectApplied(ctx, env.Client, nodeClass)
+		ExpectObjectReconciled(ctx, env.Client, controller, nodeClass)
+		nodeClass = ExpectExists(ctx, env.Client, nodeClass)
+		condition := nodeClass.StatusConditions().Get(v1.ConditionTypeLaunchPermissionsReady)
+		Expect(condition.IsUnknown()).To(BeTrue())
+		Expect(condition.Reason).To(Equal("NoNodePools"))
+		Expect(awsEnv.EC2API.CalledWithDryRunCreateFleetInput.Len()).To(Equal(0))
+	})
+	DescribeTable("should set LaunchPermissionsReady to false naming the unauthorized action", func(action string) {
+		awsEnv.EC2API.DryRunErrors.Store(action, &smithy.GenericAPIError{Code: "UnauthorizedOperation", Message: "You are not authorized to perform this operation."})
+		ExpectApplied(ctx, env.Client, nodePool, nodeClass)
+		ExpectObjectReconciled(ctx, env.Client, controller, nodeClass)
+		nodeClass = ExpectExists(ctx, env.Client, nodeClass)
+		condition := nodeClass.StatusConditions().Get(v1.ConditionTypeLaunchPermissionsReady)
+		Expect(condition.IsFalse()).To(BeTrue())
+		Expect(condition.Reason).To(Equal(fmt.Sprintf("%sUnauthorized", action)))
+		Expect(condition.Message).To(ContainSubstring(action))
+		Expect(condition.Message).To(ContainSubstring(nodePool.Name))
+		// The condition is informational, so the EC2NodeClass stays ready
+		Expect(nodeClass.StatusConditions().Get(status.ConditionReady).IsTrue()).To(BeTrue())
+	},
+		Entry("CreateLaunchTemplate", "CreateLaunchTemplate"),
+		Entry("RunInstances", "RunInstances"),
+		Entry("CreateFleet", "CreateFleet"),
+	)
+	It("should set LaunchPermissionsReady to true when the launch template of the EC2NodeClass hasn't been created yet", func() {
+		awsEnv.EC2API.DryRunErrors.Store("CreateFleet", &smithy.GenericAPIError{
+			Code:    "InvalidLaunchTemplateName.NotFoundException",
+			Message: "The specified launch template, with template name karpenter.k8s.aws/12345, does not exist.",
+		})
+		ExpectApplied(ctx, env.Client, nodePool, nodeClass)
+		ExpectObjectReconciled(ctx, env.Client, controller, nodeClass)
+		nodeClass = ExpectExists(ctx, env.Client, nodeClass)
+		condition := nodeClass.StatusConditions().Get(v1.ConditionTypeLaunchPermissionsReady)
+		Expect(condition.IsTrue()).To(BeTrue())
+		Expect(condition.Reason).To(Equal("CreateFleetUnverified"))
+		Expect(condition.Message).To(ContainSubstring(nodePool.Name))
+	})
+	It("should prefer an unauthorized action over one that couldn't be verified", func() {
+		awsEnv.EC2API.DryRunErrors.Store("CreateFleet", &smithy.GenericAPIError{Code: "InvalidLaunchTemplateName.NotFoundException"})
+		ExpectApplied(ctx, env.Client, nodePool, nodeClass)
+		ExpectObjectReconciled(ctx, env.Client, controller, nodeClass)
+		nodeClass = ExpectExists(ctx, env.Client, nodeClass)
+		Expect(nodeClass.StatusConditions().Get(v1.ConditionTypeLaunchPermissionsReady).Reason).To(Equal("CreateFleetUnverified"))
+
+		awsEnv.EC2API.DryRunErrors.Store("RunInstances", &smithy.GenericAPIError{Code: "UnauthorizedOperation"})
+		nodeClass.Spec.Tags = map[string]string{"team": "karpenter"}
+		ExpectApplied(ctx, env.Client, nodePool, nodeClass)
+		ExpectObjectReconciled(ctx, env.Client, controller, nodeClass)
+		nodeClass = ExpectExists(ctx, env.Client, nodeClass)
+		condition := nodeClass.StatusConditions().Get(v1.ConditionTypeLaunchPermissionsReady)
+		Expect(condition.IsFalse()).To(BeTrue())
+		Expect(condition.Reason).To(Equal("RunInstancesUnauthorized"))
+	})
+	It("should surface an SCP denial in the condition message", func() {
+		awsEnv.EC2API.DryRunErrors.Store("CreateFleet", &smithy.GenericAPIError{
+			Code:    "UnauthorizedOperation",
+			Message: "You are not authorized to perform this operation. with an explicit deny in a service control policy",
+		})
+		ExpectApplied(ctx, env.Client, nodePool, nodeClass)
+		ExpectObjectReconciled(ctx, env.Client, controller, nodeClass)
+		nodeClass = ExpectExists(ctx, env.Client, nodeClass)
+		condition := nodeClass.StatusConditions().Get(v1.ConditionTypeLaunchPermissionsReady)
+		Expect(condition.IsFalse()).To(BeTrue())
+		Expect(condition.Message).To(ContainSubstring("service control policy"))
+	})
+	It("should set LaunchPermissionsReady to unknown and requeue when a dry-run returns an unexpected error", func() {
+		awsEnv.EC2API.DryRunErrors.Store("RunInstances", &smithy.GenericAPIError{Code: "RequestLimitExceeded"})
+		ExpectApplied(ctx, env.Client, nodePool, nodeClass)
+		result := ExpectObjectReconciled(ctx, env.Client, controller, nodeClass)
+		Expect(result.RequeueAfter).To(BeNumerically("<=", time.Minute))
+		nodeClass = ExpectExists(ctx, env.Client, nodeClass)
+		condition := nodeClass.StatusConditions().Get(v1.ConditionTypeLaunchPermissionsReady)
+		Expect(condition.IsUnknown()).To(BeTrue())
+		Expect(condition.Reason).To(Equal("ValidationFailed"))
+		Expect(condition.Message).To(ContainSubstring("RunInstances"))
+		Expect(nodeClass.StatusConditions().Get(status.ConditionReady).IsTrue()).To(BeTrue())
+
+		// The unexpected error isn't cached, so the launch permissions are validated again on the next reconciliation
+		awsEnv.EC2API.DryRunErrors.Delete("RunInstances")
+		ExpectObjectReconciled(ctx, env.Client, controller, nodeClass)
+		nodeClass = ExpectExists(ctx, env.Client, nodeClass)
+		Expect(nodeClass.StatusConditions().Get(v1.ConditionTypeLaunchPermissionsReady).IsTrue()).To(BeTrue())
+	})
+	It("should re-evaluate the launch permissions when the EC2NodeClass changes", func() {
+		awsEnv.EC2API.DryRunErrors.Store("CreateFleet", &smithy.GenericAPIError{Code: "UnauthorizedOperation"})
+		ExpectApplied(ctx, env.Client, nodePool, nodeClass)
+		ExpectObjectReconciled(ctx, env.Client, controller, nodeClass)
+		nodeClass = ExpectExists(ctx, env.Client, nodeClass)
+		Expect(nodeClass.StatusConditions().Get(v1.ConditionTypeLaunchPermissionsReady).IsFalse()).To(BeTrue())
+
+		// The result is cached, so fixing the permissions alone doesn't change the condition until the cache expires
+		awsEnv.EC2API.DryRunErrors.Delete("CreateFleet")
+		ExpectObjectReconciled(ctx, env.Client, controller, nodeClass)
+		nodeClass = ExpectExists(ctx, env.Client, nodeClass)
+		Expect(nodeClass.StatusConditions().Get(v1.ConditionTypeLaunchPermissionsReady).IsFalse()).To(BeTrue())
+
+		nodeClass.Spec.Tags = map[string]string{"team": "karpenter"}
+		ExpectApplied(ctx, env.Client, nodePool, nodeClass)
+		ExpectObjectReconciled(ctx, env.Client, controller, nodeClass)
+		nodeClass = ExpectExists(ctx, env.Client, nodeClass)
+		Expect(nodeClass.StatusConditions().Get(v1.ConditionTypeLaunchPermissionsReady).IsTrue()).To(BeTrue())
+	})
+	It("should set LaunchPermissionsReady to unknown until its dependencies are ready", func() {
+		nodeClass.Spec.SubnetSelectorTerms = []v1.SubnetSelectorTerm{{Tags: map[string]string{"Name": "does-not-exist"}}}
+		ExpectApplied(ctx, env.Client, nodePool, nodeClass)
+		ExpectObjectReconciled(ctx, env.Client, controller, nodeClass)
+		nodeClass = ExpectExists(ctx, env.Client, nodeClass)
+		condition := nodeClass.StatusConditions().Get(v1.ConditionTypeLaunchPermissionsReady)
+		Expect(condition.IsUnknown()).To(BeTrue())
+		Expect(condition.Reason).To(Equal("DependenciesNotReady"))
+	})
+})
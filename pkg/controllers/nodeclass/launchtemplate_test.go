@@ -52,6 +52,8 @@ var _ = Describe("NodeClass Launch Template CIDR Resolution Controller", func()
 		})
 		// Cluster CIDR will only be resolved once per lifetime of the launch template provider, reset to nil between tests
 		awsEnv.LaunchTemplateProvider.ClusterCIDR.Store(nil)
+		// AL2023 launch templates are resolved when validating launch permissions, which decodes the CA bundle
+		awsEnv.LaunchTemplateProvider.CABundle = lo.ToPtr("Y2EtYnVuZGxlCg==")
 	})
 	DescribeTable(
 		"shouldn't resolve cluster CIDR for non-AL2023 NodeClasses",
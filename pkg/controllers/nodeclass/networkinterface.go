@@ -0,0 +1,100 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package nodeclass
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/samber/lo"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	v1 "github.com/aws/karpenter-provider-aws/pkg/apis/v1"
+	"github.com/aws/karpenter-provider-aws/pkg/providers/securitygroup"
+	"github.com/aws/karpenter-provider-aws/pkg/providers/subnet"
+)
+
+type NetworkInterface struct {
+	subnetProvider        subnet.Provider
+	securityGroupProvider securitygroup.Provider
+}
+
+func (n *NetworkInterface) Reconcile(ctx context.Context, nodeClass *v1.EC2NodeClass) (reconcile.Result, error) {
+	if len(nodeClass.Spec.NetworkInterfaces) == 0 {
+		nodeClass.Status.NetworkInterfaces = nil
+		nodeClass.StatusConditions().SetTrue(v1.ConditionTypeNetworkInterfacesReady)
+		return reconcile.Result{}, nil
+	}
+	var networkInterfaces []v1.NetworkInterfaceStatus
+	var zones sets.Set[string]
+	for _, ni := range nodeClass.Spec.NetworkInterfaces {
+		resolved := v1.NetworkInterfaceStatus{
+			NetworkCardIndex: ni.NetworkCardIndex,
+			DeviceIndex:      ni.DeviceIndex,
+		}
+		if len(ni.SecurityGroupSelectorTerms) != 0 {
+			securityGroups, err := n.securityGroupProvider.List(ctx, &v1.EC2NodeClass{Spec: v1.EC2NodeClassSpec{SecurityGroupSelectorTerms: ni.SecurityGroupSelectorTerms}})
+			if err != nil {
+				return reconcile.Result{}, fmt.Errorf("getting security groups of network interface %d on network card %d, %w", ni.DeviceIndex, ni.NetworkCardIndex, err)
+			}
+			if len(securityGroups) == 0 {
+				nodeClass.Status.NetworkInterfaces = nil
+				nodeClass.StatusConditions().SetFalse(v1.ConditionTypeNetworkInterfacesReady, "SecurityGroupsNotFound",
+					fmt.Sprintf("SecurityGroupSelector of network interface %d on network card %d did not match any SecurityGroups", ni.DeviceIndex, ni.NetworkCardIndex))
+				return reconcile.Result{RequeueAfter: time.Minute}, nil
+			}
+			sort.Slice(securityGroups, func(i, j int) bool {
+				return lo.FromPtr(securityGroups[i].GroupId) < lo.FromPtr(securityGroups[j].GroupId)
+			})
+			resolved.SecurityGroups = lo.Map(securityGroups, func(sg ec2types.SecurityGroup, _ int) v1.SecurityGroup {
+				return v1.SecurityGroup{ID: lo.FromPtr(sg.GroupId), Name: lo.FromPtr(sg.GroupName)}
+			})
+		}
+		if len(ni.SubnetSelectorTerms) != 0 {
+			subnets, err := n.subnetProvider.List(ctx, &v1.EC2NodeClass{Spec: v1.EC2NodeClassSpec{SubnetSelectorTerms: ni.SubnetSelectorTerms}})
+			if err != nil {
+				return reconcile.Result{}, fmt.Errorf("getting subnets of network interface %d on network card %d, %w", ni.DeviceIndex, ni.NetworkCardIndex, err)
+			}
+			if len(subnets) == 0 {
+				nodeClass.Status.NetworkInterfaces = nil
+				nodeClass.StatusConditions().SetFalse(v1.ConditionTypeNetworkInterfacesReady, "SubnetsNotFound",
+					fmt.Sprintf("SubnetSelector of network interface %d on network card %d did not match any Subnets", ni.DeviceIndex, ni.NetworkCardIndex))
+				return reconcile.Result{RequeueAfter: time.Minute}, nil
+			}
+			// Subnets are ordered by ID rather than by available IP addresses so that the launch templates of the
+			// EC2NodeClass don't change as the available IP addresses of its subnets do
+			sort.Slice(subnets, func(i, j int) bool {
+				return lo.FromPtr(subnets[i].SubnetId) < lo.FromPtr(subnets[j].SubnetId)
+			})
+			resolved.Subnets = lo.Map(subnets, func(s ec2types.Subnet, _ int) v1.Subnet {
+				return v1.Subnet{ID: lo.FromPtr(s.SubnetId), Zone: lo.FromPtr(s.AvailabilityZone), ZoneID: lo.FromPtr(s.AvailabilityZoneId)}
+			})
+			niZones := sets.New(lo.Map(resolved.Subnets, func(s v1.Subnet, _ int) string { return s.Zone })...)
+			zones = lo.Ternary(zones == nil, niZones, zones.Intersection(niZones))
+		}
+		networkInterfaces = append(networkInterfaces, resolved)
+	}
+	nodeClass.Status.NetworkInterfaces = networkInterfaces
+	if zones != nil && zones.Len() == 0 {
+		nodeClass.StatusConditions().SetFalse(v1.ConditionTypeNetworkInterfacesReady, "ZonesNotFound", "No zone has a Subnet for every network interface")
+		return reconcile.Result{RequeueAfter: time.Minute}, nil
+	}
+	nodeClass.StatusConditions().SetTrue(v1.ConditionTypeNetworkInterfacesReady)
+	return reconcile.Result{RequeueAfter: time.Minute}, nil
+}
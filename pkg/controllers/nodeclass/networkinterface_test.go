@@ -0,0 +1,124 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package nodeclass_test
+
+import (
+	"github.com/samber/lo"
+
+	v1 "github.com/aws/karpenter-provider-aws/pkg/apis/v1"
+	"github.com/aws/karpenter-provider-aws/pkg/test"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	. "sigs.k8s.io/karpenter/pkg/test/expectations"
+)
+
+var _ = Describe("NodeClass Network Interface Status Controller", func() {
+	BeforeEach(func() {
+		nodeClass = test.EC2NodeClass(v1.EC2NodeClass{
+			Spec: v1.EC2NodeClassSpec{
+				SubnetSelectorTerms: []v1.SubnetSelectorTerm{
+					{
+						Tags: map[string]string{"*": "*"},
+					},
+				},
+				SecurityGroupSelectorTerms: []v1.SecurityGroupSelectorTerm{
+					{
+						Tags: map[string]string{"*": "*"},
+					},
+				},
+				AMIFamily: lo.ToPtr(v1.AMIFamilyCustom),
+				AMISelectorTerms: []v1.AMISelectorTerm{
+					{
+						Tags: map[string]string{"*": "*"},
+					},
+				},
+			},
+		})
+	})
+	It("should not resolve network interfaces when none are specified", func() {
+		ExpectApplied(ctx, env.Client, nodeClass)
+		ExpectObjectReconciled(ctx, env.Client, controller, nodeClass)
+		nodeClass = ExpectExists(ctx, env.Client, nodeClass)
+		Expect(nodeClass.Status.NetworkInterfaces).To(BeNil())
+		Expect(nodeClass.StatusConditions().Get(v1.ConditionTypeNetworkInterfacesReady).IsTrue()).To(BeTrue())
+	})
+	It("should resolve the security groups and subnets of network interfaces", func() {
+		nodeClass.Spec.NetworkInterfaces = []*v1.NetworkInterface{
+			{DeviceIndex: 0},
+			{DeviceIndex: 1, SecurityGroupSelectorTerms: []v1.SecurityGroupSelectorTerm{{ID: "sg-test3"}}},
+			{NetworkCardIndex: 1, DeviceIndex: 0, SubnetSelectorTerms: []v1.SubnetSelectorTerm{{ID: "subnet-test2"}, {ID: "subnet-test1"}}},
+		}
+		ExpectApplied(ctx, env.Client, nodeClass)
+		ExpectObjectReconciled(ctx, env.Client, controller, nodeClass)
+		nodeClass = ExpectExists(ctx, env.Client, nodeClass)
+		Expect(nodeClass.Status.NetworkInterfaces).To(Equal([]v1.NetworkInterfaceStatus{
+			{
+				NetworkCardIndex: 0,
+				DeviceIndex:      0,
+			},
+			{
+				NetworkCardIndex: 0,
+				DeviceIndex:      1,
+				SecurityGroups:   []v1.SecurityGroup{{ID: "sg-test3", Name: "securityGroup-test3"}},
+			},
+			{
+				NetworkCardIndex: 1,
+				DeviceIndex:      0,
+				Subnets: []v1.Subnet{
+					{ID: "subnet-test1", Zone: "test-zone-1a", ZoneID: "tstz1-1a"},
+					{ID: "subnet-test2", Zone: "test-zone-1b", ZoneID: "tstz1-1b"},
+				},
+			},
+		}))
+		Expect(nodeClass.StatusConditions().Get(v1.ConditionTypeNetworkInterfacesReady).IsTrue()).To(BeTrue())
+	})
+	It("should set the NetworkInterfacesReady condition to false when a security group selector matches nothing", func() {
+		nodeClass.Spec.NetworkInterfaces = []*v1.NetworkInterface{
+			{DeviceIndex: 0, SecurityGroupSelectorTerms: []v1.SecurityGroupSelectorTerm{{ID: "sg-does-not-exist"}}},
+		}
+		ExpectApplied(ctx, env.Client, nodeClass)
+		ExpectObjectReconciled(ctx, env.Client, controller, nodeClass)
+		nodeClass = ExpectExists(ctx, env.Client, nodeClass)
+		Expect(nodeClass.Status.NetworkInterfaces).To(BeNil())
+		Expect(nodeClass.StatusConditions().Get(v1.ConditionTypeNetworkInterfacesReady).IsFalse()).To(BeTrue())
+		Expect(nodeClass.StatusConditions().Get(v1.ConditionTypeNetworkInterfacesReady).Reason).To(Equal("SecurityGroupsNotFound"))
+		Expect(nodeClass.StatusConditions().Root().IsFalse()).To(BeTrue())
+	})
+	It("should set the NetworkInterfacesReady condition to false when a subnet selector matches nothing", func() {
+		nodeClass.Spec.NetworkInterfaces = []*v1.NetworkInterface{
+			{DeviceIndex: 0},
+			{DeviceIndex: 1, SubnetSelectorTerms: []v1.SubnetSelectorTerm{{ID: "subnet-does-not-exist"}}},
+		}
+		ExpectApplied(ctx, env.Client, nodeClass)
+		ExpectObjectReconciled(ctx, env.Client, controller, nodeClass)
+		nodeClass = ExpectExists(ctx, env.Client, nodeClass)
+		Expect(nodeClass.Status.NetworkInterfaces).To(BeNil())
+		Expect(nodeClass.StatusConditions().Get(v1.ConditionTypeNetworkInterfacesReady).IsFalse()).To(BeTrue())
+		Expect(nodeClass.StatusConditions().Get(v1.ConditionTypeNetworkInterfacesReady).Reason).To(Equal("SubnetsNotFound"))
+	})
+	It("should set the NetworkInterfacesReady condition to false when no zone has a subnet for every network interface", func() {
+		nodeClass.Spec.NetworkInterfaces = []*v1.NetworkInterface{
+			{DeviceIndex: 0},
+			{DeviceIndex: 1, SubnetSelectorTerms: []v1.SubnetSelectorTerm{{ID: "subnet-test1"}}},
+			{DeviceIndex: 2, SubnetSelectorTerms: []v1.SubnetSelectorTerm{{ID: "subnet-test2"}}},
+		}
+		ExpectApplied(ctx, env.Client, nodeClass)
+		ExpectObjectReconciled(ctx, env.Client, controller, nodeClass)
+		nodeClass = ExpectExists(ctx, env.Client, nodeClass)
+		Expect(nodeClass.StatusConditions().Get(v1.ConditionTypeNetworkInterfacesReady).IsFalse()).To(BeTrue())
+		Expect(nodeClass.StatusConditions().Get(v1.ConditionTypeNetworkInterfacesReady).Reason).To(Equal("ZonesNotFound"))
+	})
+})
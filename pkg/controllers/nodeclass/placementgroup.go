@@ -0,0 +1,111 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package nodeclass
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/samber/lo"
+	"go.uber.org/multierr"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	v1 "github.com/aws/karpenter-provider-aws/pkg/apis/v1"
+	awserrors "github.com/aws/karpenter-provider-aws/pkg/errors"
+	"github.com/aws/karpenter-provider-aws/pkg/providers/placementgroup"
+)
+
+type PlacementGroup struct {
+	placementGroupProvider placementgroup.Provider
+}
+
+func (pg *PlacementGroup) Reconcile(ctx context.Context, nodeClass *v1.EC2NodeClass) (reconcile.Result, error) {
+	if nodeClass.Spec.PlacementGroup == nil {
+		nodeClass.Status.PlacementGroup = nil
+		nodeClass.StatusConditions().SetTrue(v1.ConditionTypePlacementGroupReady)
+		return reconcile.Result{}, pg.deleteUnselected(ctx, nodeClass, "")
+	}
+	placementGroup, err := pg.placementGroupProvider.Get(ctx, nodeClass)
+	if err != nil {
+		return reconcile.Result{}, fmt.Errorf("getting placement group, %w", err)
+	}
+	if placementGroup == nil {
+		nodeClass.Status.PlacementGroup = nil
+		nodeClass.StatusConditions().SetFalse(v1.ConditionTypePlacementGroupReady, "PlacementGroupNotFound", "PlacementGroupSelector did not match a PlacementGroup")
+		return reconcile.Result{RequeueAfter: time.Minute}, pg.deleteUnselected(ctx, nodeClass, "")
+	}
+	if err := pg.deleteUnselected(ctx, nodeClass, lo.FromPtr(placementGroup.GroupId)); err != nil {
+		return reconcile.Result{}, err
+	}
+	if placementGroup.State != ec2types.PlacementGroupStateAvailable {
+		nodeClass.Status.PlacementGroup = nil
+		nodeClass.StatusConditions().SetFalse(v1.ConditionTypePlacementGroupReady, "PlacementGroupNotAvailable", fmt.Sprintf("PlacementGroup is in the %q state", placementGroup.State))
+		return reconcile.Result{RequeueAfter: time.Minute}, nil
+	}
+	// Placement groups can't be modified after creation, so we surface a change in the requested strategy rather than
+	// silently continuing to launch into a placement group with the previous strategy
+	if strategy := nodeClass.Spec.PlacementGroup.Strategy; strategy != "" && string(placementGroup.Strategy) != strategy {
+		nodeClass.Status.PlacementGroup = nil
+		nodeClass.StatusConditions().SetFalse(v1.ConditionTypePlacementGroupReady, "PlacementGroupStrategyMismatch", fmt.Sprintf("PlacementGroup has strategy %q, expected %q", placementGroup.Strategy, strategy))
+		return reconcile.Result{RequeueAfter: time.Minute}, nil
+	}
+	resolved := &v1.PlacementGroup{
+		ID:             lo.FromPtr(placementGroup.GroupId),
+		Name:           lo.FromPtr(placementGroup.GroupName),
+		Strategy:       string(placementGroup.Strategy),
+		PartitionCount: lo.FromPtr(placementGroup.PartitionCount),
+		// placement groups which are selected by strategy are created by Karpenter for the EC2NodeClass
+		Managed: nodeClass.Spec.PlacementGroup.Strategy != "",
+	}
+	if placementGroup.Strategy == ec2types.PlacementStrategyCluster {
+		resolved.Zone = clusterPlacementGroupZone(nodeClass, resolved.ID)
+	}
+	nodeClass.Status.PlacementGroup = resolved
+	nodeClass.StatusConditions().SetTrue(v1.ConditionTypePlacementGroupReady)
+	return reconcile.Result{RequeueAfter: 5 * time.Minute}, nil
+}
+
+// deleteUnselected deletes the placement groups that Karpenter created for the EC2NodeClass and that are no longer
+// selected, such as after the selector moved from a strategy to an existing placement group, or to another strategy.
+// A placement group can't be deleted while it still has instances, so it's retried until drift has replaced them.
+func (pg *PlacementGroup) deleteUnselected(ctx context.Context, nodeClass *v1.EC2NodeClass, selectedID string) error {
+	err := pg.placementGroupProvider.DeleteManaged(ctx, nodeClass, selectedID)
+	if err = multierr.Combine(lo.Reject(multierr.Errors(err), func(err error, _ int) bool { return awserrors.IsPlacementGroupInUse(err) })...); err != nil {
+		return fmt.Errorf("deleting unselected placement groups, %w", err)
+	}
+	return nil
+}
+
+// clusterPlacementGroupZone returns the zone that instances are launched into for a cluster placement group. The zone
+// is kept for as long as the same placement group is selected, since the instances that are already in the placement
+// group constrain it to their zone. Otherwise, the zone of the subnet with the most available IP addresses is used.
+func clusterPlacementGroupZone(nodeClass *v1.EC2NodeClass, id string) string {
+	if nodeClass.Status.PlacementGroup != nil && nodeClass.Status.PlacementGroup.ID == id && nodeClass.Status.PlacementGroup.Zone != "" {
+		return nodeClass.Status.PlacementGroup.Zone
+	}
+	if len(nodeClass.Status.Subnets) == 0 {
+		return ""
+	}
+	return nodeClass.Status.Subnets[0].Zone
+}
+
+func (pg *PlacementGroup) Finalize(ctx context.Context, nodeClass *v1.EC2NodeClass) (reconcile.Result, error) {
+	if err := pg.placementGroupProvider.DeleteManaged(ctx, nodeClass, ""); err != nil {
+		return reconcile.Result{}, fmt.Errorf("deleting placement groups, %w", err)
+	}
+	return reconcile.Result{}, nil
+}
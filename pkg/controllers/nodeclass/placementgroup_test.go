@@ -0,0 +1,204 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package nodeclass_test
+
+import (
+	"github.com/aws/aws-sdk-go-v2/aws"
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/samber/lo"
+
+	v1 "github.com/aws/karpenter-provider-aws/pkg/apis/v1"
+	"github.com/aws/karpenter-provider-aws/pkg/fake"
+	"github.com/aws/karpenter-provider-aws/pkg/operator/options"
+	"github.com/aws/karpenter-provider-aws/pkg/test"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	. "sigs.k8s.io/karpenter/pkg/test/expectations"
+)
+
+var _ = Describe("NodeClass Placement Group Status Controller", func() {
+	BeforeEach(func() {
+		nodeClass = test.EC2NodeClass(v1.EC2NodeClass{
+			Spec: v1.EC2NodeClassSpec{
+				SubnetSelectorTerms: []v1.SubnetSelectorTerm{
+					{
+						Tags: map[string]string{"*": "*"},
+					},
+				},
+				SecurityGroupSelectorTerms: []v1.SecurityGroupSelectorTerm{
+					{
+						Tags: map[string]string{"*": "*"},
+					},
+				},
+				AMIFamily: lo.ToPtr(v1.AMIFamilyCustom),
+				AMISelectorTerms: []v1.AMISelectorTerm{
+					{
+						Tags: map[string]string{"*": "*"},
+					},
+				},
+			},
+		})
+		awsEnv.EC2API.PlacementGroups.Store("pg-existing", ec2types.PlacementGroup{
+			GroupId:   aws.String("pg-existing"),
+			GroupName: aws.String("existing"),
+			Strategy:  ec2types.PlacementStrategyCluster,
+			State:     ec2types.PlacementGroupStateAvailable,
+		})
+	})
+	It("should not resolve a placement group when none is specified", func() {
+		ExpectApplied(ctx, env.Client, nodeClass)
+		ExpectObjectReconciled(ctx, env.Client, controller, nodeClass)
+		nodeClass = ExpectExists(ctx, env.Client, nodeClass)
+		Expect(nodeClass.Status.PlacementGroup).To(BeNil())
+		Expect(nodeClass.StatusConditions().Get(v1.ConditionTypePlacementGroupReady).IsTrue()).To(BeTrue())
+	})
+	It("should resolve an existing placement group by name", func() {
+		nodeClass.Spec.PlacementGroup = &v1.PlacementGroupSelector{Name: "existing"}
+		ExpectApplied(ctx, env.Client, nodeClass)
+		ExpectObjectReconciled(ctx, env.Client, controller, nodeClass)
+		nodeClass = ExpectExists(ctx, env.Client, nodeClass)
+		Expect(nodeClass.Status.PlacementGroup).To(Equal(&v1.PlacementGroup{
+			ID:       "pg-existing",
+			Name:     "existing",
+			Strategy: string(ec2types.PlacementStrategyCluster),
+		}))
+		Expect(nodeClass.StatusConditions().Get(v1.ConditionTypePlacementGroupReady).IsTrue()).To(BeTrue())
+	})
+	It("should resolve an existing placement group by id", func() {
+		nodeClass.Spec.PlacementGroup = &v1.PlacementGroupSelector{ID: "pg-existing"}
+		ExpectApplied(ctx, env.Client, nodeClass)
+		ExpectObjectReconciled(ctx, env.Client, controller, nodeClass)
+		nodeClass = ExpectExists(ctx, env.Client, nodeClass)
+		Expect(nodeClass.Status.PlacementGroup).ToNot(BeNil())
+		Expect(nodeClass.Status.PlacementGroup.ID).To(Equal("pg-existing"))
+		Expect(nodeClass.StatusConditions().Get(v1.ConditionTypePlacementGroupReady).IsTrue()).To(BeTrue())
+	})
+	It("should set the condition to false when the placement group doesn't exist", func() {
+		nodeClass.Spec.PlacementGroup = &v1.PlacementGroupSelector{Name: "missing"}
+		ExpectApplied(ctx, env.Client, nodeClass)
+		ExpectObjectReconciled(ctx, env.Client, controller, nodeClass)
+		nodeClass = ExpectExists(ctx, env.Client, nodeClass)
+		Expect(nodeClass.Status.PlacementGroup).To(BeNil())
+		Expect(nodeClass.StatusConditions().Get(v1.ConditionTypePlacementGroupReady).IsFalse()).To(BeTrue())
+	})
+	It("should create a managed placement group when a strategy is specified", func() {
+		nodeClass.Spec.PlacementGroup = &v1.PlacementGroupSelector{Strategy: "partition", PartitionCount: lo.ToPtr[int32](3)}
+		ExpectApplied(ctx, env.Client, nodeClass)
+		ExpectObjectReconciled(ctx, env.Client, controller, nodeClass)
+		nodeClass = ExpectExists(ctx, env.Client, nodeClass)
+		Expect(nodeClass.Status.PlacementGroup).ToNot(BeNil())
+		Expect(nodeClass.Status.PlacementGroup.Name).To(Equal(nodeClass.PlacementGroupName(options.FromContext(ctx).ClusterName, fake.DefaultRegion)))
+		Expect(nodeClass.Status.PlacementGroup.Strategy).To(Equal(string(ec2types.PlacementStrategyPartition)))
+		Expect(nodeClass.Status.PlacementGroup.PartitionCount).To(BeNumerically("==", 3))
+		Expect(nodeClass.Status.PlacementGroup.Managed).To(BeTrue())
+		Expect(nodeClass.StatusConditions().Get(v1.ConditionTypePlacementGroupReady).IsTrue()).To(BeTrue())
+
+		pg, ok := awsEnv.EC2API.PlacementGroups.Load(nodeClass.Status.PlacementGroup.ID)
+		Expect(ok).To(BeTrue())
+		Expect(pg.(ec2types.PlacementGroup).Tags).To(ContainElement(ec2types.Tag{Key: aws.String(v1.LabelNodeClass), Value: aws.String(nodeClass.Name)}))
+	})
+	It("should set the condition to false when the managed placement group's strategy doesn't match", func() {
+		nodeClass.Spec.PlacementGroup = &v1.PlacementGroupSelector{Strategy: "cluster"}
+		awsEnv.EC2API.PlacementGroups.Store("pg-managed", ec2types.PlacementGroup{
+			GroupId:   aws.String("pg-managed"),
+			GroupName: aws.String(nodeClass.PlacementGroupName(options.FromContext(ctx).ClusterName, fake.DefaultRegion)),
+			Strategy:  ec2types.PlacementStrategySpread,
+			State:     ec2types.PlacementGroupStateAvailable,
+		})
+		ExpectApplied(ctx, env.Client, nodeClass)
+		ExpectObjectReconciled(ctx, env.Client, controller, nodeClass)
+		nodeClass = ExpectExists(ctx, env.Client, nodeClass)
+		Expect(nodeClass.Status.PlacementGroup).To(BeNil())
+		Expect(nodeClass.StatusConditions().Get(v1.ConditionTypePlacementGroupReady).IsFalse()).To(BeTrue())
+	})
+	It("should create a new managed placement group and delete the previous one when the strategy changes", func() {
+		nodeClass.Spec.PlacementGroup = &v1.PlacementGroupSelector{Strategy: "spread"}
+		ExpectApplied(ctx, env.Client, nodeClass)
+		ExpectObjectReconciled(ctx, env.Client, controller, nodeClass)
+		nodeClass = ExpectExists(ctx, env.Client, nodeClass)
+		previous := nodeClass.Status.PlacementGroup.ID
+
+		nodeClass.Spec.PlacementGroup = &v1.PlacementGroupSelector{Strategy: "cluster"}
+		ExpectApplied(ctx, env.Client, nodeClass)
+		ExpectObjectReconciled(ctx, env.Client, controller, nodeClass)
+		nodeClass = ExpectExists(ctx, env.Client, nodeClass)
+		Expect(nodeClass.Status.PlacementGroup).ToNot(BeNil())
+		Expect(nodeClass.Status.PlacementGroup.ID).ToNot(Equal(previous))
+		Expect(nodeClass.Status.PlacementGroup.Strategy).To(Equal(string(ec2types.PlacementStrategyCluster)))
+		Expect(nodeClass.StatusConditions().Get(v1.ConditionTypePlacementGroupReady).IsTrue()).To(BeTrue())
+		_, ok := awsEnv.EC2API.PlacementGroups.Load(previous)
+		Expect(ok).To(BeFalse())
+		_, ok = awsEnv.EC2API.PlacementGroups.Load(nodeClass.Status.PlacementGroup.ID)
+		Expect(ok).To(BeTrue())
+	})
+	It("should delete the managed placement group when the placement group is removed", func() {
+		nodeClass.Spec.PlacementGroup = &v1.PlacementGroupSelector{Strategy: "spread"}
+		ExpectApplied(ctx, env.Client, nodeClass)
+		ExpectObjectReconciled(ctx, env.Client, controller, nodeClass)
+		nodeClass = ExpectExists(ctx, env.Client, nodeClass)
+		previous := nodeClass.Status.PlacementGroup.ID
+
+		nodeClass.Spec.PlacementGroup = nil
+		ExpectApplied(ctx, env.Client, nodeClass)
+		ExpectObjectReconciled(ctx, env.Client, controller, nodeClass)
+		nodeClass = ExpectExists(ctx, env.Client, nodeClass)
+		Expect(nodeClass.Status.PlacementGroup).To(BeNil())
+		_, ok := awsEnv.EC2API.PlacementGroups.Load(previous)
+		Expect(ok).To(BeFalse())
+	})
+	It("should delete the managed placement group when an existing placement group is selected", func() {
+		nodeClass.Spec.PlacementGroup = &v1.PlacementGroupSelector{Strategy: "spread"}
+		ExpectApplied(ctx, env.Client, nodeClass)
+		ExpectObjectReconciled(ctx, env.Client, controller, nodeClass)
+		nodeClass = ExpectExists(ctx, env.Client, nodeClass)
+		previous := nodeClass.Status.PlacementGroup.ID
+
+		nodeClass.Spec.PlacementGroup = &v1.PlacementGroupSelector{Name: "existing"}
+		ExpectApplied(ctx, env.Client, nodeClass)
+		ExpectObjectReconciled(ctx, env.Client, controller, nodeClass)
+		nodeClass = ExpectExists(ctx, env.Client, nodeClass)
+		Expect(nodeClass.Status.PlacementGroup.ID).To(Equal("pg-existing"))
+		_, ok := awsEnv.EC2API.PlacementGroups.Load(previous)
+		Expect(ok).To(BeFalse())
+		_, ok = awsEnv.EC2API.PlacementGroups.Load("pg-existing")
+		Expect(ok).To(BeTrue())
+	})
+	It("should constrain a cluster placement group to a single zone", func() {
+		nodeClass.Spec.PlacementGroup = &v1.PlacementGroupSelector{Strategy: "cluster"}
+		ExpectApplied(ctx, env.Client, nodeClass)
+		ExpectObjectReconciled(ctx, env.Client, controller, nodeClass)
+		nodeClass = ExpectExists(ctx, env.Client, nodeClass)
+		Expect(nodeClass.Status.PlacementGroup).ToNot(BeNil())
+		Expect(nodeClass.Status.PlacementGroup.Zone).To(Equal(nodeClass.Status.Subnets[0].Zone))
+
+		// The zone is kept while the same placement group is selected, since its instances are already in that zone
+		zone, ok := lo.Find(nodeClass.Status.Subnets, func(s v1.Subnet) bool { return s.Zone != nodeClass.Status.PlacementGroup.Zone })
+		Expect(ok).To(BeTrue())
+		nodeClass.Status.PlacementGroup.Zone = zone.Zone
+		ExpectApplied(ctx, env.Client, nodeClass)
+		ExpectObjectReconciled(ctx, env.Client, controller, nodeClass)
+		nodeClass = ExpectExists(ctx, env.Client, nodeClass)
+		Expect(nodeClass.Status.PlacementGroup.Zone).To(Equal(zone.Zone))
+	})
+	It("should not constrain other placement group strategies to a single zone", func() {
+		nodeClass.Spec.PlacementGroup = &v1.PlacementGroupSelector{Strategy: "spread"}
+		ExpectApplied(ctx, env.Client, nodeClass)
+		ExpectObjectReconciled(ctx, env.Client, controller, nodeClass)
+		nodeClass = ExpectExists(ctx, env.Client, nodeClass)
+		Expect(nodeClass.Status.PlacementGroup).ToNot(BeNil())
+		Expect(nodeClass.Status.PlacementGroup.Zone).To(BeEmpty())
+	})
+})
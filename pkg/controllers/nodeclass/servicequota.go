@@ -0,0 +1,80 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package nodeclass
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/samber/lo"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	v1 "github.com/aws/karpenter-provider-aws/pkg/apis/v1"
+	"github.com/aws/karpenter-provider-aws/pkg/providers/instancetype"
+	"github.com/aws/karpenter-provider-aws/pkg/providers/servicequota"
+)
+
+// ServiceQuota surfaces whether the account's EC2 vCPU quotas have room to launch the EC2NodeClass' instance types. A
+// quota is exceeded for the EC2NodeClass once its headroom is less than the smallest instance type of its instance
+// class that the EC2NodeClass can launch.
+type ServiceQuota struct {
+	instanceTypeProvider instancetype.Provider
+	serviceQuotaProvider servicequota.Provider
+}
+
+func (s *ServiceQuota) Reconcile(ctx context.Context, nodeClass *v1.EC2NodeClass) (reconcile.Result, error) {
+	quotas := s.serviceQuotaProvider.Quotas()
+	// Without the quotas or the EC2NodeClass' subnets there's nothing to compare, which isn't worth surfacing
+	if len(quotas) == 0 || !nodeClass.StatusConditions().Get(v1.ConditionTypeSubnetsReady).IsTrue() {
+		_ = nodeClass.StatusConditions().Clear(v1.ConditionTypeVCPUQuotaAvailable)
+		return reconcile.Result{RequeueAfter: time.Minute}, nil
+	}
+	instanceTypes, err := s.instanceTypeProvider.List(ctx, nodeClass)
+	if err != nil {
+		return reconcile.Result{}, fmt.Errorf("listing instance types, %w", err)
+	}
+	minVCPUs := map[string]int64{}
+	for _, it := range instanceTypes {
+		class := servicequota.InstanceClass(it.Name)
+		if class == "" {
+			continue
+		}
+		if vcpus, ok := minVCPUs[class]; !ok || it.Capacity.Cpu().Value() < vcpus {
+			minVCPUs[class] = it.Capacity.Cpu().Value()
+		}
+	}
+	exceeded := lo.Filter(quotas, func(q servicequota.Quota, _ int) bool {
+		vcpus, ok := minVCPUs[q.Class]
+		return ok && q.Headroom() < vcpus
+	})
+	if len(exceeded) == 0 {
+		nodeClass.StatusConditions().SetTrue(v1.ConditionTypeVCPUQuotaAvailable)
+		return reconcile.Result{RequeueAfter: time.Minute}, nil
+	}
+	sort.Slice(exceeded, func(i, j int) bool {
+		if exceeded[i].Class != exceeded[j].Class {
+			return exceeded[i].Class < exceeded[j].Class
+		}
+		return exceeded[i].CapacityType < exceeded[j].CapacityType
+	})
+	nodeClass.StatusConditions().SetFalse(v1.ConditionTypeVCPUQuotaAvailable, "VCPUQuotaExceeded", fmt.Sprintf("Launching instance types would exceed vCPU quotas, %s",
+		strings.Join(lo.Map(exceeded, func(q servicequota.Quota, _ int) string {
+			return fmt.Sprintf("%s %s (%d of %d vCPUs used)", q.Class, q.CapacityType, q.Usage, q.Limit)
+		}), ", ")))
+	return reconcile.Result{RequeueAfter: time.Minute}, nil
+}
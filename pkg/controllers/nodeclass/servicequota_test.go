@@ -0,0 +1,112 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package nodeclass_test
+
+import (
+	"github.com/aws/aws-sdk-go-v2/service/servicequotas"
+	servicequotastypes "github.com/aws/aws-sdk-go-v2/service/servicequotas/types"
+	"github.com/awslabs/operatorpkg/status"
+	"github.com/samber/lo"
+	karpv1 "sigs.k8s.io/karpenter/pkg/apis/v1"
+
+	v1 "github.com/aws/karpenter-provider-aws/pkg/apis/v1"
+	"github.com/aws/karpenter-provider-aws/pkg/fake"
+	"github.com/aws/karpenter-provider-aws/pkg/test"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	. "sigs.k8s.io/karpenter/pkg/test/expectations"
+)
+
+var _ = Describe("NodeClass Service Quota Status Controller", func() {
+	BeforeEach(func() {
+		nodeClass = test.EC2NodeClass(v1.EC2NodeClass{
+			Spec: v1.EC2NodeClassSpec{
+				SubnetSelectorTerms: []v1.SubnetSelectorTerm{
+					{
+						Tags: map[string]string{"*": "*"},
+					},
+				},
+				SecurityGroupSelectorTerms: []v1.SecurityGroupSelectorTerm{
+					{
+						Tags: map[string]string{"*": "*"},
+					},
+				},
+				AMIFamily: lo.ToPtr(v1.AMIFamilyCustom),
+				AMISelectorTerms: []v1.AMISelectorTerm{
+					{
+						Tags: map[string]string{"*": "*"},
+					},
+				},
+			},
+		})
+	})
+	It("should not set VCPUQuotaAvailable when no quotas are known", func() {
+		ExpectApplied(ctx, env.Client, nodeClass)
+		ExpectObjectReconciled(ctx, env.Client, controller, nodeClass)
+		nodeClass = ExpectExists(ctx, env.Client, nodeClass)
+		Expect(nodeClass.StatusConditions().Get(v1.ConditionTypeVCPUQuotaAvailable)).To(BeNil())
+		Expect(nodeClass.StatusConditions().Get(status.ConditionReady).IsTrue()).To(BeTrue())
+	})
+	It("should set VCPUQuotaAvailable to true when the quotas have headroom", func() {
+		awsEnv.ServiceQuotasAPI.ListServiceQuotasBehavior.Output.Set(&servicequotas.ListServiceQuotasOutput{
+			Quotas: []servicequotastypes.ServiceQuota{
+				fake.NewServiceQuota("L-1216C47A", 64),
+				fake.NewServiceQuota("L-34B43A08", 64),
+			},
+		})
+		Expect(awsEnv.ServiceQuotaProvider.UpdateQuotas(ctx)).To(Succeed())
+		ExpectApplied(ctx, env.Client, nodeClass)
+		ExpectObjectReconciled(ctx, env.Client, controller, nodeClass)
+		nodeClass = ExpectExists(ctx, env.Client, nodeClass)
+		Expect(nodeClass.StatusConditions().Get(v1.ConditionTypeVCPUQuotaAvailable).IsTrue()).To(BeTrue())
+	})
+	It("should set VCPUQuotaAvailable to false when a quota can't fit the smallest instance type of its class", func() {
+		awsEnv.ServiceQuotasAPI.ListServiceQuotasBehavior.Output.Set(&servicequotas.ListServiceQuotasOutput{
+			Quotas: []servicequotastypes.ServiceQuota{
+				fake.NewServiceQuota("L-1216C47A", 64),
+				fake.NewServiceQuota("L-34B43A08", 1),
+				fake.NewServiceQuota("L-DB2E81BA", 16),
+			},
+		})
+		Expect(awsEnv.ServiceQuotaProvider.UpdateQuotas(ctx)).To(Succeed())
+		ExpectApplied(ctx, env.Client, nodeClass)
+		ExpectObjectReconciled(ctx, env.Client, controller, nodeClass)
+		nodeClass = ExpectExists(ctx, env.Client, nodeClass)
+		condition := nodeClass.StatusConditions().Get(v1.ConditionTypeVCPUQuotaAvailable)
+		Expect(condition.IsFalse()).To(BeTrue())
+		Expect(condition.Reason).To(Equal("VCPUQuotaExceeded"))
+		Expect(condition.Message).To(Equal("Launching instance types would exceed vCPU quotas, G and VT on-demand (0 of 16 vCPUs used), Standard spot (0 of 1 vCPUs used)"))
+		// Other instance types and capacity types can still be launched, so the EC2NodeClass remains ready
+		Expect(nodeClass.StatusConditions().Get(status.ConditionReady).IsTrue()).To(BeTrue())
+	})
+	It("should set VCPUQuotaAvailable to false once launches use up a quota", func() {
+		awsEnv.ServiceQuotasAPI.ListServiceQuotasBehavior.Output.Set(&servicequotas.ListServiceQuotasOutput{
+			Quotas: []servicequotastypes.ServiceQuota{
+				fake.NewServiceQuota("L-1216C47A", 64),
+			},
+		})
+		Expect(awsEnv.ServiceQuotaProvider.UpdateQuotas(ctx)).To(Succeed())
+		ExpectApplied(ctx, env.Client, nodeClass)
+		ExpectObjectReconciled(ctx, env.Client, controller, nodeClass)
+		nodeClass = ExpectExists(ctx, env.Client, nodeClass)
+		Expect(nodeClass.StatusConditions().Get(v1.ConditionTypeVCPUQuotaAvailable).IsTrue()).To(BeTrue())
+
+		awsEnv.ServiceQuotaProvider.MarkLaunched("m5.metal", karpv1.CapacityTypeOnDemand, 63)
+		ExpectObjectReconciled(ctx, env.Client, controller, nodeClass)
+		nodeClass = ExpectExists(ctx, env.Client, nodeClass)
+		Expect(nodeClass.StatusConditions().Get(v1.ConditionTypeVCPUQuotaAvailable).IsFalse()).To(BeTrue())
+	})
+})
@@ -50,13 +50,42 @@ func (s *Subnet) Reconcile(ctx context.Context, nodeClass *v1.EC2NodeClass) (rec
 		}
 		return *subnets[i].SubnetId < *subnets[j].SubnetId
 	})
+	zones, err := s.subnetProvider.AvailabilityZones(ctx)
+	if err != nil {
+		return reconcile.Result{}, fmt.Errorf("getting availability zones, %w", err)
+	}
 	nodeClass.Status.Subnets = lo.Map(subnets, func(ec2subnet ec2types.Subnet, _ int) v1.Subnet {
-		return v1.Subnet{
-			ID:     *ec2subnet.SubnetId,
-			Zone:   *ec2subnet.AvailabilityZone,
-			ZoneID: *ec2subnet.AvailabilityZoneId,
-		}
+		subnet := newSubnet(ec2subnet, zones)
+		subnet.PrefixFragmented = s.subnetProvider.IsPrefixFragmented(subnet.ID)
+		return subnet
 	})
+	// An Outpost is anchored to an availability zone, so we can't tell which capacity a launch into that zone should
+	// draw from if the selector matches both Outpost and in-region subnets in the same zone.
+	for zone, zoneSubnets := range lo.GroupBy(nodeClass.Status.Subnets, func(s v1.Subnet) string { return s.Zone }) {
+		if lo.ContainsBy(zoneSubnets, func(s v1.Subnet) bool { return s.OutpostARN != "" }) &&
+			lo.ContainsBy(zoneSubnets, func(s v1.Subnet) bool { return s.OutpostARN == "" }) {
+			nodeClass.StatusConditions().SetFalse(v1.ConditionTypeSubnetsReady, "OutpostSubnetConflict", fmt.Sprintf("SubnetSelector matched both Outpost and non-Outpost Subnets in zone %q", zone))
+			return reconcile.Result{RequeueAfter: time.Minute}, nil
+		}
+	}
 	nodeClass.StatusConditions().SetTrue(v1.ConditionTypeSubnetsReady)
 	return reconcile.Result{RequeueAfter: time.Minute}, nil
 }
+
+func newSubnet(ec2subnet ec2types.Subnet, zones map[string]ec2types.AvailabilityZone) v1.Subnet {
+	subnet := v1.Subnet{
+		ID:         *ec2subnet.SubnetId,
+		Zone:       *ec2subnet.AvailabilityZone,
+		ZoneID:     *ec2subnet.AvailabilityZoneId,
+		OutpostARN: lo.FromPtr(ec2subnet.OutpostArn),
+	}
+	if subnet.OutpostARN != "" {
+		subnet.ZoneType = v1.ZoneTypeOutpost
+		return subnet
+	}
+	if zone, ok := zones[subnet.Zone]; ok {
+		subnet.ZoneType = lo.FromPtr(zone.ZoneType)
+		subnet.ParentZone = lo.FromPtr(zone.ParentZoneName)
+	}
+	return subnet
+}
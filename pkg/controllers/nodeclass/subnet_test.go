@@ -57,24 +57,29 @@ var _ = Describe("NodeClass Subnet Status Controller", func() {
 		nodeClass = ExpectExists(ctx, env.Client, nodeClass)
 		Expect(nodeClass.Status.Subnets).To(Equal([]v1.Subnet{
 			{
-				ID:     "subnet-test1",
-				Zone:   "test-zone-1a",
-				ZoneID: "tstz1-1a",
+				ID:       "subnet-test1",
+				Zone:     "test-zone-1a",
+				ZoneID:   "tstz1-1a",
+				ZoneType: "availability-zone",
 			},
 			{
-				ID:     "subnet-test2",
-				Zone:   "test-zone-1b",
-				ZoneID: "tstz1-1b",
+				ID:       "subnet-test2",
+				Zone:     "test-zone-1b",
+				ZoneID:   "tstz1-1b",
+				ZoneType: "availability-zone",
 			},
 			{
-				ID:     "subnet-test3",
-				Zone:   "test-zone-1c",
-				ZoneID: "tstz1-1c",
+				ID:       "subnet-test3",
+				Zone:     "test-zone-1c",
+				ZoneID:   "tstz1-1c",
+				ZoneType: "availability-zone",
 			},
 			{
-				ID:     "subnet-test4",
-				Zone:   "test-zone-1a-local",
-				ZoneID: "tstz1-1alocal",
+				ID:         "subnet-test4",
+				Zone:       "test-zone-1a-local",
+				ZoneID:     "tstz1-1alocal",
+				ZoneType:   "local-zone",
+				ParentZone: "test-zone-1a",
 			},
 		}))
 		Expect(nodeClass.StatusConditions().IsTrue(v1.ConditionTypeSubnetsReady)).To(BeTrue())
@@ -90,19 +95,86 @@ var _ = Describe("NodeClass Subnet Status Controller", func() {
 		nodeClass = ExpectExists(ctx, env.Client, nodeClass)
 		Expect(nodeClass.Status.Subnets).To(Equal([]v1.Subnet{
 			{
-				ID:     "subnet-test2",
-				Zone:   "test-zone-1b",
-				ZoneID: "tstz1-1b",
+				ID:       "subnet-test2",
+				Zone:     "test-zone-1b",
+				ZoneID:   "tstz1-1b",
+				ZoneType: "availability-zone",
 			},
 			{
-				ID:     "subnet-test3",
-				Zone:   "test-zone-1c",
-				ZoneID: "tstz1-1c",
+				ID:       "subnet-test3",
+				Zone:     "test-zone-1c",
+				ZoneID:   "tstz1-1c",
+				ZoneType: "availability-zone",
 			},
 			{
-				ID:     "subnet-test1",
-				Zone:   "test-zone-1a",
-				ZoneID: "tstz1-1a",
+				ID:       "subnet-test1",
+				Zone:     "test-zone-1a",
+				ZoneID:   "tstz1-1a",
+				ZoneType: "availability-zone",
+			},
+		}))
+		Expect(nodeClass.StatusConditions().IsTrue(v1.ConditionTypeSubnetsReady)).To(BeTrue())
+	})
+	It("Should resolve the zone type of Outpost Subnets", func() {
+		awsEnv.EC2API.DescribeSubnetsOutput.Set(&ec2.DescribeSubnetsOutput{Subnets: []ec2types.Subnet{
+			{SubnetId: aws.String("subnet-test1"), AvailabilityZone: aws.String("test-zone-1a"), AvailabilityZoneId: aws.String("tstz1-1a"), AvailableIpAddressCount: aws.Int32(20),
+				OutpostArn: aws.String("arn:aws:outposts:us-west-2:123456789012:outpost/op-0123456789abcdef0")},
+			{SubnetId: aws.String("subnet-test2"), AvailabilityZone: aws.String("test-zone-1b"), AvailabilityZoneId: aws.String("tstz1-1b"), AvailableIpAddressCount: aws.Int32(10)},
+		}})
+		ExpectApplied(ctx, env.Client, nodeClass)
+		ExpectObjectReconciled(ctx, env.Client, controller, nodeClass)
+		nodeClass = ExpectExists(ctx, env.Client, nodeClass)
+		Expect(nodeClass.Status.Subnets).To(Equal([]v1.Subnet{
+			{
+				ID:         "subnet-test1",
+				Zone:       "test-zone-1a",
+				ZoneID:     "tstz1-1a",
+				ZoneType:   "outpost",
+				OutpostARN: "arn:aws:outposts:us-west-2:123456789012:outpost/op-0123456789abcdef0",
+			},
+			{
+				ID:       "subnet-test2",
+				Zone:     "test-zone-1b",
+				ZoneID:   "tstz1-1b",
+				ZoneType: "availability-zone",
+			},
+		}))
+		Expect(nodeClass.StatusConditions().IsTrue(v1.ConditionTypeSubnetsReady)).To(BeTrue())
+	})
+	It("Should not be ready when Outpost and non-Outpost Subnets share a zone", func() {
+		awsEnv.EC2API.DescribeSubnetsOutput.Set(&ec2.DescribeSubnetsOutput{Subnets: []ec2types.Subnet{
+			{SubnetId: aws.String("subnet-test1"), AvailabilityZone: aws.String("test-zone-1a"), AvailabilityZoneId: aws.String("tstz1-1a"), AvailableIpAddressCount: aws.Int32(20),
+				OutpostArn: aws.String("arn:aws:outposts:us-west-2:123456789012:outpost/op-0123456789abcdef0")},
+			{SubnetId: aws.String("subnet-test2"), AvailabilityZone: aws.String("test-zone-1a"), AvailabilityZoneId: aws.String("tstz1-1a"), AvailableIpAddressCount: aws.Int32(10)},
+		}})
+		ExpectApplied(ctx, env.Client, nodeClass)
+		ExpectObjectReconciled(ctx, env.Client, controller, nodeClass)
+		nodeClass = ExpectExists(ctx, env.Client, nodeClass)
+		Expect(nodeClass.StatusConditions().Get(v1.ConditionTypeSubnetsReady).IsFalse()).To(BeTrue())
+		Expect(nodeClass.StatusConditions().Get(v1.ConditionTypeSubnetsReady).Reason).To(Equal("OutpostSubnetConflict"))
+	})
+	It("Should flag Subnets that failed to allocate a /28 prefix", func() {
+		awsEnv.EC2API.DescribeSubnetsOutput.Set(&ec2.DescribeSubnetsOutput{Subnets: []ec2types.Subnet{
+			{SubnetId: aws.String("subnet-test1"), AvailabilityZone: aws.String("test-zone-1a"), AvailabilityZoneId: aws.String("tstz1-1a"), AvailableIpAddressCount: aws.Int32(20)},
+			{SubnetId: aws.String("subnet-test2"), AvailabilityZone: aws.String("test-zone-1b"), AvailabilityZoneId: aws.String("tstz1-1b"), AvailableIpAddressCount: aws.Int32(10)},
+		}})
+		awsEnv.SubnetProvider.MarkPrefixFragmented("subnet-test1")
+		ExpectApplied(ctx, env.Client, nodeClass)
+		ExpectObjectReconciled(ctx, env.Client, controller, nodeClass)
+		nodeClass = ExpectExists(ctx, env.Client, nodeClass)
+		Expect(nodeClass.Status.Subnets).To(Equal([]v1.Subnet{
+			{
+				ID:               "subnet-test1",
+				Zone:             "test-zone-1a",
+				ZoneID:           "tstz1-1a",
+				ZoneType:         "availability-zone",
+				PrefixFragmented: true,
+			},
+			{
+				ID:       "subnet-test2",
+				Zone:     "test-zone-1b",
+				ZoneID:   "tstz1-1b",
+				ZoneType: "availability-zone",
 			},
 		}))
 		Expect(nodeClass.StatusConditions().IsTrue(v1.ConditionTypeSubnetsReady)).To(BeTrue())
@@ -121,14 +193,16 @@ var _ = Describe("NodeClass Subnet Status Controller", func() {
 		nodeClass = ExpectExists(ctx, env.Client, nodeClass)
 		Expect(nodeClass.Status.Subnets).To(Equal([]v1.Subnet{
 			{
-				ID:     "subnet-test1",
-				Zone:   "test-zone-1a",
-				ZoneID: "tstz1-1a",
+				ID:       "subnet-test1",
+				Zone:     "test-zone-1a",
+				ZoneID:   "tstz1-1a",
+				ZoneType: "availability-zone",
 			},
 			{
-				ID:     "subnet-test2",
-				Zone:   "test-zone-1b",
-				ZoneID: "tstz1-1b",
+				ID:       "subnet-test2",
+				Zone:     "test-zone-1b",
+				ZoneID:   "tstz1-1b",
+				ZoneType: "availability-zone",
 			},
 		}))
 		Expect(nodeClass.StatusConditions().IsTrue(v1.ConditionTypeSubnetsReady)).To(BeTrue())
@@ -144,9 +218,10 @@ var _ = Describe("NodeClass Subnet Status Controller", func() {
 		nodeClass = ExpectExists(ctx, env.Client, nodeClass)
 		Expect(nodeClass.Status.Subnets).To(Equal([]v1.Subnet{
 			{
-				ID:     "subnet-test1",
-				Zone:   "test-zone-1a",
-				ZoneID: "tstz1-1a",
+				ID:       "subnet-test1",
+				Zone:     "test-zone-1a",
+				ZoneID:   "tstz1-1a",
+				ZoneType: "availability-zone",
 			},
 		}))
 		Expect(nodeClass.StatusConditions().IsTrue(v1.ConditionTypeSubnetsReady)).To(BeTrue())
@@ -157,24 +232,29 @@ var _ = Describe("NodeClass Subnet Status Controller", func() {
 		nodeClass = ExpectExists(ctx, env.Client, nodeClass)
 		Expect(nodeClass.Status.Subnets).To(Equal([]v1.Subnet{
 			{
-				ID:     "subnet-test1",
-				Zone:   "test-zone-1a",
-				ZoneID: "tstz1-1a",
+				ID:       "subnet-test1",
+				Zone:     "test-zone-1a",
+				ZoneID:   "tstz1-1a",
+				ZoneType: "availability-zone",
 			},
 			{
-				ID:     "subnet-test2",
-				Zone:   "test-zone-1b",
-				ZoneID: "tstz1-1b",
+				ID:       "subnet-test2",
+				Zone:     "test-zone-1b",
+				ZoneID:   "tstz1-1b",
+				ZoneType: "availability-zone",
 			},
 			{
-				ID:     "subnet-test3",
-				Zone:   "test-zone-1c",
-				ZoneID: "tstz1-1c",
+				ID:       "subnet-test3",
+				Zone:     "test-zone-1c",
+				ZoneID:   "tstz1-1c",
+				ZoneType: "availability-zone",
 			},
 			{
-				ID:     "subnet-test4",
-				Zone:   "test-zone-1a-local",
-				ZoneID: "tstz1-1alocal",
+				ID:         "subnet-test4",
+				Zone:       "test-zone-1a-local",
+				ZoneID:     "tstz1-1alocal",
+				ZoneType:   "local-zone",
+				ParentZone: "test-zone-1a",
 			},
 		}))
 
@@ -195,14 +275,16 @@ var _ = Describe("NodeClass Subnet Status Controller", func() {
 		nodeClass = ExpectExists(ctx, env.Client, nodeClass)
 		Expect(nodeClass.Status.Subnets).To(Equal([]v1.Subnet{
 			{
-				ID:     "subnet-test1",
-				Zone:   "test-zone-1a",
-				ZoneID: "tstz1-1a",
+				ID:       "subnet-test1",
+				Zone:     "test-zone-1a",
+				ZoneID:   "tstz1-1a",
+				ZoneType: "availability-zone",
 			},
 			{
-				ID:     "subnet-test2",
-				Zone:   "test-zone-1b",
-				ZoneID: "tstz1-1b",
+				ID:       "subnet-test2",
+				Zone:     "test-zone-1b",
+				ZoneID:   "tstz1-1b",
+				ZoneType: "availability-zone",
 			},
 		}))
 		Expect(nodeClass.StatusConditions().IsTrue(v1.ConditionTypeSubnetsReady)).To(BeTrue())
@@ -213,24 +295,29 @@ var _ = Describe("NodeClass Subnet Status Controller", func() {
 		nodeClass = ExpectExists(ctx, env.Client, nodeClass)
 		Expect(nodeClass.Status.Subnets).To(Equal([]v1.Subnet{
 			{
-				ID:     "subnet-test1",
-				Zone:   "test-zone-1a",
-				ZoneID: "tstz1-1a",
+				ID:       "subnet-test1",
+				Zone:     "test-zone-1a",
+				ZoneID:   "tstz1-1a",
+				ZoneType: "availability-zone",
 			},
 			{
-				ID:     "subnet-test2",
-				Zone:   "test-zone-1b",
-				ZoneID: "tstz1-1b",
+				ID:       "subnet-test2",
+				Zone:     "test-zone-1b",
+				ZoneID:   "tstz1-1b",
+				ZoneType: "availability-zone",
 			},
 			{
-				ID:     "subnet-test3",
-				Zone:   "test-zone-1c",
-				ZoneID: "tstz1-1c",
+				ID:       "subnet-test3",
+				Zone:     "test-zone-1c",
+				ZoneID:   "tstz1-1c",
+				ZoneType: "availability-zone",
 			},
 			{
-				ID:     "subnet-test4",
-				Zone:   "test-zone-1a-local",
-				ZoneID: "tstz1-1alocal",
+				ID:         "subnet-test4",
+				Zone:       "test-zone-1a-local",
+				ZoneID:     "tstz1-1alocal",
+				ZoneType:   "local-zone",
+				ParentZone: "test-zone-1a",
 			},
 		}))
 
@@ -244,9 +331,10 @@ var _ = Describe("NodeClass Subnet Status Controller", func() {
 		nodeClass = ExpectExists(ctx, env.Client, nodeClass)
 		Expect(nodeClass.Status.Subnets).To(Equal([]v1.Subnet{
 			{
-				ID:     "subnet-test1",
-				Zone:   "test-zone-1a",
-				ZoneID: "tstz1-1a",
+				ID:       "subnet-test1",
+				Zone:     "test-zone-1a",
+				ZoneID:   "tstz1-1a",
+				ZoneType: "availability-zone",
 			},
 		}))
 		Expect(nodeClass.StatusConditions().IsTrue(v1.ConditionTypeSubnetsReady)).To(BeTrue())
@@ -269,24 +357,29 @@ var _ = Describe("NodeClass Subnet Status Controller", func() {
 		nodeClass = ExpectExists(ctx, env.Client, nodeClass)
 		Expect(nodeClass.Status.Subnets).To(Equal([]v1.Subnet{
 			{
-				ID:     "subnet-test1",
-				Zone:   "test-zone-1a",
-				ZoneID: "tstz1-1a",
+				ID:       "subnet-test1",
+				Zone:     "test-zone-1a",
+				ZoneID:   "tstz1-1a",
+				ZoneType: "availability-zone",
 			},
 			{
-				ID:     "subnet-test2",
-				Zone:   "test-zone-1b",
-				ZoneID: "tstz1-1b",
+				ID:       "subnet-test2",
+				Zone:     "test-zone-1b",
+				ZoneID:   "tstz1-1b",
+				ZoneType: "availability-zone",
 			},
 			{
-				ID:     "subnet-test3",
-				Zone:   "test-zone-1c",
-				ZoneID: "tstz1-1c",
+				ID:       "subnet-test3",
+				Zone:     "test-zone-1c",
+				ZoneID:   "tstz1-1c",
+				ZoneType: "availability-zone",
 			},
 			{
-				ID:     "subnet-test4",
-				Zone:   "test-zone-1a-local",
-				ZoneID: "tstz1-1alocal",
+				ID:         "subnet-test4",
+				Zone:       "test-zone-1a-local",
+				ZoneID:     "tstz1-1alocal",
+				ZoneType:   "local-zone",
+				ParentZone: "test-zone-1a",
 			},
 		}))
 
@@ -68,11 +68,17 @@ var _ = BeforeSuite(func() {
 
 	controller = nodeclass.NewController(
 		env.Client, events.NewRecorder(&record.FakeRecorder{}),
+		awsEnv.EC2API,
 		awsEnv.SubnetProvider,
 		awsEnv.SecurityGroupProvider,
 		awsEnv.AMIProvider,
 		awsEnv.InstanceProfileProvider,
 		awsEnv.LaunchTemplateProvider,
+		awsEnv.CapacityReservationProvider,
+		awsEnv.PlacementGroupProvider,
+		awsEnv.HostProvider,
+		awsEnv.InstanceTypesProvider,
+		awsEnv.ServiceQuotaProvider,
 	)
 })
 
@@ -84,6 +90,8 @@ var _ = BeforeEach(func() {
 	ctx = coreoptions.ToContext(ctx, coretest.Options())
 	nodeClass = test.EC2NodeClass()
 	awsEnv.Reset()
+	Expect(awsEnv.InstanceTypesProvider.UpdateInstanceTypes(ctx)).To(Succeed())
+	Expect(awsEnv.InstanceTypesProvider.UpdateInstanceTypeOfferings(ctx)).To(Succeed())
 })
 
 var _ = AfterEach(func() {
@@ -264,6 +272,58 @@ var _ = Describe("NodeClass Termination", func() {
 		Expect(awsEnv.IAMAPI.InstanceProfiles).To(HaveLen(0))
 		ExpectNotFound(ctx, env.Client, nodeClass)
 	})
+	It("should succeed to delete the managed placement group", func() {
+		nodeClass.Spec.PlacementGroup = &v1.PlacementGroupSelector{Strategy: "cluster"}
+		controllerutil.AddFinalizer(nodeClass, v1.TerminationFinalizer)
+		ExpectApplied(ctx, env.Client, nodeClass)
+		ExpectObjectReconciled(ctx, env.Client, controller, nodeClass)
+		nodeClass = ExpectExists(ctx, env.Client, nodeClass)
+		Expect(nodeClass.Status.PlacementGroup).ToNot(BeNil())
+		_, ok := awsEnv.EC2API.PlacementGroups.Load(nodeClass.Status.PlacementGroup.ID)
+		Expect(ok).To(BeTrue())
+
+		Expect(env.Client.Delete(ctx, nodeClass)).To(Succeed())
+		ExpectObjectReconciled(ctx, env.Client, controller, nodeClass)
+		_, ok = awsEnv.EC2API.PlacementGroups.Load(nodeClass.Status.PlacementGroup.ID)
+		Expect(ok).To(BeFalse())
+		ExpectNotFound(ctx, env.Client, nodeClass)
+	})
+	It("should delete the managed placement group recorded in the status", func() {
+		nodeClass.Spec.PlacementGroup = &v1.PlacementGroupSelector{Strategy: "cluster"}
+		controllerutil.AddFinalizer(nodeClass, v1.TerminationFinalizer)
+		ExpectApplied(ctx, env.Client, nodeClass)
+		ExpectObjectReconciled(ctx, env.Client, controller, nodeClass)
+		nodeClass = ExpectExists(ctx, env.Client, nodeClass)
+		Expect(nodeClass.Status.PlacementGroup).ToNot(BeNil())
+		Expect(nodeClass.Status.PlacementGroup.Managed).To(BeTrue())
+
+		// the placement group is removed from the spec before the status is updated
+		nodeClass.Spec.PlacementGroup = nil
+		ExpectApplied(ctx, env.Client, nodeClass)
+		Expect(env.Client.Delete(ctx, nodeClass)).To(Succeed())
+		ExpectObjectReconciled(ctx, env.Client, controller, nodeClass)
+		_, ok := awsEnv.EC2API.PlacementGroups.Load(nodeClass.Status.PlacementGroup.ID)
+		Expect(ok).To(BeFalse())
+		ExpectNotFound(ctx, env.Client, nodeClass)
+	})
+	It("should not delete a placement group that was selected by name", func() {
+		awsEnv.EC2API.PlacementGroups.Store("pg-existing", ec2types.PlacementGroup{
+			GroupId:   aws.String("pg-existing"),
+			GroupName: aws.String("existing"),
+			Strategy:  ec2types.PlacementStrategyCluster,
+			State:     ec2types.PlacementGroupStateAvailable,
+		})
+		nodeClass.Spec.PlacementGroup = &v1.PlacementGroupSelector{Name: "existing"}
+		controllerutil.AddFinalizer(nodeClass, v1.TerminationFinalizer)
+		ExpectApplied(ctx, env.Client, nodeClass)
+		ExpectObjectReconciled(ctx, env.Client, controller, nodeClass)
+
+		Expect(env.Client.Delete(ctx, nodeClass)).To(Succeed())
+		ExpectObjectReconciled(ctx, env.Client, controller, nodeClass)
+		_, ok := awsEnv.EC2API.PlacementGroups.Load("pg-existing")
+		Expect(ok).To(BeTrue())
+		ExpectNotFound(ctx, env.Client, nodeClass)
+	})
 	It("should not call the IAM API when deleting a NodeClass with an instanceProfile specified", func() {
 		awsEnv.IAMAPI.InstanceProfiles = map[string]*iamtypes.InstanceProfile{
 			profileName: {
@@ -0,0 +1,315 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package warmpool
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/awslabs/operatorpkg/object"
+	"github.com/awslabs/operatorpkg/singleton"
+	"github.com/samber/lo"
+	"go.uber.org/multierr"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/klog/v2"
+	"k8s.io/utils/clock"
+	controllerruntime "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	karpv1 "sigs.k8s.io/karpenter/pkg/apis/v1"
+	"sigs.k8s.io/karpenter/pkg/cloudprovider"
+	"sigs.k8s.io/karpenter/pkg/operator/injection"
+	nodeutils "sigs.k8s.io/karpenter/pkg/utils/node"
+
+	v1 "github.com/aws/karpenter-provider-aws/pkg/apis/v1"
+	"github.com/aws/karpenter-provider-aws/pkg/operator/options"
+	"github.com/aws/karpenter-provider-aws/pkg/providers/instance"
+	"github.com/aws/karpenter-provider-aws/pkg/providers/instancetype"
+	"github.com/aws/karpenter-provider-aws/pkg/providers/pricing"
+)
+
+// InitializationTimeout is the amount of time a warm instance has to register a Ready node before it's replaced
+const InitializationTimeout = 15 * time.Minute
+
+// Controller maintains the warm pools of EC2NodeClasses. Warm instances are launched with the current configuration of
+// their EC2NodeClass, stopped once they have bootstrapped and joined the cluster, and replaced when the EC2NodeClass
+// drifts. Instances are removed from the warm pool by the cloudprovider when they are claimed by a NodeClaim.
+type Controller struct {
+	kubeClient           client.Client
+	clk                  clock.Clock
+	instanceProvider     instance.Provider
+	instanceTypeProvider instancetype.Provider
+	pricingProvider      pricing.Provider
+}
+
+func NewController(kubeClient client.Client, clk clock.Clock, instanceProvider instance.Provider, instanceTypeProvider instancetype.Provider, pricingProvider pricing.Provider) *Controller {
+	return &Controller{
+		kubeClient:           kubeClient,
+		clk:                  clk,
+		instanceProvider:     instanceProvider,
+		instanceTypeProvider: instanceTypeProvider,
+		pricingProvider:      pricingProvider,
+	}
+}
+
+func (c *Controller) Reconcile(ctx context.Context) (reconcile.Result, error) {
+	ctx = injection.WithControllerName(ctx, "nodeclass.warmpool")
+
+	nodeClassList := &v1.EC2NodeClassList{}
+	if err := c.kubeClient.List(ctx, nodeClassList); err != nil {
+		return reconcile.Result{}, fmt.Errorf("listing ec2nodeclasses, %w", err)
+	}
+	instances, err := c.instanceProvider.ListWarm(ctx)
+	if err != nil {
+		return reconcile.Result{}, fmt.Errorf("listing warm instances, %w", err)
+	}
+	nodeList := &corev1.NodeList{}
+	if err = c.kubeClient.List(ctx, nodeList); err != nil {
+		return reconcile.Result{}, fmt.Errorf("listing nodes, %w", err)
+	}
+	Instances.Reset()
+	EstimatedHourlyCost.Reset()
+
+	instancesByNodeClass := lo.GroupBy(instances, func(i *instance.Instance) string { return i.Tags[v1.NodeClassTagKey] })
+	var errs error
+	for i := range nodeClassList.Items {
+		nodeClass := &nodeClassList.Items[i]
+		errs = multierr.Append(errs, c.reconcileWarmPool(ctx, nodeClass, instancesByNodeClass[nodeClass.Name], nodeList))
+		delete(instancesByNodeClass, nodeClass.Name)
+	}
+	// Any remaining warm instances belong to EC2NodeClasses that no longer exist
+	for _, orphaned := range instancesByNodeClass {
+		errs = multierr.Append(errs, c.terminate(ctx, nodeList, orphaned...))
+	}
+	if errs != nil {
+		return reconcile.Result{}, fmt.Errorf("reconciling warm pools, %w", errs)
+	}
+	return reconcile.Result{RequeueAfter: time.Minute}, nil
+}
+
+func (c *Controller) reconcileWarmPool(ctx context.Context, nodeClass *v1.EC2NodeClass, instances []*instance.Instance, nodeList *corev1.NodeList) error {
+	ctx = log.IntoContext(ctx, log.FromContext(ctx).WithValues("EC2NodeClass", klog.KObj(nodeClass)))
+
+	nodeClaim, err := c.warmPoolNodeClaim(ctx, nodeClass)
+	if err != nil {
+		return err
+	}
+	size := 0
+	if nodeClaim != nil && nodeClass.DeletionTimestamp.IsZero() {
+		size = int(nodeClass.Spec.WarmPool.Size)
+	}
+	// Instances that are drifted or failed to initialize are replaced
+	current, replaced := lo.FilterReject(instances, func(i *instance.Instance, _ int) bool {
+		return nodeClaim != nil && !isDrifted(nodeClass, nodeClaim, i) && !c.failedToInitialize(i, nodeList)
+	})
+	errs := c.terminate(ctx, nodeList, replaced...)
+	if len(current) > size {
+		// Prefer keeping the instances that are already stopped since they're ready to be claimed
+		sort.SliceStable(current, func(i, j int) bool {
+			return current[i].State == ec2types.InstanceStateNameStopped && current[j].State != ec2types.InstanceStateNameStopped
+		})
+		errs = multierr.Append(errs, c.terminate(ctx, nodeList, current[size:]...))
+		current = current[:size]
+	}
+	for _, i := range current {
+		errs = multierr.Append(errs, c.stopInitialized(ctx, i, nodeList))
+	}
+	c.publishMetrics(nodeClass, current)
+	if len(current) < size && nodeClass.StatusConditions().Root().IsTrue() {
+		errs = multierr.Append(errs, c.launch(ctx, nodeClass, nodeClaim, size-len(current)))
+	}
+	return errs
+}
+
+// warmPoolNodeClaim returns the NodeClaim that warm instances are launched with, which carries the labels, taints and
+// requirements of the warm pool's NodePool. Nil is returned when the EC2NodeClass has no warm pool, or its NodePool
+// doesn't exist or doesn't use the EC2NodeClass, since no NodeClaim could claim the warm instances.
+func (c *Controller) warmPoolNodeClaim(ctx context.Context, nodeClass *v1.EC2NodeClass) (*karpv1.NodeClaim, error) {
+	if nodeClass.Spec.WarmPool == nil {
+		return nil, nil
+	}
+	nodePool := &karpv1.NodePool{}
+	if err := c.kubeClient.Get(ctx, client.ObjectKey{Name: nodeClass.Spec.WarmPool.NodePool}, nodePool); err != nil {
+		if errors.IsNotFound(err) {
+			log.FromContext(ctx).WithValues("NodePool", klog.KRef("", nodeClass.Spec.WarmPool.NodePool)).V(1).Info("warm pool nodepool not found")
+			return nil, nil
+		}
+		return nil, fmt.Errorf("getting nodepool, %w", err)
+	}
+	nodeClassRef := nodePool.Spec.Template.Spec.NodeClassRef
+	if nodeClassRef == nil || nodeClassRef.Name != nodeClass.Name || nodeClassRef.GroupKind() != object.GVK(nodeClass).GroupKind() {
+		log.FromContext(ctx).WithValues("NodePool", klog.KObj(nodePool)).V(1).Info("warm pool nodepool doesn't use the ec2nodeclass")
+		return nil, nil
+	}
+	// Mirrors the labels that are added to the NodeClaims of the NodePool when they're created
+	nodeClaim := nodePool.Spec.Template.ToNodeClaim()
+	nodeClaim.Labels = lo.Assign(nodeClaim.Labels, map[string]string{
+		karpv1.NodePoolLabelKey:                            nodePool.Name,
+		karpv1.NodeClassLabelKey(nodeClassRef.GroupKind()): nodeClassRef.Name,
+	})
+	nodeClaim.Spec.Requirements = append([]karpv1.NodeSelectorRequirementWithMinValues{
+		{NodeSelectorRequirement: corev1.NodeSelectorRequirement{Key: karpv1.CapacityTypeLabelKey, Operator: corev1.NodeSelectorOpIn, Values: []string{karpv1.CapacityTypeOnDemand}}},
+		{NodeSelectorRequirement: corev1.NodeSelectorRequirement{Key: corev1.LabelInstanceTypeStable, Operator: corev1.NodeSelectorOpIn, Values: nodeClass.Spec.WarmPool.InstanceTypes}},
+	}, nodeClaim.Spec.Requirements...)
+	return nodeClaim, nil
+}
+
+// isDrifted returns true if the warm instance was launched with a configuration that differs from the EC2NodeClass and
+// the template of its NodePool
+func isDrifted(nodeClass *v1.EC2NodeClass, nodeClaim *karpv1.NodeClaim, i *instance.Instance) bool {
+	if i.Tags[v1.WarmPoolTagKey] != nodeClass.WarmPoolHash(nodeClaim) {
+		return true
+	}
+	if len(nodeClass.Status.AMIs) != 0 && !lo.ContainsBy(nodeClass.Status.AMIs, func(ami v1.AMI) bool { return ami.ID == i.ImageID }) {
+		return true
+	}
+	return !lo.Contains(nodeClass.Spec.WarmPool.InstanceTypes, string(i.Type))
+}
+
+// failedToInitialize returns true if the warm instance is running but hasn't registered a Ready node in time
+func (c *Controller) failedToInitialize(i *instance.Instance, nodeList *corev1.NodeList) bool {
+	if i.State != ec2types.InstanceStateNameRunning || c.clk.Since(i.LaunchTime) <= InitializationTimeout {
+		return false
+	}
+	node, ok := findNode(nodeList, i)
+	return !ok || !isReady(node)
+}
+
+// stopInitialized stops a running warm instance once its node has become ready, which means that it has been fully
+// bootstrapped. The node is cordoned before the instance is stopped and deleted afterwards, since the instance registers
+// again when it's started by a NodeClaim.
+func (c *Controller) stopInitialized(ctx context.Context, i *instance.Instance, nodeList *corev1.NodeList) error {
+	if i.State != ec2types.InstanceStateNameRunning {
+		return nil
+	}
+	node, ok := findNode(nodeList, i)
+	if !ok || !isReady(node) {
+		return nil
+	}
+	if !node.Spec.Unschedulable {
+		stored := node.DeepCopy()
+		node.Spec.Unschedulable = true
+		if err := c.kubeClient.Patch(ctx, node, client.MergeFrom(stored)); client.IgnoreNotFound(err) != nil {
+			return fmt.Errorf("cordoning node, %w", err)
+		}
+	}
+	if err := c.instanceProvider.Stop(ctx, i.ID); err != nil {
+		return cloudprovider.IgnoreNodeClaimNotFoundError(err)
+	}
+	log.FromContext(ctx).WithValues("instance-id", i.ID).V(1).Info("stopped initialized warm instance")
+	i.State = ec2types.InstanceStateNameStopping
+	if err := c.kubeClient.Delete(ctx, node); err != nil {
+		return client.IgnoreNotFound(err)
+	}
+	return nil
+}
+
+func (c *Controller) launch(ctx context.Context, nodeClass *v1.EC2NodeClass, nodeClaim *karpv1.NodeClaim, count int) error {
+	instanceTypes, err := c.instanceTypeProvider.List(ctx, nodeClass)
+	if err != nil {
+		return fmt.Errorf("listing instance types, %w", err)
+	}
+	instanceTypes = lo.Filter(instanceTypes, func(it *cloudprovider.InstanceType, _ int) bool {
+		return lo.Contains(nodeClass.Spec.WarmPool.InstanceTypes, it.Name)
+	})
+	if len(instanceTypes) == 0 {
+		return fmt.Errorf("no warm pool instance types are available")
+	}
+	tags := lo.Assign(nodeClass.Spec.Tags, map[string]string{
+		fmt.Sprintf("kubernetes.io/cluster/%s", options.FromContext(ctx).ClusterName): "owned",
+		v1.EKSClusterNameTagKey: options.FromContext(ctx).ClusterName,
+		v1.NodeClassTagKey:      nodeClass.Name,
+		v1.WarmPoolTagKey:       nodeClass.WarmPoolHash(nodeClaim),
+	})
+	// Warm instances register their nodes with the warm pool taint, which isn't part of the warm pool hash since the
+	// NodeClaims that claim the instances don't carry it
+	nodeClaim = nodeClaim.DeepCopy()
+	nodeClaim.Spec.Taints = append(nodeClaim.Spec.Taints, v1.WarmPoolNoScheduleTaint)
+	for range count {
+		i, err := c.instanceProvider.Create(ctx, nodeClass, nodeClaim, tags, instanceTypes)
+		if err != nil {
+			return fmt.Errorf("launching warm instance, %w", err)
+		}
+		log.FromContext(ctx).WithValues("instance-id", i.ID, "instance-type", i.Type, "zone", i.Zone).Info("launched warm instance")
+	}
+	return nil
+}
+
+func (c *Controller) terminate(ctx context.Context, nodeList *corev1.NodeList, instances ...*instance.Instance) error {
+	var errs error
+	for _, i := range instances {
+		if i.State == ec2types.InstanceStateNameShuttingDown {
+			continue
+		}
+		if err := c.instanceProvider.Delete(ctx, i.ID); cloudprovider.IgnoreNodeClaimNotFoundError(err) != nil {
+			errs = multierr.Append(errs, err)
+			continue
+		}
+		log.FromContext(ctx).WithValues("instance-id", i.ID).V(1).Info("terminated warm instance")
+		if node, ok := findNode(nodeList, i); ok {
+			if err := c.kubeClient.Delete(ctx, node); client.IgnoreNotFound(err) != nil {
+				errs = multierr.Append(errs, err)
+			}
+		}
+	}
+	return errs
+}
+
+func (c *Controller) publishMetrics(nodeClass *v1.EC2NodeClass, instances []*instance.Instance) {
+	for key, count := range lo.CountValuesBy(instances, func(i *instance.Instance) lo.Tuple2[string, string] {
+		return lo.T2(string(i.Type), string(i.State))
+	}) {
+		Instances.Set(float64(count), map[string]string{
+			nodeClassLabel:    nodeClass.Name,
+			instanceTypeLabel: key.A,
+			stateLabel:        key.B,
+		})
+	}
+	cost := lo.SumBy(instances, func(i *instance.Instance) float64 {
+		if i.State != ec2types.InstanceStateNamePending && i.State != ec2types.InstanceStateNameRunning {
+			return 0
+		}
+		price, _ := c.pricingProvider.OnDemandPrice(pricing.OperatingSystemForAMIFamily(nodeClass.AMIFamily()), i.Type)
+		return price
+	})
+	EstimatedHourlyCost.Set(cost, map[string]string{nodeClassLabel: nodeClass.Name})
+}
+
+func isReady(node *corev1.Node) bool {
+	return nodeutils.GetCondition(node, corev1.NodeReady).Status == corev1.ConditionTrue
+}
+
+func findNode(nodeList *corev1.NodeList, i *instance.Instance) (*corev1.Node, bool) {
+	providerID := fmt.Sprintf("aws:///%s/%s", i.Zone, i.ID)
+	for j := range nodeList.Items {
+		if nodeList.Items[j].Spec.ProviderID == providerID {
+			return &nodeList.Items[j], true
+		}
+	}
+	return nil, false
+}
+
+func (c *Controller) Register(_ context.Context, m manager.Manager) error {
+	return controllerruntime.NewControllerManagedBy(m).
+		Named("nodeclass.warmpool").
+		WatchesRawSource(singleton.Source()).
+		Complete(singleton.AsReconciler(c))
+}
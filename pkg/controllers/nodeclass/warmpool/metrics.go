@@ -0,0 +1,53 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package warmpool
+
+import (
+	opmetrics "github.com/awslabs/operatorpkg/metrics"
+	"github.com/prometheus/client_golang/prometheus"
+	crmetrics "sigs.k8s.io/controller-runtime/pkg/metrics"
+
+	"sigs.k8s.io/karpenter/pkg/metrics"
+)
+
+const (
+	warmPoolSubsystem = "warm_pool"
+	nodeClassLabel    = "ec2nodeclass"
+	instanceTypeLabel = "instance_type"
+	stateLabel        = "state"
+)
+
+var (
+	Instances = opmetrics.NewPrometheusGauge(
+		crmetrics.Registry,
+		prometheus.GaugeOpts{
+			Namespace: metrics.Namespace,
+			Subsystem: warmPoolSubsystem,
+			Name:      "instances",
+			Help:      "Number of instances in the warm pool of an EC2NodeClass. Broken down by EC2NodeClass, instance type and instance state.",
+		},
+		[]string{nodeClassLabel, instanceTypeLabel, stateLabel},
+	)
+	EstimatedHourlyCost = opmetrics.NewPrometheusGauge(
+		crmetrics.Registry,
+		prometheus.GaugeOpts{
+			Namespace: metrics.Namespace,
+			Subsystem: warmPoolSubsystem,
+			Name:      "estimated_hourly_cost",
+			Help:      "Estimated on-demand cost per hour of the warm pool instances of an EC2NodeClass that are running. Stopped instances only incur storage costs, which aren't included.",
+		},
+		[]string{nodeClassLabel},
+	)
+)
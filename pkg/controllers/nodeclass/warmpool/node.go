@@ -0,0 +1,82 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package warmpool
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/samber/lo"
+	corev1 "k8s.io/api/core/v1"
+	controllerruntime "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	karpv1 "sigs.k8s.io/karpenter/pkg/apis/v1"
+	"sigs.k8s.io/karpenter/pkg/operator/injection"
+
+	v1 "github.com/aws/karpenter-provider-aws/pkg/apis/v1"
+)
+
+// NodeController removes the warm pool taint from the nodes of warm instances that have been claimed by a NodeClaim.
+// The kubelet of a warm instance registers the taint every time the instance starts, and registration only syncs the
+// taints of the NodeClaim, so the taint is removed once the node has been registered for its NodeClaim.
+type NodeController struct {
+	kubeClient client.Client
+}
+
+func NewNodeController(kubeClient client.Client) *NodeController {
+	return &NodeController{
+		kubeClient: kubeClient,
+	}
+}
+
+func (c *NodeController) Reconcile(ctx context.Context, node *corev1.Node) (reconcile.Result, error) {
+	ctx = injection.WithControllerName(ctx, "nodeclass.warmpool.node")
+
+	if !isClaimed(node) {
+		return reconcile.Result{}, nil
+	}
+	stored := node.DeepCopy()
+	node.Spec.Taints = lo.Reject(node.Spec.Taints, func(t corev1.Taint, _ int) bool {
+		return t.MatchTaint(&v1.WarmPoolNoScheduleTaint)
+	})
+	// We use client.MergeFromWithOptimisticLock because patching a list with a JSON merge patch
+	// can cause races due to the fact that it fully replaces the list on a change
+	if err := c.kubeClient.Patch(ctx, node, client.MergeFromWithOptions(stored, client.MergeFromWithOptimisticLock{})); err != nil {
+		return reconcile.Result{}, client.IgnoreNotFound(fmt.Errorf("removing warm pool taint, %w", err))
+	}
+	log.FromContext(ctx).V(1).Info("removed warm pool taint from claimed node")
+	return reconcile.Result{}, nil
+}
+
+// isClaimed returns true if the node carries the warm pool taint and has been registered for a NodeClaim
+func isClaimed(node *corev1.Node) bool {
+	return node.Labels[karpv1.NodeRegisteredLabelKey] == "true" && lo.ContainsBy(node.Spec.Taints, func(t corev1.Taint) bool {
+		return t.MatchTaint(&v1.WarmPoolNoScheduleTaint)
+	})
+}
+
+func (c *NodeController) Register(_ context.Context, m manager.Manager) error {
+	return controllerruntime.NewControllerManagedBy(m).
+		Named("nodeclass.warmpool.node").
+		For(&corev1.Node{}).
+		WithEventFilter(predicate.NewPredicateFuncs(func(o client.Object) bool {
+			return isClaimed(o.(*corev1.Node))
+		})).
+		Complete(reconcile.AsReconciler(m.GetClient(), c))
+}
@@ -0,0 +1,387 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package warmpool_test
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/awslabs/operatorpkg/object"
+	"github.com/awslabs/operatorpkg/status"
+	"github.com/samber/lo"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clock "k8s.io/utils/clock/testing"
+	karpv1 "sigs.k8s.io/karpenter/pkg/apis/v1"
+	coreoptions "sigs.k8s.io/karpenter/pkg/operator/options"
+	coretest "sigs.k8s.io/karpenter/pkg/test"
+	"sigs.k8s.io/karpenter/pkg/test/v1alpha1"
+
+	"github.com/aws/karpenter-provider-aws/pkg/apis"
+	v1 "github.com/aws/karpenter-provider-aws/pkg/apis/v1"
+	"github.com/aws/karpenter-provider-aws/pkg/controllers/nodeclass/warmpool"
+	"github.com/aws/karpenter-provider-aws/pkg/fake"
+	"github.com/aws/karpenter-provider-aws/pkg/operator/options"
+	"github.com/aws/karpenter-provider-aws/pkg/providers/instance"
+	"github.com/aws/karpenter-provider-aws/pkg/providers/pricing"
+	"github.com/aws/karpenter-provider-aws/pkg/test"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	. "sigs.k8s.io/karpenter/pkg/test/expectations"
+	. "sigs.k8s.io/karpenter/pkg/utils/testing"
+)
+
+var ctx context.Context
+var stop context.CancelFunc
+var env *coretest.Environment
+var awsEnv *test.Environment
+var fakeClock *clock.FakeClock
+var controller *warmpool.Controller
+var nodeController *warmpool.NodeController
+
+func TestAWS(t *testing.T) {
+	ctx = TestContextWithLogger(t)
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "WarmPool")
+}
+
+var _ = BeforeSuite(func() {
+	env = coretest.NewEnvironment(coretest.WithCRDs(apis.CRDs...), coretest.WithCRDs(v1alpha1.CRDs...))
+	ctx = coreoptions.ToContext(ctx, coretest.Options())
+	ctx = options.ToContext(ctx, test.Options())
+	ctx, stop = context.WithCancel(ctx)
+	awsEnv = test.NewEnvironment(ctx, env)
+	fakeClock = clock.NewFakeClock(time.Now())
+	controller = warmpool.NewController(env.Client, fakeClock, awsEnv.InstanceProvider, awsEnv.InstanceTypesProvider, awsEnv.PricingProvider)
+	nodeController = warmpool.NewNodeController(env.Client)
+})
+
+var _ = AfterSuite(func() {
+	stop()
+	Expect(env.Stop()).To(Succeed(), "Failed to stop environment")
+})
+
+var _ = BeforeEach(func() {
+	ctx = coreoptions.ToContext(ctx, coretest.Options())
+	ctx = options.ToContext(ctx, test.Options())
+	awsEnv.Reset()
+	Expect(awsEnv.InstanceTypesProvider.UpdateInstanceTypes(ctx)).To(Succeed())
+	Expect(awsEnv.InstanceTypesProvider.UpdateInstanceTypeOfferings(ctx)).To(Succeed())
+})
+
+var _ = AfterEach(func() {
+	ExpectCleanedUp(ctx, env.Client)
+})
+
+var _ = Describe("WarmPool", func() {
+	var nodeClass *v1.EC2NodeClass
+	var nodePool *karpv1.NodePool
+	BeforeEach(func() {
+		nodeClass = test.EC2NodeClass()
+		nodeClass.StatusConditions().SetTrue(status.ConditionReady)
+		nodePool = coretest.NodePool(karpv1.NodePool{
+			Spec: karpv1.NodePoolSpec{
+				Template: karpv1.NodeClaimTemplate{
+					ObjectMeta: karpv1.ObjectMeta{
+						Labels: map[string]string{"team": "warm"},
+					},
+					Spec: karpv1.NodeClaimTemplateSpec{
+						NodeClassRef: &karpv1.NodeClassReference{
+							Group: object.GVK(nodeClass).Group,
+							Kind:  object.GVK(nodeClass).Kind,
+							Name:  nodeClass.Name,
+						},
+						Taints: []corev1.Taint{{Key: "team", Value: "warm", Effect: corev1.TaintEffectNoSchedule}},
+					},
+				},
+			},
+		})
+		nodeClass.Spec.WarmPool = &v1.WarmPool{
+			NodePool:      nodePool.Name,
+			Size:          2,
+			InstanceTypes: []string{"m5.large", "m5.xlarge"},
+		}
+	})
+	AfterEach(func() {
+		ExpectDeleted(ctx, env.Client, nodeClass)
+	})
+	// warmPoolHash returns the hash that warm instances of the NodePool are expected to be tagged with
+	warmPoolHash := func() string {
+		nodeClaim := nodePool.Spec.Template.ToNodeClaim()
+		nodeClaim.Labels = lo.Assign(nodeClaim.Labels, map[string]string{
+			karpv1.NodePoolLabelKey: nodePool.Name,
+			karpv1.NodeClassLabelKey(object.GVK(nodeClass).GroupKind()): nodeClass.Name,
+		})
+		return nodeClass.WarmPoolHash(nodeClaim)
+	}
+	// warmInstance stores a warm instance of the EC2NodeClass in the fake EC2 API
+	warmInstance := func(state ec2types.InstanceStateName, launchTime time.Time, tags ...ec2types.Tag) ec2types.Instance {
+		i := ec2types.Instance{
+			InstanceId:   aws.String(fake.InstanceID()),
+			InstanceType: "m5.large",
+			ImageId:      aws.String("ami-test1"),
+			LaunchTime:   aws.Time(launchTime),
+			State:        &ec2types.InstanceState{Name: state},
+			Placement:    &ec2types.Placement{AvailabilityZone: aws.String("test-zone-1a")},
+			Tags: append([]ec2types.Tag{
+				{Key: aws.String(v1.EKSClusterNameTagKey), Value: aws.String(options.FromContext(ctx).ClusterName)},
+				{Key: aws.String(v1.NodeClassTagKey), Value: aws.String(nodeClass.Name)},
+				{Key: aws.String(v1.WarmPoolTagKey), Value: aws.String(warmPoolHash())},
+			}, tags...),
+		}
+		awsEnv.EC2API.Instances.Store(aws.ToString(i.InstanceId), i)
+		return i
+	}
+	listWarm := func() []*instance.Instance {
+		instances, err := awsEnv.InstanceProvider.ListWarm(ctx)
+		Expect(err).ToNot(HaveOccurred())
+		return instances
+	}
+
+	It("should launch warm instances up to the size of the warm pool", func() {
+		ExpectApplied(ctx, env.Client, nodePool, nodeClass)
+		ExpectSingletonReconciled(ctx, controller)
+
+		Expect(awsEnv.EC2API.CreateFleetBehavior.CalledWithInput.Len()).To(Equal(2))
+		input := awsEnv.EC2API.CreateFleetBehavior.CalledWithInput.Pop()
+		Expect(input.TargetCapacitySpecification.DefaultTargetCapacityType).To(Equal(ec2types.DefaultTargetCapacityTypeOnDemand))
+		for _, ltc := range input.LaunchTemplateConfigs {
+			for _, override := range ltc.Overrides {
+				Expect(nodeClass.Spec.WarmPool.InstanceTypes).To(ContainElement(string(override.InstanceType)))
+			}
+		}
+		tagSpec, ok := lo.Find(input.TagSpecifications, func(ts ec2types.TagSpecification) bool {
+			return ts.ResourceType == ec2types.ResourceTypeInstance
+		})
+		Expect(ok).To(BeTrue())
+		tags := lo.SliceToMap(tagSpec.Tags, func(t ec2types.Tag) (string, string) { return aws.ToString(t.Key), aws.ToString(t.Value) })
+		Expect(tags).To(HaveKeyWithValue(v1.WarmPoolTagKey, warmPoolHash()))
+		Expect(tags).To(HaveKeyWithValue(v1.NodeClassTagKey, nodeClass.Name))
+		Expect(listWarm()).To(HaveLen(2))
+	})
+	It("should only launch the missing warm instances", func() {
+		ExpectApplied(ctx, env.Client, nodePool, nodeClass)
+		warmInstance(ec2types.InstanceStateNameStopped, fakeClock.Now())
+		ExpectSingletonReconciled(ctx, controller)
+
+		Expect(awsEnv.EC2API.CreateFleetBehavior.CalledWithInput.Len()).To(Equal(1))
+		Expect(listWarm()).To(HaveLen(2))
+	})
+	It("should not launch warm instances when the EC2NodeClass isn't ready", func() {
+		nodeClass.StatusConditions().SetFalse(status.ConditionReady, "NotReady", "NotReady")
+		ExpectApplied(ctx, env.Client, nodePool, nodeClass)
+		ExpectSingletonReconciled(ctx, controller)
+
+		Expect(awsEnv.EC2API.CreateFleetBehavior.CalledWithInput.Len()).To(Equal(0))
+	})
+	It("should stop warm instances once their node is ready and delete the node", func() {
+		nodeClass.Spec.WarmPool.Size = 1
+		ExpectApplied(ctx, env.Client, nodePool, nodeClass)
+		i := warmInstance(ec2types.InstanceStateNameRunning, fakeClock.Now())
+		node := coretest.Node(coretest.NodeOptions{
+			ProviderID:  fmt.Sprintf("aws:///test-zone-1a/%s", aws.ToString(i.InstanceId)),
+			ReadyStatus: corev1.ConditionTrue,
+		})
+		ExpectApplied(ctx, env.Client, node)
+		ExpectSingletonReconciled(ctx, controller)
+
+		Expect(awsEnv.EC2API.StopInstancesBehavior.CalledWithInput.Len()).To(Equal(1))
+		Expect(awsEnv.EC2API.StopInstancesBehavior.CalledWithInput.Pop().InstanceIds).To(ConsistOf(aws.ToString(i.InstanceId)))
+		ExpectNotFound(ctx, env.Client, node)
+		Expect(listWarm()[0].State).To(Equal(ec2types.InstanceStateNameStopped))
+	})
+	It("should cordon the node of a warm instance before stopping it", func() {
+		nodeClass.Spec.WarmPool.Size = 1
+		ExpectApplied(ctx, env.Client, nodePool, nodeClass)
+		i := warmInstance(ec2types.InstanceStateNameRunning, fakeClock.Now())
+		node := coretest.Node(coretest.NodeOptions{
+			ProviderID:  fmt.Sprintf("aws:///test-zone-1a/%s", aws.ToString(i.InstanceId)),
+			ReadyStatus: corev1.ConditionTrue,
+		})
+		// The finalizer keeps the node around so that it can be inspected after the instance is stopped
+		node.Finalizers = []string{"test/finalizer"}
+		ExpectApplied(ctx, env.Client, node)
+		ExpectSingletonReconciled(ctx, controller)
+
+		Expect(awsEnv.EC2API.StopInstancesBehavior.CalledWithInput.Len()).To(Equal(1))
+		node = ExpectExists(ctx, env.Client, node)
+		Expect(node.Spec.Unschedulable).To(BeTrue())
+		Expect(node.DeletionTimestamp.IsZero()).To(BeFalse())
+		ExpectFinalizersRemoved(ctx, env.Client, node)
+		ExpectNotFound(ctx, env.Client, node)
+	})
+	It("should not stop warm instances whose node isn't ready", func() {
+		nodeClass.Spec.WarmPool.Size = 1
+		ExpectApplied(ctx, env.Client, nodePool, nodeClass)
+		i := warmInstance(ec2types.InstanceStateNameRunning, fakeClock.Now())
+		node := coretest.Node(coretest.NodeOptions{
+			ProviderID:  fmt.Sprintf("aws:///test-zone-1a/%s", aws.ToString(i.InstanceId)),
+			ReadyStatus: corev1.ConditionFalse,
+		})
+		ExpectApplied(ctx, env.Client, node)
+		ExpectSingletonReconciled(ctx, controller)
+
+		Expect(awsEnv.EC2API.StopInstancesBehavior.CalledWithInput.Len()).To(Equal(0))
+		ExpectExists(ctx, env.Client, node)
+		ExpectDeleted(ctx, env.Client, node)
+	})
+	It("should replace warm instances that fail to initialize", func() {
+		nodeClass.Spec.WarmPool.Size = 1
+		ExpectApplied(ctx, env.Client, nodePool, nodeClass)
+		i := warmInstance(ec2types.InstanceStateNameRunning, fakeClock.Now())
+		ExpectSingletonReconciled(ctx, controller)
+		_, ok := awsEnv.EC2API.Instances.Load(aws.ToString(i.InstanceId))
+		Expect(ok).To(BeTrue())
+
+		fakeClock.Step(warmpool.InitializationTimeout + time.Minute)
+		ExpectSingletonReconciled(ctx, controller)
+		_, ok = awsEnv.EC2API.Instances.Load(aws.ToString(i.InstanceId))
+		Expect(ok).To(BeFalse())
+		Expect(awsEnv.EC2API.CreateFleetBehavior.CalledWithInput.Len()).To(Equal(1))
+	})
+	It("should replace warm instances when the EC2NodeClass drifts", func() {
+		ExpectApplied(ctx, env.Client, nodePool, nodeClass)
+		stale := warmInstance(ec2types.InstanceStateNameStopped, fakeClock.Now())
+		stale.Tags = lo.Map(stale.Tags, func(t ec2types.Tag, _ int) ec2types.Tag {
+			return lo.Ternary(aws.ToString(t.Key) == v1.WarmPoolTagKey, ec2types.Tag{Key: t.Key, Value: aws.String("stale-hash")}, t)
+		})
+		awsEnv.EC2API.Instances.Store(aws.ToString(stale.InstanceId), stale)
+		outdatedAMI := warmInstance(ec2types.InstanceStateNameStopped, fakeClock.Now())
+		outdatedAMI.ImageId = aws.String("ami-outdated")
+		awsEnv.EC2API.Instances.Store(aws.ToString(outdatedAMI.InstanceId), outdatedAMI)
+		ExpectSingletonReconciled(ctx, controller)
+
+		for _, id := range []string{aws.ToString(stale.InstanceId), aws.ToString(outdatedAMI.InstanceId)} {
+			_, ok := awsEnv.EC2API.Instances.Load(id)
+			Expect(ok).To(BeFalse())
+		}
+		Expect(awsEnv.EC2API.CreateFleetBehavior.CalledWithInput.Len()).To(Equal(2))
+	})
+	It("should launch warm instances with the labels and taints of the NodePool", func() {
+		ExpectApplied(ctx, env.Client, nodePool, nodeClass)
+		ExpectSingletonReconciled(ctx, controller)
+
+		Expect(awsEnv.EC2API.CalledWithCreateLaunchTemplateInput.Len()).To(BeNumerically(">=", 1))
+		awsEnv.EC2API.CalledWithCreateLaunchTemplateInput.ForEach(func(input *ec2.CreateLaunchTemplateInput) {
+			userData, err := base64.StdEncoding.DecodeString(aws.ToString(input.LaunchTemplateData.UserData))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(string(userData)).To(ContainSubstring(fmt.Sprintf("%s=%s", karpv1.NodePoolLabelKey, nodePool.Name)))
+			Expect(string(userData)).To(ContainSubstring("team=warm"))
+			Expect(string(userData)).To(ContainSubstring("team=warm:NoSchedule"))
+		})
+	})
+	It("should launch warm instances with the warm pool taint", func() {
+		ExpectApplied(ctx, env.Client, nodePool, nodeClass)
+		ExpectSingletonReconciled(ctx, controller)
+
+		Expect(awsEnv.EC2API.CalledWithCreateLaunchTemplateInput.Len()).To(BeNumerically(">=", 1))
+		awsEnv.EC2API.CalledWithCreateLaunchTemplateInput.ForEach(func(input *ec2.CreateLaunchTemplateInput) {
+			userData, err := base64.StdEncoding.DecodeString(aws.ToString(input.LaunchTemplateData.UserData))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(string(userData)).To(ContainSubstring(v1.WarmPoolNoScheduleTaint.ToString()))
+		})
+		// The warm pool taint isn't part of the hash, so the instances can be claimed by the NodeClaims of the NodePool
+		Expect(listWarm()).To(HaveEach(HaveField("Tags", HaveKeyWithValue(v1.WarmPoolTagKey, warmPoolHash()))))
+	})
+	It("should not launch warm instances when the NodePool doesn't exist", func() {
+		ExpectApplied(ctx, env.Client, nodeClass)
+		i := warmInstance(ec2types.InstanceStateNameStopped, fakeClock.Now())
+		ExpectSingletonReconciled(ctx, controller)
+
+		Expect(awsEnv.EC2API.CreateFleetBehavior.CalledWithInput.Len()).To(Equal(0))
+		_, ok := awsEnv.EC2API.Instances.Load(aws.ToString(i.InstanceId))
+		Expect(ok).To(BeFalse())
+	})
+	It("should replace warm instances when the NodePool template changes", func() {
+		nodeClass.Spec.WarmPool.Size = 1
+		ExpectApplied(ctx, env.Client, nodePool, nodeClass)
+		i := warmInstance(ec2types.InstanceStateNameStopped, fakeClock.Now())
+		nodePool.Spec.Template.Labels["team"] = "cold"
+		ExpectApplied(ctx, env.Client, nodePool)
+		ExpectSingletonReconciled(ctx, controller)
+
+		_, ok := awsEnv.EC2API.Instances.Load(aws.ToString(i.InstanceId))
+		Expect(ok).To(BeFalse())
+		Expect(awsEnv.EC2API.CreateFleetBehavior.CalledWithInput.Len()).To(Equal(1))
+		tagSpec, ok := lo.Find(awsEnv.EC2API.CreateFleetBehavior.CalledWithInput.Pop().TagSpecifications, func(ts ec2types.TagSpecification) bool {
+			return ts.ResourceType == ec2types.ResourceTypeInstance
+		})
+		Expect(ok).To(BeTrue())
+		Expect(tagSpec.Tags).To(ContainElement(ec2types.Tag{Key: aws.String(v1.WarmPoolTagKey), Value: aws.String(warmPoolHash())}))
+	})
+	It("should terminate extra warm instances, keeping the stopped instances", func() {
+		nodeClass.Spec.WarmPool.Size = 1
+		ExpectApplied(ctx, env.Client, nodePool, nodeClass)
+		stopped := warmInstance(ec2types.InstanceStateNameStopped, fakeClock.Now())
+		running := warmInstance(ec2types.InstanceStateNameRunning, fakeClock.Now())
+		ExpectSingletonReconciled(ctx, controller)
+
+		_, ok := awsEnv.EC2API.Instances.Load(aws.ToString(running.InstanceId))
+		Expect(ok).To(BeFalse())
+		_, ok = awsEnv.EC2API.Instances.Load(aws.ToString(stopped.InstanceId))
+		Expect(ok).To(BeTrue())
+	})
+	It("should terminate the warm instances of EC2NodeClasses that no longer exist", func() {
+		i := warmInstance(ec2types.InstanceStateNameStopped, fakeClock.Now())
+		ExpectSingletonReconciled(ctx, controller)
+
+		_, ok := awsEnv.EC2API.Instances.Load(aws.ToString(i.InstanceId))
+		Expect(ok).To(BeFalse())
+	})
+	It("should publish warm pool metrics", func() {
+		ExpectApplied(ctx, env.Client, nodePool, nodeClass)
+		warmInstance(ec2types.InstanceStateNameStopped, fakeClock.Now())
+		warmInstance(ec2types.InstanceStateNameRunning, fakeClock.Now())
+		ExpectSingletonReconciled(ctx, controller)
+
+		ExpectMetricGaugeValue(warmpool.Instances, 1, map[string]string{"ec2nodeclass": nodeClass.Name, "instance_type": "m5.large", "state": "stopped"})
+		ExpectMetricGaugeValue(warmpool.Instances, 1, map[string]string{"ec2nodeclass": nodeClass.Name, "instance_type": "m5.large", "state": "running"})
+		price, ok := awsEnv.PricingProvider.OnDemandPrice(pricing.OperatingSystemLinux, "m5.large")
+		Expect(ok).To(BeTrue())
+		ExpectMetricGaugeValue(warmpool.EstimatedHourlyCost, price, map[string]string{"ec2nodeclass": nodeClass.Name})
+	})
+})
+
+var _ = Describe("WarmPool Node", func() {
+	It("should remove the warm pool taint once the node is registered for a NodeClaim", func() {
+		node := coretest.Node(coretest.NodeOptions{
+			ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{karpv1.NodeRegisteredLabelKey: "true"}},
+			Taints:     []corev1.Taint{v1.WarmPoolNoScheduleTaint, {Key: "team", Value: "warm", Effect: corev1.TaintEffectNoSchedule}},
+		})
+		ExpectApplied(ctx, env.Client, node)
+		ExpectObjectReconciled(ctx, env.Client, nodeController, node)
+
+		node = ExpectExists(ctx, env.Client, node)
+		Expect(node.Spec.Taints).ToNot(ContainElement(v1.WarmPoolNoScheduleTaint))
+		Expect(node.Spec.Taints).To(ContainElement(corev1.Taint{Key: "team", Value: "warm", Effect: corev1.TaintEffectNoSchedule}))
+	})
+	It("should keep the warm pool taint on nodes that haven't been claimed", func() {
+		node := coretest.Node(coretest.NodeOptions{
+			Taints: []corev1.Taint{v1.WarmPoolNoScheduleTaint},
+		})
+		ExpectApplied(ctx, env.Client, node)
+		ExpectObjectReconciled(ctx, env.Client, nodeController, node)
+
+		node = ExpectExists(ctx, env.Client, node)
+		Expect(node.Spec.Taints).To(ContainElement(v1.WarmPoolNoScheduleTaint))
+	})
+})
@@ -0,0 +1,68 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package checkpoint
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/awslabs/operatorpkg/singleton"
+	controllerruntime "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/karpenter/pkg/operator/injection"
+
+	"github.com/aws/karpenter-provider-aws/pkg/providers/checkpoint"
+)
+
+type Controller struct {
+	checkpointProvider checkpoint.Provider
+	// restored is true once the caches have been restored from the checkpoint
+	restored bool
+}
+
+func NewController(checkpointProvider checkpoint.Provider) *Controller {
+	return &Controller{
+		checkpointProvider: checkpointProvider,
+	}
+}
+
+func (c *Controller) Reconcile(ctx context.Context) (reconcile.Result, error) {
+	ctx = injection.WithControllerName(ctx, "providers.checkpoint")
+
+	// The caches are restored once this replica is elected leader, rather than when it starts, so that a replica that
+	// was a standby for a while doesn't overwrite the checkpoint with the contents that its caches were restored with
+	// at startup
+	if !c.restored {
+		if err := c.checkpointProvider.Restore(ctx); err != nil {
+			return reconcile.Result{}, fmt.Errorf("restoring caches, %w", err)
+		}
+		c.restored = true
+	}
+
+	if err := c.checkpointProvider.Checkpoint(ctx); err != nil {
+		return reconcile.Result{}, fmt.Errorf("checkpointing caches, %w", err)
+	}
+	return reconcile.Result{RequeueAfter: time.Minute}, nil
+}
+
+func (c *Controller) Register(_ context.Context, m manager.Manager) error {
+	// Includes a default exponential failure rate limiter of base: time.Millisecond, and max: 1000*time.Second
+	return controllerruntime.NewControllerManagedBy(m).
+		Named("providers.checkpoint").
+		WatchesRawSource(singleton.Source()).
+		Complete(singleton.AsReconciler(c))
+}
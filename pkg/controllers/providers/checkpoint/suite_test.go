@@ -0,0 +1,241 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package checkpoint_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/samber/lo"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	karpv1 "sigs.k8s.io/karpenter/pkg/apis/v1"
+	coreoptions "sigs.k8s.io/karpenter/pkg/operator/options"
+	coretest "sigs.k8s.io/karpenter/pkg/test"
+	"sigs.k8s.io/karpenter/pkg/test/v1alpha1"
+
+	"github.com/aws/karpenter-provider-aws/pkg/apis"
+	awscache "github.com/aws/karpenter-provider-aws/pkg/cache"
+	controllerscheckpoint "github.com/aws/karpenter-provider-aws/pkg/controllers/providers/checkpoint"
+	"github.com/aws/karpenter-provider-aws/pkg/operator/options"
+	"github.com/aws/karpenter-provider-aws/pkg/providers/checkpoint"
+	"github.com/aws/karpenter-provider-aws/pkg/test"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	. "sigs.k8s.io/karpenter/pkg/test/expectations"
+	. "sigs.k8s.io/karpenter/pkg/utils/testing"
+)
+
+const (
+	configMapNamespace = "default"
+	configMapName      = "karpenter-cache-checkpoint"
+)
+
+var ctx context.Context
+var stop context.CancelFunc
+var env *coretest.Environment
+var awsEnv *test.Environment
+var checkpointProvider *checkpoint.DefaultProvider
+var controller *controllerscheckpoint.Controller
+
+func TestAWS(t *testing.T) {
+	ctx = TestContextWithLogger(t)
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Checkpoint")
+}
+
+var _ = BeforeSuite(func() {
+	env = coretest.NewEnvironment(coretest.WithCRDs(apis.CRDs...), coretest.WithCRDs(v1alpha1.CRDs...))
+	ctx = coreoptions.ToContext(ctx, coretest.Options())
+	ctx = options.ToContext(ctx, test.Options(test.OptionsFields{CacheCheckpointConfigMap: lo.ToPtr(configMapName)}))
+	ctx, stop = context.WithCancel(ctx)
+	awsEnv = test.NewEnvironment(ctx, env)
+	checkpointProvider = checkpoint.NewDefaultProvider(env.Client, configMapNamespace, configMapName, map[string]awscache.Checkpointable{
+		checkpoint.DiscoveredCapacityKey:   awsEnv.InstanceTypesProvider,
+		checkpoint.UnavailableOfferingsKey: awsEnv.UnavailableOfferingsCache,
+	})
+	controller = controllerscheckpoint.NewController(checkpointProvider)
+})
+
+var _ = AfterSuite(func() {
+	stop()
+	Expect(env.Stop()).To(Succeed(), "Failed to stop environment")
+})
+
+var _ = BeforeEach(func() {
+	awsEnv.Reset()
+})
+
+var _ = AfterEach(func() {
+	Expect(client.IgnoreNotFound(env.Client.Delete(ctx, &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Namespace: configMapNamespace, Name: configMapName}}))).To(Succeed())
+	ExpectCleanedUp(ctx, env.Client)
+})
+
+var _ = Describe("Checkpoint", func() {
+	var capacityKey string
+	BeforeEach(func() {
+		capacityKey = fmt.Sprintf("%s-%016x", "m5.large", 1234)
+	})
+	It("should create the ConfigMap with the contents of each cache", func() {
+		awsEnv.UnavailableOfferingsCache.MarkUnavailable(ctx, "test", "m5.large", "test-zone-1a", karpv1.CapacityTypeSpot)
+		awsEnv.DiscoveredCapacityCache.SetDefault(capacityKey, resource.MustParse("7Gi"))
+		ExpectSingletonReconciled(ctx, controller)
+
+		cm := &corev1.ConfigMap{}
+		Expect(env.Client.Get(ctx, client.ObjectKey{Namespace: configMapNamespace, Name: configMapName}, cm)).To(Succeed())
+		Expect(cm.Data).To(HaveKey(checkpoint.UnavailableOfferingsKey))
+		Expect(cm.Data).To(HaveKey(checkpoint.DiscoveredCapacityKey))
+		Expect(cm.Data[checkpoint.UnavailableOfferingsKey]).To(ContainSubstring("spot:m5.large:test-zone-1a"))
+		Expect(cm.Data[checkpoint.DiscoveredCapacityKey]).To(ContainSubstring(capacityKey))
+	})
+	It("should update an existing ConfigMap", func() {
+		awsEnv.UnavailableOfferingsCache.MarkUnavailable(ctx, "test", "m5.large", "test-zone-1a", karpv1.CapacityTypeSpot)
+		ExpectSingletonReconciled(ctx, controller)
+		awsEnv.UnavailableOfferingsCache.MarkUnavailable(ctx, "test", "m5.xlarge", "test-zone-1b", karpv1.CapacityTypeOnDemand)
+		ExpectSingletonReconciled(ctx, controller)
+
+		awsEnv.UnavailableOfferingsCache.Flush()
+		Expect(checkpointProvider.Restore(ctx)).To(Succeed())
+		Expect(awsEnv.UnavailableOfferingsCache.IsUnavailable("m5.large", "test-zone-1a", karpv1.CapacityTypeSpot)).To(BeTrue())
+		Expect(awsEnv.UnavailableOfferingsCache.IsUnavailable("m5.xlarge", "test-zone-1b", karpv1.CapacityTypeOnDemand)).To(BeTrue())
+	})
+	It("should restore the caches from the ConfigMap", func() {
+		awsEnv.UnavailableOfferingsCache.MarkUnavailable(ctx, "test", "m5.large", "test-zone-1a", karpv1.CapacityTypeSpot)
+		awsEnv.DiscoveredCapacityCache.SetDefault(capacityKey, resource.MustParse("7Gi"))
+		ExpectSingletonReconciled(ctx, controller)
+
+		awsEnv.UnavailableOfferingsCache.Flush()
+		awsEnv.DiscoveredCapacityCache.Flush()
+		seqNum := awsEnv.UnavailableOfferingsCache.SeqNum
+		Expect(checkpointProvider.Restore(ctx)).To(Succeed())
+
+		Expect(awsEnv.UnavailableOfferingsCache.IsUnavailable("m5.large", "test-zone-1a", karpv1.CapacityTypeSpot)).To(BeTrue())
+		Expect(awsEnv.UnavailableOfferingsCache.SeqNum).To(BeNumerically(">", seqNum))
+		capacity, ok := awsEnv.DiscoveredCapacityCache.Get(capacityKey)
+		Expect(ok).To(BeTrue())
+		Expect(lo.ToPtr(capacity.(resource.Quantity)).Value()).To(Equal(lo.ToPtr(resource.MustParse("7Gi")).Value()))
+	})
+	It("should restore items with the expiration they were checkpointed with", func() {
+		awsEnv.DiscoveredCapacityCache.Set(capacityKey, resource.MustParse("7Gi"), time.Hour)
+		_, expiration, _ := awsEnv.DiscoveredCapacityCache.GetWithExpiration(capacityKey)
+		ExpectSingletonReconciled(ctx, controller)
+
+		awsEnv.DiscoveredCapacityCache.Flush()
+		Expect(checkpointProvider.Restore(ctx)).To(Succeed())
+		_, restoredExpiration, ok := awsEnv.DiscoveredCapacityCache.GetWithExpiration(capacityKey)
+		Expect(ok).To(BeTrue())
+		Expect(restoredExpiration).To(BeTemporally("~", expiration, time.Second))
+	})
+	It("should not restore items that expired before they were restored", func() {
+		data := lo.Must(json.Marshal(map[string]any{
+			"offerings": map[string]any{
+				"spot:m5.large:test-zone-1a": map[string]any{"object": struct{}{}, "expiration": time.Now().Add(-time.Minute).UnixNano()},
+				"spot:m5.large:test-zone-1b": map[string]any{"object": struct{}{}, "expiration": time.Now().Add(time.Minute).UnixNano()},
+			},
+		}))
+		ExpectApplied(ctx, env.Client, &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Namespace: configMapNamespace, Name: configMapName},
+			Data:       map[string]string{checkpoint.UnavailableOfferingsKey: string(data)},
+		})
+		Expect(checkpointProvider.Restore(ctx)).To(Succeed())
+		Expect(awsEnv.UnavailableOfferingsCache.IsUnavailable("m5.large", "test-zone-1a", karpv1.CapacityTypeSpot)).To(BeFalse())
+		Expect(awsEnv.UnavailableOfferingsCache.IsUnavailable("m5.large", "test-zone-1b", karpv1.CapacityTypeSpot)).To(BeTrue())
+	})
+	It("should restore the backoff of unavailable offerings", func() {
+		labels := map[string]string{"instance_type": "m5.large", "zone": "test-zone-1a", "capacity_type": karpv1.CapacityTypeSpot, "placement_group_id": ""}
+		awsEnv.UnavailableOfferingsCache.MarkUnavailable(ctx, "InsufficientInstanceCapacity", "m5.large", "test-zone-1a", karpv1.CapacityTypeSpot)
+		awsEnv.UnavailableOfferingsCache.Delete("m5.large", "test-zone-1a", karpv1.CapacityTypeSpot)
+		awsEnv.UnavailableOfferingsCache.MarkUnavailable(ctx, "InsufficientInstanceCapacity", "m5.large", "test-zone-1a", karpv1.CapacityTypeSpot)
+		ExpectMetricGaugeValue(awscache.UnavailableOfferingBackoffSeconds, (6 * time.Minute).Seconds(), labels)
+		ExpectSingletonReconciled(ctx, controller)
+
+		awsEnv.UnavailableOfferingsCache.Flush()
+		Expect(checkpointProvider.Restore(ctx)).To(Succeed())
+		Expect(awsEnv.UnavailableOfferingsCache.IsUnavailable("m5.large", "test-zone-1a", karpv1.CapacityTypeSpot)).To(BeTrue())
+
+		// The offering fails to launch a third time once it becomes available again
+		awsEnv.UnavailableOfferingsCache.Delete("m5.large", "test-zone-1a", karpv1.CapacityTypeSpot)
+		awsEnv.UnavailableOfferingsCache.MarkUnavailable(ctx, "InsufficientInstanceCapacity", "m5.large", "test-zone-1a", karpv1.CapacityTypeSpot)
+		ExpectMetricGaugeValue(awscache.UnavailableOfferingBackoffSeconds, (12 * time.Minute).Seconds(), labels)
+	})
+	It("should not fail to restore when the ConfigMap doesn't exist", func() {
+		Expect(checkpointProvider.Restore(ctx)).To(Succeed())
+		Expect(awsEnv.UnavailableOfferingsCache.IsUnavailable(ec2types.InstanceTypeM5Large, "test-zone-1a", karpv1.CapacityTypeSpot)).To(BeFalse())
+	})
+	It("should skip a malformed checkpoint", func() {
+		awsEnv.DiscoveredCapacityCache.SetDefault(capacityKey, resource.MustParse("7Gi"))
+		data := lo.Must(awsEnv.InstanceTypesProvider.Checkpoint())
+		awsEnv.DiscoveredCapacityCache.Flush()
+		ExpectApplied(ctx, env.Client, &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Namespace: configMapNamespace, Name: configMapName},
+			Data: map[string]string{
+				checkpoint.UnavailableOfferingsKey: "not-json",
+				checkpoint.DiscoveredCapacityKey:   string(data),
+			},
+		})
+		Expect(checkpointProvider.Restore(ctx)).To(Succeed())
+		_, ok := awsEnv.DiscoveredCapacityCache.Get(capacityKey)
+		Expect(ok).To(BeTrue())
+	})
+	It("should restore the caches on the first reconcile before checkpointing them", func() {
+		data := lo.Must(json.Marshal(map[string]any{
+			"offerings": map[string]any{
+				"spot:m5.large:test-zone-1a": map[string]any{"object": struct{}{}, "expiration": time.Now().Add(time.Minute).UnixNano()},
+			},
+		}))
+		ExpectApplied(ctx, env.Client, &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Namespace: configMapNamespace, Name: configMapName},
+			Data:       map[string]string{checkpoint.UnavailableOfferingsKey: string(data)},
+		})
+		c := controllerscheckpoint.NewController(checkpoint.NewDefaultProvider(env.Client, configMapNamespace, configMapName, map[string]awscache.Checkpointable{
+			checkpoint.DiscoveredCapacityKey:   awsEnv.InstanceTypesProvider,
+			checkpoint.UnavailableOfferingsKey: awsEnv.UnavailableOfferingsCache,
+		}))
+		ExpectSingletonReconciled(ctx, c)
+		Expect(awsEnv.UnavailableOfferingsCache.IsUnavailable("m5.large", "test-zone-1a", karpv1.CapacityTypeSpot)).To(BeTrue())
+
+		cm := &corev1.ConfigMap{}
+		Expect(env.Client.Get(ctx, client.ObjectKey{Namespace: configMapNamespace, Name: configMapName}, cm)).To(Succeed())
+		Expect(cm.Data[checkpoint.UnavailableOfferingsKey]).To(ContainSubstring("spot:m5.large:test-zone-1a"))
+
+		// The caches are only restored once, so that they aren't reverted to the contents of the checkpoint
+		awsEnv.UnavailableOfferingsCache.Delete("m5.large", "test-zone-1a", karpv1.CapacityTypeSpot)
+		ExpectSingletonReconciled(ctx, c)
+		Expect(awsEnv.UnavailableOfferingsCache.IsUnavailable("m5.large", "test-zone-1a", karpv1.CapacityTypeSpot)).To(BeFalse())
+	})
+	It("should not write the checkpoint when the caches haven't changed", func() {
+		awsEnv.UnavailableOfferingsCache.MarkUnavailable(ctx, "test", "m5.large", "test-zone-1a", karpv1.CapacityTypeSpot)
+		ExpectSingletonReconciled(ctx, controller)
+		cm := &corev1.ConfigMap{}
+		Expect(env.Client.Get(ctx, client.ObjectKey{Namespace: configMapNamespace, Name: configMapName}, cm)).To(Succeed())
+		resourceVersion := cm.ResourceVersion
+
+		ExpectSingletonReconciled(ctx, controller)
+		Expect(env.Client.Get(ctx, client.ObjectKey{Namespace: configMapNamespace, Name: configMapName}, cm)).To(Succeed())
+		Expect(cm.ResourceVersion).To(Equal(resourceVersion))
+
+		awsEnv.UnavailableOfferingsCache.MarkUnavailable(ctx, "test", "m5.xlarge", "test-zone-1a", karpv1.CapacityTypeSpot)
+		ExpectSingletonReconciled(ctx, controller)
+		Expect(env.Client.Get(ctx, client.ObjectKey{Namespace: configMapNamespace, Name: configMapName}, cm)).To(Succeed())
+		Expect(cm.ResourceVersion).ToNot(Equal(resourceVersion))
+	})
+})
@@ -20,23 +20,38 @@ import (
 	"time"
 
 	"github.com/awslabs/operatorpkg/singleton"
+	"github.com/samber/lo"
 	lop "github.com/samber/lo/parallel"
 	"go.uber.org/multierr"
+	"k8s.io/utils/clock"
 	controllerruntime "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/manager"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 	"sigs.k8s.io/karpenter/pkg/operator/injection"
 
+	"github.com/aws/karpenter-provider-aws/pkg/operator/options"
 	"github.com/aws/karpenter-provider-aws/pkg/providers/pricing"
 )
 
+// pricingRefreshInterval is how often prices are retrieved. On-demand prices are only retrieved at this interval, since
+// they rarely change.
+const pricingRefreshInterval = 12 * time.Hour
+
+// spotPricingRefreshesPerWindow is how many times aggregated spot prices are retrieved within the spot price window, so
+// that the spot price history that they're aggregated from is kept current
+const spotPricingRefreshesPerWindow = 6
+
 type Controller struct {
 	pricingProvider pricing.Provider
+	clk             clock.Clock
+	// onDemandPricingUpdated is when on-demand prices were last retrieved
+	onDemandPricingUpdated time.Time
 }
 
-func NewController(pricingProvider pricing.Provider) *Controller {
+func NewController(pricingProvider pricing.Provider, clk clock.Clock) *Controller {
 	return &Controller{
 		pricingProvider: pricingProvider,
+		clk:             clk,
 	}
 }
 
@@ -45,7 +60,10 @@ func (c *Controller) Reconcile(ctx context.Context) (reconcile.Result, error) {
 
 	work := []func(ctx context.Context) error{
 		c.pricingProvider.UpdateSpotPricing,
-		c.pricingProvider.UpdateOnDemandPricing,
+	}
+	updateOnDemandPricing := c.onDemandPricingUpdated.IsZero() || c.clk.Since(c.onDemandPricingUpdated) >= pricingRefreshInterval
+	if updateOnDemandPricing {
+		work = append(work, c.pricingProvider.UpdateOnDemandPricing)
 	}
 	errs := make([]error, len(work))
 	lop.ForEach(work, func(f func(ctx context.Context) error, i int) {
@@ -53,10 +71,18 @@ func (c *Controller) Reconcile(ctx context.Context) (reconcile.Result, error) {
 			errs[i] = err
 		}
 	})
+	if updateOnDemandPricing && errs[len(errs)-1] == nil {
+		c.onDemandPricingUpdated = c.clk.Now()
+	}
 	if err := multierr.Combine(errs...); err != nil {
 		return reconcile.Result{}, fmt.Errorf("updating pricing, %w", err)
 	}
-	return reconcile.Result{RequeueAfter: 12 * time.Hour}, nil
+	// Aggregated spot prices are refreshed more often than on-demand prices so that they reflect the spot price history
+	// throughout the spot price window
+	if options.FromContext(ctx).SpotPriceAggregation == pricing.SpotPriceAggregationLatest {
+		return reconcile.Result{RequeueAfter: pricingRefreshInterval}, nil
+	}
+	return reconcile.Result{RequeueAfter: lo.Min([]time.Duration{pricingRefreshInterval, options.FromContext(ctx).SpotPriceWindow / spotPricingRefreshesPerWindow})}, nil
 }
 
 func (c *Controller) Register(_ context.Context, m manager.Manager) error {
@@ -0,0 +1,188 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package overrides_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/samber/lo"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	coreoptions "sigs.k8s.io/karpenter/pkg/operator/options"
+	coretest "sigs.k8s.io/karpenter/pkg/test"
+	"sigs.k8s.io/karpenter/pkg/test/v1alpha1"
+
+	"github.com/aws/karpenter-provider-aws/pkg/apis"
+	controllerspricingoverrides "github.com/aws/karpenter-provider-aws/pkg/controllers/providers/pricing/overrides"
+	"github.com/aws/karpenter-provider-aws/pkg/operator/options"
+	"github.com/aws/karpenter-provider-aws/pkg/providers/pricing"
+	"github.com/aws/karpenter-provider-aws/pkg/test"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	. "sigs.k8s.io/karpenter/pkg/test/expectations"
+	. "sigs.k8s.io/karpenter/pkg/utils/testing"
+)
+
+const (
+	configMapNamespace = "default"
+	configMapName      = "karpenter-pricing-overrides"
+)
+
+var ctx context.Context
+var stop context.CancelFunc
+var env *coretest.Environment
+var awsEnv *test.Environment
+var controller *controllerspricingoverrides.Controller
+
+func TestAWS(t *testing.T) {
+	ctx = TestContextWithLogger(t)
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "PricingOverrides")
+}
+
+var _ = BeforeSuite(func() {
+	env = coretest.NewEnvironment(coretest.WithCRDs(apis.CRDs...), coretest.WithCRDs(v1alpha1.CRDs...))
+	ctx = coreoptions.ToContext(ctx, coretest.Options())
+	ctx = options.ToContext(ctx, test.Options(test.OptionsFields{PricingOverridesConfigMap: lo.ToPtr(configMapName)}))
+	ctx, stop = context.WithCancel(ctx)
+	awsEnv = test.NewEnvironment(ctx, env)
+	controller = controllerspricingoverrides.NewController(pricing.NewConfigMapOverridesProvider(env.Client, configMapNamespace, configMapName), awsEnv.PricingProvider)
+})
+
+var _ = AfterSuite(func() {
+	stop()
+	Expect(env.Stop()).To(Succeed(), "Failed to stop environment")
+})
+
+var _ = BeforeEach(func() {
+	awsEnv.Reset()
+})
+
+var _ = AfterEach(func() {
+	Expect(client.IgnoreNotFound(env.Client.Delete(ctx, &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Namespace: configMapNamespace, Name: configMapName}}))).To(Succeed())
+	ExpectCleanedUp(ctx, env.Client)
+})
+
+func applyOverrides(overrides string) {
+	ExpectApplied(ctx, env.Client, &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Namespace: configMapNamespace, Name: configMapName},
+		Data:       map[string]string{pricing.OverridesKey: overrides},
+	})
+}
+
+func expectPrices(onDemand, spot float64) {
+	GinkgoHelper()
+	price, ok := awsEnv.PricingProvider.OnDemandPrice(pricing.OperatingSystemLinux, "m5.large")
+	Expect(ok).To(BeTrue())
+	Expect(price).To(BeNumerically("~", onDemand))
+	price, ok = awsEnv.PricingProvider.SpotPrice(pricing.OperatingSystemLinux, "m5.large", "test-zone-1a")
+	Expect(ok).To(BeTrue())
+	Expect(price).To(BeNumerically("~", spot))
+}
+
+var _ = Describe("PricingOverrides", func() {
+	var rawOnDemand, rawSpot float64
+	BeforeEach(func() {
+		var ok bool
+		rawOnDemand, ok = awsEnv.PricingProvider.RawZonalOnDemandPrice(pricing.OperatingSystemLinux, "m5.large", "test-zone-1a")
+		Expect(ok).To(BeTrue())
+		rawSpot, ok = awsEnv.PricingProvider.RawSpotPrice(pricing.OperatingSystemLinux, "m5.large", "test-zone-1a")
+		Expect(ok).To(BeTrue())
+	})
+	It("should use public prices when the ConfigMap doesn't exist", func() {
+		ExpectSingletonReconciled(ctx, controller)
+		expectPrices(rawOnDemand, rawSpot)
+	})
+	It("should apply a global multiplier to all prices", func() {
+		applyOverrides(`- multiplier: 0.5`)
+		ExpectSingletonReconciled(ctx, controller)
+		expectPrices(rawOnDemand*0.5, rawSpot*0.5)
+	})
+	It("should prefer an instance type override over an instance family override", func() {
+		applyOverrides(`
+- instanceFamily: m5
+  multiplier: 0.5
+- instanceType: m5.large
+  price: 0.01
+- instanceType: m5.xlarge
+  price: 0.02
+`)
+		ExpectSingletonReconciled(ctx, controller)
+		expectPrices(0.01, 0.01)
+		price, ok := awsEnv.PricingProvider.OnDemandPrice(pricing.OperatingSystemLinux, "m5.2xlarge")
+		Expect(ok).To(BeTrue())
+		raw, ok := awsEnv.PricingProvider.RawZonalOnDemandPrice(pricing.OperatingSystemLinux, "m5.2xlarge", "test-zone-1a")
+		Expect(ok).To(BeTrue())
+		Expect(price).To(BeNumerically("~", raw*0.5))
+	})
+	It("should only apply an override to its capacity type", func() {
+		applyOverrides(`
+- capacityType: on-demand
+  multiplier: 0.7
+`)
+		ExpectSingletonReconciled(ctx, controller)
+		expectPrices(rawOnDemand*0.7, rawSpot)
+	})
+	It("should prefer an override for the capacity type over a broader override", func() {
+		applyOverrides(`
+- capacityType: spot
+  multiplier: 0.9
+- multiplier: 0.5
+`)
+		ExpectSingletonReconciled(ctx, controller)
+		expectPrices(rawOnDemand*0.5, rawSpot*0.9)
+	})
+	It("should only apply an override in its region", func() {
+		applyOverrides(`
+- region: eu-west-1
+  multiplier: 0.5
+- region: us-west-2
+  multiplier: 0.8
+`)
+		ExpectSingletonReconciled(ctx, controller)
+		expectPrices(rawOnDemand*0.8, rawSpot*0.8)
+	})
+	It("should keep the previous overrides when the overrides are invalid", func() {
+		applyOverrides(`- multiplier: 0.5`)
+		ExpectSingletonReconciled(ctx, controller)
+		for _, invalid := range []string{
+			`- multiplier: 0.5
+  price: 0.1`,
+			`- instanceType: m5.large
+  instanceFamily: m5
+  multiplier: 0.5`,
+			`- capacityType: reserved
+  multiplier: 0.5`,
+			`- multiplier: -1`,
+			`- multiplier: 0.5
+  unknown: true`,
+		} {
+			applyOverrides(invalid)
+			_ = ExpectSingletonReconcileFailed(ctx, controller)
+			expectPrices(rawOnDemand*0.5, rawSpot*0.5)
+		}
+	})
+	It("should remove the overrides when the ConfigMap is deleted", func() {
+		applyOverrides(`- multiplier: 0.5`)
+		ExpectSingletonReconciled(ctx, controller)
+		expectPrices(rawOnDemand*0.5, rawSpot*0.5)
+		Expect(env.Client.Delete(ctx, &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Namespace: configMapNamespace, Name: configMapName}})).To(Succeed())
+		ExpectSingletonReconciled(ctx, controller)
+		expectPrices(rawOnDemand, rawSpot)
+	})
+})
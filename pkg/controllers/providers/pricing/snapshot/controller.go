@@ -0,0 +1,64 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package snapshot
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/awslabs/operatorpkg/singleton"
+	controllerruntime "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/karpenter/pkg/operator/injection"
+
+	"github.com/aws/karpenter-provider-aws/pkg/providers/pricing"
+)
+
+// Controller periodically reads the pricing snapshot and applies it to the pricing provider, so that an updated
+// snapshot is picked up without restarting. Snapshots that fail to parse or validate are rejected, and the previously
+// applied prices remain in effect.
+type Controller struct {
+	snapshotProvider pricing.SnapshotProvider
+	pricingProvider  pricing.Provider
+}
+
+func NewController(snapshotProvider pricing.SnapshotProvider, pricingProvider pricing.Provider) *Controller {
+	return &Controller{
+		snapshotProvider: snapshotProvider,
+		pricingProvider:  pricingProvider,
+	}
+}
+
+func (c *Controller) Reconcile(ctx context.Context) (reconcile.Result, error) {
+	ctx = injection.WithControllerName(ctx, "providers.pricing.snapshot")
+
+	snapshot, err := c.snapshotProvider.Snapshot(ctx)
+	if err != nil {
+		return reconcile.Result{}, fmt.Errorf("getting pricing snapshot, %w", err)
+	}
+	if err := c.pricingProvider.SetSnapshot(ctx, snapshot); err != nil {
+		return reconcile.Result{}, fmt.Errorf("applying pricing snapshot, %w", err)
+	}
+	return reconcile.Result{RequeueAfter: time.Minute}, nil
+}
+
+func (c *Controller) Register(_ context.Context, m manager.Manager) error {
+	return controllerruntime.NewControllerManagedBy(m).
+		Named("providers.pricing.snapshot").
+		WatchesRawSource(singleton.Source()).
+		Complete(singleton.AsReconciler(c))
+}
@@ -0,0 +1,270 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package snapshot_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	awspricing "github.com/aws/aws-sdk-go-v2/service/pricing"
+	"github.com/samber/lo"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	coreoptions "sigs.k8s.io/karpenter/pkg/operator/options"
+	coretest "sigs.k8s.io/karpenter/pkg/test"
+	"sigs.k8s.io/karpenter/pkg/test/v1alpha1"
+
+	"github.com/aws/karpenter-provider-aws/pkg/apis"
+	controllerspricingsnapshot "github.com/aws/karpenter-provider-aws/pkg/controllers/providers/pricing/snapshot"
+	"github.com/aws/karpenter-provider-aws/pkg/fake"
+	"github.com/aws/karpenter-provider-aws/pkg/operator/options"
+	"github.com/aws/karpenter-provider-aws/pkg/providers/pricing"
+	"github.com/aws/karpenter-provider-aws/pkg/test"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	. "sigs.k8s.io/karpenter/pkg/test/expectations"
+	. "sigs.k8s.io/karpenter/pkg/utils/testing"
+)
+
+const (
+	configMapNamespace = "default"
+	configMapName      = "karpenter-pricing-snapshot"
+)
+
+var ctx context.Context
+var stop context.CancelFunc
+var env *coretest.Environment
+var awsEnv *test.Environment
+var controller *controllerspricingsnapshot.Controller
+
+func TestAWS(t *testing.T) {
+	ctx = TestContextWithLogger(t)
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "PricingSnapshot")
+}
+
+var _ = BeforeSuite(func() {
+	env = coretest.NewEnvironment(coretest.WithCRDs(apis.CRDs...), coretest.WithCRDs(v1alpha1.CRDs...))
+	ctx = coreoptions.ToContext(ctx, coretest.Options())
+	ctx = options.ToContext(ctx, test.Options(test.OptionsFields{PricingSnapshotConfigMap: lo.ToPtr(configMapName)}))
+	ctx, stop = context.WithCancel(ctx)
+	awsEnv = test.NewEnvironment(ctx, env)
+	controller = controllerspricingsnapshot.NewController(pricing.NewConfigMapSnapshotProvider(env.Client, configMapNamespace, configMapName), awsEnv.PricingProvider)
+})
+
+var _ = AfterSuite(func() {
+	stop()
+	Expect(env.Stop()).To(Succeed(), "Failed to stop environment")
+})
+
+var _ = BeforeEach(func() {
+	awsEnv.Reset()
+})
+
+var _ = AfterEach(func() {
+	Expect(client.IgnoreNotFound(env.Client.Delete(ctx, &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Namespace: configMapNamespace, Name: configMapName}}))).To(Succeed())
+	ExpectCleanedUp(ctx, env.Client)
+})
+
+func applySnapshot(snapshot string) {
+	ExpectApplied(ctx, env.Client, &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Namespace: configMapNamespace, Name: configMapName},
+		Data:       map[string]string{pricing.SnapshotKey: snapshot},
+	})
+}
+
+func expectPrices(onDemand, spot float64) {
+	GinkgoHelper()
+	price, ok := awsEnv.PricingProvider.OnDemandPrice(pricing.OperatingSystemLinux, "m5.large")
+	Expect(ok).To(BeTrue())
+	Expect(price).To(BeNumerically("==", onDemand))
+	price, ok = awsEnv.PricingProvider.SpotPrice(pricing.OperatingSystemLinux, "m5.large", "test-zone-1a")
+	Expect(ok).To(BeTrue())
+	Expect(price).To(BeNumerically("==", spot))
+}
+
+var _ = Describe("PricingSnapshot", func() {
+	var staticPrice float64
+	BeforeEach(func() {
+		var ok bool
+		staticPrice, ok = awsEnv.PricingProvider.OnDemandPrice(pricing.OperatingSystemLinux, "m5.large")
+		Expect(ok).To(BeTrue())
+	})
+	It("should use the static prices when the ConfigMap doesn't exist", func() {
+		ExpectSingletonReconciled(ctx, controller)
+		expectPrices(staticPrice, staticPrice)
+	})
+	It("should replace the static prices with the prices of the region in the snapshot", func() {
+		applySnapshot(`{"us-west-2": {"onDemand": {"Linux": {"m5.large": 0.5, "m99.large": 1.5}}}, "us-east-1": {"onDemand": {"Linux": {"m5.large": 0.25}}}}`)
+		ExpectSingletonReconciled(ctx, controller)
+		expectPrices(0.5, 0.5)
+		price, ok := awsEnv.PricingProvider.OnDemandPrice(pricing.OperatingSystemLinux, "m99.large")
+		Expect(ok).To(BeTrue())
+		Expect(price).To(BeNumerically("==", 1.5))
+		_, ok = awsEnv.PricingProvider.OnDemandPrice(pricing.OperatingSystemLinux, "m5.xlarge")
+		Expect(ok).To(BeFalse())
+		Expect(awsEnv.PricingProvider.Snapshot()).To(HaveKey("us-west-2"))
+		Expect(awsEnv.PricingProvider.Snapshot()["us-west-2"].OnDemand).To(Equal(map[pricing.OperatingSystem]map[ec2types.InstanceType]float64{
+			pricing.OperatingSystemLinux: {"m5.large": 0.5, "m99.large": 1.5},
+		}))
+		Expect(awsEnv.PricingProvider.Snapshot()["us-west-2"].Spot).To(BeEmpty())
+	})
+	It("should use the spot prices and the prices of other operating systems in the snapshot", func() {
+		applySnapshot(`{"us-west-2": {
+			"onDemand": {"Linux": {"m5.large": 0.5, "m5.xlarge": 1.0}, "Windows": {"m5.large": 0.9}},
+			"spot": {"Linux": {"m5.large": {"test-zone-1a": 0.2}}, "Windows": {"m5.large": {"test-zone-1a": 0.6}}}
+		}}`)
+		ExpectSingletonReconciled(ctx, controller)
+		expectPrices(0.5, 0.2)
+		// zones without a spot price in the snapshot aren't priced
+		_, ok := awsEnv.PricingProvider.SpotPrice(pricing.OperatingSystemLinux, "m5.large", "test-zone-1b")
+		Expect(ok).To(BeFalse())
+		// instance types without spot prices in the snapshot default to their on-demand price
+		price, ok := awsEnv.PricingProvider.SpotPrice(pricing.OperatingSystemLinux, "m5.xlarge", "test-zone-1b")
+		Expect(ok).To(BeTrue())
+		Expect(price).To(BeNumerically("==", 1.0))
+		price, ok = awsEnv.PricingProvider.OnDemandPrice(pricing.OperatingSystemWindows, "m5.large")
+		Expect(ok).To(BeTrue())
+		Expect(price).To(BeNumerically("==", 0.9))
+		price, ok = awsEnv.PricingProvider.SpotPrice(pricing.OperatingSystemWindows, "m5.large", "test-zone-1a")
+		Expect(ok).To(BeTrue())
+		Expect(price).To(BeNumerically("==", 0.6))
+	})
+	It("should prefer spot prices from the EC2 API over the snapshot", func() {
+		now := time.Now()
+		awsEnv.EC2API.DescribeSpotPriceHistoryOutput.Set(&ec2.DescribeSpotPriceHistoryOutput{
+			SpotPriceHistory: []ec2types.SpotPrice{
+				{
+					AvailabilityZone:   aws.String("test-zone-1a"),
+					InstanceType:       "m5.large",
+					ProductDescription: ec2types.RIProductDescriptionLinuxUnixAmazonVpc,
+					SpotPrice:          aws.String("0.30"),
+					Timestamp:          &now,
+				},
+			},
+		})
+		Expect(awsEnv.PricingProvider.UpdateSpotPricing(ctx)).To(Succeed())
+		applySnapshot(`{"us-west-2": {"onDemand": {"Linux": {"m5.large": 0.5}}, "spot": {"Linux": {"m5.large": {"test-zone-1a": 0.2}}}}}`)
+		ExpectSingletonReconciled(ctx, controller)
+		expectPrices(0.5, 0.30)
+	})
+	It("should serve its prices in the snapshot format", func() {
+		applySnapshot(`{"us-west-2": {"onDemand": {"Linux": {"m5.large": 0.5}}, "spot": {"Windows": {"m5.large": {"test-zone-1a": 0.6}}}}}`)
+		ExpectSingletonReconciled(ctx, controller)
+
+		recorder := httptest.NewRecorder()
+		awsEnv.PricingProvider.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/debug/pricing-snapshot", nil))
+		Expect(recorder.Code).To(Equal(http.StatusOK))
+		snapshot, err := pricing.ParseSnapshot(recorder.Body.Bytes())
+		Expect(err).ToNot(HaveOccurred())
+		Expect(snapshot).To(Equal(awsEnv.PricingProvider.Snapshot()))
+		Expect(snapshot["us-west-2"].Spot[pricing.OperatingSystemWindows]).To(Equal(map[ec2types.InstanceType]map[string]float64{"m5.large": {"test-zone-1a": 0.6}}))
+	})
+	It("should reload the snapshot when it changes", func() {
+		applySnapshot(`{"us-west-2": {"onDemand": {"Linux": {"m5.large": 0.5}}}}`)
+		ExpectSingletonReconciled(ctx, controller)
+		expectPrices(0.5, 0.5)
+		applySnapshot(`{"us-west-2": {"onDemand": {"Linux": {"m5.large": 0.75}}}}`)
+		ExpectSingletonReconciled(ctx, controller)
+		expectPrices(0.75, 0.75)
+	})
+	It("should restore the static prices when the ConfigMap is deleted", func() {
+		applySnapshot(`{"us-west-2": {"onDemand": {"Linux": {"m5.large": 0.5}}}}`)
+		ExpectSingletonReconciled(ctx, controller)
+		expectPrices(0.5, 0.5)
+		Expect(env.Client.Delete(ctx, &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Namespace: configMapNamespace, Name: configMapName}})).To(Succeed())
+		ExpectSingletonReconciled(ctx, controller)
+		expectPrices(staticPrice, staticPrice)
+	})
+	It("should keep the previous prices when the snapshot is invalid", func() {
+		applySnapshot(`{"us-west-2": {"onDemand": {"Linux": {"m5.large": 0.5}}}}`)
+		ExpectSingletonReconciled(ctx, controller)
+		for _, invalid := range []string{
+			`{"us-east-1": {"onDemand": {"Linux": {"m5.large": 0.5}}}}`,
+			`{"us-west-2": {"onDemand": {"Linux": {"m5.large": -0.5}}}}`,
+			`{"us-west-2": {"onDemand": {"Linux": {"m5.large": "expensive"}}}}`,
+			`{"us-west-2": {"onDemand": {"Windows": {"m5.large": 0.5}}}}`,
+			`{"us-west-2": {"onDemand": {"Linux": {"m5.large": 0.5}, "BeOS": {"m5.large": 0.5}}}}`,
+			`{"us-west-2": {"onDemand": {"Linux": {"m5.large": 0.5}}, "spot": {"Linux": {"m5.large": {"test-zone-1a": -0.5}}}}}`,
+			`{"us-west-2": {"m5.large": 0.5}}`,
+		} {
+			applySnapshot(invalid)
+			_ = ExpectSingletonReconcileFailed(ctx, controller)
+			expectPrices(0.5, 0.5)
+		}
+	})
+	It("should prefer on-demand prices from the pricing API over the snapshot", func() {
+		awsEnv.PricingAPI.GetProductsOutput.Set(&awspricing.GetProductsOutput{
+			PriceList: []string{fake.NewOnDemandPrice("m5.large", 1.00)},
+		})
+		Expect(awsEnv.PricingProvider.UpdateOnDemandPricing(ctx)).To(Succeed())
+		applySnapshot(`{"us-west-2": {"onDemand": {"Linux": {"m5.large": 0.5}}}}`)
+		ExpectSingletonReconciled(ctx, controller)
+		expectPrices(1.00, 0.5)
+	})
+	It("should keep on-demand prices of other operating systems which have been retrieved", func() {
+		awsEnv.PricingAPI.GetProductsOutputByOperatingSystem.Set(&map[string]awspricing.GetProductsOutput{
+			"Linux": {},
+			"Windows": {
+				PriceList: []string{fake.NewOnDemandPrice("m5.large", 1.00)},
+			},
+		})
+		Expect(awsEnv.PricingProvider.UpdateOnDemandPricing(ctx)).ToNot(Succeed())
+		applySnapshot(`{"us-west-2": {"onDemand": {"Linux": {"m5.large": 0.5}, "Windows": {"m5.large": 0.9}}}}`)
+		ExpectSingletonReconciled(ctx, controller)
+		expectPrices(0.5, 0.5)
+		price, ok := awsEnv.PricingProvider.OnDemandPrice(pricing.OperatingSystemWindows, "m5.large")
+		Expect(ok).To(BeTrue())
+		Expect(price).To(BeNumerically("==", 1.00))
+	})
+	It("should not modify the snapshot when on-demand prices are retrieved", func() {
+		snapshot := pricing.Snapshot{"us-west-2": pricing.RegionSnapshot{
+			OnDemand: map[pricing.OperatingSystem]map[ec2types.InstanceType]float64{pricing.OperatingSystemLinux: {"m5.large": 0.5}},
+		}}
+		Expect(awsEnv.PricingProvider.SetSnapshot(ctx, snapshot)).To(Succeed())
+		awsEnv.PricingAPI.GetProductsOutput.Set(&awspricing.GetProductsOutput{
+			PriceList: []string{fake.NewOnDemandPrice("m5.large", 1.00)},
+		})
+		Expect(awsEnv.PricingProvider.UpdateOnDemandPricing(ctx)).To(Succeed())
+		expectPrices(1.00, 0.5)
+		Expect(snapshot["us-west-2"].OnDemand).To(Equal(map[pricing.OperatingSystem]map[ec2types.InstanceType]float64{
+			pricing.OperatingSystemLinux: {"m5.large": 0.5},
+		}))
+	})
+	It("should read the snapshot from a file", func() {
+		path := filepath.Join(GinkgoT().TempDir(), "prices.json")
+		fileController := controllerspricingsnapshot.NewController(pricing.NewFileSnapshotProvider(path), awsEnv.PricingProvider)
+		ExpectSingletonReconciled(ctx, fileController)
+		expectPrices(staticPrice, staticPrice)
+
+		Expect(os.WriteFile(path, []byte(`{"us-west-2": {"onDemand": {"Linux": {"m5.large": 0.5}}}}`), 0600)).To(Succeed())
+		ExpectSingletonReconciled(ctx, fileController)
+		expectPrices(0.5, 0.5)
+
+		Expect(os.WriteFile(path, []byte(`{"us-west-2": {"onDemand": {"Linux": {"m5.large": 0.75}}}}`), 0600)).To(Succeed())
+		ExpectSingletonReconciled(ctx, fileController)
+		expectPrices(0.75, 0.75)
+	})
+})
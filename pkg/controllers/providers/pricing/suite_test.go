@@ -27,6 +27,7 @@ import (
 	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
 	awspricing "github.com/aws/aws-sdk-go-v2/service/pricing"
 	"github.com/samber/lo"
+	karpv1 "sigs.k8s.io/karpenter/pkg/apis/v1"
 	coreoptions "sigs.k8s.io/karpenter/pkg/operator/options"
 	coretest "sigs.k8s.io/karpenter/pkg/test"
 
@@ -61,7 +62,6 @@ var _ = BeforeSuite(func() {
 	ctx = options.ToContext(ctx, test.Options())
 	ctx, stop = context.WithCancel(ctx)
 	awsEnv = test.NewEnvironment(ctx, env)
-	controller = controllerspricing.NewController(awsEnv.PricingProvider)
 })
 
 var _ = AfterSuite(func() {
@@ -74,6 +74,8 @@ var _ = BeforeEach(func() {
 	ctx = options.ToContext(ctx, test.Options())
 
 	awsEnv.Reset()
+	awsEnv.Clock.SetTime(time.Now())
+	controller = controllerspricing.NewController(awsEnv.PricingProvider, awsEnv.Clock)
 })
 
 var _ = AfterEach(func() {
@@ -85,9 +87,9 @@ var _ = Describe("Pricing", func() {
 		"should return correct static data for all partitions",
 		func(staticPricing map[string]map[ec2types.InstanceType]float64) {
 			for region, prices := range staticPricing {
-				provider := pricing.NewDefaultProvider(ctx, awsEnv.PricingAPI, awsEnv.EC2API, region)
+				provider := pricing.NewDefaultProvider(ctx, awsEnv.PricingAPI, awsEnv.EC2API, region, awsEnv.Clock)
 				for instance, price := range prices {
-					val, ok := provider.OnDemandPrice(instance)
+					val, ok := provider.OnDemandPrice(pricing.OperatingSystemLinux, instance)
 					Expect(ok).To(BeTrue())
 					Expect(val).To(Equal(price))
 				}
@@ -100,14 +102,14 @@ var _ = Describe("Pricing", func() {
 	It("should return static on-demand data if pricing API fails", func() {
 		awsEnv.PricingAPI.NextError.Set(fmt.Errorf("failed"))
 		_ = ExpectSingletonReconcileFailed(ctx, controller)
-		price, ok := awsEnv.PricingProvider.OnDemandPrice("c5.large")
+		price, ok := awsEnv.PricingProvider.OnDemandPrice(pricing.OperatingSystemLinux, "c5.large")
 		Expect(ok).To(BeTrue())
 		Expect(price).To(BeNumerically(">", 0))
 	})
 	It("should return static spot data if EC2 describeSpotPriceHistory API fails", func() {
 		awsEnv.PricingAPI.NextError.Set(fmt.Errorf("failed"))
 		_ = ExpectSingletonReconcileFailed(ctx, controller)
-		price, ok := awsEnv.PricingProvider.SpotPrice("c5.large", "test-zone-1a")
+		price, ok := awsEnv.PricingProvider.SpotPrice(pricing.OperatingSystemLinux, "c5.large", "test-zone-1a")
 		Expect(ok).To(BeTrue())
 		Expect(price).To(BeNumerically(">", 0))
 	})
@@ -122,11 +124,11 @@ var _ = Describe("Pricing", func() {
 		})
 		_ = ExpectSingletonReconcileFailed(ctx, controller)
 
-		price, ok := awsEnv.PricingProvider.OnDemandPrice("c98.large")
+		price, ok := awsEnv.PricingProvider.OnDemandPrice(pricing.OperatingSystemLinux, "c98.large")
 		Expect(ok).To(BeTrue())
 		Expect(price).To(BeNumerically("==", 1.20))
 
-		price, ok = awsEnv.PricingProvider.OnDemandPrice("c99.large")
+		price, ok = awsEnv.PricingProvider.OnDemandPrice(pricing.OperatingSystemLinux, "c99.large")
 		Expect(ok).To(BeTrue())
 		Expect(price).To(BeNumerically("==", 1.23))
 	})
@@ -169,11 +171,11 @@ var _ = Describe("Pricing", func() {
 		})
 		ExpectSingletonReconciled(ctx, controller)
 
-		price, ok := awsEnv.PricingProvider.SpotPrice("c98.large", "test-zone-1b")
+		price, ok := awsEnv.PricingProvider.SpotPrice(pricing.OperatingSystemLinux, "c98.large", "test-zone-1b")
 		Expect(ok).To(BeTrue())
 		Expect(price).To(BeNumerically("==", 1.10))
 
-		price, ok = awsEnv.PricingProvider.SpotPrice("c99.large", "test-zone-1a")
+		price, ok = awsEnv.PricingProvider.SpotPrice(pricing.OperatingSystemLinux, "c99.large", "test-zone-1a")
 		Expect(ok).To(BeTrue())
 		Expect(price).To(BeNumerically("==", 1.23))
 	})
@@ -203,13 +205,88 @@ var _ = Describe("Pricing", func() {
 		})
 		ExpectSingletonReconciled(ctx, controller)
 
-		price, ok := awsEnv.PricingProvider.SpotPrice("c98.large", "test-zone-1a")
+		price, ok := awsEnv.PricingProvider.SpotPrice(pricing.OperatingSystemLinux, "c98.large", "test-zone-1a")
 		Expect(ok).To(BeTrue())
 		Expect(price).To(BeNumerically("==", 1.20))
 
-		_, ok = awsEnv.PricingProvider.SpotPrice("c98.large", "test-zone-1b")
+		_, ok = awsEnv.PricingProvider.SpotPrice(pricing.OperatingSystemLinux, "c98.large", "test-zone-1b")
 		Expect(ok).ToNot(BeTrue())
 	})
+	It("should update zonal on-demand pricing for zones in a separate network border group", func() {
+		awsEnv.EC2API.DescribeAvailabilityZonesOutput.Set(&ec2.DescribeAvailabilityZonesOutput{AvailabilityZones: []ec2types.AvailabilityZone{
+			{ZoneName: aws.String("test-zone-1a"), ZoneType: aws.String("availability-zone"), NetworkBorderGroup: aws.String(fake.DefaultRegion)},
+			{ZoneName: aws.String("test-zone-1a-local"), ZoneType: aws.String("local-zone"), NetworkBorderGroup: aws.String("test-region-local-1")},
+		}})
+		awsEnv.PricingAPI.GetProductsOutput.Set(&awspricing.GetProductsOutput{
+			PriceList: []string{
+				fake.NewOnDemandPrice("c98.large", 1.20),
+				fake.NewOnDemandPrice("c99.large", 1.23),
+			},
+		})
+		awsEnv.PricingAPI.GetProductsOutputByRegionCode.Set(&map[string]awspricing.GetProductsOutput{
+			"test-region-local-1": {
+				PriceList: []string{
+					fake.NewOnDemandPrice("c98.large", 1.44),
+				},
+			},
+		})
+		_ = ExpectSingletonReconcileFailed(ctx, controller)
+
+		price, ok := awsEnv.PricingProvider.ZonalOnDemandPrice(pricing.OperatingSystemLinux, "c98.large", "test-zone-1a-local")
+		Expect(ok).To(BeTrue())
+		Expect(price).To(BeNumerically("==", 1.44))
+		// instance types without pricing data in the zone fall back to the regional price
+		price, ok = awsEnv.PricingProvider.ZonalOnDemandPrice(pricing.OperatingSystemLinux, "c99.large", "test-zone-1a-local")
+		Expect(ok).To(BeTrue())
+		Expect(price).To(BeNumerically("==", 1.23))
+		// zones within the region fall back to the regional price
+		price, ok = awsEnv.PricingProvider.ZonalOnDemandPrice(pricing.OperatingSystemLinux, "c98.large", "test-zone-1a")
+		Expect(ok).To(BeTrue())
+		Expect(price).To(BeNumerically("==", 1.20))
+	})
+	It("should fall back to regional on-demand pricing if zonal pricing fails", func() {
+		awsEnv.EC2API.DescribeAvailabilityZonesOutput.Set(&ec2.DescribeAvailabilityZonesOutput{AvailabilityZones: []ec2types.AvailabilityZone{
+			{ZoneName: aws.String("test-zone-1a-local"), ZoneType: aws.String("local-zone"), NetworkBorderGroup: aws.String("test-region-local-1")},
+		}})
+		awsEnv.PricingAPI.GetProductsOutput.Set(&awspricing.GetProductsOutput{
+			PriceList: []string{
+				fake.NewOnDemandPrice("c98.large", 1.20),
+			},
+		})
+		awsEnv.PricingAPI.GetProductsOutputByRegionCode.Set(&map[string]awspricing.GetProductsOutput{
+			"test-region-local-1": {},
+		})
+		_ = ExpectSingletonReconcileFailed(ctx, controller)
+
+		price, ok := awsEnv.PricingProvider.ZonalOnDemandPrice(pricing.OperatingSystemLinux, "c98.large", "test-zone-1a-local")
+		Expect(ok).To(BeTrue())
+		Expect(price).To(BeNumerically("==", 1.20))
+	})
+	It("should update zonal on-demand pricing when prices for an operating system can't be retrieved", func() {
+		awsEnv.EC2API.DescribeAvailabilityZonesOutput.Set(&ec2.DescribeAvailabilityZonesOutput{AvailabilityZones: []ec2types.AvailabilityZone{
+			{ZoneName: aws.String("test-zone-1a-local"), ZoneType: aws.String("local-zone"), NetworkBorderGroup: aws.String("test-region-local-1")},
+		}})
+		awsEnv.PricingAPI.GetProductsOutputByRegionCode.Set(&map[string]awspricing.GetProductsOutput{
+			fake.DefaultRegion: {
+				PriceList: []string{
+					fake.NewOnDemandPrice("c98.large", 1.20),
+				},
+			},
+			"test-region-local-1": {
+				PriceList: []string{
+					fake.NewOnDemandPrice("c98.large", 1.44),
+				},
+			},
+		})
+		awsEnv.PricingAPI.GetProductsOutputByOperatingSystem.Set(&map[string]awspricing.GetProductsOutput{
+			"Windows": {},
+		})
+		_ = ExpectSingletonReconcileFailed(ctx, controller)
+
+		price, ok := awsEnv.PricingProvider.ZonalOnDemandPrice(pricing.OperatingSystemLinux, "c98.large", "test-zone-1a-local")
+		Expect(ok).To(BeTrue())
+		Expect(price).To(BeNumerically("==", 1.44))
+	})
 	It("should respond with false if price doesn't exist in zone", func() {
 		now := time.Now()
 		awsEnv.EC2API.DescribeSpotPriceHistoryOutput.Set(&ec2.DescribeSpotPriceHistoryOutput{
@@ -230,7 +307,7 @@ var _ = Describe("Pricing", func() {
 		})
 		ExpectSingletonReconciled(ctx, controller)
 
-		_, ok := awsEnv.PricingProvider.SpotPrice("c99.large", "test-zone-1b")
+		_, ok := awsEnv.PricingProvider.SpotPrice(pricing.OperatingSystemLinux, "c99.large", "test-zone-1b")
 		Expect(ok).To(BeFalse())
 	})
 	It("should query for both `Linux/UNIX` and `Linux/UNIX (Amazon VPC)`", func() {
@@ -291,17 +368,17 @@ var _ = Describe("Pricing", func() {
 			},
 		})
 		ExpectSingletonReconciled(ctx, controller)
-		price, ok := awsEnv.PricingProvider.OnDemandPrice("c3.2xlarge")
+		price, ok := awsEnv.PricingProvider.OnDemandPrice(pricing.OperatingSystemLinux, "c3.2xlarge")
 		Expect(ok).To(BeTrue())
 		Expect(price).To(BeNumerically("==", 0.420000))
 
-		price, ok = awsEnv.PricingProvider.SpotPrice("c98.large", "test-zone-1b")
+		price, ok = awsEnv.PricingProvider.SpotPrice(pricing.OperatingSystemLinux, "c98.large", "test-zone-1b")
 		Expect(ok).To(BeTrue())
 		Expect(price).To(BeNumerically("==", 1.10))
 	})
 	It("should update on-demand pricing with response from the pricing API when in the CN partition", func() {
-		tmpPricingProvider := pricing.NewDefaultProvider(ctx, awsEnv.PricingAPI, awsEnv.EC2API, "cn-anywhere-1")
-		tmpController := controllerspricing.NewController(tmpPricingProvider)
+		tmpPricingProvider := pricing.NewDefaultProvider(ctx, awsEnv.PricingAPI, awsEnv.EC2API, "cn-anywhere-1", awsEnv.Clock)
+		tmpController := controllerspricing.NewController(tmpPricingProvider, awsEnv.Clock)
 
 		now := time.Now()
 		awsEnv.EC2API.DescribeSpotPriceHistoryOutput.Set(&ec2.DescribeSpotPriceHistoryOutput{
@@ -322,12 +399,297 @@ var _ = Describe("Pricing", func() {
 		})
 		ExpectSingletonReconciled(ctx, tmpController)
 
-		price, ok := tmpPricingProvider.OnDemandPrice("c98.large")
+		price, ok := tmpPricingProvider.OnDemandPrice(pricing.OperatingSystemLinux, "c98.large")
 		Expect(ok).To(BeTrue())
 		Expect(price).To(BeNumerically("==", 1.20))
 
-		price, ok = tmpPricingProvider.OnDemandPrice("c99.large")
+		price, ok = tmpPricingProvider.OnDemandPrice(pricing.OperatingSystemLinux, "c99.large")
 		Expect(ok).To(BeTrue())
 		Expect(price).To(BeNumerically("==", 1.23))
 	})
+	Context("Operating Systems", func() {
+		BeforeEach(func() {
+			now := time.Now()
+			awsEnv.EC2API.DescribeSpotPriceHistoryOutput.Set(&ec2.DescribeSpotPriceHistoryOutput{
+				SpotPriceHistory: []ec2types.SpotPrice{
+					{
+						AvailabilityZone:   aws.String("test-zone-1a"),
+						InstanceType:       "c98.large",
+						ProductDescription: ec2types.RIProductDescriptionLinuxUnixAmazonVpc,
+						SpotPrice:          aws.String("0.50"),
+						Timestamp:          &now,
+					},
+					{
+						AvailabilityZone:   aws.String("test-zone-1a"),
+						InstanceType:       "c99.large",
+						ProductDescription: ec2types.RIProductDescriptionLinuxUnixAmazonVpc,
+						SpotPrice:          aws.String("0.60"),
+						Timestamp:          &now,
+					},
+					{
+						AvailabilityZone:   aws.String("test-zone-1a"),
+						InstanceType:       "c98.large",
+						ProductDescription: ec2types.RIProductDescriptionWindowsAmazonVpc,
+						SpotPrice:          aws.String("0.90"),
+						Timestamp:          &now,
+					},
+					{
+						AvailabilityZone:   aws.String("test-zone-1a"),
+						InstanceType:       "c97.large",
+						ProductDescription: ec2types.RIProductDescriptionLinuxUnixAmazonVpc,
+						SpotPrice:          aws.String("0.40"),
+						Timestamp:          &now,
+					},
+				},
+			})
+			awsEnv.PricingAPI.GetProductsOutputByOperatingSystem.Set(&map[string]awspricing.GetProductsOutput{
+				"Linux": {
+					PriceList: []string{
+						fake.NewOnDemandPrice("c97.large", 1.10),
+						fake.NewOnDemandPrice("c98.large", 1.20),
+						fake.NewOnDemandPrice("c99.large", 1.23),
+					},
+				},
+				"Windows": {
+					PriceList: []string{
+						fake.NewOnDemandPrice("c97.large", 2.10),
+						fake.NewOnDemandPrice("c98.large", 2.20),
+					},
+				},
+			})
+		})
+		It("should filter on-demand and spot prices by operating system", func() {
+			ExpectSingletonReconciled(ctx, controller)
+			Expect(awsEnv.EC2API.DescribeSpotPriceHistoryInput.Clone().ProductDescriptions).To(ConsistOf("Linux/UNIX", "Linux/UNIX (Amazon VPC)", "Windows", "Windows (Amazon VPC)"))
+
+			price, ok := awsEnv.PricingProvider.OnDemandPrice(pricing.OperatingSystemLinux, "c98.large")
+			Expect(ok).To(BeTrue())
+			Expect(price).To(BeNumerically("==", 1.20))
+			price, ok = awsEnv.PricingProvider.OnDemandPrice(pricing.OperatingSystemWindows, "c98.large")
+			Expect(ok).To(BeTrue())
+			Expect(price).To(BeNumerically("==", 2.20))
+			price, ok = awsEnv.PricingProvider.ZonalOnDemandPrice(pricing.OperatingSystemWindows, "c98.large", "test-zone-1a")
+			Expect(ok).To(BeTrue())
+			Expect(price).To(BeNumerically("==", 2.20))
+
+			price, ok = awsEnv.PricingProvider.SpotPrice(pricing.OperatingSystemLinux, "c98.large", "test-zone-1a")
+			Expect(ok).To(BeTrue())
+			Expect(price).To(BeNumerically("==", 0.50))
+			price, ok = awsEnv.PricingProvider.SpotPrice(pricing.OperatingSystemWindows, "c98.large", "test-zone-1a")
+			Expect(ok).To(BeTrue())
+			Expect(price).To(BeNumerically("==", 0.90))
+		})
+		It("should not price instance types without an on-demand price for the operating system", func() {
+			ExpectSingletonReconciled(ctx, controller)
+
+			_, ok := awsEnv.PricingProvider.OnDemandPrice(pricing.OperatingSystemWindows, "c99.large")
+			Expect(ok).To(BeFalse())
+			_, ok = awsEnv.PricingProvider.ZonalOnDemandPrice(pricing.OperatingSystemWindows, "c99.large", "test-zone-1a")
+			Expect(ok).To(BeFalse())
+		})
+		It("should not price instance types without a spot price for the operating system", func() {
+			ExpectSingletonReconciled(ctx, controller)
+
+			_, ok := awsEnv.PricingProvider.SpotPrice(pricing.OperatingSystemWindows, "c97.large", "test-zone-1a")
+			Expect(ok).To(BeFalse())
+			_, ok = awsEnv.PricingProvider.SpotPrice(pricing.OperatingSystemWindows, "c99.large", "test-zone-1a")
+			Expect(ok).To(BeFalse())
+		})
+		It("should fall back to the on-demand price of the operating system until spot prices are retrieved", func() {
+			fallbacks := priceFallbacks(pricing.OperatingSystemWindows, karpv1.CapacityTypeSpot)
+			Expect(awsEnv.PricingProvider.UpdateOnDemandPricing(ctx)).To(Succeed())
+
+			// The Linux spot price doesn't include the license of the operating system, so the on-demand price that the
+			// instance type is offered at with the operating system is used instead
+			price, ok := awsEnv.PricingProvider.SpotPrice(pricing.OperatingSystemWindows, "c97.large", "test-zone-1a")
+			Expect(ok).To(BeTrue())
+			Expect(price).To(BeNumerically("==", 2.10))
+			Expect(priceFallbacks(pricing.OperatingSystemWindows, karpv1.CapacityTypeSpot)).To(BeNumerically("==", fallbacks+1))
+		})
+		It("should update Linux prices when prices for another operating system can't be retrieved", func() {
+			awsEnv.PricingAPI.GetProductsOutputByOperatingSystem.Set(&map[string]awspricing.GetProductsOutput{
+				"Linux": {
+					PriceList: []string{
+						fake.NewOnDemandPrice("c98.large", 1.20),
+					},
+				},
+				"Windows": {},
+			})
+			_ = ExpectSingletonReconcileFailed(ctx, controller)
+
+			price, ok := awsEnv.PricingProvider.OnDemandPrice(pricing.OperatingSystemLinux, "c98.large")
+			Expect(ok).To(BeTrue())
+			Expect(price).To(BeNumerically("==", 1.20))
+			// The Windows prices haven't been retrieved, so the Linux prices are used instead
+			fallbacks := priceFallbacks(pricing.OperatingSystemWindows, karpv1.CapacityTypeOnDemand)
+			price, ok = awsEnv.PricingProvider.OnDemandPrice(pricing.OperatingSystemWindows, "c98.large")
+			Expect(ok).To(BeTrue())
+			Expect(price).To(BeNumerically("==", 1.20))
+			Expect(priceFallbacks(pricing.OperatingSystemWindows, karpv1.CapacityTypeOnDemand)).To(BeNumerically("==", fallbacks+1))
+		})
+	})
+	Context("Spot Price Aggregation", func() {
+		BeforeEach(func() {
+			now := time.Now()
+			awsEnv.EC2API.DescribeSpotPriceHistoryOutput.Set(&ec2.DescribeSpotPriceHistoryOutput{
+				SpotPriceHistory: []ec2types.SpotPrice{
+					{
+						AvailabilityZone: aws.String("test-zone-1a"),
+						InstanceType:     "c99.large",
+						SpotPrice:        aws.String("2.00"),
+						Timestamp:        lo.ToPtr(now.Add(-time.Hour)),
+					},
+					{
+						AvailabilityZone: aws.String("test-zone-1a"),
+						InstanceType:     "c99.large",
+						SpotPrice:        aws.String("1.00"),
+						Timestamp:        lo.ToPtr(now.Add(-5 * time.Hour)),
+					},
+				},
+			})
+			awsEnv.PricingAPI.GetProductsOutput.Set(&awspricing.GetProductsOutput{
+				PriceList: []string{
+					fake.NewOnDemandPrice("c99.large", 3.00),
+				},
+			})
+		})
+		It("should request the spot price history within the spot price window", func() {
+			ctx = options.ToContext(ctx, test.Options(test.OptionsFields{
+				SpotPriceAggregation: lo.ToPtr("average"),
+				SpotPriceWindow:      lo.ToPtr(2 * time.Hour),
+			}))
+			ExpectSingletonReconciled(ctx, controller)
+
+			inp := awsEnv.EC2API.DescribeSpotPriceHistoryInput.Clone()
+			Expect(lo.FromPtr(inp.StartTime)).To(BeTemporally("~", time.Now().Add(-2*time.Hour), time.Minute))
+		})
+		It("should only request the current spot prices when prices aren't aggregated", func() {
+			ExpectSingletonReconciled(ctx, controller)
+
+			inp := awsEnv.EC2API.DescribeSpotPriceHistoryInput.Clone()
+			Expect(lo.FromPtr(inp.StartTime)).To(BeTemporally("~", time.Now(), time.Minute))
+		})
+		It("should refresh aggregated spot prices several times within the spot price window", func() {
+			ctx = options.ToContext(ctx, test.Options(test.OptionsFields{
+				SpotPriceAggregation: lo.ToPtr("average"),
+				SpotPriceWindow:      lo.ToPtr(6 * time.Hour),
+			}))
+			result := ExpectSingletonReconciled(ctx, controller)
+			Expect(result.RequeueAfter).To(Equal(time.Hour))
+		})
+		It("should refresh spot prices every 12 hours when they aren't aggregated", func() {
+			ctx = options.ToContext(ctx, test.Options(test.OptionsFields{SpotPriceWindow: lo.ToPtr(6 * time.Hour)}))
+			result := ExpectSingletonReconciled(ctx, controller)
+			Expect(result.RequeueAfter).To(Equal(12 * time.Hour))
+		})
+		It("should request the spot price history relative to the clock", func() {
+			ctx = options.ToContext(ctx, test.Options(test.OptionsFields{
+				SpotPriceAggregation: lo.ToPtr("average"),
+				SpotPriceWindow:      lo.ToPtr(2 * time.Hour),
+			}))
+			awsEnv.Clock.Step(24 * time.Hour)
+			ExpectSingletonReconciled(ctx, controller)
+
+			inp := awsEnv.EC2API.DescribeSpotPriceHistoryInput.Clone()
+			Expect(lo.FromPtr(inp.StartTime)).To(Equal(awsEnv.Clock.Now().Add(-2 * time.Hour)))
+		})
+		It("should only refresh on-demand prices every 12 hours", func() {
+			ExpectSingletonReconciled(ctx, controller)
+
+			awsEnv.PricingAPI.GetProductsOutput.Set(&awspricing.GetProductsOutput{
+				PriceList: []string{
+					fake.NewOnDemandPrice("c99.large", 4.00),
+				},
+			})
+			awsEnv.EC2API.DescribeSpotPriceHistoryOutput.Set(&ec2.DescribeSpotPriceHistoryOutput{
+				SpotPriceHistory: []ec2types.SpotPrice{
+					{
+						AvailabilityZone: aws.String("test-zone-1a"),
+						InstanceType:     "c99.large",
+						SpotPrice:        aws.String("2.50"),
+						Timestamp:        lo.ToPtr(time.Now()),
+					},
+				},
+			})
+			awsEnv.Clock.Step(time.Hour)
+			ExpectSingletonReconciled(ctx, controller)
+			price, ok := awsEnv.PricingProvider.OnDemandPrice(pricing.OperatingSystemLinux, "c99.large")
+			Expect(ok).To(BeTrue())
+			Expect(price).To(BeNumerically("==", 3.00))
+			price, ok = awsEnv.PricingProvider.SpotPrice(pricing.OperatingSystemLinux, "c99.large", "test-zone-1a")
+			Expect(ok).To(BeTrue())
+			Expect(price).To(BeNumerically("==", 2.50))
+
+			awsEnv.Clock.Step(11 * time.Hour)
+			ExpectSingletonReconciled(ctx, controller)
+			price, ok = awsEnv.PricingProvider.OnDemandPrice(pricing.OperatingSystemLinux, "c99.large")
+			Expect(ok).To(BeTrue())
+			Expect(price).To(BeNumerically("==", 4.00))
+		})
+		DescribeTable(
+			"should aggregate the spot price history",
+			func(aggregation string, expected float64) {
+				ctx = options.ToContext(ctx, test.Options(test.OptionsFields{SpotPriceAggregation: lo.ToPtr(aggregation)}))
+				ExpectSingletonReconciled(ctx, controller)
+
+				price, ok := awsEnv.PricingProvider.SpotPrice(pricing.OperatingSystemLinux, "c99.large", "test-zone-1a")
+				Expect(ok).To(BeTrue())
+				Expect(price).To(BeNumerically("~", expected, 0.001))
+			},
+			Entry("latest", "latest", 2.00),
+			// 1.00 was in effect for four hours and 2.00 for one hour of the window
+			Entry("average", "average", 1.20),
+			Entry("p50", "p50", 1.00),
+			Entry("p90", "p90", 2.00),
+		)
+		It("should include the price in effect at the start of the spot price window", func() {
+			ctx = options.ToContext(ctx, test.Options(test.OptionsFields{
+				SpotPriceAggregation: lo.ToPtr("average"),
+				SpotPriceWindow:      lo.ToPtr(2 * time.Hour),
+			}))
+			ExpectSingletonReconciled(ctx, controller)
+
+			// 1.00 was in effect for the first hour of the window and 2.00 for the second
+			price, ok := awsEnv.PricingProvider.SpotPrice(pricing.OperatingSystemLinux, "c99.large", "test-zone-1a")
+			Expect(ok).To(BeTrue())
+			Expect(price).To(BeNumerically("~", 1.50, 0.001))
+		})
+		It("should retain the spot price history across updates", func() {
+			ctx = options.ToContext(ctx, test.Options(test.OptionsFields{SpotPriceAggregation: lo.ToPtr("average")}))
+			ExpectSingletonReconciled(ctx, controller)
+
+			// only the most recent spot price is returned by the second update
+			output := awsEnv.EC2API.DescribeSpotPriceHistoryOutput.Clone()
+			output.SpotPriceHistory = output.SpotPriceHistory[:1]
+			awsEnv.EC2API.DescribeSpotPriceHistoryOutput.Set(output)
+			ExpectSingletonReconciled(ctx, controller)
+
+			price, ok := awsEnv.PricingProvider.SpotPrice(pricing.OperatingSystemLinux, "c99.large", "test-zone-1a")
+			Expect(ok).To(BeTrue())
+			Expect(price).To(BeNumerically("~", 1.20, 0.001))
+		})
+		It("should expose the spot price volatility", func() {
+			ExpectSingletonReconciled(ctx, controller)
+
+			// the time-weighted mean is 1.20 and the time-weighted standard deviation is 0.40
+			metric, ok := FindMetricWithLabelValues("karpenter_cloudprovider_spot_price_volatility", map[string]string{
+				"instance_type": "c99.large",
+				"zone":          "test-zone-1a",
+			})
+			Expect(ok).To(BeTrue())
+			Expect(metric.GetGauge().GetValue()).To(BeNumerically("~", 1.0/3, 0.001))
+		})
+	})
 })
+
+// priceFallbacks returns the number of fallback prices that were returned for the operating system and capacity type
+func priceFallbacks(osName pricing.OperatingSystem, capacityType string) float64 {
+	metric, ok := FindMetricWithLabelValues("karpenter_cloudprovider_price_fallbacks_total", map[string]string{
+		"operating_system": string(osName),
+		"capacity_type":    capacityType,
+	})
+	if !ok {
+		return 0
+	}
+	return metric.GetCounter().GetValue()
+}
@@ -0,0 +1,71 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package servicequota
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/awslabs/operatorpkg/singleton"
+	"github.com/samber/lo"
+	controllerruntime "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	karpv1 "sigs.k8s.io/karpenter/pkg/apis/v1"
+	"sigs.k8s.io/karpenter/pkg/operator/injection"
+
+	awserrors "github.com/aws/karpenter-provider-aws/pkg/errors"
+	"github.com/aws/karpenter-provider-aws/pkg/providers/servicequota"
+)
+
+type Controller struct {
+	kubeClient           client.Client
+	serviceQuotaProvider servicequota.Provider
+}
+
+func NewController(kubeClient client.Client, serviceQuotaProvider servicequota.Provider) *Controller {
+	return &Controller{
+		kubeClient:           kubeClient,
+		serviceQuotaProvider: serviceQuotaProvider,
+	}
+}
+
+func (c *Controller) Reconcile(ctx context.Context) (reconcile.Result, error) {
+	ctx = injection.WithControllerName(ctx, "providers.servicequota")
+
+	if err := c.serviceQuotaProvider.UpdateQuotas(ctx); err != nil {
+		// Reading the quotas requires an additional permission, so launches aren't constrained by quotas without it
+		if !awserrors.IsUnauthorizedOperation(err) {
+			return reconcile.Result{}, fmt.Errorf("updating service quotas, %w", err)
+		}
+		log.FromContext(ctx).V(1).Info("not authorized to list service quotas, vcpu quotas won't be considered when launching")
+	}
+	nodeClaimList := &karpv1.NodeClaimList{}
+	if err := c.kubeClient.List(ctx, nodeClaimList); err != nil {
+		return reconcile.Result{}, fmt.Errorf("listing nodeclaims, %w", err)
+	}
+	c.serviceQuotaProvider.UpdateUsage(ctx, lo.ToSlicePtr(nodeClaimList.Items))
+	return reconcile.Result{RequeueAfter: time.Minute}, nil
+}
+
+func (c *Controller) Register(_ context.Context, m manager.Manager) error {
+	return controllerruntime.NewControllerManagedBy(m).
+		Named("providers.servicequota").
+		WatchesRawSource(singleton.Source()).
+		Complete(singleton.AsReconciler(c))
+}
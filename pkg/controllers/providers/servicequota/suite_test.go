@@ -0,0 +1,187 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package servicequota_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/servicequotas"
+	servicequotastypes "github.com/aws/aws-sdk-go-v2/service/servicequotas/types"
+	"github.com/aws/smithy-go"
+	"github.com/samber/lo"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	karpv1 "sigs.k8s.io/karpenter/pkg/apis/v1"
+	coreoptions "sigs.k8s.io/karpenter/pkg/operator/options"
+	coretest "sigs.k8s.io/karpenter/pkg/test"
+	"sigs.k8s.io/karpenter/pkg/test/v1alpha1"
+
+	"github.com/aws/karpenter-provider-aws/pkg/apis"
+	controllersservicequota "github.com/aws/karpenter-provider-aws/pkg/controllers/providers/servicequota"
+	"github.com/aws/karpenter-provider-aws/pkg/fake"
+	"github.com/aws/karpenter-provider-aws/pkg/operator/options"
+	"github.com/aws/karpenter-provider-aws/pkg/providers/servicequota"
+	"github.com/aws/karpenter-provider-aws/pkg/test"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	. "sigs.k8s.io/karpenter/pkg/test/expectations"
+	. "sigs.k8s.io/karpenter/pkg/utils/testing"
+)
+
+var ctx context.Context
+var stop context.CancelFunc
+var env *coretest.Environment
+var awsEnv *test.Environment
+var controller *controllersservicequota.Controller
+
+func TestAWS(t *testing.T) {
+	ctx = TestContextWithLogger(t)
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "ServiceQuota")
+}
+
+var _ = BeforeSuite(func() {
+	env = coretest.NewEnvironment(coretest.WithCRDs(apis.CRDs...), coretest.WithCRDs(v1alpha1.CRDs...))
+	ctx = coreoptions.ToContext(ctx, coretest.Options())
+	ctx = options.ToContext(ctx, test.Options())
+	ctx, stop = context.WithCancel(ctx)
+	awsEnv = test.NewEnvironment(ctx, env)
+	controller = controllersservicequota.NewController(env.Client, awsEnv.ServiceQuotaProvider)
+})
+
+var _ = AfterSuite(func() {
+	stop()
+	Expect(env.Stop()).To(Succeed(), "Failed to stop environment")
+})
+
+var _ = BeforeEach(func() {
+	ctx = coreoptions.ToContext(ctx, coretest.Options())
+	ctx = options.ToContext(ctx, test.Options())
+	awsEnv.Reset()
+	awsEnv.ServiceQuotasAPI.ListServiceQuotasBehavior.Output.Set(&servicequotas.ListServiceQuotasOutput{
+		Quotas: []servicequotastypes.ServiceQuota{
+			fake.NewServiceQuota("L-1216C47A", 64),
+			fake.NewServiceQuota("L-34B43A08", 32),
+			fake.NewServiceQuota("L-DB2E81BA", 0),
+			// Quotas that aren't vCPU quotas are ignored
+			fake.NewServiceQuota("L-0263D0A3", 5),
+		},
+	})
+})
+
+var _ = AfterEach(func() {
+	ExpectCleanedUp(ctx, env.Client)
+})
+
+func nodeClaim(instanceType, capacityType string, cpu string) *karpv1.NodeClaim {
+	return coretest.NodeClaim(karpv1.NodeClaim{
+		ObjectMeta: metav1.ObjectMeta{
+			Labels: map[string]string{
+				corev1.LabelInstanceTypeStable: instanceType,
+				karpv1.CapacityTypeLabelKey:    capacityType,
+			},
+		},
+		Status: karpv1.NodeClaimStatus{
+			Capacity: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse(cpu)},
+		},
+	})
+}
+
+var _ = Describe("ServiceQuota", func() {
+	It("should discover the vcpu quotas", func() {
+		ExpectSingletonReconciled(ctx, controller)
+
+		Expect(awsEnv.ServiceQuotasAPI.ListServiceQuotasBehavior.CalledWithInput.Len()).To(Equal(1))
+		Expect(lo.FromPtr(awsEnv.ServiceQuotasAPI.ListServiceQuotasBehavior.CalledWithInput.Pop().ServiceCode)).To(Equal("ec2"))
+		Expect(awsEnv.ServiceQuotaProvider.Quotas()).To(ConsistOf(
+			servicequota.Quota{Key: servicequota.Key{Class: servicequota.ClassStandard, CapacityType: karpv1.CapacityTypeOnDemand}, Limit: 64},
+			servicequota.Quota{Key: servicequota.Key{Class: servicequota.ClassStandard, CapacityType: karpv1.CapacityTypeSpot}, Limit: 32},
+			servicequota.Quota{Key: servicequota.Key{Class: servicequota.ClassG, CapacityType: karpv1.CapacityTypeOnDemand}, Limit: 0},
+		))
+	})
+	It("should not list the quotas again until they've expired", func() {
+		ExpectSingletonReconciled(ctx, controller)
+		ExpectSingletonReconciled(ctx, controller)
+		Expect(awsEnv.ServiceQuotasAPI.ListServiceQuotasBehavior.Calls()).To(Equal(1))
+	})
+	It("should track usage from the nodeclaims that have been launched", func() {
+		ExpectApplied(ctx, env.Client,
+			nodeClaim("m5.xlarge", karpv1.CapacityTypeOnDemand, "4"),
+			nodeClaim("c6g.large", karpv1.CapacityTypeOnDemand, "2"),
+			nodeClaim("m5.large", karpv1.CapacityTypeSpot, "2"),
+		)
+		ExpectSingletonReconciled(ctx, controller)
+
+		quotas := lo.SliceToMap(awsEnv.ServiceQuotaProvider.Quotas(), func(q servicequota.Quota) (servicequota.Key, servicequota.Quota) { return q.Key, q })
+		Expect(quotas[servicequota.Key{Class: servicequota.ClassStandard, CapacityType: karpv1.CapacityTypeOnDemand}].Headroom()).To(BeNumerically("==", 58))
+		Expect(quotas[servicequota.Key{Class: servicequota.ClassStandard, CapacityType: karpv1.CapacityTypeSpot}].Headroom()).To(BeNumerically("==", 30))
+		ExpectMetricGaugeValue(servicequota.VCPUQuotaLimit, 64, map[string]string{"instance_class": servicequota.ClassStandard, "capacity_type": karpv1.CapacityTypeOnDemand})
+		ExpectMetricGaugeValue(servicequota.VCPUQuotaHeadroom, 58, map[string]string{"instance_class": servicequota.ClassStandard, "capacity_type": karpv1.CapacityTypeOnDemand})
+		ExpectMetricGaugeValue(servicequota.VCPUQuotaHeadroom, 30, map[string]string{"instance_class": servicequota.ClassStandard, "capacity_type": karpv1.CapacityTypeSpot})
+	})
+	It("should not fit instance types that would exceed their quota", func() {
+		ExpectSingletonReconciled(ctx, controller)
+		Expect(awsEnv.ServiceQuotaProvider.Fits("m5.metal", karpv1.CapacityTypeOnDemand, 64)).To(BeTrue())
+		Expect(awsEnv.ServiceQuotaProvider.Fits("m5.metal", karpv1.CapacityTypeSpot, 64)).To(BeFalse())
+		Expect(awsEnv.ServiceQuotaProvider.Fits("g4dn.8xlarge", karpv1.CapacityTypeOnDemand, 32)).To(BeFalse())
+		// Instance classes without a known quota aren't constrained
+		Expect(awsEnv.ServiceQuotaProvider.Fits("g4dn.8xlarge", karpv1.CapacityTypeSpot, 32)).To(BeTrue())
+		Expect(awsEnv.ServiceQuotaProvider.Fits("p3.8xlarge", karpv1.CapacityTypeOnDemand, 32)).To(BeTrue())
+		Expect(awsEnv.ServiceQuotaProvider.Fits("trn1.2xlarge", karpv1.CapacityTypeOnDemand, 8)).To(BeTrue())
+	})
+	It("should count launches towards the quota until they're reflected in the nodeclaims", func() {
+		ExpectSingletonReconciled(ctx, controller)
+		awsEnv.ServiceQuotaProvider.MarkLaunched("m5.xlarge", karpv1.CapacityTypeSpot, 30)
+		Expect(awsEnv.ServiceQuotaProvider.Fits("m5.large", karpv1.CapacityTypeSpot, 2)).To(BeTrue())
+		Expect(awsEnv.ServiceQuotaProvider.Fits("m5.xlarge", karpv1.CapacityTypeSpot, 4)).To(BeFalse())
+
+		// Once usage is reconciled from the nodeclaims, launches that were never registered no longer count
+		ExpectSingletonReconciled(ctx, controller)
+		Expect(awsEnv.ServiceQuotaProvider.Fits("m5.xlarge", karpv1.CapacityTypeSpot, 4)).To(BeTrue())
+	})
+	It("should not fail when not authorized to list service quotas", func() {
+		awsEnv.ServiceQuotasAPI.ListServiceQuotasBehavior.Error.Set(&smithy.GenericAPIError{Code: "AccessDeniedException", Message: "not authorized"})
+		ExpectSingletonReconciled(ctx, controller)
+		Expect(awsEnv.ServiceQuotaProvider.Quotas()).To(BeEmpty())
+		Expect(awsEnv.ServiceQuotaProvider.Fits("m5.metal", karpv1.CapacityTypeSpot, 96)).To(BeTrue())
+	})
+	It("should fail when listing service quotas fails", func() {
+		awsEnv.ServiceQuotasAPI.ListServiceQuotasBehavior.Error.Set(fmt.Errorf("failed"))
+		_, err := controller.Reconcile(ctx)
+		Expect(err).To(HaveOccurred())
+	})
+	DescribeTable("should map instance types to their instance class",
+		func(instanceType string, class string) {
+			Expect(servicequota.InstanceClass(instanceType)).To(Equal(class))
+		},
+		Entry("general purpose", "m5.large", servicequota.ClassStandard),
+		Entry("burstable", "t4g.small", servicequota.ClassStandard),
+		Entry("high memory z", "z1d.large", servicequota.ClassStandard),
+		Entry("graphics", "g4dn.8xlarge", servicequota.ClassG),
+		Entry("video transcoding", "vt1.3xlarge", servicequota.ClassG),
+		Entry("accelerated computing", "p3.8xlarge", servicequota.ClassP),
+		Entry("memory optimized x", "x2iedn.xlarge", servicequota.ClassX),
+		Entry("fpga", "f1.2xlarge", servicequota.ClassF),
+		Entry("inferentia", "inf2.xlarge", servicequota.ClassInf),
+		Entry("trainium", "trn1.2xlarge", ""),
+		Entry("high memory u", "u-6tb1.metal", ""),
+		Entry("mac", "mac2.metal", ""),
+		Entry("hpc", "hpc7g.4xlarge", ""),
+	)
+})
@@ -0,0 +1,57 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package spotplacementscore
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/awslabs/operatorpkg/singleton"
+	controllerruntime "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/karpenter/pkg/operator/injection"
+
+	"github.com/aws/karpenter-provider-aws/pkg/providers/spotplacementscore"
+)
+
+type Controller struct {
+	spotPlacementScoreProvider spotplacementscore.Provider
+}
+
+func NewController(spotPlacementScoreProvider spotplacementscore.Provider) *Controller {
+	return &Controller{
+		spotPlacementScoreProvider: spotPlacementScoreProvider,
+	}
+}
+
+func (c *Controller) Reconcile(ctx context.Context) (reconcile.Result, error) {
+	ctx = injection.WithControllerName(ctx, "providers.spotplacementscore")
+
+	// Scores are requested for the sets of instance types that spot fleet requests were made for, which only calls
+	// GetSpotPlacementScores for sets whose scores are missing or stale, and only within the query limit of the account
+	if err := c.spotPlacementScoreProvider.UpdateScores(ctx); err != nil {
+		return reconcile.Result{}, fmt.Errorf("updating spot placement scores, %w", err)
+	}
+	return reconcile.Result{RequeueAfter: 5 * time.Minute}, nil
+}
+
+func (c *Controller) Register(_ context.Context, m manager.Manager) error {
+	return controllerruntime.NewControllerManagedBy(m).
+		Named("providers.spotplacementscore").
+		WatchesRawSource(singleton.Source()).
+		Complete(singleton.AsReconciler(c))
+}
@@ -0,0 +1,189 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package spotplacementscore_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"sigs.k8s.io/karpenter/pkg/test/v1alpha1"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	coreoptions "sigs.k8s.io/karpenter/pkg/operator/options"
+	coretest "sigs.k8s.io/karpenter/pkg/test"
+
+	"github.com/aws/karpenter-provider-aws/pkg/apis"
+	controllersspotplacementscore "github.com/aws/karpenter-provider-aws/pkg/controllers/providers/spotplacementscore"
+	"github.com/aws/karpenter-provider-aws/pkg/fake"
+	"github.com/aws/karpenter-provider-aws/pkg/operator/options"
+	"github.com/aws/karpenter-provider-aws/pkg/providers/spotplacementscore"
+	"github.com/aws/karpenter-provider-aws/pkg/test"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	. "sigs.k8s.io/karpenter/pkg/test/expectations"
+	. "sigs.k8s.io/karpenter/pkg/utils/testing"
+)
+
+var ctx context.Context
+var stop context.CancelFunc
+var env *coretest.Environment
+var awsEnv *test.Environment
+var controller *controllersspotplacementscore.Controller
+
+func TestAWS(t *testing.T) {
+	ctx = TestContextWithLogger(t)
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "SpotPlacementScore")
+}
+
+var _ = BeforeSuite(func() {
+	env = coretest.NewEnvironment(coretest.WithCRDs(apis.CRDs...), coretest.WithCRDs(v1alpha1.CRDs...))
+	ctx = coreoptions.ToContext(ctx, coretest.Options())
+	ctx = options.ToContext(ctx, test.Options())
+	ctx, stop = context.WithCancel(ctx)
+	awsEnv = test.NewEnvironment(ctx, env)
+	controller = controllersspotplacementscore.NewController(awsEnv.SpotPlacementScoreProvider)
+})
+
+var _ = AfterSuite(func() {
+	stop()
+	Expect(env.Stop()).To(Succeed(), "Failed to stop environment")
+})
+
+var _ = BeforeEach(func() {
+	ctx = coreoptions.ToContext(ctx, coretest.Options())
+	ctx = options.ToContext(ctx, test.Options())
+	awsEnv.Reset()
+	Expect(awsEnv.InstanceTypesProvider.UpdateInstanceTypes(ctx)).To(Succeed())
+	Expect(awsEnv.InstanceTypesProvider.UpdateInstanceTypeOfferings(ctx)).To(Succeed())
+})
+
+var _ = AfterEach(func() {
+	ExpectCleanedUp(ctx, env.Client)
+})
+
+var _ = Describe("SpotPlacementScore", func() {
+	It("should request scores for the sets of instance types that were requested", func() {
+		_, ok := awsEnv.SpotPlacementScoreProvider.Scores([]string{"m5.xlarge", "m5.large"})
+		Expect(ok).To(BeFalse())
+		result := ExpectSingletonReconciled(ctx, controller)
+		Expect(result.RequeueAfter).To(Equal(5 * time.Minute))
+
+		Expect(awsEnv.EC2API.GetSpotPlacementScoresBehavior.CalledWithInput.Len()).To(Equal(1))
+		input := awsEnv.EC2API.GetSpotPlacementScoresBehavior.CalledWithInput.Pop()
+		Expect(aws.ToInt32(input.TargetCapacity)).To(BeNumerically("==", 1))
+		Expect(aws.ToBool(input.SingleAvailabilityZone)).To(BeTrue())
+		Expect(input.RegionNames).To(ConsistOf(fake.DefaultRegion))
+		Expect(input.InstanceTypes).To(Equal([]string{"m5.large", "m5.xlarge"}))
+
+		scores, ok := awsEnv.SpotPlacementScoreProvider.Scores([]string{"m5.large", "m5.xlarge"})
+		Expect(ok).To(BeTrue())
+		Expect(scores).To(HaveKeyWithValue("tstz1-1a", int32(10)))
+	})
+	It("should not request scores when no sets of instance types were requested", func() {
+		ExpectSingletonReconciled(ctx, controller)
+		Expect(awsEnv.EC2API.GetSpotPlacementScoresBehavior.CalledWithInput.Len()).To(Equal(0))
+	})
+	It("should only know the scores of the sets of instance types that were queried", func() {
+		awsEnv.SpotPlacementScoreProvider.Scores([]string{"m5.large", "m5.xlarge"})
+		ExpectSingletonReconciled(ctx, controller)
+
+		_, ok := awsEnv.SpotPlacementScoreProvider.Scores([]string{"m5.large"})
+		Expect(ok).To(BeFalse())
+		_, ok = awsEnv.SpotPlacementScoreProvider.Scores([]string{"m5.large", "m5.xlarge", "c5.large"})
+		Expect(ok).To(BeFalse())
+	})
+	It("should publish scores as metrics", func() {
+		awsEnv.EC2API.GetSpotPlacementScoresBehavior.Output.Set(&ec2.GetSpotPlacementScoresOutput{
+			SpotPlacementScores: []ec2types.SpotPlacementScore{
+				{AvailabilityZoneId: aws.String("tstz1-1a"), Region: aws.String(fake.DefaultRegion), Score: aws.Int32(2)},
+				{AvailabilityZoneId: aws.String("tstz1-1b"), Region: aws.String(fake.DefaultRegion), Score: aws.Int32(7)},
+			},
+		})
+		awsEnv.SpotPlacementScoreProvider.Scores([]string{"m5.large", "c5.large"})
+		ExpectSingletonReconciled(ctx, controller)
+
+		ExpectMetricGaugeValue(spotplacementscore.SpotPlacementScore, 2, map[string]string{"instance_types": "c5.large,m5.large", "zone_id": "tstz1-1a"})
+		ExpectMetricGaugeValue(spotplacementscore.SpotPlacementScore, 7, map[string]string{"instance_types": "c5.large,m5.large", "zone_id": "tstz1-1b"})
+	})
+	It("should ignore scores for other regions", func() {
+		awsEnv.EC2API.GetSpotPlacementScoresBehavior.Output.Set(&ec2.GetSpotPlacementScoresOutput{
+			SpotPlacementScores: []ec2types.SpotPlacementScore{
+				{AvailabilityZoneId: aws.String("tstz1-1a"), Region: aws.String("us-east-1"), Score: aws.Int32(1)},
+			},
+		})
+		awsEnv.SpotPlacementScoreProvider.Scores([]string{"m5.large"})
+		ExpectSingletonReconciled(ctx, controller)
+
+		scores, ok := awsEnv.SpotPlacementScoreProvider.Scores([]string{"m5.large"})
+		Expect(ok).To(BeTrue())
+		Expect(scores).To(BeEmpty())
+	})
+	It("should fail when the spot placement score API fails", func() {
+		awsEnv.SpotPlacementScoreProvider.Scores([]string{"m5.large"})
+		awsEnv.EC2API.GetSpotPlacementScoresBehavior.Error.Set(fmt.Errorf("failed"), fake.MaxCalls(0))
+		_ = ExpectSingletonReconcileFailed(ctx, controller)
+
+		_, ok := awsEnv.SpotPlacementScoreProvider.Scores([]string{"m5.large"})
+		Expect(ok).To(BeFalse())
+	})
+	It("should keep the last known scores when the spot placement score API fails", func() {
+		awsEnv.SpotPlacementScoreProvider.Scores([]string{"m5.large"})
+		Expect(awsEnv.SpotPlacementScoreProvider.UpdateScores(ctx)).To(Succeed())
+		awsEnv.Clock.Step(spotplacementscore.RefreshInterval)
+		awsEnv.EC2API.GetSpotPlacementScoresBehavior.Error.Set(fmt.Errorf("failed"), fake.MaxCalls(0))
+		Expect(awsEnv.SpotPlacementScoreProvider.UpdateScores(ctx)).ToNot(Succeed())
+
+		scores, ok := awsEnv.SpotPlacementScoreProvider.Scores([]string{"m5.large"})
+		Expect(ok).To(BeTrue())
+		Expect(scores).To(HaveKeyWithValue("tstz1-1a", int32(10)))
+	})
+	It("should only refresh scores once they're stale", func() {
+		awsEnv.SpotPlacementScoreProvider.Scores([]string{"m5.large"})
+		Expect(awsEnv.SpotPlacementScoreProvider.UpdateScores(ctx)).To(Succeed())
+		Expect(awsEnv.SpotPlacementScoreProvider.UpdateScores(ctx)).To(Succeed())
+		Expect(awsEnv.EC2API.GetSpotPlacementScoresBehavior.CalledWithInput.Len()).To(Equal(1))
+
+		awsEnv.Clock.Step(spotplacementscore.RefreshInterval)
+		awsEnv.SpotPlacementScoreProvider.Scores([]string{"m5.large"})
+		Expect(awsEnv.SpotPlacementScoreProvider.UpdateScores(ctx)).To(Succeed())
+		Expect(awsEnv.EC2API.GetSpotPlacementScoresBehavior.CalledWithInput.Len()).To(Equal(2))
+	})
+	It("should not make more than the maximum number of queries within the query window", func() {
+		instanceTypes := []string{"m5.large", "m5.xlarge", "m5.2xlarge", "c5.large", "c5.xlarge", "r5.large", "t3.micro", "t3.small", "p3.8xlarge", "g4dn.xlarge", "inf1.xlarge", "m6g.large"}
+		for _, instanceType := range instanceTypes {
+			awsEnv.SpotPlacementScoreProvider.Scores([]string{instanceType})
+			awsEnv.Clock.Step(time.Second)
+		}
+		Expect(awsEnv.SpotPlacementScoreProvider.UpdateScores(ctx)).To(Succeed())
+		Expect(awsEnv.EC2API.GetSpotPlacementScoresBehavior.CalledWithInput.Len()).To(Equal(spotplacementscore.MaxQueries))
+		// The most recently requested sets are queried first
+		_, ok := awsEnv.SpotPlacementScoreProvider.Scores([]string{"m6g.large"})
+		Expect(ok).To(BeTrue())
+		_, ok = awsEnv.SpotPlacementScoreProvider.Scores([]string{"m5.large"})
+		Expect(ok).To(BeFalse())
+
+		Expect(awsEnv.SpotPlacementScoreProvider.UpdateScores(ctx)).To(Succeed())
+		Expect(awsEnv.EC2API.GetSpotPlacementScoresBehavior.CalledWithInput.Len()).To(Equal(spotplacementscore.MaxQueries))
+		awsEnv.Clock.Step(spotplacementscore.QueryWindow)
+		Expect(awsEnv.SpotPlacementScoreProvider.UpdateScores(ctx)).To(Succeed())
+		Expect(awsEnv.EC2API.GetSpotPlacementScoresBehavior.CalledWithInput.Len()).To(BeNumerically(">", spotplacementscore.MaxQueries))
+	})
+})
@@ -25,6 +25,9 @@ import (
 
 const (
 	launchTemplateNameNotFoundCode = "InvalidLaunchTemplateName.NotFoundException"
+	dryRunOperationCode            = "DryRunOperation"
+	insufficientCidrBlocksCode     = "InsufficientCidrBlocks"
+	placementGroupInUseCode        = "InvalidPlacementGroup.InUse"
 )
 
 var (
@@ -35,10 +38,17 @@ var (
 		"InvalidLaunchTemplateId.NotFound",
 		"QueueDoesNotExist",
 		"NoSuchEntity",
+		"InvalidPlacementGroup.Unknown",
 	)
 	alreadyExistsErrorCodes = sets.New[string](
 		"EntityAlreadyExists",
 	)
+	unauthorizedErrorCodes = sets.New[string](
+		"UnauthorizedOperation",
+		"AccessDenied",
+		"AccessDeniedException",
+		"AuthFailure",
+	)
 
 	// unfulfillableCapacityErrorCodes signify that capacity is temporarily unable to be launched
 	unfulfillableCapacityErrorCodes = sets.New[string](
@@ -48,6 +58,7 @@ var (
 		"UnfulfillableCapacity",
 		"Unsupported",
 		"InsufficientFreeAddressesInSubnet",
+		"ReservationCapacityExceeded",
 	)
 )
 
@@ -97,6 +108,25 @@ func IsUnfulfillableCapacity(err ec2types.CreateFleetError) bool {
 	return unfulfillableCapacityErrorCodes.Has(*err.ErrorCode)
 }
 
+// IsInsufficientCidrBlocks returns true if the Fleet err means that the subnet
+// doesn't have a contiguous /28 prefix available for prefix delegation
+func IsInsufficientCidrBlocks(err ec2types.CreateFleetError) bool {
+	return *err.ErrorCode == insufficientCidrBlocksCode
+}
+
+// IsPlacementGroupInUse returns true if the err means that a placement group
+// can't be deleted because it still has instances
+func IsPlacementGroupInUse(err error) bool {
+	if err == nil {
+		return false
+	}
+	var apiErr smithy.APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.ErrorCode() == placementGroupInUseCode
+	}
+	return false
+}
+
 func IsLaunchTemplateNotFound(err error) bool {
 	if err == nil {
 		return false
@@ -108,6 +138,31 @@ func IsLaunchTemplateNotFound(err error) bool {
 	return false
 }
 
+// IsDryRunError returns true if the err is the error that EC2 returns when a DryRun request would have succeeded
+func IsDryRunError(err error) bool {
+	if err == nil {
+		return false
+	}
+	var apiErr smithy.APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.ErrorCode() == dryRunOperationCode
+	}
+	return false
+}
+
+// IsUnauthorizedOperation returns true if the err is an AWS error (even if it's wrapped) that indicates the caller isn't
+// permitted to perform the operation, whether due to an identity-based policy, a permissions boundary or an SCP
+func IsUnauthorizedOperation(err error) bool {
+	if err == nil {
+		return false
+	}
+	var apiErr smithy.APIError
+	if errors.As(err, &apiErr) {
+		return unauthorizedErrorCodes.Has(apiErr.ErrorCode()) || strings.HasPrefix(apiErr.ErrorCode(), "AuthFailure.")
+	}
+	return false
+}
+
 // ToReasonMessage converts an error message from AWS into a well-known condition reason
 // and well-known condition message that can be used for Launch failure classification
 // nolint:gocyclo
@@ -156,5 +211,8 @@ func ToReasonMessage(err error) (string, string) {
 	if strings.Contains(err.Error(), "InsufficientFreeAddressesInSubnet") {
 		return "InsufficientFreeAddressesInSubnet", "There are not enough free IP addresses to launch an instance in this subnet"
 	}
+	if strings.Contains(err.Error(), "ReservationCapacityExceeded") {
+		return "ReservationCapacityExceeded", "There is not enough remaining capacity in the capacity reservation to launch an instance"
+	}
 	return "LaunchFailed", "Instance launch failed"
 }
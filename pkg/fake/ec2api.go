@@ -46,25 +46,38 @@ type CapacityPool struct {
 // EC2Behavior must be reset between tests otherwise tests will
 // pollute each other.
 type EC2Behavior struct {
-	DescribeImagesOutput                AtomicPtr[ec2.DescribeImagesOutput]
-	DescribeLaunchTemplatesOutput       AtomicPtr[ec2.DescribeLaunchTemplatesOutput]
-	DescribeSubnetsOutput               AtomicPtr[ec2.DescribeSubnetsOutput]
-	DescribeSecurityGroupsOutput        AtomicPtr[ec2.DescribeSecurityGroupsOutput]
-	DescribeInstanceTypesOutput         AtomicPtr[ec2.DescribeInstanceTypesOutput]
-	DescribeInstanceTypeOfferingsOutput AtomicPtr[ec2.DescribeInstanceTypeOfferingsOutput]
-	DescribeAvailabilityZonesOutput     AtomicPtr[ec2.DescribeAvailabilityZonesOutput]
-	DescribeSpotPriceHistoryInput       AtomicPtr[ec2.DescribeSpotPriceHistoryInput]
-	DescribeSpotPriceHistoryOutput      AtomicPtr[ec2.DescribeSpotPriceHistoryOutput]
-	CreateFleetBehavior                 MockedFunction[ec2.CreateFleetInput, ec2.CreateFleetOutput]
-	TerminateInstancesBehavior          MockedFunction[ec2.TerminateInstancesInput, ec2.TerminateInstancesOutput]
-	DescribeInstancesBehavior           MockedFunction[ec2.DescribeInstancesInput, ec2.DescribeInstancesOutput]
-	CreateTagsBehavior                  MockedFunction[ec2.CreateTagsInput, ec2.CreateTagsOutput]
-	CalledWithCreateLaunchTemplateInput AtomicPtrSlice[ec2.CreateLaunchTemplateInput]
-	CalledWithDescribeImagesInput       AtomicPtrSlice[ec2.DescribeImagesInput]
-	Instances                           sync.Map
-	LaunchTemplates                     sync.Map
-	InsufficientCapacityPools           atomic.Slice[CapacityPool]
-	NextError                           AtomicError
+	DescribeImagesOutput                 AtomicPtr[ec2.DescribeImagesOutput]
+	DescribeLaunchTemplatesOutput        AtomicPtr[ec2.DescribeLaunchTemplatesOutput]
+	DescribeSubnetsOutput                AtomicPtr[ec2.DescribeSubnetsOutput]
+	DescribeSecurityGroupsOutput         AtomicPtr[ec2.DescribeSecurityGroupsOutput]
+	DescribeInstanceTypesOutput          AtomicPtr[ec2.DescribeInstanceTypesOutput]
+	DescribeInstanceTypeOfferingsOutput  AtomicPtr[ec2.DescribeInstanceTypeOfferingsOutput]
+	DescribeAvailabilityZonesOutput      AtomicPtr[ec2.DescribeAvailabilityZonesOutput]
+	DescribeSpotPriceHistoryInput        AtomicPtr[ec2.DescribeSpotPriceHistoryInput]
+	DescribeSpotPriceHistoryOutput       AtomicPtr[ec2.DescribeSpotPriceHistoryOutput]
+	DescribeCapacityReservationsOutput   AtomicPtr[ec2.DescribeCapacityReservationsOutput]
+	DescribeHostsOutput                  AtomicPtr[ec2.DescribeHostsOutput]
+	CreateFleetBehavior                  MockedFunction[ec2.CreateFleetInput, ec2.CreateFleetOutput]
+	TerminateInstancesBehavior           MockedFunction[ec2.TerminateInstancesInput, ec2.TerminateInstancesOutput]
+	StartInstancesBehavior               MockedFunction[ec2.StartInstancesInput, ec2.StartInstancesOutput]
+	StopInstancesBehavior                MockedFunction[ec2.StopInstancesInput, ec2.StopInstancesOutput]
+	DescribeInstancesBehavior            MockedFunction[ec2.DescribeInstancesInput, ec2.DescribeInstancesOutput]
+	DescribeInstanceStatusBehavior       MockedFunction[ec2.DescribeInstanceStatusInput, ec2.DescribeInstanceStatusOutput]
+	DescribeSpotInstanceRequestsBehavior MockedFunction[ec2.DescribeSpotInstanceRequestsInput, ec2.DescribeSpotInstanceRequestsOutput]
+	CreateTagsBehavior                   MockedFunction[ec2.CreateTagsInput, ec2.CreateTagsOutput]
+	DeleteTagsBehavior                   MockedFunction[ec2.DeleteTagsInput, ec2.DeleteTagsOutput]
+	GetSpotPlacementScoresBehavior       MockedFunction[ec2.GetSpotPlacementScoresInput, ec2.GetSpotPlacementScoresOutput]
+	CalledWithCreateLaunchTemplateInput  AtomicPtrSlice[ec2.CreateLaunchTemplateInput]
+	CalledWithDescribeImagesInput        AtomicPtrSlice[ec2.DescribeImagesInput]
+	CalledWithDryRunCreateFleetInput     AtomicPtrSlice[ec2.CreateFleetInput]
+	Instances                            sync.Map
+	LaunchTemplates                      sync.Map
+	PlacementGroups                      sync.Map
+	InsufficientCapacityPools            atomic.Slice[CapacityPool]
+	// DryRunErrors maps the names of actions (e.g. "CreateFleet") to the errors returned by DryRun requests for them.
+	// DryRun requests for actions without an error succeed.
+	DryRunErrors sync.Map
+	NextError    AtomicError
 }
 
 type EC2API struct {
@@ -91,11 +104,20 @@ func (e *EC2API) Reset() {
 	e.DescribeAvailabilityZonesOutput.Reset()
 	e.CreateFleetBehavior.Reset()
 	e.TerminateInstancesBehavior.Reset()
+	e.StartInstancesBehavior.Reset()
+	e.StopInstancesBehavior.Reset()
+	e.DeleteTagsBehavior.Reset()
 	e.DescribeInstancesBehavior.Reset()
+	e.DescribeInstanceStatusBehavior.Reset()
+	e.DescribeSpotInstanceRequestsBehavior.Reset()
+	e.GetSpotPlacementScoresBehavior.Reset()
 	e.CalledWithCreateLaunchTemplateInput.Reset()
 	e.CalledWithDescribeImagesInput.Reset()
+	e.CalledWithDryRunCreateFleetInput.Reset()
 	e.DescribeSpotPriceHistoryInput.Reset()
 	e.DescribeSpotPriceHistoryOutput.Reset()
+	e.DescribeCapacityReservationsOutput.Reset()
+	e.DescribeHostsOutput.Reset()
 	e.Instances.Range(func(k, v any) bool {
 		e.Instances.Delete(k)
 		return true
@@ -104,18 +126,47 @@ func (e *EC2API) Reset() {
 		e.LaunchTemplates.Delete(k)
 		return true
 	})
+	e.PlacementGroups.Range(func(k, v any) bool {
+		e.PlacementGroups.Delete(k)
+		return true
+	})
+	e.DryRunErrors.Range(func(k, v any) bool {
+		e.DryRunErrors.Delete(k)
+		return true
+	})
 	e.InsufficientCapacityPools.Reset()
 	e.NextError.Reset()
 }
 
+// dryRun returns the error that EC2 responds to a DryRun request for the action with
+func (e *EC2API) dryRun(action string) error {
+	if err, ok := e.DryRunErrors.Load(action); ok {
+		return err.(error)
+	}
+	return &smithy.GenericAPIError{Code: "DryRunOperation", Message: "Request would have succeeded, but DryRun flag is set."}
+}
+
+func (e *EC2API) RunInstances(_ context.Context, input *ec2.RunInstancesInput, _ ...func(*ec2.Options)) (*ec2.RunInstancesOutput, error) {
+	if lo.FromPtr(input.DryRun) {
+		return nil, e.dryRun("RunInstances")
+	}
+	// instances are only launched through CreateFleet
+	return nil, fmt.Errorf("RunInstances is only supported for DryRun requests")
+}
+
 // nolint: gocyclo
 func (e *EC2API) CreateFleet(_ context.Context, input *ec2.CreateFleetInput, _ ...func(*ec2.Options)) (*ec2.CreateFleetOutput, error) {
+	if lo.FromPtr(input.DryRun) {
+		e.CalledWithDryRunCreateFleetInput.Add(input)
+		return nil, e.dryRun("CreateFleet")
+	}
 	return e.CreateFleetBehavior.Invoke(input, func(input *ec2.CreateFleetInput) (*ec2.CreateFleetOutput, error) {
 		if input.LaunchTemplateConfigs[0].LaunchTemplateSpecification.LaunchTemplateName == nil {
 			return nil, fmt.Errorf("missing launch template name")
 		}
 		var instanceIds []string
 		var skippedPools []CapacityPool
+		var skippedPoolLaunchTemplates []*string
 		var spotInstanceRequestID *string
 
 		if string(input.TargetCapacitySpecification.DefaultTargetCapacityType) == karpv1.CapacityTypeSpot {
@@ -131,6 +182,7 @@ func (e *EC2API) CreateFleet(_ context.Context, input *ec2.CreateFleetInput, _ .
 						pool.Zone == aws.ToString(override.AvailabilityZone) &&
 						pool.CapacityType == string(input.TargetCapacitySpecification.DefaultTargetCapacityType) {
 						skippedPools = append(skippedPools, pool)
+						skippedPoolLaunchTemplates = append(skippedPoolLaunchTemplates, ltc.LaunchTemplateSpecification.LaunchTemplateName)
 						skipInstance = true
 						return false
 					}
@@ -140,9 +192,16 @@ func (e *EC2API) CreateFleet(_ context.Context, input *ec2.CreateFleetInput, _ .
 					continue
 				}
 				amiID := aws.String("")
+				placement := &ec2types.Placement{AvailabilityZone: input.LaunchTemplateConfigs[0].Overrides[0].AvailabilityZone}
 				if e.CalledWithCreateLaunchTemplateInput.Len() > 0 {
 					lt := e.CalledWithCreateLaunchTemplateInput.Pop()
 					amiID = lt.LaunchTemplateData.ImageId
+					if lt.LaunchTemplateData.Placement != nil {
+						placement.GroupId = lt.LaunchTemplateData.Placement.GroupId
+						if pg, ok := e.PlacementGroups.Load(aws.ToString(placement.GroupId)); ok && pg.(ec2types.PlacementGroup).Strategy == ec2types.PlacementStrategyPartition {
+							placement.PartitionNumber = aws.Int32(1)
+						}
+					}
 					e.CalledWithCreateLaunchTemplateInput.Add(lt)
 				}
 				instanceState := ec2types.InstanceStateNameRunning
@@ -150,13 +209,17 @@ func (e *EC2API) CreateFleet(_ context.Context, input *ec2.CreateFleetInput, _ .
 					instance := ec2types.Instance{
 						ImageId:               aws.String(*amiID),
 						InstanceId:            aws.String(test.RandomName()),
-						Placement:             &ec2types.Placement{AvailabilityZone: input.LaunchTemplateConfigs[0].Overrides[0].AvailabilityZone},
+						LaunchTime:            aws.Time(time.Now()),
+						Placement:             placement,
 						PrivateDnsName:        aws.String(randomdata.IpV4Address()),
 						InstanceType:          input.LaunchTemplateConfigs[0].Overrides[0].InstanceType,
 						SpotInstanceRequestId: spotInstanceRequestID,
 						State: &ec2types.InstanceState{
 							Name: instanceState,
 						},
+						Tags: lo.FlatMap(input.TagSpecifications, func(ts ec2types.TagSpecification, _ int) []ec2types.Tag {
+							return lo.Ternary(ts.ResourceType == ec2types.ResourceTypeInstance, ts.Tags, nil)
+						}),
 					}
 					e.Instances.Store(*instance.InstanceId, instance)
 					instanceIds = append(instanceIds, *instance.InstanceId)
@@ -172,6 +235,9 @@ func (e *EC2API) CreateFleet(_ context.Context, input *ec2.CreateFleetInput, _ .
 				InstanceType: input.LaunchTemplateConfigs[0].Overrides[0].InstanceType,
 				Lifecycle:    ec2types.InstanceLifecycle(input.TargetCapacitySpecification.DefaultTargetCapacityType),
 				LaunchTemplateAndOverrides: &ec2types.LaunchTemplateAndOverridesResponse{
+					LaunchTemplateSpecification: &ec2types.FleetLaunchTemplateSpecification{
+						LaunchTemplateName: input.LaunchTemplateConfigs[0].LaunchTemplateSpecification.LaunchTemplateName,
+					},
 					Overrides: &ec2types.FleetLaunchTemplateOverrides{
 						SubnetId:         input.LaunchTemplateConfigs[0].Overrides[0].SubnetId,
 						ImageId:          input.LaunchTemplateConfigs[0].Overrides[0].ImageId,
@@ -181,10 +247,13 @@ func (e *EC2API) CreateFleet(_ context.Context, input *ec2.CreateFleetInput, _ .
 				},
 			},
 		}}
-		for _, pool := range skippedPools {
+		for i, pool := range skippedPools {
 			result.Errors = append(result.Errors, ec2types.CreateFleetError{
 				ErrorCode: aws.String("InsufficientInstanceCapacity"),
 				LaunchTemplateAndOverrides: &ec2types.LaunchTemplateAndOverridesResponse{
+					LaunchTemplateSpecification: &ec2types.FleetLaunchTemplateSpecification{
+						LaunchTemplateName: skippedPoolLaunchTemplates[i],
+					},
 					Overrides: &ec2types.FleetLaunchTemplateOverrides{
 						InstanceType:     ec2types.InstanceType(pool.InstanceType),
 						AvailabilityZone: aws.String(pool.Zone),
@@ -212,7 +281,42 @@ func (e *EC2API) TerminateInstances(_ context.Context, input *ec2.TerminateInsta
 	})
 }
 
+func (e *EC2API) StartInstances(_ context.Context, input *ec2.StartInstancesInput, _ ...func(*ec2.Options)) (*ec2.StartInstancesOutput, error) {
+	return e.StartInstancesBehavior.Invoke(input, func(input *ec2.StartInstancesInput) (*ec2.StartInstancesOutput, error) {
+		return &ec2.StartInstancesOutput{StartingInstances: e.setInstanceStates(input.InstanceIds, ec2types.InstanceStateNamePending)}, nil
+	})
+}
+
+func (e *EC2API) StopInstances(_ context.Context, input *ec2.StopInstancesInput, _ ...func(*ec2.Options)) (*ec2.StopInstancesOutput, error) {
+	return e.StopInstancesBehavior.Invoke(input, func(input *ec2.StopInstancesInput) (*ec2.StopInstancesOutput, error) {
+		return &ec2.StopInstancesOutput{StoppingInstances: e.setInstanceStates(input.InstanceIds, ec2types.InstanceStateNameStopped)}, nil
+	})
+}
+
+// setInstanceStates transitions the stored instances to the state and returns their state changes
+func (e *EC2API) setInstanceStates(ids []string, state ec2types.InstanceStateName) []ec2types.InstanceStateChange {
+	var instanceStateChanges []ec2types.InstanceStateChange
+	for _, id := range ids {
+		raw, ok := e.Instances.Load(id)
+		if !ok {
+			continue
+		}
+		instance := raw.(ec2types.Instance)
+		instanceStateChanges = append(instanceStateChanges, ec2types.InstanceStateChange{
+			PreviousState: instance.State,
+			CurrentState:  &ec2types.InstanceState{Name: state},
+			InstanceId:    aws.String(id),
+		})
+		instance.State = &ec2types.InstanceState{Name: state}
+		e.Instances.Store(id, instance)
+	}
+	return instanceStateChanges
+}
+
 func (e *EC2API) CreateLaunchTemplate(_ context.Context, input *ec2.CreateLaunchTemplateInput, _ ...func(*ec2.Options)) (*ec2.CreateLaunchTemplateOutput, error) {
+	if lo.FromPtr(input.DryRun) {
+		return nil, e.dryRun("CreateLaunchTemplate")
+	}
 	if !e.NextError.IsNil() {
 		defer e.NextError.Reset()
 		return nil, e.NextError.Get()
@@ -247,6 +351,23 @@ func (e *EC2API) CreateTags(_ context.Context, input *ec2.CreateTagsInput, _ ...
 	})
 }
 
+func (e *EC2API) DeleteTags(_ context.Context, input *ec2.DeleteTagsInput, _ ...func(*ec2.Options)) (*ec2.DeleteTagsOutput, error) {
+	return e.DeleteTagsBehavior.Invoke(input, func(input *ec2.DeleteTagsInput) (*ec2.DeleteTagsOutput, error) {
+		for _, id := range input.Resources {
+			raw, ok := e.Instances.Load(id)
+			if !ok {
+				return nil, fmt.Errorf("instance with id '%s' does not exist", id)
+			}
+			instance := raw.(ec2types.Instance)
+			instance.Tags = lo.Reject(instance.Tags, func(t ec2types.Tag, _ int) bool {
+				return lo.ContainsBy(input.Tags, func(deleted ec2types.Tag) bool { return aws.ToString(deleted.Key) == aws.ToString(t.Key) })
+			})
+			e.Instances.Swap(id, instance)
+		}
+		return &ec2.DeleteTagsOutput{}, nil
+	})
+}
+
 func (e *EC2API) DescribeInstances(_ context.Context, input *ec2.DescribeInstancesInput, _ ...func(*ec2.Options)) (*ec2.DescribeInstancesOutput, error) {
 	return e.DescribeInstancesBehavior.Invoke(input, func(input *ec2.DescribeInstancesInput) (*ec2.DescribeInstancesOutput, error) {
 		var instances []ec2types.Instance
@@ -271,6 +392,18 @@ func (e *EC2API) DescribeInstances(_ context.Context, input *ec2.DescribeInstanc
 	})
 }
 
+func (e *EC2API) DescribeInstanceStatus(_ context.Context, input *ec2.DescribeInstanceStatusInput, _ ...func(*ec2.Options)) (*ec2.DescribeInstanceStatusOutput, error) {
+	return e.DescribeInstanceStatusBehavior.Invoke(input, func(_ *ec2.DescribeInstanceStatusInput) (*ec2.DescribeInstanceStatusOutput, error) {
+		return &ec2.DescribeInstanceStatusOutput{}, nil
+	})
+}
+
+func (e *EC2API) DescribeSpotInstanceRequests(_ context.Context, input *ec2.DescribeSpotInstanceRequestsInput, _ ...func(*ec2.Options)) (*ec2.DescribeSpotInstanceRequestsOutput, error) {
+	return e.DescribeSpotInstanceRequestsBehavior.Invoke(input, func(_ *ec2.DescribeSpotInstanceRequestsInput) (*ec2.DescribeSpotInstanceRequestsOutput, error) {
+		return &ec2.DescribeSpotInstanceRequestsOutput{}, nil
+	})
+}
+
 //nolint:gocyclo
 func filterInstances(instances []ec2types.Instance, filters []ec2types.Filter) []ec2types.Instance {
 	var ret []ec2types.Instance
@@ -380,6 +513,63 @@ func (e *EC2API) DeleteLaunchTemplate(_ context.Context, input *ec2.DeleteLaunch
 	return nil, nil
 }
 
+func (e *EC2API) DescribePlacementGroups(_ context.Context, input *ec2.DescribePlacementGroupsInput, _ ...func(*ec2.Options)) (*ec2.DescribePlacementGroupsOutput, error) {
+	if !e.NextError.IsNil() {
+		defer e.NextError.Reset()
+		return nil, e.NextError.Get()
+	}
+	output := &ec2.DescribePlacementGroupsOutput{}
+	e.PlacementGroups.Range(func(_, value any) bool {
+		placementGroup := value.(ec2types.PlacementGroup)
+		if lo.Contains(input.GroupNames, aws.ToString(placementGroup.GroupName)) || lo.Contains(input.GroupIds, aws.ToString(placementGroup.GroupId)) ||
+			(len(input.Filters) != 0 && Filter(input.Filters, aws.ToString(placementGroup.GroupId), aws.ToString(placementGroup.GroupName), placementGroup.Tags)) {
+			output.PlacementGroups = append(output.PlacementGroups, placementGroup)
+		}
+		return true
+	})
+	// Placement groups that are requested by name or id must exist, while filters may not match any placement groups
+	if len(output.PlacementGroups) == 0 && (len(input.GroupNames) != 0 || len(input.GroupIds) != 0) {
+		return nil, &smithy.GenericAPIError{
+			Code:    "InvalidPlacementGroup.Unknown",
+			Message: "The specified placement group does not exist.",
+		}
+	}
+	return output, nil
+}
+
+func (e *EC2API) CreatePlacementGroup(_ context.Context, input *ec2.CreatePlacementGroupInput, _ ...func(*ec2.Options)) (*ec2.CreatePlacementGroupOutput, error) {
+	if !e.NextError.IsNil() {
+		defer e.NextError.Reset()
+		return nil, e.NextError.Get()
+	}
+	placementGroup := ec2types.PlacementGroup{
+		GroupId:        aws.String(fmt.Sprintf("pg-%s", randomdata.Alphanumeric(17))),
+		GroupName:      input.GroupName,
+		Strategy:       input.Strategy,
+		PartitionCount: input.PartitionCount,
+		State:          ec2types.PlacementGroupStateAvailable,
+		Tags: lo.FlatMap(input.TagSpecifications, func(ts ec2types.TagSpecification, _ int) []ec2types.Tag {
+			return ts.Tags
+		}),
+	}
+	e.PlacementGroups.Store(aws.ToString(placementGroup.GroupId), placementGroup)
+	return &ec2.CreatePlacementGroupOutput{PlacementGroup: lo.ToPtr(placementGroup)}, nil
+}
+
+func (e *EC2API) DeletePlacementGroup(_ context.Context, input *ec2.DeletePlacementGroupInput, _ ...func(*ec2.Options)) (*ec2.DeletePlacementGroupOutput, error) {
+	if !e.NextError.IsNil() {
+		defer e.NextError.Reset()
+		return nil, e.NextError.Get()
+	}
+	e.PlacementGroups.Range(func(key, value any) bool {
+		if aws.ToString(value.(ec2types.PlacementGroup).GroupName) == aws.ToString(input.GroupName) {
+			e.PlacementGroups.Delete(key)
+		}
+		return true
+	})
+	return &ec2.DeletePlacementGroupOutput{}, nil
+}
+
 func (e *EC2API) DescribeSubnets(_ context.Context, input *ec2.DescribeSubnetsInput, _ ...func(*ec2.Options)) (*ec2.DescribeSubnetsOutput, error) {
 	if !e.NextError.IsNil() {
 		defer e.NextError.Reset()
@@ -484,6 +674,53 @@ func (e *EC2API) DescribeSecurityGroups(_ context.Context, input *ec2.DescribeSe
 	return &ec2.DescribeSecurityGroupsOutput{SecurityGroups: FilterDescribeSecurtyGroups(sgs, input.Filters)}, nil
 }
 
+func (e *EC2API) DescribeCapacityReservations(_ context.Context, input *ec2.DescribeCapacityReservationsInput, _ ...func(*ec2.Options)) (*ec2.DescribeCapacityReservationsOutput, error) {
+	if !e.NextError.IsNil() {
+		defer e.NextError.Reset()
+		return nil, e.NextError.Get()
+	}
+	if e.DescribeCapacityReservationsOutput.IsNil() {
+		return &ec2.DescribeCapacityReservationsOutput{}, nil
+	}
+	return &ec2.DescribeCapacityReservationsOutput{
+		CapacityReservations: FilterDescribeCapacityReservations(e.DescribeCapacityReservationsOutput.Clone().CapacityReservations, input.CapacityReservationIds, input.Filters),
+	}, nil
+}
+
+func (e *EC2API) DescribeHosts(_ context.Context, input *ec2.DescribeHostsInput, _ ...func(*ec2.Options)) (*ec2.DescribeHostsOutput, error) {
+	if !e.NextError.IsNil() {
+		defer e.NextError.Reset()
+		return nil, e.NextError.Get()
+	}
+	if e.DescribeHostsOutput.IsNil() {
+		return &ec2.DescribeHostsOutput{}, nil
+	}
+	return &ec2.DescribeHostsOutput{
+		Hosts: FilterDescribeHosts(e.DescribeHostsOutput.Clone().Hosts, input.HostIds, input.Filter),
+	}, nil
+}
+
+func (e *EC2API) GetSpotPlacementScores(_ context.Context, input *ec2.GetSpotPlacementScoresInput, _ ...func(*ec2.Options)) (*ec2.GetSpotPlacementScoresOutput, error) {
+	if !e.NextError.IsNil() {
+		defer e.NextError.Reset()
+		return nil, e.NextError.Get()
+	}
+	return e.GetSpotPlacementScoresBehavior.Invoke(input, func(input *ec2.GetSpotPlacementScoresInput) (*ec2.GetSpotPlacementScoresOutput, error) {
+		// Return the highest score for every zone in each of the requested regions
+		return &ec2.GetSpotPlacementScoresOutput{
+			SpotPlacementScores: lo.FlatMap(input.RegionNames, func(region string, _ int) []ec2types.SpotPlacementScore {
+				return lo.Map([]string{"tstz1-1a", "tstz1-1b", "tstz1-1c", "tstz1-1alocal"}, func(zoneID string, _ int) ec2types.SpotPlacementScore {
+					return ec2types.SpotPlacementScore{
+						AvailabilityZoneId: aws.String(zoneID),
+						Region:             aws.String(region),
+						Score:              aws.Int32(10),
+					}
+				})
+			}),
+		}, nil
+	})
+}
+
 func (e *EC2API) DescribeAvailabilityZones(context.Context, *ec2.DescribeAvailabilityZonesInput, ...func(*ec2.Options)) (*ec2.DescribeAvailabilityZonesOutput, error) {
 	if !e.NextError.IsNil() {
 		defer e.NextError.Reset()
@@ -496,7 +733,7 @@ func (e *EC2API) DescribeAvailabilityZones(context.Context, *ec2.DescribeAvailab
 		{ZoneName: aws.String("test-zone-1a"), ZoneId: aws.String("tstz1-1a"), ZoneType: aws.String("availability-zone")},
 		{ZoneName: aws.String("test-zone-1b"), ZoneId: aws.String("tstz1-1b"), ZoneType: aws.String("availability-zone")},
 		{ZoneName: aws.String("test-zone-1c"), ZoneId: aws.String("tstz1-1c"), ZoneType: aws.String("availability-zone")},
-		{ZoneName: aws.String("test-zone-1a-local"), ZoneId: aws.String("tstz1-1alocal"), ZoneType: aws.String("local-zone")},
+		{ZoneName: aws.String("test-zone-1a-local"), ZoneId: aws.String("tstz1-1alocal"), ZoneType: aws.String("local-zone"), ParentZoneName: aws.String("test-zone-1a"), ParentZoneId: aws.String("tstz1-1a")},
 	}}, nil
 }
 
@@ -511,14 +748,23 @@ func (e *EC2API) DescribeInstanceTypes(_ context.Context, _ *ec2.DescribeInstanc
 	return defaultDescribeInstanceTypesOutput, nil
 }
 
-func (e *EC2API) DescribeInstanceTypeOfferings(_ context.Context, _ *ec2.DescribeInstanceTypeOfferingsInput, _ ...func(*ec2.Options)) (*ec2.DescribeInstanceTypeOfferingsOutput, error) {
+func (e *EC2API) DescribeInstanceTypeOfferings(_ context.Context, input *ec2.DescribeInstanceTypeOfferingsInput, _ ...func(*ec2.Options)) (*ec2.DescribeInstanceTypeOfferingsOutput, error) {
 	if !e.NextError.IsNil() {
 		defer e.NextError.Reset()
 		return nil, e.NextError.Get()
 	}
+	output := defaultDescribeInstanceTypeOfferingsOutput()
 	if !e.DescribeInstanceTypeOfferingsOutput.IsNil() {
-		return e.DescribeInstanceTypeOfferingsOutput.Clone(), nil
+		output = e.DescribeInstanceTypeOfferingsOutput.Clone()
 	}
+	// Outpost offerings are located by the Outpost's ARN rather than by a zone name
+	output.InstanceTypeOfferings = lo.Filter(output.InstanceTypeOfferings, func(o ec2types.InstanceTypeOffering, _ int) bool {
+		return (input.LocationType == ec2types.LocationTypeOutpost) == strings.HasPrefix(aws.ToString(o.Location), "arn:")
+	})
+	return output, nil
+}
+
+func defaultDescribeInstanceTypeOfferingsOutput() *ec2.DescribeInstanceTypeOfferingsOutput {
 	return &ec2.DescribeInstanceTypeOfferingsOutput{
 		InstanceTypeOfferings: []ec2types.InstanceTypeOffering{
 			{
@@ -638,7 +884,7 @@ func (e *EC2API) DescribeInstanceTypeOfferings(_ context.Context, _ *ec2.Describ
 				Location:     aws.String("test-zone-1c"),
 			},
 		},
-	}, nil
+	}
 }
 
 func (e *EC2API) DescribeSpotPriceHistory(_ context.Context, input *ec2.DescribeSpotPriceHistoryInput, _ ...func(*ec2.Options)) (*ec2.DescribeSpotPriceHistoryOutput, error) {
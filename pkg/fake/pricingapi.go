@@ -21,6 +21,8 @@ import (
 	"fmt"
 
 	"github.com/aws/aws-sdk-go-v2/service/pricing"
+	pricingtypes "github.com/aws/aws-sdk-go-v2/service/pricing/types"
+	"github.com/samber/lo"
 
 	sdk "github.com/aws/karpenter-provider-aws/pkg/aws"
 )
@@ -32,17 +34,36 @@ type PricingAPI struct {
 type PricingBehavior struct {
 	NextError         AtomicError
 	GetProductsOutput AtomicPtr[pricing.GetProductsOutput]
+	// GetProductsOutputByRegionCode overrides GetProductsOutput for requests that filter on a matching region code
+	GetProductsOutputByRegionCode AtomicPtr[map[string]pricing.GetProductsOutput]
+	// GetProductsOutputByOperatingSystem overrides GetProductsOutput and GetProductsOutputByRegionCode for requests that
+	// filter on a matching operating system
+	GetProductsOutputByOperatingSystem AtomicPtr[map[string]pricing.GetProductsOutput]
 }
 
 func (p *PricingAPI) Reset() {
 	p.NextError.Reset()
 	p.GetProductsOutput.Reset()
+	p.GetProductsOutputByRegionCode.Reset()
+	p.GetProductsOutputByOperatingSystem.Reset()
 }
 
-func (p *PricingAPI) GetProducts(_ context.Context, _ *pricing.GetProductsInput, _ ...func(*pricing.Options)) (*pricing.GetProductsOutput, error) {
+func (p *PricingAPI) GetProducts(_ context.Context, input *pricing.GetProductsInput, _ ...func(*pricing.Options)) (*pricing.GetProductsOutput, error) {
 	if !p.NextError.IsNil() {
 		return &pricing.GetProductsOutput{}, p.NextError.Get()
 	}
+	if !p.GetProductsOutputByOperatingSystem.IsNil() {
+		operatingSystem, _ := lo.Find(input.Filters, func(f pricingtypes.Filter) bool { return lo.FromPtr(f.Field) == "operatingSystem" })
+		if output, ok := (*p.GetProductsOutputByOperatingSystem.Clone())[lo.FromPtr(operatingSystem.Value)]; ok {
+			return &output, nil
+		}
+	}
+	if !p.GetProductsOutputByRegionCode.IsNil() {
+		regionCode, _ := lo.Find(input.Filters, func(f pricingtypes.Filter) bool { return lo.FromPtr(f.Field) == "regionCode" })
+		if output, ok := (*p.GetProductsOutputByRegionCode.Clone())[lo.FromPtr(regionCode.Value)]; ok {
+			return &output, nil
+		}
+	}
 	if !p.GetProductsOutput.IsNil() {
 		return p.GetProductsOutput.Clone(), nil
 	}
@@ -0,0 +1,62 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package fake
+
+import (
+	"context"
+
+	"github.com/aws/aws-sdk-go-v2/service/servicequotas"
+	servicequotastypes "github.com/aws/aws-sdk-go-v2/service/servicequotas/types"
+	"github.com/samber/lo"
+
+	sdk "github.com/aws/karpenter-provider-aws/pkg/aws"
+)
+
+// ServiceQuotasAPIBehavior must be reset between tests otherwise tests will
+// pollute each other.
+type ServiceQuotasAPIBehavior struct {
+	ListServiceQuotasBehavior MockedFunction[servicequotas.ListServiceQuotasInput, servicequotas.ListServiceQuotasOutput]
+}
+
+type ServiceQuotasAPI struct {
+	sdk.ServiceQuotasAPI
+	ServiceQuotasAPIBehavior
+}
+
+func NewServiceQuotasAPI() *ServiceQuotasAPI {
+	return &ServiceQuotasAPI{}
+}
+
+// Reset must be called between tests otherwise tests will pollute
+// each other.
+func (s *ServiceQuotasAPI) Reset() {
+	s.ListServiceQuotasBehavior.Reset()
+}
+
+// ListServiceQuotas returns no quotas unless the test provides them, so that launches aren't constrained by quotas
+func (s *ServiceQuotasAPI) ListServiceQuotas(_ context.Context, input *servicequotas.ListServiceQuotasInput, _ ...func(*servicequotas.Options)) (*servicequotas.ListServiceQuotasOutput, error) {
+	return s.ListServiceQuotasBehavior.Invoke(input, func(*servicequotas.ListServiceQuotasInput) (*servicequotas.ListServiceQuotasOutput, error) {
+		return &servicequotas.ListServiceQuotasOutput{}, nil
+	})
+}
+
+// NewServiceQuota returns an EC2 service quota with the quota code and value
+func NewServiceQuota(code string, value float64) servicequotastypes.ServiceQuota {
+	return servicequotastypes.ServiceQuota{
+		ServiceCode: lo.ToPtr("ec2"),
+		QuotaCode:   lo.ToPtr(code),
+		Value:       lo.ToPtr(value),
+	}
+}
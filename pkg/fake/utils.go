@@ -25,6 +25,7 @@ import (
 	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
 	"github.com/samber/lo"
 	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/utils/clock"
 
 	"github.com/aws/karpenter-provider-aws/pkg/operator/options"
 	"github.com/aws/karpenter-provider-aws/pkg/providers/pricing"
@@ -108,6 +109,40 @@ func FilterDescribeImages(images []ec2types.Image, filters []ec2types.Filter) []
 	})
 }
 
+func FilterDescribeCapacityReservations(capacityReservations []ec2types.CapacityReservation, ids []string, filters []ec2types.Filter) []ec2types.CapacityReservation {
+	return lo.Filter(capacityReservations, func(cr ec2types.CapacityReservation, _ int) bool {
+		if len(ids) != 0 && !lo.Contains(ids, aws.ToString(cr.CapacityReservationId)) {
+			return false
+		}
+		return lo.EveryBy(filters, func(filter ec2types.Filter) bool {
+			switch filterName := aws.ToString(filter.Name); {
+			case filterName == "state":
+				return lo.Contains(filter.Values, string(cr.State))
+			case filterName == "owner-id":
+				return lo.Contains(filter.Values, aws.ToString(cr.OwnerId))
+			default:
+				return Filter([]ec2types.Filter{filter}, aws.ToString(cr.CapacityReservationId), "", cr.Tags)
+			}
+		})
+	})
+}
+
+func FilterDescribeHosts(hosts []ec2types.Host, ids []string, filters []ec2types.Filter) []ec2types.Host {
+	return lo.Filter(hosts, func(h ec2types.Host, _ int) bool {
+		if len(ids) != 0 && !lo.Contains(ids, aws.ToString(h.HostId)) {
+			return false
+		}
+		return lo.EveryBy(filters, func(filter ec2types.Filter) bool {
+			switch filterName := aws.ToString(filter.Name); {
+			case filterName == "state":
+				return lo.Contains(filter.Values, string(h.State))
+			default:
+				return Filter([]ec2types.Filter{filter}, aws.ToString(h.HostId), "", h.Tags)
+			}
+		})
+	})
+}
+
 //nolint:gocyclo
 func Filter(filters []ec2types.Filter, id, name string, tags []ec2types.Tag) bool {
 	return lo.EveryBy(filters, func(filter ec2types.Filter) bool {
@@ -167,7 +202,7 @@ func MakeInstances() []ec2types.InstanceTypeInfo {
 	ctx := options.ToContext(context.Background(), &options.Options{IsolatedVPC: true})
 	// Use keys from the static pricing data so that we guarantee pricing for the data
 	// Create uniform instance data so all of them schedule for a given pod
-	for _, it := range pricing.NewDefaultProvider(ctx, nil, nil, "us-east-1").InstanceTypes() {
+	for _, it := range pricing.NewDefaultProvider(ctx, nil, nil, "us-east-1", clock.RealClock{}).InstanceTypes() {
 		instanceTypes = append(instanceTypes, ec2types.InstanceTypeInfo{
 			InstanceType: it,
 			ProcessorInfo: &ec2types.ProcessorInfo{
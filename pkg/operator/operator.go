@@ -31,6 +31,7 @@ import (
 	"github.com/aws/aws-sdk-go-v2/service/ec2"
 	"github.com/aws/aws-sdk-go-v2/service/eks"
 	"github.com/aws/aws-sdk-go-v2/service/iam"
+	"github.com/aws/aws-sdk-go-v2/service/servicequotas"
 	"github.com/aws/aws-sdk-go-v2/service/ssm"
 
 	"github.com/aws/smithy-go"
@@ -48,21 +49,30 @@ import (
 
 	karpv1 "sigs.k8s.io/karpenter/pkg/apis/v1"
 	"sigs.k8s.io/karpenter/pkg/operator"
+	"sigs.k8s.io/karpenter/pkg/utils/env"
 
 	prometheusv2 "github.com/jonathan-innis/aws-sdk-go-prometheus/v2"
 
 	"sigs.k8s.io/karpenter/pkg/apis"
 
 	sdk "github.com/aws/karpenter-provider-aws/pkg/aws"
+	"github.com/aws/karpenter-provider-aws/pkg/batcher"
 	awscache "github.com/aws/karpenter-provider-aws/pkg/cache"
+	"github.com/aws/karpenter-provider-aws/pkg/controllers/interruption/messages/rule"
 	"github.com/aws/karpenter-provider-aws/pkg/operator/options"
 	"github.com/aws/karpenter-provider-aws/pkg/providers/amifamily"
+	"github.com/aws/karpenter-provider-aws/pkg/providers/capacityreservation"
+	"github.com/aws/karpenter-provider-aws/pkg/providers/checkpoint"
+	"github.com/aws/karpenter-provider-aws/pkg/providers/host"
 	"github.com/aws/karpenter-provider-aws/pkg/providers/instance"
 	"github.com/aws/karpenter-provider-aws/pkg/providers/instanceprofile"
 	"github.com/aws/karpenter-provider-aws/pkg/providers/instancetype"
 	"github.com/aws/karpenter-provider-aws/pkg/providers/launchtemplate"
+	"github.com/aws/karpenter-provider-aws/pkg/providers/placementgroup"
 	"github.com/aws/karpenter-provider-aws/pkg/providers/pricing"
 	"github.com/aws/karpenter-provider-aws/pkg/providers/securitygroup"
+	"github.com/aws/karpenter-provider-aws/pkg/providers/servicequota"
+	"github.com/aws/karpenter-provider-aws/pkg/providers/spotplacementscore"
 	ssmp "github.com/aws/karpenter-provider-aws/pkg/providers/ssm"
 	"github.com/aws/karpenter-provider-aws/pkg/providers/subnet"
 	"github.com/aws/karpenter-provider-aws/pkg/providers/version"
@@ -75,20 +85,32 @@ func init() {
 // Operator is injected into the AWS CloudProvider's factories
 type Operator struct {
 	*operator.Operator
-	Config                    aws.Config
-	UnavailableOfferingsCache *awscache.UnavailableOfferings
-	SSMCache                  *cache.Cache
-	SubnetProvider            subnet.Provider
-	SecurityGroupProvider     securitygroup.Provider
-	InstanceProfileProvider   instanceprofile.Provider
-	AMIProvider               amifamily.Provider
-	AMIResolver               amifamily.Resolver
-	LaunchTemplateProvider    launchtemplate.Provider
-	PricingProvider           pricing.Provider
-	VersionProvider           *version.DefaultProvider
-	InstanceTypesProvider     *instancetype.DefaultProvider
-	InstanceProvider          instance.Provider
-	SSMProvider               ssmp.Provider
+	Config                      aws.Config
+	EC2API                      sdk.EC2API
+	EC2Batcher                  *batcher.EC2API
+	UnavailableOfferingsCache   *awscache.UnavailableOfferings
+	PolledInterruptions         *awscache.PolledInterruptions
+	SSMCache                    *cache.Cache
+	SubnetProvider              subnet.Provider
+	SecurityGroupProvider       securitygroup.Provider
+	CapacityReservationProvider capacityreservation.Provider
+	PlacementGroupProvider      placementgroup.Provider
+	HostProvider                host.Provider
+	SpotPlacementScoreProvider  spotplacementscore.Provider
+	ServiceQuotaProvider        servicequota.Provider
+	InstanceProfileProvider     instanceprofile.Provider
+	AMIProvider                 amifamily.Provider
+	AMIResolver                 amifamily.Resolver
+	LaunchTemplateProvider      launchtemplate.Provider
+	PricingProvider             pricing.Provider
+	VersionProvider             *version.DefaultProvider
+	InstanceTypesProvider       *instancetype.DefaultProvider
+	InstanceProvider            instance.Provider
+	SSMProvider                 ssmp.Provider
+	PricingOverridesProvider    pricing.OverridesProvider
+	PricingSnapshotProvider     pricing.SnapshotProvider
+	CheckpointProvider          checkpoint.Provider
+	InterruptionRulesProvider   rule.Provider
 }
 
 func NewOperator(ctx context.Context, operator *operator.Operator) (context.Context, *Operator) {
@@ -137,17 +159,26 @@ func NewOperator(ctx context.Context, operator *operator.Operator) (context.Cont
 		log.FromContext(ctx).WithValues("kube-dns-ip", kubeDNSIP).V(1).Info("discovered kube dns")
 	}
 	unavailableOfferingsCache := awscache.NewUnavailableOfferings()
+	lo.Must0(operator.Manager.AddMetricsServerExtraHandler("/debug/unavailable-offerings", unavailableOfferingsCache))
 	ssmCache := cache.New(awscache.SSMCacheTTL, awscache.DefaultCleanupInterval)
 
-	subnetProvider := subnet.NewDefaultProvider(ec2api, cache.New(awscache.DefaultTTL, awscache.DefaultCleanupInterval), cache.New(awscache.AvailableIPAddressTTL, awscache.DefaultCleanupInterval), cache.New(awscache.AssociatePublicIPAddressTTL, awscache.DefaultCleanupInterval))
+	subnetProvider := subnet.NewDefaultProvider(ec2api, cache.New(awscache.DefaultTTL, awscache.DefaultCleanupInterval), cache.New(awscache.AvailableIPAddressTTL, awscache.DefaultCleanupInterval), cache.New(awscache.AssociatePublicIPAddressTTL, awscache.DefaultCleanupInterval),
+		cache.New(awscache.InstanceTypesAndZonesTTL, awscache.DefaultCleanupInterval), cache.New(awscache.PrefixFragmentedSubnetTTL, awscache.DefaultCleanupInterval))
 	securityGroupProvider := securitygroup.NewDefaultProvider(ec2api, cache.New(awscache.DefaultTTL, awscache.DefaultCleanupInterval))
+	capacityReservationProvider := capacityreservation.NewDefaultProvider(ec2api, cache.New(awscache.DefaultTTL, awscache.DefaultCleanupInterval), cache.New(awscache.CapacityReservationAvailabilityTTL, awscache.DefaultCleanupInterval))
+	placementGroupProvider := placementgroup.NewDefaultProvider(cfg.Region, ec2api, cache.New(awscache.DefaultTTL, awscache.DefaultCleanupInterval))
+	hostProvider := host.NewDefaultProvider(ec2api, cache.New(awscache.DefaultTTL, awscache.DefaultCleanupInterval))
+	spotPlacementScoreProvider := spotplacementscore.NewDefaultProvider(cfg.Region, ec2api, operator.Clock, cache.New(awscache.SpotPlacementScoreTTL, awscache.DefaultCleanupInterval))
+	serviceQuotaProvider := servicequota.NewDefaultProvider(servicequotas.NewFromConfig(cfg), cache.New(awscache.ServiceQuotasTTL, awscache.DefaultCleanupInterval))
 	instanceProfileProvider := instanceprofile.NewDefaultProvider(cfg.Region, iam.NewFromConfig(cfg), cache.New(awscache.InstanceProfileTTL, awscache.DefaultCleanupInterval))
 	pricingProvider := pricing.NewDefaultProvider(
 		ctx,
 		pricing.NewAPI(cfg),
 		ec2api,
 		cfg.Region,
+		operator.Clock,
 	)
+	lo.Must0(operator.Manager.AddMetricsServerExtraHandler("/debug/pricing-snapshot", pricingProvider))
 	versionProvider := version.NewDefaultProvider(operator.KubernetesInterface, eksapi)
 	// Ensure we're able to hydrate the version before starting any reliant controllers.
 	// Version updates are hydrated asynchronously after this, in the event of a failure
@@ -174,33 +205,87 @@ func NewOperator(ctx context.Context, operator *operator.Operator) (context.Cont
 		cache.New(awscache.DiscoveredCapacityCacheTTL, awscache.DefaultCleanupInterval),
 		ec2api,
 		subnetProvider,
-		instancetype.NewDefaultResolver(cfg.Region, pricingProvider, unavailableOfferingsCache),
+		instancetype.NewDefaultResolver(cfg.Region, pricingProvider, unavailableOfferingsCache, capacityReservationProvider, serviceQuotaProvider, operator.Clock),
 	)
+	ec2Batcher := batcher.EC2(ctx, ec2api)
 	instanceProvider := instance.NewDefaultProvider(
-		ctx,
 		cfg.Region,
 		ec2api,
+		ec2Batcher,
 		unavailableOfferingsCache,
 		subnetProvider,
 		launchTemplateProvider,
+		capacityReservationProvider,
+		spotPlacementScoreProvider,
+		serviceQuotaProvider,
 	)
+	var pricingOverridesProvider pricing.OverridesProvider
+	if name := options.FromContext(ctx).PricingOverridesConfigMap; name != "" {
+		pricingOverridesProvider = pricing.NewConfigMapOverridesProvider(kubeClient, env.WithDefaultString("SYSTEM_NAMESPACE", "kube-system"), name)
+		// Apply the overrides before any instance types are resolved, so that launches aren't made with public prices
+		if overrides, err := pricingOverridesProvider.Overrides(ctx); err != nil {
+			log.FromContext(ctx).Error(err, "failed getting pricing overrides")
+		} else {
+			pricingProvider.SetOverrides(ctx, overrides)
+		}
+	}
+	var pricingSnapshotProvider pricing.SnapshotProvider
+	if path := options.FromContext(ctx).PricingSnapshotPath; path != "" {
+		pricingSnapshotProvider = pricing.NewFileSnapshotProvider(path)
+	}
+	if name := options.FromContext(ctx).PricingSnapshotConfigMap; name != "" {
+		pricingSnapshotProvider = pricing.NewConfigMapSnapshotProvider(kubeClient, env.WithDefaultString("SYSTEM_NAMESPACE", "kube-system"), name)
+	}
+	if pricingSnapshotProvider != nil {
+		// Apply the snapshot before any instance types are resolved, so that launches aren't made with stale prices
+		if snapshot, err := pricingSnapshotProvider.Snapshot(ctx); err != nil {
+			log.FromContext(ctx).Error(err, "failed getting pricing snapshot")
+		} else if err := pricingProvider.SetSnapshot(ctx, snapshot); err != nil {
+			log.FromContext(ctx).Error(err, "failed applying pricing snapshot")
+		}
+	}
+	polledInterruptions := awscache.NewPolledInterruptions()
+	var checkpointProvider checkpoint.Provider
+	if name := options.FromContext(ctx).CacheCheckpointConfigMap; name != "" {
+		checkpointProvider = checkpoint.NewDefaultProvider(kubeClient, env.WithDefaultString("SYSTEM_NAMESPACE", "kube-system"), name, map[string]awscache.Checkpointable{
+			checkpoint.DiscoveredCapacityKey:   instanceTypeProvider,
+			checkpoint.UnavailableOfferingsKey: unavailableOfferingsCache,
+			checkpoint.PolledInterruptionsKey:  polledInterruptions,
+		})
+	}
+	var interruptionRulesProvider rule.Provider
+	if name := options.FromContext(ctx).InterruptionRulesConfigMap; name != "" {
+		interruptionRulesProvider = rule.NewConfigMapProvider(kubeClient, env.WithDefaultString("SYSTEM_NAMESPACE", "kube-system"), name)
+	}
 
 	return ctx, &Operator{
-		Operator:                  operator,
-		Config:                    cfg,
-		UnavailableOfferingsCache: unavailableOfferingsCache,
-		SSMCache:                  ssmCache,
-		SubnetProvider:            subnetProvider,
-		SecurityGroupProvider:     securityGroupProvider,
-		InstanceProfileProvider:   instanceProfileProvider,
-		AMIProvider:               amiProvider,
-		AMIResolver:               amiResolver,
-		VersionProvider:           versionProvider,
-		LaunchTemplateProvider:    launchTemplateProvider,
-		PricingProvider:           pricingProvider,
-		InstanceTypesProvider:     instanceTypeProvider,
-		InstanceProvider:          instanceProvider,
-		SSMProvider:               ssmProvider,
+		Operator:                    operator,
+		Config:                      cfg,
+		EC2API:                      ec2api,
+		EC2Batcher:                  ec2Batcher,
+		UnavailableOfferingsCache:   unavailableOfferingsCache,
+		PolledInterruptions:         polledInterruptions,
+		SSMCache:                    ssmCache,
+		SubnetProvider:              subnetProvider,
+		SecurityGroupProvider:       securityGroupProvider,
+		CapacityReservationProvider: capacityReservationProvider,
+		PlacementGroupProvider:      placementGroupProvider,
+		HostProvider:                hostProvider,
+		SpotPlacementScoreProvider:  spotPlacementScoreProvider,
+		ServiceQuotaProvider:        serviceQuotaProvider,
+		InstanceProfileProvider:     instanceProfileProvider,
+		AMIProvider:                 amiProvider,
+		AMIResolver:                 amiResolver,
+		VersionProvider:             versionProvider,
+		LaunchTemplateProvider:      launchTemplateProvider,
+		PricingProvider:             pricingProvider,
+		InstanceTypesProvider:       instanceTypeProvider,
+		InstanceProvider:            instanceProvider,
+		SSMProvider:                 ssmProvider,
+		CheckpointProvider:          checkpointProvider,
+		PricingOverridesProvider:    pricingOverridesProvider,
+		PricingSnapshotProvider:     pricingSnapshotProvider,
+		InterruptionRulesProvider:   interruptionRulesProvider,
 	}
 }
 
@@ -20,7 +20,10 @@ import (
 	"flag"
 	"fmt"
 	"os"
+	"strings"
+	"time"
 
+	"github.com/samber/lo"
 	coreoptions "sigs.k8s.io/karpenter/pkg/operator/options"
 	"sigs.k8s.io/karpenter/pkg/utils/env"
 
@@ -34,14 +37,27 @@ func init() {
 type optionsKey struct{}
 
 type Options struct {
-	ClusterCABundle         string
-	ClusterName             string
-	ClusterEndpoint         string
-	IsolatedVPC             bool
-	EKSControlPlane         bool
-	VMMemoryOverheadPercent float64
-	InterruptionQueue       string
-	ReservedENIs            int
+	ClusterCABundle              string
+	ClusterName                  string
+	ClusterEndpoint              string
+	IsolatedVPC                  bool
+	EKSControlPlane              bool
+	VMMemoryOverheadPercent      float64
+	InterruptionQueue            string
+	InterruptionQueues           string
+	InterruptionAllowedAccounts  string
+	InterruptionAllowedRegions   string
+	InterruptionRulesConfigMap   string
+	InterruptionPollingInterval  time.Duration
+	InterruptionPollingAPIBudget int
+	ReservedENIs                 int
+	CacheCheckpointConfigMap     string
+	PricingOverridesConfigMap    string
+	PricingSnapshotPath          string
+	PricingSnapshotConfigMap     string
+	SpotPriceAggregation         string
+	SpotPriceWindow              time.Duration
+	ScheduledChangeLeadTime      time.Duration
 }
 
 func (o *Options) AddFlags(fs *coreoptions.FlagSet) {
@@ -52,7 +68,20 @@ func (o *Options) AddFlags(fs *coreoptions.FlagSet) {
 	fs.BoolVarWithEnv(&o.EKSControlPlane, "eks-control-plane", "EKS_CONTROL_PLANE", false, "Marking this true means that your cluster is running with an EKS control plane and Karpenter should attempt to discover cluster details from the DescribeCluster API ")
 	fs.Float64Var(&o.VMMemoryOverheadPercent, "vm-memory-overhead-percent", utils.WithDefaultFloat64("VM_MEMORY_OVERHEAD_PERCENT", 0.075), "The VM memory overhead as a percent that will be subtracted from the total memory for all instance types when cached information is unavailable.")
 	fs.StringVar(&o.InterruptionQueue, "interruption-queue", env.WithDefaultString("INTERRUPTION_QUEUE", ""), "Interruption queue is the name of the SQS queue used for processing interruption events from EC2. Interruption handling is disabled if not specified. Enabling interruption handling may require additional permissions on the controller service account. Additional permissions are outlined in the docs.")
+	fs.StringVar(&o.InterruptionQueues, "interruption-queues", env.WithDefaultString("INTERRUPTION_QUEUES", ""), "Comma separated list of additional SQS queues used for processing interruption events, such as queues in other accounts or regions. Each queue is a queue name or URL, optionally followed by '=' and the ARN of a role which is assumed to access the queue.")
+	fs.StringVar(&o.InterruptionAllowedAccounts, "interruption-allowed-accounts", env.WithDefaultString("INTERRUPTION_ALLOWED_ACCOUNTS", ""), "Comma separated list of the accounts that interruption events, and the SNS topics that deliver them, may come from. Events from any account are accepted if not specified.")
+	fs.StringVar(&o.InterruptionAllowedRegions, "interruption-allowed-regions", env.WithDefaultString("INTERRUPTION_ALLOWED_REGIONS", ""), "Comma separated list of the regions that interruption events, and the SNS topics that deliver them, may come from. Events from any region are accepted if not specified.")
+	fs.StringVar(&o.InterruptionRulesConfigMap, "interruption-rules-configmap", env.WithDefaultString("INTERRUPTION_RULES_CONFIGMAP", ""), "Name of the ConfigMap in the controller's namespace containing user-defined interruption rules, which route additional EventBridge events from the interruption queues to actions on the affected nodes. Only the built-in interruption events are handled if not specified.")
+	fs.DurationVar(&o.InterruptionPollingInterval, "interruption-polling-interval", env.WithDefaultDuration("INTERRUPTION_POLLING_INTERVAL", 0), "How often the EC2 API is polled for scheduled events, spot interruptions and stopped or terminated instances, as an alternative to an interruption queue for accounts which can't deliver EventBridge events to SQS. Interruption polling is disabled if not specified, and can't be enabled along with interruption-queue or interruption-queues.")
+	fs.IntVar(&o.InterruptionPollingAPIBudget, "interruption-polling-api-budget", env.WithDefaultInt("INTERRUPTION_POLLING_API_BUDGET", 10), "The maximum number of EC2 API requests made each time the EC2 API is polled for interruptions. When the budget doesn't cover the state of every instance, the instances are checked over several polls.")
 	fs.IntVar(&o.ReservedENIs, "reserved-enis", env.WithDefaultInt("RESERVED_ENIS", 0), "Reserved ENIs are not included in the calculations for max-pods or kube-reserved. This is most often used in the VPC CNI custom networking setup https://docs.aws.amazon.com/eks/latest/userguide/cni-custom-network.html.")
+	fs.StringVar(&o.CacheCheckpointConfigMap, "cache-checkpoint-configmap", env.WithDefaultString("CACHE_CHECKPOINT_CONFIGMAP", ""), "Name of the ConfigMap in the controller's namespace used to persist the discovered capacity, unavailable offerings and polled interruptions caches across restarts. Cache checkpointing is disabled if not specified.")
+	fs.StringVar(&o.PricingOverridesConfigMap, "pricing-overrides-configmap", env.WithDefaultString("PRICING_OVERRIDES_CONFIGMAP", ""), "Name of the ConfigMap in the controller's namespace containing overrides for the prices of instance types, such as private pricing, Savings Plans or Reserved Instance discounts. Public prices are used if not specified.")
+	fs.StringVar(&o.PricingSnapshotPath, "pricing-snapshot-path", env.WithDefaultString("PRICING_SNAPSHOT_PATH", ""), "Path to a file containing a snapshot of on-demand and spot prices, which replaces the static prices that are used when the pricing API can't be reached, such as in an isolated VPC. The file is reloaded when it changes. Only one of pricing-snapshot-path or pricing-snapshot-configmap may be specified.")
+	fs.StringVar(&o.PricingSnapshotConfigMap, "pricing-snapshot-configmap", env.WithDefaultString("PRICING_SNAPSHOT_CONFIGMAP", ""), "Name of the ConfigMap in the controller's namespace containing a snapshot of on-demand and spot prices, which replaces the static prices that are used when the pricing API can't be reached, such as in an isolated VPC. Only one of pricing-snapshot-path or pricing-snapshot-configmap may be specified.")
+	fs.StringVar(&o.SpotPriceAggregation, "spot-price-aggregation", env.WithDefaultString("SPOT_PRICE_AGGREGATION", "latest"), "How the spot price history within the spot price window is aggregated into the price of spot offerings. Valid values are 'latest', 'average' for the time-weighted average, or a percentile such as 'p90'.")
+	fs.DurationVar(&o.SpotPriceWindow, "spot-price-window", env.WithDefaultDuration("SPOT_PRICE_WINDOW", 6*time.Hour), "The length of the spot price history that is retained for aggregating spot prices and measuring their volatility.")
+	fs.DurationVar(&o.ScheduledChangeLeadTime, "scheduled-change-lead-time", env.WithDefaultDuration("SCHEDULED_CHANGE_LEAD_TIME", 0), "How long before the start of a scheduled change, such as a scheduled instance retirement or reboot, the affected nodes are disrupted. Nodes are disrupted during their NodePool's maintenance window before then, if it has one. When 0, nodes are disrupted as soon as the scheduled change is received, unless their NodePool has a maintenance window.")
 }
 
 func (o *Options) Parse(fs *coreoptions.FlagSet, args ...string) error {
@@ -83,3 +112,45 @@ func FromContext(ctx context.Context) *Options {
 	}
 	return retval.(*Options)
 }
+
+// InterruptionQueue is an SQS queue used for processing interruption events
+type InterruptionQueue struct {
+	// Name is the name or URL of the queue
+	Name string
+	// RoleARN is the ARN of the role which is assumed to access the queue, if any
+	RoleARN string
+}
+
+// InterruptionQueueList returns the interruption queues from both interruption-queue and interruption-queues
+func (o Options) InterruptionQueueList() ([]InterruptionQueue, error) {
+	var queues []InterruptionQueue
+	if o.InterruptionQueue != "" {
+		queues = append(queues, InterruptionQueue{Name: o.InterruptionQueue})
+	}
+	for _, entry := range splitList(o.InterruptionQueues) {
+		name, roleARN, _ := strings.Cut(entry, "=")
+		name, roleARN = strings.TrimSpace(name), strings.TrimSpace(roleARN)
+		if name == "" {
+			return nil, fmt.Errorf("interruption queue %q is missing a queue name", entry)
+		}
+		if strings.Contains(entry, "=") && !strings.HasPrefix(roleARN, "arn:") {
+			return nil, fmt.Errorf("interruption queue %q has an invalid role ARN", entry)
+		}
+		queues = append(queues, InterruptionQueue{Name: name, RoleARN: roleARN})
+	}
+	return queues, nil
+}
+
+// InterruptionAllowedAccountList returns the accounts whose SNS topics may publish interruption events
+func (o Options) InterruptionAllowedAccountList() []string {
+	return splitList(o.InterruptionAllowedAccounts)
+}
+
+// InterruptionAllowedRegionList returns the regions whose SNS topics may publish interruption events
+func (o Options) InterruptionAllowedRegionList() []string {
+	return splitList(o.InterruptionAllowedRegions)
+}
+
+func splitList(s string) []string {
+	return lo.Compact(lo.Map(strings.Split(s, ","), func(e string, _ int) string { return strings.TrimSpace(e) }))
+}
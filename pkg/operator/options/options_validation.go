@@ -17,6 +17,7 @@ package options
 import (
 	"fmt"
 	"net/url"
+	"regexp"
 
 	"go.uber.org/multierr"
 )
@@ -27,6 +28,12 @@ func (o Options) Validate() error {
 		o.validateVMMemoryOverheadPercent(),
 		o.validateReservedENIs(),
 		o.validateRequiredFields(),
+		o.validateInterruptionQueues(),
+		o.validatePricingSnapshot(),
+		o.validateSpotPriceAggregation(),
+		o.validateSpotPriceWindow(),
+		o.validateScheduledChangeLeadTime(),
+		o.validateInterruptionPolling(),
 	)
 }
 
@@ -63,3 +70,55 @@ func (o Options) validateRequiredFields() error {
 	}
 	return nil
 }
+
+func (o Options) validateInterruptionQueues() error {
+	if _, err := o.InterruptionQueueList(); err != nil {
+		return fmt.Errorf("invalid interruption-queues, %w", err)
+	}
+	return nil
+}
+
+func (o Options) validatePricingSnapshot() error {
+	if o.PricingSnapshotPath != "" && o.PricingSnapshotConfigMap != "" {
+		return fmt.Errorf("only one of pricing-snapshot-path or pricing-snapshot-configmap may be specified")
+	}
+	return nil
+}
+
+var spotPriceAggregationRegex = regexp.MustCompile(`^(latest|average|p([1-9][0-9]?|100))$`)
+
+func (o Options) validateSpotPriceAggregation() error {
+	if !spotPriceAggregationRegex.MatchString(o.SpotPriceAggregation) {
+		return fmt.Errorf("spot-price-aggregation must be 'latest', 'average' or a percentile between 'p1' and 'p100'")
+	}
+	return nil
+}
+
+func (o Options) validateSpotPriceWindow() error {
+	if o.SpotPriceWindow <= 0 {
+		return fmt.Errorf("spot-price-window must be positive")
+	}
+	return nil
+}
+
+func (o Options) validateScheduledChangeLeadTime() error {
+	if o.ScheduledChangeLeadTime < 0 {
+		return fmt.Errorf("scheduled-change-lead-time cannot be negative")
+	}
+	return nil
+}
+
+func (o Options) validateInterruptionPolling() error {
+	if o.InterruptionPollingInterval < 0 {
+		return fmt.Errorf("interruption-polling-interval cannot be negative")
+	}
+	// Interruptions would be acted on twice, once for the event from the queue and once for polling
+	if o.InterruptionPollingInterval > 0 && (o.InterruptionQueue != "" || o.InterruptionQueues != "") {
+		return fmt.Errorf("interruption-polling-interval cannot be set with interruption-queue or interruption-queues")
+	}
+	// Polling makes at least one request for each of scheduled events, spot instance requests and instance states
+	if o.InterruptionPollingAPIBudget < 3 {
+		return fmt.Errorf("interruption-polling-api-budget must be at least 3")
+	}
+	return nil
+}
@@ -19,6 +19,7 @@ import (
 	"flag"
 	"os"
 	"testing"
+	"time"
 
 	"github.com/samber/lo"
 	coreoptions "sigs.k8s.io/karpenter/pkg/operator/options"
@@ -62,16 +63,32 @@ var _ = Describe("Options", func() {
 			"--isolated-vpc",
 			"--vm-memory-overhead-percent", "0.1",
 			"--interruption-queue", "env-cluster",
-			"--reserved-enis", "10")
+			"--interruption-queues", "https://sqs.us-east-1.amazonaws.com/111111111111/env-cluster=arn:aws:iam::111111111111:role/env-cluster",
+			"--interruption-allowed-accounts", "111111111111",
+			"--interruption-allowed-regions", "us-east-1",
+			"--interruption-rules-configmap", "karpenter-interruption-rules",
+			"--interruption-polling-api-budget", "20",
+			"--reserved-enis", "10",
+			"--spot-price-aggregation", "p90",
+			"--spot-price-window", "2h",
+			"--scheduled-change-lead-time", "48h")
 		Expect(err).ToNot(HaveOccurred())
 		expectOptionsEqual(opts, test.Options(test.OptionsFields{
-			ClusterCABundle:         lo.ToPtr("env-bundle"),
-			ClusterName:             lo.ToPtr("env-cluster"),
-			ClusterEndpoint:         lo.ToPtr("https://env-cluster"),
-			IsolatedVPC:             lo.ToPtr(true),
-			VMMemoryOverheadPercent: lo.ToPtr[float64](0.1),
-			InterruptionQueue:       lo.ToPtr("env-cluster"),
-			ReservedENIs:            lo.ToPtr(10),
+			ClusterCABundle:              lo.ToPtr("env-bundle"),
+			ClusterName:                  lo.ToPtr("env-cluster"),
+			ClusterEndpoint:              lo.ToPtr("https://env-cluster"),
+			IsolatedVPC:                  lo.ToPtr(true),
+			VMMemoryOverheadPercent:      lo.ToPtr[float64](0.1),
+			InterruptionQueue:            lo.ToPtr("env-cluster"),
+			InterruptionQueues:           lo.ToPtr("https://sqs.us-east-1.amazonaws.com/111111111111/env-cluster=arn:aws:iam::111111111111:role/env-cluster"),
+			InterruptionAllowedAccounts:  lo.ToPtr("111111111111"),
+			InterruptionAllowedRegions:   lo.ToPtr("us-east-1"),
+			InterruptionRulesConfigMap:   lo.ToPtr("karpenter-interruption-rules"),
+			InterruptionPollingAPIBudget: lo.ToPtr(20),
+			ReservedENIs:                 lo.ToPtr(10),
+			SpotPriceAggregation:         lo.ToPtr("p90"),
+			SpotPriceWindow:              lo.ToPtr(2 * time.Hour),
+			ScheduledChangeLeadTime:      lo.ToPtr(48 * time.Hour),
 		}))
 	})
 	It("should correctly fallback to env vars when CLI flags aren't set", func() {
@@ -81,7 +98,15 @@ var _ = Describe("Options", func() {
 		os.Setenv("ISOLATED_VPC", "true")
 		os.Setenv("VM_MEMORY_OVERHEAD_PERCENT", "0.1")
 		os.Setenv("INTERRUPTION_QUEUE", "env-cluster")
+		os.Setenv("INTERRUPTION_QUEUES", "https://sqs.us-east-1.amazonaws.com/111111111111/env-cluster=arn:aws:iam::111111111111:role/env-cluster")
+		os.Setenv("INTERRUPTION_ALLOWED_ACCOUNTS", "111111111111")
+		os.Setenv("INTERRUPTION_ALLOWED_REGIONS", "us-east-1")
+		os.Setenv("INTERRUPTION_RULES_CONFIGMAP", "karpenter-interruption-rules")
+		os.Setenv("INTERRUPTION_POLLING_API_BUDGET", "20")
 		os.Setenv("RESERVED_ENIS", "10")
+		os.Setenv("SPOT_PRICE_AGGREGATION", "p90")
+		os.Setenv("SPOT_PRICE_WINDOW", "2h")
+		os.Setenv("SCHEDULED_CHANGE_LEAD_TIME", "48h")
 
 		// Add flags after we set the environment variables so that the parsing logic correctly refers
 		// to the new environment variable values
@@ -89,13 +114,21 @@ var _ = Describe("Options", func() {
 		err := opts.Parse(fs)
 		Expect(err).ToNot(HaveOccurred())
 		expectOptionsEqual(opts, test.Options(test.OptionsFields{
-			ClusterCABundle:         lo.ToPtr("env-bundle"),
-			ClusterName:             lo.ToPtr("env-cluster"),
-			ClusterEndpoint:         lo.ToPtr("https://env-cluster"),
-			IsolatedVPC:             lo.ToPtr(true),
-			VMMemoryOverheadPercent: lo.ToPtr[float64](0.1),
-			InterruptionQueue:       lo.ToPtr("env-cluster"),
-			ReservedENIs:            lo.ToPtr(10),
+			ClusterCABundle:              lo.ToPtr("env-bundle"),
+			ClusterName:                  lo.ToPtr("env-cluster"),
+			ClusterEndpoint:              lo.ToPtr("https://env-cluster"),
+			IsolatedVPC:                  lo.ToPtr(true),
+			VMMemoryOverheadPercent:      lo.ToPtr[float64](0.1),
+			InterruptionQueue:            lo.ToPtr("env-cluster"),
+			InterruptionQueues:           lo.ToPtr("https://sqs.us-east-1.amazonaws.com/111111111111/env-cluster=arn:aws:iam::111111111111:role/env-cluster"),
+			InterruptionAllowedAccounts:  lo.ToPtr("111111111111"),
+			InterruptionAllowedRegions:   lo.ToPtr("us-east-1"),
+			InterruptionRulesConfigMap:   lo.ToPtr("karpenter-interruption-rules"),
+			InterruptionPollingAPIBudget: lo.ToPtr(20),
+			ReservedENIs:                 lo.ToPtr(10),
+			SpotPriceAggregation:         lo.ToPtr("p90"),
+			SpotPriceWindow:              lo.ToPtr(2 * time.Hour),
+			ScheduledChangeLeadTime:      lo.ToPtr(48 * time.Hour),
 		}))
 	})
 
@@ -119,6 +152,77 @@ var _ = Describe("Options", func() {
 			err := opts.Parse(fs, "--cluster-name", "test-cluster", "--reserved-enis", "-1")
 			Expect(err).To(HaveOccurred())
 		})
+		It("should fail when both a pricing snapshot path and ConfigMap are set", func() {
+			err := opts.Parse(fs, "--cluster-name", "test-cluster", "--pricing-snapshot-path", "/etc/karpenter/prices.json", "--pricing-snapshot-configmap", "karpenter-pricing-snapshot")
+			Expect(err).To(HaveOccurred())
+		})
+		DescribeTable("should fail when spotPriceAggregation is invalid",
+			func(aggregation string) {
+				err := opts.Parse(fs, "--cluster-name", "test-cluster", "--spot-price-aggregation", aggregation)
+				Expect(err).To(HaveOccurred())
+			},
+			Entry("unknown", "median"),
+			Entry("zero percentile", "p0"),
+			Entry("percentile above 100", "p101"),
+		)
+		It("should fail when spotPriceWindow isn't positive", func() {
+			err := opts.Parse(fs, "--cluster-name", "test-cluster", "--spot-price-window", "0s")
+			Expect(err).To(HaveOccurred())
+		})
+		It("should fail when scheduledChangeLeadTime is negative", func() {
+			err := opts.Parse(fs, "--cluster-name", "test-cluster", "--scheduled-change-lead-time", "-1h")
+			Expect(err).To(HaveOccurred())
+		})
+		It("should fail when interruptionPollingInterval is negative", func() {
+			err := opts.Parse(fs, "--cluster-name", "test-cluster", "--interruption-polling-interval", "-1m")
+			Expect(err).To(HaveOccurred())
+		})
+		DescribeTable("should fail when interruptionPollingInterval is set with an interruption queue",
+			func(flag string) {
+				err := opts.Parse(fs, "--cluster-name", "test-cluster", "--interruption-polling-interval", "1m", flag, "test-cluster")
+				Expect(err).To(HaveOccurred())
+			},
+			Entry("interruptionQueue", "--interruption-queue"),
+			Entry("interruptionQueues", "--interruption-queues"),
+		)
+		It("should succeed when interruptionPollingInterval is set without an interruption queue", func() {
+			err := opts.Parse(fs, "--cluster-name", "test-cluster", "--interruption-polling-interval", "1m")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(opts.InterruptionPollingInterval).To(Equal(time.Minute))
+		})
+		It("should fail when interruptionPollingAPIBudget is less than 3", func() {
+			err := opts.Parse(fs, "--cluster-name", "test-cluster", "--interruption-polling-api-budget", "2")
+			Expect(err).To(HaveOccurred())
+		})
+		DescribeTable("should fail when interruptionQueues is invalid",
+			func(queues string) {
+				err := opts.Parse(fs, "--cluster-name", "test-cluster", "--interruption-queues", queues)
+				Expect(err).To(HaveOccurred())
+			},
+			Entry("missing queue name", "=arn:aws:iam::111111111111:role/test-cluster"),
+			Entry("missing role ARN", "test-cluster="),
+			Entry("invalid role ARN", "test-cluster=test-cluster"),
+		)
+	})
+	Context("InterruptionQueueList", func() {
+		It("should combine interruptionQueue and interruptionQueues", func() {
+			opts = test.Options(test.OptionsFields{
+				InterruptionQueue:  lo.ToPtr("test-cluster"),
+				InterruptionQueues: lo.ToPtr(" other-cluster , https://sqs.us-east-1.amazonaws.com/111111111111/test-cluster=arn:aws:iam::111111111111:role/test-cluster,"),
+			})
+			queues, err := opts.InterruptionQueueList()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(queues).To(Equal([]options.InterruptionQueue{
+				{Name: "test-cluster"},
+				{Name: "other-cluster"},
+				{Name: "https://sqs.us-east-1.amazonaws.com/111111111111/test-cluster", RoleARN: "arn:aws:iam::111111111111:role/test-cluster"},
+			}))
+		})
+		It("should return no queues when interruption handling is disabled", func() {
+			queues, err := test.Options().InterruptionQueueList()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(queues).To(BeEmpty())
+		})
 	})
 })
 
@@ -128,7 +232,16 @@ func expectOptionsEqual(optsA *options.Options, optsB *options.Options) {
 	Expect(optsA.ClusterName).To(Equal(optsB.ClusterName))
 	Expect(optsA.ClusterEndpoint).To(Equal(optsB.ClusterEndpoint))
 	Expect(optsA.IsolatedVPC).To(Equal(optsB.IsolatedVPC))
+	Expect(optsA.SpotPriceAggregation).To(Equal(optsB.SpotPriceAggregation))
+	Expect(optsA.SpotPriceWindow).To(Equal(optsB.SpotPriceWindow))
+	Expect(optsA.ScheduledChangeLeadTime).To(Equal(optsB.ScheduledChangeLeadTime))
 	Expect(optsA.VMMemoryOverheadPercent).To(Equal(optsB.VMMemoryOverheadPercent))
 	Expect(optsA.InterruptionQueue).To(Equal(optsB.InterruptionQueue))
+	Expect(optsA.InterruptionQueues).To(Equal(optsB.InterruptionQueues))
+	Expect(optsA.InterruptionAllowedAccounts).To(Equal(optsB.InterruptionAllowedAccounts))
+	Expect(optsA.InterruptionAllowedRegions).To(Equal(optsB.InterruptionAllowedRegions))
+	Expect(optsA.InterruptionRulesConfigMap).To(Equal(optsB.InterruptionRulesConfigMap))
+	Expect(optsA.InterruptionPollingInterval).To(Equal(optsB.InterruptionPollingInterval))
+	Expect(optsA.InterruptionPollingAPIBudget).To(Equal(optsB.InterruptionPollingAPIBudget))
 	Expect(optsA.ReservedENIs).To(Equal(optsB.ReservedENIs))
 }
@@ -17,13 +17,19 @@ package amifamily
 import (
 	"context"
 	"fmt"
+	"hash/fnv"
 	"net"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
 	"github.com/samber/lo"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/util/sets"
 
 	karpv1 "sigs.k8s.io/karpenter/pkg/apis/v1"
 
@@ -35,6 +41,10 @@ import (
 	"github.com/aws/karpenter-provider-aws/pkg/providers/ssm"
 )
 
+// MaxHostsPerLaunchTemplate is the most Dedicated Hosts that a launch template is resolved for when the EC2NodeClass
+// selects hosts, so that the launch templates of a launch don't grow with the number of selected hosts
+const MaxHostsPerLaunchTemplate = 5
+
 var DefaultEBS = v1.BlockDevice{
 	Encrypted:  aws.Bool(true),
 	VolumeType: aws.String(string(ec2types.VolumeTypeGp3)),
@@ -63,6 +73,23 @@ type Options struct {
 	KubeDNSIP                net.IP
 	AssociatePublicIPAddress *bool
 	NodeClassName            string
+	// NetworkInterfaces are the network interfaces that instances are launched with, if the EC2NodeClass configures them
+	NetworkInterfaces []NetworkInterface
+}
+
+// NetworkInterface is a network interface of the EC2NodeClass with its security groups and subnets resolved
+type NetworkInterface struct {
+	NetworkCardIndex        int32
+	DeviceIndex             int32
+	InterfaceType           string
+	IPv4PrefixCount         *int32
+	SecondaryIPAddressCount *int32
+	// SecurityGroupIDs are the security groups of the network interface. The security groups of the EC2NodeClass are
+	// used when unset.
+	SecurityGroupIDs []string
+	// ZonalSubnetIDs maps zones to the subnet that the network interface is created in. The network interface is
+	// created in the subnet that the instance is launched into when unset.
+	ZonalSubnetIDs map[string]string
 }
 
 // LaunchTemplate holds the dynamically generated launch template parameters
@@ -76,6 +103,25 @@ type LaunchTemplate struct {
 	DetailedMonitoring  bool
 	EFACount            int
 	CapacityType        string
+	// CapacityReservationID is set when the launch template targets a specific capacity reservation
+	CapacityReservationID string
+	// CapacityReservationType is the type of the targeted capacity reservation, which determines the market type
+	CapacityReservationType string
+	// PlacementGroup is the resolved placement group that instances launched with the launch template are placed into
+	PlacementGroup *v1.PlacementGroup
+	// Tenancy is the tenancy of instances launched with the launch template
+	Tenancy string
+	// HostResourceGroupARN is the host resource group that instances with "host" tenancy are launched into
+	HostResourceGroupARN string
+	// CPUOptions are the CPU options that instances are launched with. The core count and threads per core are resolved
+	// for the launch template's instance types.
+	CPUOptions *v1.CPUOptions
+	// Zone is set when the launch template's network interfaces are created in subnets of their own. Those subnets
+	// must be in the same zone as the instance, so instances launched with the launch template are constrained to it.
+	// It's also set to the zone of the Dedicated Host that the launch template places instances onto.
+	Zone string
+	// HostID is the Dedicated Host that instances launched with the launch template are placed onto
+	HostID string
 }
 
 // AMIFamily can be implemented to override the default logic for generating dynamic launch template parameters
@@ -136,9 +182,11 @@ func (r DefaultResolver) Resolve(nodeClass *v1.EC2NodeClass, nodeClaim *karpv1.N
 		// This requires that we resolve a unique launch template per max-pods value.
 		// Similarly, instance types configured with EfAs require unique launch templates depending on the number of
 		// EFAs they support.
+		// Instance types launched with CPU options also require unique launch templates per core count and threads per core.
 		type launchTemplateParams struct {
-			efaCount int
-			maxPods  int
+			efaCount   int
+			maxPods    int
+			cpuOptions cpuOptions
 		}
 		paramsToInstanceTypes := lo.GroupBy(instanceTypes, func(instanceType *cloudprovider.InstanceType) launchTemplateParams {
 			return launchTemplateParams{
@@ -147,17 +195,160 @@ func (r DefaultResolver) Resolve(nodeClass *v1.EC2NodeClass, nodeClaim *karpv1.N
 					int(lo.ToPtr(instanceType.Capacity[v1.ResourceEFA]).Value()),
 					0,
 				),
-				maxPods: int(instanceType.Capacity.Pods().Value()),
+				maxPods:    int(instanceType.Capacity.Pods().Value()),
+				cpuOptions: resolveCPUOptions(nodeClass, instanceType),
 			}
 		})
 		for params, instanceTypes := range paramsToInstanceTypes {
-			resolved := r.resolveLaunchTemplate(nodeClass, nodeClaim, instanceTypes, capacityType, amiFamily, amiID, params.maxPods, params.efaCount, options)
+			if capacityType == v1.CapacityTypeReserved {
+				// Each capacity reservation must be targeted explicitly through the launch template, so we resolve a
+				// unique launch template per reservation with the instance types that can be launched into it
+				for id, instanceTypes := range reservationIDsToInstanceTypes(instanceTypes) {
+					resolved := r.resolveLaunchTemplate(nodeClass, nodeClaim, instanceTypes, capacityType, amiFamily, amiID, params.maxPods, params.efaCount, params.cpuOptions, options)
+					resolved.CapacityReservationID = id
+					resolved.CapacityReservationType = lo.FindOrElse(nodeClass.Status.CapacityReservations, v1.CapacityReservation{}, func(cr v1.CapacityReservation) bool {
+						return cr.ID == id
+					}).ReservationType
+					resolvedTemplates = append(resolvedTemplates, resolved)
+				}
+				continue
+			}
+			resolved := r.resolveLaunchTemplate(nodeClass, nodeClaim, instanceTypes, capacityType, amiFamily, amiID, params.maxPods, params.efaCount, params.cpuOptions, options)
 			resolvedTemplates = append(resolvedTemplates, resolved)
 		}
 	}
+	resolvedTemplates, err := zonalLaunchTemplates(resolvedTemplates, options)
+	if err != nil {
+		return nil, err
+	}
+	return hostLaunchTemplates(nodeClass, nodeClaim, resolvedTemplates)
+}
+
+// hostLaunchTemplates resolves a launch template per Dedicated Host when the EC2NodeClass selects hosts, since instances
+// are placed onto a host through the launch template. Each launch template is constrained to the instance types that
+// its host is able to run and to the host's zone. At most MaxHostsPerLaunchTemplate hosts are used for each launch
+// template, starting at a host picked from the NodeClaim's name so that launches are spread across the selected hosts.
+// When the EC2NodeClass has a host resource group, EC2 chooses the host from the group instead.
+func hostLaunchTemplates(nodeClass *v1.EC2NodeClass, nodeClaim *karpv1.NodeClaim, launchTemplates []*LaunchTemplate) ([]*LaunchTemplate, error) {
+	if nodeClass.Tenancy() != v1.TenancyHost || len(nodeClass.Spec.HostSelectorTerms) == 0 || nodeClass.Spec.HostResourceGroupARN != nil {
+		return launchTemplates, nil
+	}
+	hosts := slices.Clone(nodeClass.Status.Hosts)
+	sort.Slice(hosts, func(i, j int) bool { return hosts[i].ID < hosts[j].ID })
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(nodeClaim.Name))
+	var resolvedTemplates []*LaunchTemplate
+	for _, launchTemplate := range launchTemplates {
+		candidates := lo.Filter(hosts, func(host v1.Host, _ int) bool {
+			return launchTemplate.Zone == "" || launchTemplate.Zone == host.AvailabilityZone
+		})
+		if len(candidates) == 0 {
+			continue
+		}
+		offset := int(h.Sum32() % uint32(len(candidates)))
+		candidates = slices.Concat(candidates[offset:], candidates[:offset])
+		var resolvedHosts int
+		for _, host := range candidates {
+			if resolvedHosts == MaxHostsPerLaunchTemplate {
+				break
+			}
+			instanceTypes := lo.Filter(launchTemplate.InstanceTypes, func(it *cloudprovider.InstanceType, _ int) bool {
+				if host.InstanceType != "" {
+					return host.InstanceType == it.Name
+				}
+				return host.InstanceFamily == strings.Split(it.Name, ".")[0]
+			})
+			if len(instanceTypes) == 0 {
+				continue
+			}
+			resolved := *launchTemplate
+			resolved.InstanceTypes = instanceTypes
+			resolved.Zone = host.AvailabilityZone
+			resolved.HostID = host.ID
+			resolvedTemplates = append(resolvedTemplates, &resolved)
+			resolvedHosts++
+		}
+	}
+	if len(resolvedTemplates) == 0 {
+		return nil, fmt.Errorf("no selected dedicated host is able to run the instance types")
+	}
+	return resolvedTemplates, nil
+}
+
+// zonalLaunchTemplates resolves a launch template per zone when network interfaces are created in subnets of their own,
+// since the subnets of the network interfaces that instances are launched with depend on the zone of the instance.
+func zonalLaunchTemplates(launchTemplates []*LaunchTemplate, options *Options) ([]*LaunchTemplate, error) {
+	var zones sets.Set[string]
+	for _, ni := range options.NetworkInterfaces {
+		if ni.ZonalSubnetIDs == nil {
+			continue
+		}
+		if zones == nil {
+			zones = sets.New(lo.Keys(ni.ZonalSubnetIDs)...)
+			continue
+		}
+		zones = zones.Intersection(sets.New(lo.Keys(ni.ZonalSubnetIDs)...))
+	}
+	if zones == nil {
+		return launchTemplates, nil
+	}
+	if zones.Len() == 0 {
+		return nil, fmt.Errorf("no zone has a subnet for every network interface")
+	}
+	var resolvedTemplates []*LaunchTemplate
+	for _, launchTemplate := range launchTemplates {
+		for _, zone := range sets.List(zones) {
+			resolved := *launchTemplate
+			resolved.Zone = zone
+			resolvedTemplates = append(resolvedTemplates, &resolved)
+		}
+	}
 	return resolvedTemplates, nil
 }
 
+// cpuOptions is the core count and threads per core that an instance type is launched with
+type cpuOptions struct {
+	coreCount      int32
+	threadsPerCore int32
+}
+
+// resolveCPUOptions resolves the core count and threads per core of an instance type from its cores and vCPUs, which
+// reflect the EC2NodeClass' CPU options. The zero value is returned when the EC2NodeClass doesn't configure either.
+func resolveCPUOptions(nodeClass *v1.EC2NodeClass, instanceType *cloudprovider.InstanceType) cpuOptions {
+	options := nodeClass.Spec.CPUOptions
+	if options == nil || (options.CoreCount == nil && options.CoreRatio == nil && options.ThreadsPerCore == nil) {
+		return cpuOptions{}
+	}
+	if !instanceType.Requirements.Has(v1.LabelInstanceCPUCores) {
+		return cpuOptions{}
+	}
+	cores, err := strconv.ParseInt(instanceType.Requirements.Get(v1.LabelInstanceCPUCores).Any(), 10, 32)
+	if err != nil || cores == 0 {
+		return cpuOptions{}
+	}
+	return cpuOptions{
+		//nolint:gosec
+		coreCount: int32(cores),
+		//nolint:gosec
+		threadsPerCore: int32(instanceType.Capacity.Cpu().Value() / cores),
+	}
+}
+
+// reservationIDsToInstanceTypes groups the instance types by the capacity reservations of their available reserved offerings
+func reservationIDsToInstanceTypes(instanceTypes []*cloudprovider.InstanceType) map[string][]*cloudprovider.InstanceType {
+	res := map[string][]*cloudprovider.InstanceType{}
+	for _, it := range instanceTypes {
+		for _, o := range it.Offerings.Available() {
+			if o.Requirements.Get(karpv1.CapacityTypeLabelKey).Any() != v1.CapacityTypeReserved {
+				continue
+			}
+			id := o.Requirements.Get(v1.LabelCapacityReservationID).Any()
+			res[id] = append(res[id], it)
+		}
+	}
+	return res
+}
+
 func GetAMIFamily(amiFamily string, options *Options) AMIFamily {
 	switch amiFamily {
 	case v1.AMIFamilyBottlerocket:
@@ -202,7 +393,7 @@ func (r DefaultResolver) defaultClusterDNS(opts *Options, kubeletConfig *v1.Kube
 }
 
 func (r DefaultResolver) resolveLaunchTemplate(nodeClass *v1.EC2NodeClass, nodeClaim *karpv1.NodeClaim, instanceTypes []*cloudprovider.InstanceType, capacityType string,
-	amiFamily AMIFamily, amiID string, maxPods int, efaCount int, options *Options) *LaunchTemplate {
+	amiFamily AMIFamily, amiID string, maxPods int, efaCount int, cpuOptions cpuOptions, options *Options) *LaunchTemplate {
 	kubeletConfig := &v1.KubeletConfiguration{}
 	if nodeClass.Spec.Kubelet != nil {
 		kubeletConfig = nodeClass.Spec.Kubelet.DeepCopy()
@@ -234,13 +425,23 @@ func (r DefaultResolver) resolveLaunchTemplate(nodeClass *v1.EC2NodeClass, nodeC
 			nodeClass.Spec.UserData,
 			options.InstanceStorePolicy,
 		),
-		BlockDeviceMappings: nodeClass.Spec.BlockDeviceMappings,
-		MetadataOptions:     nodeClass.Spec.MetadataOptions,
-		DetailedMonitoring:  aws.ToBool(nodeClass.Spec.DetailedMonitoring),
-		AMIID:               amiID,
-		InstanceTypes:       instanceTypes,
-		EFACount:            efaCount,
-		CapacityType:        capacityType,
+		BlockDeviceMappings:  nodeClass.Spec.BlockDeviceMappings,
+		MetadataOptions:      nodeClass.Spec.MetadataOptions,
+		DetailedMonitoring:   aws.ToBool(nodeClass.Spec.DetailedMonitoring),
+		AMIID:                amiID,
+		InstanceTypes:        instanceTypes,
+		EFACount:             efaCount,
+		CapacityType:         capacityType,
+		PlacementGroup:       nodeClass.Status.PlacementGroup,
+		Tenancy:              nodeClass.Tenancy(),
+		HostResourceGroupARN: lo.FromPtr(nodeClass.Spec.HostResourceGroupARN),
+	}
+	if nodeClass.Spec.CPUOptions != nil {
+		resolved.CPUOptions = &v1.CPUOptions{
+			CoreCount:      lo.Ternary(cpuOptions.coreCount != 0, lo.ToPtr(cpuOptions.coreCount), nil),
+			ThreadsPerCore: lo.Ternary(cpuOptions.threadsPerCore != 0, lo.ToPtr(cpuOptions.threadsPerCore), nil),
+			AMDSEVSNP:      nodeClass.Spec.CPUOptions.AMDSEVSNP,
+		}
 	}
 	if len(resolved.BlockDeviceMappings) == 0 {
 		resolved.BlockDeviceMappings = amiFamily.DefaultBlockDeviceMappings()
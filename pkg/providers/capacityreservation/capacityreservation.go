@@ -0,0 +1,217 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package capacityreservation
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/mitchellh/hashstructure/v2"
+	"github.com/patrickmn/go-cache"
+	"github.com/samber/lo"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	"sigs.k8s.io/karpenter/pkg/utils/pretty"
+
+	v1 "github.com/aws/karpenter-provider-aws/pkg/apis/v1"
+	sdk "github.com/aws/karpenter-provider-aws/pkg/aws"
+)
+
+const (
+	// CapacityBlockExpirationWindow is the amount of time before a capacity block's end time that we stop launching into
+	// the block and begin replacing its nodes, which are drifted so that replacements are launched before they're drained
+	CapacityBlockExpirationWindow = time.Hour
+	// CapacityBlockDeletionWindow is the amount of time before a capacity block's end time that its remaining nodes are
+	// deleted, whether or not they've been replaced. EC2 begins terminating the instances in a capacity block 30 minutes
+	// before the block's end time, so we leave workloads an additional 10 minutes to be rescheduled.
+	CapacityBlockDeletionWindow = 40 * time.Minute
+)
+
+type Provider interface {
+	List(context.Context, *v1.EC2NodeClass) ([]ec2types.CapacityReservation, error)
+	GetAvailableInstanceCount(string) int32
+	MarkLaunched(string)
+	MarkUnavailable(...string)
+}
+
+type DefaultProvider struct {
+	sync.Mutex
+	ec2api                 sdk.EC2API
+	cache                  *cache.Cache
+	availableInstanceCache *cache.Cache
+	cm                     *pretty.ChangeMonitor
+	inflightInstances      map[string]int32
+}
+
+func NewDefaultProvider(ec2api sdk.EC2API, cache *cache.Cache, availableInstanceCache *cache.Cache) *DefaultProvider {
+	return &DefaultProvider{
+		ec2api:                 ec2api,
+		cm:                     pretty.NewChangeMonitor(),
+		cache:                  cache,
+		availableInstanceCache: availableInstanceCache,
+		// inflightInstances is used to track the remaining instance count of reservations that we have launched into
+		// since the last time that we refreshed the reservation from EC2
+		inflightInstances: map[string]int32{},
+	}
+}
+
+func (p *DefaultProvider) List(ctx context.Context, nodeClass *v1.EC2NodeClass) ([]ec2types.CapacityReservation, error) {
+	p.Lock()
+	defer p.Unlock()
+
+	queries := getQueries(nodeClass.Spec.CapacityReservationSelectorTerms)
+	if len(queries) == 0 {
+		return []ec2types.CapacityReservation{}, nil
+	}
+	hash, err := hashstructure.Hash(queries, hashstructure.FormatV2, &hashstructure.HashOptions{SlicesAsSets: true})
+	if err != nil {
+		return nil, err
+	}
+	if capacityReservations, ok := p.cache.Get(fmt.Sprint(hash)); ok {
+		// Ensure what's returned from this function is a shallow-copy of the slice (not a deep-copy of the data itself)
+		// so that modifications to the ordering of the data don't affect the original
+		return append([]ec2types.CapacityReservation{}, capacityReservations.([]ec2types.CapacityReservation)...), nil
+	}
+	// Ensure that all the capacity reservations that are returned here are unique
+	capacityReservations := map[string]ec2types.CapacityReservation{}
+	for _, query := range queries {
+		paginator := ec2.NewDescribeCapacityReservationsPaginator(p.ec2api, query)
+		for paginator.HasMorePages() {
+			out, err := paginator.NextPage(ctx)
+			if err != nil {
+				return nil, fmt.Errorf("describing capacity reservations %s, %w", pretty.Concise(query), err)
+			}
+			for i := range out.CapacityReservations {
+				id := lo.FromPtr(out.CapacityReservations[i].CapacityReservationId)
+				capacityReservations[id] = out.CapacityReservations[i]
+				p.availableInstanceCache.SetDefault(id, lo.FromPtr(out.CapacityReservations[i].AvailableInstanceCount))
+				// remove any previously tracked instance launches since we just refreshed from EC2
+				delete(p.inflightInstances, id)
+			}
+		}
+	}
+	p.cache.SetDefault(fmt.Sprint(hash), lo.Values(capacityReservations))
+	if p.cm.HasChanged(fmt.Sprintf("capacity-reservations/%s", nodeClass.Name), lo.Keys(capacityReservations)) {
+		log.FromContext(ctx).
+			WithValues("capacity-reservations", lo.Map(lo.Values(capacityReservations), func(cr ec2types.CapacityReservation, _ int) v1.CapacityReservation {
+				return v1.CapacityReservation{
+					ID:               lo.FromPtr(cr.CapacityReservationId),
+					InstanceType:     lo.FromPtr(cr.InstanceType),
+					AvailabilityZone: lo.FromPtr(cr.AvailabilityZone),
+				}
+			})).V(1).Info("discovered capacity reservations")
+	}
+	return lo.Values(capacityReservations), nil
+}
+
+// GetAvailableInstanceCount returns the number of instances which can still be launched into the capacity reservation,
+// taking into account any launches that have occurred since the reservation was last refreshed from EC2
+func (p *DefaultProvider) GetAvailableInstanceCount(id string) int32 {
+	p.Lock()
+	defer p.Unlock()
+	if count, ok := p.inflightInstances[id]; ok {
+		return count
+	}
+	if count, ok := p.availableInstanceCache.Get(id); ok {
+		return count.(int32)
+	}
+	return 0
+}
+
+// MarkLaunched deducts a launched instance from the in-memory available instance count of the capacity reservation.
+// The tracked count is replaced the next time the reservation is refreshed from EC2.
+func (p *DefaultProvider) MarkLaunched(id string) {
+	p.Lock()
+	defer p.Unlock()
+	prev, ok := p.inflightInstances[id]
+	if !ok {
+		if count, ok := p.availableInstanceCache.Get(id); ok {
+			prev = count.(int32)
+		}
+	}
+	p.inflightInstances[id] = lo.Max([]int32{prev - 1, 0})
+}
+
+// MarkUnavailable marks the capacity reservations as having no remaining capacity. This is used when EC2 tells us that
+// a reservation is exhausted before we have observed it through a refresh.
+func (p *DefaultProvider) MarkUnavailable(ids ...string) {
+	p.Lock()
+	defer p.Unlock()
+	for _, id := range ids {
+		p.inflightInstances[id] = 0
+	}
+}
+
+func (p *DefaultProvider) Reset() {
+	p.Lock()
+	defer p.Unlock()
+	p.inflightInstances = map[string]int32{}
+}
+
+// IsExpiring returns true if the capacity reservation is a capacity block which is within its expiration window
+func IsExpiring(cr v1.CapacityReservation, now time.Time) bool {
+	if cr.ReservationType != v1.CapacityReservationTypeCapacityBlock || cr.EndTime == nil {
+		return false
+	}
+	return !now.Before(cr.EndTime.Add(-CapacityBlockExpirationWindow))
+}
+
+func getQueries(terms []v1.CapacityReservationSelectorTerm) (res []*ec2.DescribeCapacityReservationsInput) {
+	stateFilter := ec2types.Filter{
+		Name:   aws.String("state"),
+		Values: []string{string(ec2types.CapacityReservationStateActive)},
+	}
+	var ids []string
+	for _, term := range terms {
+		switch {
+		case term.ID != "":
+			ids = append(ids, term.ID)
+		default:
+			filters := []ec2types.Filter{stateFilter}
+			if term.OwnerID != "" {
+				filters = append(filters, ec2types.Filter{
+					Name:   aws.String("owner-id"),
+					Values: []string{term.OwnerID},
+				})
+			}
+			for k, v := range term.Tags {
+				if v == "*" {
+					filters = append(filters, ec2types.Filter{
+						Name:   aws.String("tag-key"),
+						Values: []string{k},
+					})
+				} else {
+					filters = append(filters, ec2types.Filter{
+						Name:   aws.String(fmt.Sprintf("tag:%s", k)),
+						Values: []string{v},
+					})
+				}
+			}
+			res = append(res, &ec2.DescribeCapacityReservationsInput{Filters: filters})
+		}
+	}
+	if len(ids) > 0 {
+		res = append(res, &ec2.DescribeCapacityReservationsInput{
+			CapacityReservationIds: ids,
+			Filters:                []ec2types.Filter{stateFilter},
+		})
+	}
+	return res
+}
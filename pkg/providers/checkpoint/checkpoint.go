@@ -0,0 +1,127 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package checkpoint
+
+import (
+	"context"
+	"fmt"
+	"maps"
+
+	"github.com/samber/lo"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	awscache "github.com/aws/karpenter-provider-aws/pkg/cache"
+)
+
+const (
+	DiscoveredCapacityKey   = "discovered-capacity"
+	UnavailableOfferingsKey = "unavailable-offerings"
+	PolledInterruptionsKey  = "polled-interruptions"
+)
+
+type Provider interface {
+	Checkpoint(context.Context) error
+	Restore(context.Context) error
+}
+
+// DefaultProvider persists the contents of caches that are expensive to rebuild to a ConfigMap, so that they can be
+// restored when the controller restarts rather than being relearned from failed launches and newly registered nodes.
+// Each cache is stored under its own key in the ConfigMap's data.
+type DefaultProvider struct {
+	kubeClient client.Client
+	namespace  string
+	name       string
+	caches     map[string]awscache.Checkpointable
+	// seqNums are the change counters of the caches when they were last checkpointed
+	seqNums map[string]uint64
+}
+
+func NewDefaultProvider(kubeClient client.Client, namespace, name string, caches map[string]awscache.Checkpointable) *DefaultProvider {
+	return &DefaultProvider{
+		kubeClient: kubeClient,
+		namespace:  namespace,
+		name:       name,
+		caches:     caches,
+	}
+}
+
+// Checkpoint writes the current contents of each cache to the ConfigMap, creating it if it doesn't exist. The ConfigMap
+// isn't written if none of the caches have changed since they were last checkpointed.
+func (p *DefaultProvider) Checkpoint(ctx context.Context) error {
+	seqNums := lo.MapValues(p.caches, func(c awscache.Checkpointable, _ string) uint64 { return c.CheckpointSeqNum() })
+	if p.seqNums != nil && maps.Equal(seqNums, p.seqNums) {
+		return nil
+	}
+	data := map[string]string{}
+	for key, c := range p.caches {
+		checkpoint, err := c.Checkpoint()
+		if err != nil {
+			return fmt.Errorf("checkpointing %s, %w", key, err)
+		}
+		data[key] = string(checkpoint)
+	}
+	cm := &corev1.ConfigMap{}
+	if err := p.kubeClient.Get(ctx, types.NamespacedName{Namespace: p.namespace, Name: p.name}, cm); err != nil {
+		if !errors.IsNotFound(err) {
+			return fmt.Errorf("getting configmap, %w", err)
+		}
+		cm = &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Namespace: p.namespace, Name: p.name},
+			Data:       data,
+		}
+		if err := p.kubeClient.Create(ctx, cm); err != nil {
+			return fmt.Errorf("creating configmap, %w", err)
+		}
+		p.seqNums = seqNums
+		return nil
+	}
+	cm.Data = lo.Assign(cm.Data, data)
+	if err := p.kubeClient.Update(ctx, cm); err != nil {
+		return fmt.Errorf("updating configmap, %w", err)
+	}
+	p.seqNums = seqNums
+	return nil
+}
+
+// Restore hydrates each cache from the ConfigMap. A missing ConfigMap, or a missing key within it, isn't an error since
+// there is nothing to restore before the first checkpoint is written. A cache whose checkpoint can't be restored is
+// skipped, since it's overwritten by the next checkpoint and the controller relearns its contents as it would without
+// a checkpoint.
+func (p *DefaultProvider) Restore(ctx context.Context) error {
+	cm := &corev1.ConfigMap{}
+	if err := p.kubeClient.Get(ctx, types.NamespacedName{Namespace: p.namespace, Name: p.name}, cm); err != nil {
+		if errors.IsNotFound(err) {
+			return nil
+		}
+		return fmt.Errorf("getting configmap, %w", err)
+	}
+	for key, c := range p.caches {
+		checkpoint, ok := cm.Data[key]
+		if !ok {
+			continue
+		}
+		if err := c.Restore([]byte(checkpoint)); err != nil {
+			log.FromContext(ctx).WithValues("cache", key).Error(err, "failed restoring cache from checkpoint")
+			continue
+		}
+		log.FromContext(ctx).WithValues("cache", key).V(1).Info("restored cache from checkpoint")
+	}
+	return nil
+}
@@ -0,0 +1,135 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package host
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/mitchellh/hashstructure/v2"
+	"github.com/patrickmn/go-cache"
+	"github.com/samber/lo"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	"sigs.k8s.io/karpenter/pkg/utils/pretty"
+
+	v1 "github.com/aws/karpenter-provider-aws/pkg/apis/v1"
+	sdk "github.com/aws/karpenter-provider-aws/pkg/aws"
+)
+
+type Provider interface {
+	List(context.Context, *v1.EC2NodeClass) ([]ec2types.Host, error)
+}
+
+type DefaultProvider struct {
+	sync.Mutex
+	ec2api sdk.EC2API
+	cache  *cache.Cache
+	cm     *pretty.ChangeMonitor
+}
+
+func NewDefaultProvider(ec2api sdk.EC2API, cache *cache.Cache) *DefaultProvider {
+	return &DefaultProvider{
+		ec2api: ec2api,
+		cm:     pretty.NewChangeMonitor(),
+		cache:  cache,
+	}
+}
+
+// List returns the available Dedicated Hosts that match the EC2NodeClass' host selector terms
+func (p *DefaultProvider) List(ctx context.Context, nodeClass *v1.EC2NodeClass) ([]ec2types.Host, error) {
+	p.Lock()
+	defer p.Unlock()
+
+	queries := getQueries(nodeClass.Spec.HostSelectorTerms)
+	if len(queries) == 0 {
+		return []ec2types.Host{}, nil
+	}
+	hash, err := hashstructure.Hash(queries, hashstructure.FormatV2, &hashstructure.HashOptions{SlicesAsSets: true})
+	if err != nil {
+		return nil, err
+	}
+	if hosts, ok := p.cache.Get(fmt.Sprint(hash)); ok {
+		// Ensure what's returned from this function is a shallow-copy of the slice (not a deep-copy of the data itself)
+		// so that modifications to the ordering of the data don't affect the original
+		return append([]ec2types.Host{}, hosts.([]ec2types.Host)...), nil
+	}
+	// Ensure that all the hosts that are returned here are unique
+	hosts := map[string]ec2types.Host{}
+	for _, query := range queries {
+		paginator := ec2.NewDescribeHostsPaginator(p.ec2api, query)
+		for paginator.HasMorePages() {
+			out, err := paginator.NextPage(ctx)
+			if err != nil {
+				return nil, fmt.Errorf("describing hosts %s, %w", pretty.Concise(query), err)
+			}
+			for i := range out.Hosts {
+				// DescribeHosts doesn't allow filters to be combined with host ids, so we check the state here
+				if out.Hosts[i].State != ec2types.AllocationStateAvailable {
+					continue
+				}
+				hosts[lo.FromPtr(out.Hosts[i].HostId)] = out.Hosts[i]
+			}
+		}
+	}
+	p.cache.SetDefault(fmt.Sprint(hash), lo.Values(hosts))
+	if p.cm.HasChanged(fmt.Sprintf("hosts/%s", nodeClass.Name), lo.Keys(hosts)) {
+		log.FromContext(ctx).
+			WithValues("hosts", lo.Map(lo.Values(hosts), func(h ec2types.Host, _ int) v1.Host {
+				return v1.Host{
+					ID:               lo.FromPtr(h.HostId),
+					AvailabilityZone: lo.FromPtr(h.AvailabilityZone),
+				}
+			})).V(1).Info("discovered hosts")
+	}
+	return lo.Values(hosts), nil
+}
+
+func getQueries(terms []v1.HostSelectorTerm) (res []*ec2.DescribeHostsInput) {
+	var ids []string
+	for _, term := range terms {
+		switch {
+		case term.ID != "":
+			ids = append(ids, term.ID)
+		default:
+			filters := []ec2types.Filter{{
+				Name:   aws.String("state"),
+				Values: []string{string(ec2types.AllocationStateAvailable)},
+			}}
+			for k, v := range term.Tags {
+				if v == "*" {
+					filters = append(filters, ec2types.Filter{
+						Name:   aws.String("tag-key"),
+						Values: []string{k},
+					})
+				} else {
+					filters = append(filters, ec2types.Filter{
+						Name:   aws.String(fmt.Sprintf("tag:%s", k)),
+						Values: []string{v},
+					})
+				}
+			}
+			res = append(res, &ec2.DescribeHostsInput{Filter: filters})
+		}
+	}
+	if len(ids) > 0 {
+		res = append(res, &ec2.DescribeHostsInput{HostIds: ids})
+	}
+	return res
+}
@@ -21,6 +21,7 @@ import (
 	"math"
 	"sort"
 	"strings"
+	"sync"
 
 	sdk "github.com/aws/karpenter-provider-aws/pkg/aws"
 
@@ -42,7 +43,10 @@ import (
 	"github.com/aws/karpenter-provider-aws/pkg/cache"
 	awserrors "github.com/aws/karpenter-provider-aws/pkg/errors"
 	"github.com/aws/karpenter-provider-aws/pkg/operator/options"
+	"github.com/aws/karpenter-provider-aws/pkg/providers/capacityreservation"
 	"github.com/aws/karpenter-provider-aws/pkg/providers/launchtemplate"
+	"github.com/aws/karpenter-provider-aws/pkg/providers/servicequota"
+	"github.com/aws/karpenter-provider-aws/pkg/providers/spotplacementscore"
 	"github.com/aws/karpenter-provider-aws/pkg/providers/subnet"
 	"github.com/aws/karpenter-provider-aws/pkg/utils"
 
@@ -74,26 +78,39 @@ type Provider interface {
 	List(context.Context) ([]*Instance, error)
 	Delete(context.Context, string) error
 	CreateTags(context.Context, string, map[string]string) error
+	Start(context.Context, string) error
+	Stop(context.Context, string) error
+	ListWarm(context.Context) ([]*Instance, error)
+	CreateFromWarmPool(context.Context, *v1.EC2NodeClass, *karpv1.NodeClaim, map[string]string, []*cloudprovider.InstanceType) (*Instance, error)
 }
 
 type DefaultProvider struct {
-	region                 string
-	ec2api                 sdk.EC2API
-	unavailableOfferings   *cache.UnavailableOfferings
-	subnetProvider         subnet.Provider
-	launchTemplateProvider launchtemplate.Provider
-	ec2Batcher             *batcher.EC2API
+	region                      string
+	ec2api                      sdk.EC2API
+	unavailableOfferings        *cache.UnavailableOfferings
+	subnetProvider              subnet.Provider
+	launchTemplateProvider      launchtemplate.Provider
+	capacityReservationProvider capacityreservation.Provider
+	spotPlacementScoreProvider  spotplacementscore.Provider
+	serviceQuotaProvider        servicequota.Provider
+	ec2Batcher                  *batcher.EC2API
+	// warmPoolMu ensures that a warm instance is only claimed by a single NodeClaim
+	warmPoolMu sync.Mutex
 }
 
-func NewDefaultProvider(ctx context.Context, region string, ec2api sdk.EC2API, unavailableOfferings *cache.UnavailableOfferings,
-	subnetProvider subnet.Provider, launchTemplateProvider launchtemplate.Provider) *DefaultProvider {
+func NewDefaultProvider(region string, ec2api sdk.EC2API, ec2Batcher *batcher.EC2API, unavailableOfferings *cache.UnavailableOfferings,
+	subnetProvider subnet.Provider, launchTemplateProvider launchtemplate.Provider, capacityReservationProvider capacityreservation.Provider,
+	spotPlacementScoreProvider spotplacementscore.Provider, serviceQuotaProvider servicequota.Provider) *DefaultProvider {
 	return &DefaultProvider{
-		region:                 region,
-		ec2api:                 ec2api,
-		unavailableOfferings:   unavailableOfferings,
-		subnetProvider:         subnetProvider,
-		launchTemplateProvider: launchTemplateProvider,
-		ec2Batcher:             batcher.EC2(ctx, ec2api),
+		region:                      region,
+		ec2api:                      ec2api,
+		unavailableOfferings:        unavailableOfferings,
+		subnetProvider:              subnetProvider,
+		launchTemplateProvider:      launchTemplateProvider,
+		capacityReservationProvider: capacityReservationProvider,
+		spotPlacementScoreProvider:  spotPlacementScoreProvider,
+		serviceQuotaProvider:        serviceQuotaProvider,
+		ec2Batcher:                  ec2Batcher,
 	}
 }
 
@@ -101,23 +118,33 @@ func (p *DefaultProvider) Create(ctx context.Context, nodeClass *v1.EC2NodeClass
 	schedulingRequirements := scheduling.NewNodeSelectorRequirementsWithMinValues(nodeClaim.Spec.Requirements...)
 	// Only filter the instances if there are no minValues in the requirement.
 	if !schedulingRequirements.HasMinValues() {
-		instanceTypes = p.filterInstanceTypes(nodeClaim, instanceTypes)
+		instanceTypes = p.filterInstanceTypes(nodeClass, nodeClaim, instanceTypes)
 	}
-	instanceTypes, err := cloudprovider.InstanceTypes(instanceTypes).Truncate(schedulingRequirements, maxInstanceTypes)
+	instanceTypes, err := truncateInstanceTypes(nodeClass, schedulingRequirements, instanceTypes)
 	if err != nil {
 		return nil, cloudprovider.NewCreateError(fmt.Errorf("truncating instance types, %w", err), "InstanceTypeResolutionFailed", "Error truncating instance types based on the passed-in requirements")
 	}
-	fleetInstance, err := p.launchInstance(ctx, nodeClass, nodeClaim, instanceTypes, tags)
+	fleetInstance, capacityReservationID, err := p.launchInstance(ctx, nodeClass, nodeClaim, instanceTypes, tags)
 	if awserrors.IsLaunchTemplateNotFound(err) {
 		// retry once if launch template is not found. This allows karpenter to generate a new LT if the
 		// cache was out-of-sync on the first try
-		fleetInstance, err = p.launchInstance(ctx, nodeClass, nodeClaim, instanceTypes, tags)
+		fleetInstance, capacityReservationID, err = p.launchInstance(ctx, nodeClass, nodeClaim, instanceTypes, tags)
 	}
 	if err != nil {
 		return nil, err
 	}
 	efaEnabled := lo.Contains(lo.Keys(nodeClaim.Spec.Resources.Requests), v1.ResourceEFA)
-	return NewInstanceFromFleet(fleetInstance, tags, efaEnabled), nil
+	instance := NewInstanceFromFleet(fleetInstance, capacityReservationID, tags, efaEnabled)
+	if nodeClass.Status.PlacementGroup != nil && nodeClass.Status.PlacementGroup.Strategy == string(ec2types.PlacementStrategyPartition) {
+		// EC2 assigns the partition at launch and doesn't return it from CreateFleet, so we describe the instance to
+		// discover it. Failing to discover the partition shouldn't fail the launch, so the partition label is omitted.
+		if out, err := p.Get(ctx, instance.ID); err != nil {
+			log.FromContext(ctx).WithValues("instance-id", instance.ID).V(1).Info(fmt.Sprintf("unable to discover placement group partition, %s", err))
+		} else {
+			instance.PartitionNumber = out.PartitionNumber
+		}
+	}
+	return instance, nil
 }
 
 func (p *DefaultProvider) Get(ctx context.Context, id string) (*Instance, error) {
@@ -207,18 +234,162 @@ func (p *DefaultProvider) CreateTags(ctx context.Context, id string, tags map[st
 	return nil
 }
 
-func (p *DefaultProvider) launchInstance(ctx context.Context, nodeClass *v1.EC2NodeClass, nodeClaim *karpv1.NodeClaim, instanceTypes []*cloudprovider.InstanceType, tags map[string]string) (ec2types.CreateFleetInstance, error) {
+func (p *DefaultProvider) Start(ctx context.Context, id string) error {
+	if _, err := p.ec2api.StartInstances(ctx, &ec2.StartInstancesInput{
+		InstanceIds: []string{id},
+	}); err != nil {
+		if awserrors.IsNotFound(err) {
+			return cloudprovider.NewNodeClaimNotFoundError(fmt.Errorf("starting instance, %w", err))
+		}
+		return fmt.Errorf("starting instance, %w", err)
+	}
+	return nil
+}
+
+func (p *DefaultProvider) Stop(ctx context.Context, id string) error {
+	if _, err := p.ec2api.StopInstances(ctx, &ec2.StopInstancesInput{
+		InstanceIds: []string{id},
+	}); err != nil {
+		if awserrors.IsNotFound(err) {
+			return cloudprovider.NewNodeClaimNotFoundError(fmt.Errorf("stopping instance, %w", err))
+		}
+		return fmt.Errorf("stopping instance, %w", err)
+	}
+	return nil
+}
+
+// ListWarm returns the instances of the cluster that belong to an EC2NodeClass warm pool. Warm instances don't carry
+// the NodePool tag, so they are never returned by List.
+func (p *DefaultProvider) ListWarm(ctx context.Context) ([]*Instance, error) {
+	var out = &ec2.DescribeInstancesOutput{}
+
+	paginator := ec2.NewDescribeInstancesPaginator(p.ec2api, &ec2.DescribeInstancesInput{
+		Filters: []ec2types.Filter{
+			{
+				Name:   aws.String("tag-key"),
+				Values: []string{v1.WarmPoolTagKey},
+			},
+			{
+				Name:   aws.String(fmt.Sprintf("tag:%s", v1.EKSClusterNameTagKey)),
+				Values: []string{options.FromContext(ctx).ClusterName},
+			},
+			instanceStateFilter,
+		},
+	})
+
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("describing ec2 instances, %w", err)
+		}
+		out.Reservations = append(out.Reservations, page.Reservations...)
+	}
+	instances, err := instancesFromOutput(out)
+	return instances, cloudprovider.IgnoreNodeClaimNotFoundError(err)
+}
+
+// CreateFromWarmPool claims the cheapest stopped instance from the EC2NodeClass' warm pool that is compatible with the
+// NodeClaim and starts it. Only instances that were bootstrapped with the NodeClaim's labels and taints are compatible.
+// Nil is returned when the EC2NodeClass has no warm pool or none of its instances fit, in which case the caller should
+// launch a new instance.
+func (p *DefaultProvider) CreateFromWarmPool(ctx context.Context, nodeClass *v1.EC2NodeClass, nodeClaim *karpv1.NodeClaim, tags map[string]string, instanceTypes []*cloudprovider.InstanceType) (*Instance, error) {
+	if nodeClass.Spec.WarmPool == nil || nodeClass.Spec.WarmPool.Size == 0 {
+		return nil, nil
+	}
+	reqs := scheduling.NewNodeSelectorRequirementsWithMinValues(nodeClaim.Spec.Requirements...)
+	if !reqs.Get(karpv1.CapacityTypeLabelKey).Has(karpv1.CapacityTypeOnDemand) {
+		return nil, nil
+	}
+	p.warmPoolMu.Lock()
+	defer p.warmPoolMu.Unlock()
+
+	instances, err := p.ListWarm(ctx)
+	if err != nil {
+		return nil, err
+	}
+	candidates := p.warmPoolCandidates(nodeClass, nodeClass.WarmPoolHash(nodeClaim), reqs, instances, instanceTypes)
+	for _, candidate := range candidates {
+		// The instance is claimed before it is started so that it's no longer considered part of the warm pool
+		if err := p.CreateTags(ctx, candidate.ID, tags); err != nil {
+			log.FromContext(ctx).WithValues("instance-id", candidate.ID).V(1).Info(fmt.Sprintf("failed claiming warm instance, %s", err))
+			continue
+		}
+		if _, err := p.ec2api.DeleteTags(ctx, &ec2.DeleteTagsInput{
+			Resources: []string{candidate.ID},
+			Tags:      []ec2types.Tag{{Key: aws.String(v1.WarmPoolTagKey)}},
+		}); err != nil {
+			log.FromContext(ctx).WithValues("instance-id", candidate.ID).V(1).Info(fmt.Sprintf("failed claiming warm instance, %s", err))
+			continue
+		}
+		if err := p.Start(ctx, candidate.ID); err != nil {
+			// The instance has been claimed, so we terminate it rather than returning it to the warm pool
+			log.FromContext(ctx).WithValues("instance-id", candidate.ID).V(1).Info(fmt.Sprintf("failed starting warm instance, %s", err))
+			if err := p.Delete(ctx, candidate.ID); cloudprovider.IgnoreNodeClaimNotFoundError(err) != nil {
+				log.FromContext(ctx).WithValues("instance-id", candidate.ID).Error(err, "failed terminating warm instance")
+			}
+			continue
+		}
+		candidate.State = ec2types.InstanceStateNamePending
+		candidate.Tags = lo.OmitByKeys(lo.Assign(candidate.Tags, tags), []string{v1.WarmPoolTagKey})
+		return candidate, nil
+	}
+	return nil, nil
+}
+
+// warmPoolCandidates returns the stopped warm instances of the EC2NodeClass that were launched with the warm pool hash
+// and have an available on-demand offering compatible with the requirements, ordered by price
+func (p *DefaultProvider) warmPoolCandidates(nodeClass *v1.EC2NodeClass, hash string, reqs scheduling.Requirements, instances []*Instance, instanceTypes []*cloudprovider.InstanceType) []*Instance {
+	prices := map[string]float64{}
+	candidates := lo.Filter(instances, func(i *Instance, _ int) bool {
+		if i.State != ec2types.InstanceStateNameStopped || i.Tags[v1.NodeClassTagKey] != nodeClass.Name || i.Tags[v1.WarmPoolTagKey] != hash {
+			return false
+		}
+		if !lo.ContainsBy(nodeClass.Status.AMIs, func(ami v1.AMI) bool { return ami.ID == i.ImageID }) {
+			return false
+		}
+		instanceType, ok := lo.Find(instanceTypes, func(it *cloudprovider.InstanceType) bool { return it.Name == string(i.Type) })
+		if !ok {
+			return false
+		}
+		offeringReqs := scheduling.NewRequirements(reqs.Values()...)
+		offeringReqs.Add(
+			scheduling.NewRequirement(karpv1.CapacityTypeLabelKey, corev1.NodeSelectorOpIn, karpv1.CapacityTypeOnDemand),
+			scheduling.NewRequirement(corev1.LabelTopologyZone, corev1.NodeSelectorOpIn, i.Zone),
+		)
+		offerings := instanceType.Offerings.Available().Compatible(offeringReqs)
+		if len(offerings) == 0 {
+			return false
+		}
+		prices[i.ID] = offerings.Cheapest().Price
+		return true
+	})
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return prices[candidates[i].ID] < prices[candidates[j].ID]
+	})
+	return candidates
+}
+
+// launchInstance launches an instance through CreateFleet and returns the fleet instance along with the ID of the
+// capacity reservation that the instance was launched into, if any
+//
+//nolint:gocyclo
+func (p *DefaultProvider) launchInstance(ctx context.Context, nodeClass *v1.EC2NodeClass, nodeClaim *karpv1.NodeClaim, instanceTypes []*cloudprovider.InstanceType, tags map[string]string) (ec2types.CreateFleetInstance, string, error) {
 	capacityType := p.getCapacityType(nodeClaim, instanceTypes)
+	// Concurrent launches may have used up a vCPU quota since the instance types were resolved
+	instanceTypes = p.filterServiceQuotaInstanceTypes(instanceTypes, capacityType)
+	if len(instanceTypes) == 0 {
+		return ec2types.CreateFleetInstance{}, "", cloudprovider.NewInsufficientCapacityError(fmt.Errorf("launching the instance types would exceed the %s vcpu service quota", capacityType))
+	}
 	zonalSubnets, err := p.subnetProvider.ZonalSubnetsForLaunch(ctx, nodeClass, instanceTypes, capacityType)
 	if err != nil {
-		return ec2types.CreateFleetInstance{}, cloudprovider.NewCreateError(fmt.Errorf("getting subnets, %w", err), "SubnetResolutionFailed", "Error getting subnets")
+		return ec2types.CreateFleetInstance{}, "", cloudprovider.NewCreateError(fmt.Errorf("getting subnets, %w", err), "SubnetResolutionFailed", "Error getting subnets")
 	}
 
 	// Get Launch Template Configs, which may differ due to GPU or Architecture requirements
-	launchTemplateConfigs, err := p.getLaunchTemplateConfigs(ctx, nodeClass, nodeClaim, instanceTypes, zonalSubnets, capacityType, tags)
+	launchTemplateConfigs, capacityReservationIDs, err := p.getLaunchTemplateConfigs(ctx, nodeClass, nodeClaim, instanceTypes, zonalSubnets, capacityType, tags)
 	if err != nil {
 		reason, message := awserrors.ToReasonMessage(err)
-		return ec2types.CreateFleetInstance{}, cloudprovider.NewCreateError(fmt.Errorf("getting launch template configs, %w", err), reason, fmt.Sprintf("Error getting launch template configs: %s", message))
+		return ec2types.CreateFleetInstance{}, "", cloudprovider.NewCreateError(fmt.Errorf("getting launch template configs, %w", err), reason, fmt.Sprintf("Error getting launch template configs: %s", message))
 	}
 	if err := p.checkODFallback(nodeClaim, instanceTypes, launchTemplateConfigs); err != nil {
 		log.FromContext(ctx).Error(err, "failed while checking on-demand fallback")
@@ -229,7 +400,7 @@ func (p *DefaultProvider) launchInstance(ctx context.Context, nodeClass *v1.EC2N
 		Context:               nodeClass.Spec.Context,
 		LaunchTemplateConfigs: launchTemplateConfigs,
 		TargetCapacitySpecification: &ec2types.TargetCapacitySpecificationRequest{
-			DefaultTargetCapacityType: ec2types.DefaultTargetCapacityType(capacityType),
+			DefaultTargetCapacityType: getTargetCapacityType(nodeClass, capacityType, capacityReservationIDs),
 			TotalTargetCapacity:       aws.Int32(1),
 		},
 		TagSpecifications: []ec2types.TagSpecification{
@@ -239,32 +410,53 @@ func (p *DefaultProvider) launchInstance(ctx context.Context, nodeClass *v1.EC2N
 		},
 	}
 	if capacityType == karpv1.CapacityTypeSpot {
-		createFleetInput.SpotOptions = &ec2types.SpotOptionsRequest{AllocationStrategy: ec2types.SpotAllocationStrategyPriceCapacityOptimized}
+		createFleetInput.SpotOptions = &ec2types.SpotOptionsRequest{AllocationStrategy: ec2types.SpotAllocationStrategy(nodeClass.SpotAllocationStrategy())}
 	} else {
-		createFleetInput.OnDemandOptions = &ec2types.OnDemandOptionsRequest{AllocationStrategy: ec2types.FleetOnDemandAllocationStrategyLowestPrice}
+		createFleetInput.OnDemandOptions = &ec2types.OnDemandOptionsRequest{AllocationStrategy: ec2types.FleetOnDemandAllocationStrategy(nodeClass.OnDemandAllocationStrategy())}
 	}
 
 	createFleetOutput, err := p.ec2Batcher.CreateFleet(ctx, createFleetInput)
-	p.subnetProvider.UpdateInflightIPs(createFleetInput, createFleetOutput, instanceTypes, lo.Values(zonalSubnets), capacityType)
+	p.subnetProvider.UpdateInflightIPs(createFleetInput, createFleetOutput, instanceTypes, lo.Values(zonalSubnets), capacityType, nodeClass.IPAddressMode())
 	if err != nil {
 		if awserrors.IsLaunchTemplateNotFound(err) {
 			for _, lt := range launchTemplateConfigs {
 				p.launchTemplateProvider.InvalidateCache(ctx, aws.ToString(lt.LaunchTemplateSpecification.LaunchTemplateName), aws.ToString(lt.LaunchTemplateSpecification.LaunchTemplateId))
 			}
-			return ec2types.CreateFleetInstance{}, fmt.Errorf("creating fleet %w", err)
+			return ec2types.CreateFleetInstance{}, "", fmt.Errorf("creating fleet %w", err)
 		}
 		reason, message := awserrors.ToReasonMessage(err)
 		var reqErr *awshttp.ResponseError
 		if errors.As(err, &reqErr) {
-			return ec2types.CreateFleetInstance{}, cloudprovider.NewCreateError(fmt.Errorf("creating fleet request, %w (%v)", err, reqErr.ServiceRequestID()), reason, fmt.Sprintf("Error creating fleet request: %s", message))
+			return ec2types.CreateFleetInstance{}, "", cloudprovider.NewCreateError(fmt.Errorf("creating fleet request, %w (%v)", err, reqErr.ServiceRequestID()), reason, fmt.Sprintf("Error creating fleet request: %s", message))
 		}
-		return ec2types.CreateFleetInstance{}, cloudprovider.NewCreateError(fmt.Errorf("creating fleet request, %w", err), reason, fmt.Sprintf("Error creating fleet request: %s", message))
+		return ec2types.CreateFleetInstance{}, "", cloudprovider.NewCreateError(fmt.Errorf("creating fleet request, %w", err), reason, fmt.Sprintf("Error creating fleet request: %s", message))
 	}
-	p.updateUnavailableOfferingsCache(ctx, createFleetOutput.Errors, capacityType)
+	p.updateUnavailableOfferingsCache(ctx, createFleetOutput.Errors, capacityType, capacityReservationIDs, placementGroupID(nodeClass))
 	if len(createFleetOutput.Instances) == 0 || len(createFleetOutput.Instances[0].InstanceIds) == 0 {
-		return ec2types.CreateFleetInstance{}, combineFleetErrors(createFleetOutput.Errors)
+		return ec2types.CreateFleetInstance{}, "", combineFleetErrors(createFleetOutput.Errors)
+	}
+	var capacityReservationID string
+	if capacityType == v1.CapacityTypeReserved {
+		capacityReservationID = capacityReservationIDs[launchTemplateName(createFleetOutput.Instances[0].LaunchTemplateAndOverrides)]
+		p.capacityReservationProvider.MarkLaunched(capacityReservationID)
+	} else if it, ok := lo.Find(instanceTypes, func(it *cloudprovider.InstanceType) bool {
+		return it.Name == string(createFleetOutput.Instances[0].InstanceType)
+	}); ok {
+		// Reserved capacity doesn't count towards a quota, the same as when instance types are filtered by their quotas
+		p.serviceQuotaProvider.MarkLaunched(it.Name, capacityType, it.Capacity.Cpu().Value())
+	}
+	return createFleetOutput.Instances[0], capacityReservationID, nil
+}
+
+// filterServiceQuotaInstanceTypes removes the instance types that would exceed the vCPU quota of their instance class
+// for the capacity type. Reserved capacity is launched into an existing reservation, so it doesn't count towards a quota.
+func (p *DefaultProvider) filterServiceQuotaInstanceTypes(instanceTypes []*cloudprovider.InstanceType, capacityType string) []*cloudprovider.InstanceType {
+	if capacityType == v1.CapacityTypeReserved {
+		return instanceTypes
 	}
-	return createFleetOutput.Instances[0], nil
+	return lo.Filter(instanceTypes, func(it *cloudprovider.InstanceType, _ int) bool {
+		return p.serviceQuotaProvider.Fits(it.Name, capacityType, it.Capacity.Cpu().Value())
+	})
 }
 
 func (p *DefaultProvider) checkODFallback(nodeClaim *karpv1.NodeClaim, instanceTypes []*cloudprovider.InstanceType, launchTemplateConfigs []ec2types.FleetLaunchTemplateConfigRequest) error {
@@ -287,18 +479,38 @@ func (p *DefaultProvider) checkODFallback(nodeClaim *karpv1.NodeClaim, instanceT
 	return nil
 }
 
+// getLaunchTemplateConfigs returns the launch template configs for the fleet request along with a mapping of launch
+// template name to the capacity reservation that the launch template targets, for reserved launches
 func (p *DefaultProvider) getLaunchTemplateConfigs(ctx context.Context, nodeClass *v1.EC2NodeClass, nodeClaim *karpv1.NodeClaim,
-	instanceTypes []*cloudprovider.InstanceType, zonalSubnets map[string]*subnet.Subnet, capacityType string, tags map[string]string) ([]ec2types.FleetLaunchTemplateConfigRequest, error) {
+	instanceTypes []*cloudprovider.InstanceType, zonalSubnets map[string]*subnet.Subnet, capacityType string, tags map[string]string) ([]ec2types.FleetLaunchTemplateConfigRequest, map[string]string, error) {
 	var launchTemplateConfigs []ec2types.FleetLaunchTemplateConfigRequest
+	capacityReservationIDs := map[string]string{}
 	launchTemplates, err := p.launchTemplateProvider.EnsureAll(ctx, nodeClass, nodeClaim, instanceTypes, capacityType, tags)
 	if err != nil {
-		return nil, fmt.Errorf("getting launch templates, %w", err)
+		return nil, nil, fmt.Errorf("getting launch templates, %w", err)
+	}
+	if capacityType == v1.CapacityTypeReserved {
+		launchTemplates = filterReservedLaunchTemplates(launchTemplates)
 	}
 	requirements := scheduling.NewNodeSelectorRequirementsWithMinValues(nodeClaim.Spec.Requirements...)
 	requirements[karpv1.CapacityTypeLabelKey] = scheduling.NewRequirement(karpv1.CapacityTypeLabelKey, corev1.NodeSelectorOpIn, capacityType)
+	priorities := instanceTypePriorities(nodeClass, capacityType)
 	for _, launchTemplate := range launchTemplates {
+		reqs := requirements
+		if launchTemplate.CapacityReservationID != "" {
+			// Constrain the overrides to the offerings of the capacity reservation that the launch template targets
+			reqs = scheduling.NewRequirements(requirements.Values()...)
+			reqs[v1.LabelCapacityReservationID] = scheduling.NewRequirement(v1.LabelCapacityReservationID, corev1.NodeSelectorOpIn, launchTemplate.CapacityReservationID)
+			capacityReservationIDs[launchTemplate.Name] = launchTemplate.CapacityReservationID
+		}
+		if launchTemplate.Zone != "" {
+			// Constrain the overrides to the zone of the subnets that the launch template's network interfaces are created in,
+			// or of the Dedicated Host that it places instances onto
+			reqs = scheduling.NewRequirements(reqs.Values()...)
+			reqs.Add(scheduling.NewRequirement(corev1.LabelTopologyZone, corev1.NodeSelectorOpIn, launchTemplate.Zone))
+		}
 		launchTemplateConfig := ec2types.FleetLaunchTemplateConfigRequest{
-			Overrides: p.getOverrides(launchTemplate.InstanceTypes, zonalSubnets, requirements, launchTemplate.ImageID),
+			Overrides: p.getOverrides(launchTemplate.InstanceTypes, zonalSubnets, reqs, launchTemplate.ImageID, priorities),
 			LaunchTemplateSpecification: &ec2types.FleetLaunchTemplateSpecificationRequest{
 				LaunchTemplateName: aws.String(launchTemplate.Name),
 				Version:            aws.String("$Latest"),
@@ -308,15 +520,147 @@ func (p *DefaultProvider) getLaunchTemplateConfigs(ctx context.Context, nodeClas
 			launchTemplateConfigs = append(launchTemplateConfigs, launchTemplateConfig)
 		}
 	}
+	if capacityType == karpv1.CapacityTypeSpot {
+		zoneScores := p.spotZoneScores(launchTemplateConfigs, zonalSubnets)
+		launchTemplateConfigs = filterLowScoreSpotOverrides(launchTemplateConfigs, zoneScores)
+		orderSpotOverridesByScore(launchTemplateConfigs, zoneScores, priorities)
+	}
 	if len(launchTemplateConfigs) == 0 {
-		return nil, fmt.Errorf("no capacity offerings are currently available given the constraints")
+		return nil, nil, fmt.Errorf("no capacity offerings are currently available given the constraints")
+	}
+	return launchTemplateConfigs, capacityReservationIDs, nil
+}
+
+// spotZoneScores returns the spot placement scores, by zone name, of launching a single instance of any of the
+// instance types of the fleet request. Scores are only known once the set of instance types has been scored, which
+// happens periodically for the sets that fleet requests are made for.
+func (p *DefaultProvider) spotZoneScores(launchTemplateConfigs []ec2types.FleetLaunchTemplateConfigRequest, zonalSubnets map[string]*subnet.Subnet) map[string]int32 {
+	instanceTypes := lo.FlatMap(launchTemplateConfigs, func(ltc ec2types.FleetLaunchTemplateConfigRequest, _ int) []string {
+		return lo.Map(ltc.Overrides, func(override ec2types.FleetLaunchTemplateOverridesRequest, _ int) string {
+			return string(override.InstanceType)
+		})
+	})
+	scores, ok := p.spotPlacementScoreProvider.Scores(instanceTypes)
+	if !ok {
+		return nil
+	}
+	return lo.MapEntries(zonalSubnets, func(zone string, subnet *subnet.Subnet) (string, int32) {
+		return zone, scores[subnet.ZoneID]
+	})
+}
+
+// filterLowScoreSpotOverrides removes the spot pools in zones with a low spot placement score from the fleet request,
+// so long as at least one pool in a zone without a low score remains. This steers launches away from zones that are
+// unlikely to fulfill the request before we learn about them through an insufficient capacity error.
+func filterLowScoreSpotOverrides(launchTemplateConfigs []ec2types.FleetLaunchTemplateConfigRequest, zoneScores map[string]int32) []ec2types.FleetLaunchTemplateConfigRequest {
+	isLowScore := func(override ec2types.FleetLaunchTemplateOverridesRequest, _ int) bool {
+		// Zones without a known score have a score of 0
+		score := zoneScores[aws.ToString(override.AvailabilityZone)]
+		return score != 0 && score < spotplacementscore.LowScoreThreshold
+	}
+	if lo.EveryBy(launchTemplateConfigs, func(ltc ec2types.FleetLaunchTemplateConfigRequest) bool {
+		return len(lo.Reject(ltc.Overrides, isLowScore)) == 0
+	}) {
+		return launchTemplateConfigs
+	}
+	return lo.FilterMap(launchTemplateConfigs, func(ltc ec2types.FleetLaunchTemplateConfigRequest, _ int) (ec2types.FleetLaunchTemplateConfigRequest, bool) {
+		ltc.Overrides = lo.Reject(ltc.Overrides, isLowScore)
+		return ltc, len(ltc.Overrides) > 0
+	})
+}
+
+// orderSpotOverridesByScore orders the spot pools of each launch template by the descending spot placement score of
+// their zone, with the pools in zones without a known score last. When launching with a prioritized allocation
+// strategy, which is the only strategy that takes the order of the pools into account, the pools of instance types
+// without a priority are prioritized by the score of their zone below the instance types with a priority.
+func orderSpotOverridesByScore(launchTemplateConfigs []ec2types.FleetLaunchTemplateConfigRequest, zoneScores map[string]int32, priorities map[string]float64) {
+	score := func(override ec2types.FleetLaunchTemplateOverridesRequest) int32 {
+		return zoneScores[aws.ToString(override.AvailabilityZone)]
+	}
+	lowestPriority := lo.Max(lo.Values(priorities)) + 1
+	for i := range launchTemplateConfigs {
+		overrides := launchTemplateConfigs[i].Overrides
+		sort.SliceStable(overrides, func(a, b int) bool { return score(overrides[a]) > score(overrides[b]) })
+		if priorities == nil {
+			continue
+		}
+		for j := range overrides {
+			if overrides[j].Priority == nil {
+				overrides[j].Priority = lo.ToPtr(lowestPriority + float64(spotplacementscore.MaxScore-score(overrides[j])))
+			}
+		}
+	}
+}
+
+// filterReservedLaunchTemplates ensures that a single fleet request doesn't target both On-Demand Capacity Reservations
+// and capacity blocks, since they require different target capacity types. On-Demand Capacity Reservations are
+// preferred since instances launched into capacity blocks are terminated when the block expires.
+func filterReservedLaunchTemplates(launchTemplates []*launchtemplate.LaunchTemplate) []*launchtemplate.LaunchTemplate {
+	odcrLaunchTemplates := lo.Reject(launchTemplates, func(lt *launchtemplate.LaunchTemplate, _ int) bool {
+		return lt.CapacityReservationType == v1.CapacityReservationTypeCapacityBlock
+	})
+	if len(odcrLaunchTemplates) != 0 {
+		return odcrLaunchTemplates
 	}
-	return launchTemplateConfigs, nil
+	return launchTemplates
+}
+
+// getTargetCapacityType returns the fleet target capacity type for the capacity type. Instances launched into an
+// On-Demand Capacity Reservation are on-demand instances from the perspective of EC2, while instances launched into a
+// capacity block use the capacity-block market type.
+func getTargetCapacityType(nodeClass *v1.EC2NodeClass, capacityType string, capacityReservationIDs map[string]string) ec2types.DefaultTargetCapacityType {
+	if capacityType != v1.CapacityTypeReserved {
+		return ec2types.DefaultTargetCapacityType(capacityType)
+	}
+	if lo.SomeBy(nodeClass.Status.CapacityReservations, func(cr v1.CapacityReservation) bool {
+		return cr.ReservationType == v1.CapacityReservationTypeCapacityBlock && lo.Contains(lo.Values(capacityReservationIDs), cr.ID)
+	}) {
+		return ec2types.DefaultTargetCapacityTypeCapacityBlock
+	}
+	return ec2types.DefaultTargetCapacityTypeOnDemand
+}
+
+// truncateInstanceTypes truncates the instance types to the cheapest maxInstanceTypes. Instance types with a launch
+// priority are kept ahead of cheaper instance types without one, so that a prioritized instance type isn't dropped
+// before it reaches the fleet request.
+func truncateInstanceTypes(nodeClass *v1.EC2NodeClass, requirements scheduling.Requirements, instanceTypes []*cloudprovider.InstanceType) (cloudprovider.InstanceTypes, error) {
+	priorities := lo.Assign(instanceTypePriorities(nodeClass, karpv1.CapacityTypeSpot), instanceTypePriorities(nodeClass, karpv1.CapacityTypeOnDemand))
+	if len(priorities) == 0 {
+		return cloudprovider.InstanceTypes(instanceTypes).Truncate(requirements, maxInstanceTypes)
+	}
+	prioritized, unprioritized := lo.FilterReject(cloudprovider.InstanceTypes(instanceTypes).OrderByPrice(requirements), func(it *cloudprovider.InstanceType, _ int) bool {
+		_, ok := priorities[it.Name]
+		return ok
+	})
+	truncated := cloudprovider.InstanceTypes(lo.Slice(append(prioritized, unprioritized...), 0, maxInstanceTypes))
+	if requirements.HasMinValues() {
+		if _, err := truncated.SatisfiesMinValues(requirements); err != nil {
+			return instanceTypes, fmt.Errorf("validating minValues, %w", err)
+		}
+	}
+	return truncated, nil
+}
+
+// instanceTypePriorities returns the launch priority of each instance type with a configured priority, if the
+// allocation strategy for the capacity type is prioritized
+func instanceTypePriorities(nodeClass *v1.EC2NodeClass, capacityType string) map[string]float64 {
+	if nodeClass.Spec.LaunchStrategy == nil {
+		return nil
+	}
+	if capacityType == karpv1.CapacityTypeSpot && nodeClass.SpotAllocationStrategy() != v1.SpotAllocationStrategyCapacityOptimizedPrioritized {
+		return nil
+	}
+	if capacityType != karpv1.CapacityTypeSpot && nodeClass.OnDemandAllocationStrategy() != v1.OnDemandAllocationStrategyPrioritized {
+		return nil
+	}
+	return lo.SliceToMap(nodeClass.Spec.LaunchStrategy.InstanceTypePriorities, func(p v1.InstanceTypePriority) (string, float64) {
+		return p.InstanceType, float64(p.Priority)
+	})
 }
 
 // getOverrides creates and returns launch template overrides for the cross product of InstanceTypes and subnets (with subnets being constrained by
-// zones and the offerings in InstanceTypes)
-func (p *DefaultProvider) getOverrides(instanceTypes []*cloudprovider.InstanceType, zonalSubnets map[string]*subnet.Subnet, reqs scheduling.Requirements, image string) []ec2types.FleetLaunchTemplateOverridesRequest {
+// zones and the offerings in InstanceTypes). Overrides are given the priority of their instance type, if one is set.
+func (p *DefaultProvider) getOverrides(instanceTypes []*cloudprovider.InstanceType, zonalSubnets map[string]*subnet.Subnet, reqs scheduling.Requirements, image string, priorities map[string]float64) []ec2types.FleetLaunchTemplateOverridesRequest {
 	// Unwrap all the offerings to a flat slice that includes a pointer
 	// to the parent instance type name
 	type offeringWithParentName struct {
@@ -342,37 +686,69 @@ func (p *DefaultProvider) getOverrides(instanceTypes []*cloudprovider.InstanceTy
 		if !ok {
 			continue
 		}
-		overrides = append(overrides, ec2types.FleetLaunchTemplateOverridesRequest{
+		override := ec2types.FleetLaunchTemplateOverridesRequest{
 			InstanceType: offering.parentInstanceTypeName,
 			SubnetId:     lo.ToPtr(subnet.ID),
 			ImageId:      aws.String(image),
 			// This is technically redundant, but is useful if we have to parse insufficient capacity errors from
 			// CreateFleet so that we can figure out the zone rather than additional API calls to look up the subnet
 			AvailabilityZone: lo.ToPtr(subnet.Zone),
-		})
+		}
+		if priority, ok := priorities[string(offering.parentInstanceTypeName)]; ok {
+			override.Priority = lo.ToPtr(priority)
+		}
+		overrides = append(overrides, override)
 	}
 	return overrides
 }
 
-func (p *DefaultProvider) updateUnavailableOfferingsCache(ctx context.Context, errors []ec2types.CreateFleetError, capacityType string) {
+func (p *DefaultProvider) updateUnavailableOfferingsCache(ctx context.Context, errors []ec2types.CreateFleetError, capacityType string, capacityReservationIDs map[string]string, placementGroupID string) {
 	for _, err := range errors {
 		if awserrors.IsUnfulfillableCapacity(err) {
-			p.unavailableOfferings.MarkUnavailableForFleetErr(ctx, err, capacityType)
+			p.unavailableOfferings.MarkUnavailableForFleetErr(ctx, err, capacityType, placementGroupID)
+			// The reservation that was targeted can no longer be launched into, so we stop offering it until we
+			// refresh its available instance count from EC2
+			if id, ok := capacityReservationIDs[launchTemplateName(err.LaunchTemplateAndOverrides)]; ok {
+				p.capacityReservationProvider.MarkUnavailable(id)
+			}
 		}
+		// The subnet has enough free addresses but none of them form a contiguous /28 prefix, so we stop choosing it for
+		// prefix delegation until it is defragmented
+		if awserrors.IsInsufficientCidrBlocks(err) && err.LaunchTemplateAndOverrides != nil && err.LaunchTemplateAndOverrides.Overrides != nil {
+			p.subnetProvider.MarkPrefixFragmented(aws.ToString(err.LaunchTemplateAndOverrides.Overrides.SubnetId))
+		}
+	}
+}
+
+// placementGroupID returns the id of the placement group that the EC2NodeClass launches instances into, if any
+func placementGroupID(nodeClass *v1.EC2NodeClass) string {
+	if nodeClass.Status.PlacementGroup == nil {
+		return ""
 	}
+	return nodeClass.Status.PlacementGroup.ID
 }
 
-// getCapacityType selects spot if both constraints are flexible and there is an
-// available offering. The AWS Cloud Provider defaults to [ on-demand ], so spot
-// must be explicitly included in capacity type requirements.
+func launchTemplateName(ltAndOverrides *ec2types.LaunchTemplateAndOverridesResponse) string {
+	if ltAndOverrides == nil || ltAndOverrides.LaunchTemplateSpecification == nil {
+		return ""
+	}
+	return aws.ToString(ltAndOverrides.LaunchTemplateSpecification.LaunchTemplateName)
+}
+
+// getCapacityType selects reserved if it is allowed by the constraints and there is an available reserved offering,
+// followed by spot if both constraints are flexible and there is an available offering. The AWS Cloud Provider
+// defaults to [ on-demand ], so reserved and spot must be explicitly included in capacity type requirements.
 func (p *DefaultProvider) getCapacityType(nodeClaim *karpv1.NodeClaim, instanceTypes []*cloudprovider.InstanceType) string {
-	requirements := scheduling.NewNodeSelectorRequirementsWithMinValues(nodeClaim.Spec.Requirements...)
-	if requirements.Get(karpv1.CapacityTypeLabelKey).Has(karpv1.CapacityTypeSpot) {
-		requirements[karpv1.CapacityTypeLabelKey] = scheduling.NewRequirement(karpv1.CapacityTypeLabelKey, corev1.NodeSelectorOpIn, karpv1.CapacityTypeSpot)
+	for _, capacityType := range []string{v1.CapacityTypeReserved, karpv1.CapacityTypeSpot} {
+		requirements := scheduling.NewNodeSelectorRequirementsWithMinValues(nodeClaim.Spec.Requirements...)
+		if !requirements.Get(karpv1.CapacityTypeLabelKey).Has(capacityType) {
+			continue
+		}
+		requirements[karpv1.CapacityTypeLabelKey] = scheduling.NewRequirement(karpv1.CapacityTypeLabelKey, corev1.NodeSelectorOpIn, capacityType)
 		for _, instanceType := range instanceTypes {
 			for _, offering := range instanceType.Offerings.Available() {
 				if requirements.Compatible(offering.Requirements, scheduling.AllowUndefinedWellKnownLabels) == nil {
-					return karpv1.CapacityTypeSpot
+					return capacityType
 				}
 			}
 		}
@@ -382,12 +758,12 @@ func (p *DefaultProvider) getCapacityType(nodeClaim *karpv1.NodeClaim, instanceT
 
 // filterInstanceTypes is used to provide filtering on the list of potential instance types to further limit it to those
 // that make the most sense given our specific AWS cloudprovider.
-func (p *DefaultProvider) filterInstanceTypes(nodeClaim *karpv1.NodeClaim, instanceTypes []*cloudprovider.InstanceType) []*cloudprovider.InstanceType {
+func (p *DefaultProvider) filterInstanceTypes(nodeClass *v1.EC2NodeClass, nodeClaim *karpv1.NodeClaim, instanceTypes []*cloudprovider.InstanceType) []*cloudprovider.InstanceType {
 	instanceTypes = filterExoticInstanceTypes(instanceTypes)
 	// If we could potentially launch either a spot or on-demand node, we want to filter out the spot instance types that
 	// are more expensive than the cheapest on-demand type.
 	if p.isMixedCapacityLaunch(nodeClaim, instanceTypes) {
-		instanceTypes = filterUnwantedSpot(instanceTypes)
+		instanceTypes = filterUnwantedSpot(nodeClass, instanceTypes)
 	}
 	return instanceTypes
 }
@@ -421,8 +797,12 @@ func (p *DefaultProvider) isMixedCapacityLaunch(nodeClaim *karpv1.NodeClaim, ins
 }
 
 // filterUnwantedSpot is used to filter out spot types that are more expensive than the cheapest on-demand type that we
-// could launch during mixed capacity-type launches
-func filterUnwantedSpot(instanceTypes []*cloudprovider.InstanceType) []*cloudprovider.InstanceType {
+// could launch during mixed capacity-type launches. Capacity optimized spot allocation strategies favor the pools that
+// are least likely to be interrupted over price, so no spot types are filtered out when they are used.
+func filterUnwantedSpot(nodeClass *v1.EC2NodeClass, instanceTypes []*cloudprovider.InstanceType) []*cloudprovider.InstanceType {
+	if lo.Contains([]string{v1.SpotAllocationStrategyCapacityOptimized, v1.SpotAllocationStrategyCapacityOptimizedPrioritized}, nodeClass.SpotAllocationStrategy()) {
+		return instanceTypes
+	}
 	cheapestOnDemand := math.MaxFloat64
 	// first, find the price of our cheapest available on-demand instance type that could support this node
 	for _, it := range instanceTypes {
@@ -23,9 +23,13 @@ import (
 	"sigs.k8s.io/karpenter/pkg/test/v1alpha1"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
 	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/aws/aws-sdk-go-v2/service/servicequotas"
+	servicequotastypes "github.com/aws/aws-sdk-go-v2/service/servicequotas/types"
 	"github.com/awslabs/operatorpkg/object"
 	"github.com/samber/lo"
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/client-go/tools/record"
@@ -33,6 +37,7 @@ import (
 	corecloudprovider "sigs.k8s.io/karpenter/pkg/cloudprovider"
 	"sigs.k8s.io/karpenter/pkg/events"
 	coreoptions "sigs.k8s.io/karpenter/pkg/operator/options"
+	"sigs.k8s.io/karpenter/pkg/scheduling"
 	coretest "sigs.k8s.io/karpenter/pkg/test"
 
 	"github.com/aws/karpenter-provider-aws/pkg/apis"
@@ -137,6 +142,234 @@ var _ = Describe("InstanceProvider", func() {
 		Expect(corecloudprovider.IsInsufficientCapacityError(err)).To(BeTrue())
 		Expect(instance).To(BeNil())
 	})
+	It("should mark a subnet as prefix fragmented when CreateFleet can't allocate a /28 prefix", func() {
+		nodeClass.Spec.IPAddressMode = lo.ToPtr(v1.IPAddressModePrefixDelegation)
+		ExpectApplied(ctx, env.Client, nodeClaim, nodePool, nodeClass)
+		nodeClass = ExpectExists(ctx, env.Client, nodeClass)
+		awsEnv.EC2API.CreateFleetBehavior.Output.Set(&ec2.CreateFleetOutput{
+			Errors: []ec2types.CreateFleetError{{
+				ErrorCode:    aws.String("InsufficientCidrBlocks"),
+				ErrorMessage: aws.String("The specified subnet does not have enough free cidr blocks to satisfy the request"),
+				LaunchTemplateAndOverrides: &ec2types.LaunchTemplateAndOverridesResponse{
+					Overrides: &ec2types.FleetLaunchTemplateOverrides{
+						InstanceType:     "m5.xlarge",
+						SubnetId:         aws.String("subnet-test1"),
+						AvailabilityZone: aws.String("test-zone-1a"),
+					},
+				},
+			}},
+		})
+		instanceTypes, err := cloudProvider.GetInstanceTypes(ctx, nodePool)
+		Expect(err).ToNot(HaveOccurred())
+		instanceTypes = lo.Filter(instanceTypes, func(i *corecloudprovider.InstanceType, _ int) bool { return i.Name == "m5.xlarge" })
+
+		_, err = awsEnv.InstanceProvider.Create(ctx, nodeClass, nodeClaim, nil, instanceTypes)
+		Expect(err).To(HaveOccurred())
+		Expect(awsEnv.SubnetProvider.IsPrefixFragmented("subnet-test1")).To(BeTrue())
+		Expect(awsEnv.SubnetProvider.IsPrefixFragmented("subnet-test2")).To(BeFalse())
+	})
+	Context("Spot Placement Scores", func() {
+		var instanceTypes []*corecloudprovider.InstanceType
+		BeforeEach(func() {
+			ExpectApplied(ctx, env.Client, nodeClaim, nodePool, nodeClass)
+			nodeClass = ExpectExists(ctx, env.Client, nodeClass)
+			its, err := cloudProvider.GetInstanceTypes(ctx, nodePool)
+			Expect(err).ToNot(HaveOccurred())
+			instanceTypes = lo.Filter(its, func(i *corecloudprovider.InstanceType, _ int) bool { return i.Name == "m5.xlarge" })
+		})
+		It("should score the set of instance types of spot fleet requests", func() {
+			_, err := awsEnv.InstanceProvider.Create(ctx, nodeClass, nodeClaim, nil, instanceTypes)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(awsEnv.SpotPlacementScoreProvider.UpdateScores(ctx)).To(Succeed())
+
+			Expect(awsEnv.EC2API.GetSpotPlacementScoresBehavior.CalledWithInput.Len()).To(Equal(1))
+			Expect(awsEnv.EC2API.GetSpotPlacementScoresBehavior.CalledWithInput.Pop().InstanceTypes).To(Equal([]string{"m5.xlarge"}))
+			_, ok := awsEnv.SpotPlacementScoreProvider.Scores([]string{"m5.xlarge"})
+			Expect(ok).To(BeTrue())
+		})
+		It("should not filter spot pools by the scores of other sets of instance types", func() {
+			awsEnv.EC2API.GetSpotPlacementScoresBehavior.Output.Set(&ec2.GetSpotPlacementScoresOutput{
+				SpotPlacementScores: []ec2types.SpotPlacementScore{
+					{AvailabilityZoneId: aws.String("tstz1-1a"), Region: aws.String(fake.DefaultRegion), Score: aws.Int32(1)},
+					{AvailabilityZoneId: aws.String("tstz1-1b"), Region: aws.String(fake.DefaultRegion), Score: aws.Int32(9)},
+				},
+			})
+			awsEnv.SpotPlacementScoreProvider.Scores([]string{"m5.xlarge", "m5.large"})
+			Expect(awsEnv.SpotPlacementScoreProvider.UpdateScores(ctx)).To(Succeed())
+
+			_, err := awsEnv.InstanceProvider.Create(ctx, nodeClass, nodeClaim, nil, instanceTypes)
+			Expect(err).ToNot(HaveOccurred())
+			createFleetInput := awsEnv.EC2API.CreateFleetBehavior.CalledWithInput.Pop()
+			zones := lo.FlatMap(createFleetInput.LaunchTemplateConfigs, func(ltc ec2types.FleetLaunchTemplateConfigRequest, _ int) []string {
+				return lo.Map(ltc.Overrides, func(o ec2types.FleetLaunchTemplateOverridesRequest, _ int) string {
+					return aws.ToString(o.AvailabilityZone)
+				})
+			})
+			Expect(zones).To(ContainElement("test-zone-1a"))
+		})
+		It("should exclude spot pools with a low spot placement score from the fleet request", func() {
+			awsEnv.EC2API.GetSpotPlacementScoresBehavior.Output.Set(&ec2.GetSpotPlacementScoresOutput{
+				SpotPlacementScores: []ec2types.SpotPlacementScore{
+					{AvailabilityZoneId: aws.String("tstz1-1a"), Region: aws.String(fake.DefaultRegion), Score: aws.Int32(1)},
+					{AvailabilityZoneId: aws.String("tstz1-1b"), Region: aws.String(fake.DefaultRegion), Score: aws.Int32(9)},
+				},
+			})
+			awsEnv.SpotPlacementScoreProvider.Scores([]string{"m5.xlarge"})
+			Expect(awsEnv.SpotPlacementScoreProvider.UpdateScores(ctx)).To(Succeed())
+
+			instance, err := awsEnv.InstanceProvider.Create(ctx, nodeClass, nodeClaim, nil, instanceTypes)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(instance.CapacityType).To(Equal(karpv1.CapacityTypeSpot))
+			Expect(awsEnv.EC2API.CreateFleetBehavior.CalledWithInput.Len()).To(Equal(1))
+			createFleetInput := awsEnv.EC2API.CreateFleetBehavior.CalledWithInput.Pop()
+			zones := lo.FlatMap(createFleetInput.LaunchTemplateConfigs, func(ltc ec2types.FleetLaunchTemplateConfigRequest, _ int) []string {
+				return lo.Map(ltc.Overrides, func(o ec2types.FleetLaunchTemplateOverridesRequest, _ int) string {
+					return aws.ToString(o.AvailabilityZone)
+				})
+			})
+			Expect(zones).ToNot(BeEmpty())
+			Expect(zones).ToNot(ContainElement("test-zone-1a"))
+			Expect(zones).To(ContainElement("test-zone-1b"))
+		})
+		It("should keep spot pools with a low spot placement score when no other pools are available", func() {
+			awsEnv.EC2API.GetSpotPlacementScoresBehavior.Output.Set(&ec2.GetSpotPlacementScoresOutput{
+				SpotPlacementScores: lo.Map([]string{"tstz1-1a", "tstz1-1b", "tstz1-1c", "tstz1-1alocal"}, func(zoneID string, _ int) ec2types.SpotPlacementScore {
+					return ec2types.SpotPlacementScore{AvailabilityZoneId: aws.String(zoneID), Region: aws.String(fake.DefaultRegion), Score: aws.Int32(1)}
+				}),
+			})
+			awsEnv.SpotPlacementScoreProvider.Scores([]string{"m5.xlarge"})
+			Expect(awsEnv.SpotPlacementScoreProvider.UpdateScores(ctx)).To(Succeed())
+
+			instance, err := awsEnv.InstanceProvider.Create(ctx, nodeClass, nodeClaim, nil, instanceTypes)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(instance.CapacityType).To(Equal(karpv1.CapacityTypeSpot))
+			Expect(awsEnv.EC2API.CreateFleetBehavior.CalledWithInput.Len()).To(Equal(1))
+			createFleetInput := awsEnv.EC2API.CreateFleetBehavior.CalledWithInput.Pop()
+			Expect(createFleetInput.LaunchTemplateConfigs).ToNot(BeEmpty())
+			Expect(createFleetInput.LaunchTemplateConfigs[0].Overrides).To(ContainElement(HaveField("AvailabilityZone", HaveValue(Equal("test-zone-1a")))))
+		})
+		It("should order spot pools by their spot placement score", func() {
+			awsEnv.EC2API.GetSpotPlacementScoresBehavior.Output.Set(&ec2.GetSpotPlacementScoresOutput{
+				SpotPlacementScores: []ec2types.SpotPlacementScore{
+					{AvailabilityZoneId: aws.String("tstz1-1a"), Region: aws.String(fake.DefaultRegion), Score: aws.Int32(4)},
+					{AvailabilityZoneId: aws.String("tstz1-1b"), Region: aws.String(fake.DefaultRegion), Score: aws.Int32(9)},
+				},
+			})
+			awsEnv.SpotPlacementScoreProvider.Scores([]string{"m5.xlarge"})
+			Expect(awsEnv.SpotPlacementScoreProvider.UpdateScores(ctx)).To(Succeed())
+
+			_, err := awsEnv.InstanceProvider.Create(ctx, nodeClass, nodeClaim, nil, instanceTypes)
+			Expect(err).ToNot(HaveOccurred())
+			createFleetInput := awsEnv.EC2API.CreateFleetBehavior.CalledWithInput.Pop()
+			Expect(createFleetInput.LaunchTemplateConfigs).To(HaveLen(1))
+			zones := lo.Map(createFleetInput.LaunchTemplateConfigs[0].Overrides, func(o ec2types.FleetLaunchTemplateOverridesRequest, _ int) string {
+				return aws.ToString(o.AvailabilityZone)
+			})
+			Expect(zones).To(Equal([]string{"test-zone-1b", "test-zone-1a"}))
+			for _, override := range createFleetInput.LaunchTemplateConfigs[0].Overrides {
+				Expect(override.Priority).To(BeNil())
+			}
+		})
+		It("should prioritize spot pools by their spot placement score with a prioritized allocation strategy", func() {
+			nodeClass.Spec.LaunchStrategy = &v1.LaunchStrategy{
+				SpotAllocationStrategy: lo.ToPtr(v1.SpotAllocationStrategyCapacityOptimizedPrioritized),
+			}
+			awsEnv.EC2API.GetSpotPlacementScoresBehavior.Output.Set(&ec2.GetSpotPlacementScoresOutput{
+				SpotPlacementScores: []ec2types.SpotPlacementScore{
+					{AvailabilityZoneId: aws.String("tstz1-1a"), Region: aws.String(fake.DefaultRegion), Score: aws.Int32(4)},
+					{AvailabilityZoneId: aws.String("tstz1-1b"), Region: aws.String(fake.DefaultRegion), Score: aws.Int32(9)},
+				},
+			})
+			awsEnv.SpotPlacementScoreProvider.Scores([]string{"m5.xlarge"})
+			Expect(awsEnv.SpotPlacementScoreProvider.UpdateScores(ctx)).To(Succeed())
+
+			_, err := awsEnv.InstanceProvider.Create(ctx, nodeClass, nodeClaim, nil, instanceTypes)
+			Expect(err).ToNot(HaveOccurred())
+			createFleetInput := awsEnv.EC2API.CreateFleetBehavior.CalledWithInput.Pop()
+			Expect(createFleetInput.LaunchTemplateConfigs).To(HaveLen(1))
+			priorities := lo.SliceToMap(createFleetInput.LaunchTemplateConfigs[0].Overrides, func(o ec2types.FleetLaunchTemplateOverridesRequest) (string, float64) {
+				return aws.ToString(o.AvailabilityZone), aws.ToFloat64(o.Priority)
+			})
+			// Lower numbers are launched with a higher priority
+			Expect(priorities).To(HaveLen(2))
+			Expect(priorities["test-zone-1b"]).To(BeNumerically("<", priorities["test-zone-1a"]))
+		})
+	})
+	Context("Service Quotas", func() {
+		var instanceTypes []*corecloudprovider.InstanceType
+		BeforeEach(func() {
+			ExpectApplied(ctx, env.Client, nodeClaim, nodePool, nodeClass)
+			nodeClass = ExpectExists(ctx, env.Client, nodeClass)
+			its, err := cloudProvider.GetInstanceTypes(ctx, nodePool)
+			Expect(err).ToNot(HaveOccurred())
+			instanceTypes = lo.Filter(its, func(i *corecloudprovider.InstanceType, _ int) bool { return i.Name == "m5.xlarge" })
+		})
+		It("should return an ICE error when launching would exceed the vcpu quota", func() {
+			// The quota is discovered after the instance types were resolved, as if concurrent launches had used it up
+			awsEnv.ServiceQuotasAPI.ListServiceQuotasBehavior.Output.Set(&servicequotas.ListServiceQuotasOutput{
+				Quotas: []servicequotastypes.ServiceQuota{fake.NewServiceQuota("L-34B43A08", 2)},
+			})
+			Expect(awsEnv.ServiceQuotaProvider.UpdateQuotas(ctx)).To(Succeed())
+
+			instance, err := awsEnv.InstanceProvider.Create(ctx, nodeClass, nodeClaim, nil, instanceTypes)
+			Expect(corecloudprovider.IsInsufficientCapacityError(err)).To(BeTrue())
+			Expect(instance).To(BeNil())
+			Expect(awsEnv.EC2API.CreateFleetBehavior.CalledWithInput.Len()).To(Equal(0))
+		})
+		It("should count launched instances towards the vcpu quota", func() {
+			awsEnv.ServiceQuotasAPI.ListServiceQuotasBehavior.Output.Set(&servicequotas.ListServiceQuotasOutput{
+				Quotas: []servicequotastypes.ServiceQuota{fake.NewServiceQuota("L-34B43A08", 5)},
+			})
+			Expect(awsEnv.ServiceQuotaProvider.UpdateQuotas(ctx)).To(Succeed())
+
+			instance, err := awsEnv.InstanceProvider.Create(ctx, nodeClass, nodeClaim, nil, instanceTypes)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(instance.CapacityType).To(Equal(karpv1.CapacityTypeSpot))
+			Expect(awsEnv.ServiceQuotaProvider.Fits("m5.large", karpv1.CapacityTypeSpot, 2)).To(BeFalse())
+			Expect(awsEnv.ServiceQuotaProvider.Fits("m5.large", karpv1.CapacityTypeOnDemand, 2)).To(BeTrue())
+		})
+	})
+	It("should keep prioritized instance types when truncating the instance types in the launch request", func() {
+		instances := fake.MakeInstances()
+		awsEnv.EC2API.DescribeInstanceTypesOutput.Set(&ec2.DescribeInstanceTypesOutput{InstanceTypes: instances})
+		awsEnv.EC2API.DescribeInstanceTypeOfferingsOutput.Set(&ec2.DescribeInstanceTypeOfferingsOutput{
+			InstanceTypeOfferings: fake.MakeInstanceOfferings(instances),
+		})
+		Expect(awsEnv.InstanceTypesProvider.UpdateInstanceTypes(ctx)).To(Succeed())
+		Expect(awsEnv.InstanceTypesProvider.UpdateInstanceTypeOfferings(ctx)).To(Succeed())
+		nodeClaim.Spec.Requirements = []karpv1.NodeSelectorRequirementWithMinValues{{
+			NodeSelectorRequirement: corev1.NodeSelectorRequirement{Key: karpv1.CapacityTypeLabelKey, Operator: corev1.NodeSelectorOpIn, Values: []string{karpv1.CapacityTypeOnDemand}},
+		}}
+		ExpectApplied(ctx, env.Client, nodeClaim, nodePool, nodeClass)
+		nodeClass = ExpectExists(ctx, env.Client, nodeClass)
+		instanceTypes, err := cloudProvider.GetInstanceTypes(ctx, nodePool)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(len(instanceTypes)).To(BeNumerically(">", 60))
+
+		// Prioritize the most expensive instance type, which wouldn't be among the cheapest 60
+		reqs := scheduling.NewNodeSelectorRequirementsWithMinValues(nodeClaim.Spec.Requirements...)
+		mostExpensive := lo.MaxBy(instanceTypes, func(a, b *corecloudprovider.InstanceType) bool {
+			return a.Offerings.Available().Compatible(reqs).Cheapest().Price > b.Offerings.Available().Compatible(reqs).Cheapest().Price
+		})
+		nodeClass.Spec.LaunchStrategy = &v1.LaunchStrategy{
+			OnDemandAllocationStrategy: lo.ToPtr(v1.OnDemandAllocationStrategyPrioritized),
+			InstanceTypePriorities:     []v1.InstanceTypePriority{{InstanceType: mostExpensive.Name, Priority: 0}},
+		}
+
+		_, err = awsEnv.InstanceProvider.Create(ctx, nodeClass, nodeClaim, nil, instanceTypes)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(awsEnv.EC2API.CreateFleetBehavior.CalledWithInput.Len()).To(Equal(1))
+		call := awsEnv.EC2API.CreateFleetBehavior.CalledWithInput.Pop()
+		overrides := lo.FlatMap(call.LaunchTemplateConfigs, func(ltc ec2types.FleetLaunchTemplateConfigRequest, _ int) []ec2types.FleetLaunchTemplateOverridesRequest {
+			return ltc.Overrides
+		})
+		Expect(lo.Uniq(lo.Map(overrides, func(o ec2types.FleetLaunchTemplateOverridesRequest, _ int) ec2types.InstanceType {
+			return o.InstanceType
+		}))).To(HaveLen(60))
+		Expect(lo.ContainsBy(overrides, func(o ec2types.FleetLaunchTemplateOverridesRequest) bool {
+			return string(o.InstanceType) == mostExpensive.Name
+		})).To(BeTrue())
+	})
 	It("should return all NodePool-owned instances from List", func() {
 		ids := sets.New[string]()
 		// Provision instances that have the karpenter.sh/nodepool key
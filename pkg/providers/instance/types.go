@@ -22,6 +22,8 @@ import (
 	"github.com/samber/lo"
 
 	karpv1 "sigs.k8s.io/karpenter/pkg/apis/v1"
+
+	v1 "github.com/aws/karpenter-provider-aws/pkg/apis/v1"
 )
 
 // Instance is an internal data representation of either an ec2.Instance or an ec2.FleetInstance
@@ -38,6 +40,26 @@ type Instance struct {
 	SubnetID         string
 	Tags             map[string]string
 	EFAEnabled       bool
+	// CapacityReservationID is the ID of the capacity reservation that the instance was launched into, if any
+	CapacityReservationID string
+	// PlacementGroupID is the ID of the placement group that the instance was launched into, if any
+	PlacementGroupID string
+	// PartitionNumber is the partition of the partition placement group that the instance was launched into, if any
+	PartitionNumber int32
+	// HostID is the ID of the Dedicated Host that the instance is running on, if any
+	HostID string
+	// HostResourceGroupARN is the ARN of the host resource group that the instance was launched into, if any
+	HostResourceGroupARN string
+	// NetworkInterfaces are the network interfaces that are attached to the instance
+	NetworkInterfaces []NetworkInterface
+}
+
+// NetworkInterface is a network interface that is attached to an instance
+type NetworkInterface struct {
+	NetworkCardIndex int32
+	DeviceIndex      int32
+	SubnetID         string
+	SecurityGroupIDs []string
 }
 
 func NewInstance(out ec2types.Instance) *Instance {
@@ -48,7 +70,7 @@ func NewInstance(out ec2types.Instance) *Instance {
 		ImageID:      aws.ToString(out.ImageId),
 		Type:         out.InstanceType,
 		Zone:         aws.ToString(out.Placement.AvailabilityZone),
-		CapacityType: lo.Ternary(out.SpotInstanceRequestId != nil, karpv1.CapacityTypeSpot, karpv1.CapacityTypeOnDemand),
+		CapacityType: capacityType(out),
 		SecurityGroupIDs: lo.Map(out.SecurityGroups, func(securitygroup ec2types.GroupIdentifier, _ int) string {
 			return aws.ToString(securitygroup.GroupId)
 		}),
@@ -57,21 +79,46 @@ func NewInstance(out ec2types.Instance) *Instance {
 		EFAEnabled: lo.ContainsBy(out.NetworkInterfaces, func(item ec2types.InstanceNetworkInterface) bool {
 			return item.InterfaceType != nil && *item.InterfaceType == string(ec2types.NetworkInterfaceTypeEfa)
 		}),
+		CapacityReservationID: aws.ToString(out.CapacityReservationId),
+		PlacementGroupID:      aws.ToString(out.Placement.GroupId),
+		PartitionNumber:       aws.ToInt32(out.Placement.PartitionNumber),
+		HostID:                aws.ToString(out.Placement.HostId),
+		HostResourceGroupARN:  aws.ToString(out.Placement.HostResourceGroupArn),
+		NetworkInterfaces: lo.Map(out.NetworkInterfaces, func(ni ec2types.InstanceNetworkInterface, _ int) NetworkInterface {
+			attachment := lo.FromPtr(ni.Attachment)
+			return NetworkInterface{
+				NetworkCardIndex: aws.ToInt32(attachment.NetworkCardIndex),
+				DeviceIndex:      aws.ToInt32(attachment.DeviceIndex),
+				SubnetID:         aws.ToString(ni.SubnetId),
+				SecurityGroupIDs: lo.Map(ni.Groups, func(g ec2types.GroupIdentifier, _ int) string { return aws.ToString(g.GroupId) }),
+			}
+		}),
 	}
 
 }
 
-func NewInstanceFromFleet(out ec2types.CreateFleetInstance, tags map[string]string, efaEnabled bool) *Instance {
+func NewInstanceFromFleet(out ec2types.CreateFleetInstance, capacityReservationID string, tags map[string]string, efaEnabled bool) *Instance {
 	return &Instance{
-		LaunchTime:   time.Now(), // estimate the launch time since we just launched
-		State:        ec2types.InstanceStateNamePending,
-		ID:           out.InstanceIds[0],
-		ImageID:      aws.ToString(out.LaunchTemplateAndOverrides.Overrides.ImageId),
-		Type:         out.InstanceType,
-		Zone:         aws.ToString(out.LaunchTemplateAndOverrides.Overrides.AvailabilityZone),
-		CapacityType: string(out.Lifecycle),
-		SubnetID:     aws.ToString(out.LaunchTemplateAndOverrides.Overrides.SubnetId),
-		Tags:         tags,
-		EFAEnabled:   efaEnabled,
+		LaunchTime:            time.Now(), // estimate the launch time since we just launched
+		State:                 ec2types.InstanceStateNamePending,
+		ID:                    out.InstanceIds[0],
+		ImageID:               aws.ToString(out.LaunchTemplateAndOverrides.Overrides.ImageId),
+		Type:                  out.InstanceType,
+		Zone:                  aws.ToString(out.LaunchTemplateAndOverrides.Overrides.AvailabilityZone),
+		CapacityType:          lo.Ternary(capacityReservationID != "", v1.CapacityTypeReserved, string(out.Lifecycle)),
+		SubnetID:              aws.ToString(out.LaunchTemplateAndOverrides.Overrides.SubnetId),
+		Tags:                  tags,
+		EFAEnabled:            efaEnabled,
+		CapacityReservationID: capacityReservationID,
+	}
+}
+
+func capacityType(out ec2types.Instance) string {
+	if out.SpotInstanceRequestId != nil {
+		return karpv1.CapacityTypeSpot
+	}
+	if out.CapacityReservationId != nil {
+		return v1.CapacityTypeReserved
 	}
+	return karpv1.CapacityTypeOnDemand
 }
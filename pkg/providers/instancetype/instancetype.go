@@ -17,6 +17,7 @@ package instancetype
 import (
 	"context"
 	"fmt"
+	"strings"
 	"sync"
 	"sync/atomic"
 
@@ -39,6 +40,7 @@ import (
 
 	v1 "github.com/aws/karpenter-provider-aws/pkg/apis/v1"
 	sdk "github.com/aws/karpenter-provider-aws/pkg/aws"
+	awscache "github.com/aws/karpenter-provider-aws/pkg/cache"
 
 	"github.com/aws/karpenter-provider-aws/pkg/providers/subnet"
 
@@ -65,6 +67,8 @@ type DefaultProvider struct {
 
 	muInstanceTypesOfferings sync.RWMutex
 	instanceTypesOfferings   map[string]sets.Set[string]
+	// instanceTypesOutpostOfferings maps instance types to the ARNs of the Outposts that have capacity for them
+	instanceTypesOutpostOfferings map[string]sets.Set[string]
 
 	instanceTypesCache      *cache.Cache
 	discoveredCapacityCache *cache.Cache
@@ -73,19 +77,22 @@ type DefaultProvider struct {
 	instanceTypesSeqNum uint64
 	// instanceTypesOfferingsSeqNum is a monotonically increasing change counter used to avoid the expensive hashing operation on instance types
 	instanceTypesOfferingsSeqNum uint64
+	// discoveredCapacitySeqNum is a monotonically increasing change counter of the discovered capacity
+	discoveredCapacitySeqNum uint64
 }
 
 func NewDefaultProvider(instanceTypesCache *cache.Cache, discoveredCapacityCache *cache.Cache, ec2api sdk.EC2API, subnetProvider subnet.Provider, instanceTypesResolver Resolver) *DefaultProvider {
 	return &DefaultProvider{
-		ec2api:                  ec2api,
-		subnetProvider:          subnetProvider,
-		instanceTypesInfo:       []ec2types.InstanceTypeInfo{},
-		instanceTypesOfferings:  map[string]sets.Set[string]{},
-		instanceTypesResolver:   instanceTypesResolver,
-		instanceTypesCache:      instanceTypesCache,
-		discoveredCapacityCache: discoveredCapacityCache,
-		cm:                      pretty.NewChangeMonitor(),
-		instanceTypesSeqNum:     0,
+		ec2api:                        ec2api,
+		subnetProvider:                subnetProvider,
+		instanceTypesInfo:             []ec2types.InstanceTypeInfo{},
+		instanceTypesOfferings:        map[string]sets.Set[string]{},
+		instanceTypesResolver:         instanceTypesResolver,
+		instanceTypesOutpostOfferings: map[string]sets.Set[string]{},
+		instanceTypesCache:            instanceTypesCache,
+		discoveredCapacityCache:       discoveredCapacityCache,
+		cm:                            pretty.NewChangeMonitor(),
+		instanceTypesSeqNum:           0,
 	}
 }
 
@@ -109,18 +116,40 @@ func (p *DefaultProvider) List(ctx context.Context, nodeClass *v1.EC2NodeClass)
 	subnetZones := sets.New(lo.Map(nodeClass.Status.Subnets, func(s v1.Subnet, _ int) string {
 		return lo.FromPtr(&s.Zone)
 	})...)
+	// Instances can only be launched into zones that have a subnet for every network interface that they're launched with
+	networkInterfaceZones := networkInterfaceZones(nodeClass)
+	if networkInterfaceZones != nil {
+		subnetZones = subnetZones.Intersection(networkInterfaceZones)
+	}
+	// All of the instances of a cluster placement group must be launched into a single zone
+	placementGroupZone := lo.FromPtr(nodeClass.Status.PlacementGroup).Zone
+	if placementGroupZone != "" {
+		subnetZones = subnetZones.Intersection(sets.New(placementGroupZone))
+	}
 
-	// Compute fully initialized instance types hash key
-	subnetZonesHash, _ := hashstructure.Hash(subnetZones, hashstructure.FormatV2, &hashstructure.HashOptions{SlicesAsSets: true})
+	// Compute fully initialized instance types hash key. The zone type and Outpost of each subnet are included since they
+	// determine both the offerings' requirements and the capacity that the offerings are drawn from.
+	subnetZonesHash, _ := hashstructure.Hash(lo.Map(nodeClass.Status.Subnets, func(s v1.Subnet, _ int) v1.Subnet {
+		return v1.Subnet{Zone: s.Zone, ZoneType: s.ZoneType, ParentZone: s.ParentZone, OutpostARN: s.OutpostARN}
+	}), hashstructure.FormatV2, &hashstructure.HashOptions{SlicesAsSets: true})
 
 	// Compute hash key against node class AMIs (used to force cache rebuild when AMIs change)
 	amiHash, _ := hashstructure.Hash(nodeClass.Status.AMIs, hashstructure.FormatV2, &hashstructure.HashOptions{SlicesAsSets: true})
 
-	key := fmt.Sprintf("%d-%d-%016x-%016x-%016x",
+	// Compute hash key against node class hosts (used to force cache rebuild when the selected hosts change)
+	hostsHash, _ := hashstructure.Hash(nodeClass.Status.Hosts, hashstructure.FormatV2, &hashstructure.HashOptions{SlicesAsSets: true})
+
+	// Compute hash key against the zones that have a subnet for every network interface
+	networkInterfaceZonesHash, _ := hashstructure.Hash(sets.List(networkInterfaceZones), hashstructure.FormatV2, &hashstructure.HashOptions{SlicesAsSets: true})
+
+	key := fmt.Sprintf("%d-%d-%016x-%016x-%016x-%016x-%s-%016x",
 		p.instanceTypesSeqNum,
 		p.instanceTypesOfferingsSeqNum,
 		amiHash,
 		subnetZonesHash,
+		hostsHash,
+		networkInterfaceZonesHash,
+		placementGroupZone,
 		p.instanceTypesResolver.CacheKey(nodeClass),
 	)
 	if item, ok := p.instanceTypesCache.Get(key); ok {
@@ -143,7 +172,21 @@ func (p *DefaultProvider) List(ctx context.Context, nodeClass *v1.EC2NodeClass)
 	subnetZoneToID := lo.SliceToMap(nodeClass.Status.Subnets, func(s v1.Subnet) (string, string) {
 		return s.Zone, s.ZoneID
 	})
-	result := lo.Map(p.instanceTypesInfo, func(i ec2types.InstanceTypeInfo, _ int) *cloudprovider.InstanceType {
+	subnetZoneToSubnet := lo.SliceToMap(nodeClass.Status.Subnets, func(s v1.Subnet) (string, v1.Subnet) {
+		return s.Zone, s
+	})
+	// Instance types in zones with Outpost subnets are launched onto the Outpost, so they are only available if the
+	// Outpost has capacity for them
+	subnetZoneToOutposts := lo.MapValues(lo.GroupBy(lo.Filter(nodeClass.Status.Subnets, func(s v1.Subnet, _ int) bool {
+		return s.OutpostARN != ""
+	}), func(s v1.Subnet) string { return s.Zone }), func(subnets []v1.Subnet, _ string) sets.Set[string] {
+		return sets.New(lo.Map(subnets, func(s v1.Subnet, _ int) string { return s.OutpostARN })...)
+	})
+	instanceTypesInfo := lo.Filter(p.instanceTypesInfo, func(i ec2types.InstanceTypeInfo, _ int) bool {
+		_, _, compatibleWithCPUOptions := resolveCPUOptions(i, nodeClass.Spec.CPUOptions)
+		return compatibleWithCPUOptions && isCompatibleWithTenancy(i, nodeClass) && isCompatibleWithNetworkInterfaces(i, nodeClass)
+	})
+	result := lo.Map(instanceTypesInfo, func(i ec2types.InstanceTypeInfo, _ int) *cloudprovider.InstanceType {
 		InstanceTypeVCPU.Set(float64(lo.FromPtr(i.VCpuInfo.DefaultVCpus)), map[string]string{
 			instanceTypeLabel: string(i.InstanceType),
 		})
@@ -151,17 +194,24 @@ func (p *DefaultProvider) List(ctx context.Context, nodeClass *v1.EC2NodeClass)
 			instanceTypeLabel: string(i.InstanceType),
 		})
 
+		hostZones := hostZones(i, nodeClass)
 		zoneData := lo.Map(allZones.UnsortedList(), func(zoneName string, _ int) ZoneData {
-			if !p.instanceTypesOfferings[string(i.InstanceType)].Has(zoneName) || !subnetZones.Has(zoneName) {
+			offered := p.instanceTypesOfferings[string(i.InstanceType)].Has(zoneName)
+			if outposts, ok := subnetZoneToOutposts[zoneName]; ok {
+				offered = p.instanceTypesOutpostOfferings[string(i.InstanceType)].HasAny(outposts.UnsortedList()...)
+			}
+			if !offered || !subnetZones.Has(zoneName) || (hostZones != nil && !hostZones.Has(zoneName)) {
 				return ZoneData{
 					Name:      zoneName,
 					Available: false,
 				}
 			}
 			return ZoneData{
-				Name:      zoneName,
-				ID:        subnetZoneToID[zoneName],
-				Available: true,
+				Name:       zoneName,
+				ID:         subnetZoneToID[zoneName],
+				Type:       subnetZoneToSubnet[zoneName].ZoneType,
+				ParentZone: subnetZoneToSubnet[zoneName].ParentZone,
+				Available:  true,
 			}
 		})
 
@@ -175,11 +225,6 @@ func (p *DefaultProvider) List(ctx context.Context, nodeClass *v1.EC2NodeClass)
 				capacityTypeLabel: of.Requirements.Get(karpv1.CapacityTypeLabelKey).Any(),
 				zoneLabel:         of.Requirements.Get(corev1.LabelTopologyZone).Any(),
 			})
-			InstanceTypeOfferingPriceEstimate.Set(of.Price, map[string]string{
-				instanceTypeLabel: it.Name,
-				capacityTypeLabel: of.Requirements.Get(karpv1.CapacityTypeLabelKey).Any(),
-				zoneLabel:         of.Requirements.Get(corev1.LabelTopologyZone).Any(),
-			})
 		}
 		return it
 	})
@@ -187,6 +232,27 @@ func (p *DefaultProvider) List(ctx context.Context, nodeClass *v1.EC2NodeClass)
 	return result, nil
 }
 
+// networkInterfaceZones returns the zones that have a subnet for every network interface of the EC2NodeClass that is
+// created in subnets of its own. Nil is returned when no network interface selects its own subnets.
+func networkInterfaceZones(nodeClass *v1.EC2NodeClass) sets.Set[string] {
+	var zones sets.Set[string]
+	for _, ni := range nodeClass.Spec.NetworkInterfaces {
+		if len(ni.SubnetSelectorTerms) == 0 {
+			continue
+		}
+		status, _ := lo.Find(nodeClass.Status.NetworkInterfaces, func(s v1.NetworkInterfaceStatus) bool {
+			return s.NetworkCardIndex == ni.NetworkCardIndex && s.DeviceIndex == ni.DeviceIndex
+		})
+		niZones := sets.New(lo.Map(status.Subnets, func(s v1.Subnet, _ int) string { return s.Zone })...)
+		if zones == nil {
+			zones = niZones
+			continue
+		}
+		zones = zones.Intersection(niZones)
+	}
+	return zones
+}
+
 func (p *DefaultProvider) UpdateInstanceTypes(ctx context.Context) error {
 	// DO NOT REMOVE THIS LOCK ----------------------------------------------------------------------------
 	// We lock here so that multiple callers to getInstanceTypeOfferings do not result in cache misses and multiple
@@ -205,7 +271,7 @@ func (p *DefaultProvider) UpdateInstanceTypes(ctx context.Context) error {
 			},
 			{
 				Name:   aws.String("processor-info.supported-architecture"),
-				Values: []string{"x86_64", "arm64"},
+				Values: []string{"x86_64", "arm64", "x86_64_mac", "arm64_mac"},
 			},
 		},
 	})
@@ -226,6 +292,14 @@ func (p *DefaultProvider) UpdateInstanceTypes(ctx context.Context) error {
 		log.FromContext(ctx).WithValues(
 			"count", len(instanceTypes)).V(1).Info("discovered instance types")
 	}
+	for _, info := range instanceTypes {
+		for limit, mismatch := range networkLimitMismatches(info) {
+			InstanceTypeNetworkLimitsMismatch.Set(float64(lo.Ternary(mismatch, 1, 0)), map[string]string{
+				instanceTypeLabel: string(info.InstanceType),
+				limitLabel:        limit,
+			})
+		}
+	}
 	p.instanceTypesInfo = instanceTypes
 	return nil
 }
@@ -240,18 +314,40 @@ func (p *DefaultProvider) UpdateInstanceTypeOfferings(ctx context.Context) error
 	defer p.muInstanceTypesOfferings.Unlock()
 
 	// Get offerings from EC2
-	instanceTypeOfferings := map[string]sets.Set[string]{}
+	instanceTypeOfferings, err := p.describeInstanceTypeOfferings(ctx, ec2types.LocationTypeAvailabilityZone)
+	if err != nil {
+		return fmt.Errorf("describing instance type zone offerings, %w", err)
+	}
+	// Outposts report the instance types that they have capacity for as offerings located at the Outpost's ARN
+	instanceTypeOutpostOfferings, err := p.describeInstanceTypeOfferings(ctx, ec2types.LocationTypeOutpost)
+	if err != nil {
+		return fmt.Errorf("describing instance type outpost offerings, %w", err)
+	}
+
+	offeringsChanged := p.cm.HasChanged("instance-type-offering", instanceTypeOfferings)
+	outpostOfferingsChanged := p.cm.HasChanged("instance-type-outpost-offering", instanceTypeOutpostOfferings)
+	if offeringsChanged || outpostOfferingsChanged {
+		// Only update instanceTypesSeqNun with the instance type offerings  have been changed
+		// This is to not create new keys with duplicate instance type offerings option
+		atomic.AddUint64(&p.instanceTypesOfferingsSeqNum, 1)
+		log.FromContext(ctx).WithValues("instance-type-count", len(instanceTypeOfferings)).V(1).Info("discovered offerings for instance types")
+	}
+	p.instanceTypesOfferings = instanceTypeOfferings
+	p.instanceTypesOutpostOfferings = instanceTypeOutpostOfferings
+	return nil
+}
 
+// describeInstanceTypeOfferings returns the locations that each instance type is offered in for the given location type
+func (p *DefaultProvider) describeInstanceTypeOfferings(ctx context.Context, locationType ec2types.LocationType) (map[string]sets.Set[string], error) {
+	instanceTypeOfferings := map[string]sets.Set[string]{}
 	paginator := ec2.NewDescribeInstanceTypeOfferingsPaginator(p.ec2api, &ec2.DescribeInstanceTypeOfferingsInput{
-		LocationType: ec2types.LocationTypeAvailabilityZone,
+		LocationType: locationType,
 	})
-
 	for paginator.HasMorePages() {
 		page, err := paginator.NextPage(ctx)
 		if err != nil {
-			return fmt.Errorf("describing instance type zone offerings, %w", err)
+			return nil, err
 		}
-
 		for _, offering := range page.InstanceTypeOfferings {
 			if _, ok := instanceTypeOfferings[string(offering.InstanceType)]; !ok {
 				instanceTypeOfferings[string(offering.InstanceType)] = sets.New[string]()
@@ -259,15 +355,7 @@ func (p *DefaultProvider) UpdateInstanceTypeOfferings(ctx context.Context) error
 			instanceTypeOfferings[string(offering.InstanceType)].Insert(lo.FromPtr(offering.Location))
 		}
 	}
-
-	if p.cm.HasChanged("instance-type-offering", instanceTypeOfferings) {
-		// Only update instanceTypesSeqNun with the instance type offerings  have been changed
-		// This is to not create new keys with duplicate instance type offerings option
-		atomic.AddUint64(&p.instanceTypesOfferingsSeqNum, 1)
-		log.FromContext(ctx).WithValues("instance-type-count", len(instanceTypeOfferings)).V(1).Info("discovered offerings for instance types")
-	}
-	p.instanceTypesOfferings = instanceTypeOfferings
-	return nil
+	return instanceTypeOfferings, nil
 }
 
 func (p *DefaultProvider) UpdateInstanceTypeCapacityFromNode(ctx context.Context, node *corev1.Node, nodeClaim *karpv1.NodeClaim, nodeClass *v1.EC2NodeClass) error {
@@ -292,13 +380,89 @@ func (p *DefaultProvider) UpdateInstanceTypeCapacityFromNode(ctx context.Context
 	if cachedCapacity, ok := p.discoveredCapacityCache.Get(key); !ok || actualCapacity.Cmp(cachedCapacity.(resource.Quantity)) < 1 {
 		log.FromContext(ctx).WithValues("memory-capacity", actualCapacity, "instance-type", instanceTypeName).V(1).Info("updating discovered capacity cache")
 		p.discoveredCapacityCache.SetDefault(key, *actualCapacity)
+		atomic.AddUint64(&p.discoveredCapacitySeqNum, 1)
+	}
+	return nil
+}
+
+// Checkpoint serializes the memory capacity that has been discovered from the nodes of each instance type
+func (p *DefaultProvider) Checkpoint() ([]byte, error) {
+	return awscache.Checkpoint[resource.Quantity](p.discoveredCapacityCache)
+}
+
+// Restore adds the discovered memory capacity of a checkpoint, so that instance types don't fall back to the VM memory
+// overhead estimate after the controller restarts
+func (p *DefaultProvider) Restore(data []byte) error {
+	if err := awscache.Restore[resource.Quantity](p.discoveredCapacityCache, data); err != nil {
+		return err
 	}
+	atomic.AddUint64(&p.discoveredCapacitySeqNum, 1)
+	p.instanceTypesCache.Flush()
 	return nil
 }
 
+// CheckpointSeqNum returns the change counter of the discovered capacity
+func (p *DefaultProvider) CheckpointSeqNum() uint64 {
+	return atomic.LoadUint64(&p.discoveredCapacitySeqNum)
+}
+
 func (p *DefaultProvider) Reset() {
 	p.instanceTypesInfo = []ec2types.InstanceTypeInfo{}
 	p.instanceTypesOfferings = map[string]sets.Set[string]{}
+	p.instanceTypesOutpostOfferings = map[string]sets.Set[string]{}
 	p.instanceTypesCache.Flush()
 	p.discoveredCapacityCache.Flush()
+	atomic.AddUint64(&p.discoveredCapacitySeqNum, 1)
+}
+
+// isCompatibleWithTenancy returns true if the instance type can be launched with the EC2NodeClass' tenancy. Mac instance
+// types are only available on Dedicated Hosts, and when hosts are selected, instance types are limited to those that
+// at least one of the hosts is able to run.
+func isCompatibleWithTenancy(info ec2types.InstanceTypeInfo, nodeClass *v1.EC2NodeClass) bool {
+	if isMac(info) && nodeClass.Tenancy() != v1.TenancyHost {
+		return false
+	}
+	zones := hostZones(info, nodeClass)
+	return zones == nil || zones.Len() > 0
+}
+
+// isCompatibleWithNetworkInterfaces returns true if the instance type can be launched with the EC2NodeClass' network
+// interfaces. Each network interface must be attached to a network card that the instance type has, a network card
+// can't have more network interfaces than it supports, and EFA interfaces require EFA support.
+func isCompatibleWithNetworkInterfaces(info ec2types.InstanceTypeInfo, nodeClass *v1.EC2NodeClass) bool {
+	if len(nodeClass.Spec.NetworkInterfaces) == 0 {
+		return true
+	}
+	networkCards := lo.CountValuesBy(nodeClass.Spec.NetworkInterfaces, func(ni *v1.NetworkInterface) int32 { return ni.NetworkCardIndex })
+	for index, count := range networkCards {
+		if int32(count) > maxNetworkInterfaces(info, index) { //nolint:gosec
+			return false
+		}
+	}
+	efaCount := efas(info, nodeClass.Spec.NetworkInterfaces).Value()
+	if efaCount == 0 {
+		return true
+	}
+	return info.NetworkInfo.EfaInfo != nil && efaCount <= int64(lo.FromPtr(info.NetworkInfo.EfaInfo.MaximumEfaInterfaces))
+}
+
+// hostZones returns the zones of the selected hosts that are able to run the instance type. A nil set is returned when
+// the EC2NodeClass doesn't select any hosts, in which case instance type zones aren't constrained by hosts.
+func hostZones(info ec2types.InstanceTypeInfo, nodeClass *v1.EC2NodeClass) sets.Set[string] {
+	if len(nodeClass.Spec.HostSelectorTerms) == 0 {
+		return nil
+	}
+	family := strings.Split(string(info.InstanceType), ".")[0]
+	return sets.New(lo.FilterMap(nodeClass.Status.Hosts, func(h v1.Host, _ int) (string, bool) {
+		if h.InstanceType != "" {
+			return h.AvailabilityZone, h.InstanceType == string(info.InstanceType)
+		}
+		return h.AvailabilityZone, h.InstanceFamily == family
+	})...)
+}
+
+func isMac(info ec2types.InstanceTypeInfo) bool {
+	return info.ProcessorInfo != nil && lo.ContainsBy(info.ProcessorInfo.SupportedArchitectures, func(a ec2types.ArchitectureType) bool {
+		return strings.HasSuffix(string(a), "_mac")
+	})
 }
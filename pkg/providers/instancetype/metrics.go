@@ -27,6 +27,15 @@ const (
 	instanceTypeLabel      = "instance_type"
 	capacityTypeLabel      = "capacity_type"
 	zoneLabel              = "zone"
+	limitLabel             = "limit"
+	priceTypeLabel         = "price_type"
+
+	rawPriceType       = "raw"
+	effectivePriceType = "effective"
+
+	maxNetworkInterfacesLimit      = "max_network_interfaces"
+	ipv4AddressesPerInterfaceLimit = "ipv4_addresses_per_interface"
+	networkBandwidthLimit          = "network_bandwidth"
 )
 
 var (
@@ -54,6 +63,19 @@ var (
 			instanceTypeLabel,
 		},
 	)
+	InstanceTypeNetworkLimitsMismatch = opmetrics.NewPrometheusGauge(
+		crmetrics.Registry,
+		prometheus.GaugeOpts{
+			Namespace: metrics.Namespace,
+			Subsystem: cloudProviderSubsystem,
+			Name:      "instance_type_network_limits_mismatch",
+			Help:      "Whether a network limit of an instance type reported by EC2 disagrees with the generated tables, based on instance type and limit. 1 if the sources disagree, 0 otherwise.",
+		},
+		[]string{
+			instanceTypeLabel,
+			limitLabel,
+		},
+	)
 	InstanceTypeOfferingAvailable = opmetrics.NewPrometheusGauge(
 		crmetrics.Registry,
 		prometheus.GaugeOpts{
@@ -74,11 +96,12 @@ var (
 			Namespace: metrics.Namespace,
 			Subsystem: cloudProviderSubsystem,
 			Name:      "instance_type_offering_price_estimate",
-			Help:      "Instance type offering estimated hourly price used when making informed decisions on node cost calculation, based on instance type, capacity type, and zone.",
+			Help:      "Instance type offering estimated hourly price used when making informed decisions on node cost calculation, based on instance type, capacity type, zone and price type. The raw price is the public price, while the effective price has pricing overrides applied and is the price used to order offerings.",
 		},
 		[]string{
 			instanceTypeLabel,
 			capacityTypeLabel,
 			zoneLabel,
+			priceTypeLabel,
 		})
 )
@@ -16,9 +16,12 @@ package instancetype_test
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"math"
 	"net"
+	"net/http"
+	"net/http/httptest"
 	"reflect"
 	"sort"
 	"strings"
@@ -31,6 +34,9 @@ import (
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/ec2"
 	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	awspricing "github.com/aws/aws-sdk-go-v2/service/pricing"
+	"github.com/aws/aws-sdk-go-v2/service/servicequotas"
+	servicequotastypes "github.com/aws/aws-sdk-go-v2/service/servicequotas/types"
 	"github.com/awslabs/operatorpkg/object"
 	"github.com/awslabs/operatorpkg/status"
 	"github.com/imdario/mergo"
@@ -60,10 +66,14 @@ import (
 
 	"github.com/aws/karpenter-provider-aws/pkg/apis"
 	v1 "github.com/aws/karpenter-provider-aws/pkg/apis/v1"
+	awscache "github.com/aws/karpenter-provider-aws/pkg/cache"
 	"github.com/aws/karpenter-provider-aws/pkg/cloudprovider"
 	"github.com/aws/karpenter-provider-aws/pkg/fake"
 	"github.com/aws/karpenter-provider-aws/pkg/operator/options"
+	"github.com/aws/karpenter-provider-aws/pkg/providers/capacityreservation"
 	"github.com/aws/karpenter-provider-aws/pkg/providers/instancetype"
+	"github.com/aws/karpenter-provider-aws/pkg/providers/pricing"
+	"github.com/aws/karpenter-provider-aws/pkg/providers/servicequota"
 	"github.com/aws/karpenter-provider-aws/pkg/test"
 )
 
@@ -131,16 +141,19 @@ var _ = Describe("InstanceTypeProvider", func() {
 					},
 					Subnets: []v1.Subnet{
 						{
-							ID:   "subnet-test1",
-							Zone: "test-zone-1a",
+							ID:       "subnet-test1",
+							Zone:     "test-zone-1a",
+							ZoneType: v1.ZoneTypeAvailabilityZone,
 						},
 						{
-							ID:   "subnet-test2",
-							Zone: "test-zone-1b",
+							ID:       "subnet-test2",
+							Zone:     "test-zone-1b",
+							ZoneType: v1.ZoneTypeAvailabilityZone,
 						},
 						{
-							ID:   "subnet-test3",
-							Zone: "test-zone-1c",
+							ID:       "subnet-test3",
+							Zone:     "test-zone-1c",
+							ZoneType: v1.ZoneTypeAvailabilityZone,
 						},
 					},
 				},
@@ -240,6 +253,7 @@ var _ = Describe("InstanceTypeProvider", func() {
 			v1.LabelInstanceFamily:                       "g4dn",
 			v1.LabelInstanceSize:                         "8xlarge",
 			v1.LabelInstanceCPU:                          "32",
+			v1.LabelInstanceCPUCores:                     "16",
 			v1.LabelInstanceCPUManufacturer:              "intel",
 			v1.LabelInstanceCPUSustainedClockSpeedMhz:    "2500",
 			v1.LabelInstanceMemory:                       "131072",
@@ -255,6 +269,8 @@ var _ = Describe("InstanceTypeProvider", func() {
 			v1.LabelInstanceAcceleratorManufacturer: "aws",
 			v1.LabelInstanceAcceleratorCount:        "1",
 			v1.LabelTopologyZoneID:                  "tstz1-1a",
+			v1.LabelTopologyZoneType:                "availability-zone",
+			v1.LabelInstanceTenancy:                 "default",
 			// Deprecated Labels
 			corev1.LabelFailureDomainBetaRegion: fake.DefaultRegion,
 			corev1.LabelFailureDomainBetaZone:   "test-zone-1a",
@@ -265,8 +281,12 @@ var _ = Describe("InstanceTypeProvider", func() {
 			corev1.LabelWindowsBuild:            v1.Windows2022Build,
 		}
 
-		// Ensure that we're exercising all well known labels
-		Expect(lo.Keys(nodeSelector)).To(ContainElements(append(karpv1.WellKnownLabels.UnsortedList(), lo.Keys(karpv1.NormalizedLabels)...)))
+		// Ensure that we're exercising all well known labels except for the capacity reservation label, which only applies
+		// to reserved offerings, and the parent zone label, which only applies to Local Zones and Wavelength Zones
+		Expect(lo.Keys(nodeSelector)).To(ContainElements(append(karpv1.WellKnownLabels.Difference(sets.New(
+			v1.LabelCapacityReservationID,
+			v1.LabelTopologyParentZone,
+		)).UnsortedList(), lo.Keys(karpv1.NormalizedLabels)...)))
 
 		var pods []*corev1.Pod
 		for key, value := range nodeSelector {
@@ -297,6 +317,7 @@ var _ = Describe("InstanceTypeProvider", func() {
 			v1.LabelInstanceFamily:                       "g4dn",
 			v1.LabelInstanceSize:                         "8xlarge",
 			v1.LabelInstanceCPU:                          "32",
+			v1.LabelInstanceCPUCores:                     "16",
 			v1.LabelInstanceCPUManufacturer:              "intel",
 			v1.LabelInstanceCPUSustainedClockSpeedMhz:    "2500",
 			v1.LabelInstanceMemory:                       "131072",
@@ -308,6 +329,8 @@ var _ = Describe("InstanceTypeProvider", func() {
 			v1.LabelInstanceGPUMemory:                    "16384",
 			v1.LabelInstanceLocalNVME:                    "900",
 			v1.LabelTopologyZoneID:                       "tstz1-1a",
+			v1.LabelTopologyZoneType:                     "availability-zone",
+			v1.LabelInstanceTenancy:                      "default",
 			// Deprecated Labels
 			corev1.LabelFailureDomainBetaRegion: fake.DefaultRegion,
 			corev1.LabelFailureDomainBetaZone:   "test-zone-1a",
@@ -324,6 +347,8 @@ var _ = Describe("InstanceTypeProvider", func() {
 					v1.LabelInstanceAcceleratorCount,
 					v1.LabelInstanceAcceleratorName,
 					v1.LabelInstanceAcceleratorManufacturer,
+					v1.LabelCapacityReservationID,
+					v1.LabelTopologyParentZone,
 					corev1.LabelWindowsBuild,
 				)).UnsortedList(), lo.Keys(karpv1.NormalizedLabels)...)))
 
@@ -351,6 +376,7 @@ var _ = Describe("InstanceTypeProvider", func() {
 			v1.LabelInstanceFamily:                       "inf2",
 			v1.LabelInstanceSize:                         "xlarge",
 			v1.LabelInstanceCPU:                          "4",
+			v1.LabelInstanceCPUCores:                     "2",
 			v1.LabelInstanceCPUSustainedClockSpeedMhz:    "3600",
 			v1.LabelInstanceCPUManufacturer:              "amd",
 			v1.LabelInstanceMemory:                       "16384",
@@ -360,6 +386,8 @@ var _ = Describe("InstanceTypeProvider", func() {
 			v1.LabelInstanceAcceleratorManufacturer:      "aws",
 			v1.LabelInstanceAcceleratorCount:             "1",
 			v1.LabelTopologyZoneID:                       "tstz1-1a",
+			v1.LabelTopologyZoneType:                     "availability-zone",
+			v1.LabelInstanceTenancy:                      "default",
 			// Deprecated Labels
 			corev1.LabelFailureDomainBetaRegion: fake.DefaultRegion,
 			corev1.LabelFailureDomainBetaZone:   "test-zone-1a",
@@ -376,6 +404,8 @@ var _ = Describe("InstanceTypeProvider", func() {
 			v1.LabelInstanceGPUManufacturer,
 			v1.LabelInstanceGPUMemory,
 			v1.LabelInstanceLocalNVME,
+			v1.LabelCapacityReservationID,
+			v1.LabelTopologyParentZone,
 			corev1.LabelWindowsBuild,
 		)).UnsortedList(), lo.Keys(karpv1.NormalizedLabels)...)
 		Expect(lo.Keys(nodeSelector)).To(ContainElements(expectedLabels))
@@ -502,7 +532,7 @@ var _ = Describe("InstanceTypeProvider", func() {
 		// find the cheapest OD price that works
 		cheapestODPrice := math.MaxFloat64
 		for _, override := range call.LaunchTemplateConfigs[0].Overrides {
-			odPrice, ok := awsEnv.PricingProvider.OnDemandPrice(override.InstanceType)
+			odPrice, ok := awsEnv.PricingProvider.OnDemandPrice(pricing.OperatingSystemLinux, override.InstanceType)
 			Expect(ok).To(BeTrue())
 			if odPrice < cheapestODPrice {
 				cheapestODPrice = odPrice
@@ -510,7 +540,7 @@ var _ = Describe("InstanceTypeProvider", func() {
 		}
 		// and our spot prices should be cheaper than the OD price
 		for _, override := range call.LaunchTemplateConfigs[0].Overrides {
-			spotPrice, ok := awsEnv.PricingProvider.SpotPrice(override.InstanceType, *override.AvailabilityZone)
+			spotPrice, ok := awsEnv.PricingProvider.SpotPrice(pricing.OperatingSystemLinux, override.InstanceType, *override.AvailabilityZone)
 			Expect(ok).To(BeTrue())
 			Expect(spotPrice).To(BeNumerically("<", cheapestODPrice))
 		}
@@ -954,6 +984,9 @@ var _ = Describe("InstanceTypeProvider", func() {
 				fake.DefaultRegion,
 				nodeClass.Spec.BlockDeviceMappings,
 				nodeClass.Spec.InstanceStorePolicy,
+				nodeClass.Spec.CPUOptions,
+				nodeClass.Spec.NetworkInterfaces,
+				nodeClass.IPAddressMode(),
 				nodeClass.Spec.Kubelet.MaxPods,
 				nodeClass.Spec.Kubelet.PodsPerCore,
 				nodeClass.Spec.Kubelet.KubeReserved,
@@ -976,6 +1009,9 @@ var _ = Describe("InstanceTypeProvider", func() {
 				fake.DefaultRegion,
 				windowsNodeClass.Spec.BlockDeviceMappings,
 				windowsNodeClass.Spec.InstanceStorePolicy,
+				windowsNodeClass.Spec.CPUOptions,
+				windowsNodeClass.Spec.NetworkInterfaces,
+				windowsNodeClass.IPAddressMode(),
 				nodeClass.Spec.Kubelet.MaxPods,
 				nodeClass.Spec.Kubelet.PodsPerCore,
 				nodeClass.Spec.Kubelet.KubeReserved,
@@ -988,6 +1024,18 @@ var _ = Describe("InstanceTypeProvider", func() {
 			Expect(it.Capacity.Pods().Value()).To(BeNumerically("==", 110))
 		}
 	})
+	It("should only offer the zone of a cluster placement group", func() {
+		nodeClass.Status.PlacementGroup = &v1.PlacementGroup{ID: "pg-test", Name: "test", Strategy: string(ec2types.PlacementStrategyCluster), Zone: "test-zone-1b"}
+		instanceTypes, err := awsEnv.InstanceTypesProvider.List(ctx, nodeClass)
+		Expect(err).To(BeNil())
+		zones := sets.New[string]()
+		for _, it := range instanceTypes {
+			for _, of := range it.Offerings.Available() {
+				zones.Insert(of.Requirements.Get(corev1.LabelTopologyZone).Any())
+			}
+		}
+		Expect(sets.List(zones)).To(Equal([]string{"test-zone-1b"}))
+	})
 	Context("Metrics", func() {
 		It("should expose vcpu metrics for instance types", func() {
 			instanceTypes, err := awsEnv.InstanceTypesProvider.List(ctx, nodeClass)
@@ -1045,6 +1093,7 @@ var _ = Describe("InstanceTypeProvider", func() {
 						"instance_type": it.Name,
 						"capacity_type": of.Requirements.Get(karpv1.CapacityTypeLabelKey).Any(),
 						"zone":          of.Requirements.Get(corev1.LabelTopologyZone).Any(),
+						"price_type":    "effective",
 					})
 					Expect(ok).To(BeTrue())
 					Expect(metric).To(Not(BeNil()))
@@ -1053,6 +1102,36 @@ var _ = Describe("InstanceTypeProvider", func() {
 				}
 			}
 		})
+		It("should expose raw and effective pricing metrics when prices are overridden", func() {
+			// List the instance types before setting the overrides to ensure that cached offerings are invalidated
+			_, err := awsEnv.InstanceTypesProvider.List(ctx, nodeClass)
+			Expect(err).To(BeNil())
+			awsEnv.PricingProvider.SetOverrides(ctx, []pricing.Override{{InstanceFamily: "m5", Multiplier: lo.ToPtr(0.5)}})
+			instanceTypes, err := awsEnv.InstanceTypesProvider.List(ctx, nodeClass)
+			Expect(err).To(BeNil())
+			it, ok := lo.Find(instanceTypes, func(it *corecloudprovider.InstanceType) bool { return it.Name == "m5.large" })
+			Expect(ok).To(BeTrue())
+			for _, of := range it.Offerings {
+				capacityType := of.Requirements.Get(karpv1.CapacityTypeLabelKey).Any()
+				zone := of.Requirements.Get(corev1.LabelTopologyZone).Any()
+				raw, ok := FindMetricWithLabelValues("karpenter_cloudprovider_instance_type_offering_price_estimate", map[string]string{
+					"instance_type": it.Name,
+					"capacity_type": capacityType,
+					"zone":          zone,
+					"price_type":    "raw",
+				})
+				Expect(ok).To(BeTrue())
+				effective, ok := FindMetricWithLabelValues("karpenter_cloudprovider_instance_type_offering_price_estimate", map[string]string{
+					"instance_type": it.Name,
+					"capacity_type": capacityType,
+					"zone":          zone,
+					"price_type":    "effective",
+				})
+				Expect(ok).To(BeTrue())
+				Expect(aws.ToFloat64(effective.GetGauge().Value)).To(BeNumerically("==", of.Price))
+				Expect(aws.ToFloat64(raw.GetGauge().Value)).To(BeNumerically("~", of.Price*2))
+			}
+		})
 	})
 	It("should launch instances in local zones", func() {
 		nodeClass.Status.Subnets = []v1.Subnet{
@@ -1072,6 +1151,159 @@ var _ = Describe("InstanceTypeProvider", func() {
 		ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
 		ExpectScheduled(ctx, env.Client, pod)
 	})
+	Context("Operating Systems", func() {
+		BeforeEach(func() {
+			now := time.Now()
+			awsEnv.PricingAPI.GetProductsOutputByOperatingSystem.Set(&map[string]awspricing.GetProductsOutput{
+				"Linux":   {PriceList: []string{fake.NewOnDemandPrice("m5.large", 1.00)}},
+				"Windows": {PriceList: []string{fake.NewOnDemandPrice("m5.large", 1.75)}},
+			})
+			awsEnv.EC2API.DescribeSpotPriceHistoryOutput.Set(&ec2.DescribeSpotPriceHistoryOutput{
+				SpotPriceHistory: []ec2types.SpotPrice{
+					{
+						AvailabilityZone:   aws.String("test-zone-1a"),
+						InstanceType:       "m5.large",
+						ProductDescription: ec2types.RIProductDescriptionLinuxUnixAmazonVpc,
+						SpotPrice:          aws.String("0.30"),
+						Timestamp:          &now,
+					},
+					{
+						AvailabilityZone:   aws.String("test-zone-1a"),
+						InstanceType:       "m5.large",
+						ProductDescription: ec2types.RIProductDescriptionWindowsAmazonVpc,
+						SpotPrice:          aws.String("0.95"),
+						Timestamp:          &now,
+					},
+				},
+			})
+			Expect(awsEnv.PricingProvider.UpdateOnDemandPricing(ctx)).To(Succeed())
+			Expect(awsEnv.PricingProvider.UpdateSpotPricing(ctx)).To(Succeed())
+			nodeClass.Status.Subnets = []v1.Subnet{{ID: "subnet-test1", Zone: "test-zone-1a", ZoneID: "tstz1-1a"}}
+		})
+		DescribeTable("should price offerings for the operating system of the AMI family",
+			func(alias string, onDemandPrice, spotPrice float64) {
+				nodeClass.Spec.AMISelectorTerms = []v1.AMISelectorTerm{{Alias: alias}}
+				instanceTypes, err := awsEnv.InstanceTypesProvider.List(ctx, nodeClass)
+				Expect(err).To(BeNil())
+				it, ok := lo.Find(instanceTypes, func(it *corecloudprovider.InstanceType) bool { return it.Name == "m5.large" })
+				Expect(ok).To(BeTrue())
+				prices := lo.SliceToMap(lo.Filter(it.Offerings, func(of corecloudprovider.Offering, _ int) bool {
+					return of.Requirements.Get(corev1.LabelTopologyZone).Any() == "test-zone-1a"
+				}), func(of corecloudprovider.Offering) (string, float64) {
+					return of.Requirements.Get(karpv1.CapacityTypeLabelKey).Any(), of.Price
+				})
+				Expect(prices).To(HaveKeyWithValue(karpv1.CapacityTypeOnDemand, onDemandPrice))
+				Expect(prices).To(HaveKeyWithValue(karpv1.CapacityTypeSpot, spotPrice))
+			},
+			Entry("AL2023", "al2023@latest", 1.00, 0.30),
+			Entry("Bottlerocket", "bottlerocket@latest", 1.00, 0.30),
+			Entry("Windows2019", "windows2019@latest", 1.75, 0.95),
+			Entry("Windows2022", "windows2022@latest", 1.75, 0.95),
+		)
+	})
+	Context("Zone Types", func() {
+		It("should add zone type and parent zone requirements to local zone offerings", func() {
+			nodeClass.Status.Subnets = []v1.Subnet{
+				{ID: "subnet-test1", Zone: "test-zone-1a", ZoneID: "tstz1-1a", ZoneType: v1.ZoneTypeAvailabilityZone},
+				{ID: "subnet-test4", Zone: "test-zone-1a-local", ZoneID: "tstz1-1alocal", ZoneType: v1.ZoneTypeLocalZone, ParentZone: "test-zone-1a"},
+			}
+			instanceTypes, err := awsEnv.InstanceTypesProvider.List(ctx, nodeClass)
+			Expect(err).To(BeNil())
+			it, ok := lo.Find(instanceTypes, func(it *corecloudprovider.InstanceType) bool { return it.Name == "m5.large" })
+			Expect(ok).To(BeTrue())
+			for _, of := range it.Offerings.Available() {
+				switch of.Requirements.Get(corev1.LabelTopologyZone).Any() {
+				case "test-zone-1a-local":
+					Expect(of.Requirements.Get(v1.LabelTopologyZoneType).Any()).To(Equal(v1.ZoneTypeLocalZone))
+					Expect(of.Requirements.Get(v1.LabelTopologyParentZone).Any()).To(Equal("test-zone-1a"))
+				case "test-zone-1a":
+					Expect(of.Requirements.Get(v1.LabelTopologyZoneType).Any()).To(Equal(v1.ZoneTypeAvailabilityZone))
+					Expect(of.Requirements.Get(v1.LabelTopologyParentZone).Operator()).To(Equal(corev1.NodeSelectorOpDoesNotExist))
+				}
+			}
+			Expect(it.Requirements.Get(v1.LabelTopologyZoneType).Values()).To(ConsistOf(v1.ZoneTypeAvailabilityZone, v1.ZoneTypeLocalZone))
+		})
+		It("should launch into a local zone when selecting on zone type", func() {
+			nodeClass.Status.Subnets = []v1.Subnet{
+				{ID: "subnet-test1", Zone: "test-zone-1a", ZoneID: "tstz1-1a", ZoneType: v1.ZoneTypeAvailabilityZone},
+				{ID: "subnet-test4", Zone: "test-zone-1a-local", ZoneID: "tstz1-1alocal", ZoneType: v1.ZoneTypeLocalZone, ParentZone: "test-zone-1a"},
+			}
+			ExpectApplied(ctx, env.Client, nodePool, nodeClass)
+			pod := coretest.UnschedulablePod(coretest.PodOptions{
+				NodeSelector: map[string]string{
+					v1.LabelTopologyZoneType:   v1.ZoneTypeLocalZone,
+					v1.LabelTopologyParentZone: "test-zone-1a",
+				},
+			})
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+			node := ExpectScheduled(ctx, env.Client, pod)
+			Expect(node.Labels).To(HaveKeyWithValue(corev1.LabelTopologyZone, "test-zone-1a-local"))
+		})
+		It("should use the on-demand price of the local zone", func() {
+			awsEnv.EC2API.DescribeAvailabilityZonesOutput.Set(&ec2.DescribeAvailabilityZonesOutput{AvailabilityZones: []ec2types.AvailabilityZone{
+				{ZoneName: aws.String("test-zone-1a"), ZoneType: aws.String(v1.ZoneTypeAvailabilityZone), NetworkBorderGroup: aws.String(fake.DefaultRegion)},
+				{ZoneName: aws.String("test-zone-1a-local"), ZoneType: aws.String(v1.ZoneTypeLocalZone), NetworkBorderGroup: aws.String("test-region-local-1")},
+			}})
+			awsEnv.PricingAPI.GetProductsOutput.Set(&awspricing.GetProductsOutput{
+				PriceList: []string{fake.NewOnDemandPrice("m5.large", 1.00)},
+			})
+			awsEnv.PricingAPI.GetProductsOutputByRegionCode.Set(&map[string]awspricing.GetProductsOutput{
+				"test-region-local-1": {PriceList: []string{fake.NewOnDemandPrice("m5.large", 1.25)}},
+			})
+			Expect(awsEnv.PricingProvider.UpdateOnDemandPricing(ctx)).To(Succeed())
+			nodeClass.Status.Subnets = []v1.Subnet{
+				{ID: "subnet-test1", Zone: "test-zone-1a", ZoneID: "tstz1-1a", ZoneType: v1.ZoneTypeAvailabilityZone},
+				{ID: "subnet-test4", Zone: "test-zone-1a-local", ZoneID: "tstz1-1alocal", ZoneType: v1.ZoneTypeLocalZone, ParentZone: "test-zone-1a"},
+			}
+			instanceTypes, err := awsEnv.InstanceTypesProvider.List(ctx, nodeClass)
+			Expect(err).To(BeNil())
+			it, ok := lo.Find(instanceTypes, func(it *corecloudprovider.InstanceType) bool { return it.Name == "m5.large" })
+			Expect(ok).To(BeTrue())
+			prices := lo.SliceToMap(lo.Filter(it.Offerings, func(of corecloudprovider.Offering, _ int) bool {
+				return of.Requirements.Get(karpv1.CapacityTypeLabelKey).Any() == karpv1.CapacityTypeOnDemand
+			}), func(of corecloudprovider.Offering) (string, float64) {
+				return of.Requirements.Get(corev1.LabelTopologyZone).Any(), of.Price
+			})
+			Expect(prices).To(HaveKeyWithValue("test-zone-1a", 1.00))
+			Expect(prices).To(HaveKeyWithValue("test-zone-1a-local", 1.25))
+		})
+		Context("Outposts", func() {
+			outpostARN := "arn:aws:outposts:us-west-2:123456789012:outpost/op-0123456789abcdef0"
+			BeforeEach(func() {
+				offerings, err := awsEnv.EC2API.DescribeInstanceTypeOfferings(ctx, &ec2.DescribeInstanceTypeOfferingsInput{})
+				Expect(err).To(BeNil())
+				offerings.InstanceTypeOfferings = append(offerings.InstanceTypeOfferings, ec2types.InstanceTypeOffering{
+					InstanceType: "m5.large",
+					Location:     aws.String(outpostARN),
+					LocationType: ec2types.LocationTypeOutpost,
+				})
+				awsEnv.EC2API.DescribeInstanceTypeOfferingsOutput.Set(offerings)
+				Expect(awsEnv.InstanceTypesProvider.UpdateInstanceTypeOfferings(ctx)).To(Succeed())
+				nodeClass.Status.Subnets = []v1.Subnet{
+					{ID: "subnet-test1", Zone: "test-zone-1a", ZoneID: "tstz1-1a", ZoneType: v1.ZoneTypeOutpost, OutpostARN: outpostARN},
+				}
+			})
+			It("should only offer instance types that the outpost has capacity for", func() {
+				instanceTypes, err := awsEnv.InstanceTypesProvider.List(ctx, nodeClass)
+				Expect(err).To(BeNil())
+				available := lo.Filter(instanceTypes, func(it *corecloudprovider.InstanceType, _ int) bool {
+					return len(it.Offerings.Available()) != 0
+				})
+				Expect(lo.Map(available, func(it *corecloudprovider.InstanceType, _ int) string { return it.Name })).To(ConsistOf("m5.large"))
+				Expect(available[0].Requirements.Get(v1.LabelTopologyZoneType).Values()).To(ConsistOf(v1.ZoneTypeOutpost))
+			})
+			It("should not create spot offerings for outposts", func() {
+				instanceTypes, err := awsEnv.InstanceTypesProvider.List(ctx, nodeClass)
+				Expect(err).To(BeNil())
+				it, ok := lo.Find(instanceTypes, func(it *corecloudprovider.InstanceType) bool { return it.Name == "m5.large" })
+				Expect(ok).To(BeTrue())
+				Expect(it.Offerings.Available()).ToNot(BeEmpty())
+				for _, of := range it.Offerings.Available() {
+					Expect(of.Requirements.Get(karpv1.CapacityTypeLabelKey).Any()).To(Equal(karpv1.CapacityTypeOnDemand))
+				}
+			})
+		})
+	})
 	Context("Overhead", func() {
 		var info ec2types.InstanceTypeInfo
 		BeforeEach(func() {
@@ -1095,6 +1327,9 @@ var _ = Describe("InstanceTypeProvider", func() {
 					fake.DefaultRegion,
 					nodeClass.Spec.BlockDeviceMappings,
 					nodeClass.Spec.InstanceStorePolicy,
+					nodeClass.Spec.CPUOptions,
+					nodeClass.Spec.NetworkInterfaces,
+					nodeClass.IPAddressMode(),
 					nodeClass.Spec.Kubelet.MaxPods,
 					nodeClass.Spec.Kubelet.PodsPerCore,
 					nodeClass.Spec.Kubelet.KubeReserved,
@@ -1121,6 +1356,9 @@ var _ = Describe("InstanceTypeProvider", func() {
 					fake.DefaultRegion,
 					nodeClass.Spec.BlockDeviceMappings,
 					nodeClass.Spec.InstanceStorePolicy,
+					nodeClass.Spec.CPUOptions,
+					nodeClass.Spec.NetworkInterfaces,
+					nodeClass.IPAddressMode(),
 					nodeClass.Spec.Kubelet.MaxPods,
 					nodeClass.Spec.Kubelet.PodsPerCore,
 					nodeClass.Spec.Kubelet.KubeReserved,
@@ -1143,6 +1381,9 @@ var _ = Describe("InstanceTypeProvider", func() {
 					fake.DefaultRegion,
 					nodeClass.Spec.BlockDeviceMappings,
 					nodeClass.Spec.InstanceStorePolicy,
+					nodeClass.Spec.CPUOptions,
+					nodeClass.Spec.NetworkInterfaces,
+					nodeClass.IPAddressMode(),
 					nodeClass.Spec.Kubelet.MaxPods,
 					nodeClass.Spec.Kubelet.PodsPerCore,
 					nodeClass.Spec.Kubelet.KubeReserved,
@@ -1174,6 +1415,9 @@ var _ = Describe("InstanceTypeProvider", func() {
 					fake.DefaultRegion,
 					nodeClass.Spec.BlockDeviceMappings,
 					nodeClass.Spec.InstanceStorePolicy,
+					nodeClass.Spec.CPUOptions,
+					nodeClass.Spec.NetworkInterfaces,
+					nodeClass.IPAddressMode(),
 					nodeClass.Spec.Kubelet.MaxPods,
 					nodeClass.Spec.Kubelet.PodsPerCore,
 					nodeClass.Spec.Kubelet.KubeReserved,
@@ -1212,6 +1456,9 @@ var _ = Describe("InstanceTypeProvider", func() {
 						fake.DefaultRegion,
 						nodeClass.Spec.BlockDeviceMappings,
 						nodeClass.Spec.InstanceStorePolicy,
+						nodeClass.Spec.CPUOptions,
+						nodeClass.Spec.NetworkInterfaces,
+						nodeClass.IPAddressMode(),
 						nodeClass.Spec.Kubelet.MaxPods,
 						nodeClass.Spec.Kubelet.PodsPerCore,
 						nodeClass.Spec.Kubelet.KubeReserved,
@@ -1240,6 +1487,9 @@ var _ = Describe("InstanceTypeProvider", func() {
 						fake.DefaultRegion,
 						nodeClass.Spec.BlockDeviceMappings,
 						nodeClass.Spec.InstanceStorePolicy,
+						nodeClass.Spec.CPUOptions,
+						nodeClass.Spec.NetworkInterfaces,
+						nodeClass.IPAddressMode(),
 						nodeClass.Spec.Kubelet.MaxPods,
 						nodeClass.Spec.Kubelet.PodsPerCore,
 						nodeClass.Spec.Kubelet.KubeReserved,
@@ -1268,6 +1518,9 @@ var _ = Describe("InstanceTypeProvider", func() {
 						fake.DefaultRegion,
 						nodeClass.Spec.BlockDeviceMappings,
 						nodeClass.Spec.InstanceStorePolicy,
+						nodeClass.Spec.CPUOptions,
+						nodeClass.Spec.NetworkInterfaces,
+						nodeClass.IPAddressMode(),
 						nodeClass.Spec.Kubelet.MaxPods,
 						nodeClass.Spec.Kubelet.PodsPerCore,
 						nodeClass.Spec.Kubelet.KubeReserved,
@@ -1296,6 +1549,9 @@ var _ = Describe("InstanceTypeProvider", func() {
 						fake.DefaultRegion,
 						nodeClass.Spec.BlockDeviceMappings,
 						nodeClass.Spec.InstanceStorePolicy,
+						nodeClass.Spec.CPUOptions,
+						nodeClass.Spec.NetworkInterfaces,
+						nodeClass.IPAddressMode(),
 						nodeClass.Spec.Kubelet.MaxPods,
 						nodeClass.Spec.Kubelet.PodsPerCore,
 						nodeClass.Spec.Kubelet.KubeReserved,
@@ -1326,6 +1582,9 @@ var _ = Describe("InstanceTypeProvider", func() {
 						fake.DefaultRegion,
 						nodeClass.Spec.BlockDeviceMappings,
 						nodeClass.Spec.InstanceStorePolicy,
+						nodeClass.Spec.CPUOptions,
+						nodeClass.Spec.NetworkInterfaces,
+						nodeClass.IPAddressMode(),
 						nodeClass.Spec.Kubelet.MaxPods,
 						nodeClass.Spec.Kubelet.PodsPerCore,
 						nodeClass.Spec.Kubelet.KubeReserved,
@@ -1357,6 +1616,9 @@ var _ = Describe("InstanceTypeProvider", func() {
 						fake.DefaultRegion,
 						nodeClass.Spec.BlockDeviceMappings,
 						nodeClass.Spec.InstanceStorePolicy,
+						nodeClass.Spec.CPUOptions,
+						nodeClass.Spec.NetworkInterfaces,
+						nodeClass.IPAddressMode(),
 						nodeClass.Spec.Kubelet.MaxPods,
 						nodeClass.Spec.Kubelet.PodsPerCore,
 						nodeClass.Spec.Kubelet.KubeReserved,
@@ -1385,6 +1647,9 @@ var _ = Describe("InstanceTypeProvider", func() {
 						fake.DefaultRegion,
 						nodeClass.Spec.BlockDeviceMappings,
 						nodeClass.Spec.InstanceStorePolicy,
+						nodeClass.Spec.CPUOptions,
+						nodeClass.Spec.NetworkInterfaces,
+						nodeClass.IPAddressMode(),
 						nodeClass.Spec.Kubelet.MaxPods,
 						nodeClass.Spec.Kubelet.PodsPerCore,
 						nodeClass.Spec.Kubelet.KubeReserved,
@@ -1417,6 +1682,9 @@ var _ = Describe("InstanceTypeProvider", func() {
 						fake.DefaultRegion,
 						nodeClass.Spec.BlockDeviceMappings,
 						nodeClass.Spec.InstanceStorePolicy,
+						nodeClass.Spec.CPUOptions,
+						nodeClass.Spec.NetworkInterfaces,
+						nodeClass.IPAddressMode(),
 						nodeClass.Spec.Kubelet.MaxPods,
 						nodeClass.Spec.Kubelet.PodsPerCore,
 						nodeClass.Spec.Kubelet.KubeReserved,
@@ -1436,6 +1704,9 @@ var _ = Describe("InstanceTypeProvider", func() {
 					fake.DefaultRegion,
 					nodeClass.Spec.BlockDeviceMappings,
 					nodeClass.Spec.InstanceStorePolicy,
+					nodeClass.Spec.CPUOptions,
+					nodeClass.Spec.NetworkInterfaces,
+					nodeClass.IPAddressMode(),
 					nodeClass.Spec.Kubelet.MaxPods,
 					nodeClass.Spec.Kubelet.PodsPerCore,
 					nodeClass.Spec.Kubelet.KubeReserved,
@@ -1469,6 +1740,9 @@ var _ = Describe("InstanceTypeProvider", func() {
 					fake.DefaultRegion,
 					nodeClass.Spec.BlockDeviceMappings,
 					nodeClass.Spec.InstanceStorePolicy,
+					nodeClass.Spec.CPUOptions,
+					nodeClass.Spec.NetworkInterfaces,
+					nodeClass.IPAddressMode(),
 					nodeClass.Spec.Kubelet.MaxPods,
 					nodeClass.Spec.Kubelet.PodsPerCore,
 					nodeClass.Spec.Kubelet.KubeReserved,
@@ -1500,6 +1774,9 @@ var _ = Describe("InstanceTypeProvider", func() {
 					fake.DefaultRegion,
 					nodeClass.Spec.BlockDeviceMappings,
 					nodeClass.Spec.InstanceStorePolicy,
+					nodeClass.Spec.CPUOptions,
+					nodeClass.Spec.NetworkInterfaces,
+					nodeClass.IPAddressMode(),
 					nodeClass.Spec.Kubelet.MaxPods,
 					nodeClass.Spec.Kubelet.PodsPerCore,
 					nodeClass.Spec.Kubelet.KubeReserved,
@@ -1531,6 +1808,9 @@ var _ = Describe("InstanceTypeProvider", func() {
 					fake.DefaultRegion,
 					nodeClass.Spec.BlockDeviceMappings,
 					nodeClass.Spec.InstanceStorePolicy,
+					nodeClass.Spec.CPUOptions,
+					nodeClass.Spec.NetworkInterfaces,
+					nodeClass.IPAddressMode(),
 					nodeClass.Spec.Kubelet.MaxPods,
 					nodeClass.Spec.Kubelet.PodsPerCore,
 					nodeClass.Spec.Kubelet.KubeReserved,
@@ -1554,6 +1834,9 @@ var _ = Describe("InstanceTypeProvider", func() {
 						fake.DefaultRegion,
 						nodeClass.Spec.BlockDeviceMappings,
 						nodeClass.Spec.InstanceStorePolicy,
+						nodeClass.Spec.CPUOptions,
+						nodeClass.Spec.NetworkInterfaces,
+						nodeClass.IPAddressMode(),
 						nodeClass.Spec.Kubelet.MaxPods,
 						nodeClass.Spec.Kubelet.PodsPerCore,
 						nodeClass.Spec.Kubelet.KubeReserved,
@@ -1571,6 +1854,9 @@ var _ = Describe("InstanceTypeProvider", func() {
 						fake.DefaultRegion,
 						nodeClass.Spec.BlockDeviceMappings,
 						nodeClass.Spec.InstanceStorePolicy,
+						nodeClass.Spec.CPUOptions,
+						nodeClass.Spec.NetworkInterfaces,
+						nodeClass.IPAddressMode(),
 						nodeClass.Spec.Kubelet.MaxPods,
 						nodeClass.Spec.Kubelet.PodsPerCore,
 						nodeClass.Spec.Kubelet.KubeReserved,
@@ -1596,6 +1882,9 @@ var _ = Describe("InstanceTypeProvider", func() {
 					fake.DefaultRegion,
 					nodeClass.Spec.BlockDeviceMappings,
 					nodeClass.Spec.InstanceStorePolicy,
+					nodeClass.Spec.CPUOptions,
+					nodeClass.Spec.NetworkInterfaces,
+					nodeClass.IPAddressMode(),
 					nodeClass.Spec.Kubelet.MaxPods,
 					nodeClass.Spec.Kubelet.PodsPerCore,
 					nodeClass.Spec.Kubelet.KubeReserved,
@@ -1620,6 +1909,9 @@ var _ = Describe("InstanceTypeProvider", func() {
 					fake.DefaultRegion,
 					nodeClass.Spec.BlockDeviceMappings,
 					nodeClass.Spec.InstanceStorePolicy,
+					nodeClass.Spec.CPUOptions,
+					nodeClass.Spec.NetworkInterfaces,
+					nodeClass.IPAddressMode(),
 					nodeClass.Spec.Kubelet.MaxPods,
 					nodeClass.Spec.Kubelet.PodsPerCore,
 					nodeClass.Spec.Kubelet.KubeReserved,
@@ -1649,6 +1941,9 @@ var _ = Describe("InstanceTypeProvider", func() {
 				fake.DefaultRegion,
 				nodeClass.Spec.BlockDeviceMappings,
 				nodeClass.Spec.InstanceStorePolicy,
+				nodeClass.Spec.CPUOptions,
+				nodeClass.Spec.NetworkInterfaces,
+				nodeClass.IPAddressMode(),
 				nodeClass.Spec.Kubelet.MaxPods,
 				nodeClass.Spec.Kubelet.PodsPerCore,
 				nodeClass.Spec.Kubelet.KubeReserved,
@@ -1683,6 +1978,9 @@ var _ = Describe("InstanceTypeProvider", func() {
 				fake.DefaultRegion,
 				nodeClass.Spec.BlockDeviceMappings,
 				nodeClass.Spec.InstanceStorePolicy,
+				nodeClass.Spec.CPUOptions,
+				nodeClass.Spec.NetworkInterfaces,
+				nodeClass.IPAddressMode(),
 				nodeClass.Spec.Kubelet.MaxPods,
 				nodeClass.Spec.Kubelet.PodsPerCore,
 				nodeClass.Spec.Kubelet.KubeReserved,
@@ -1713,6 +2011,9 @@ var _ = Describe("InstanceTypeProvider", func() {
 					fake.DefaultRegion,
 					nodeClass.Spec.BlockDeviceMappings,
 					nodeClass.Spec.InstanceStorePolicy,
+					nodeClass.Spec.CPUOptions,
+					nodeClass.Spec.NetworkInterfaces,
+					nodeClass.IPAddressMode(),
 					nodeClass.Spec.Kubelet.MaxPods,
 					nodeClass.Spec.Kubelet.PodsPerCore,
 					nodeClass.Spec.Kubelet.KubeReserved,
@@ -1738,6 +2039,9 @@ var _ = Describe("InstanceTypeProvider", func() {
 					fake.DefaultRegion,
 					nodeClass.Spec.BlockDeviceMappings,
 					nodeClass.Spec.InstanceStorePolicy,
+					nodeClass.Spec.CPUOptions,
+					nodeClass.Spec.NetworkInterfaces,
+					nodeClass.IPAddressMode(),
 					nodeClass.Spec.Kubelet.MaxPods,
 					nodeClass.Spec.Kubelet.PodsPerCore,
 					nodeClass.Spec.Kubelet.KubeReserved,
@@ -1763,6 +2067,9 @@ var _ = Describe("InstanceTypeProvider", func() {
 					fake.DefaultRegion,
 					nodeClass.Spec.BlockDeviceMappings,
 					nodeClass.Spec.InstanceStorePolicy,
+					nodeClass.Spec.CPUOptions,
+					nodeClass.Spec.NetworkInterfaces,
+					nodeClass.IPAddressMode(),
 					nodeClass.Spec.Kubelet.MaxPods,
 					nodeClass.Spec.Kubelet.PodsPerCore,
 					nodeClass.Spec.Kubelet.KubeReserved,
@@ -1772,7 +2079,7 @@ var _ = Describe("InstanceTypeProvider", func() {
 					nodeClass.AMIFamily(),
 					nil,
 				)
-				limitedPods := instancetype.ENILimitedPods(ctx, info)
+				limitedPods := instancetype.ENILimitedPods(ctx, info, nil, "")
 				Expect(it.Capacity.Pods().Value()).To(BeNumerically("==", limitedPods.Value()))
 			}
 		})
@@ -1789,6 +2096,9 @@ var _ = Describe("InstanceTypeProvider", func() {
 						fake.DefaultRegion,
 						nodeClass.Spec.BlockDeviceMappings,
 						nodeClass.Spec.InstanceStorePolicy,
+						nodeClass.Spec.CPUOptions,
+						nodeClass.Spec.NetworkInterfaces,
+						nodeClass.IPAddressMode(),
 						nodeClass.Spec.Kubelet.MaxPods,
 						nodeClass.Spec.Kubelet.PodsPerCore,
 						nodeClass.Spec.Kubelet.KubeReserved,
@@ -1806,6 +2116,9 @@ var _ = Describe("InstanceTypeProvider", func() {
 						fake.DefaultRegion,
 						nodeClass.Spec.BlockDeviceMappings,
 						nodeClass.Spec.InstanceStorePolicy,
+						nodeClass.Spec.CPUOptions,
+						nodeClass.Spec.NetworkInterfaces,
+						nodeClass.IPAddressMode(),
 						nodeClass.Spec.Kubelet.MaxPods,
 						nodeClass.Spec.Kubelet.PodsPerCore,
 						nodeClass.Spec.Kubelet.KubeReserved,
@@ -2084,6 +2397,131 @@ var _ = Describe("InstanceTypeProvider", func() {
 			node := ExpectScheduled(ctx, env.Client, pod)
 			Expect(node.Labels).To(HaveKeyWithValue(karpv1.CapacityTypeLabelKey, karpv1.CapacityTypeOnDemand))
 		})
+		It("should only mark offerings as unavailable within the placement group when launching into a placement group", func() {
+			nodeClass.Status.PlacementGroup = &v1.PlacementGroup{ID: "pg-test", Name: "test", Strategy: string(ec2types.PlacementStrategyCluster)}
+			awsEnv.EC2API.InsufficientCapacityPools.Set([]fake.CapacityPool{{CapacityType: karpv1.CapacityTypeOnDemand, InstanceType: "p3.8xlarge", Zone: "test-zone-1a"}})
+			pod := coretest.UnschedulablePod(coretest.PodOptions{
+				NodeSelector: map[string]string{corev1.LabelInstanceTypeStable: "p3.8xlarge"},
+				ResourceRequirements: corev1.ResourceRequirements{
+					Requests: corev1.ResourceList{v1.ResourceNVIDIAGPU: resource.MustParse("1")},
+					Limits:   corev1.ResourceList{v1.ResourceNVIDIAGPU: resource.MustParse("1")},
+				},
+			})
+			pod.Spec.Affinity = &corev1.Affinity{NodeAffinity: &corev1.NodeAffinity{PreferredDuringSchedulingIgnoredDuringExecution: []corev1.PreferredSchedulingTerm{
+				{
+					Weight: 1, Preference: corev1.NodeSelectorTerm{MatchExpressions: []corev1.NodeSelectorRequirement{
+						{Key: corev1.LabelTopologyZone, Operator: corev1.NodeSelectorOpIn, Values: []string{"test-zone-1a"}},
+					}},
+				},
+			}}}
+			ExpectApplied(ctx, env.Client, nodePool, nodeClass)
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+			ExpectNotScheduled(ctx, env.Client, pod)
+			Expect(awsEnv.UnavailableOfferingsCache.IsUnavailable("p3.8xlarge", "test-zone-1a", karpv1.CapacityTypeOnDemand)).To(BeFalse())
+			Expect(awsEnv.UnavailableOfferingsCache.IsPlacementGroupUnavailable("pg-test", "p3.8xlarge", "test-zone-1a", karpv1.CapacityTypeOnDemand)).To(BeTrue())
+
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+			node := ExpectScheduled(ctx, env.Client, pod)
+			Expect(node.Labels).To(SatisfyAll(
+				HaveKeyWithValue(corev1.LabelInstanceTypeStable, "p3.8xlarge"),
+				HaveKeyWithValue(corev1.LabelTopologyZone, "test-zone-1b")))
+		})
+		It("should back off offerings that repeatedly fail to launch", func() {
+			labels := map[string]string{"instance_type": "m5.large", "zone": "test-zone-1a", "capacity_type": karpv1.CapacityTypeOnDemand, "placement_group_id": ""}
+			for _, ttl := range []time.Duration{3 * time.Minute, 6 * time.Minute, 12 * time.Minute, 24 * time.Minute, 48 * time.Minute, time.Hour, time.Hour} {
+				awsEnv.UnavailableOfferingsCache.MarkUnavailable(ctx, "InsufficientInstanceCapacity", "m5.large", "test-zone-1a", karpv1.CapacityTypeOnDemand)
+				Expect(awsEnv.UnavailableOfferingsCache.IsUnavailable("m5.large", "test-zone-1a", karpv1.CapacityTypeOnDemand)).To(BeTrue())
+				ExpectMetricGaugeValue(awscache.UnavailableOfferingBackoffSeconds, ttl.Seconds(), labels)
+				// the offering becomes available again before it fails to launch
+				awsEnv.UnavailableOfferingsCache.Delete("m5.large", "test-zone-1a", karpv1.CapacityTypeOnDemand)
+			}
+		})
+		It("should not back off offerings that are marked again while they are unavailable", func() {
+			labels := map[string]string{"instance_type": "m5.large", "zone": "test-zone-1a", "capacity_type": karpv1.CapacityTypeOnDemand, "placement_group_id": ""}
+			for i := 0; i < 3; i++ {
+				awsEnv.UnavailableOfferingsCache.MarkUnavailable(ctx, "InsufficientInstanceCapacity", "m5.large", "test-zone-1a", karpv1.CapacityTypeOnDemand)
+			}
+			ExpectMetricGaugeValue(awscache.UnavailableOfferingBackoffSeconds, (3 * time.Minute).Seconds(), labels)
+		})
+		It("should mark offerings as unavailable for a time based on the reason", func() {
+			awsEnv.UnavailableOfferingsCache.MarkUnavailable(ctx, "spot_interrupted", "m5.large", "test-zone-1a", karpv1.CapacityTypeSpot)
+			awsEnv.UnavailableOfferingsCache.MarkUnavailable(ctx, "InsufficientFreeAddressesInSubnet", "m5.large", "test-zone-1b", karpv1.CapacityTypeOnDemand)
+			awsEnv.UnavailableOfferingsCache.MarkUnavailable(ctx, "UnknownReason", "m5.large", "test-zone-1c", karpv1.CapacityTypeOnDemand)
+			ExpectMetricGaugeValue(awscache.UnavailableOfferingBackoffSeconds, (10 * time.Minute).Seconds(), map[string]string{"instance_type": "m5.large", "zone": "test-zone-1a", "capacity_type": karpv1.CapacityTypeSpot})
+			ExpectMetricGaugeValue(awscache.UnavailableOfferingBackoffSeconds, (5 * time.Minute).Seconds(), map[string]string{"instance_type": "m5.large", "zone": "test-zone-1b", "capacity_type": karpv1.CapacityTypeOnDemand})
+			ExpectMetricGaugeValue(awscache.UnavailableOfferingBackoffSeconds, awscache.UnavailableOfferingsTTL.Seconds(), map[string]string{"instance_type": "m5.large", "zone": "test-zone-1c", "capacity_type": karpv1.CapacityTypeOnDemand})
+			ExpectMetricCounterValue(awscache.UnavailableOfferingsMarkedTotal, 1, map[string]string{"reason": "spot_interrupted", "capacity_type": karpv1.CapacityTypeSpot})
+		})
+		It("should mark the capacity type as unavailable for account-wide errors", func() {
+			awsEnv.UnavailableOfferingsCache.MarkUnavailableForFleetErr(ctx, ec2types.CreateFleetError{
+				ErrorCode: lo.ToPtr("MaxSpotInstanceCountExceeded"),
+				LaunchTemplateAndOverrides: &ec2types.LaunchTemplateAndOverridesResponse{
+					Overrides: &ec2types.FleetLaunchTemplateOverrides{InstanceType: "m5.large", AvailabilityZone: lo.ToPtr("test-zone-1a")},
+				},
+			}, karpv1.CapacityTypeSpot, "")
+			Expect(awsEnv.UnavailableOfferingsCache.IsCapacityTypeUnavailable(karpv1.CapacityTypeSpot)).To(BeTrue())
+			Expect(awsEnv.UnavailableOfferingsCache.IsUnavailable("c5.xlarge", "test-zone-1b", karpv1.CapacityTypeSpot)).To(BeTrue())
+			Expect(awsEnv.UnavailableOfferingsCache.IsUnavailable("m5.large", "test-zone-1a", karpv1.CapacityTypeOnDemand)).To(BeFalse())
+		})
+		It("should mark the instance types of the vCPU quota as unavailable when the quota is exceeded", func() {
+			awsEnv.UnavailableOfferingsCache.MarkUnavailableForFleetErr(ctx, ec2types.CreateFleetError{
+				ErrorCode: lo.ToPtr("VcpuLimitExceeded"),
+				LaunchTemplateAndOverrides: &ec2types.LaunchTemplateAndOverridesResponse{
+					Overrides: &ec2types.FleetLaunchTemplateOverrides{InstanceType: "m5.large", AvailabilityZone: lo.ToPtr("test-zone-1a")},
+				},
+			}, karpv1.CapacityTypeOnDemand, "")
+			Expect(awsEnv.UnavailableOfferingsCache.IsCapacityTypeUnavailable(karpv1.CapacityTypeOnDemand)).To(BeFalse())
+			// Instance types of the same instance class count towards the same quota
+			Expect(awsEnv.UnavailableOfferingsCache.IsUnavailable("c5.xlarge", "test-zone-1b", karpv1.CapacityTypeOnDemand)).To(BeTrue())
+			Expect(awsEnv.UnavailableOfferingsCache.IsUnavailable("g4dn.xlarge", "test-zone-1a", karpv1.CapacityTypeOnDemand)).To(BeFalse())
+			Expect(awsEnv.UnavailableOfferingsCache.IsUnavailable("m5.large", "test-zone-1a", karpv1.CapacityTypeSpot)).To(BeFalse())
+		})
+		It("should mark the instance family as unavailable when the vCPU quota of a family without an instance class is exceeded", func() {
+			awsEnv.UnavailableOfferingsCache.MarkUnavailableForFleetErr(ctx, ec2types.CreateFleetError{
+				ErrorCode: lo.ToPtr("VcpuLimitExceeded"),
+				LaunchTemplateAndOverrides: &ec2types.LaunchTemplateAndOverridesResponse{
+					Overrides: &ec2types.FleetLaunchTemplateOverrides{InstanceType: "trn1.2xlarge", AvailabilityZone: lo.ToPtr("test-zone-1a")},
+				},
+			}, karpv1.CapacityTypeOnDemand, "")
+			Expect(awsEnv.UnavailableOfferingsCache.IsUnavailable("trn1.32xlarge", "test-zone-1b", karpv1.CapacityTypeOnDemand)).To(BeTrue())
+			Expect(awsEnv.UnavailableOfferingsCache.IsUnavailable("dl1.24xlarge", "test-zone-1a", karpv1.CapacityTypeOnDemand)).To(BeFalse())
+			Expect(awsEnv.UnavailableOfferingsCache.IsUnavailable("m5.large", "test-zone-1a", karpv1.CapacityTypeOnDemand)).To(BeFalse())
+		})
+		It("should launch on-demand capacity if flexible to both spot and on-demand, but the spot quota is exceeded", func() {
+			awsEnv.UnavailableOfferingsCache.MarkCapacityTypeUnavailable(ctx, "MaxSpotInstanceCountExceeded", karpv1.CapacityTypeSpot)
+			nodePool.Spec.Template.Spec.Requirements = []karpv1.NodeSelectorRequirementWithMinValues{
+				{NodeSelectorRequirement: corev1.NodeSelectorRequirement{Key: karpv1.CapacityTypeLabelKey, Operator: corev1.NodeSelectorOpIn, Values: []string{karpv1.CapacityTypeSpot, karpv1.CapacityTypeOnDemand}}},
+			}
+			ExpectApplied(ctx, env.Client, nodePool, nodeClass)
+			pod := coretest.UnschedulablePod()
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+			node := ExpectScheduled(ctx, env.Client, pod)
+			Expect(node.Labels).To(HaveKeyWithValue(karpv1.CapacityTypeLabelKey, karpv1.CapacityTypeOnDemand))
+		})
+		It("should serve the unavailable offerings as JSON", func() {
+			awsEnv.UnavailableOfferingsCache.MarkUnavailable(ctx, "InsufficientInstanceCapacity", "m5.large", "test-zone-1a", karpv1.CapacityTypeOnDemand)
+			awsEnv.UnavailableOfferingsCache.MarkCapacityTypeUnavailable(ctx, "MaxSpotInstanceCountExceeded", karpv1.CapacityTypeSpot)
+			recorder := httptest.NewRecorder()
+			awsEnv.UnavailableOfferingsCache.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/debug/unavailable-offerings", nil))
+			Expect(recorder.Code).To(Equal(http.StatusOK))
+
+			var offerings []map[string]any
+			Expect(json.Unmarshal(recorder.Body.Bytes(), &offerings)).To(Succeed())
+			Expect(offerings).To(HaveLen(2))
+			Expect(offerings[0]).To(SatisfyAll(
+				HaveKeyWithValue("reason", "InsufficientInstanceCapacity"),
+				HaveKeyWithValue("capacityType", karpv1.CapacityTypeOnDemand),
+				HaveKeyWithValue("instanceType", "m5.large"),
+				HaveKeyWithValue("zone", "test-zone-1a"),
+				HaveKeyWithValue("failures", BeNumerically("==", 1)),
+				HaveKey("expiration"),
+			))
+			Expect(offerings[1]).To(SatisfyAll(
+				HaveKeyWithValue("reason", "MaxSpotInstanceCountExceeded"),
+				HaveKeyWithValue("capacityType", karpv1.CapacityTypeSpot),
+				Not(HaveKey("instanceType")),
+			))
+		})
 		It("should return all instance types, even though with no offerings due to Insufficient Capacity Error", func() {
 			awsEnv.EC2API.InsufficientCapacityPools.Set([]fake.CapacityPool{
 				{CapacityType: karpv1.CapacityTypeOnDemand, InstanceType: "m5.xlarge", Zone: "test-zone-1a"},
@@ -2137,6 +2575,51 @@ var _ = Describe("InstanceTypeProvider", func() {
 			Expect(instanceTypeNames.Has("m5.xlarge"))
 		})
 	})
+	Context("Service Quotas", func() {
+		BeforeEach(func() {
+			nodePool.Spec.Template.Spec.Requirements = []karpv1.NodeSelectorRequirementWithMinValues{
+				{NodeSelectorRequirement: corev1.NodeSelectorRequirement{Key: karpv1.CapacityTypeLabelKey, Operator: corev1.NodeSelectorOpIn, Values: []string{karpv1.CapacityTypeSpot, karpv1.CapacityTypeOnDemand}}},
+			}
+		})
+		It("should mark offerings unavailable when their instance type would exceed the vcpu quota", func() {
+			awsEnv.ServiceQuotasAPI.ListServiceQuotasBehavior.Output.Set(&servicequotas.ListServiceQuotasOutput{
+				Quotas: []servicequotastypes.ServiceQuota{
+					fake.NewServiceQuota("L-1216C47A", 64),
+					fake.NewServiceQuota("L-34B43A08", 4),
+				},
+			})
+			Expect(awsEnv.ServiceQuotaProvider.UpdateQuotas(ctx)).To(Succeed())
+			ExpectApplied(ctx, env.Client, nodePool, nodeClass)
+			instanceTypes, err := cloudProvider.GetInstanceTypes(ctx, nodePool)
+			Expect(err).ToNot(HaveOccurred())
+			its := lo.SliceToMap(instanceTypes, func(it *corecloudprovider.InstanceType) (string, *corecloudprovider.InstanceType) { return it.Name, it })
+
+			spot := scheduling.NewRequirements(scheduling.NewRequirement(karpv1.CapacityTypeLabelKey, corev1.NodeSelectorOpIn, karpv1.CapacityTypeSpot))
+			onDemand := scheduling.NewRequirements(scheduling.NewRequirement(karpv1.CapacityTypeLabelKey, corev1.NodeSelectorOpIn, karpv1.CapacityTypeOnDemand))
+			Expect(its["m5.xlarge"].Offerings.Available().HasCompatible(spot)).To(BeTrue())
+			Expect(its["m5.metal"].Offerings.Available().HasCompatible(spot)).To(BeFalse())
+			Expect(its["m5.metal"].Offerings.Available().HasCompatible(onDemand)).To(BeFalse())
+			Expect(its["m6idn.32xlarge"].Offerings.Available().HasCompatible(onDemand)).To(BeFalse())
+			// Instance classes without a known quota aren't constrained
+			Expect(its["g4dn.8xlarge"].Offerings.Available().HasCompatible(spot)).To(BeTrue())
+		})
+		It("should launch on-demand capacity when the spot vcpu quota is used up", func() {
+			awsEnv.ServiceQuotasAPI.ListServiceQuotasBehavior.Output.Set(&servicequotas.ListServiceQuotasOutput{
+				Quotas: []servicequotastypes.ServiceQuota{
+					fake.NewServiceQuota("L-1216C47A", 64),
+					fake.NewServiceQuota("L-34B43A08", 0),
+				},
+			})
+			Expect(awsEnv.ServiceQuotaProvider.UpdateQuotas(ctx)).To(Succeed())
+			ExpectApplied(ctx, env.Client, nodePool, nodeClass)
+			pod := coretest.UnschedulablePod(coretest.PodOptions{
+				NodeSelector: map[string]string{corev1.LabelInstanceTypeStable: "m5.large"},
+			})
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+			node := ExpectScheduled(ctx, env.Client, pod)
+			Expect(node.Labels).To(HaveKeyWithValue(karpv1.CapacityTypeLabelKey, karpv1.CapacityTypeOnDemand))
+		})
+	})
 	Context("CapacityType", func() {
 		It("should default to on-demand", func() {
 			ExpectApplied(ctx, env.Client, nodePool, nodeClass)
@@ -2206,6 +2689,534 @@ var _ = Describe("InstanceTypeProvider", func() {
 			node := ExpectScheduled(ctx, env.Client, pod)
 			Expect(node.Labels).To(HaveKeyWithValue(karpv1.NodePoolLabelKey, nodePool.Name))
 		})
+		Context("Reserved", func() {
+			BeforeEach(func() {
+				awsEnv.EC2API.DescribeCapacityReservationsOutput.Set(&ec2.DescribeCapacityReservationsOutput{
+					CapacityReservations: []ec2types.CapacityReservation{
+						{
+							CapacityReservationId:  aws.String("cr-m5-large-1a"),
+							InstanceType:           aws.String("m5.large"),
+							AvailabilityZone:       aws.String("test-zone-1a"),
+							OwnerId:                aws.String("012345678901"),
+							InstanceMatchCriteria:  ec2types.InstanceMatchCriteriaTargeted,
+							AvailableInstanceCount: aws.Int32(1),
+							State:                  ec2types.CapacityReservationStateActive,
+						},
+					},
+				})
+				nodeClass.Spec.CapacityReservationSelectorTerms = []v1.CapacityReservationSelectorTerm{{ID: "cr-m5-large-1a"}}
+				nodeClass.Status.CapacityReservations = []v1.CapacityReservation{
+					{
+						ID:                    "cr-m5-large-1a",
+						InstanceType:          "m5.large",
+						AvailabilityZone:      "test-zone-1a",
+						OwnerID:               "012345678901",
+						InstanceMatchCriteria: string(ec2types.InstanceMatchCriteriaTargeted),
+					},
+				}
+				_, err := awsEnv.CapacityReservationProvider.List(ctx, nodeClass)
+				Expect(err).ToNot(HaveOccurred())
+			})
+			It("should create a reserved offering for each capacity reservation", func() {
+				ExpectApplied(ctx, env.Client, nodeClass)
+				its, err := cloudProvider.GetInstanceTypes(ctx, nodePool)
+				Expect(err).ToNot(HaveOccurred())
+				it, ok := lo.Find(its, func(it *corecloudprovider.InstanceType) bool { return it.Name == "m5.large" })
+				Expect(ok).To(BeTrue())
+				reserved := it.Offerings.Available().Compatible(scheduling.NewRequirements(
+					scheduling.NewRequirement(karpv1.CapacityTypeLabelKey, corev1.NodeSelectorOpIn, v1.CapacityTypeReserved),
+				))
+				Expect(reserved).To(HaveLen(1))
+				Expect(reserved[0].Requirements.Get(v1.LabelCapacityReservationID).Any()).To(Equal("cr-m5-large-1a"))
+				Expect(reserved[0].Requirements.Get(corev1.LabelTopologyZone).Any()).To(Equal("test-zone-1a"))
+
+				onDemand := it.Offerings.Compatible(scheduling.NewRequirements(
+					scheduling.NewRequirement(karpv1.CapacityTypeLabelKey, corev1.NodeSelectorOpIn, karpv1.CapacityTypeOnDemand),
+					scheduling.NewRequirement(corev1.LabelTopologyZone, corev1.NodeSelectorOpIn, "test-zone-1a"),
+				))
+				Expect(onDemand).To(HaveLen(1))
+				Expect(reserved[0].Price).To(BeNumerically("<", onDemand[0].Price))
+			})
+			It("should not create reserved offerings for instance types that don't match a reservation", func() {
+				ExpectApplied(ctx, env.Client, nodeClass)
+				its, err := cloudProvider.GetInstanceTypes(ctx, nodePool)
+				Expect(err).ToNot(HaveOccurred())
+				for _, it := range its {
+					if it.Name == "m5.large" {
+						continue
+					}
+					Expect(it.Offerings.Compatible(scheduling.NewRequirements(
+						scheduling.NewRequirement(karpv1.CapacityTypeLabelKey, corev1.NodeSelectorOpIn, v1.CapacityTypeReserved),
+					))).To(BeEmpty())
+				}
+			})
+			It("should mark reserved offerings as unavailable when the reservation has no remaining capacity", func() {
+				awsEnv.CapacityReservationProvider.MarkLaunched("cr-m5-large-1a")
+				ExpectApplied(ctx, env.Client, nodeClass)
+				its, err := cloudProvider.GetInstanceTypes(ctx, nodePool)
+				Expect(err).ToNot(HaveOccurred())
+				it, ok := lo.Find(its, func(it *corecloudprovider.InstanceType) bool { return it.Name == "m5.large" })
+				Expect(ok).To(BeTrue())
+				Expect(it.Offerings.Available().Compatible(scheduling.NewRequirements(
+					scheduling.NewRequirement(karpv1.CapacityTypeLabelKey, corev1.NodeSelectorOpIn, v1.CapacityTypeReserved),
+				))).To(BeEmpty())
+			})
+			It("should not count launches into a capacity reservation towards the on-demand vcpu quota", func() {
+				awsEnv.ServiceQuotasAPI.ListServiceQuotasBehavior.Output.Set(&servicequotas.ListServiceQuotasOutput{
+					Quotas: []servicequotastypes.ServiceQuota{fake.NewServiceQuota("L-1216C47A", 4)},
+				})
+				Expect(awsEnv.ServiceQuotaProvider.UpdateQuotas(ctx)).To(Succeed())
+				nodePool.Spec.Template.Spec.Requirements = []karpv1.NodeSelectorRequirementWithMinValues{
+					{NodeSelectorRequirement: corev1.NodeSelectorRequirement{Key: karpv1.CapacityTypeLabelKey, Operator: corev1.NodeSelectorOpIn, Values: []string{v1.CapacityTypeReserved}}},
+				}
+				ExpectApplied(ctx, env.Client, nodePool, nodeClass)
+				pod := coretest.UnschedulablePod()
+				ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+				node := ExpectScheduled(ctx, env.Client, pod)
+				Expect(node.Labels).To(HaveKeyWithValue(karpv1.CapacityTypeLabelKey, v1.CapacityTypeReserved))
+
+				quota, ok := lo.Find(awsEnv.ServiceQuotaProvider.Quotas(), func(q servicequota.Quota) bool {
+					return q.Class == servicequota.ClassStandard && q.CapacityType == karpv1.CapacityTypeOnDemand
+				})
+				Expect(ok).To(BeTrue())
+				Expect(quota.Headroom()).To(BeNumerically("==", 4))
+			})
+			It("should launch into a capacity reservation", func() {
+				nodePool.Spec.Template.Spec.Requirements = []karpv1.NodeSelectorRequirementWithMinValues{
+					{NodeSelectorRequirement: corev1.NodeSelectorRequirement{Key: karpv1.CapacityTypeLabelKey, Operator: corev1.NodeSelectorOpIn, Values: []string{v1.CapacityTypeReserved}}},
+				}
+				ExpectApplied(ctx, env.Client, nodePool, nodeClass)
+				pod := coretest.UnschedulablePod()
+				ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+				node := ExpectScheduled(ctx, env.Client, pod)
+				Expect(node.Labels).To(HaveKeyWithValue(karpv1.CapacityTypeLabelKey, v1.CapacityTypeReserved))
+				Expect(node.Labels).To(HaveKeyWithValue(v1.LabelCapacityReservationID, "cr-m5-large-1a"))
+				Expect(node.Labels).To(HaveKeyWithValue(corev1.LabelInstanceTypeStable, "m5.large"))
+
+				Expect(awsEnv.EC2API.CreateFleetBehavior.CalledWithInput.Len()).To(Equal(1))
+				createFleetInput := awsEnv.EC2API.CreateFleetBehavior.CalledWithInput.Pop()
+				Expect(createFleetInput.TargetCapacitySpecification.DefaultTargetCapacityType).To(Equal(ec2types.DefaultTargetCapacityTypeOnDemand))
+				Expect(awsEnv.EC2API.CalledWithCreateLaunchTemplateInput.Len()).To(BeNumerically(">=", 1))
+				awsEnv.EC2API.CalledWithCreateLaunchTemplateInput.ForEach(func(ltInput *ec2.CreateLaunchTemplateInput) {
+					Expect(ltInput.LaunchTemplateData.CapacityReservationSpecification).ToNot(BeNil())
+					Expect(aws.ToString(ltInput.LaunchTemplateData.CapacityReservationSpecification.CapacityReservationTarget.CapacityReservationId)).To(Equal("cr-m5-large-1a"))
+				})
+				Expect(awsEnv.CapacityReservationProvider.GetAvailableInstanceCount("cr-m5-large-1a")).To(BeNumerically("==", 0))
+			})
+			Context("Capacity Blocks", func() {
+				var endTime time.Time
+				BeforeEach(func() {
+					endTime = time.Now().Add(time.Hour)
+					awsEnv.EC2API.DescribeCapacityReservationsOutput.Set(&ec2.DescribeCapacityReservationsOutput{
+						CapacityReservations: []ec2types.CapacityReservation{
+							{
+								CapacityReservationId:  aws.String("cr-m5-large-1a"),
+								InstanceType:           aws.String("m5.large"),
+								AvailabilityZone:       aws.String("test-zone-1a"),
+								OwnerId:                aws.String("012345678901"),
+								InstanceMatchCriteria:  ec2types.InstanceMatchCriteriaTargeted,
+								ReservationType:        ec2types.CapacityReservationTypeCapacityBlock,
+								AvailableInstanceCount: aws.Int32(1),
+								EndDate:                aws.Time(endTime),
+								State:                  ec2types.CapacityReservationStateActive,
+							},
+						},
+					})
+					nodeClass.Status.CapacityReservations[0].ReservationType = v1.CapacityReservationTypeCapacityBlock
+					nodeClass.Status.CapacityReservations[0].EndTime = lo.ToPtr(metav1.NewTime(endTime))
+					awsEnv.CapacityReservationProvider.Reset()
+					awsEnv.CapacityReservationCache.Flush()
+					_, err := awsEnv.CapacityReservationProvider.List(ctx, nodeClass)
+					Expect(err).ToNot(HaveOccurred())
+				})
+				It("should launch into a capacity block with the capacity-block market type", func() {
+					nodePool.Spec.Template.Spec.Requirements = []karpv1.NodeSelectorRequirementWithMinValues{
+						{NodeSelectorRequirement: corev1.NodeSelectorRequirement{Key: karpv1.CapacityTypeLabelKey, Operator: corev1.NodeSelectorOpIn, Values: []string{v1.CapacityTypeReserved}}},
+					}
+					ExpectApplied(ctx, env.Client, nodePool, nodeClass)
+					pod := coretest.UnschedulablePod()
+					ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+					node := ExpectScheduled(ctx, env.Client, pod)
+					Expect(node.Labels).To(HaveKeyWithValue(karpv1.CapacityTypeLabelKey, v1.CapacityTypeReserved))
+					Expect(node.Labels).To(HaveKeyWithValue(v1.LabelCapacityReservationID, "cr-m5-large-1a"))
+
+					Expect(awsEnv.EC2API.CreateFleetBehavior.CalledWithInput.Len()).To(Equal(1))
+					createFleetInput := awsEnv.EC2API.CreateFleetBehavior.CalledWithInput.Pop()
+					Expect(createFleetInput.TargetCapacitySpecification.DefaultTargetCapacityType).To(Equal(ec2types.DefaultTargetCapacityTypeCapacityBlock))
+					awsEnv.EC2API.CalledWithCreateLaunchTemplateInput.ForEach(func(ltInput *ec2.CreateLaunchTemplateInput) {
+						Expect(ltInput.LaunchTemplateData.InstanceMarketOptions).ToNot(BeNil())
+						Expect(ltInput.LaunchTemplateData.InstanceMarketOptions.MarketType).To(Equal(ec2types.MarketTypeCapacityBlock))
+					})
+				})
+				It("should not offer capacity blocks which are expiring", func() {
+					nodeClass.Status.CapacityReservations[0].EndTime = lo.ToPtr(metav1.NewTime(awsEnv.Clock.Now().Add(capacityreservation.CapacityBlockExpirationWindow / 2)))
+					ExpectApplied(ctx, env.Client, nodeClass)
+					its, err := cloudProvider.GetInstanceTypes(ctx, nodePool)
+					Expect(err).ToNot(HaveOccurred())
+					it, ok := lo.Find(its, func(it *corecloudprovider.InstanceType) bool { return it.Name == "m5.large" })
+					Expect(ok).To(BeTrue())
+					reserved := it.Offerings.Compatible(scheduling.NewRequirements(
+						scheduling.NewRequirement(karpv1.CapacityTypeLabelKey, corev1.NodeSelectorOpIn, v1.CapacityTypeReserved),
+					))
+					Expect(reserved).To(HaveLen(1))
+					Expect(reserved[0].Available).To(BeFalse())
+				})
+			})
+		})
+	})
+	Context("Tenancy", func() {
+		It("should label nodes with the default tenancy", func() {
+			ExpectApplied(ctx, env.Client, nodePool, nodeClass)
+			pod := coretest.UnschedulablePod()
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+			node := ExpectScheduled(ctx, env.Client, pod)
+			Expect(node.Labels).To(HaveKeyWithValue(v1.LabelInstanceTenancy, v1.TenancyDefault))
+		})
+		It("should launch on-demand capacity with dedicated tenancy even when flexible to spot", func() {
+			nodeClass.Spec.Tenancy = lo.ToPtr(v1.TenancyDedicated)
+			nodePool.Spec.Template.Spec.Requirements = []karpv1.NodeSelectorRequirementWithMinValues{
+				{NodeSelectorRequirement: corev1.NodeSelectorRequirement{Key: karpv1.CapacityTypeLabelKey, Operator: corev1.NodeSelectorOpIn, Values: []string{karpv1.CapacityTypeSpot, karpv1.CapacityTypeOnDemand}}},
+			}
+			ExpectApplied(ctx, env.Client, nodePool, nodeClass)
+			pod := coretest.UnschedulablePod()
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+			node := ExpectScheduled(ctx, env.Client, pod)
+			Expect(node.Labels).To(HaveKeyWithValue(karpv1.CapacityTypeLabelKey, karpv1.CapacityTypeOnDemand))
+			Expect(node.Labels).To(HaveKeyWithValue(v1.LabelInstanceTenancy, v1.TenancyDedicated))
+		})
+		It("should not schedule pods that select a tenancy other than the EC2NodeClass'", func() {
+			ExpectApplied(ctx, env.Client, nodePool, nodeClass)
+			pod := coretest.UnschedulablePod(coretest.PodOptions{NodeSelector: map[string]string{v1.LabelInstanceTenancy: v1.TenancyDedicated}})
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+			ExpectNotScheduled(ctx, env.Client, pod)
+		})
+		It("should only include mac instance types with host tenancy", func() {
+			out := lo.Must(awsEnv.EC2API.DescribeInstanceTypes(ctx, &ec2.DescribeInstanceTypesInput{}))
+			macInfo := lo.Must(lo.Find(out.InstanceTypes, func(info ec2types.InstanceTypeInfo) bool {
+				return info.InstanceType == "c6g.large"
+			}))
+			macInfo.InstanceType = "mac2.metal"
+			macInfo.ProcessorInfo = &ec2types.ProcessorInfo{SupportedArchitectures: []ec2types.ArchitectureType{ec2types.ArchitectureTypeArm64Mac}}
+			awsEnv.EC2API.DescribeInstanceTypesOutput.Set(&ec2.DescribeInstanceTypesOutput{
+				InstanceTypes: append(append([]ec2types.InstanceTypeInfo{}, out.InstanceTypes...), macInfo),
+			})
+			Expect(awsEnv.InstanceTypesProvider.UpdateInstanceTypes(ctx)).To(Succeed())
+
+			instanceTypes, err := awsEnv.InstanceTypesProvider.List(ctx, nodeClass)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(lo.Map(instanceTypes, func(it *corecloudprovider.InstanceType, _ int) string { return it.Name })).ToNot(ContainElement("mac2.metal"))
+
+			nodeClass.Spec.Tenancy = lo.ToPtr(v1.TenancyHost)
+			instanceTypes, err = awsEnv.InstanceTypesProvider.List(ctx, nodeClass)
+			Expect(err).ToNot(HaveOccurred())
+			mac, ok := lo.Find(instanceTypes, func(it *corecloudprovider.InstanceType) bool { return it.Name == "mac2.metal" })
+			Expect(ok).To(BeTrue())
+			Expect(mac.Requirements.Get(corev1.LabelArchStable).Any()).To(Equal(karpv1.ArchitectureArm64))
+			Expect(mac.Requirements.Get(v1.LabelInstanceTenancy).Any()).To(Equal(v1.TenancyHost))
+		})
+		It("should filter instance types and zones to those supported by the selected hosts", func() {
+			nodeClass.Spec.Tenancy = lo.ToPtr(v1.TenancyHost)
+			nodeClass.Spec.HostSelectorTerms = []v1.HostSelectorTerm{{ID: "h-test1"}}
+			nodeClass.Status.Hosts = []v1.Host{{ID: "h-test1", AvailabilityZone: "test-zone-1a", InstanceFamily: "m5"}}
+			instanceTypes, err := awsEnv.InstanceTypesProvider.List(ctx, nodeClass)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(instanceTypes).ToNot(BeEmpty())
+			for _, it := range instanceTypes {
+				Expect(it.Name).To(HavePrefix("m5."))
+				for _, of := range it.Offerings.Available() {
+					Expect(of.Requirements.Get(corev1.LabelTopologyZone).Any()).To(Equal("test-zone-1a"))
+					Expect(of.Requirements.Get(karpv1.CapacityTypeLabelKey).Any()).ToNot(Equal(karpv1.CapacityTypeSpot))
+				}
+			}
+		})
+	})
+	Context("CPU Options", func() {
+		BeforeEach(func() {
+			out := lo.Must(awsEnv.EC2API.DescribeInstanceTypes(ctx, &ec2.DescribeInstanceTypesInput{}))
+			awsEnv.EC2API.DescribeInstanceTypesOutput.Set(&ec2.DescribeInstanceTypesOutput{
+				InstanceTypes: lo.Map(out.InstanceTypes, func(info ec2types.InstanceTypeInfo, _ int) ec2types.InstanceTypeInfo {
+					switch info.InstanceType {
+					case "m5.xlarge":
+						info.VCpuInfo = &ec2types.VCpuInfo{
+							DefaultCores:          aws.Int32(2),
+							DefaultVCpus:          aws.Int32(4),
+							DefaultThreadsPerCore: aws.Int32(2),
+							ValidCores:            []int32{1, 2},
+							ValidThreadsPerCore:   []int32{1, 2},
+						}
+						processorInfo := *info.ProcessorInfo
+						processorInfo.SupportedFeatures = []ec2types.SupportedAdditionalProcessorFeature{ec2types.SupportedAdditionalProcessorFeatureAmdSevSnp}
+						info.ProcessorInfo = &processorInfo
+					case "g4dn.8xlarge":
+						info.VCpuInfo = &ec2types.VCpuInfo{
+							DefaultCores:          aws.Int32(16),
+							DefaultVCpus:          aws.Int32(32),
+							DefaultThreadsPerCore: aws.Int32(2),
+							ValidCores:            []int32{2, 4, 6, 8, 10, 12, 14, 16},
+							ValidThreadsPerCore:   []int32{1, 2},
+						}
+					}
+					return info
+				}),
+			})
+			Expect(awsEnv.InstanceTypesProvider.UpdateInstanceTypes(ctx)).To(Succeed())
+			Expect(awsEnv.InstanceTypesProvider.UpdateInstanceTypeOfferings(ctx)).To(Succeed())
+		})
+		It("should not change the instance types when no CPU options are configured", func() {
+			instanceTypes, err := awsEnv.InstanceTypesProvider.List(ctx, nodeClass)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(instanceTypes).To(HaveLen(len(lo.Must(awsEnv.EC2API.DescribeInstanceTypes(ctx, &ec2.DescribeInstanceTypesInput{})).InstanceTypes)))
+			m5, ok := lo.Find(instanceTypes, func(it *corecloudprovider.InstanceType) bool { return it.Name == "m5.xlarge" })
+			Expect(ok).To(BeTrue())
+			Expect(m5.Capacity.Cpu().Value()).To(BeNumerically("==", 4))
+			Expect(m5.Requirements.Get(v1.LabelInstanceCPU).Any()).To(Equal("4"))
+			Expect(m5.Requirements.Get(v1.LabelInstanceCPUCores).Any()).To(Equal("2"))
+		})
+		DescribeTable("should reflect the CPU options in the offered instance types",
+			func(cpuOptions *v1.CPUOptions, expected map[string][2]int) {
+				nodeClass.Spec.CPUOptions = cpuOptions
+				instanceTypes, err := awsEnv.InstanceTypesProvider.List(ctx, nodeClass)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(lo.Map(instanceTypes, func(it *corecloudprovider.InstanceType, _ int) string { return it.Name })).To(ConsistOf(lo.Keys(expected)))
+				for _, it := range instanceTypes {
+					Expect(it.Requirements.Get(v1.LabelInstanceCPUCores).Any()).To(Equal(fmt.Sprint(expected[it.Name][0])))
+					Expect(it.Requirements.Get(v1.LabelInstanceCPU).Any()).To(Equal(fmt.Sprint(expected[it.Name][1])))
+					Expect(it.Capacity.Cpu().Value()).To(BeNumerically("==", expected[it.Name][1]))
+				}
+			},
+			Entry("core count", &v1.CPUOptions{CoreCount: aws.Int32(2)}, map[string][2]int{"m5.xlarge": {2, 4}, "g4dn.8xlarge": {2, 4}}),
+			Entry("core ratio", &v1.CPUOptions{CoreRatio: aws.Int32(50)}, map[string][2]int{"m5.xlarge": {1, 2}, "g4dn.8xlarge": {8, 16}}),
+			Entry("core ratio rounded down to a valid core count", &v1.CPUOptions{CoreRatio: aws.Int32(40)}, map[string][2]int{"g4dn.8xlarge": {6, 12}}),
+			Entry("threads per core", &v1.CPUOptions{ThreadsPerCore: aws.Int32(1)}, map[string][2]int{"m5.xlarge": {2, 2}, "g4dn.8xlarge": {16, 16}}),
+			Entry("core count and threads per core", &v1.CPUOptions{CoreCount: aws.Int32(4), ThreadsPerCore: aws.Int32(1)}, map[string][2]int{"g4dn.8xlarge": {4, 4}}),
+			Entry("AMD SEV-SNP", &v1.CPUOptions{AMDSEVSNP: aws.String("enabled")}, map[string][2]int{"m5.xlarge": {2, 4}}),
+		)
+		It("should not offer any instance types when none support the core count", func() {
+			nodeClass.Spec.CPUOptions = &v1.CPUOptions{CoreCount: aws.Int32(3)}
+			instanceTypes, err := awsEnv.InstanceTypesProvider.List(ctx, nodeClass)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(instanceTypes).To(BeEmpty())
+		})
+		It("should offer instance types without AMD SEV-SNP support when it's disabled", func() {
+			nodeClass.Spec.CPUOptions = &v1.CPUOptions{AMDSEVSNP: aws.String("disabled")}
+			instanceTypes, err := awsEnv.InstanceTypesProvider.List(ctx, nodeClass)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(instanceTypes).To(HaveLen(len(lo.Must(awsEnv.EC2API.DescribeInstanceTypes(ctx, &ec2.DescribeInstanceTypesInput{})).InstanceTypes)))
+		})
+		It("should scale pods per core with the reduced number of vCPUs", func() {
+			nodeClass.Spec.AMISelectorTerms = []v1.AMISelectorTerm{{Alias: "al2@latest"}}
+			nodeClass.Spec.Kubelet = &v1.KubeletConfiguration{PodsPerCore: aws.Int32(2)}
+			nodeClass.Spec.CPUOptions = &v1.CPUOptions{ThreadsPerCore: aws.Int32(1)}
+			instanceTypes, err := awsEnv.InstanceTypesProvider.List(ctx, nodeClass)
+			Expect(err).ToNot(HaveOccurred())
+			m5, ok := lo.Find(instanceTypes, func(it *corecloudprovider.InstanceType) bool { return it.Name == "m5.xlarge" })
+			Expect(ok).To(BeTrue())
+			Expect(m5.Capacity.Pods().Value()).To(BeNumerically("==", 4))
+		})
+		It("should count the vCPUs of the CPU options towards the vcpu quota", func() {
+			awsEnv.ServiceQuotasAPI.ListServiceQuotasBehavior.Output.Set(&servicequotas.ListServiceQuotasOutput{
+				Quotas: []servicequotastypes.ServiceQuota{fake.NewServiceQuota("L-34B43A08", 2)},
+			})
+			Expect(awsEnv.ServiceQuotaProvider.UpdateQuotas(ctx)).To(Succeed())
+			spot := scheduling.NewRequirements(scheduling.NewRequirement(karpv1.CapacityTypeLabelKey, corev1.NodeSelectorOpIn, karpv1.CapacityTypeSpot))
+
+			instanceTypes, err := awsEnv.InstanceTypesProvider.List(ctx, nodeClass)
+			Expect(err).ToNot(HaveOccurred())
+			m5, ok := lo.Find(instanceTypes, func(it *corecloudprovider.InstanceType) bool { return it.Name == "m5.xlarge" })
+			Expect(ok).To(BeTrue())
+			Expect(m5.Offerings.Available().HasCompatible(spot)).To(BeFalse())
+
+			nodeClass.Spec.CPUOptions = &v1.CPUOptions{ThreadsPerCore: aws.Int32(1)}
+			instanceTypes, err = awsEnv.InstanceTypesProvider.List(ctx, nodeClass)
+			Expect(err).ToNot(HaveOccurred())
+			m5, ok = lo.Find(instanceTypes, func(it *corecloudprovider.InstanceType) bool { return it.Name == "m5.xlarge" })
+			Expect(ok).To(BeTrue())
+			Expect(m5.Offerings.Available().HasCompatible(spot)).To(BeTrue())
+		})
+		It("should label nodes with the number of CPU cores", func() {
+			nodeClass.Spec.CPUOptions = &v1.CPUOptions{CoreRatio: aws.Int32(50)}
+			ExpectApplied(ctx, env.Client, nodePool, nodeClass)
+			pod := coretest.UnschedulablePod(coretest.PodOptions{NodeSelector: map[string]string{corev1.LabelInstanceTypeStable: "g4dn.8xlarge"}})
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+			node := ExpectScheduled(ctx, env.Client, pod)
+			Expect(node.Labels).To(HaveKeyWithValue(v1.LabelInstanceCPUCores, "8"))
+			Expect(node.Labels).To(HaveKeyWithValue(v1.LabelInstanceCPU, "16"))
+		})
+	})
+	Context("Network Interfaces", func() {
+		BeforeEach(func() {
+			nodeClass.Spec.AMISelectorTerms = []v1.AMISelectorTerm{{Alias: "al2@latest"}}
+		})
+		listInstanceTypes := func() map[string]*corecloudprovider.InstanceType {
+			instanceTypes, err := awsEnv.InstanceTypesProvider.List(ctx, nodeClass)
+			Expect(err).ToNot(HaveOccurred())
+			return lo.SliceToMap(instanceTypes, func(it *corecloudprovider.InstanceType) (string, *corecloudprovider.InstanceType) { return it.Name, it })
+		}
+		It("should only offer instance types with the network cards of the network interfaces", func() {
+			nodeClass.Spec.NetworkInterfaces = []*v1.NetworkInterface{
+				{NetworkCardIndex: 0, DeviceIndex: 0},
+				{NetworkCardIndex: 1, DeviceIndex: 0},
+			}
+			Expect(lo.Keys(listInstanceTypes())).To(ConsistOf("dl1.24xlarge", "m6idn.32xlarge"))
+		})
+		It("should only offer instance types that support the number of network interfaces on each network card", func() {
+			nodeClass.Spec.NetworkInterfaces = lo.Times(4, func(i int) *v1.NetworkInterface {
+				return &v1.NetworkInterface{DeviceIndex: int32(i)}
+			})
+			instanceTypes := listInstanceTypes()
+			Expect(instanceTypes).ToNot(BeEmpty())
+			Expect(lo.Keys(instanceTypes)).ToNot(ContainElements("c6g.large", "m5.large", "t3.large", "t4g.medium", "t4g.small"))
+			Expect(lo.Keys(instanceTypes)).To(ContainElements("m5.xlarge", "g4dn.8xlarge"))
+		})
+		It("should only offer instance types that support the EFA interfaces and expose them as EFA capacity", func() {
+			nodeClass.Spec.NetworkInterfaces = []*v1.NetworkInterface{
+				{NetworkCardIndex: 0, DeviceIndex: 0, InterfaceType: v1.NetworkInterfaceTypeEFA},
+				{NetworkCardIndex: 1, DeviceIndex: 0, InterfaceType: v1.NetworkInterfaceTypeEFAOnly},
+			}
+			instanceTypes := listInstanceTypes()
+			Expect(lo.Keys(instanceTypes)).To(ConsistOf("dl1.24xlarge", "m6idn.32xlarge"))
+			for _, it := range instanceTypes {
+				Expect(it.Capacity).To(HaveKeyWithValue(v1.ResourceEFA, resource.MustParse("2")))
+			}
+		})
+		It("should only make offerings available in zones with a subnet for every network interface", func() {
+			nodeClass.Spec.NetworkInterfaces = []*v1.NetworkInterface{
+				{DeviceIndex: 0},
+				{DeviceIndex: 1, SubnetSelectorTerms: []v1.SubnetSelectorTerm{{ID: "subnet-test2"}}},
+			}
+			nodeClass.Status.NetworkInterfaces = []v1.NetworkInterfaceStatus{
+				{DeviceIndex: 0},
+				{DeviceIndex: 1, Subnets: []v1.Subnet{{ID: "subnet-test2", Zone: "test-zone-1b", ZoneID: "tstz1-1b"}}},
+			}
+			instanceTypes := listInstanceTypes()
+			Expect(instanceTypes).ToNot(BeEmpty())
+			for _, it := range instanceTypes {
+				for _, of := range it.Offerings.Available() {
+					Expect(of.Requirements.Get(corev1.LabelTopologyZone).Any()).To(Equal("test-zone-1b"))
+				}
+			}
+		})
+		It("should include the ENIs of every network card with IP addresses in the ENI-limited pods", func() {
+			nodeClass.Spec.NetworkInterfaces = []*v1.NetworkInterface{
+				{NetworkCardIndex: 0, DeviceIndex: 0},
+				{NetworkCardIndex: 1, DeviceIndex: 0},
+			}
+			// (8 + 8 ENIs) * (50 - 1 addresses per ENI) + 2
+			Expect(listInstanceTypes()["m6idn.32xlarge"].Capacity.Pods().Value()).To(BeNumerically("==", 786))
+
+			nodeClass.Spec.NetworkInterfaces[1].InterfaceType = v1.NetworkInterfaceTypeEFAOnly
+			// 8 ENIs * (50 - 1 addresses per ENI) + 2
+			Expect(listInstanceTypes()["m6idn.32xlarge"].Capacity.Pods().Value()).To(BeNumerically("==", 394))
+		})
+		It("should include the assigned prefixes in the ENI-limited pods", func() {
+			nodeClass.Spec.NetworkInterfaces = []*v1.NetworkInterface{{DeviceIndex: 0}}
+			Expect(listInstanceTypes()["t4g.small"].Capacity.Pods().Value()).To(BeNumerically("==", 11))
+
+			nodeClass.Spec.NetworkInterfaces[0].IPv4PrefixCount = lo.ToPtr[int32](1)
+			instanceTypes := listInstanceTypes()
+			// 3 ENIs * (4 - 1 prefixes per ENI) * 16 + 2, capped at the recommended maximum of 110 pods for instance types
+			// with less than 30 vCPUs
+			Expect(instanceTypes["t4g.small"].Capacity.Pods().Value()).To(BeNumerically("==", 110))
+			// Capped at the recommended maximum of 250 pods for instance types with at least 30 vCPUs
+			Expect(instanceTypes["m5.metal"].Capacity.Pods().Value()).To(BeNumerically("==", 250))
+		})
+	})
+	Context("IP Address Mode", func() {
+		BeforeEach(func() {
+			nodeClass.Spec.AMISelectorTerms = []v1.AMISelectorTerm{{Alias: "al2@latest"}}
+		})
+		listInstanceTypes := func() map[string]*corecloudprovider.InstanceType {
+			instanceTypes, err := awsEnv.InstanceTypesProvider.List(ctx, nodeClass)
+			Expect(err).ToNot(HaveOccurred())
+			return lo.SliceToMap(instanceTypes, func(it *corecloudprovider.InstanceType) (string, *corecloudprovider.InstanceType) { return it.Name, it })
+		}
+		It("should limit pods by the secondary IP addresses of the ENIs by default", func() {
+			instanceTypes := listInstanceTypes()
+			// 3 ENIs * (4 - 1 addresses per ENI) + 2
+			Expect(instanceTypes["t4g.small"].Capacity.Pods().Value()).To(BeNumerically("==", 11))
+			Expect(instanceTypes["m5.metal"].Capacity.Pods().Value()).To(BeNumerically("==", 737))
+		})
+		DescribeTable("should limit pods by the /28 prefixes of the ENIs",
+			func(ipAddressMode string) {
+				nodeClass.Spec.IPAddressMode = lo.ToPtr(ipAddressMode)
+				instanceTypes := listInstanceTypes()
+				// 3 ENIs * (4 - 1 prefixes per ENI) * 16 + 2, capped at the recommended maximum of 110 pods for instance
+				// types with less than 30 vCPUs
+				Expect(instanceTypes["t4g.small"].Capacity.Pods().Value()).To(BeNumerically("==", 110))
+				// Capped at the recommended maximum of 250 pods for instance types with at least 30 vCPUs
+				Expect(instanceTypes["m5.metal"].Capacity.Pods().Value()).To(BeNumerically("==", 250))
+			},
+			Entry("prefix-delegation", v1.IPAddressModePrefixDelegation),
+			Entry("ipv6", v1.IPAddressModeIPv6),
+		)
+		It("should reserve kube-reserved memory for the pods of the IP address mode", func() {
+			secondaryIP := listInstanceTypes()["t4g.small"].Overhead.KubeReserved.Memory().Value()
+			nodeClass.Spec.IPAddressMode = lo.ToPtr(v1.IPAddressModePrefixDelegation)
+			prefixDelegation := listInstanceTypes()["t4g.small"].Overhead.KubeReserved.Memory().Value()
+			// 11Mi per pod
+			Expect(prefixDelegation - secondaryIP).To(BeNumerically("==", (110-11)*11*1024*1024))
+		})
+	})
+	Context("Network Limits", func() {
+		BeforeEach(func() {
+			nodeClass.Spec.AMISelectorTerms = []v1.AMISelectorTerm{{Alias: "al2@latest"}}
+			out := lo.Must(awsEnv.EC2API.DescribeInstanceTypes(ctx, &ec2.DescribeInstanceTypesInput{}))
+			awsEnv.EC2API.DescribeInstanceTypesOutput.Set(&ec2.DescribeInstanceTypesOutput{
+				InstanceTypes: lo.Map(out.InstanceTypes, func(info ec2types.InstanceTypeInfo, _ int) ec2types.InstanceTypeInfo {
+					switch info.InstanceType {
+					case "m5.large":
+						networkInfo := *info.NetworkInfo
+						networkInfo.NetworkCards = lo.Map(networkInfo.NetworkCards, func(card ec2types.NetworkCardInfo, _ int) ec2types.NetworkCardInfo {
+							card.BaselineBandwidthInGbps = aws.Float64(0.8)
+							return card
+						})
+						info.NetworkInfo = &networkInfo
+					case "m5.xlarge":
+						// EC2 doesn't report the ENI limits of the instance type
+						info.NetworkInfo = &ec2types.NetworkInfo{EncryptionInTransitSupported: aws.Bool(true)}
+					}
+					return info
+				}),
+			})
+			Expect(awsEnv.InstanceTypesProvider.UpdateInstanceTypes(ctx)).To(Succeed())
+			Expect(awsEnv.InstanceTypesProvider.UpdateInstanceTypeOfferings(ctx)).To(Succeed())
+		})
+		listInstanceTypes := func() map[string]*corecloudprovider.InstanceType {
+			instanceTypes, err := awsEnv.InstanceTypesProvider.List(ctx, nodeClass)
+			Expect(err).ToNot(HaveOccurred())
+			return lo.SliceToMap(instanceTypes, func(it *corecloudprovider.InstanceType) (string, *corecloudprovider.InstanceType) { return it.Name, it })
+		}
+		It("should derive the network bandwidth label from the baseline bandwidth reported by EC2", func() {
+			instanceTypes := listInstanceTypes()
+			Expect(instanceTypes["m5.large"].Requirements.Get(v1.LabelInstanceNetworkBandwidth).Values()).To(ConsistOf("800"))
+			// Falls back to the generated bandwidth table
+			Expect(instanceTypes["m5.xlarge"].Requirements.Get(v1.LabelInstanceNetworkBandwidth).Values()).To(ConsistOf("1250"))
+		})
+		It("should fall back to the VPC limits for instance types that EC2 doesn't report ENI limits for", func() {
+			instanceTypes := listInstanceTypes()
+			// 4 ENIs * (15 - 1 addresses per ENI) + 2
+			Expect(instanceTypes["m5.xlarge"].Capacity.Pods().Value()).To(BeNumerically("==", 58))
+		})
+		It("should derive vpc.amazonaws.com/PrivateIPv4Address from the IPv4 addresses per ENI reported by EC2", func() {
+			nodeClass.Spec.AMISelectorTerms = []v1.AMISelectorTerm{{Alias: "windows2022@latest"}}
+			instanceTypes := listInstanceTypes()
+			Expect(instanceTypes["m5.large"].Capacity).To(HaveKeyWithValue(v1.ResourcePrivateIPv4Address, resource.MustParse("9")))
+			Expect(instanceTypes["m5.xlarge"].Capacity).To(HaveKeyWithValue(v1.ResourcePrivateIPv4Address, resource.MustParse("14")))
+		})
+		It("should expose whether the network limits reported by EC2 disagree with the generated tables", func() {
+			metric, ok := FindMetricWithLabelValues("karpenter_cloudprovider_instance_type_network_limits_mismatch", map[string]string{
+				"instance_type": "m5.large",
+				"limit":         "network_bandwidth",
+			})
+			Expect(ok).To(BeTrue())
+			Expect(metric.GetGauge().GetValue()).To(BeNumerically("==", 1))
+			metric, ok = FindMetricWithLabelValues("karpenter_cloudprovider_instance_type_network_limits_mismatch", map[string]string{
+				"instance_type": "m5.large",
+				"limit":         "ipv4_addresses_per_interface",
+			})
+			Expect(ok).To(BeTrue())
+			Expect(metric.GetGauge().GetValue()).To(BeNumerically("==", 0))
+		})
 	})
 	Context("Ephemeral Storage", func() {
 		BeforeEach(func() {
@@ -29,6 +29,7 @@ import (
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
 	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/utils/clock"
 	"sigs.k8s.io/controller-runtime/pkg/log"
 	karpv1 "sigs.k8s.io/karpenter/pkg/apis/v1"
 
@@ -36,7 +37,9 @@ import (
 	awscache "github.com/aws/karpenter-provider-aws/pkg/cache"
 	"github.com/aws/karpenter-provider-aws/pkg/operator/options"
 	"github.com/aws/karpenter-provider-aws/pkg/providers/amifamily"
+	"github.com/aws/karpenter-provider-aws/pkg/providers/capacityreservation"
 	"github.com/aws/karpenter-provider-aws/pkg/providers/pricing"
+	"github.com/aws/karpenter-provider-aws/pkg/providers/servicequota"
 
 	"sigs.k8s.io/karpenter/pkg/cloudprovider"
 	"sigs.k8s.io/karpenter/pkg/scheduling"
@@ -48,14 +51,25 @@ const (
 	NodeFSAvailable = "nodefs.available"
 )
 
+const (
+	// reservedCapacityPriceFactor is applied to the on-demand price of an instance type to compute the price of its
+	// reserved offerings. Capacity reservations are paid for whether or not they are used, so we want reserved
+	// offerings to be preferred over all spot and on-demand offerings while still being ordered by instance size.
+	reservedCapacityPriceFactor = 1.0 / 10_000_000.0
+)
+
 var (
 	instanceTypeScheme = regexp.MustCompile(`(^[a-z]+)(\-[0-9]+tb)?([0-9]+).*\.`)
 )
 
 type ZoneData struct {
-	Name      string
-	ID        string
-	Available bool
+	Name string
+	ID   string
+	// Type is the zone type (e.g. availability-zone, local-zone) and ParentZone is the zone that a Local Zone or
+	// Wavelength Zone is anchored to. Both are resolved from the EC2NodeClass' subnets and may be empty.
+	Type       string
+	ParentZone string
+	Available  bool
 }
 
 type Resolver interface {
@@ -66,16 +80,23 @@ type Resolver interface {
 }
 
 type DefaultResolver struct {
-	region               string
-	pricingProvider      pricing.Provider
-	unavailableOfferings *awscache.UnavailableOfferings
+	region                      string
+	pricingProvider             pricing.Provider
+	unavailableOfferings        *awscache.UnavailableOfferings
+	capacityReservationProvider capacityreservation.Provider
+	serviceQuotaProvider        servicequota.Provider
+	clk                         clock.Clock
 }
 
-func NewDefaultResolver(region string, pricingProvider pricing.Provider, unavailableOfferingsCache *awscache.UnavailableOfferings) *DefaultResolver {
+func NewDefaultResolver(region string, pricingProvider pricing.Provider, unavailableOfferingsCache *awscache.UnavailableOfferings,
+	capacityReservationProvider capacityreservation.Provider, serviceQuotaProvider servicequota.Provider, clk clock.Clock) *DefaultResolver {
 	return &DefaultResolver{
-		region:               region,
-		pricingProvider:      pricingProvider,
-		unavailableOfferings: unavailableOfferingsCache,
+		region:                      region,
+		pricingProvider:             pricingProvider,
+		unavailableOfferings:        unavailableOfferingsCache,
+		capacityReservationProvider: capacityReservationProvider,
+		serviceQuotaProvider:        serviceQuotaProvider,
+		clk:                         clk,
 	}
 }
 
@@ -86,12 +107,26 @@ func (d *DefaultResolver) CacheKey(nodeClass *v1.EC2NodeClass) string {
 	}
 	kcHash, _ := hashstructure.Hash(kc, hashstructure.FormatV2, &hashstructure.HashOptions{SlicesAsSets: true})
 	blockDeviceMappingsHash, _ := hashstructure.Hash(nodeClass.Spec.BlockDeviceMappings, hashstructure.FormatV2, &hashstructure.HashOptions{SlicesAsSets: true})
-	return fmt.Sprintf("%016x-%016x-%s-%s-%d",
+	cpuOptionsHash, _ := hashstructure.Hash(nodeClass.Spec.CPUOptions, hashstructure.FormatV2, &hashstructure.HashOptions{SlicesAsSets: true})
+	networkInterfacesHash, _ := hashstructure.Hash(nodeClass.Spec.NetworkInterfaces, hashstructure.FormatV2, &hashstructure.HashOptions{SlicesAsSets: true})
+	// Reserved offerings depend on both the resolved reservations and their remaining instance counts
+	capacityReservationsHash, _ := hashstructure.Hash(lo.SliceToMap(nodeClass.Status.CapacityReservations, func(cr v1.CapacityReservation) (string, int32) {
+		return cr.ID, d.availableInstanceCount(cr)
+	}), hashstructure.FormatV2, &hashstructure.HashOptions{SlicesAsSets: true})
+	return fmt.Sprintf("%016x-%016x-%016x-%016x-%016x-%s-%s-%s-%s-%s-%d-%d-%d",
 		kcHash,
 		blockDeviceMappingsHash,
+		cpuOptionsHash,
+		networkInterfacesHash,
+		capacityReservationsHash,
 		lo.FromPtr((*string)(nodeClass.Spec.InstanceStorePolicy)),
 		nodeClass.AMIFamily(),
+		placementGroupID(nodeClass),
+		nodeClass.Tenancy(),
+		nodeClass.IPAddressMode(),
 		d.unavailableOfferings.SeqNum,
+		d.serviceQuotaProvider.SeqNum(),
+		d.pricingProvider.OverridesSeqNum(),
 	)
 }
 
@@ -104,8 +139,10 @@ func (d *DefaultResolver) Resolve(ctx context.Context, info ec2types.InstanceTyp
 	if nodeClass.Spec.Kubelet != nil {
 		kc = nodeClass.Spec.Kubelet
 	}
-	return NewInstanceType(ctx, info, d.region, nodeClass.Spec.BlockDeviceMappings, nodeClass.Spec.InstanceStorePolicy, kc.MaxPods, kc.PodsPerCore, kc.KubeReserved,
-		kc.SystemReserved, kc.EvictionHard, kc.EvictionSoft, nodeClass.AMIFamily(), d.createOfferings(ctx, info, zoneData))
+	it := NewInstanceType(ctx, info, d.region, nodeClass.Spec.BlockDeviceMappings, nodeClass.Spec.InstanceStorePolicy, nodeClass.Spec.CPUOptions, nodeClass.Spec.NetworkInterfaces, nodeClass.IPAddressMode(), kc.MaxPods, kc.PodsPerCore, kc.KubeReserved,
+		kc.SystemReserved, kc.EvictionHard, kc.EvictionSoft, nodeClass.AMIFamily(), d.createOfferings(ctx, info, zoneData, nodeClass))
+	it.Requirements.Add(scheduling.NewRequirement(v1.LabelInstanceTenancy, corev1.NodeSelectorOpIn, nodeClass.Tenancy()))
+	return it
 }
 
 // createOfferings creates a set of mutually exclusive offerings for a given instance type. This provider maintains an
@@ -117,32 +154,61 @@ func (d *DefaultResolver) Resolve(ctx context.Context, info ec2types.InstanceTyp
 // offering, you can do the following thanks to this invariant:
 //
 //	offering.Requirements.Get(v1.TopologyLabelZone).Any()
-func (d *DefaultResolver) createOfferings(ctx context.Context, instanceType ec2types.InstanceTypeInfo, zoneData []ZoneData) []cloudprovider.Offering {
+//
+// Spot offerings are only created for instance types launched with the "default" tenancy, since EC2 doesn't support
+// launching spot instances onto dedicated hardware. Outposts and Wavelength Zones don't support spot either.
+//
+// When the zone's type is known, the zone type and parent zone are injected into the offering requirements. Like zoneID,
+// these are a function of the zone and don't change the number of offerings. On-demand offerings are priced using the
+// zone's own on-demand price when it's priced separately from the region, as Local Zones and Wavelength Zones are.
+// Offerings are priced for the operating system of the EC2NodeClass's AMI family, since licensed operating systems
+// such as Windows are more expensive than Linux. Spot offerings are priced at the spot price aggregated from the zone's
+// spot price history, according to the configured spot price aggregation.
+//
+// In addition to the spot and on-demand offerings, a "reserved" offering is created for each capacity reservation that
+// matches the instance type. Reserved offerings are distinguished by their capacity reservation ID, which is the only
+// requirement that is allowed to be undefined (DoesNotExist) on spot and on-demand offerings.
+//
+// Offerings which have recently seen an insufficient capacity error within the EC2NodeClass's placement group are also
+// unavailable, since instances for the EC2NodeClass are launched into the placement group. Spot and on-demand offerings
+// are unavailable when launching the instance type would exceed the account's vCPU quota for its instance class. The
+// vCPUs of the instance type are counted as it's launched with the EC2NodeClass's CPU options, as they are for launches.
+func (d *DefaultResolver) createOfferings(ctx context.Context, instanceType ec2types.InstanceTypeInfo, zoneData []ZoneData, nodeClass *v1.EC2NodeClass) []cloudprovider.Offering {
 	var offerings []cloudprovider.Offering
+	pgID := placementGroupID(nodeClass)
+	osName := pricing.OperatingSystemForAMIFamily(nodeClass.AMIFamily())
 	for _, zone := range zoneData {
 		// while usage classes should be a distinct set, there's no guarantee of that
 		for capacityType := range sets.New((instanceType.SupportedUsageClasses)...) {
 			// exclude any offerings that have recently seen an insufficient capacity error from EC2
-			isUnavailable := d.unavailableOfferings.IsUnavailable(instanceType.InstanceType, zone.Name, string(capacityType))
-			var price float64
+			isUnavailable := d.isUnavailable(instanceType.InstanceType, zone.Name, string(capacityType), pgID)
+			var price, rawPrice float64
 			var ok bool
 			switch capacityType {
 			case ec2types.UsageClassTypeSpot:
-				price, ok = d.pricingProvider.SpotPrice(instanceType.InstanceType, zone.Name)
+				if nodeClass.Tenancy() != v1.TenancyDefault || !supportsSpot(zone) {
+					continue
+				}
+				price, ok = d.pricingProvider.SpotPrice(osName, instanceType.InstanceType, zone.Name)
+				rawPrice, _ = d.pricingProvider.RawSpotPrice(osName, instanceType.InstanceType, zone.Name)
 			case ec2types.UsageClassTypeOnDemand:
-				price, ok = d.pricingProvider.OnDemandPrice(instanceType.InstanceType)
+				price, ok = d.pricingProvider.ZonalOnDemandPrice(osName, instanceType.InstanceType, zone.Name)
+				rawPrice, _ = d.pricingProvider.RawZonalOnDemandPrice(osName, instanceType.InstanceType, zone.Name)
 			case "capacity-block":
-				// ignore since karpenter doesn't support it yet, but do not log an unknown capacity type error
+				// capacity blocks can only be launched into through a reservation, so they are offered through the
+				// reserved offerings of the capacity blocks that are selected by the EC2NodeClass
 				continue
 			default:
 				log.FromContext(ctx).WithValues("capacity-type", capacityType, "instance-type", instanceType.InstanceType).Error(fmt.Errorf("received unknown capacity type"), "failed parsing offering")
 				continue
 			}
-			available := !isUnavailable && ok && zone.Available
+			fitsQuota := d.serviceQuotaProvider.Fits(string(instanceType.InstanceType), string(capacityType), int64(vCPUs(instanceType, nodeClass.Spec.CPUOptions)))
+			available := !isUnavailable && fitsQuota && ok && zone.Available
 			offering := cloudprovider.Offering{
 				Requirements: scheduling.NewRequirements(
 					scheduling.NewRequirement(karpv1.CapacityTypeLabelKey, corev1.NodeSelectorOpIn, string(capacityType)),
 					scheduling.NewRequirement(corev1.LabelTopologyZone, corev1.NodeSelectorOpIn, zone.Name),
+					scheduling.NewRequirement(v1.LabelCapacityReservationID, corev1.NodeSelectorOpDoesNotExist),
 				),
 				Price:     price,
 				Available: available,
@@ -150,37 +216,139 @@ func (d *DefaultResolver) createOfferings(ctx context.Context, instanceType ec2t
 			if zone.ID != "" {
 				offering.Requirements.Add(scheduling.NewRequirement(v1.LabelTopologyZoneID, corev1.NodeSelectorOpIn, zone.ID))
 			}
+			offering.Requirements.Add(zoneTypeRequirements(zone)...)
 			offerings = append(offerings, offering)
+			setPriceEstimates(string(instanceType.InstanceType), string(capacityType), zone.Name, rawPrice, price)
+		}
+	}
+	return append(offerings, d.createReservedOfferings(instanceType, zoneData, nodeClass.Status.CapacityReservations, pgID, osName)...)
+}
+
+// createReservedOfferings creates an offering for each capacity reservation that targets the instance type. Reserved
+// offerings are only available while the reservation has remaining instance capacity and its zone is available.
+// Offerings for capacity blocks are additionally time-bounded and become unavailable once the block is expiring.
+func (d *DefaultResolver) createReservedOfferings(instanceType ec2types.InstanceTypeInfo, zoneData []ZoneData, capacityReservations []v1.CapacityReservation, placementGroupID string, osName pricing.OperatingSystem) []cloudprovider.Offering {
+	var offerings []cloudprovider.Offering
+	for _, cr := range capacityReservations {
+		if cr.InstanceType != string(instanceType.InstanceType) {
+			continue
+		}
+		zone, ok := lo.Find(zoneData, func(z ZoneData) bool { return z.Name == cr.AvailabilityZone })
+		if !ok {
+			continue
+		}
+		odPrice, ok := d.pricingProvider.ZonalOnDemandPrice(osName, instanceType.InstanceType, zone.Name)
+		rawODPrice, _ := d.pricingProvider.RawZonalOnDemandPrice(osName, instanceType.InstanceType, zone.Name)
+		isUnavailable := d.isUnavailable(instanceType.InstanceType, zone.Name, v1.CapacityTypeReserved, placementGroupID)
+		offering := cloudprovider.Offering{
+			Requirements: scheduling.NewRequirements(
+				scheduling.NewRequirement(karpv1.CapacityTypeLabelKey, corev1.NodeSelectorOpIn, v1.CapacityTypeReserved),
+				scheduling.NewRequirement(corev1.LabelTopologyZone, corev1.NodeSelectorOpIn, zone.Name),
+				scheduling.NewRequirement(v1.LabelCapacityReservationID, corev1.NodeSelectorOpIn, cr.ID),
+			),
+			Price:     odPrice * reservedCapacityPriceFactor,
+			Available: !isUnavailable && ok && zone.Available && d.availableInstanceCount(cr) > 0,
 		}
+		if zone.ID != "" {
+			offering.Requirements.Add(scheduling.NewRequirement(v1.LabelTopologyZoneID, corev1.NodeSelectorOpIn, zone.ID))
+		}
+		offering.Requirements.Add(zoneTypeRequirements(zone)...)
+		offerings = append(offerings, offering)
+		setPriceEstimates(string(instanceType.InstanceType), v1.CapacityTypeReserved, zone.Name, rawODPrice*reservedCapacityPriceFactor, offering.Price)
 	}
 	return offerings
 }
 
+// setPriceEstimates publishes both the public price of an offering and the effective price that offerings are ordered by
+func setPriceEstimates(instanceType, capacityType, zone string, rawPrice, effectivePrice float64) {
+	for priceType, price := range map[string]float64{rawPriceType: rawPrice, effectivePriceType: effectivePrice} {
+		InstanceTypeOfferingPriceEstimate.Set(price, map[string]string{
+			instanceTypeLabel: instanceType,
+			capacityTypeLabel: capacityType,
+			zoneLabel:         zone,
+			priceTypeLabel:    priceType,
+		})
+	}
+}
+
+// zoneTypeRequirements returns the zone type and parent zone requirements for an offering in the zone. Zones without a
+// parent zone have their parent zone constrained to DoesNotExist so that pods selecting a parent zone aren't scheduled
+// to regular availability zones. No requirements are returned if the zone type hasn't been resolved.
+func zoneTypeRequirements(zone ZoneData) []*scheduling.Requirement {
+	if zone.Type == "" {
+		return nil
+	}
+	if zone.ParentZone == "" {
+		return []*scheduling.Requirement{
+			scheduling.NewRequirement(v1.LabelTopologyZoneType, corev1.NodeSelectorOpIn, zone.Type),
+			scheduling.NewRequirement(v1.LabelTopologyParentZone, corev1.NodeSelectorOpDoesNotExist),
+		}
+	}
+	return []*scheduling.Requirement{
+		scheduling.NewRequirement(v1.LabelTopologyZoneType, corev1.NodeSelectorOpIn, zone.Type),
+		scheduling.NewRequirement(v1.LabelTopologyParentZone, corev1.NodeSelectorOpIn, zone.ParentZone),
+	}
+}
+
+// offeringValue returns the value of an offering's requirement, or an empty string if the offering doesn't define it or
+// requires that it doesn't exist
+func offeringValue(o cloudprovider.Offering, key string) string {
+	if !o.Requirements.Has(key) {
+		return ""
+	}
+	return o.Requirements.Get(key).Any()
+}
+
+// supportsSpot returns false for zones that don't offer spot capacity
+func supportsSpot(zone ZoneData) bool {
+	return zone.Type != v1.ZoneTypeOutpost && zone.Type != v1.ZoneTypeWavelengthZone
+}
+
+// isUnavailable returns true if the offering has recently seen an insufficient capacity error, either globally or within
+// the placement group that instances are launched into
+func (d *DefaultResolver) isUnavailable(instanceType ec2types.InstanceType, zone, capacityType, placementGroupID string) bool {
+	if d.unavailableOfferings.IsUnavailable(instanceType, zone, capacityType) {
+		return true
+	}
+	return placementGroupID != "" && d.unavailableOfferings.IsPlacementGroupUnavailable(placementGroupID, instanceType, zone, capacityType)
+}
+
+// availableInstanceCount returns the number of instances that can still be launched into the capacity reservation.
+// Capacity blocks that are within their expiration window can't be launched into, regardless of remaining capacity.
+func (d *DefaultResolver) availableInstanceCount(cr v1.CapacityReservation) int32 {
+	if capacityreservation.IsExpiring(cr, d.clk.Now()) {
+		return 0
+	}
+	return d.capacityReservationProvider.GetAvailableInstanceCount(cr.ID)
+}
+
 func NewInstanceType(ctx context.Context, info ec2types.InstanceTypeInfo, region string,
-	blockDeviceMappings []*v1.BlockDeviceMapping, instanceStorePolicy *v1.InstanceStorePolicy, maxPods *int32, podsPerCore *int32,
-	kubeReserved map[string]string, systemReserved map[string]string, evictionHard map[string]string, evictionSoft map[string]string,
+	blockDeviceMappings []*v1.BlockDeviceMapping, instanceStorePolicy *v1.InstanceStorePolicy, cpuOptions *v1.CPUOptions, networkInterfaces []*v1.NetworkInterface,
+	ipAddressMode string, maxPods *int32, podsPerCore *int32, kubeReserved map[string]string, systemReserved map[string]string, evictionHard map[string]string, evictionSoft map[string]string,
 	amiFamilyType string, offerings cloudprovider.Offerings) *cloudprovider.InstanceType {
 
 	amiFamily := amifamily.GetAMIFamily(amiFamilyType, &amifamily.Options{})
 	it := &cloudprovider.InstanceType{
 		Name:         string(info.InstanceType),
-		Requirements: computeRequirements(info, offerings, region, amiFamily),
+		Requirements: computeRequirements(info, offerings, region, amiFamily, cpuOptions),
 		Offerings:    offerings,
-		Capacity:     computeCapacity(ctx, info, amiFamily, blockDeviceMappings, instanceStorePolicy, maxPods, podsPerCore),
+		Capacity:     computeCapacity(ctx, info, amiFamily, blockDeviceMappings, instanceStorePolicy, cpuOptions, networkInterfaces, ipAddressMode, maxPods, podsPerCore),
 		Overhead: &cloudprovider.InstanceTypeOverhead{
-			KubeReserved:      kubeReservedResources(cpu(info), pods(ctx, info, amiFamily, maxPods, podsPerCore), ENILimitedPods(ctx, info), amiFamily, kubeReserved),
+			KubeReserved: kubeReservedResources(cpu(info, cpuOptions), pods(ctx, info, amiFamily, cpuOptions, networkInterfaces, ipAddressMode, maxPods, podsPerCore),
+				ENILimitedPods(ctx, info, networkInterfaces, ipAddressMode), amiFamily, kubeReserved),
 			SystemReserved:    systemReservedResources(systemReserved),
 			EvictionThreshold: evictionThreshold(memory(ctx, info), ephemeralStorage(info, amiFamily, blockDeviceMappings, instanceStorePolicy), amiFamily, evictionHard, evictionSoft),
 		},
 	}
 	if it.Requirements.Compatible(scheduling.NewRequirements(scheduling.NewRequirement(corev1.LabelOSStable, corev1.NodeSelectorOpIn, string(corev1.Windows)))) == nil {
-		it.Capacity[v1.ResourcePrivateIPv4Address] = *privateIPv4Address(string(info.InstanceType))
+		it.Capacity[v1.ResourcePrivateIPv4Address] = *privateIPv4Address(info)
 	}
 	return it
 }
 
 //nolint:gocyclo
-func computeRequirements(info ec2types.InstanceTypeInfo, offerings cloudprovider.Offerings, region string, amiFamily amifamily.AMIFamily) scheduling.Requirements {
+func computeRequirements(info ec2types.InstanceTypeInfo, offerings cloudprovider.Offerings, region string, amiFamily amifamily.AMIFamily, cpuOptions *v1.CPUOptions) scheduling.Requirements {
+	cores, _, _ := resolveCPUOptions(info, cpuOptions)
 	requirements := scheduling.NewRequirements(
 		// Well Known Upstream
 		scheduling.NewRequirement(corev1.LabelInstanceTypeStable, corev1.NodeSelectorOpIn, string(info.InstanceType)),
@@ -196,7 +364,8 @@ func computeRequirements(info ec2types.InstanceTypeInfo, offerings cloudprovider
 			return o.Requirements.Get(karpv1.CapacityTypeLabelKey).Any()
 		})...),
 		// Well Known to AWS
-		scheduling.NewRequirement(v1.LabelInstanceCPU, corev1.NodeSelectorOpIn, fmt.Sprint(lo.FromPtr(info.VCpuInfo.DefaultVCpus))),
+		scheduling.NewRequirement(v1.LabelInstanceCPU, corev1.NodeSelectorOpIn, fmt.Sprint(vCPUs(info, cpuOptions))),
+		scheduling.NewRequirement(v1.LabelInstanceCPUCores, corev1.NodeSelectorOpDoesNotExist),
 		scheduling.NewRequirement(v1.LabelInstanceCPUManufacturer, corev1.NodeSelectorOpDoesNotExist),
 		scheduling.NewRequirement(v1.LabelInstanceCPUSustainedClockSpeedMhz, corev1.NodeSelectorOpDoesNotExist),
 		scheduling.NewRequirement(v1.LabelInstanceMemory, corev1.NodeSelectorOpIn, fmt.Sprint(lo.FromPtr(info.MemoryInfo.SizeInMiB))),
@@ -225,6 +394,23 @@ func computeRequirements(info ec2types.InstanceTypeInfo, offerings cloudprovider
 	}); len(zoneIDs) != 0 {
 		requirements.Add(scheduling.NewRequirement(v1.LabelTopologyZoneID, corev1.NodeSelectorOpIn, zoneIDs...))
 	}
+	// Zone type and parent zone are only available once the nodeclass subnet status has been updated with them. The
+	// parent zone is only constrained when every available offering agrees on whether it has a parent zone.
+	if zoneTypes := lo.FilterMap(offerings.Available(), func(o cloudprovider.Offering, _ int) (string, bool) {
+		zoneType := offeringValue(o, v1.LabelTopologyZoneType)
+		return zoneType, zoneType != ""
+	}); len(zoneTypes) != 0 {
+		requirements.Add(scheduling.NewRequirement(v1.LabelTopologyZoneType, corev1.NodeSelectorOpIn, zoneTypes...))
+		parentZones := lo.FilterMap(offerings.Available(), func(o cloudprovider.Offering, _ int) (string, bool) {
+			parentZone := offeringValue(o, v1.LabelTopologyParentZone)
+			return parentZone, parentZone != ""
+		})
+		if len(parentZones) == len(offerings.Available()) {
+			requirements.Add(scheduling.NewRequirement(v1.LabelTopologyParentZone, corev1.NodeSelectorOpIn, parentZones...))
+		} else if len(parentZones) == 0 && len(zoneTypes) == len(offerings.Available()) {
+			requirements.Add(scheduling.NewRequirement(v1.LabelTopologyParentZone, corev1.NodeSelectorOpDoesNotExist))
+		}
+	}
 	// Instance Type Labels
 	instanceFamilyParts := instanceTypeScheme.FindStringSubmatch(string(info.InstanceType))
 	if len(instanceFamilyParts) == 4 {
@@ -240,7 +426,7 @@ func computeRequirements(info ec2types.InstanceTypeInfo, offerings cloudprovider
 		requirements[v1.LabelInstanceLocalNVME].Insert(fmt.Sprint(lo.FromPtr(info.InstanceStorageInfo.TotalSizeInGB)))
 	}
 	// Network bandwidth
-	if bandwidth, ok := InstanceTypeBandwidthMegabits[string(info.InstanceType)]; ok {
+	if bandwidth, ok := networkBandwidth(info); ok {
 		requirements[v1.LabelInstanceNetworkBandwidth].Insert(fmt.Sprint(bandwidth))
 	}
 	// GPU Labels
@@ -269,6 +455,10 @@ func computeRequirements(info ec2types.InstanceTypeInfo, offerings cloudprovider
 	if family, ok := amiFamily.(*amifamily.Windows); ok {
 		requirements.Get(corev1.LabelWindowsBuild).Insert(family.Build)
 	}
+	// CPU Cores
+	if cores != 0 {
+		requirements.Get(v1.LabelInstanceCPUCores).Insert(fmt.Sprint(cores))
+	}
 	// CPU Manufacturer, valid options: aws, intel, amd
 	if info.ProcessorInfo != nil {
 		requirements.Get(v1.LabelInstanceCPUManufacturer).Insert(lowerKabobCase(aws.ToString(info.ProcessorInfo.Manufacturer)))
@@ -305,27 +495,88 @@ func getArchitecture(info ec2types.InstanceTypeInfo) string {
 }
 
 func computeCapacity(ctx context.Context, info ec2types.InstanceTypeInfo, amiFamily amifamily.AMIFamily,
-	blockDeviceMapping []*v1.BlockDeviceMapping, instanceStorePolicy *v1.InstanceStorePolicy,
-	maxPods *int32, podsPerCore *int32) corev1.ResourceList {
+	blockDeviceMapping []*v1.BlockDeviceMapping, instanceStorePolicy *v1.InstanceStorePolicy, cpuOptions *v1.CPUOptions,
+	networkInterfaces []*v1.NetworkInterface, ipAddressMode string, maxPods *int32, podsPerCore *int32) corev1.ResourceList {
 
 	resourceList := corev1.ResourceList{
-		corev1.ResourceCPU:              *cpu(info),
+		corev1.ResourceCPU:              *cpu(info, cpuOptions),
 		corev1.ResourceMemory:           *memory(ctx, info),
 		corev1.ResourceEphemeralStorage: *ephemeralStorage(info, amiFamily, blockDeviceMapping, instanceStorePolicy),
-		corev1.ResourcePods:             *pods(ctx, info, amiFamily, maxPods, podsPerCore),
-		v1.ResourceAWSPodENI:            *awsPodENI(string(info.InstanceType)),
+		corev1.ResourcePods:             *pods(ctx, info, amiFamily, cpuOptions, networkInterfaces, ipAddressMode, maxPods, podsPerCore),
+		v1.ResourceAWSPodENI:            *awsPodENI(info),
 		v1.ResourceNVIDIAGPU:            *nvidiaGPUs(info),
 		v1.ResourceAMDGPU:               *amdGPUs(info),
 		v1.ResourceAWSNeuron:            *awsNeuronDevices(info),
 		v1.ResourceAWSNeuronCore:        *awsNeuronCores(info),
 		v1.ResourceHabanaGaudi:          *habanaGaudis(info),
-		v1.ResourceEFA:                  *efas(info),
+		v1.ResourceEFA:                  *efas(info, networkInterfaces),
 	}
 	return resourceList
 }
 
-func cpu(info ec2types.InstanceTypeInfo) *resource.Quantity {
-	return resources.Quantity(fmt.Sprint(*info.VCpuInfo.DefaultVCpus))
+func cpu(info ec2types.InstanceTypeInfo, cpuOptions *v1.CPUOptions) *resource.Quantity {
+	return resources.Quantity(fmt.Sprint(vCPUs(info, cpuOptions)))
+}
+
+// vCPUs returns the number of vCPUs of an instance type when it's launched with the CPU options
+func vCPUs(info ec2types.InstanceTypeInfo, cpuOptions *v1.CPUOptions) int32 {
+	if cpuOptions == nil || (cpuOptions.CoreCount == nil && cpuOptions.CoreRatio == nil && cpuOptions.ThreadsPerCore == nil) {
+		return lo.FromPtr(info.VCpuInfo.DefaultVCpus)
+	}
+	cores, threadsPerCore, _ := resolveCPUOptions(info, cpuOptions)
+	return cores * threadsPerCore
+}
+
+// resolveCPUOptions returns the number of cores and threads per core that an instance type is launched with given the
+// CPU options of the EC2NodeClass. False is returned when the instance type can't be launched with the CPU options, in
+// which case the instance type's defaults are returned.
+//
+//nolint:gocyclo
+func resolveCPUOptions(info ec2types.InstanceTypeInfo, cpuOptions *v1.CPUOptions) (int32, int32, bool) {
+	vcpus := lo.FromPtr(info.VCpuInfo.DefaultVCpus)
+	cores := lo.FromPtr(info.VCpuInfo.DefaultCores)
+	threadsPerCore := lo.FromPtr(info.VCpuInfo.DefaultThreadsPerCore)
+	if threadsPerCore == 0 && cores != 0 {
+		threadsPerCore = vcpus / cores
+	}
+	if cpuOptions == nil {
+		return cores, threadsPerCore, true
+	}
+	if lo.FromPtr(cpuOptions.AMDSEVSNP) == string(ec2types.AmdSevSnpSpecificationEnabled) && (info.ProcessorInfo == nil ||
+		!lo.Contains(info.ProcessorInfo.SupportedFeatures, ec2types.SupportedAdditionalProcessorFeatureAmdSevSnp)) {
+		return cores, threadsPerCore, false
+	}
+	if cpuOptions.CoreCount == nil && cpuOptions.CoreRatio == nil && cpuOptions.ThreadsPerCore == nil {
+		return cores, threadsPerCore, true
+	}
+	// Instance types which don't report the core counts they support can't be launched with CPU options
+	if len(info.VCpuInfo.ValidCores) == 0 {
+		return cores, threadsPerCore, false
+	}
+	resolvedCores := cores
+	switch {
+	case cpuOptions.CoreCount != nil:
+		if !lo.Contains(info.VCpuInfo.ValidCores, lo.FromPtr(cpuOptions.CoreCount)) {
+			return cores, threadsPerCore, false
+		}
+		resolvedCores = lo.FromPtr(cpuOptions.CoreCount)
+	case cpuOptions.CoreRatio != nil:
+		validCores := lo.Filter(info.VCpuInfo.ValidCores, func(c int32, _ int) bool {
+			return c <= cores*lo.FromPtr(cpuOptions.CoreRatio)/100
+		})
+		if len(validCores) == 0 {
+			return cores, threadsPerCore, false
+		}
+		resolvedCores = lo.Max(validCores)
+	}
+	resolvedThreadsPerCore := threadsPerCore
+	if cpuOptions.ThreadsPerCore != nil {
+		if !lo.Contains(info.VCpuInfo.ValidThreadsPerCore, lo.FromPtr(cpuOptions.ThreadsPerCore)) {
+			return cores, threadsPerCore, false
+		}
+		resolvedThreadsPerCore = lo.FromPtr(cpuOptions.ThreadsPerCore)
+	}
+	return resolvedCores, resolvedThreadsPerCore, true
 }
 
 func memory(ctx context.Context, info ec2types.InstanceTypeInfo) *resource.Quantity {
@@ -379,9 +630,14 @@ func ephemeralStorage(info ec2types.InstanceTypeInfo, amiFamily amifamily.AMIFam
 }
 
 // awsPodENI relies on the VPC resource controller to populate the vpc.amazonaws.com/pod-eni resource
-func awsPodENI(instanceTypeName string) *resource.Quantity {
+func awsPodENI(info ec2types.InstanceTypeInfo) *resource.Quantity {
 	// https://docs.aws.amazon.com/eks/latest/userguide/security-groups-for-pods.html#supported-instance-types
-	limits, ok := Limits[instanceTypeName]
+	// Trunking is only supported on Nitro instance types. EC2 doesn't report the number of branch interfaces, so they
+	// come from the VPC limits.
+	if info.Hypervisor != "" && info.Hypervisor != ec2types.InstanceTypeHypervisorNitro {
+		return resources.Quantity("0")
+	}
+	limits, ok := Limits[string(info.InstanceType)]
 	if ok && limits.IsTrunkingCompatible {
 		return resources.Quantity(fmt.Sprint(limits.BranchInterface))
 	}
@@ -444,37 +700,153 @@ func habanaGaudis(info ec2types.InstanceTypeInfo) *resource.Quantity {
 	return resources.Quantity(fmt.Sprint(count))
 }
 
-func efas(info ec2types.InstanceTypeInfo) *resource.Quantity {
+func efas(info ec2types.InstanceTypeInfo, networkInterfaces []*v1.NetworkInterface) *resource.Quantity {
 	count := int32(0)
-	if info.NetworkInfo != nil && info.NetworkInfo.EfaInfo != nil && info.NetworkInfo.EfaInfo.MaximumEfaInterfaces != nil {
+	if len(networkInterfaces) != 0 {
+		// Instances are only launched with the EFA interfaces that the EC2NodeClass configures
+		//nolint:gosec
+		count = int32(lo.CountBy(networkInterfaces, func(ni *v1.NetworkInterface) bool {
+			return ni.InterfaceType == v1.NetworkInterfaceTypeEFA || ni.InterfaceType == v1.NetworkInterfaceTypeEFAOnly
+		}))
+	} else if info.NetworkInfo != nil && info.NetworkInfo.EfaInfo != nil && info.NetworkInfo.EfaInfo.MaximumEfaInterfaces != nil {
 		count = *info.NetworkInfo.EfaInfo.MaximumEfaInterfaces
 	}
 	return resources.Quantity(fmt.Sprint(count))
 }
 
-func ENILimitedPods(ctx context.Context, info ec2types.InstanceTypeInfo) *resource.Quantity {
+func ENILimitedPods(ctx context.Context, info ec2types.InstanceTypeInfo, networkInterfaces []*v1.NetworkInterface, ipAddressMode string) *resource.Quantity {
 	// The number of pods per node is calculated using the formula:
 	// max number of ENIs * (IPv4 Addresses per ENI -1) + 2
 	// https://github.com/awslabs/amazon-eks-ami/blob/main/templates/shared/runtime/eni-max-pods.txt
 
+	info.NetworkInfo = networkInfo(info)
+	if info.NetworkInfo == nil {
+		return resource.NewQuantity(0, resource.DecimalSI)
+	}
 	// VPC CNI only uses the default network interface
 	// https://github.com/aws/amazon-vpc-cni-k8s/blob/3294231c0dce52cfe473bf6c62f47956a3b333b6/scripts/gen_vpc_ip_limits.go#L162
-	networkInterfaces := *info.NetworkInfo.NetworkCards[*info.NetworkInfo.DefaultNetworkCardIndex].MaximumNetworkInterfaces
-	usableNetworkInterfaces := lo.Max([]int64{int64(int(networkInterfaces) - options.FromContext(ctx).ReservedENIs), 0})
+	// unless the EC2NodeClass attaches network interfaces with IP addresses to other network cards, in which case the
+	// ENIs of those network cards are also available to pods.
+	defaultNetworkCardIndex := lo.FromPtr(info.NetworkInfo.DefaultNetworkCardIndex)
+	networkCards := sets.New(defaultNetworkCardIndex)
+	for _, ni := range networkInterfaces {
+		if ni.InterfaceType != v1.NetworkInterfaceTypeEFAOnly {
+			networkCards.Insert(ni.NetworkCardIndex)
+		}
+	}
+	networkInterfaceCount := int64(0)
+	for _, index := range networkCards.UnsortedList() {
+		networkInterfaceCount += int64(maxNetworkInterfaces(info, index))
+	}
+	usableNetworkInterfaces := lo.Max([]int64{networkInterfaceCount - int64(options.FromContext(ctx).ReservedENIs), 0})
 	if usableNetworkInterfaces == 0 {
 		return resource.NewQuantity(0, resource.DecimalSI)
 	}
-	addressesPerInterface := *info.NetworkInfo.Ipv4AddressesPerInterface
-	return resources.Quantity(fmt.Sprint(usableNetworkInterfaces*(int64(addressesPerInterface)-1) + 2))
+	addressesPerInterface := int64(*info.NetworkInfo.Ipv4AddressesPerInterface) - 1
+	// Each address of an ENI is a /28 prefix of 16 addresses with prefix delegation or IPv6, or when prefixes are assigned
+	// to the network interfaces, in which case the number of pods is capped at the recommended maximum of the EKS max
+	// pods calculator
+	// https://github.com/awslabs/amazon-eks-ami/blob/main/templates/al2/runtime/max-pods-calculator.sh
+	if ipAddressMode == v1.IPAddressModePrefixDelegation || ipAddressMode == v1.IPAddressModeIPv6 ||
+		lo.ContainsBy(networkInterfaces, func(ni *v1.NetworkInterface) bool { return ni.IPv4PrefixCount != nil }) {
+		return resources.Quantity(fmt.Sprint(lo.Min([]int64{
+			usableNetworkInterfaces*addressesPerInterface*16 + 2,
+			lo.Ternary[int64](lo.FromPtr(info.VCpuInfo.DefaultVCpus) < 30, 110, 250),
+		})))
+	}
+	return resources.Quantity(fmt.Sprint(usableNetworkInterfaces*addressesPerInterface + 2))
 }
 
-func privateIPv4Address(instanceTypeName string) *resource.Quantity {
-	//https://github.com/aws/amazon-vpc-resource-controller-k8s/blob/ecbd6965a0100d9a070110233762593b16023287/pkg/provider/ip/provider.go#L297
-	limits, ok := Limits[instanceTypeName]
+// maxNetworkInterfaces returns the maximum number of network interfaces that can be attached to a network card of the
+// instance type, or zero if the instance type doesn't have the network card.
+func maxNetworkInterfaces(info ec2types.InstanceTypeInfo, networkCardIndex int32) int32 {
+	networkInfo := networkInfo(info)
+	if networkInfo == nil {
+		return 0
+	}
+	card, ok := lo.Find(networkInfo.NetworkCards, func(c ec2types.NetworkCardInfo) bool {
+		return lo.FromPtr(c.NetworkCardIndex) == networkCardIndex
+	})
 	if !ok {
+		return 0
+	}
+	return lo.FromPtr(card.MaximumNetworkInterfaces)
+}
+
+func privateIPv4Address(info ec2types.InstanceTypeInfo) *resource.Quantity {
+	//https://github.com/aws/amazon-vpc-resource-controller-k8s/blob/ecbd6965a0100d9a070110233762593b16023287/pkg/provider/ip/provider.go#L297
+	// The VPC resource controller only advertises the resource for instance types in its limits table, but the number of
+	// addresses is taken from EC2 when it's reported since the table may be out of date
+	if _, ok := Limits[string(info.InstanceType)]; !ok {
 		return resources.Quantity("0")
 	}
-	return resources.Quantity(fmt.Sprint(limits.IPv4PerInterface - 1))
+	networkInfo := networkInfo(info)
+	return resources.Quantity(fmt.Sprint(lo.FromPtr(networkInfo.Ipv4AddressesPerInterface) - 1))
+}
+
+// networkInfo returns the network info that EC2 reports for the instance type. Instance types that EC2 doesn't report
+// ENI limits for fall back to the generated VPC limits, and nil is returned if neither source knows the instance type.
+func networkInfo(info ec2types.InstanceTypeInfo) *ec2types.NetworkInfo {
+	if info.NetworkInfo != nil && info.NetworkInfo.Ipv4AddressesPerInterface != nil && len(info.NetworkInfo.NetworkCards) != 0 {
+		return info.NetworkInfo
+	}
+	limits, ok := Limits[string(info.InstanceType)]
+	if !ok {
+		return nil
+	}
+	//nolint:gosec
+	return &ec2types.NetworkInfo{
+		MaximumNetworkInterfaces:  lo.ToPtr(int32(limits.Interface)),
+		Ipv4AddressesPerInterface: lo.ToPtr(int32(limits.IPv4PerInterface)),
+		DefaultNetworkCardIndex:   lo.ToPtr(int32(limits.DefaultNetworkCardIndex)),
+		NetworkCards: lo.Map(limits.NetworkCards, func(card NetworkCard, _ int) ec2types.NetworkCardInfo {
+			return ec2types.NetworkCardInfo{
+				NetworkCardIndex:         lo.ToPtr(int32(card.NetworkCardIndex)),
+				MaximumNetworkInterfaces: lo.ToPtr(int32(card.MaximumNetworkInterfaces)),
+			}
+		}),
+	}
+}
+
+// networkBandwidth returns the baseline network bandwidth of the instance type in megabits, summed across its network
+// cards. Instance types that EC2 doesn't report a baseline bandwidth for fall back to the generated bandwidth table.
+func networkBandwidth(info ec2types.InstanceTypeInfo) (int64, bool) {
+	if bandwidth, ok := describedNetworkBandwidth(info); ok {
+		return bandwidth, true
+	}
+	bandwidth, ok := InstanceTypeBandwidthMegabits[string(info.InstanceType)]
+	return bandwidth, ok
+}
+
+func describedNetworkBandwidth(info ec2types.InstanceTypeInfo) (int64, bool) {
+	if info.NetworkInfo == nil || !lo.ContainsBy(info.NetworkInfo.NetworkCards, func(card ec2types.NetworkCardInfo) bool {
+		return card.BaselineBandwidthInGbps != nil
+	}) {
+		return 0, false
+	}
+	return int64(math.Round(lo.SumBy(info.NetworkInfo.NetworkCards, func(card ec2types.NetworkCardInfo) float64 {
+		return lo.FromPtr(card.BaselineBandwidthInGbps)
+	}) * 1000)), true
+}
+
+// networkLimitMismatches compares the network limits that EC2 reports for the instance type with the generated tables,
+// and returns whether each of the limits that both sources know of disagrees
+func networkLimitMismatches(info ec2types.InstanceTypeInfo) map[string]bool {
+	mismatches := map[string]bool{}
+	if limits, ok := Limits[string(info.InstanceType)]; ok && info.NetworkInfo != nil {
+		if info.NetworkInfo.MaximumNetworkInterfaces != nil {
+			mismatches[maxNetworkInterfacesLimit] = int(*info.NetworkInfo.MaximumNetworkInterfaces) != limits.Interface
+		}
+		if info.NetworkInfo.Ipv4AddressesPerInterface != nil {
+			mismatches[ipv4AddressesPerInterfaceLimit] = int(*info.NetworkInfo.Ipv4AddressesPerInterface) != limits.IPv4PerInterface
+		}
+	}
+	if bandwidth, ok := InstanceTypeBandwidthMegabits[string(info.InstanceType)]; ok {
+		if described, ok := describedNetworkBandwidth(info); ok {
+			mismatches[networkBandwidthLimit] = described != bandwidth
+		}
+	}
+	return mismatches
 }
 
 func systemReservedResources(systemReserved map[string]string) corev1.ResourceList {
@@ -547,19 +919,20 @@ func evictionThreshold(memory *resource.Quantity, storage *resource.Quantity, am
 	return lo.Assign(overhead, override)
 }
 
-func pods(ctx context.Context, info ec2types.InstanceTypeInfo, amiFamily amifamily.AMIFamily, maxPods *int32, podsPerCore *int32) *resource.Quantity {
+func pods(ctx context.Context, info ec2types.InstanceTypeInfo, amiFamily amifamily.AMIFamily, cpuOptions *v1.CPUOptions, networkInterfaces []*v1.NetworkInterface,
+	ipAddressMode string, maxPods *int32, podsPerCore *int32) *resource.Quantity {
 	var count int64
 	switch {
 	case maxPods != nil:
 		count = int64(lo.FromPtr(maxPods))
 	case amiFamily.FeatureFlags().SupportsENILimitedPodDensity:
-		count = ENILimitedPods(ctx, info).Value()
+		count = ENILimitedPods(ctx, info, networkInterfaces, ipAddressMode).Value()
 	default:
 		count = 110
 
 	}
 	if lo.FromPtr(podsPerCore) > 0 && amiFamily.FeatureFlags().PodsPerCoreEnabled {
-		count = lo.Min([]int64{int64(lo.FromPtr(podsPerCore)) * int64(lo.FromPtr(info.VCpuInfo.DefaultVCpus)), count})
+		count = lo.Min([]int64{int64(lo.FromPtr(podsPerCore)) * int64(vCPUs(info, cpuOptions)), count})
 	}
 	return resources.Quantity(fmt.Sprint(count))
 }
@@ -593,3 +966,10 @@ func mustParsePercentage(v string) float64 {
 	}
 	return p
 }
+
+func placementGroupID(nodeClass *v1.EC2NodeClass) string {
+	if nodeClass.Status.PlacementGroup == nil {
+		return ""
+	}
+	return nodeClass.Status.PlacementGroup.ID
+}
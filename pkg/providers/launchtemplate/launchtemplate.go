@@ -19,6 +19,8 @@ import (
 	"fmt"
 	"math"
 	"net"
+	"slices"
+	"sort"
 	"strings"
 	"sync"
 	"sync/atomic"
@@ -55,14 +57,21 @@ import (
 type Provider interface {
 	EnsureAll(context.Context, *v1.EC2NodeClass, *karpv1.NodeClaim,
 		[]*cloudprovider.InstanceType, string, map[string]string) ([]*LaunchTemplate, error)
+	CreateLaunchTemplateInputs(context.Context, *v1.EC2NodeClass, *karpv1.NodeClaim,
+		[]*cloudprovider.InstanceType, string, map[string]string) ([]*ec2.CreateLaunchTemplateInput, error)
 	DeleteAll(context.Context, *v1.EC2NodeClass) error
 	InvalidateCache(context.Context, string, string)
 	ResolveClusterCIDR(context.Context) error
 }
 type LaunchTemplate struct {
-	Name          string
-	InstanceTypes []*cloudprovider.InstanceType
-	ImageID       string
+	Name                    string
+	InstanceTypes           []*cloudprovider.InstanceType
+	ImageID                 string
+	CapacityReservationID   string
+	CapacityReservationType string
+	// Zone is set when instances launched with the launch template are constrained to a zone by the subnets of their
+	// network interfaces or by their Dedicated Host
+	Zone string
 }
 
 type DefaultProvider struct {
@@ -128,11 +137,41 @@ func (p *DefaultProvider) EnsureAll(ctx context.Context, nodeClass *v1.EC2NodeCl
 		if err != nil {
 			return nil, err
 		}
-		launchTemplates = append(launchTemplates, &LaunchTemplate{Name: *ec2LaunchTemplate.LaunchTemplateName, InstanceTypes: resolvedLaunchTemplate.InstanceTypes, ImageID: resolvedLaunchTemplate.AMIID})
+		launchTemplates = append(launchTemplates, &LaunchTemplate{
+			Name:                    *ec2LaunchTemplate.LaunchTemplateName,
+			InstanceTypes:           resolvedLaunchTemplate.InstanceTypes,
+			ImageID:                 resolvedLaunchTemplate.AMIID,
+			CapacityReservationID:   resolvedLaunchTemplate.CapacityReservationID,
+			CapacityReservationType: resolvedLaunchTemplate.CapacityReservationType,
+			Zone:                    resolvedLaunchTemplate.Zone,
+		})
 	}
 	return launchTemplates, nil
 }
 
+// CreateLaunchTemplateInputs resolves the launch templates that EnsureAll would create for the instance types, without
+// creating them. This allows callers to validate the launch templates, e.g. with a dry-run, before they're needed.
+func (p *DefaultProvider) CreateLaunchTemplateInputs(ctx context.Context, nodeClass *v1.EC2NodeClass, nodeClaim *karpv1.NodeClaim,
+	instanceTypes []*cloudprovider.InstanceType, capacityType string, tags map[string]string) ([]*ec2.CreateLaunchTemplateInput, error) {
+	options, err := p.createAMIOptions(ctx, nodeClass, lo.Assign(nodeClaim.Labels, map[string]string{karpv1.CapacityTypeLabelKey: capacityType}), tags)
+	if err != nil {
+		return nil, err
+	}
+	resolvedLaunchTemplates, err := p.amiFamily.Resolve(nodeClass, nodeClaim, instanceTypes, capacityType, options)
+	if err != nil {
+		return nil, err
+	}
+	var inputs []*ec2.CreateLaunchTemplateInput
+	for _, resolvedLaunchTemplate := range resolvedLaunchTemplates {
+		input, err := p.createLaunchTemplateInput(resolvedLaunchTemplate)
+		if err != nil {
+			return nil, err
+		}
+		inputs = append(inputs, input)
+	}
+	return inputs, nil
+}
+
 // InvalidateCache deletes a launch template from cache if it exists
 func (p *DefaultProvider) InvalidateCache(ctx context.Context, ltName string, ltID string) {
 	ctx = log.IntoContext(ctx, log.FromContext(ctx).WithValues("launch-template-name", ltName, "launch-template-id", ltID))
@@ -165,6 +204,10 @@ func (p *DefaultProvider) createAMIOptions(ctx context.Context, nodeClass *v1.EC
 	if len(nodeClass.Status.SecurityGroups) == 0 {
 		return nil, fmt.Errorf("no security groups are present in the status")
 	}
+	networkInterfaces, err := resolveNetworkInterfaces(nodeClass)
+	if err != nil {
+		return nil, err
+	}
 	return &amifamily.Options{
 		ClusterName:              options.FromContext(ctx).ClusterName,
 		ClusterEndpoint:          p.ClusterEndpoint,
@@ -178,9 +221,51 @@ func (p *DefaultProvider) createAMIOptions(ctx context.Context, nodeClass *v1.EC
 		KubeDNSIP:                p.KubeDNSIP,
 		AssociatePublicIPAddress: nodeClass.Spec.AssociatePublicIPAddress,
 		NodeClassName:            nodeClass.Name,
+		NetworkInterfaces:        networkInterfaces,
 	}, nil
 }
 
+// resolveNetworkInterfaces resolves the security groups and subnets of the EC2NodeClass' network interfaces from the
+// EC2NodeClass' status. Network interfaces are created in the subnet with the lowest ID in each zone.
+func resolveNetworkInterfaces(nodeClass *v1.EC2NodeClass) ([]amifamily.NetworkInterface, error) {
+	var networkInterfaces []amifamily.NetworkInterface
+	for _, ni := range nodeClass.Spec.NetworkInterfaces {
+		resolved := amifamily.NetworkInterface{
+			NetworkCardIndex:        ni.NetworkCardIndex,
+			DeviceIndex:             ni.DeviceIndex,
+			InterfaceType:           lo.Ternary(ni.InterfaceType != "", ni.InterfaceType, v1.NetworkInterfaceTypeInterface),
+			IPv4PrefixCount:         ni.IPv4PrefixCount,
+			SecondaryIPAddressCount: ni.SecondaryIPAddressCount,
+		}
+		status, ok := lo.Find(nodeClass.Status.NetworkInterfaces, func(s v1.NetworkInterfaceStatus) bool {
+			return s.NetworkCardIndex == ni.NetworkCardIndex && s.DeviceIndex == ni.DeviceIndex
+		})
+		if len(ni.SecurityGroupSelectorTerms) != 0 {
+			if !ok || len(status.SecurityGroups) == 0 {
+				return nil, fmt.Errorf("no security groups are present in the status for network interface %d on network card %d", ni.DeviceIndex, ni.NetworkCardIndex)
+			}
+			resolved.SecurityGroupIDs = lo.Map(status.SecurityGroups, func(sg v1.SecurityGroup, _ int) string { return sg.ID })
+		}
+		if len(ni.SubnetSelectorTerms) != 0 {
+			if !ok || len(status.Subnets) == 0 {
+				return nil, fmt.Errorf("no subnets are present in the status for network interface %d on network card %d", ni.DeviceIndex, ni.NetworkCardIndex)
+			}
+			// Subnets are chosen by ID rather than by available IP addresses so that the launch templates of the
+			// EC2NodeClass don't change as the available IP addresses of its subnets do
+			subnets := slices.Clone(status.Subnets)
+			sort.Slice(subnets, func(i, j int) bool { return subnets[i].ID < subnets[j].ID })
+			resolved.ZonalSubnetIDs = map[string]string{}
+			for _, subnet := range subnets {
+				if _, ok := resolved.ZonalSubnetIDs[subnet.Zone]; !ok {
+					resolved.ZonalSubnetIDs[subnet.Zone] = subnet.ID
+				}
+			}
+		}
+		networkInterfaces = append(networkInterfaces, resolved)
+	}
+	return networkInterfaces, nil
+}
+
 func (p *DefaultProvider) ensureLaunchTemplate(ctx context.Context, options *amifamily.LaunchTemplate) (ec2types.LaunchTemplate, error) {
 	var launchTemplate ec2types.LaunchTemplate
 	name := LaunchTemplateName(options)
@@ -215,10 +300,23 @@ func (p *DefaultProvider) ensureLaunchTemplate(ctx context.Context, options *ami
 }
 
 func (p *DefaultProvider) createLaunchTemplate(ctx context.Context, options *amifamily.LaunchTemplate) (ec2types.LaunchTemplate, error) {
-	userData, err := options.UserData.Script()
+	input, err := p.createLaunchTemplateInput(options)
+	if err != nil {
+		return ec2types.LaunchTemplate{}, err
+	}
+	output, err := p.ec2api.CreateLaunchTemplate(ctx, input)
 	if err != nil {
 		return ec2types.LaunchTemplate{}, err
 	}
+	log.FromContext(ctx).WithValues("id", aws.ToString(output.LaunchTemplate.LaunchTemplateId)).V(1).Info("created launch template")
+	return lo.FromPtr(output.LaunchTemplate), nil
+}
+
+func (p *DefaultProvider) createLaunchTemplateInput(options *amifamily.LaunchTemplate) (*ec2.CreateLaunchTemplateInput, error) {
+	userData, err := options.UserData.Script()
+	if err != nil {
+		return nil, err
+	}
 	launchTemplateDataTags := []ec2types.LaunchTemplateTagSpecificationRequest{
 		{ResourceType: ec2types.ResourceTypeNetworkInterface, Tags: utils.MergeTags(options.Tags)},
 	}
@@ -226,7 +324,7 @@ func (p *DefaultProvider) createLaunchTemplate(ctx context.Context, options *ami
 		launchTemplateDataTags = append(launchTemplateDataTags, ec2types.LaunchTemplateTagSpecificationRequest{ResourceType: ec2types.ResourceTypeSpotInstancesRequest, Tags: utils.MergeTags(options.Tags)})
 	}
 	networkInterfaces := p.generateNetworkInterfaces(options)
-	output, err := p.ec2api.CreateLaunchTemplate(ctx, &ec2.CreateLaunchTemplateInput{
+	return &ec2.CreateLaunchTemplateInput{
 		LaunchTemplateName: aws.String(LaunchTemplateName(options)),
 		LaunchTemplateData: &ec2types.RequestLaunchTemplateData{
 			BlockDeviceMappings: p.blockDeviceMappings(options.BlockDeviceMappings),
@@ -254,8 +352,12 @@ func (p *DefaultProvider) createLaunchTemplate(ctx context.Context, options *ami
 				// See https://docs.aws.amazon.com/AWSEC2/latest/UserGuide/configuring-instance-metadata-options.html#instance-metadata-options-order-of-precedence
 				InstanceMetadataTags: ec2types.LaunchTemplateInstanceMetadataTagsStateDisabled,
 			},
-			NetworkInterfaces: networkInterfaces,
-			TagSpecifications: launchTemplateDataTags,
+			NetworkInterfaces:                networkInterfaces,
+			TagSpecifications:                launchTemplateDataTags,
+			CapacityReservationSpecification: p.capacityReservationSpecification(options),
+			InstanceMarketOptions:            p.instanceMarketOptions(options),
+			Placement:                        p.placement(options),
+			CpuOptions:                       p.cpuOptions(options),
 		},
 		TagSpecifications: []ec2types.TagSpecification{
 			{
@@ -263,16 +365,92 @@ func (p *DefaultProvider) createLaunchTemplate(ctx context.Context, options *ami
 				Tags:         utils.MergeTags(options.Tags),
 			},
 		},
-	})
-	if err != nil {
-		return ec2types.LaunchTemplate{}, err
+	}, nil
+}
+
+// capacityReservationSpecification targets the capacity reservation of the launch template, if one was resolved.
+func (p *DefaultProvider) capacityReservationSpecification(options *amifamily.LaunchTemplate) *ec2types.LaunchTemplateCapacityReservationSpecificationRequest {
+	if options.CapacityReservationID == "" {
+		return nil
+	}
+	return &ec2types.LaunchTemplateCapacityReservationSpecificationRequest{
+		CapacityReservationTarget: &ec2types.CapacityReservationTarget{
+			CapacityReservationId: aws.String(options.CapacityReservationID),
+		},
 	}
-	log.FromContext(ctx).WithValues("id", aws.ToString(output.LaunchTemplate.LaunchTemplateId)).V(1).Info("created launch template")
-	return lo.FromPtr(output.LaunchTemplate), nil
+}
+
+// instanceMarketOptions sets the capacity-block market type for launch templates which target a capacity block.
+func (p *DefaultProvider) instanceMarketOptions(options *amifamily.LaunchTemplate) *ec2types.LaunchTemplateInstanceMarketOptionsRequest {
+	if options.CapacityReservationType != v1.CapacityReservationTypeCapacityBlock {
+		return nil
+	}
+	return &ec2types.LaunchTemplateInstanceMarketOptionsRequest{
+		MarketType: ec2types.MarketTypeCapacityBlock,
+	}
+}
+
+// cpuOptions sets the core count, threads per core and AMD SEV-SNP state of instances launched with the launch template,
+// if the EC2NodeClass configures CPU options.
+func (p *DefaultProvider) cpuOptions(options *amifamily.LaunchTemplate) *ec2types.LaunchTemplateCpuOptionsRequest {
+	if options.CPUOptions == nil {
+		return nil
+	}
+	return &ec2types.LaunchTemplateCpuOptionsRequest{
+		CoreCount:      options.CPUOptions.CoreCount,
+		ThreadsPerCore: options.CPUOptions.ThreadsPerCore,
+		AmdSevSnp:      ec2types.AmdSevSnpSpecification(lo.FromPtr(options.CPUOptions.AMDSEVSNP)),
+	}
+}
+
+// placement places instances into the placement group resolved for the EC2NodeClass, if one was resolved, and sets the
+// tenancy of instances that aren't launched with the default tenancy. Instances with "host" tenancy are placed onto the
+// launch template's Dedicated Host, with host affinity so that they're restarted on it, or into the host resource group.
+func (p *DefaultProvider) placement(options *amifamily.LaunchTemplate) *ec2types.LaunchTemplatePlacementRequest {
+	// The default tenancy is omitted so that the placement is only set when it's needed
+	tenancy := lo.Ternary(options.Tenancy == v1.TenancyDefault, "", options.Tenancy)
+	if options.PlacementGroup == nil && tenancy == "" {
+		return nil
+	}
+	placement := &ec2types.LaunchTemplatePlacementRequest{
+		Tenancy: ec2types.Tenancy(tenancy),
+	}
+	if options.PlacementGroup != nil {
+		placement.GroupId = aws.String(options.PlacementGroup.ID)
+	}
+	if options.HostID != "" {
+		placement.HostId = aws.String(options.HostID)
+		placement.Affinity = aws.String("host")
+	}
+	if options.HostResourceGroupARN != "" {
+		placement.HostResourceGroupArn = aws.String(options.HostResourceGroupARN)
+	}
+	return placement
 }
 
 // generateNetworkInterfaces generates network interfaces for the launch template.
 func (p *DefaultProvider) generateNetworkInterfaces(options *amifamily.LaunchTemplate) []ec2types.LaunchTemplateInstanceNetworkInterfaceSpecificationRequest {
+	if len(options.NetworkInterfaces) != 0 {
+		return lo.Map(options.NetworkInterfaces, func(ni amifamily.NetworkInterface, _ int) ec2types.LaunchTemplateInstanceNetworkInterfaceSpecificationRequest {
+			primary := ni.NetworkCardIndex == 0 && ni.DeviceIndex == 0
+			hasAddresses := ni.InterfaceType != v1.NetworkInterfaceTypeEFAOnly
+			return ec2types.LaunchTemplateInstanceNetworkInterfaceSpecificationRequest{
+				NetworkCardIndex: lo.ToPtr(ni.NetworkCardIndex),
+				DeviceIndex:      lo.ToPtr(ni.DeviceIndex),
+				InterfaceType:    lo.Ternary(ni.InterfaceType != v1.NetworkInterfaceTypeInterface, lo.ToPtr(ni.InterfaceType), nil),
+				Groups: lo.Ternary(len(ni.SecurityGroupIDs) != 0, ni.SecurityGroupIDs, lo.Map(options.SecurityGroups, func(s v1.SecurityGroup, _ int) string {
+					return s.ID
+				})),
+				SubnetId:                       lo.EmptyableToPtr(ni.ZonalSubnetIDs[options.Zone]),
+				Ipv4PrefixCount:                ni.IPv4PrefixCount,
+				SecondaryPrivateIpAddressCount: ni.SecondaryIPAddressCount,
+				// Public IP addresses can only be associated with the primary network interface
+				AssociatePublicIpAddress: lo.Ternary(primary, options.AssociatePublicIPAddress, nil),
+				PrimaryIpv6:              lo.Ternary(primary && p.ClusterIPFamily == corev1.IPv6Protocol, lo.ToPtr(true), nil),
+				Ipv6AddressCount:         lo.Ternary(hasAddresses && p.ClusterIPFamily == corev1.IPv6Protocol, lo.ToPtr(int32(1)), nil),
+			}
+		})
+	}
 	if options.EFACount != 0 {
 		return lo.Times(options.EFACount, func(i int) ec2types.LaunchTemplateInstanceNetworkInterfaceSpecificationRequest {
 			return ec2types.LaunchTemplateInstanceNetworkInterfaceSpecificationRequest{
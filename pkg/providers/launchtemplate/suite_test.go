@@ -1018,6 +1018,9 @@ var _ = Describe("LaunchTemplate Provider", func() {
 				"",
 				nodeClass.Spec.BlockDeviceMappings,
 				nodeClass.Spec.InstanceStorePolicy,
+				nodeClass.Spec.CPUOptions,
+				nodeClass.Spec.NetworkInterfaces,
+				nodeClass.IPAddressMode(),
 				nodeClass.Spec.Kubelet.MaxPods,
 				nodeClass.Spec.Kubelet.PodsPerCore,
 				nodeClass.Spec.Kubelet.KubeReserved,
@@ -1069,6 +1072,9 @@ var _ = Describe("LaunchTemplate Provider", func() {
 				"",
 				nodeClass.Spec.BlockDeviceMappings,
 				nodeClass.Spec.InstanceStorePolicy,
+				nodeClass.Spec.CPUOptions,
+				nodeClass.Spec.NetworkInterfaces,
+				nodeClass.IPAddressMode(),
 				nodeClass.Spec.Kubelet.MaxPods,
 				nodeClass.Spec.Kubelet.PodsPerCore,
 				nodeClass.Spec.Kubelet.KubeReserved,
@@ -1094,6 +1100,9 @@ var _ = Describe("LaunchTemplate Provider", func() {
 				"",
 				nodeClass.Spec.BlockDeviceMappings,
 				nodeClass.Spec.InstanceStorePolicy,
+				nodeClass.Spec.CPUOptions,
+				nodeClass.Spec.NetworkInterfaces,
+				nodeClass.IPAddressMode(),
 				nodeClass.Spec.Kubelet.MaxPods,
 				nodeClass.Spec.Kubelet.PodsPerCore,
 				nodeClass.Spec.Kubelet.KubeReserved,
@@ -2027,7 +2036,7 @@ essential = true
 				nodeClass.Spec.AMIFamily = lo.ToPtr(v1.AMIFamilyCustom)
 				nodeClass.Spec.AMISelectorTerms = []v1.AMISelectorTerm{{Tags: map[string]string{"*": "*"}}}
 				ExpectApplied(ctx, env.Client, nodeClass)
-				controller := nodeclass.NewController(env.Client, recorder, awsEnv.SubnetProvider, awsEnv.SecurityGroupProvider, awsEnv.AMIProvider, awsEnv.InstanceProfileProvider, awsEnv.LaunchTemplateProvider)
+				controller := nodeclass.NewController(env.Client, recorder, awsEnv.EC2API, awsEnv.SubnetProvider, awsEnv.SecurityGroupProvider, awsEnv.AMIProvider, awsEnv.InstanceProfileProvider, awsEnv.LaunchTemplateProvider, awsEnv.CapacityReservationProvider, awsEnv.PlacementGroupProvider, awsEnv.HostProvider, awsEnv.InstanceTypesProvider, awsEnv.ServiceQuotaProvider)
 				ExpectObjectReconciled(ctx, env.Client, controller, nodeClass)
 				nodePool.Spec.Template.Spec.Requirements = []karpv1.NodeSelectorRequirementWithMinValues{
 					{
@@ -2197,6 +2206,302 @@ essential = true
 			})
 		})
 	})
+	Context("Placement Group", func() {
+		It("should not set a placement group by default", func() {
+			ExpectApplied(ctx, env.Client, nodePool, nodeClass)
+			pod := coretest.UnschedulablePod()
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+			ExpectScheduled(ctx, env.Client, pod)
+			Expect(awsEnv.EC2API.CalledWithCreateLaunchTemplateInput.Len()).To(BeNumerically("==", 5))
+			awsEnv.EC2API.CalledWithCreateLaunchTemplateInput.ForEach(func(ltInput *ec2.CreateLaunchTemplateInput) {
+				Expect(ltInput.LaunchTemplateData.Placement).To(BeNil())
+			})
+		})
+		It("should pass the resolved placement group to the launch template at creation", func() {
+			nodeClass.Status.PlacementGroup = &v1.PlacementGroup{ID: "pg-test", Name: "test", Strategy: string(ec2types.PlacementStrategyCluster)}
+			ExpectApplied(ctx, env.Client, nodePool, nodeClass)
+			pod := coretest.UnschedulablePod()
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+			ExpectScheduled(ctx, env.Client, pod)
+			Expect(awsEnv.EC2API.CalledWithCreateLaunchTemplateInput.Len()).To(BeNumerically("==", 5))
+			awsEnv.EC2API.CalledWithCreateLaunchTemplateInput.ForEach(func(ltInput *ec2.CreateLaunchTemplateInput) {
+				Expect(ltInput.LaunchTemplateData.Placement).ToNot(BeNil())
+				Expect(aws.ToString(ltInput.LaunchTemplateData.Placement.GroupId)).To(Equal("pg-test"))
+			})
+		})
+		It("should label nodes with the partition of a partition placement group", func() {
+			awsEnv.EC2API.PlacementGroups.Store("pg-test", ec2types.PlacementGroup{
+				GroupId:        aws.String("pg-test"),
+				GroupName:      aws.String("test"),
+				Strategy:       ec2types.PlacementStrategyPartition,
+				PartitionCount: aws.Int32(3),
+				State:          ec2types.PlacementGroupStateAvailable,
+			})
+			nodeClass.Status.PlacementGroup = &v1.PlacementGroup{ID: "pg-test", Name: "test", Strategy: string(ec2types.PlacementStrategyPartition), PartitionCount: 3}
+			ExpectApplied(ctx, env.Client, nodePool, nodeClass)
+			pod := coretest.UnschedulablePod()
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+			node := ExpectScheduled(ctx, env.Client, pod)
+			Expect(node.Labels).To(HaveKeyWithValue(v1.LabelPlacementGroupPartition, "1"))
+		})
+	})
+	Context("Tenancy", func() {
+		It("should set the tenancy on the launch template", func() {
+			nodeClass.Spec.Tenancy = lo.ToPtr(v1.TenancyDedicated)
+			ExpectApplied(ctx, env.Client, nodePool, nodeClass)
+			pod := coretest.UnschedulablePod()
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+			ExpectScheduled(ctx, env.Client, pod)
+			Expect(awsEnv.EC2API.CalledWithCreateLaunchTemplateInput.Len()).To(BeNumerically(">", 0))
+			awsEnv.EC2API.CalledWithCreateLaunchTemplateInput.ForEach(func(ltInput *ec2.CreateLaunchTemplateInput) {
+				Expect(ltInput.LaunchTemplateData.Placement).ToNot(BeNil())
+				Expect(ltInput.LaunchTemplateData.Placement.Tenancy).To(Equal(ec2types.TenancyDedicated))
+				Expect(ltInput.LaunchTemplateData.Placement.HostResourceGroupArn).To(BeNil())
+			})
+		})
+		It("should set the host resource group on the launch template", func() {
+			nodeClass.Spec.Tenancy = lo.ToPtr(v1.TenancyHost)
+			nodeClass.Spec.HostResourceGroupARN = lo.ToPtr("arn:aws:resource-groups:us-west-2:111122223333:group/test")
+			ExpectApplied(ctx, env.Client, nodePool, nodeClass)
+			pod := coretest.UnschedulablePod()
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+			ExpectScheduled(ctx, env.Client, pod)
+			Expect(awsEnv.EC2API.CalledWithCreateLaunchTemplateInput.Len()).To(BeNumerically(">", 0))
+			awsEnv.EC2API.CalledWithCreateLaunchTemplateInput.ForEach(func(ltInput *ec2.CreateLaunchTemplateInput) {
+				Expect(ltInput.LaunchTemplateData.Placement).ToNot(BeNil())
+				Expect(ltInput.LaunchTemplateData.Placement.Tenancy).To(Equal(ec2types.TenancyHost))
+				Expect(aws.ToString(ltInput.LaunchTemplateData.Placement.HostResourceGroupArn)).To(Equal("arn:aws:resource-groups:us-west-2:111122223333:group/test"))
+			})
+		})
+		It("should place instances onto the selected dedicated hosts", func() {
+			nodeClass.Spec.Tenancy = lo.ToPtr(v1.TenancyHost)
+			nodeClass.Spec.HostSelectorTerms = []v1.HostSelectorTerm{{Tags: map[string]string{"*": "*"}}}
+			nodeClass.Status.Hosts = []v1.Host{
+				{ID: "h-test1", AvailabilityZone: "test-zone-1a", InstanceFamily: "m5"},
+				{ID: "h-test2", AvailabilityZone: "test-zone-1b", InstanceType: "c5.large"},
+			}
+			ExpectApplied(ctx, env.Client, nodePool, nodeClass)
+			pod := coretest.UnschedulablePod()
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+			ExpectScheduled(ctx, env.Client, pod)
+			Expect(awsEnv.EC2API.CalledWithCreateLaunchTemplateInput.Len()).To(BeNumerically(">", 0))
+			hostIDs := map[string]string{}
+			awsEnv.EC2API.CalledWithCreateLaunchTemplateInput.ForEach(func(ltInput *ec2.CreateLaunchTemplateInput) {
+				Expect(ltInput.LaunchTemplateData.Placement).ToNot(BeNil())
+				Expect(ltInput.LaunchTemplateData.Placement.Tenancy).To(Equal(ec2types.TenancyHost))
+				Expect(aws.ToString(ltInput.LaunchTemplateData.Placement.Affinity)).To(Equal("host"))
+				Expect(aws.ToString(ltInput.LaunchTemplateData.Placement.HostId)).To(BeElementOf("h-test1", "h-test2"))
+				hostIDs[aws.ToString(ltInput.LaunchTemplateName)] = aws.ToString(ltInput.LaunchTemplateData.Placement.HostId)
+			})
+			// Each launch template is constrained to the instance types and the zone of its host
+			Expect(awsEnv.EC2API.CreateFleetBehavior.CalledWithInput.Len()).To(Equal(1))
+			for _, ltc := range awsEnv.EC2API.CreateFleetBehavior.CalledWithInput.Pop().LaunchTemplateConfigs {
+				hostID := hostIDs[aws.ToString(ltc.LaunchTemplateSpecification.LaunchTemplateName)]
+				for _, override := range ltc.Overrides {
+					if hostID == "h-test1" {
+						Expect(string(override.InstanceType)).To(HavePrefix("m5."))
+						Expect(aws.ToString(override.AvailabilityZone)).To(Equal("test-zone-1a"))
+					} else {
+						Expect(string(override.InstanceType)).To(Equal("c5.large"))
+						Expect(aws.ToString(override.AvailabilityZone)).To(Equal("test-zone-1b"))
+					}
+				}
+			}
+		})
+		It("should resolve launch templates for at most a limited number of the selected dedicated hosts", func() {
+			nodeClass.Spec.Tenancy = lo.ToPtr(v1.TenancyHost)
+			nodeClass.Spec.HostSelectorTerms = []v1.HostSelectorTerm{{Tags: map[string]string{"*": "*"}}}
+			nodeClass.Status.Hosts = lo.Times(amifamily.MaxHostsPerLaunchTemplate*2, func(i int) v1.Host {
+				return v1.Host{ID: fmt.Sprintf("h-test%d", i), AvailabilityZone: "test-zone-1a", InstanceFamily: "m5"}
+			})
+			ExpectApplied(ctx, env.Client, nodePool, nodeClass)
+			pod := coretest.UnschedulablePod()
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+			ExpectScheduled(ctx, env.Client, pod)
+			hostIDs := sets.New[string]()
+			awsEnv.EC2API.CalledWithCreateLaunchTemplateInput.ForEach(func(ltInput *ec2.CreateLaunchTemplateInput) {
+				Expect(ltInput.LaunchTemplateData.Placement).ToNot(BeNil())
+				hostIDs.Insert(aws.ToString(ltInput.LaunchTemplateData.Placement.HostId))
+			})
+			Expect(hostIDs.Len()).To(Equal(amifamily.MaxHostsPerLaunchTemplate))
+		})
+		It("should let EC2 choose the dedicated host from the host resource group", func() {
+			nodeClass.Spec.Tenancy = lo.ToPtr(v1.TenancyHost)
+			nodeClass.Spec.HostResourceGroupARN = lo.ToPtr("arn:aws:resource-groups:us-west-2:111122223333:group/test")
+			nodeClass.Spec.HostSelectorTerms = []v1.HostSelectorTerm{{Tags: map[string]string{"*": "*"}}}
+			nodeClass.Status.Hosts = []v1.Host{
+				{ID: "h-test1", AvailabilityZone: "test-zone-1a", InstanceFamily: "m5"},
+				{ID: "h-test2", AvailabilityZone: "test-zone-1b", InstanceFamily: "m5"},
+			}
+			ExpectApplied(ctx, env.Client, nodePool, nodeClass)
+			pod := coretest.UnschedulablePod()
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+			ExpectScheduled(ctx, env.Client, pod)
+			Expect(awsEnv.EC2API.CalledWithCreateLaunchTemplateInput.Len()).To(BeNumerically(">", 0))
+			awsEnv.EC2API.CalledWithCreateLaunchTemplateInput.ForEach(func(ltInput *ec2.CreateLaunchTemplateInput) {
+				Expect(ltInput.LaunchTemplateData.Placement).ToNot(BeNil())
+				Expect(ltInput.LaunchTemplateData.Placement.HostId).To(BeNil())
+				Expect(aws.ToString(ltInput.LaunchTemplateData.Placement.HostResourceGroupArn)).To(Equal("arn:aws:resource-groups:us-west-2:111122223333:group/test"))
+			})
+		})
+	})
+	Context("CPU Options", func() {
+		It("should not set CPU options by default", func() {
+			ExpectApplied(ctx, env.Client, nodePool, nodeClass)
+			pod := coretest.UnschedulablePod()
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+			ExpectScheduled(ctx, env.Client, pod)
+			Expect(awsEnv.EC2API.CalledWithCreateLaunchTemplateInput.Len()).To(BeNumerically(">", 0))
+			awsEnv.EC2API.CalledWithCreateLaunchTemplateInput.ForEach(func(ltInput *ec2.CreateLaunchTemplateInput) {
+				Expect(ltInput.LaunchTemplateData.CpuOptions).To(BeNil())
+			})
+		})
+		It("should set AMD SEV-SNP on the launch template", func() {
+			nodeClass.Spec.CPUOptions = &v1.CPUOptions{AMDSEVSNP: lo.ToPtr("disabled")}
+			ExpectApplied(ctx, env.Client, nodePool, nodeClass)
+			pod := coretest.UnschedulablePod()
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+			ExpectScheduled(ctx, env.Client, pod)
+			Expect(awsEnv.EC2API.CalledWithCreateLaunchTemplateInput.Len()).To(BeNumerically(">", 0))
+			awsEnv.EC2API.CalledWithCreateLaunchTemplateInput.ForEach(func(ltInput *ec2.CreateLaunchTemplateInput) {
+				Expect(ltInput.LaunchTemplateData.CpuOptions).ToNot(BeNil())
+				Expect(ltInput.LaunchTemplateData.CpuOptions.AmdSevSnp).To(Equal(ec2types.AmdSevSnpSpecificationDisabled))
+				Expect(ltInput.LaunchTemplateData.CpuOptions.CoreCount).To(BeNil())
+				Expect(ltInput.LaunchTemplateData.CpuOptions.ThreadsPerCore).To(BeNil())
+			})
+		})
+		It("should set the resolved core count and threads per core on a launch template per instance type", func() {
+			out := lo.Must(awsEnv.EC2API.DescribeInstanceTypes(ctx, &ec2.DescribeInstanceTypesInput{}))
+			awsEnv.EC2API.DescribeInstanceTypesOutput.Set(&ec2.DescribeInstanceTypesOutput{
+				InstanceTypes: lo.Map(out.InstanceTypes, func(info ec2types.InstanceTypeInfo, _ int) ec2types.InstanceTypeInfo {
+					switch info.InstanceType {
+					case "m5.xlarge":
+						info.VCpuInfo = &ec2types.VCpuInfo{DefaultCores: aws.Int32(2), DefaultVCpus: aws.Int32(4), DefaultThreadsPerCore: aws.Int32(2), ValidCores: []int32{1, 2}, ValidThreadsPerCore: []int32{1, 2}}
+					case "m5.large":
+						info.VCpuInfo = &ec2types.VCpuInfo{DefaultCores: aws.Int32(1), DefaultVCpus: aws.Int32(2), DefaultThreadsPerCore: aws.Int32(2), ValidCores: []int32{1}, ValidThreadsPerCore: []int32{1, 2}}
+					}
+					return info
+				}),
+			})
+			Expect(awsEnv.InstanceTypesProvider.UpdateInstanceTypes(ctx)).To(Succeed())
+			Expect(awsEnv.InstanceTypesProvider.UpdateInstanceTypeOfferings(ctx)).To(Succeed())
+			nodeClass.Spec.CPUOptions = &v1.CPUOptions{CoreRatio: lo.ToPtr[int32](100), ThreadsPerCore: lo.ToPtr[int32](1)}
+			ExpectApplied(ctx, env.Client, nodePool, nodeClass)
+			pod := coretest.UnschedulablePod()
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+			ExpectScheduled(ctx, env.Client, pod)
+			var cpuOptions []ec2types.LaunchTemplateCpuOptionsRequest
+			awsEnv.EC2API.CalledWithCreateLaunchTemplateInput.ForEach(func(ltInput *ec2.CreateLaunchTemplateInput) {
+				Expect(ltInput.LaunchTemplateData.CpuOptions).ToNot(BeNil())
+				cpuOptions = append(cpuOptions, *ltInput.LaunchTemplateData.CpuOptions)
+			})
+			Expect(cpuOptions).To(ConsistOf(
+				ec2types.LaunchTemplateCpuOptionsRequest{CoreCount: aws.Int32(1), ThreadsPerCore: aws.Int32(1)},
+				ec2types.LaunchTemplateCpuOptionsRequest{CoreCount: aws.Int32(2), ThreadsPerCore: aws.Int32(1)},
+			))
+		})
+	})
+	Context("Network Interfaces", func() {
+		It("should pass the configured network interfaces to the launch template", func() {
+			nodeClass.Spec.AssociatePublicIPAddress = lo.ToPtr(true)
+			nodeClass.Spec.NetworkInterfaces = []*v1.NetworkInterface{
+				{NetworkCardIndex: 0, DeviceIndex: 0, InterfaceType: v1.NetworkInterfaceTypeEFA, IPv4PrefixCount: lo.ToPtr[int32](2)},
+				{NetworkCardIndex: 0, DeviceIndex: 1, InterfaceType: v1.NetworkInterfaceTypeInterface, SecondaryIPAddressCount: lo.ToPtr[int32](4), SecurityGroupSelectorTerms: []v1.SecurityGroupSelectorTerm{{ID: "sg-test3"}}},
+				{NetworkCardIndex: 1, DeviceIndex: 0, InterfaceType: v1.NetworkInterfaceTypeEFAOnly},
+			}
+			nodeClass.Status.NetworkInterfaces = []v1.NetworkInterfaceStatus{
+				{NetworkCardIndex: 0, DeviceIndex: 0},
+				{NetworkCardIndex: 0, DeviceIndex: 1, SecurityGroups: []v1.SecurityGroup{{ID: "sg-test3"}}},
+				{NetworkCardIndex: 1, DeviceIndex: 0},
+			}
+			ExpectApplied(ctx, env.Client, nodePool, nodeClass)
+			pod := coretest.UnschedulablePod()
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+			ExpectScheduled(ctx, env.Client, pod)
+			Expect(awsEnv.EC2API.CalledWithCreateLaunchTemplateInput.Len()).To(BeNumerically(">", 0))
+			awsEnv.EC2API.CalledWithCreateLaunchTemplateInput.ForEach(func(ltInput *ec2.CreateLaunchTemplateInput) {
+				Expect(ltInput.LaunchTemplateData.NetworkInterfaces).To(HaveLen(3))
+				primary := ltInput.LaunchTemplateData.NetworkInterfaces[0]
+				Expect(aws.ToInt32(primary.NetworkCardIndex)).To(BeNumerically("==", 0))
+				Expect(aws.ToInt32(primary.DeviceIndex)).To(BeNumerically("==", 0))
+				Expect(aws.ToString(primary.InterfaceType)).To(Equal(v1.NetworkInterfaceTypeEFA))
+				Expect(aws.ToInt32(primary.Ipv4PrefixCount)).To(BeNumerically("==", 2))
+				Expect(aws.ToBool(primary.AssociatePublicIpAddress)).To(BeTrue())
+				Expect(primary.Groups).To(ConsistOf("sg-test1", "sg-test2", "sg-test3"))
+				Expect(primary.SubnetId).To(BeNil())
+
+				secondary := ltInput.LaunchTemplateData.NetworkInterfaces[1]
+				Expect(aws.ToInt32(secondary.DeviceIndex)).To(BeNumerically("==", 1))
+				Expect(secondary.InterfaceType).To(BeNil())
+				Expect(aws.ToInt32(secondary.SecondaryPrivateIpAddressCount)).To(BeNumerically("==", 4))
+				Expect(secondary.AssociatePublicIpAddress).To(BeNil())
+				Expect(secondary.Groups).To(ConsistOf("sg-test3"))
+
+				efaOnly := ltInput.LaunchTemplateData.NetworkInterfaces[2]
+				Expect(aws.ToInt32(efaOnly.NetworkCardIndex)).To(BeNumerically("==", 1))
+				Expect(aws.ToString(efaOnly.InterfaceType)).To(Equal(v1.NetworkInterfaceTypeEFAOnly))
+				Expect(efaOnly.AssociatePublicIpAddress).To(BeNil())
+			})
+		})
+		It("should launch into the zone of the subnets that network interfaces are created in", func() {
+			nodeClass.Spec.NetworkInterfaces = []*v1.NetworkInterface{
+				{DeviceIndex: 0},
+				{DeviceIndex: 1, SubnetSelectorTerms: []v1.SubnetSelectorTerm{{ID: "subnet-test2"}}},
+			}
+			nodeClass.Status.NetworkInterfaces = []v1.NetworkInterfaceStatus{
+				{DeviceIndex: 0},
+				{DeviceIndex: 1, Subnets: []v1.Subnet{{ID: "subnet-test2", Zone: "test-zone-1b", ZoneID: "tstz1-1b"}}},
+			}
+			ExpectApplied(ctx, env.Client, nodePool, nodeClass)
+			pod := coretest.UnschedulablePod()
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+			node := ExpectScheduled(ctx, env.Client, pod)
+			Expect(node.Labels).To(HaveKeyWithValue(corev1.LabelTopologyZone, "test-zone-1b"))
+			Expect(awsEnv.EC2API.CalledWithCreateLaunchTemplateInput.Len()).To(BeNumerically(">", 0))
+			awsEnv.EC2API.CalledWithCreateLaunchTemplateInput.ForEach(func(ltInput *ec2.CreateLaunchTemplateInput) {
+				Expect(ltInput.LaunchTemplateData.NetworkInterfaces).To(HaveLen(2))
+				Expect(ltInput.LaunchTemplateData.NetworkInterfaces[0].SubnetId).To(BeNil())
+				Expect(aws.ToString(ltInput.LaunchTemplateData.NetworkInterfaces[1].SubnetId)).To(Equal("subnet-test2"))
+			})
+			createFleetInput := awsEnv.EC2API.CreateFleetBehavior.CalledWithInput.Pop()
+			for _, ltc := range createFleetInput.LaunchTemplateConfigs {
+				for _, override := range ltc.Overrides {
+					Expect(aws.ToString(override.AvailabilityZone)).To(Equal("test-zone-1b"))
+				}
+			}
+		})
+		It("should create network interfaces in the subnet with the lowest ID in each zone", func() {
+			nodeClass.Spec.NetworkInterfaces = []*v1.NetworkInterface{
+				{DeviceIndex: 0},
+				{DeviceIndex: 1, SubnetSelectorTerms: []v1.SubnetSelectorTerm{{Tags: map[string]string{"*": "*"}}}},
+			}
+			nodeClass.Status.NetworkInterfaces = []v1.NetworkInterfaceStatus{
+				{DeviceIndex: 0},
+				{DeviceIndex: 1, Subnets: []v1.Subnet{
+					{ID: "subnet-test5", Zone: "test-zone-1a", ZoneID: "tstz1-1a"},
+					{ID: "subnet-test4", Zone: "test-zone-1a", ZoneID: "tstz1-1a"},
+				}},
+			}
+			ExpectApplied(ctx, env.Client, nodePool, nodeClass)
+			pod := coretest.UnschedulablePod()
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+			ExpectScheduled(ctx, env.Client, pod)
+			Expect(awsEnv.EC2API.CalledWithCreateLaunchTemplateInput.Len()).To(BeNumerically(">", 0))
+			awsEnv.EC2API.CalledWithCreateLaunchTemplateInput.ForEach(func(ltInput *ec2.CreateLaunchTemplateInput) {
+				Expect(aws.ToString(ltInput.LaunchTemplateData.NetworkInterfaces[1].SubnetId)).To(Equal("subnet-test4"))
+			})
+		})
+		It("should not launch when no subnets are resolved for a network interface", func() {
+			nodeClass.Spec.NetworkInterfaces = []*v1.NetworkInterface{
+				{DeviceIndex: 0},
+				{DeviceIndex: 1, SubnetSelectorTerms: []v1.SubnetSelectorTerm{{ID: "subnet-does-not-exist"}}},
+			}
+			ExpectApplied(ctx, env.Client, nodePool, nodeClass)
+			pod := coretest.UnschedulablePod()
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+			ExpectNotScheduled(ctx, env.Client, pod)
+		})
+	})
 	Context("Instance Metadata", func() {
 		It("should set the default instance metadata settings on instances", func() {
 			ExpectApplied(ctx, env.Client, nodePool, nodeClass)
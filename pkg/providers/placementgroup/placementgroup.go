@@ -0,0 +1,204 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package placementgroup
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/mitchellh/hashstructure/v2"
+	"github.com/patrickmn/go-cache"
+	"github.com/samber/lo"
+	"go.uber.org/multierr"
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	"sigs.k8s.io/karpenter/pkg/utils/pretty"
+
+	v1 "github.com/aws/karpenter-provider-aws/pkg/apis/v1"
+	sdk "github.com/aws/karpenter-provider-aws/pkg/aws"
+	awserrors "github.com/aws/karpenter-provider-aws/pkg/errors"
+	"github.com/aws/karpenter-provider-aws/pkg/operator/options"
+)
+
+type Provider interface {
+	Get(context.Context, *v1.EC2NodeClass) (*ec2types.PlacementGroup, error)
+	DeleteManaged(context.Context, *v1.EC2NodeClass, string) error
+}
+
+type DefaultProvider struct {
+	region string
+	ec2api sdk.EC2API
+	cache  *cache.Cache
+	cm     *pretty.ChangeMonitor
+}
+
+func NewDefaultProvider(region string, ec2api sdk.EC2API, cache *cache.Cache) *DefaultProvider {
+	return &DefaultProvider{
+		region: region,
+		ec2api: ec2api,
+		cache:  cache,
+		cm:     pretty.NewChangeMonitor(),
+	}
+}
+
+// Get returns the placement group selected by the EC2NodeClass. If the EC2NodeClass specifies a strategy rather than
+// an existing placement group, the placement group is created if it doesn't already exist.
+func (p *DefaultProvider) Get(ctx context.Context, nodeClass *v1.EC2NodeClass) (*ec2types.PlacementGroup, error) {
+	if nodeClass.Spec.PlacementGroup == nil {
+		return nil, nil
+	}
+	input := p.describeInput(ctx, nodeClass)
+	hash, err := hashstructure.Hash(input, hashstructure.FormatV2, &hashstructure.HashOptions{SlicesAsSets: true})
+	if err != nil {
+		return nil, err
+	}
+	if placementGroup, ok := p.cache.Get(fmt.Sprint(hash)); ok {
+		return lo.ToPtr(placementGroup.(ec2types.PlacementGroup)), nil
+	}
+	var placementGroup *ec2types.PlacementGroup
+	out, err := p.ec2api.DescribePlacementGroups(ctx, input)
+	if err != nil && !awserrors.IsNotFound(err) {
+		return nil, fmt.Errorf("describing placement groups %s, %w", pretty.Concise(input), err)
+	}
+	if out != nil && len(out.PlacementGroups) > 0 {
+		placementGroup = &out.PlacementGroups[0]
+	}
+	if placementGroup == nil {
+		if nodeClass.Spec.PlacementGroup.Strategy == "" {
+			return nil, nil
+		}
+		if placementGroup, err = p.create(ctx, nodeClass); err != nil {
+			return nil, err
+		}
+	}
+	p.cache.SetDefault(fmt.Sprint(hash), *placementGroup)
+	if p.cm.HasChanged(fmt.Sprintf("placement-group/%s", nodeClass.Name), aws.ToString(placementGroup.GroupId)) {
+		log.FromContext(ctx).WithValues(
+			"id", aws.ToString(placementGroup.GroupId),
+			"name", aws.ToString(placementGroup.GroupName),
+			"strategy", placementGroup.Strategy,
+		).V(1).Info("discovered placement group")
+	}
+	return placementGroup, nil
+}
+
+// DeleteManaged deletes the placement groups that Karpenter created for the EC2NodeClass, other than the placement
+// group with the given id. Managed placement groups are found by their tags rather than the status of the EC2NodeClass,
+// since a placement group can't be deleted while it has instances, and the status moves on to the newly selected
+// placement group as soon as the selector changes. Placement groups which were selected by name or id aren't managed
+// by Karpenter and are left in place.
+func (p *DefaultProvider) DeleteManaged(ctx context.Context, nodeClass *v1.EC2NodeClass, exceptID string) error {
+	placementGroups, err := p.listManaged(ctx, nodeClass)
+	if err != nil {
+		return err
+	}
+	var errs error
+	deleted := false
+	for _, placementGroup := range placementGroups {
+		if aws.ToString(placementGroup.GroupId) == exceptID {
+			continue
+		}
+		name := aws.ToString(placementGroup.GroupName)
+		if _, err := p.ec2api.DeletePlacementGroup(ctx, &ec2.DeletePlacementGroupInput{GroupName: aws.String(name)}); err != nil {
+			errs = multierr.Append(errs, awserrors.IgnoreNotFound(fmt.Errorf("deleting placement group %q, %w", name, err)))
+			continue
+		}
+		p.invalidate(name)
+		deleted = true
+		log.FromContext(ctx).WithValues("name", name, "strategy", placementGroup.Strategy).V(1).Info("deleted placement group")
+	}
+	if deleted {
+		p.invalidateManaged(ctx, nodeClass)
+	}
+	return errs
+}
+
+// listManaged returns the placement groups that Karpenter created for the EC2NodeClass
+func (p *DefaultProvider) listManaged(ctx context.Context, nodeClass *v1.EC2NodeClass) ([]ec2types.PlacementGroup, error) {
+	input := p.managedInput(ctx, nodeClass)
+	hash, err := hashstructure.Hash(input, hashstructure.FormatV2, &hashstructure.HashOptions{SlicesAsSets: true})
+	if err != nil {
+		return nil, err
+	}
+	if placementGroups, ok := p.cache.Get(fmt.Sprint(hash)); ok {
+		return placementGroups.([]ec2types.PlacementGroup), nil
+	}
+	out, err := p.ec2api.DescribePlacementGroups(ctx, input)
+	if err != nil && !awserrors.IsNotFound(err) {
+		return nil, fmt.Errorf("describing placement groups %s, %w", pretty.Concise(input), err)
+	}
+	var placementGroups []ec2types.PlacementGroup
+	if out != nil {
+		placementGroups = out.PlacementGroups
+	}
+	p.cache.SetDefault(fmt.Sprint(hash), placementGroups)
+	return placementGroups, nil
+}
+
+func (p *DefaultProvider) managedInput(ctx context.Context, nodeClass *v1.EC2NodeClass) *ec2.DescribePlacementGroupsInput {
+	return &ec2.DescribePlacementGroupsInput{
+		Filters: []ec2types.Filter{
+			{Name: aws.String(fmt.Sprintf("tag:%s", v1.LabelNodeClass)), Values: []string{nodeClass.Name}},
+			{Name: aws.String(fmt.Sprintf("tag:%s", v1.EKSClusterNameTagKey)), Values: []string{options.FromContext(ctx).ClusterName}},
+			{Name: aws.String(fmt.Sprintf("tag:%s", corev1.LabelTopologyRegion)), Values: []string{p.region}},
+		},
+	}
+}
+
+func (p *DefaultProvider) invalidateManaged(ctx context.Context, nodeClass *v1.EC2NodeClass) {
+	if hash, err := hashstructure.Hash(p.managedInput(ctx, nodeClass), hashstructure.FormatV2, &hashstructure.HashOptions{SlicesAsSets: true}); err == nil {
+		p.cache.Delete(fmt.Sprint(hash))
+	}
+}
+
+func (p *DefaultProvider) invalidate(name string) {
+	if hash, err := hashstructure.Hash(&ec2.DescribePlacementGroupsInput{GroupNames: []string{name}}, hashstructure.FormatV2, &hashstructure.HashOptions{SlicesAsSets: true}); err == nil {
+		p.cache.Delete(fmt.Sprint(hash))
+	}
+}
+
+func (p *DefaultProvider) create(ctx context.Context, nodeClass *v1.EC2NodeClass) (*ec2types.PlacementGroup, error) {
+	name := nodeClass.PlacementGroupName(options.FromContext(ctx).ClusterName, p.region)
+	tags := lo.Assign(nodeClass.InstanceProfileTags(options.FromContext(ctx).ClusterName), map[string]string{corev1.LabelTopologyRegion: p.region})
+	out, err := p.ec2api.CreatePlacementGroup(ctx, &ec2.CreatePlacementGroupInput{
+		GroupName:      aws.String(name),
+		Strategy:       ec2types.PlacementStrategy(nodeClass.Spec.PlacementGroup.Strategy),
+		PartitionCount: nodeClass.Spec.PlacementGroup.PartitionCount,
+		TagSpecifications: []ec2types.TagSpecification{{
+			ResourceType: ec2types.ResourceTypePlacementGroup,
+			Tags:         lo.MapToSlice(tags, func(k, v string) ec2types.Tag { return ec2types.Tag{Key: aws.String(k), Value: aws.String(v)} }),
+		}},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("creating placement group %q, %w", name, err)
+	}
+	log.FromContext(ctx).WithValues("name", name, "strategy", nodeClass.Spec.PlacementGroup.Strategy).V(1).Info("created placement group")
+	return out.PlacementGroup, nil
+}
+
+func (p *DefaultProvider) describeInput(ctx context.Context, nodeClass *v1.EC2NodeClass) *ec2.DescribePlacementGroupsInput {
+	switch {
+	case nodeClass.Spec.PlacementGroup.ID != "":
+		return &ec2.DescribePlacementGroupsInput{GroupIds: []string{nodeClass.Spec.PlacementGroup.ID}}
+	case nodeClass.Spec.PlacementGroup.Name != "":
+		return &ec2.DescribePlacementGroupsInput{GroupNames: []string{nodeClass.Spec.PlacementGroup.Name}}
+	default:
+		return &ec2.DescribePlacementGroupsInput{GroupNames: []string{nodeClass.PlacementGroupName(options.FromContext(ctx).ClusterName, p.region)}}
+	}
+}
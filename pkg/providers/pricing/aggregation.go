@@ -0,0 +1,122 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pricing
+
+import (
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/samber/lo"
+)
+
+const (
+	// SpotPriceAggregationLatest prices spot offerings at the most recent spot price
+	SpotPriceAggregationLatest = "latest"
+	// SpotPriceAggregationAverage prices spot offerings at the time-weighted average spot price within the window
+	SpotPriceAggregationAverage = "average"
+	// SpotPriceAggregationPercentilePrefix prefixes percentile aggregations, such as "p90", which price spot offerings at
+	// the time-weighted percentile of the spot price within the window
+	SpotPriceAggregationPercentilePrefix = "p"
+)
+
+// pricePoint is a spot price which is in effect from its timestamp until the timestamp of the next price
+type pricePoint struct {
+	timestamp time.Time
+	price     float64
+}
+
+// segment is a price and how long it was in effect within a window
+type segment struct {
+	price    float64
+	duration time.Duration
+}
+
+// mergeHistory adds the points to a price history and drops the points that were no longer in effect at the start of
+// the window. The most recent point before the start of the window is kept, since it was in effect at the start.
+func mergeHistory(history []pricePoint, points []pricePoint, window time.Duration, now time.Time) []pricePoint {
+	merged := lo.UniqBy(append(append([]pricePoint{}, points...), history...), func(p pricePoint) int64 { return p.timestamp.UnixNano() })
+	sort.Slice(merged, func(i, j int) bool { return merged[i].timestamp.Before(merged[j].timestamp) })
+	start := now.Add(-window)
+	first := 0
+	for i := range merged {
+		if !merged[i].timestamp.After(start) {
+			first = i
+		}
+	}
+	return merged[first:]
+}
+
+// segments returns how long each price of a sorted price history was in effect within the window
+func segments(history []pricePoint, window time.Duration, now time.Time) []segment {
+	start := now.Add(-window)
+	var result []segment
+	for i, p := range history {
+		from := lo.Ternary(p.timestamp.Before(start), start, p.timestamp)
+		to := now
+		if i+1 < len(history) {
+			to = history[i+1].timestamp
+		}
+		if to.After(from) {
+			result = append(result, segment{price: p.price, duration: to.Sub(from)})
+		}
+	}
+	return result
+}
+
+// aggregate returns the price of a sorted, non-empty price history according to the aggregation. Prices are weighted
+// by how long they were in effect within the window, so that brief spikes or dips have little effect on the price.
+func aggregate(history []pricePoint, aggregation string, window time.Duration, now time.Time) float64 {
+	latest := history[len(history)-1].price
+	segs := segments(history, window, now)
+	total := lo.SumBy(segs, func(s segment) time.Duration { return s.duration })
+	if aggregation == SpotPriceAggregationLatest || total == 0 {
+		return latest
+	}
+	if aggregation == SpotPriceAggregationAverage {
+		return lo.SumBy(segs, func(s segment) float64 { return s.price * s.duration.Seconds() }) / total.Seconds()
+	}
+	percentile, err := strconv.Atoi(strings.TrimPrefix(aggregation, SpotPriceAggregationPercentilePrefix))
+	if err != nil || percentile < 1 || percentile > 100 {
+		return latest
+	}
+	sort.Slice(segs, func(i, j int) bool { return segs[i].price < segs[j].price })
+	threshold := total.Seconds() * float64(percentile) / 100
+	cumulative := 0.0
+	for _, s := range segs {
+		cumulative += s.duration.Seconds()
+		if cumulative >= threshold {
+			return s.price
+		}
+	}
+	return segs[len(segs)-1].price
+}
+
+// volatility returns the time-weighted coefficient of variation of a sorted price history within the window
+func volatility(history []pricePoint, window time.Duration, now time.Time) float64 {
+	segs := segments(history, window, now)
+	total := lo.SumBy(segs, func(s segment) time.Duration { return s.duration }).Seconds()
+	if total == 0 {
+		return 0
+	}
+	mean := lo.SumBy(segs, func(s segment) float64 { return s.price * s.duration.Seconds() }) / total
+	if mean == 0 {
+		return 0
+	}
+	variance := lo.SumBy(segs, func(s segment) float64 { return math.Pow(s.price-mean, 2) * s.duration.Seconds() }) / total
+	return math.Sqrt(variance) / mean
+}
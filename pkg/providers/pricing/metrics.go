@@ -0,0 +1,60 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pricing
+
+import (
+	opmetrics "github.com/awslabs/operatorpkg/metrics"
+	"github.com/prometheus/client_golang/prometheus"
+	crmetrics "sigs.k8s.io/controller-runtime/pkg/metrics"
+
+	"sigs.k8s.io/karpenter/pkg/metrics"
+)
+
+const (
+	cloudProviderSubsystem = "cloudprovider"
+	instanceTypeLabel      = "instance_type"
+	zoneLabel              = "zone"
+	operatingSystemLabel   = "operating_system"
+	capacityTypeLabel      = "capacity_type"
+)
+
+var (
+	SpotPriceVolatility = opmetrics.NewPrometheusGauge(
+		crmetrics.Registry,
+		prometheus.GaugeOpts{
+			Namespace: metrics.Namespace,
+			Subsystem: cloudProviderSubsystem,
+			Name:      "spot_price_volatility",
+			Help:      "The volatility of the Linux spot price within the spot price window, as the time-weighted standard deviation of the spot price divided by its time-weighted mean, based on instance type and zone.",
+		},
+		[]string{
+			instanceTypeLabel,
+			zoneLabel,
+		},
+	)
+	PriceFallbacksTotal = opmetrics.NewPrometheusCounter(
+		crmetrics.Registry,
+		prometheus.CounterOpts{
+			Namespace: metrics.Namespace,
+			Subsystem: cloudProviderSubsystem,
+			Name:      "price_fallbacks_total",
+			Help:      "The number of prices of licensed operating systems that were substituted because the prices of the operating system haven't been retrieved, based on operating system and capacity type. On-demand prices fall back to the Linux on-demand price and spot prices fall back to the on-demand price of the operating system.",
+		},
+		[]string{
+			operatingSystemLabel,
+			capacityTypeLabel,
+		},
+	)
+)
@@ -0,0 +1,63 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pricing
+
+import (
+	"strings"
+
+	v1 "github.com/aws/karpenter-provider-aws/pkg/apis/v1"
+)
+
+// OperatingSystem is the operating system, including its license, that an instance is priced for. The price of an
+// instance type depends on its operating system, since licensed operating systems such as Windows are billed as part of
+// the instance's hourly price.
+type OperatingSystem string
+
+const (
+	OperatingSystemLinux   OperatingSystem = "Linux"
+	OperatingSystemWindows OperatingSystem = "Windows"
+)
+
+// OperatingSystems are the operating systems that prices are tracked for
+var OperatingSystems = []OperatingSystem{OperatingSystemLinux, OperatingSystemWindows}
+
+// OperatingSystemForAMIFamily returns the operating system that instances launched with the AMI family are priced for.
+// Custom AMIs are priced as Linux, since their operating system can't be inferred from the AMI family.
+func OperatingSystemForAMIFamily(amiFamily string) OperatingSystem {
+	switch amiFamily {
+	case v1.AMIFamilyWindows2019, v1.AMIFamilyWindows2022:
+		return OperatingSystemWindows
+	default:
+		return OperatingSystemLinux
+	}
+}
+
+// productDescriptions returns the spot price history product descriptions of the operating system
+func (o OperatingSystem) productDescriptions() []string {
+	switch o {
+	case OperatingSystemWindows:
+		return []string{"Windows", "Windows (Amazon VPC)"}
+	default:
+		return []string{"Linux/UNIX", "Linux/UNIX (Amazon VPC)"}
+	}
+}
+
+// operatingSystemForProductDescription returns the operating system of a spot price history product description
+func operatingSystemForProductDescription(productDescription string) OperatingSystem {
+	if strings.HasPrefix(productDescription, string(OperatingSystemWindows)) {
+		return OperatingSystemWindows
+	}
+	return OperatingSystemLinux
+}
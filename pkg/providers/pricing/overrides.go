@@ -0,0 +1,165 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pricing
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	karpv1 "sigs.k8s.io/karpenter/pkg/apis/v1"
+	"sigs.k8s.io/yaml"
+)
+
+// OverridesKey is the key of the ConfigMap's data that holds the pricing overrides
+const OverridesKey = "overrides"
+
+// Override adjusts the prices of the instance types that it selects, to reflect the price that is effectively paid for
+// them under private pricing, Savings Plans or Reserved Instances. An override selects a single instance type, an
+// instance family or, when neither is set, every instance type. It can be further restricted to a capacity type and a
+// region. Exactly one of Multiplier or Price must be set.
+type Override struct {
+	InstanceType   string `json:"instanceType,omitempty"`
+	InstanceFamily string `json:"instanceFamily,omitempty"`
+	CapacityType   string `json:"capacityType,omitempty"`
+	Region         string `json:"region,omitempty"`
+	// Multiplier is applied to the public price, e.g. 0.72 for a 28% discount
+	Multiplier *float64 `json:"multiplier,omitempty"`
+	// Price replaces the public price with an absolute hourly price
+	Price *float64 `json:"price,omitempty"`
+}
+
+func (o Override) validate() error {
+	if o.InstanceType != "" && o.InstanceFamily != "" {
+		return fmt.Errorf("only one of instanceType or instanceFamily may be set")
+	}
+	if o.CapacityType != "" && o.CapacityType != karpv1.CapacityTypeOnDemand && o.CapacityType != karpv1.CapacityTypeSpot {
+		return fmt.Errorf("capacityType must be one of %q or %q", karpv1.CapacityTypeOnDemand, karpv1.CapacityTypeSpot)
+	}
+	if (o.Multiplier == nil) == (o.Price == nil) {
+		return fmt.Errorf("exactly one of multiplier or price must be set")
+	}
+	if o.Multiplier != nil && *o.Multiplier < 0 {
+		return fmt.Errorf("multiplier must not be negative")
+	}
+	if o.Price != nil && *o.Price < 0 {
+		return fmt.Errorf("price must not be negative")
+	}
+	return nil
+}
+
+// matches returns whether the override applies to the instance type's price for the capacity type in the region
+func (o Override) matches(instanceType ec2types.InstanceType, capacityType, region string) bool {
+	family, _, _ := strings.Cut(string(instanceType), ".")
+	return (o.InstanceType == "" || o.InstanceType == string(instanceType)) &&
+		(o.InstanceFamily == "" || o.InstanceFamily == family) &&
+		(o.CapacityType == "" || o.CapacityType == capacityType) &&
+		(o.Region == "" || o.Region == region)
+}
+
+// specificity ranks overrides so that the most specific override for a price is the one that's applied. The instance
+// selector takes precedence over the capacity type, which takes precedence over the region.
+func (o Override) specificity() int {
+	s := 0
+	switch {
+	case o.InstanceType != "":
+		s += 4
+	case o.InstanceFamily != "":
+		s += 2
+	}
+	if o.CapacityType != "" {
+		s++
+	}
+	s *= 2
+	if o.Region != "" {
+		s++
+	}
+	return s
+}
+
+func (o Override) apply(price float64) float64 {
+	if o.Price != nil {
+		return *o.Price
+	}
+	return price * *o.Multiplier
+}
+
+// applyOverrides returns the effective price of the instance type for the capacity type, using the most specific
+// matching override. When several overrides are equally specific, the last one wins.
+func applyOverrides(overrides []Override, instanceType ec2types.InstanceType, capacityType, region string, price float64) float64 {
+	var match *Override
+	for i := range overrides {
+		if !overrides[i].matches(instanceType, capacityType, region) {
+			continue
+		}
+		if match == nil || overrides[i].specificity() >= match.specificity() {
+			match = &overrides[i]
+		}
+	}
+	if match == nil {
+		return price
+	}
+	return match.apply(price)
+}
+
+// ParseOverrides parses and validates a YAML or JSON list of overrides
+func ParseOverrides(data string) ([]Override, error) {
+	var overrides []Override
+	if err := yaml.UnmarshalStrict([]byte(data), &overrides); err != nil {
+		return nil, fmt.Errorf("parsing pricing overrides, %w", err)
+	}
+	for i, o := range overrides {
+		if err := o.validate(); err != nil {
+			return nil, fmt.Errorf("validating pricing override %d, %w", i, err)
+		}
+	}
+	return overrides, nil
+}
+
+type OverridesProvider interface {
+	Overrides(context.Context) ([]Override, error)
+}
+
+// ConfigMapOverridesProvider reads pricing overrides from a ConfigMap. A missing ConfigMap, or a ConfigMap without
+// overrides, results in no overrides so that the public prices are used.
+type ConfigMapOverridesProvider struct {
+	kubeClient client.Client
+	namespace  string
+	name       string
+}
+
+func NewConfigMapOverridesProvider(kubeClient client.Client, namespace, name string) *ConfigMapOverridesProvider {
+	return &ConfigMapOverridesProvider{
+		kubeClient: kubeClient,
+		namespace:  namespace,
+		name:       name,
+	}
+}
+
+func (p *ConfigMapOverridesProvider) Overrides(ctx context.Context) ([]Override, error) {
+	cm := &corev1.ConfigMap{}
+	if err := p.kubeClient.Get(ctx, types.NamespacedName{Namespace: p.namespace, Name: p.name}, cm); err != nil {
+		if errors.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("getting configmap, %w", err)
+	}
+	return ParseOverrides(cm.Data[OverridesKey])
+}
@@ -23,7 +23,7 @@ import (
 	"strconv"
 	"strings"
 	"sync"
-	"time"
+	"sync/atomic"
 
 	"sigs.k8s.io/controller-runtime/pkg/log"
 
@@ -37,6 +37,9 @@ import (
 	pricingtypes "github.com/aws/aws-sdk-go-v2/service/pricing/types"
 	"github.com/samber/lo"
 	"go.uber.org/multierr"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/utils/clock"
+	karpv1 "sigs.k8s.io/karpenter/pkg/apis/v1"
 	"sigs.k8s.io/karpenter/pkg/utils/pretty"
 )
 
@@ -45,8 +48,14 @@ var initialOnDemandPrices = lo.Assign(InitialOnDemandPricesAWS, InitialOnDemandP
 type Provider interface {
 	LivenessProbe(*http.Request) error
 	InstanceTypes() []ec2types.InstanceType
-	OnDemandPrice(ec2types.InstanceType) (float64, bool)
-	SpotPrice(ec2types.InstanceType, string) (float64, bool)
+	OnDemandPrice(OperatingSystem, ec2types.InstanceType) (float64, bool)
+	ZonalOnDemandPrice(OperatingSystem, ec2types.InstanceType, string) (float64, bool)
+	SpotPrice(OperatingSystem, ec2types.InstanceType, string) (float64, bool)
+	RawZonalOnDemandPrice(OperatingSystem, ec2types.InstanceType, string) (float64, bool)
+	RawSpotPrice(OperatingSystem, ec2types.InstanceType, string) (float64, bool)
+	SetOverrides(context.Context, []Override)
+	SetSnapshot(context.Context, Snapshot) error
+	OverridesSeqNum() uint64
 	UpdateOnDemandPricing(context.Context) error
 	UpdateSpotPricing(context.Context) error
 }
@@ -57,18 +66,51 @@ type Provider interface {
 // relative ordering that is still more accurate than our previous pricing model.  In the event that a pricing update
 // fails, the previous pricing information is retained and used which may be the static initial pricing data if pricing
 // updates never succeed.
+//
+// Prices can be adjusted with overrides to reflect the price that is effectively paid for an instance type, which may
+// differ from its public price under private pricing, Savings Plans or Reserved Instances. The OnDemandPrice,
+// ZonalOnDemandPrice and SpotPrice methods return effective prices, while the Raw methods return public prices.
+//
+// Prices are tracked per operating system, since licensed operating systems such as Windows are more expensive than
+// Linux. The static pricing data only covers Linux, so until the prices of another operating system are retrieved its
+// on-demand prices fall back to the Linux prices and its spot prices fall back to its on-demand prices. Fallback prices
+// are counted by the PriceFallbacksTotal metric. Once the prices of an operating system are retrieved, instance types
+// without a price for it aren't priced.
+//
+// The static pricing data can be replaced with a more recent snapshot of the on-demand prices, which keeps the prices
+// current in isolated VPCs where the pricing API can't be reached.
+//
+// Spot prices are aggregated from the spot price history within a rolling window, so that offerings can be priced at
+// an average or percentile of the spot price rather than only at the most recent spot price.
 type DefaultProvider struct {
 	ec2     sdk.EC2API
 	pricing sdk.PricingAPI
 	region  string
+	clk     clock.Clock
 	cm      *pretty.ChangeMonitor
 
 	muOnDemand     sync.RWMutex
-	onDemandPrices map[ec2types.InstanceType]float64
-
-	muSpot             sync.RWMutex
-	spotPrices         map[ec2types.InstanceType]zonal
+	onDemandPrices map[OperatingSystem]map[ec2types.InstanceType]float64
+	// onDemandPricingUpdated contains the operating systems whose on-demand prices have been retrieved, until which the
+	// static prices or the pricing snapshot are used
+	onDemandPricingUpdated sets.Set[OperatingSystem]
+	// zonalOnDemandPrices contains the on-demand prices of zones which are priced separately from the region, such as
+	// Local Zones and Wavelength Zones, keyed by zone name
+	zonalOnDemandPrices map[OperatingSystem]map[string]map[ec2types.InstanceType]float64
+
+	muSpot     sync.RWMutex
+	spotPrices map[OperatingSystem]map[ec2types.InstanceType]zonal
+	// spotPriceHistory contains the spot prices within the spot price window per instance type and zone, from which
+	// the spot prices are aggregated
+	spotPriceHistory map[OperatingSystem]map[ec2types.InstanceType]map[string][]pricePoint
+	// spotPricingUpdated is set once spot prices have been retrieved, until which the Linux spot prices default to the
+	// on-demand prices
 	spotPricingUpdated bool
+
+	muOverrides sync.RWMutex
+	overrides   []Override
+	// overridesSeqNum is incremented whenever the overrides change, so that cached prices can be invalidated
+	overridesSeqNum uint64
 }
 
 // zonalPricing is used to capture the per-zone price
@@ -105,11 +147,12 @@ func NewAPI(cfg aws.Config) *pricing.Client {
 	return pricing.NewFromConfig(pricingCfg)
 }
 
-func NewDefaultProvider(_ context.Context, pricing sdk.PricingAPI, ec2Api sdk.EC2API, region string) *DefaultProvider {
+func NewDefaultProvider(_ context.Context, pricing sdk.PricingAPI, ec2Api sdk.EC2API, region string, clk clock.Clock) *DefaultProvider {
 	p := &DefaultProvider{
 		region:  region,
 		ec2:     ec2Api,
 		pricing: pricing,
+		clk:     clk,
 		cm:      pretty.NewChangeMonitor(),
 	}
 	// sets the pricing data from the static default state for the provider
@@ -124,31 +167,81 @@ func (p *DefaultProvider) InstanceTypes() []ec2types.InstanceType {
 	p.muSpot.RLock()
 	defer p.muOnDemand.RUnlock()
 	defer p.muSpot.RUnlock()
-	return lo.Union(lo.Keys(p.onDemandPrices), lo.Keys(p.spotPrices))
+	return lo.Union(lo.Keys(p.onDemandPrices[OperatingSystemLinux]), lo.Keys(p.spotPrices[OperatingSystemLinux]))
 }
 
-// OnDemandPrice returns the last known on-demand price for a given instance type, returning an error if there is no
-// known on-demand pricing for the instance type.
-func (p *DefaultProvider) OnDemandPrice(instanceType ec2types.InstanceType) (float64, bool) {
-	p.muOnDemand.RLock()
-	defer p.muOnDemand.RUnlock()
-	price, ok := p.onDemandPrices[instanceType]
+// OnDemandPrice returns the effective on-demand price for a given instance type and operating system, returning an
+// error if there is no known on-demand pricing for the instance type.
+func (p *DefaultProvider) OnDemandPrice(osName OperatingSystem, instanceType ec2types.InstanceType) (float64, bool) {
+	price, ok := p.rawOnDemandPrice(osName, instanceType, "")
+	if !ok {
+		return 0.0, false
+	}
+	return p.effectivePrice(instanceType, karpv1.CapacityTypeOnDemand, price), true
+}
+
+// ZonalOnDemandPrice returns the effective on-demand price for a given instance type in a zone. Zones that aren't priced
+// separately from the region, such as regular availability zones, and instance types without a price in the zone fall
+// back to the regional on-demand price.
+func (p *DefaultProvider) ZonalOnDemandPrice(osName OperatingSystem, instanceType ec2types.InstanceType, zone string) (float64, bool) {
+	price, ok := p.RawZonalOnDemandPrice(osName, instanceType, zone)
 	if !ok {
 		return 0.0, false
 	}
-	return price, true
+	return p.effectivePrice(instanceType, karpv1.CapacityTypeOnDemand, price), true
 }
 
-// SpotPrice returns the last known spot price for a given instance type and zone, returning an error
+// SpotPrice returns the effective spot price for a given instance type and zone, returning an error
 // if there is no known spot pricing for that instance type or zone
-func (p *DefaultProvider) SpotPrice(instanceType ec2types.InstanceType, zone string) (float64, bool) {
+func (p *DefaultProvider) SpotPrice(osName OperatingSystem, instanceType ec2types.InstanceType, zone string) (float64, bool) {
+	price, ok := p.RawSpotPrice(osName, instanceType, zone)
+	if !ok {
+		return 0.0, false
+	}
+	return p.effectivePrice(instanceType, karpv1.CapacityTypeSpot, price), true
+}
+
+// RawZonalOnDemandPrice returns the last known public on-demand price for a given instance type in a zone, without
+// applying any overrides
+func (p *DefaultProvider) RawZonalOnDemandPrice(osName OperatingSystem, instanceType ec2types.InstanceType, zone string) (float64, bool) {
+	return p.rawOnDemandPrice(osName, instanceType, zone)
+}
+
+// rawOnDemandPrice returns the last known public on-demand price for an instance type in a zone, or in the region if the
+// zone doesn't have a price for the instance type. Operating systems whose prices haven't been retrieved fall back to the Linux price.
+func (p *DefaultProvider) rawOnDemandPrice(osName OperatingSystem, instanceType ec2types.InstanceType, zone string) (float64, bool) {
+	p.muOnDemand.RLock()
+	defer p.muOnDemand.RUnlock()
+	lookup := func(o OperatingSystem) (float64, bool) {
+		if price, ok := p.zonalOnDemandPrices[o][zone][instanceType]; ok {
+			return price, true
+		}
+		price, ok := p.onDemandPrices[o][instanceType]
+		return price, ok
+	}
+	if _, ok := p.onDemandPrices[osName]; ok {
+		return lookup(osName)
+	}
+	price, ok := lookup(OperatingSystemLinux)
+	if ok {
+		PriceFallbacksTotal.Inc(map[string]string{operatingSystemLabel: string(osName), capacityTypeLabel: karpv1.CapacityTypeOnDemand})
+	}
+	return price, ok
+}
+
+// RawSpotPrice returns the last known spot price for a given instance type and zone, without applying any overrides
+func (p *DefaultProvider) RawSpotPrice(osName OperatingSystem, instanceType ec2types.InstanceType, zone string) (float64, bool) {
+	if osName != OperatingSystemLinux {
+		return p.rawLicensedSpotPrice(osName, instanceType, zone)
+	}
 	p.muSpot.RLock()
 	defer p.muSpot.RUnlock()
-	if val, ok := p.spotPrices[instanceType]; ok {
-		if !p.spotPricingUpdated {
+	if val, ok := p.spotPrices[OperatingSystemLinux][instanceType]; ok {
+		// the on-demand price is used until spot prices are retrieved, unless the pricing snapshot has spot prices
+		if !p.spotPricingUpdated && len(val.prices) == 0 {
 			return val.defaultPrice, true
 		}
-		if price, ok := p.spotPrices[instanceType].prices[zone]; ok {
+		if price, ok := val.prices[zone]; ok {
 			return price, true
 		}
 		return 0.0, false
@@ -156,12 +249,139 @@ func (p *DefaultProvider) SpotPrice(instanceType ec2types.InstanceType, zone str
 	return 0.0, false
 }
 
-func (p *DefaultProvider) UpdateOnDemandPricing(ctx context.Context) error {
-	// standard on-demand instances
-	var wg sync.WaitGroup
-	var onDemandPrices, onDemandMetalPrices map[ec2types.InstanceType]float64
-	var onDemandErr, onDemandMetalErr error
+// rawLicensedSpotPrice returns the last known spot price for an instance type and zone with a licensed operating system.
+// The Linux spot price doesn't include the license, so until spot prices are retrieved the on-demand price that the
+// instance type is offered at with the operating system, which the spot price can't exceed, is returned instead.
+func (p *DefaultProvider) rawLicensedSpotPrice(osName OperatingSystem, instanceType ec2types.InstanceType, zone string) (float64, bool) {
+	p.muSpot.RLock()
+	price, ok := p.spotPrices[osName][instanceType].prices[zone]
+	spotPricingUpdated := p.spotPricingUpdated
+	p.muSpot.RUnlock()
+	if ok {
+		return price, true
+	}
+	// the spot price history includes every spot offering, so the instance type isn't offered as spot in the zone
+	if spotPricingUpdated {
+		return 0.0, false
+	}
+	price, ok = p.RawZonalOnDemandPrice(osName, instanceType, zone)
+	if ok {
+		PriceFallbacksTotal.Inc(map[string]string{operatingSystemLabel: string(osName), capacityTypeLabel: karpv1.CapacityTypeSpot})
+	}
+	return price, ok
+}
+
+// SetOverrides replaces the overrides that are applied to the public prices
+func (p *DefaultProvider) SetOverrides(ctx context.Context, overrides []Override) {
+	p.muOverrides.Lock()
+	defer p.muOverrides.Unlock()
+	p.overrides = overrides
+	if p.cm.HasChanged("pricing-overrides", overrides) {
+		atomic.AddUint64(&p.overridesSeqNum, 1)
+		log.FromContext(ctx).WithValues("override-count", len(overrides)).V(1).Info("updated pricing overrides")
+	}
+}
+
+// SetSnapshot replaces the static on-demand prices with the region's prices from the snapshot, or restores the static
+// prices if there's no snapshot. The snapshot's on-demand prices of each operating system are used until that operating
+// system's on-demand prices have been retrieved from the pricing API, which never happens in an isolated VPC. Its spot
+// prices are used until spot prices have been retrieved, and the spot prices of instance types without a spot price in
+// the snapshot default to their on-demand prices.
+func (p *DefaultProvider) SetSnapshot(ctx context.Context, snapshot Snapshot) error {
+	prices := RegionSnapshot{OnDemand: map[OperatingSystem]map[ec2types.InstanceType]float64{OperatingSystemLinux: p.staticOnDemandPrices()}}
+	if snapshot != nil {
+		var ok bool
+		if prices, ok = snapshot[p.region]; !ok || len(prices.OnDemand[OperatingSystemLinux]) == 0 {
+			return fmt.Errorf("pricing snapshot doesn't contain %s on-demand prices for %q", OperatingSystemLinux, p.region)
+		}
+	}
+	p.muOnDemand.Lock()
+	defer p.muOnDemand.Unlock()
+	p.muSpot.Lock()
+	defer p.muSpot.Unlock()
+	// The snapshot is copied rather than stored, so that retrieving prices doesn't modify the caller's snapshot, and is
+	// merged per operating system, so that prices which have been retrieved aren't replaced
+	for osName := range p.onDemandPrices {
+		if _, ok := prices.OnDemand[osName]; !ok && !p.onDemandPricingUpdated.Has(osName) {
+			delete(p.onDemandPrices, osName)
+		}
+	}
+	for osName, instanceTypes := range prices.OnDemand {
+		if !p.onDemandPricingUpdated.Has(osName) {
+			p.onDemandPrices[osName] = maps.Clone(instanceTypes)
+		}
+	}
+	if !p.spotPricingUpdated {
+		p.spotPrices = map[OperatingSystem]map[ec2types.InstanceType]zonal{OperatingSystemLinux: populateInitialSpotPricing(prices.OnDemand[OperatingSystemLinux])}
+		for osName, instanceTypes := range prices.Spot {
+			if _, ok := p.spotPrices[osName]; !ok {
+				p.spotPrices[osName] = map[ec2types.InstanceType]zonal{}
+			}
+			for it, zones := range instanceTypes {
+				if _, ok := p.spotPrices[osName][it]; !ok {
+					p.spotPrices[osName][it] = newZonalPricing(0)
+				}
+				maps.Copy(p.spotPrices[osName][it].prices, zones)
+			}
+		}
+	}
+	if p.cm.HasChanged("pricing-snapshot", prices) {
+		log.FromContext(ctx).WithValues("instance-type-count", len(prices.OnDemand[OperatingSystemLinux]), "snapshot", snapshot != nil).V(1).Info("updated static pricing")
+	}
+	return nil
+}
+
+// Snapshot returns the region's current on-demand and spot prices of every operating system in the format of a pricing
+// snapshot
+func (p *DefaultProvider) Snapshot() Snapshot {
+	p.muOnDemand.RLock()
+	p.muSpot.RLock()
+	defer p.muOnDemand.RUnlock()
+	defer p.muSpot.RUnlock()
+	prices := RegionSnapshot{
+		OnDemand: map[OperatingSystem]map[ec2types.InstanceType]float64{},
+		Spot:     map[OperatingSystem]map[ec2types.InstanceType]map[string]float64{},
+	}
+	for osName, instanceTypes := range p.onDemandPrices {
+		prices.OnDemand[osName] = maps.Clone(instanceTypes)
+	}
+	for osName, instanceTypes := range p.spotPrices {
+		for it, z := range instanceTypes {
+			if len(z.prices) == 0 {
+				continue
+			}
+			if _, ok := prices.Spot[osName]; !ok {
+				prices.Spot[osName] = map[ec2types.InstanceType]map[string]float64{}
+			}
+			prices.Spot[osName][it] = maps.Clone(z.prices)
+		}
+	}
+	return Snapshot{p.region: prices}
+}
 
+// ServeHTTP writes the region's current prices as a JSON pricing snapshot, which hack/tools/pricing_snapshot exports for
+// use by clusters that can't reach the pricing API
+func (p *DefaultProvider) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	data, err := MarshalSnapshot(p.Snapshot())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write(data)
+}
+
+func (p *DefaultProvider) OverridesSeqNum() uint64 {
+	return atomic.LoadUint64(&p.overridesSeqNum)
+}
+
+func (p *DefaultProvider) effectivePrice(instanceType ec2types.InstanceType, capacityType string, price float64) float64 {
+	p.muOverrides.RLock()
+	defer p.muOverrides.RUnlock()
+	return applyOverrides(p.overrides, instanceType, capacityType, p.region, price)
+}
+
+func (p *DefaultProvider) UpdateOnDemandPricing(ctx context.Context) error {
 	// if we are in isolated vpc, skip updating on demand pricing
 	// as pricing api may not be available
 	if options.FromContext(ctx).IsolatedVPC {
@@ -171,13 +391,44 @@ func (p *DefaultProvider) UpdateOnDemandPricing(ctx context.Context) error {
 		return nil
 	}
 
-	p.muOnDemand.Lock()
-	defer p.muOnDemand.Unlock()
+	// Operating systems are updated independently, so that failing to retrieve the prices of one operating system
+	// doesn't prevent the prices of the others from being updated. Prices are retrieved without holding the lock, so
+	// that prices can be read while they're being retrieved.
+	var errs error
+	for _, osName := range OperatingSystems {
+		prices, err := p.fetchRegionalOnDemandPricing(ctx, osName)
+		if err != nil {
+			errs = multierr.Append(errs, fmt.Errorf("retreiving %s on-demand pricing data, %w", osName, err))
+			continue
+		}
+		p.muOnDemand.Lock()
+		p.onDemandPrices[osName] = prices
+		p.onDemandPricingUpdated.Insert(osName)
+		p.muOnDemand.Unlock()
+		if p.cm.HasChanged(fmt.Sprintf("on-demand-prices-%s", osName), prices) {
+			log.FromContext(ctx).WithValues("operating-system", osName, "instance-type-count", len(prices)).V(1).Info("updated on-demand pricing")
+		}
+	}
+	// Zonal prices are updated even if the prices of an operating system can't be retrieved, and zones without pricing
+	// data are priced using their region's on-demand prices
+	if err := p.updateZonalOnDemandPricing(ctx); err != nil {
+		errs = multierr.Append(errs, fmt.Errorf("updating zonal on-demand pricing, %w", err))
+	}
+	return errs
+}
+
+// fetchRegionalOnDemandPricing retrieves the on-demand prices of the region for the operating system, including the
+// prices of bare metal instance types
+func (p *DefaultProvider) fetchRegionalOnDemandPricing(ctx context.Context, osName OperatingSystem) (map[ec2types.InstanceType]float64, error) {
+	// standard on-demand instances
+	var wg sync.WaitGroup
+	var onDemandPrices, onDemandMetalPrices map[ec2types.InstanceType]float64
+	var onDemandErr, onDemandMetalErr error
 
 	wg.Add(1)
 	go func() {
 		defer wg.Done()
-		onDemandPrices, onDemandErr = p.fetchOnDemandPricing(ctx,
+		onDemandPrices, onDemandErr = p.fetchOnDemandPricing(ctx, p.region, osName,
 			pricingtypes.Filter{
 				Field: aws.String("tenancy"),
 				Type:  "TERM_MATCH",
@@ -194,7 +445,7 @@ func (p *DefaultProvider) UpdateOnDemandPricing(ctx context.Context) error {
 	wg.Add(1)
 	go func() {
 		defer wg.Done()
-		onDemandMetalPrices, onDemandMetalErr = p.fetchOnDemandPricing(ctx,
+		onDemandMetalPrices, onDemandMetalErr = p.fetchOnDemandPricing(ctx, p.region, osName,
 			pricingtypes.Filter{
 				Field: aws.String("tenancy"),
 				Type:  "TERM_MATCH",
@@ -209,29 +460,72 @@ func (p *DefaultProvider) UpdateOnDemandPricing(ctx context.Context) error {
 
 	wg.Wait()
 
-	err := multierr.Append(onDemandErr, onDemandMetalErr)
-	if err != nil {
-		return fmt.Errorf("retreiving on-demand pricing data, %w", err)
+	if err := multierr.Append(onDemandErr, onDemandMetalErr); err != nil {
+		return nil, err
 	}
-
 	if len(onDemandPrices) == 0 || len(onDemandMetalPrices) == 0 {
-		return fmt.Errorf("no on-demand pricing found")
+		return nil, fmt.Errorf("no on-demand pricing found")
 	}
+	return lo.Assign(onDemandPrices, onDemandMetalPrices), nil
+}
 
-	p.onDemandPrices = lo.Assign(onDemandPrices, onDemandMetalPrices)
-	if p.cm.HasChanged("on-demand-prices", p.onDemandPrices) {
-		log.FromContext(ctx).WithValues("instance-type-count", len(p.onDemandPrices)).V(1).Info("updated on-demand pricing")
+// updateZonalOnDemandPricing retrieves the on-demand prices of the Local Zones and Wavelength Zones that are enabled for
+// the account. These zones belong to their own network border group, which the pricing API uses as their region code.
+func (p *DefaultProvider) updateZonalOnDemandPricing(ctx context.Context) error {
+	out, err := p.ec2.DescribeAvailabilityZones(ctx, &ec2.DescribeAvailabilityZonesInput{})
+	if err != nil {
+		return fmt.Errorf("describing availability zones, %w", err)
 	}
-	return nil
+	zonesByBorderGroup := map[string][]string{}
+	for _, zone := range out.AvailabilityZones {
+		if borderGroup := aws.ToString(zone.NetworkBorderGroup); borderGroup != "" && borderGroup != p.region {
+			zonesByBorderGroup[borderGroup] = append(zonesByBorderGroup[borderGroup], aws.ToString(zone.ZoneName))
+		}
+	}
+	zonalPrices := map[OperatingSystem]map[string]map[ec2types.InstanceType]float64{}
+	var errs error
+	for _, osName := range OperatingSystems {
+		zonalPrices[osName] = map[string]map[ec2types.InstanceType]float64{}
+		for borderGroup, zones := range zonesByBorderGroup {
+			prices, err := p.fetchOnDemandPricing(ctx, borderGroup, osName,
+				pricingtypes.Filter{
+					Field: aws.String("tenancy"),
+					Type:  "TERM_MATCH",
+					Value: aws.String("Shared"),
+				},
+				pricingtypes.Filter{
+					Field: aws.String("productFamily"),
+					Type:  "TERM_MATCH",
+					Value: aws.String("Compute Instance"),
+				})
+			if err != nil {
+				errs = multierr.Append(errs, fmt.Errorf("retrieving %s on-demand pricing data for %q, %w", osName, borderGroup, err))
+				continue
+			}
+			if len(prices) == 0 {
+				continue
+			}
+			for _, zone := range zones {
+				zonalPrices[osName][zone] = prices
+			}
+		}
+	}
+	p.muOnDemand.Lock()
+	p.zonalOnDemandPrices = zonalPrices
+	p.muOnDemand.Unlock()
+	if p.cm.HasChanged("zonal-on-demand-prices", zonalPrices) {
+		log.FromContext(ctx).WithValues("zones", lo.Keys(zonalPrices[OperatingSystemLinux])).V(1).Info("updated zonal on-demand pricing")
+	}
+	return errs
 }
 
-func (p *DefaultProvider) fetchOnDemandPricing(ctx context.Context, additionalFilters ...pricingtypes.Filter) (map[ec2types.InstanceType]float64, error) {
+func (p *DefaultProvider) fetchOnDemandPricing(ctx context.Context, regionCode string, osName OperatingSystem, additionalFilters ...pricingtypes.Filter) (map[ec2types.InstanceType]float64, error) {
 	prices := map[ec2types.InstanceType]float64{}
 	filters := append([]pricingtypes.Filter{
 		{
 			Field: aws.String("regionCode"),
 			Type:  "TERM_MATCH",
-			Value: aws.String(p.region),
+			Value: aws.String(regionCode),
 		},
 		{
 			Field: aws.String("serviceCode"),
@@ -246,7 +540,13 @@ func (p *DefaultProvider) fetchOnDemandPricing(ctx context.Context, additionalFi
 		{
 			Field: aws.String("operatingSystem"),
 			Type:  "TERM_MATCH",
-			Value: aws.String("Linux"),
+			Value: aws.String(string(osName)),
+		},
+		{
+			// exclude prices for bringing your own license, the license is included in the price of licensed operating systems
+			Field: aws.String("licenseModel"),
+			Type:  "TERM_MATCH",
+			Value: aws.String("No License required"),
 		},
 		{
 			Field: aws.String("capacitystatus"),
@@ -278,8 +578,8 @@ func (p *DefaultProvider) fetchOnDemandPricing(ctx context.Context, additionalFi
 	return prices, nil
 }
 
-func (p *DefaultProvider) spotPage(ctx context.Context, output *ec2.DescribeSpotPriceHistoryOutput) map[ec2types.InstanceType]zonal {
-	result := map[ec2types.InstanceType]zonal{}
+func (p *DefaultProvider) spotPage(ctx context.Context, output *ec2.DescribeSpotPriceHistoryOutput) map[OperatingSystem]map[ec2types.InstanceType]map[string][]pricePoint {
+	result := map[OperatingSystem]map[ec2types.InstanceType]map[string][]pricePoint{}
 	for _, sph := range output.SpotPriceHistory {
 		spotPriceStr := aws.ToString(sph.SpotPrice)
 		spotPrice, err := strconv.ParseFloat(spotPriceStr, 64)
@@ -293,14 +593,14 @@ func (p *DefaultProvider) spotPage(ctx context.Context, output *ec2.DescribeSpot
 		}
 		instanceType := sph.InstanceType
 		az := aws.ToString(sph.AvailabilityZone)
-		_, ok := result[instanceType]
-		if !ok {
-			result[instanceType] = zonal{
-				prices: map[string]float64{},
-			}
+		osName := operatingSystemForProductDescription(string(sph.ProductDescription))
+		if _, ok := result[osName]; !ok {
+			result[osName] = map[ec2types.InstanceType]map[string][]pricePoint{}
 		}
-		result[instanceType].prices[az] = spotPrice
-
+		if _, ok := result[osName][instanceType]; !ok {
+			result[osName][instanceType] = map[string][]pricePoint{}
+		}
+		result[osName][instanceType][az] = append(result[osName][instanceType][az], pricePoint{timestamp: *sph.Timestamp, price: spotPrice})
 	}
 	return result
 }
@@ -354,19 +654,21 @@ func (p *DefaultProvider) onDemandPage(ctx context.Context, output *pricing.GetP
 
 // nolint: gocyclo
 func (p *DefaultProvider) UpdateSpotPricing(ctx context.Context) error {
-	prices := map[ec2types.InstanceType]zonal{}
-
-	p.muSpot.Lock()
-	defer p.muSpot.Unlock()
+	points := map[OperatingSystem]map[ec2types.InstanceType]map[string][]pricePoint{}
+	aggregation := options.FromContext(ctx).SpotPriceAggregation
+	window := options.FromContext(ctx).SpotPriceWindow
+	now := p.clk.Now()
 
 	input := &ec2.DescribeSpotPriceHistoryInput{
-		ProductDescriptions: []string{
-			"Linux/UNIX",
-			"Linux/UNIX (Amazon VPC)",
-		},
-		// get the latest spot price for each instance type
-		StartTime: aws.Time(time.Now()),
+		ProductDescriptions: lo.FlatMap(OperatingSystems, func(osName OperatingSystem, _ int) []string { return osName.productDescriptions() }),
+		// get the current spot prices
+		StartTime: aws.Time(now),
+	}
+	if aggregation != SpotPriceAggregationLatest {
+		// get the spot price history within the window, which includes the spot price in effect at its start
+		input.StartTime = aws.Time(now.Add(-window))
 	}
+	// the spot price history is retrieved without holding the lock, so that prices can be read while it's retrieved
 
 	paginator := ec2.NewDescribeSpotPriceHistoryPaginator(p.ec2, input)
 	for paginator.HasMorePages() {
@@ -374,27 +676,64 @@ func (p *DefaultProvider) UpdateSpotPricing(ctx context.Context) error {
 		if err != nil {
 			return fmt.Errorf("retrieving spot pricing data, %w", err)
 		}
-		prices = lo.Assign(prices, p.spotPage(ctx, output))
+		for osName, page := range p.spotPage(ctx, output) {
+			if _, ok := points[osName]; !ok {
+				points[osName] = map[ec2types.InstanceType]map[string][]pricePoint{}
+			}
+			for it, zones := range page {
+				if _, ok := points[osName][it]; !ok {
+					points[osName][it] = map[string][]pricePoint{}
+				}
+				for zone, zonePoints := range zones {
+					points[osName][it][zone] = append(points[osName][it][zone], zonePoints...)
+				}
+			}
+		}
 	}
-	if len(prices) == 0 {
+	if len(points) == 0 {
 		return fmt.Errorf("no spot pricing found")
 	}
 
-	totalOfferings := 0
-	for it, zoneData := range prices {
-		if _, ok := p.spotPrices[it]; !ok {
-			p.spotPrices[it] = newZonalPricing(0)
+	p.muSpot.Lock()
+	defer p.muSpot.Unlock()
+
+	for osName, osPoints := range points {
+		if _, ok := p.spotPrices[osName]; !ok {
+			p.spotPrices[osName] = map[ec2types.InstanceType]zonal{}
+		}
+		if _, ok := p.spotPriceHistory[osName]; !ok {
+			p.spotPriceHistory[osName] = map[ec2types.InstanceType]map[string][]pricePoint{}
+		}
+		totalOfferings := 0
+		for it, zones := range osPoints {
+			if _, ok := p.spotPrices[osName][it]; !ok {
+				p.spotPrices[osName][it] = newZonalPricing(0)
+			}
+			if _, ok := p.spotPriceHistory[osName][it]; !ok {
+				p.spotPriceHistory[osName][it] = map[string][]pricePoint{}
+			}
+			for zone, zonePoints := range zones {
+				history := mergeHistory(p.spotPriceHistory[osName][it][zone], zonePoints, window, now)
+				p.spotPriceHistory[osName][it][zone] = history
+				p.spotPrices[osName][it].prices[zone] = aggregate(history, aggregation, window, now)
+				if osName == OperatingSystemLinux {
+					SpotPriceVolatility.Set(volatility(history, window, now), map[string]string{
+						instanceTypeLabel: string(it),
+						zoneLabel:         zone,
+					})
+				}
+			}
+			totalOfferings += len(zones)
+		}
+		if p.cm.HasChanged(fmt.Sprintf("spot-prices-%s", osName), p.spotPrices[osName]) {
+			log.FromContext(ctx).WithValues(
+				"operating-system", osName,
+				"instance-type-count", len(p.spotPrices[osName]),
+				"offering-count", totalOfferings,
+				"aggregation", aggregation).V(1).Info("updated spot pricing with instance types and offerings")
 		}
-		maps.Copy(p.spotPrices[it].prices, zoneData.prices)
-		totalOfferings += len(zoneData.prices)
 	}
-
 	p.spotPricingUpdated = true
-	if p.cm.HasChanged("spot-prices", p.spotPrices) {
-		log.FromContext(ctx).WithValues(
-			"instance-type-count", len(p.onDemandPrices),
-			"offering-count", totalOfferings).V(1).Info("updated spot pricing with instance types and offerings")
-	}
 	return nil
 }
 
@@ -416,16 +755,27 @@ func populateInitialSpotPricing(pricing map[ec2types.InstanceType]float64) map[e
 	return m
 }
 
-func (p *DefaultProvider) Reset() {
+func (p *DefaultProvider) staticOnDemandPrices() map[ec2types.InstanceType]float64 {
 	// see if we've got region specific pricing data
 	staticPricing, ok := initialOnDemandPrices[p.region]
 	if !ok {
 		// and if not, fall back to the always available us-east-1
 		staticPricing = initialOnDemandPrices["us-east-1"]
 	}
+	return staticPricing
+}
+
+func (p *DefaultProvider) Reset() {
+	staticPricing := p.staticOnDemandPrices()
 
-	p.onDemandPrices = staticPricing
+	// the static pricing data only covers Linux, other operating systems fall back to it until a price update
+	p.onDemandPrices = map[OperatingSystem]map[ec2types.InstanceType]float64{OperatingSystemLinux: staticPricing}
+	p.zonalOnDemandPrices = map[OperatingSystem]map[string]map[ec2types.InstanceType]float64{}
+	p.onDemandPricingUpdated = sets.New[OperatingSystem]()
 	// default our spot pricing to the same as the on-demand pricing until a price update
-	p.spotPrices = populateInitialSpotPricing(staticPricing)
+	p.spotPrices = map[OperatingSystem]map[ec2types.InstanceType]zonal{OperatingSystemLinux: populateInitialSpotPricing(staticPricing)}
+	p.spotPriceHistory = map[OperatingSystem]map[ec2types.InstanceType]map[string][]pricePoint{}
 	p.spotPricingUpdated = false
+	p.overrides = nil
+	atomic.AddUint64(&p.overridesSeqNum, 1)
 }
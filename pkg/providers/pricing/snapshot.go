@@ -0,0 +1,142 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pricing
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/samber/lo"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/yaml"
+)
+
+// SnapshotKey is the key of the ConfigMap's data that holds the pricing snapshot
+const SnapshotKey = "prices"
+
+// Snapshot contains the prices of instance types, keyed by region. This is the format that the pricing provider
+// exports its prices in, that hack/tools/pricing_snapshot retrieves from a running controller, and that
+// hack/code/prices_gen generates the static prices from.
+type Snapshot map[string]RegionSnapshot
+
+// RegionSnapshot contains the hourly prices of instance types in a region, keyed by operating system
+type RegionSnapshot struct {
+	// OnDemand contains the on-demand prices, keyed by operating system and instance type
+	OnDemand map[OperatingSystem]map[ec2types.InstanceType]float64 `json:"onDemand"`
+	// Spot contains the spot prices, keyed by operating system, instance type and zone
+	Spot map[OperatingSystem]map[ec2types.InstanceType]map[string]float64 `json:"spot,omitempty"`
+}
+
+// MarshalSnapshot encodes a pricing snapshot as JSON, which ParseSnapshot parses
+func MarshalSnapshot(snapshot Snapshot) ([]byte, error) {
+	data, err := json.MarshalIndent(snapshot, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("encoding pricing snapshot, %w", err)
+	}
+	return append(data, '\n'), nil
+}
+
+// ParseSnapshot parses and validates a YAML or JSON pricing snapshot
+func ParseSnapshot(data []byte) (Snapshot, error) {
+	var snapshot Snapshot
+	if err := yaml.UnmarshalStrict(data, &snapshot); err != nil {
+		return nil, fmt.Errorf("parsing pricing snapshot, %w", err)
+	}
+	for region, prices := range snapshot {
+		for osName, instanceTypes := range prices.OnDemand {
+			if !lo.Contains(OperatingSystems, osName) {
+				return nil, fmt.Errorf("validating pricing snapshot, unsupported operating system %q in %q", osName, region)
+			}
+			for instanceType, price := range instanceTypes {
+				if price < 0 {
+					return nil, fmt.Errorf("validating pricing snapshot, on-demand price of %q for %s in %q must not be negative", instanceType, osName, region)
+				}
+			}
+		}
+		for osName, instanceTypes := range prices.Spot {
+			if !lo.Contains(OperatingSystems, osName) {
+				return nil, fmt.Errorf("validating pricing snapshot, unsupported operating system %q in %q", osName, region)
+			}
+			for instanceType, zones := range instanceTypes {
+				for zone, price := range zones {
+					if price < 0 {
+						return nil, fmt.Errorf("validating pricing snapshot, spot price of %q for %s in %q must not be negative", instanceType, osName, zone)
+					}
+				}
+			}
+		}
+	}
+	return snapshot, nil
+}
+
+type SnapshotProvider interface {
+	Snapshot(context.Context) (Snapshot, error)
+}
+
+// FileSnapshotProvider reads a pricing snapshot from a file, such as a mounted ConfigMap. A missing file results in no
+// snapshot so that the static prices are used.
+type FileSnapshotProvider struct {
+	path string
+}
+
+func NewFileSnapshotProvider(path string) *FileSnapshotProvider {
+	return &FileSnapshotProvider{path: path}
+}
+
+func (p *FileSnapshotProvider) Snapshot(_ context.Context) (Snapshot, error) {
+	data, err := os.ReadFile(p.path)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("reading pricing snapshot, %w", err)
+	}
+	return ParseSnapshot(data)
+}
+
+// ConfigMapSnapshotProvider reads a pricing snapshot from a ConfigMap. A missing ConfigMap, or a ConfigMap without a
+// snapshot, results in no snapshot so that the static prices are used.
+type ConfigMapSnapshotProvider struct {
+	kubeClient client.Client
+	namespace  string
+	name       string
+}
+
+func NewConfigMapSnapshotProvider(kubeClient client.Client, namespace, name string) *ConfigMapSnapshotProvider {
+	return &ConfigMapSnapshotProvider{
+		kubeClient: kubeClient,
+		namespace:  namespace,
+		name:       name,
+	}
+}
+
+func (p *ConfigMapSnapshotProvider) Snapshot(ctx context.Context) (Snapshot, error) {
+	cm := &corev1.ConfigMap{}
+	if err := p.kubeClient.Get(ctx, types.NamespacedName{Namespace: p.namespace, Name: p.name}, cm); err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("getting configmap, %w", err)
+	}
+	return ParseSnapshot([]byte(cm.Data[SnapshotKey]))
+}
@@ -0,0 +1,58 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package servicequota
+
+import (
+	opmetrics "github.com/awslabs/operatorpkg/metrics"
+	"github.com/prometheus/client_golang/prometheus"
+	crmetrics "sigs.k8s.io/controller-runtime/pkg/metrics"
+
+	"sigs.k8s.io/karpenter/pkg/metrics"
+)
+
+const (
+	cloudProviderSubsystem = "cloudprovider"
+	instanceClassLabel     = "instance_class"
+	capacityTypeLabel      = "capacity_type"
+)
+
+var (
+	VCPUQuotaLimit = opmetrics.NewPrometheusGauge(
+		crmetrics.Registry,
+		prometheus.GaugeOpts{
+			Namespace: metrics.Namespace,
+			Subsystem: cloudProviderSubsystem,
+			Name:      "vcpu_quota_limit",
+			Help:      "The EC2 vCPU service quota of the account, based on instance class and capacity type.",
+		},
+		[]string{
+			instanceClassLabel,
+			capacityTypeLabel,
+		},
+	)
+	VCPUQuotaHeadroom = opmetrics.NewPrometheusGauge(
+		crmetrics.Registry,
+		prometheus.GaugeOpts{
+			Namespace: metrics.Namespace,
+			Subsystem: cloudProviderSubsystem,
+			Name:      "vcpu_quota_headroom",
+			Help:      "The vCPUs that can still be launched before the EC2 vCPU service quota is exceeded, based on instance class and capacity type. Only instances launched by Karpenter are counted towards the quota.",
+		},
+		[]string{
+			instanceClassLabel,
+			capacityTypeLabel,
+		},
+	)
+)
@@ -0,0 +1,263 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package servicequota
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/aws/aws-sdk-go-v2/service/servicequotas"
+	"github.com/patrickmn/go-cache"
+	"github.com/samber/lo"
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	karpv1 "sigs.k8s.io/karpenter/pkg/apis/v1"
+
+	sdk "github.com/aws/karpenter-provider-aws/pkg/aws"
+)
+
+const (
+	serviceCode = "ec2"
+
+	ClassStandard = "Standard"
+	ClassG        = "G and VT"
+	ClassP        = "P"
+	ClassX        = "X"
+	ClassF        = "F"
+	ClassInf      = "Inf"
+)
+
+var (
+	// quotaCodes are the codes of the EC2 vCPU quotas in Service Quotas, for each instance class and capacity type
+	quotaCodes = map[string]Key{
+		"L-1216C47A": {Class: ClassStandard, CapacityType: karpv1.CapacityTypeOnDemand},
+		"L-34B43A08": {Class: ClassStandard, CapacityType: karpv1.CapacityTypeSpot},
+		"L-DB2E81BA": {Class: ClassG, CapacityType: karpv1.CapacityTypeOnDemand},
+		"L-3819A6DF": {Class: ClassG, CapacityType: karpv1.CapacityTypeSpot},
+		"L-417A185B": {Class: ClassP, CapacityType: karpv1.CapacityTypeOnDemand},
+		"L-7212CCBC": {Class: ClassP, CapacityType: karpv1.CapacityTypeSpot},
+		"L-7295265B": {Class: ClassX, CapacityType: karpv1.CapacityTypeOnDemand},
+		"L-E3A00192": {Class: ClassX, CapacityType: karpv1.CapacityTypeSpot},
+		"L-74FC7D96": {Class: ClassF, CapacityType: karpv1.CapacityTypeOnDemand},
+		"L-88CF9481": {Class: ClassF, CapacityType: karpv1.CapacityTypeSpot},
+		"L-1945791B": {Class: ClassInf, CapacityType: karpv1.CapacityTypeOnDemand},
+		"L-B5D1601B": {Class: ClassInf, CapacityType: karpv1.CapacityTypeSpot},
+	}
+)
+
+// Key identifies a vCPU quota, which is shared by the instance families of an instance class for a capacity type
+type Key struct {
+	Class        string
+	CapacityType string
+}
+
+// Quota is the vCPU quota of an instance class and capacity type, along with the vCPUs of the instances that Karpenter
+// has launched which count towards it
+type Quota struct {
+	Key
+	Limit int64
+	Usage int64
+}
+
+// Headroom returns the vCPUs that can still be launched before the quota is exceeded
+func (q Quota) Headroom() int64 {
+	return q.Limit - q.Usage
+}
+
+type Provider interface {
+	UpdateQuotas(context.Context) error
+	UpdateUsage(context.Context, []*karpv1.NodeClaim)
+	Fits(instanceType string, capacityType string, vcpus int64) bool
+	MarkLaunched(instanceType string, capacityType string, vcpus int64)
+	Quotas() []Quota
+	SeqNum() uint64
+}
+
+// DefaultProvider tracks the EC2 vCPU quotas of the account, so that Karpenter doesn't launch instances that would
+// exceed them. Usage is tracked from the NodeClaims that Karpenter manages, so instances that are launched outside of
+// Karpenter aren't accounted for and EC2 may still reject a launch for exceeding a quota. Instance types whose instance
+// class doesn't have a known quota are never constrained.
+type DefaultProvider struct {
+	servicequotasapi sdk.ServiceQuotasAPI
+	cache            *cache.Cache
+
+	mu     sync.RWMutex
+	limits map[Key]int64
+	usage  map[Key]int64
+	// seqNum is a monotonically increasing change counter that's incremented whenever a quota or its usage changes
+	seqNum uint64
+}
+
+func NewDefaultProvider(servicequotasapi sdk.ServiceQuotasAPI, cache *cache.Cache) *DefaultProvider {
+	return &DefaultProvider{
+		servicequotasapi: servicequotasapi,
+		cache:            cache,
+		limits:           map[Key]int64{},
+		usage:            map[Key]int64{},
+	}
+}
+
+// UpdateQuotas refreshes the vCPU quotas from Service Quotas, once the previously retrieved quotas have expired
+func (p *DefaultProvider) UpdateQuotas(ctx context.Context) error {
+	if _, ok := p.cache.Get(serviceCode); ok {
+		return nil
+	}
+	limits := map[Key]int64{}
+	paginator := servicequotas.NewListServiceQuotasPaginator(p.servicequotasapi, &servicequotas.ListServiceQuotasInput{
+		ServiceCode: lo.ToPtr(serviceCode),
+	})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return fmt.Errorf("listing service quotas, %w", err)
+		}
+		for _, quota := range page.Quotas {
+			key, ok := quotaCodes[lo.FromPtr(quota.QuotaCode)]
+			if !ok || quota.Value == nil {
+				continue
+			}
+			limits[key] = int64(*quota.Value)
+		}
+	}
+	p.cache.SetDefault(serviceCode, struct{}{})
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if len(limits) != len(p.limits) || lo.SomeBy(lo.Keys(limits), func(k Key) bool { return limits[k] != p.limits[k] }) {
+		log.FromContext(ctx).WithValues("quotas", len(limits)).V(1).Info("discovered vcpu service quotas")
+		p.limits = limits
+		atomic.AddUint64(&p.seqNum, 1)
+	}
+	p.updateMetrics()
+	return nil
+}
+
+// UpdateUsage replaces the usage of each quota with the vCPUs of the NodeClaims that have been launched. This also
+// reconciles the usage that was added by MarkLaunched with the instances that were actually launched.
+func (p *DefaultProvider) UpdateUsage(_ context.Context, nodeClaims []*karpv1.NodeClaim) {
+	usage := map[Key]int64{}
+	for _, nodeClaim := range nodeClaims {
+		class := InstanceClass(nodeClaim.Labels[corev1.LabelInstanceTypeStable])
+		capacityType := nodeClaim.Labels[karpv1.CapacityTypeLabelKey]
+		if class == "" || capacityType == "" {
+			continue
+		}
+		usage[Key{Class: class, CapacityType: capacityType}] += nodeClaim.Status.Capacity.Cpu().Value()
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if len(usage) != len(p.usage) || lo.SomeBy(lo.Keys(usage), func(k Key) bool { return usage[k] != p.usage[k] }) {
+		p.usage = usage
+		atomic.AddUint64(&p.seqNum, 1)
+	}
+	p.updateMetrics()
+}
+
+// Fits returns false if launching an instance of the instance type with the capacity type would exceed the vCPU quota
+// of its instance class
+func (p *DefaultProvider) Fits(instanceType string, capacityType string, vcpus int64) bool {
+	key := Key{Class: InstanceClass(instanceType), CapacityType: capacityType}
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	limit, ok := p.limits[key]
+	if !ok {
+		return true
+	}
+	return p.usage[key]+vcpus <= limit
+}
+
+// MarkLaunched adds the vCPUs of a launched instance to the usage of its quota. Launches are only reflected in the
+// NodeClaims once they've been labeled, so this stops a burst of launches from exceeding a quota in the meantime.
+func (p *DefaultProvider) MarkLaunched(instanceType string, capacityType string, vcpus int64) {
+	key := Key{Class: InstanceClass(instanceType), CapacityType: capacityType}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if _, ok := p.limits[key]; !ok {
+		return
+	}
+	p.usage[key] += vcpus
+	atomic.AddUint64(&p.seqNum, 1)
+	p.updateMetrics()
+}
+
+// Quotas returns the known vCPU quotas along with their usage
+func (p *DefaultProvider) Quotas() []Quota {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return lo.MapToSlice(p.limits, func(key Key, limit int64) Quota {
+		return Quota{Key: key, Limit: limit, Usage: p.usage[key]}
+	})
+}
+
+func (p *DefaultProvider) SeqNum() uint64 {
+	return atomic.LoadUint64(&p.seqNum)
+}
+
+func (p *DefaultProvider) Reset() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.limits = map[Key]int64{}
+	p.usage = map[Key]int64{}
+	atomic.AddUint64(&p.seqNum, 1)
+	VCPUQuotaLimit.Reset()
+	VCPUQuotaHeadroom.Reset()
+}
+
+// updateMetrics must be called while holding the lock
+func (p *DefaultProvider) updateMetrics() {
+	VCPUQuotaLimit.Reset()
+	VCPUQuotaHeadroom.Reset()
+	for key, limit := range p.limits {
+		labels := map[string]string{instanceClassLabel: key.Class, capacityTypeLabel: key.CapacityType}
+		VCPUQuotaLimit.Set(float64(limit), labels)
+		VCPUQuotaHeadroom.Set(float64(limit-p.usage[key]), labels)
+	}
+}
+
+// InstanceClass returns the instance class of an instance type, which determines the vCPU quota that it counts
+// towards, or an empty string if its quota isn't tracked
+func InstanceClass(instanceType string) string {
+	family, _, _ := strings.Cut(instanceType, ".")
+	switch {
+	case family == "":
+		return ""
+	// Families whose prefix overlaps with another class, and families with their own quotas that aren't tracked
+	case strings.HasPrefix(family, "inf"):
+		return ClassInf
+	case strings.HasPrefix(family, "vt"):
+		return ClassG
+	case strings.HasPrefix(family, "dl"), strings.HasPrefix(family, "trn"), strings.HasPrefix(family, "hpc"),
+		strings.HasPrefix(family, "mac"), strings.HasPrefix(family, "u"):
+		return ""
+	}
+	switch family[0] {
+	case 'a', 'c', 'd', 'h', 'i', 'm', 'r', 't', 'z':
+		return ClassStandard
+	case 'g':
+		return ClassG
+	case 'p':
+		return ClassP
+	case 'x':
+		return ClassX
+	case 'f':
+		return ClassF
+	}
+	return ""
+}
@@ -0,0 +1,190 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package spotplacementscore
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	"github.com/patrickmn/go-cache"
+	"github.com/samber/lo"
+	"go.uber.org/multierr"
+	"k8s.io/utils/clock"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	"sigs.k8s.io/karpenter/pkg/utils/pretty"
+
+	sdk "github.com/aws/karpenter-provider-aws/pkg/aws"
+	awscache "github.com/aws/karpenter-provider-aws/pkg/cache"
+)
+
+const (
+	// LowScoreThreshold is the spot placement score below which a spot pool is considered unlikely to be fulfilled.
+	// Scores range from 1 to MaxScore, where a score of MaxScore indicates that a spot request is highly likely to succeed.
+	LowScoreThreshold int32 = 3
+	MaxScore          int32 = 10
+	// MaxQueries is the number of GetSpotPlacementScores queries made within QueryWindow. EC2 only allows an account to
+	// request scores for about 10 distinct configurations in a 24 hour period.
+	MaxQueries  = 10
+	QueryWindow = 24 * time.Hour
+	// RefreshInterval is how long the scores of a set of instance types are used for before they're requested again
+	RefreshInterval = 12 * time.Hour
+)
+
+type Provider interface {
+	UpdateScores(context.Context) error
+	Scores([]string) (map[string]int32, bool)
+}
+
+// DefaultProvider scores the sets of instance types that spot fleet requests are made for. A spot placement score
+// is the likelihood of a request for a single instance of any of the instance types in a set succeeding in a zone,
+// so scores are only ever known for the exact sets that were queried.
+type DefaultProvider struct {
+	sync.Mutex
+	region    string
+	ec2api    sdk.EC2API
+	clk       clock.Clock
+	cache     *cache.Cache
+	requested *cache.Cache
+	// queried is when each of the queries made within the last QueryWindow were made
+	queried []time.Time
+	cm      *pretty.ChangeMonitor
+}
+
+type scores struct {
+	zoneScores map[string]int32
+	updated    time.Time
+}
+
+type request struct {
+	instanceTypes []string
+	requested     time.Time
+}
+
+func NewDefaultProvider(region string, ec2api sdk.EC2API, clk clock.Clock, scoreCache *cache.Cache) *DefaultProvider {
+	return &DefaultProvider{
+		region:    region,
+		ec2api:    ec2api,
+		clk:       clk,
+		cache:     scoreCache,
+		requested: cache.New(awscache.SpotPlacementScoreTTL, awscache.DefaultCleanupInterval),
+		cm:        pretty.NewChangeMonitor(),
+	}
+}
+
+// UpdateScores requests the per-zone spot placement scores of the most recently requested sets of instance types that
+// haven't been scored within the RefreshInterval, for as long as fewer than MaxQueries queries were made within the
+// QueryWindow. The last known scores of a set whose query fails are kept.
+func (p *DefaultProvider) UpdateScores(ctx context.Context) error {
+	p.Lock()
+	defer p.Unlock()
+
+	p.queried = lo.Filter(p.queried, func(t time.Time, _ int) bool { return p.clk.Since(t) < QueryWindow })
+	var errs error
+	updated := map[string]map[string]int32{}
+	for _, instanceTypes := range p.mostRecentlyRequested() {
+		if len(p.queried) >= MaxQueries {
+			break
+		}
+		key := setKey(instanceTypes)
+		if cached, ok := p.cache.Get(key); ok && p.clk.Since(cached.(scores).updated) < RefreshInterval {
+			continue
+		}
+		p.queried = append(p.queried, p.clk.Now())
+		zoneScores, err := p.getScores(ctx, instanceTypes)
+		if err != nil {
+			errs = multierr.Append(errs, fmt.Errorf("getting spot placement scores for %s, %w", key, err))
+			continue
+		}
+		p.cache.SetDefault(key, scores{zoneScores: zoneScores, updated: p.clk.Now()})
+		updated[key] = zoneScores
+		for zoneID, score := range zoneScores {
+			SpotPlacementScore.Set(float64(score), map[string]string{
+				instanceTypesLabel: key,
+				zoneIDLabel:        zoneID,
+			})
+		}
+	}
+	if len(updated) != 0 && p.cm.HasChanged("spot-placement-scores", updated) {
+		log.FromContext(ctx).WithValues("count", len(updated)).V(1).Info("updated spot placement scores")
+	}
+	return errs
+}
+
+// Scores returns the per-zone spot placement scores of the set of instance types, if the set has been scored. The set
+// is recorded as requested so that it's scored, or its scores are kept up to date, by UpdateScores.
+func (p *DefaultProvider) Scores(instanceTypes []string) (map[string]int32, bool) {
+	instanceTypes = lo.Uniq(instanceTypes)
+	sort.Strings(instanceTypes)
+	key := setKey(instanceTypes)
+	p.requested.SetDefault(key, request{instanceTypes: instanceTypes, requested: p.clk.Now()})
+	cached, ok := p.cache.Get(key)
+	if !ok {
+		return nil, false
+	}
+	return cached.(scores).zoneScores, true
+}
+
+func (p *DefaultProvider) Reset() {
+	p.Lock()
+	defer p.Unlock()
+	p.requested.Flush()
+	p.queried = nil
+}
+
+// mostRecentlyRequested returns the requested sets of instance types, most recently requested first
+func (p *DefaultProvider) mostRecentlyRequested() [][]string {
+	requests := lo.MapToSlice(p.requested.Items(), func(_ string, item cache.Item) request { return item.Object.(request) })
+	sort.Slice(requests, func(i, j int) bool {
+		if !requests[i].requested.Equal(requests[j].requested) {
+			return requests[i].requested.After(requests[j].requested)
+		}
+		return setKey(requests[i].instanceTypes) < setKey(requests[j].instanceTypes)
+	})
+	return lo.Map(requests, func(r request, _ int) []string { return r.instanceTypes })
+}
+
+func setKey(instanceTypes []string) string {
+	return strings.Join(instanceTypes, ",")
+}
+
+func (p *DefaultProvider) getScores(ctx context.Context, instanceTypes []string) (map[string]int32, error) {
+	zoneScores := map[string]int32{}
+	paginator := ec2.NewGetSpotPlacementScoresPaginator(p.ec2api, &ec2.GetSpotPlacementScoresInput{
+		InstanceTypes:          instanceTypes,
+		TargetCapacity:         aws.Int32(1),
+		SingleAvailabilityZone: aws.Bool(true),
+		RegionNames:            []string{p.region},
+	})
+	for paginator.HasMorePages() {
+		out, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, score := range out.SpotPlacementScores {
+			if lo.FromPtr(score.Region) != p.region || score.AvailabilityZoneId == nil {
+				continue
+			}
+			zoneScores[lo.FromPtr(score.AvailabilityZoneId)] = lo.FromPtr(score.Score)
+		}
+	}
+	return zoneScores, nil
+}
@@ -19,10 +19,13 @@ import (
 	"encoding/json"
 	"fmt"
 	"strings"
+	"sync"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/credentials/stscreds"
 	"github.com/aws/aws-sdk-go-v2/service/sqs"
 	sqstypes "github.com/aws/aws-sdk-go-v2/service/sqs/types"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
 	"github.com/samber/lo"
 
 	sdk "github.com/aws/karpenter-provider-aws/pkg/aws"
@@ -38,22 +41,92 @@ type Provider interface {
 type DefaultProvider struct {
 	client sdk.SQSAPI
 
+	// queue is the name or URL of the queue, and queueURL is its URL once it's been resolved
+	queue    string
+	mu       sync.Mutex
 	queueURL string
 }
 
 func NewDefaultProvider(client sdk.SQSAPI, queueURL string) (*DefaultProvider, error) {
 	return &DefaultProvider{
 		client:   client,
+		queue:    queueURL,
 		queueURL: queueURL,
 	}, nil
 }
 
+// NewDefaultProviderForQueue returns a provider for a queue which is either the name of a queue in the controller's
+// account and region, or the URL of a queue in any account and region. The role is assumed to access the queue if
+// a role ARN is passed.
+func NewDefaultProviderForQueue(ctx context.Context, cfg aws.Config, queue string, roleARN string) (*DefaultProvider, error) {
+	p := NewUnresolvedProviderForQueue(cfg, queue, roleARN)
+	if _, err := p.url(ctx); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// NewUnresolvedProviderForQueue returns a provider for a queue like NewDefaultProviderForQueue, without resolving the
+// URL of a queue that's passed by name. The URL is resolved when the queue is first used, and resolving it is retried
+// each time that the queue is used until it succeeds.
+func NewUnresolvedProviderForQueue(cfg aws.Config, queue string, roleARN string) *DefaultProvider {
+	cfg = cfg.Copy()
+	if roleARN != "" {
+		cfg.Credentials = aws.NewCredentialsCache(stscreds.NewAssumeRoleProvider(sts.NewFromConfig(cfg), roleARN))
+	}
+	if !strings.HasPrefix(queue, "https://") {
+		return NewUnresolvedProvider(sqs.NewFromConfig(cfg), queue)
+	}
+	if region := regionFromQueueURL(queue); region != "" {
+		cfg.Region = region
+	}
+	return &DefaultProvider{client: sqs.NewFromConfig(cfg), queue: queue, queueURL: queue}
+}
+
+// NewUnresolvedProvider returns a provider for the queue with the name, whose URL is resolved when the queue is first used
+func NewUnresolvedProvider(client sdk.SQSAPI, queueName string) *DefaultProvider {
+	return &DefaultProvider{
+		client: client,
+		queue:  queueName,
+	}
+}
+
+// url returns the URL of the queue, resolving it from the name of the queue if it hasn't been resolved yet
+func (p *DefaultProvider) url(ctx context.Context) (string, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.queueURL != "" {
+		return p.queueURL, nil
+	}
+	out, err := p.client.GetQueueUrl(ctx, &sqs.GetQueueUrlInput{QueueName: aws.String(p.queue)})
+	if err != nil {
+		return "", fmt.Errorf("getting url of queue %q, %w", p.queue, err)
+	}
+	p.queueURL = aws.ToString(out.QueueUrl)
+	return p.queueURL, nil
+}
+
+// regionFromQueueURL returns the region of a queue URL such as https://sqs.us-west-2.amazonaws.com/000000000000/queue,
+// or an empty string if the URL doesn't contain one
+func regionFromQueueURL(queueURL string) string {
+	host := strings.Split(strings.TrimPrefix(queueURL, "https://"), "/")[0]
+	parts := strings.Split(host, ".")
+	if len(parts) < 3 || parts[0] != "sqs" {
+		return ""
+	}
+	return parts[1]
+}
+
 func (p *DefaultProvider) Name() string {
-	ss := strings.Split(p.queueURL, "/")
+	ss := strings.Split(p.queue, "/")
 	return ss[len(ss)-1]
 }
 
 func (p *DefaultProvider) GetSQSMessages(ctx context.Context) ([]*sqstypes.Message, error) {
+	queueURL, err := p.url(ctx)
+	if err != nil {
+		return nil, err
+	}
 	input := &sqs.ReceiveMessageInput{
 		MaxNumberOfMessages: int32(10),
 		VisibilityTimeout:   int32(20), // Seconds
@@ -64,7 +137,7 @@ func (p *DefaultProvider) GetSQSMessages(ctx context.Context) ([]*sqstypes.Messa
 		MessageAttributeNames: []string{
 			string(sqstypes.QueueAttributeNameAll),
 		},
-		QueueUrl: aws.String(p.queueURL),
+		QueueUrl: aws.String(queueURL),
 	}
 
 	result, err := p.client.ReceiveMessage(ctx, input)
@@ -76,13 +149,17 @@ func (p *DefaultProvider) GetSQSMessages(ctx context.Context) ([]*sqstypes.Messa
 }
 
 func (p *DefaultProvider) SendMessage(ctx context.Context, body interface{}) (string, error) {
+	queueURL, err := p.url(ctx)
+	if err != nil {
+		return "", err
+	}
 	raw, err := json.Marshal(body)
 	if err != nil {
 		return "", fmt.Errorf("marshaling the passed body as json, %w", err)
 	}
 	input := &sqs.SendMessageInput{
 		MessageBody: aws.String(string(raw)),
-		QueueUrl:    aws.String(p.queueURL),
+		QueueUrl:    aws.String(queueURL),
 	}
 	result, err := p.client.SendMessage(ctx, input)
 	if err != nil {
@@ -92,8 +169,12 @@ func (p *DefaultProvider) SendMessage(ctx context.Context, body interface{}) (st
 }
 
 func (p *DefaultProvider) DeleteSQSMessage(ctx context.Context, msg *sqstypes.Message) error {
+	queueURL, err := p.url(ctx)
+	if err != nil {
+		return err
+	}
 	input := &sqs.DeleteMessageInput{
-		QueueUrl:      aws.String(p.queueURL),
+		QueueUrl:      aws.String(queueURL),
 		ReceiptHandle: msg.ReceiptHandle,
 	}
 
@@ -44,7 +44,10 @@ type Provider interface {
 	LivenessProbe(*http.Request) error
 	List(context.Context, *v1.EC2NodeClass) ([]ec2types.Subnet, error)
 	ZonalSubnetsForLaunch(context.Context, *v1.EC2NodeClass, []*cloudprovider.InstanceType, string) (map[string]*Subnet, error)
-	UpdateInflightIPs(*ec2.CreateFleetInput, *ec2.CreateFleetOutput, []*cloudprovider.InstanceType, []*Subnet, string)
+	UpdateInflightIPs(*ec2.CreateFleetInput, *ec2.CreateFleetOutput, []*cloudprovider.InstanceType, []*Subnet, string, string)
+	AvailabilityZones(context.Context) (map[string]ec2types.AvailabilityZone, error)
+	MarkPrefixFragmented(string)
+	IsPrefixFragmented(string) bool
 }
 
 type DefaultProvider struct {
@@ -53,10 +56,17 @@ type DefaultProvider struct {
 	cache                         *cache.Cache
 	availableIPAddressCache       *cache.Cache
 	associatePublicIPAddressCache *cache.Cache
+	availabilityZoneCache         *cache.Cache
+	prefixFragmentedCache         *cache.Cache
 	cm                            *pretty.ChangeMonitor
 	inflightIPs                   map[string]int32
 }
 
+const availabilityZonesCacheKey = "availability-zones"
+
+// prefixSize is the number of addresses in the /28 prefixes that are delegated to network interfaces
+const prefixSize = 16
+
 type Subnet struct {
 	ID                      string
 	Zone                    string
@@ -64,7 +74,8 @@ type Subnet struct {
 	AvailableIPAddressCount int32
 }
 
-func NewDefaultProvider(ec2api sdk.EC2API, cache *cache.Cache, availableIPAddressCache *cache.Cache, associatePublicIPAddressCache *cache.Cache) *DefaultProvider {
+func NewDefaultProvider(ec2api sdk.EC2API, cache *cache.Cache, availableIPAddressCache *cache.Cache, associatePublicIPAddressCache *cache.Cache,
+	availabilityZoneCache *cache.Cache, prefixFragmentedCache *cache.Cache) *DefaultProvider {
 	return &DefaultProvider{
 		ec2api: ec2api,
 		cm:     pretty.NewChangeMonitor(),
@@ -73,6 +84,8 @@ func NewDefaultProvider(ec2api sdk.EC2API, cache *cache.Cache, availableIPAddres
 		cache:                         cache,
 		availableIPAddressCache:       availableIPAddressCache,
 		associatePublicIPAddressCache: associatePublicIPAddressCache,
+		availabilityZoneCache:         availabilityZoneCache,
+		prefixFragmentedCache:         prefixFragmentedCache,
 		// inflightIPs is used to track IPs from known launched instances
 		inflightIPs: map[string]int32{},
 	}
@@ -133,6 +146,7 @@ func (p *DefaultProvider) ZonalSubnetsForLaunch(ctx context.Context, nodeClass *
 	p.Lock()
 	defer p.Unlock()
 
+	ipAddressMode := nodeClass.IPAddressMode()
 	zonalSubnets := map[string]*Subnet{}
 	availableIPAddressCount := map[string]int32{}
 	for _, subnet := range nodeClass.Status.Subnets {
@@ -142,6 +156,9 @@ func (p *DefaultProvider) ZonalSubnetsForLaunch(ctx context.Context, nodeClass *
 	}
 
 	for _, subnet := range nodeClass.Status.Subnets {
+		if ipAddressMode == v1.IPAddressModePrefixDelegation && !p.hasPrefixAvailable(subnet.ID, availableIPAddressCount) {
+			continue
+		}
 		if v, ok := zonalSubnets[subnet.Zone]; ok {
 			currentZonalSubnetIPAddressCount := v.AvailableIPAddressCount
 			newZonalSubnetIPAddressCount := availableIPAddressCount[subnet.ID]
@@ -158,12 +175,18 @@ func (p *DefaultProvider) ZonalSubnetsForLaunch(ctx context.Context, nodeClass *
 		}
 		zonalSubnets[subnet.Zone] = &Subnet{ID: subnet.ID, Zone: subnet.Zone, ZoneID: subnet.ZoneID, AvailableIPAddressCount: availableIPAddressCount[subnet.ID]}
 	}
+	if len(zonalSubnets) == 0 {
+		if ipAddressMode == v1.IPAddressModePrefixDelegation {
+			return nil, fmt.Errorf("no subnets have a /28 prefix available for prefix delegation")
+		}
+		return nil, fmt.Errorf("no subnets are available for launch")
+	}
 
 	for _, subnet := range zonalSubnets {
-		predictedIPsUsed := p.minPods(instanceTypes, scheduling.NewRequirements(
+		predictedIPsUsed := predictedIPs(ipAddressMode, p.minPods(instanceTypes, scheduling.NewRequirements(
 			scheduling.NewRequirement(karpv1.CapacityTypeLabelKey, corev1.NodeSelectorOpIn, capacityType),
 			scheduling.NewRequirement(corev1.LabelTopologyZone, corev1.NodeSelectorOpIn, subnet.Zone),
-		))
+		)))
 		prevIPs := subnet.AvailableIPAddressCount
 		if trackedIPs, ok := p.inflightIPs[subnet.ID]; ok {
 			prevIPs = trackedIPs
@@ -175,7 +198,7 @@ func (p *DefaultProvider) ZonalSubnetsForLaunch(ctx context.Context, nodeClass *
 
 // UpdateInflightIPs is used to refresh the in-memory IP usage by adding back unused IPs after a CreateFleet response is returned
 func (p *DefaultProvider) UpdateInflightIPs(createFleetInput *ec2.CreateFleetInput, createFleetOutput *ec2.CreateFleetOutput, instanceTypes []*cloudprovider.InstanceType,
-	subnets []*Subnet, capacityType string) {
+	subnets []*Subnet, capacityType string, ipAddressMode string) {
 	p.Lock()
 	defer p.Unlock()
 
@@ -226,12 +249,41 @@ func (p *DefaultProvider) UpdateInflightIPs(createFleetInput *ec2.CreateFleetInp
 					scheduling.NewRequirement(karpv1.CapacityTypeLabelKey, corev1.NodeSelectorOpIn, capacityType),
 					scheduling.NewRequirement(corev1.LabelTopologyZone, corev1.NodeSelectorOpIn, originalSubnet.Zone),
 				))
-				p.inflightIPs[originalSubnet.ID] = ips + minPods
+				p.inflightIPs[originalSubnet.ID] = ips + predictedIPs(ipAddressMode, minPods)
 			}
 		}
 	}
 }
 
+// AvailabilityZones returns the zones of the region that are enabled for the account, keyed by zone name. This includes
+// the Local Zones and Wavelength Zones that the account has opted into.
+func (p *DefaultProvider) AvailabilityZones(ctx context.Context) (map[string]ec2types.AvailabilityZone, error) {
+	if zones, ok := p.availabilityZoneCache.Get(availabilityZonesCacheKey); ok {
+		return zones.(map[string]ec2types.AvailabilityZone), nil
+	}
+	out, err := p.ec2api.DescribeAvailabilityZones(ctx, &ec2.DescribeAvailabilityZonesInput{})
+	if err != nil {
+		return nil, fmt.Errorf("describing availability zones, %w", err)
+	}
+	zones := lo.SliceToMap(out.AvailabilityZones, func(zone ec2types.AvailabilityZone) (string, ec2types.AvailabilityZone) {
+		return lo.FromPtr(zone.ZoneName), zone
+	})
+	p.availabilityZoneCache.SetDefault(availabilityZonesCacheKey, zones)
+	return zones, nil
+}
+
+// MarkPrefixFragmented records that a subnet failed to allocate a /28 prefix, so that it isn't chosen for prefix
+// delegation until the record expires
+func (p *DefaultProvider) MarkPrefixFragmented(id string) {
+	p.prefixFragmentedCache.SetDefault(id, struct{}{})
+}
+
+// IsPrefixFragmented returns true if the subnet recently failed to allocate a /28 prefix
+func (p *DefaultProvider) IsPrefixFragmented(id string) bool {
+	_, ok := p.prefixFragmentedCache.Get(id)
+	return ok
+}
+
 func (p *DefaultProvider) LivenessProbe(_ *http.Request) error {
 	p.Lock()
 	//nolint: staticcheck
@@ -255,6 +307,36 @@ func (p *DefaultProvider) minPods(instanceTypes []*cloudprovider.InstanceType, r
 	return int32(pods)
 }
 
+// hasPrefixAvailable returns false if the subnet is known to be unable to allocate a /28 prefix, either because it is
+// fragmented or because it doesn't have enough free addresses left
+func (p *DefaultProvider) hasPrefixAvailable(id string, availableIPAddressCount map[string]int32) bool {
+	if p.IsPrefixFragmented(id) {
+		return false
+	}
+	ips, ok := availableIPAddressCount[id]
+	if trackedIPs, tracked := p.inflightIPs[id]; tracked {
+		ips, ok = trackedIPs, true
+	}
+	return !ok || ips >= prefixSize
+}
+
+// predictedIPs returns the number of subnet IPv4 addresses that a node running the given number of pods consumes
+func predictedIPs(ipAddressMode string, pods int32) int32 {
+	if pods == 0 {
+		return 0
+	}
+	switch ipAddressMode {
+	case v1.IPAddressModePrefixDelegation:
+		// Pod addresses are allocated in whole /28 prefixes
+		return (pods + prefixSize - 1) / prefixSize * prefixSize
+	case v1.IPAddressModeIPv6:
+		// Pods are assigned IPv6 addresses, so only the primary IPv4 address of the node is consumed
+		return 1
+	default:
+		return pods
+	}
+}
+
 func getFilterSets(terms []v1.SubnetSelectorTerm) (res [][]ec2types.Filter) {
 	idFilter := ec2types.Filter{Name: aws.String("subnet-id")}
 	for _, term := range terms {
@@ -27,15 +27,21 @@ import (
 	karpv1 "sigs.k8s.io/karpenter/pkg/apis/v1"
 
 	v1 "github.com/aws/karpenter-provider-aws/pkg/apis/v1"
+	"github.com/aws/karpenter-provider-aws/pkg/batcher"
 	awscache "github.com/aws/karpenter-provider-aws/pkg/cache"
 	"github.com/aws/karpenter-provider-aws/pkg/fake"
 	"github.com/aws/karpenter-provider-aws/pkg/providers/amifamily"
+	"github.com/aws/karpenter-provider-aws/pkg/providers/capacityreservation"
+	"github.com/aws/karpenter-provider-aws/pkg/providers/host"
 	"github.com/aws/karpenter-provider-aws/pkg/providers/instance"
 	"github.com/aws/karpenter-provider-aws/pkg/providers/instanceprofile"
 	"github.com/aws/karpenter-provider-aws/pkg/providers/instancetype"
 	"github.com/aws/karpenter-provider-aws/pkg/providers/launchtemplate"
+	"github.com/aws/karpenter-provider-aws/pkg/providers/placementgroup"
 	"github.com/aws/karpenter-provider-aws/pkg/providers/pricing"
 	"github.com/aws/karpenter-provider-aws/pkg/providers/securitygroup"
+	"github.com/aws/karpenter-provider-aws/pkg/providers/servicequota"
+	"github.com/aws/karpenter-provider-aws/pkg/providers/spotplacementscore"
 	ssmp "github.com/aws/karpenter-provider-aws/pkg/providers/ssm"
 	"github.com/aws/karpenter-provider-aws/pkg/providers/subnet"
 	"github.com/aws/karpenter-provider-aws/pkg/providers/version"
@@ -55,11 +61,12 @@ type Environment struct {
 	Clock *clock.FakeClock
 
 	// API
-	EC2API     *fake.EC2API
-	EKSAPI     *fake.EKSAPI
-	SSMAPI     *fake.SSMAPI
-	IAMAPI     *fake.IAMAPI
-	PricingAPI *fake.PricingAPI
+	EC2API           *fake.EC2API
+	EKSAPI           *fake.EKSAPI
+	SSMAPI           *fake.SSMAPI
+	IAMAPI           *fake.IAMAPI
+	PricingAPI       *fake.PricingAPI
+	ServiceQuotasAPI *fake.ServiceQuotasAPI
 
 	// Cache
 	EC2Cache                      *cache.Cache
@@ -67,25 +74,38 @@ type Environment struct {
 	UnavailableOfferingsCache     *awscache.UnavailableOfferings
 	LaunchTemplateCache           *cache.Cache
 	SubnetCache                   *cache.Cache
+	AvailabilityZoneCache         *cache.Cache
 	AvailableIPAdressCache        *cache.Cache
 	AssociatePublicIPAddressCache *cache.Cache
+	PrefixFragmentedSubnetCache   *cache.Cache
 	SecurityGroupCache            *cache.Cache
+	CapacityReservationCache      *cache.Cache
+	AvailableInstanceCountCache   *cache.Cache
+	PlacementGroupCache           *cache.Cache
+	HostCache                     *cache.Cache
+	SpotPlacementScoreCache       *cache.Cache
+	ServiceQuotasCache            *cache.Cache
 	InstanceProfileCache          *cache.Cache
 	SSMCache                      *cache.Cache
 	DiscoveredCapacityCache       *cache.Cache
 
 	// Providers
-	InstanceTypesResolver   *instancetype.DefaultResolver
-	InstanceTypesProvider   *instancetype.DefaultProvider
-	InstanceProvider        *instance.DefaultProvider
-	SubnetProvider          *subnet.DefaultProvider
-	SecurityGroupProvider   *securitygroup.DefaultProvider
-	InstanceProfileProvider *instanceprofile.DefaultProvider
-	PricingProvider         *pricing.DefaultProvider
-	AMIProvider             *amifamily.DefaultProvider
-	AMIResolver             *amifamily.DefaultResolver
-	VersionProvider         *version.DefaultProvider
-	LaunchTemplateProvider  *launchtemplate.DefaultProvider
+	InstanceTypesResolver       *instancetype.DefaultResolver
+	InstanceTypesProvider       *instancetype.DefaultProvider
+	InstanceProvider            *instance.DefaultProvider
+	SubnetProvider              *subnet.DefaultProvider
+	SecurityGroupProvider       *securitygroup.DefaultProvider
+	CapacityReservationProvider *capacityreservation.DefaultProvider
+	PlacementGroupProvider      *placementgroup.DefaultProvider
+	HostProvider                *host.DefaultProvider
+	SpotPlacementScoreProvider  *spotplacementscore.DefaultProvider
+	ServiceQuotaProvider        *servicequota.DefaultProvider
+	InstanceProfileProvider     *instanceprofile.DefaultProvider
+	PricingProvider             *pricing.DefaultProvider
+	AMIProvider                 *amifamily.DefaultProvider
+	AMIResolver                 *amifamily.DefaultResolver
+	VersionProvider             *version.DefaultProvider
+	LaunchTemplateProvider      *launchtemplate.DefaultProvider
 }
 
 func NewEnvironment(ctx context.Context, env *coretest.Environment) *Environment {
@@ -97,6 +117,7 @@ func NewEnvironment(ctx context.Context, env *coretest.Environment) *Environment
 	eksapi := fake.NewEKSAPI()
 	ssmapi := fake.NewSSMAPI()
 	iamapi := fake.NewIAMAPI()
+	servicequotasapi := fake.NewServiceQuotasAPI()
 
 	// cache
 	ec2Cache := cache.New(awscache.DefaultTTL, awscache.DefaultCleanupInterval)
@@ -107,15 +128,28 @@ func NewEnvironment(ctx context.Context, env *coretest.Environment) *Environment
 	subnetCache := cache.New(awscache.DefaultTTL, awscache.DefaultCleanupInterval)
 	availableIPAdressCache := cache.New(awscache.AvailableIPAddressTTL, awscache.DefaultCleanupInterval)
 	associatePublicIPAddressCache := cache.New(awscache.AssociatePublicIPAddressTTL, awscache.DefaultCleanupInterval)
+	prefixFragmentedSubnetCache := cache.New(awscache.PrefixFragmentedSubnetTTL, awscache.DefaultCleanupInterval)
 	securityGroupCache := cache.New(awscache.DefaultTTL, awscache.DefaultCleanupInterval)
+	capacityReservationCache := cache.New(awscache.DefaultTTL, awscache.DefaultCleanupInterval)
+	availableInstanceCountCache := cache.New(awscache.CapacityReservationAvailabilityTTL, awscache.DefaultCleanupInterval)
+	placementGroupCache := cache.New(awscache.DefaultTTL, awscache.DefaultCleanupInterval)
+	hostCache := cache.New(awscache.DefaultTTL, awscache.DefaultCleanupInterval)
+	availabilityZoneCache := cache.New(awscache.InstanceTypesAndZonesTTL, awscache.DefaultCleanupInterval)
+	spotPlacementScoreCache := cache.New(awscache.SpotPlacementScoreTTL, awscache.DefaultCleanupInterval)
+	serviceQuotasCache := cache.New(awscache.ServiceQuotasTTL, awscache.DefaultCleanupInterval)
 	instanceProfileCache := cache.New(awscache.DefaultTTL, awscache.DefaultCleanupInterval)
 	ssmCache := cache.New(awscache.DefaultTTL, awscache.DefaultCleanupInterval)
 	fakePricingAPI := &fake.PricingAPI{}
 
 	// Providers
-	pricingProvider := pricing.NewDefaultProvider(ctx, fakePricingAPI, ec2api, fake.DefaultRegion)
-	subnetProvider := subnet.NewDefaultProvider(ec2api, subnetCache, availableIPAdressCache, associatePublicIPAddressCache)
+	pricingProvider := pricing.NewDefaultProvider(ctx, fakePricingAPI, ec2api, fake.DefaultRegion, clock)
+	subnetProvider := subnet.NewDefaultProvider(ec2api, subnetCache, availableIPAdressCache, associatePublicIPAddressCache, availabilityZoneCache, prefixFragmentedSubnetCache)
 	securityGroupProvider := securitygroup.NewDefaultProvider(ec2api, securityGroupCache)
+	capacityReservationProvider := capacityreservation.NewDefaultProvider(ec2api, capacityReservationCache, availableInstanceCountCache)
+	placementGroupProvider := placementgroup.NewDefaultProvider(fake.DefaultRegion, ec2api, placementGroupCache)
+	hostProvider := host.NewDefaultProvider(ec2api, hostCache)
+	spotPlacementScoreProvider := spotplacementscore.NewDefaultProvider(fake.DefaultRegion, ec2api, clock, spotPlacementScoreCache)
+	serviceQuotaProvider := servicequota.NewDefaultProvider(servicequotasapi, serviceQuotasCache)
 	versionProvider := version.NewDefaultProvider(env.KubernetesInterface, eksapi)
 	// Ensure we're able to hydrate the version before starting any reliant controllers.
 	// Version updates are hydrated asynchronously after this, in the event of a failure
@@ -125,7 +159,7 @@ func NewEnvironment(ctx context.Context, env *coretest.Environment) *Environment
 	ssmProvider := ssmp.NewDefaultProvider(ssmapi, ssmCache)
 	amiProvider := amifamily.NewDefaultProvider(clock, versionProvider, ssmProvider, ec2api, ec2Cache)
 	amiResolver := amifamily.NewDefaultResolver()
-	instanceTypesResolver := instancetype.NewDefaultResolver(fake.DefaultRegion, pricingProvider, unavailableOfferingsCache)
+	instanceTypesResolver := instancetype.NewDefaultResolver(fake.DefaultRegion, pricingProvider, unavailableOfferingsCache, capacityReservationProvider, serviceQuotaProvider, clock)
 	instanceTypesProvider := instancetype.NewDefaultProvider(instanceTypeCache, discoveredCapacityCache, ec2api, subnetProvider, instanceTypesResolver)
 	launchTemplateProvider :=
 		launchtemplate.NewDefaultProvider(
@@ -142,46 +176,64 @@ func NewEnvironment(ctx context.Context, env *coretest.Environment) *Environment
 			"https://test-cluster",
 		)
 	instanceProvider :=
-		instance.NewDefaultProvider(ctx,
+		instance.NewDefaultProvider(
 			"",
 			ec2api,
+			batcher.EC2(ctx, ec2api),
 			unavailableOfferingsCache,
 			subnetProvider,
 			launchTemplateProvider,
+			capacityReservationProvider,
+			spotPlacementScoreProvider,
+			serviceQuotaProvider,
 		)
 
 	return &Environment{
 		Clock: clock,
 
-		EC2API:     ec2api,
-		EKSAPI:     eksapi,
-		SSMAPI:     ssmapi,
-		IAMAPI:     iamapi,
-		PricingAPI: fakePricingAPI,
+		EC2API:           ec2api,
+		EKSAPI:           eksapi,
+		SSMAPI:           ssmapi,
+		IAMAPI:           iamapi,
+		PricingAPI:       fakePricingAPI,
+		ServiceQuotasAPI: servicequotasapi,
 
 		EC2Cache:                      ec2Cache,
 		InstanceTypeCache:             instanceTypeCache,
 		LaunchTemplateCache:           launchTemplateCache,
 		SubnetCache:                   subnetCache,
+		AvailabilityZoneCache:         availabilityZoneCache,
 		AvailableIPAdressCache:        availableIPAdressCache,
 		AssociatePublicIPAddressCache: associatePublicIPAddressCache,
+		PrefixFragmentedSubnetCache:   prefixFragmentedSubnetCache,
 		SecurityGroupCache:            securityGroupCache,
+		CapacityReservationCache:      capacityReservationCache,
+		AvailableInstanceCountCache:   availableInstanceCountCache,
+		PlacementGroupCache:           placementGroupCache,
+		HostCache:                     hostCache,
+		SpotPlacementScoreCache:       spotPlacementScoreCache,
+		ServiceQuotasCache:            serviceQuotasCache,
 		InstanceProfileCache:          instanceProfileCache,
 		UnavailableOfferingsCache:     unavailableOfferingsCache,
 		SSMCache:                      ssmCache,
 		DiscoveredCapacityCache:       discoveredCapacityCache,
 
-		InstanceTypesResolver:   instanceTypesResolver,
-		InstanceTypesProvider:   instanceTypesProvider,
-		InstanceProvider:        instanceProvider,
-		SubnetProvider:          subnetProvider,
-		SecurityGroupProvider:   securityGroupProvider,
-		LaunchTemplateProvider:  launchTemplateProvider,
-		InstanceProfileProvider: instanceProfileProvider,
-		PricingProvider:         pricingProvider,
-		AMIProvider:             amiProvider,
-		AMIResolver:             amiResolver,
-		VersionProvider:         versionProvider,
+		InstanceTypesResolver:       instanceTypesResolver,
+		InstanceTypesProvider:       instanceTypesProvider,
+		InstanceProvider:            instanceProvider,
+		SubnetProvider:              subnetProvider,
+		SecurityGroupProvider:       securityGroupProvider,
+		CapacityReservationProvider: capacityReservationProvider,
+		PlacementGroupProvider:      placementGroupProvider,
+		HostProvider:                hostProvider,
+		SpotPlacementScoreProvider:  spotPlacementScoreProvider,
+		ServiceQuotaProvider:        serviceQuotaProvider,
+		LaunchTemplateProvider:      launchTemplateProvider,
+		InstanceProfileProvider:     instanceProfileProvider,
+		PricingProvider:             pricingProvider,
+		AMIProvider:                 amiProvider,
+		AMIResolver:                 amiResolver,
+		VersionProvider:             versionProvider,
 	}
 }
 
@@ -194,14 +246,26 @@ func (env *Environment) Reset() {
 	env.PricingAPI.Reset()
 	env.PricingProvider.Reset()
 	env.InstanceTypesProvider.Reset()
+	env.CapacityReservationProvider.Reset()
+	env.ServiceQuotasAPI.Reset()
+	env.ServiceQuotaProvider.Reset()
+	env.SpotPlacementScoreProvider.Reset()
 
 	env.EC2Cache.Flush()
 	env.UnavailableOfferingsCache.Flush()
 	env.LaunchTemplateCache.Flush()
 	env.SubnetCache.Flush()
+	env.AvailabilityZoneCache.Flush()
 	env.AssociatePublicIPAddressCache.Flush()
 	env.AvailableIPAdressCache.Flush()
+	env.PrefixFragmentedSubnetCache.Flush()
 	env.SecurityGroupCache.Flush()
+	env.CapacityReservationCache.Flush()
+	env.AvailableInstanceCountCache.Flush()
+	env.PlacementGroupCache.Flush()
+	env.HostCache.Flush()
+	env.SpotPlacementScoreCache.Flush()
+	env.ServiceQuotasCache.Flush()
 	env.InstanceProfileCache.Flush()
 	env.SSMCache.Flush()
 	env.DiscoveredCapacityCache.Flush()
@@ -102,19 +102,22 @@ func EC2NodeClass(overrides ...v1.EC2NodeClass) *v1.EC2NodeClass {
 		}
 		options.Status.Subnets = []v1.Subnet{
 			{
-				ID:     "subnet-test1",
-				Zone:   "test-zone-1a",
-				ZoneID: "tstz1-1a",
+				ID:       "subnet-test1",
+				Zone:     "test-zone-1a",
+				ZoneID:   "tstz1-1a",
+				ZoneType: v1.ZoneTypeAvailabilityZone,
 			},
 			{
-				ID:     "subnet-test2",
-				Zone:   "test-zone-1b",
-				ZoneID: "tstz1-1b",
+				ID:       "subnet-test2",
+				Zone:     "test-zone-1b",
+				ZoneID:   "tstz1-1b",
+				ZoneType: v1.ZoneTypeAvailabilityZone,
 			},
 			{
-				ID:     "subnet-test3",
-				Zone:   "test-zone-1c",
-				ZoneID: "tstz1-1c",
+				ID:       "subnet-test3",
+				Zone:     "test-zone-1c",
+				ZoneID:   "tstz1-1c",
+				ZoneType: v1.ZoneTypeAvailabilityZone,
 			},
 		}
 	}
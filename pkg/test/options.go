@@ -16,6 +16,7 @@ package test
 
 import (
 	"fmt"
+	"time"
 
 	"github.com/imdario/mergo"
 	"github.com/samber/lo"
@@ -24,14 +25,27 @@ import (
 )
 
 type OptionsFields struct {
-	ClusterCABundle         *string
-	ClusterName             *string
-	ClusterEndpoint         *string
-	IsolatedVPC             *bool
-	EKSControlPlane         *bool
-	VMMemoryOverheadPercent *float64
-	InterruptionQueue       *string
-	ReservedENIs            *int
+	ClusterCABundle              *string
+	ClusterName                  *string
+	ClusterEndpoint              *string
+	IsolatedVPC                  *bool
+	EKSControlPlane              *bool
+	VMMemoryOverheadPercent      *float64
+	InterruptionQueue            *string
+	InterruptionQueues           *string
+	InterruptionAllowedAccounts  *string
+	InterruptionAllowedRegions   *string
+	InterruptionRulesConfigMap   *string
+	InterruptionPollingInterval  *time.Duration
+	InterruptionPollingAPIBudget *int
+	ReservedENIs                 *int
+	CacheCheckpointConfigMap     *string
+	PricingOverridesConfigMap    *string
+	PricingSnapshotPath          *string
+	PricingSnapshotConfigMap     *string
+	SpotPriceAggregation         *string
+	SpotPriceWindow              *time.Duration
+	ScheduledChangeLeadTime      *time.Duration
 }
 
 func Options(overrides ...OptionsFields) *options.Options {
@@ -42,13 +56,26 @@ func Options(overrides ...OptionsFields) *options.Options {
 		}
 	}
 	return &options.Options{
-		ClusterCABundle:         lo.FromPtrOr(opts.ClusterCABundle, ""),
-		ClusterName:             lo.FromPtrOr(opts.ClusterName, "test-cluster"),
-		ClusterEndpoint:         lo.FromPtrOr(opts.ClusterEndpoint, "https://test-cluster"),
-		IsolatedVPC:             lo.FromPtrOr(opts.IsolatedVPC, false),
-		EKSControlPlane:         lo.FromPtrOr(opts.EKSControlPlane, false),
-		VMMemoryOverheadPercent: lo.FromPtrOr(opts.VMMemoryOverheadPercent, 0.075),
-		InterruptionQueue:       lo.FromPtrOr(opts.InterruptionQueue, ""),
-		ReservedENIs:            lo.FromPtrOr(opts.ReservedENIs, 0),
+		ClusterCABundle:              lo.FromPtrOr(opts.ClusterCABundle, ""),
+		ClusterName:                  lo.FromPtrOr(opts.ClusterName, "test-cluster"),
+		ClusterEndpoint:              lo.FromPtrOr(opts.ClusterEndpoint, "https://test-cluster"),
+		IsolatedVPC:                  lo.FromPtrOr(opts.IsolatedVPC, false),
+		EKSControlPlane:              lo.FromPtrOr(opts.EKSControlPlane, false),
+		VMMemoryOverheadPercent:      lo.FromPtrOr(opts.VMMemoryOverheadPercent, 0.075),
+		InterruptionQueue:            lo.FromPtrOr(opts.InterruptionQueue, ""),
+		InterruptionQueues:           lo.FromPtrOr(opts.InterruptionQueues, ""),
+		InterruptionAllowedAccounts:  lo.FromPtrOr(opts.InterruptionAllowedAccounts, ""),
+		InterruptionAllowedRegions:   lo.FromPtrOr(opts.InterruptionAllowedRegions, ""),
+		InterruptionRulesConfigMap:   lo.FromPtrOr(opts.InterruptionRulesConfigMap, ""),
+		InterruptionPollingInterval:  lo.FromPtrOr(opts.InterruptionPollingInterval, 0),
+		InterruptionPollingAPIBudget: lo.FromPtrOr(opts.InterruptionPollingAPIBudget, 10),
+		ReservedENIs:                 lo.FromPtrOr(opts.ReservedENIs, 0),
+		CacheCheckpointConfigMap:     lo.FromPtrOr(opts.CacheCheckpointConfigMap, ""),
+		PricingOverridesConfigMap:    lo.FromPtrOr(opts.PricingOverridesConfigMap, ""),
+		PricingSnapshotPath:          lo.FromPtrOr(opts.PricingSnapshotPath, ""),
+		PricingSnapshotConfigMap:     lo.FromPtrOr(opts.PricingSnapshotConfigMap, ""),
+		SpotPriceAggregation:         lo.FromPtrOr(opts.SpotPriceAggregation, "latest"),
+		SpotPriceWindow:              lo.FromPtrOr(opts.SpotPriceWindow, 6*time.Hour),
+		ScheduledChangeLeadTime:      lo.FromPtrOr(opts.ScheduledChangeLeadTime, 0),
 	}
 }